@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -220,9 +221,21 @@ func (c *CachedAccountRepository) Deposit(ctx context.Context, id int64, amount
 	return account, nil
 }
 
+// Credit delegates to repo and invalidates affected caches.
+func (c *CachedAccountRepository) Credit(ctx context.Context, id int64, amount decimal.Decimal, op *models.Operation) (*models.Account, error) {
+	account, err := c.repo.Credit(ctx, id, amount, op)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidateAccount(ctx, id, account.AccountNumber)
+	c.del(ctx, keyAccountActive())
+	return account, nil
+}
+
 // Withdraw delegates to repo and invalidates affected caches.
-func (c *CachedAccountRepository) Withdraw(ctx context.Context, id int64, amount decimal.Decimal) (*models.Account, error) {
-	account, err := c.repo.Withdraw(ctx, id, amount)
+func (c *CachedAccountRepository) Withdraw(ctx context.Context, id int64, amount decimal.Decimal, op *models.Operation) (*models.Account, error) {
+	account, err := c.repo.Withdraw(ctx, id, amount, op)
 	if err != nil {
 		return nil, err
 	}
@@ -232,17 +245,23 @@ func (c *CachedAccountRepository) Withdraw(ctx context.Context, id int64, amount
 	return account, nil
 }
 
-// Transfer delegates to repo and invalidates both accounts.
-func (c *CachedAccountRepository) Transfer(ctx context.Context, fromID, toID int64, amount decimal.Decimal) error {
+// Transfer delegates to repo and invalidates both accounts and the fee account.
+func (c *CachedAccountRepository) Transfer(ctx context.Context, fromID, toID int64, amount decimal.Decimal, fee *models.Fee, op *models.Operation) error {
 	// Get account numbers before transfer for cache invalidation
 	fromAcct, _ := c.repo.GetByID(ctx, fromID)
 	toAcct, _ := c.repo.GetByID(ctx, toID)
 
-	err := c.repo.Transfer(ctx, fromID, toID, amount)
+	err := c.repo.Transfer(ctx, fromID, toID, amount, fee, op)
 	if err != nil {
 		return err
 	}
 
+	if fee != nil && fee.Amount.IsPositive() {
+		if feeAcct, _ := c.repo.GetByID(ctx, fee.AccountID); feeAcct != nil {
+			c.invalidateAccount(ctx, feeAcct.ID, feeAcct.AccountNumber)
+		}
+	}
+
 	c.del(ctx, keyAccountByID(fromID))
 	c.del(ctx, keyAccountByID(toID))
 	if fromAcct != nil {
@@ -255,6 +274,82 @@ func (c *CachedAccountRepository) Transfer(ctx context.Context, fromID, toID int
 	return nil
 }
 
+// ReturnPayment delegates to repo and invalidates every account the refund moved funds between.
+func (c *CachedAccountRepository) ReturnPayment(ctx context.Context, fromID, toID int64, amount decimal.Decimal, fee *models.Fee, op *models.Operation) error {
+	if err := c.repo.ReturnPayment(ctx, fromID, toID, amount, fee, op); err != nil {
+		return err
+	}
+
+	ids := []int64{fromID, toID}
+	if fee != nil && fee.Amount.IsPositive() {
+		ids = append(ids, fee.AccountID)
+	}
+	c.invalidateMoved(ctx, ids...)
+	return nil
+}
+
+// Reverse delegates to repo and invalidates every account involved, including when the rest was held with a lien.
+func (c *CachedAccountRepository) Reverse(ctx context.Context, reversalOf, fromID, toID int64, amount decimal.Decimal, policy string, feeRefund *models.Fee, op *models.Operation) (decimal.Decimal, error) {
+	settled, err := c.repo.Reverse(ctx, reversalOf, fromID, toID, amount, policy, feeRefund, op)
+	if err != nil && !errors.Is(err, repository.ErrReversalHeld) {
+		return settled, err
+	}
+
+	ids := []int64{fromID, toID}
+	if feeRefund != nil && feeRefund.Amount.IsPositive() {
+		ids = append(ids, feeRefund.AccountID)
+	}
+	c.invalidateMoved(ctx, ids...)
+	return settled, err
+}
+
+// ListActiveLiens delegates directly; liens are not cached.
+func (c *CachedAccountRepository) ListActiveLiens(ctx context.Context) ([]models.Lien, error) {
+	return c.repo.ListActiveLiens(ctx)
+}
+
+// CollectLien delegates to repo and invalidates every account the collection moved funds between.
+func (c *CachedAccountRepository) CollectLien(ctx context.Context, id int64) (*models.Lien, error) {
+	lien, err := c.repo.CollectLien(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int64{lien.AccountID, lien.BeneficiaryAccountID}
+	if lien.FeeAccountID != nil {
+		ids = append(ids, *lien.FeeAccountID)
+	}
+	c.invalidateMoved(ctx, ids...)
+	return lien, nil
+}
+
+// invalidateMoved drops the cached balances of accounts funds were moved between
+func (c *CachedAccountRepository) invalidateMoved(ctx context.Context, ids ...int64) {
+	for _, id := range ids {
+		if acct, _ := c.repo.GetByID(ctx, id); acct != nil {
+			c.invalidateAccount(ctx, acct.ID, acct.AccountNumber)
+		} else {
+			c.del(ctx, keyAccountByID(id))
+		}
+	}
+	c.del(ctx, keyAccountActive())
+}
+
+// RecordFailedOperation delegates directly; operations are not cached.
+func (c *CachedAccountRepository) RecordFailedOperation(ctx context.Context, op *models.Operation, reason string) error {
+	return c.repo.RecordFailedOperation(ctx, op, reason)
+}
+
+// CancelOperation delegates directly; operations are not cached.
+func (c *CachedAccountRepository) CancelOperation(ctx context.Context, op *models.Operation, reason string) (*models.Operation, error) {
+	return c.repo.CancelOperation(ctx, op, reason)
+}
+
+// GetOperation delegates directly; operations are not cached.
+func (c *CachedAccountRepository) GetOperation(ctx context.Context, referenceID string) (*models.Operation, error) {
+	return c.repo.GetOperation(ctx, referenceID)
+}
+
 // setCache marshals the value and stores it in Redis. Errors are logged, never returned.
 func (c *CachedAccountRepository) setCache(ctx context.Context, key string, value interface{}, ttl time.Duration) {
 	data, err := json.Marshal(value)
@@ -3,7 +3,9 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"time"
 
 	"account/models"
 	"account/repository"
@@ -19,16 +21,24 @@ const (
 	TopicPaymentRequested  = "payment.requested"
 	TopicPaymentCompleted  = "payment.completed"
 	TopicPaymentFailed     = "payment.failed"
+	TopicRefundRequested   = "payment.refund_requested"
+	TopicRefunded          = "payment.refunded"
+	TopicRefundFailed      = "payment.refund_failed"
 )
 
+// lienSweepInterval is how often held reversals are checked for liens the recipient can now cover
+const lienSweepInterval = time.Minute
+
 type Consumer struct {
 	transferReader *kafka.Reader
 	paymentReader  *kafka.Reader
+	refundReader   *kafka.Reader
 	repo           repository.AccountRepo
 	producer       *Producer
+	feeAccountID   int64 // bank revenue account credited with transfer fees and merchant service charges
 }
 
-func NewConsumer(brokers []string, groupID string, repo repository.AccountRepo, producer *Producer) *Consumer {
+func NewConsumer(brokers []string, groupID string, repo repository.AccountRepo, producer *Producer, feeAccountID int64) *Consumer {
 	transferReader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:     brokers,
 		Topic:       TopicTransferRequested,
@@ -47,18 +57,31 @@ func NewConsumer(brokers []string, groupID string, repo repository.AccountRepo,
 		StartOffset: kafka.FirstOffset,
 	})
 
+	refundReader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       TopicRefundRequested,
+		GroupID:     groupID + "-refunds",
+		MinBytes:    10e3, // 10KB
+		MaxBytes:    10e6, // 10MB
+		StartOffset: kafka.FirstOffset,
+	})
+
 	return &Consumer{
 		transferReader: transferReader,
 		paymentReader:  paymentReader,
+		refundReader:   refundReader,
 		repo:           repo,
 		producer:       producer,
+		feeAccountID:   feeAccountID,
 	}
 }
 
-// Start starts consuming transfer and payment requested events
+// Start starts consuming transfer, payment and refund requested events, and collecting liens
 func (c *Consumer) Start(ctx context.Context) {
 	go c.consumeTransfers(ctx)
 	go c.consumePayments(ctx)
+	go c.consumeRefunds(ctx)
+	go c.collectLiens(ctx)
 }
 
 func (c *Consumer) consumeTransfers(ctx context.Context) {
@@ -119,18 +142,85 @@ func (c *Consumer) processTransferMessage(ctx context.Context, msg kafka.Message
 		formatAccountID(event.FromAccountID), formatAccountID(event.ToAccountID),
 		event.Amount.String())
 
+	op := &models.Operation{
+		ReferenceID:   event.ReferenceID,
+		OperationType: models.OperationTypeTransfer,
+		SourceID:      event.TransferID,
+	}
+	if event.ReversalOf != 0 {
+		op.OperationType = models.OperationTypeReversal
+	}
+
+	// A redelivered or re-published request replays the recorded outcome instead of moving funds again
+	recorded, err := c.repo.GetOperation(ctx, event.ReferenceID)
+	if err == nil {
+		log.Printf("Transfer %d already processed (%s), replaying result", event.TransferID, recorded.Status)
+		c.publishTransferResult(ctx, event, recorded)
+		return
+	}
+	if !errors.Is(err, repository.ErrOperationNotFound) {
+		log.Printf("Failed to look up operation for transfer %d: %v", event.TransferID, err)
+		return
+	}
+
+	// Perform the transfer; reversals may settle for less than the requested amount
+	settled := event.Amount
+	if event.ReversalOf != 0 {
+		feeRefund := &models.Fee{Amount: event.FeeRefund, AccountID: c.feeAccountID}
+		settled, err = c.repo.Reverse(ctx, event.ReversalOf, event.FromAccountID, event.ToAccountID, event.Amount, event.ReversalPolicy, feeRefund, op)
+	} else {
+		fee := &models.Fee{Amount: event.Fee, AccountID: c.feeAccountID}
+		err = c.repo.Transfer(ctx, event.FromAccountID, event.ToAccountID, event.Amount, fee, op)
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrOperationExists):
+		// Processed concurrently by another consumer; replay what it recorded
+		if recorded, getErr := c.repo.GetOperation(ctx, event.ReferenceID); getErr == nil {
+			c.publishTransferResult(ctx, event, recorded)
+		}
+		return
+	case errors.Is(err, repository.ErrReversalHeld):
+		// The result is published when the lien for the rest is collected
+		log.Printf("Reversal %d held: reversed %s of %s, lien placed on account %d for the rest",
+			event.TransferID, settled.String(), event.Amount.String(), event.FromAccountID)
+		return
+	case err != nil:
+		log.Printf("Transfer %d failed: %v", event.TransferID, err)
+		reason := err.Error()
+		if recErr := c.repo.RecordFailedOperation(ctx, op, reason); recErr != nil {
+			log.Printf("Failed to record failed operation for transfer %d: %v", event.TransferID, recErr)
+		}
+		op.Status = models.OperationStatusFailed
+		op.FailureReason = &reason
+	default:
+		log.Printf("Transfer %d completed successfully", event.TransferID)
+		op.Status = models.OperationStatusCompleted
+		op.Amount = settled
+	}
+
+	c.publishTransferResult(ctx, event, op)
+}
+
+// publishTransferResult publishes the completed or failed event for a transfer operation. A held
+// reversal has no result until its lien is collected.
+func (c *Consumer) publishTransferResult(ctx context.Context, event models.TransferEvent, op *models.Operation) {
+	if op.Status == models.OperationStatusHeld {
+		log.Printf("Reversal %d is held until its lien is collected; no result to publish", event.TransferID)
+		return
+	}
+
 	// Get account info to include user IDs in the result event
 	fromAccount, _ := c.repo.GetByID(ctx, event.FromAccountID)
 	toAccount, _ := c.repo.GetByID(ctx, event.ToAccountID)
 
-	// Perform the transfer
-	err := c.repo.Transfer(ctx, event.FromAccountID, event.ToAccountID, event.Amount)
-
 	var result models.TransferResultEvent
 	result.TransferID = event.TransferID
 	result.ReferenceID = event.ReferenceID
 	result.FromAccountID = event.FromAccountID
 	result.ToAccountID = event.ToAccountID
+	result.Amount = op.Amount
+	result.ReversalOf = event.ReversalOf
 	if fromAccount != nil {
 		result.FromUserID = fromAccount.UserID
 	}
@@ -138,26 +228,81 @@ func (c *Consumer) processTransferMessage(ctx context.Context, msg kafka.Message
 		result.ToUserID = toAccount.UserID
 	}
 
-	if err != nil {
+	if op.Status == models.OperationStatusFailed {
 		result.Status = "failed"
-		result.FailureReason = err.Error()
-		log.Printf("Transfer %d failed: %v", event.TransferID, err)
+		if op.FailureReason != nil {
+			result.FailureReason = *op.FailureReason
+		}
 
 		// Publish failure event
 		if pubErr := c.producer.PublishTransferFailed(ctx, result); pubErr != nil {
 			log.Printf("Failed to publish transfer.failed event: %v", pubErr)
 		}
-	} else {
-		result.Status = "completed"
-		log.Printf("Transfer %d completed successfully", event.TransferID)
+		return
+	}
 
-		// Publish success event
-		if pubErr := c.producer.PublishTransferCompleted(ctx, result); pubErr != nil {
-			log.Printf("Failed to publish transfer.completed event: %v", pubErr)
+	result.Status = "completed"
+	result.Fee = event.Fee
+
+	// Publish success event
+	if pubErr := c.producer.PublishTransferCompleted(ctx, result); pubErr != nil {
+		log.Printf("Failed to publish transfer.completed event: %v", pubErr)
+	}
+}
+
+// collectLiens periodically collects the liens of held reversals whose recipient can now cover
+// them, and publishes the completed reversal
+func (c *Consumer) collectLiens(ctx context.Context) {
+	ticker := time.NewTicker(lienSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			liens, err := c.repo.ListActiveLiens(ctx)
+			if err != nil {
+				log.Printf("Failed to list liens: %v", err)
+				continue
+			}
+			for _, lien := range liens {
+				c.collectLien(ctx, lien)
+			}
 		}
 	}
 }
 
+func (c *Consumer) collectLien(ctx context.Context, lien models.Lien) {
+	collected, err := c.repo.CollectLien(ctx, lien.ID)
+	if errors.Is(err, repository.ErrInsufficientFunds) || errors.Is(err, repository.ErrLienNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to collect lien %d for reversal %d: %v", lien.ID, lien.SourceID, err)
+		return
+	}
+
+	op, err := c.repo.GetOperation(ctx, collected.ReferenceID)
+	if err != nil {
+		// The result is replayed if the transfer service re-publishes the reversal
+		log.Printf("Collected lien %d but failed to look up reversal %d: %v", lien.ID, lien.SourceID, err)
+		return
+	}
+
+	log.Printf("Reversal %d completed: collected lien %d of %s from account %d",
+		collected.SourceID, collected.ID, collected.Amount.String(), collected.AccountID)
+	event := models.TransferEvent{
+		TransferID:    collected.SourceID,
+		ReferenceID:   collected.ReferenceID,
+		FromAccountID: collected.AccountID,
+		ToAccountID:   collected.BeneficiaryAccountID,
+		Amount:        op.Amount,
+		ReversalOf:    collected.ReversalOf,
+	}
+	c.publishTransferResult(ctx, event, op)
+}
+
 func (c *Consumer) processPaymentMessage(ctx context.Context, msg kafka.Message) {
 	var event models.PaymentEvent
 	if err := json.Unmarshal(msg.Value, &event); err != nil {
@@ -169,49 +314,214 @@ func (c *Consumer) processPaymentMessage(ctx context.Context, msg kafka.Message)
 		event.PaymentID, event.ReferenceID,
 		event.AccountID, event.Amount.String(), event.PaymentType)
 
-	// Get account info
-	account, err := c.repo.GetByID(ctx, event.AccountID)
-	if err != nil {
-		log.Printf("Payment %d failed: account not found: %v", event.PaymentID, err)
-		result := models.PaymentResultEvent{
-			PaymentID:     event.PaymentID,
-			ReferenceID:   event.ReferenceID,
-			Status:        "failed",
-			FailureReason: "account not found",
-			AccountID:     event.AccountID,
-			UserID:        event.UserID,
+	op := &models.Operation{
+		ReferenceID:   event.ReferenceID,
+		OperationType: models.OperationTypePayment,
+		SourceID:      event.PaymentID,
+	}
+
+	// A redelivered or re-published request replays the recorded outcome instead of debiting again
+	recorded, err := c.repo.GetOperation(ctx, event.ReferenceID)
+	if err == nil {
+		log.Printf("Payment %d already processed (%s), replaying result", event.PaymentID, recorded.Status)
+		c.publishPaymentResult(ctx, event, recorded)
+		return
+	}
+	if !errors.Is(err, repository.ErrOperationNotFound) {
+		log.Printf("Failed to look up operation for payment %d: %v", event.PaymentID, err)
+		return
+	}
+
+	// Debit the account; payments collected inside the bank, such as bills and acquired merchant
+	// payments, are moved to the collecting account in the same transaction. A merchant's service
+	// charge is withheld from its credit and paid to the fee revenue account.
+	if event.SettlementAccountID != 0 {
+		credit := event.Amount
+		var fee *models.Fee
+		if event.Fee.IsPositive() {
+			credit = event.Amount.Sub(event.Fee)
+			fee = &models.Fee{Amount: event.Fee, AccountID: c.feeAccountID}
+		}
+		err = c.repo.Transfer(ctx, event.AccountID, event.SettlementAccountID, credit, fee, op)
+	} else {
+		_, err = c.repo.Withdraw(ctx, event.AccountID, event.Amount, op)
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrOperationExists):
+		if recorded, getErr := c.repo.GetOperation(ctx, event.ReferenceID); getErr == nil {
+			c.publishPaymentResult(ctx, event, recorded)
 		}
-		c.producer.PublishPaymentFailed(ctx, result)
 		return
+	case err != nil:
+		log.Printf("Payment %d failed: %v", event.PaymentID, err)
+		reason := err.Error()
+		if errors.Is(err, repository.ErrAccountNotFound) {
+			reason = "account not found"
+		}
+		if recErr := c.repo.RecordFailedOperation(ctx, op, reason); recErr != nil {
+			log.Printf("Failed to record failed operation for payment %d: %v", event.PaymentID, recErr)
+		}
+		op.Status = models.OperationStatusFailed
+		op.FailureReason = &reason
+	default:
+		log.Printf("Payment %d completed successfully (debited %s from account %d)",
+			event.PaymentID, event.Amount.String(), event.AccountID)
+		op.Status = models.OperationStatusCompleted
+		op.Amount = event.Amount
 	}
 
-	// Perform the withdrawal (debit from account)
-	_, err = c.repo.Withdraw(ctx, event.AccountID, event.Amount)
+	c.publishPaymentResult(ctx, event, op)
+}
 
-	var result models.PaymentResultEvent
-	result.PaymentID = event.PaymentID
-	result.ReferenceID = event.ReferenceID
-	result.AccountID = event.AccountID
-	result.UserID = account.UserID
+// publishPaymentResult publishes the completed or failed event for a payment operation
+func (c *Consumer) publishPaymentResult(ctx context.Context, event models.PaymentEvent, op *models.Operation) {
+	result := models.PaymentResultEvent{
+		PaymentID:   event.PaymentID,
+		ReferenceID: event.ReferenceID,
+		AccountID:   event.AccountID,
+		UserID:      event.UserID,
+	}
+	if account, err := c.repo.GetByID(ctx, event.AccountID); err == nil {
+		result.UserID = account.UserID
+	}
 
-	if err != nil {
+	if op.Status == models.OperationStatusFailed {
 		result.Status = "failed"
-		result.FailureReason = err.Error()
-		log.Printf("Payment %d failed: %v", event.PaymentID, err)
+		if op.FailureReason != nil {
+			result.FailureReason = *op.FailureReason
+		}
 
 		// Publish failure event
 		if pubErr := c.producer.PublishPaymentFailed(ctx, result); pubErr != nil {
 			log.Printf("Failed to publish payment.failed event: %v", pubErr)
 		}
+		return
+	}
+
+	result.Status = "completed"
+
+	// Publish success event
+	if pubErr := c.producer.PublishPaymentCompleted(ctx, result); pubErr != nil {
+		log.Printf("Failed to publish payment.completed event: %v", pubErr)
+	}
+}
+
+func (c *Consumer) consumeRefunds(ctx context.Context) {
+	log.Println("Starting payment.refund_requested consumer")
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping payment.refund_requested consumer")
+			return
+		default:
+			msg, err := c.refundReader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Error fetching refund message: %v", err)
+				continue
+			}
+
+			c.processRefundMessage(ctx, msg)
+			c.refundReader.CommitMessages(ctx, msg)
+		}
+	}
+}
+
+func (c *Consumer) processRefundMessage(ctx context.Context, msg kafka.Message) {
+	var event models.RefundEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		log.Printf("Error unmarshaling refund event: %v", err)
+		return
+	}
+
+	log.Printf("Processing refund %d of payment %d (ref: %s): account %d, amount: %s",
+		event.RefundID, event.PaymentID, event.ReferenceID, event.AccountID, event.Amount.String())
+
+	op := &models.Operation{
+		ReferenceID:   event.ReferenceID,
+		OperationType: models.OperationTypePaymentRefund,
+		SourceID:      event.RefundID,
+	}
+
+	// A redelivered or re-published refund replays the recorded outcome instead of crediting again
+	recorded, err := c.repo.GetOperation(ctx, event.ReferenceID)
+	if err == nil {
+		log.Printf("Refund %d already processed (%s), replaying result", event.RefundID, recorded.Status)
+		c.publishRefundResult(ctx, event, recorded)
+		return
+	}
+	if !errors.Is(err, repository.ErrOperationNotFound) {
+		log.Printf("Failed to look up operation for refund %d: %v", event.RefundID, err)
+		return
+	}
+
+	// Credit the payer; refunds of payments collected inside the bank come back out of the
+	// collecting account in the same transaction, and the refunded service charge out of revenue
+	if event.SettlementAccountID != 0 {
+		err = c.repo.ReturnPayment(ctx, event.SettlementAccountID, event.AccountID, event.Amount,
+			&models.Fee{Amount: event.Fee, AccountID: c.feeAccountID}, op)
 	} else {
-		result.Status = "completed"
-		log.Printf("Payment %d completed successfully (debited %s from account %d)",
-			event.PaymentID, event.Amount.String(), event.AccountID)
+		_, err = c.repo.Credit(ctx, event.AccountID, event.Amount, op)
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrOperationExists):
+		if recorded, getErr := c.repo.GetOperation(ctx, event.ReferenceID); getErr == nil {
+			c.publishRefundResult(ctx, event, recorded)
+		}
+		return
+	case err != nil:
+		log.Printf("Refund %d failed: %v", event.RefundID, err)
+		reason := err.Error()
+		if errors.Is(err, repository.ErrAccountNotFound) {
+			reason = "account not found"
+		}
+		if recErr := c.repo.RecordFailedOperation(ctx, op, reason); recErr != nil {
+			log.Printf("Failed to record failed operation for refund %d: %v", event.RefundID, recErr)
+		}
+		op.Status = models.OperationStatusFailed
+		op.FailureReason = &reason
+	default:
+		log.Printf("Refund %d completed successfully (credited %s to account %d)",
+			event.RefundID, event.Amount.String(), event.AccountID)
+		op.Status = models.OperationStatusCompleted
+		op.Amount = event.Amount
+	}
+
+	c.publishRefundResult(ctx, event, op)
+}
 
-		// Publish success event
-		if pubErr := c.producer.PublishPaymentCompleted(ctx, result); pubErr != nil {
-			log.Printf("Failed to publish payment.completed event: %v", pubErr)
+// publishRefundResult publishes the refunded or refund failed event for a refund operation
+func (c *Consumer) publishRefundResult(ctx context.Context, event models.RefundEvent, op *models.Operation) {
+	result := models.RefundResultEvent{
+		RefundID:      event.RefundID,
+		ReferenceID:   event.ReferenceID,
+		PaymentID:     event.PaymentID,
+		AccountID:     event.AccountID,
+		UserID:        event.UserID,
+		PaymentType:   event.PaymentType,
+		RecipientName: event.RecipientName,
+		Amount:        event.Amount,
+		Currency:      event.Currency,
+	}
+
+	if op.Status == models.OperationStatusFailed {
+		result.Status = "failed"
+		if op.FailureReason != nil {
+			result.FailureReason = *op.FailureReason
 		}
+		if pubErr := c.producer.PublishRefundFailed(ctx, result); pubErr != nil {
+			log.Printf("Failed to publish payment.refund_failed event: %v", pubErr)
+		}
+		return
+	}
+
+	result.Status = "completed"
+	if pubErr := c.producer.PublishRefunded(ctx, result); pubErr != nil {
+		log.Printf("Failed to publish payment.refunded event: %v", pubErr)
 	}
 }
 
@@ -219,10 +529,13 @@ func formatAccountID(id int64) string {
 	return decimal.NewFromInt(id).String()
 }
 
-// Close closes both readers
+// Close closes all readers
 func (c *Consumer) Close() error {
 	if err := c.transferReader.Close(); err != nil {
 		return err
 	}
-	return c.paymentReader.Close()
+	if err := c.paymentReader.Close(); err != nil {
+		return err
+	}
+	return c.refundReader.Close()
 }
@@ -17,6 +17,8 @@ type Producer struct {
 	failedWriter           *kafka.Writer
 	paymentCompletedWriter *kafka.Writer
 	paymentFailedWriter    *kafka.Writer
+	refundedWriter         *kafka.Writer
+	refundFailedWriter     *kafka.Writer
 }
 
 func NewProducer(brokers []string) *Producer {
@@ -56,11 +58,31 @@ func NewProducer(brokers []string) *Producer {
 		Async:        false,
 	}
 
+	refundedWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicRefunded,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	refundFailedWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicRefundFailed,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
 	return &Producer{
 		completedWriter:        completedWriter,
 		failedWriter:           failedWriter,
 		paymentCompletedWriter: paymentCompletedWriter,
 		paymentFailedWriter:    paymentFailedWriter,
+		refundedWriter:         refundedWriter,
+		refundFailedWriter:     refundFailedWriter,
 	}
 }
 
@@ -160,6 +182,40 @@ func (p *Producer) PublishPaymentFailed(ctx context.Context, event models.Paymen
 	return nil
 }
 
+// PublishRefunded publishes a refund credited back to the payer's account
+func (p *Producer) PublishRefunded(ctx context.Context, event models.RefundResultEvent) error {
+	return p.publishRefundResult(ctx, p.refundedWriter, "payment.refunded", event)
+}
+
+// PublishRefundFailed publishes a refund that could not be credited
+func (p *Producer) PublishRefundFailed(ctx context.Context, event models.RefundResultEvent) error {
+	return p.publishRefundResult(ctx, p.refundFailedWriter, "payment.refund_failed", event)
+}
+
+func (p *Producer) publishRefundResult(ctx context.Context, writer *kafka.Writer, eventType string, event models.RefundResultEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(event.ReferenceID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(eventType)},
+			{Key: "payment_id", Value: []byte(fmt.Sprintf("%d", event.PaymentID))},
+			{Key: "refund_id", Value: []byte(fmt.Sprintf("%d", event.RefundID))},
+		},
+	}
+
+	if err := writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	log.Printf("Published %s event for refund %d of payment %d", eventType, event.RefundID, event.PaymentID)
+	return nil
+}
+
 // Close closes all writers
 func (p *Producer) Close() error {
 	if err := p.completedWriter.Close(); err != nil {
@@ -171,7 +227,13 @@ func (p *Producer) Close() error {
 	if err := p.paymentCompletedWriter.Close(); err != nil {
 		return err
 	}
-	return p.paymentFailedWriter.Close()
+	if err := p.paymentFailedWriter.Close(); err != nil {
+		return err
+	}
+	if err := p.refundedWriter.Close(); err != nil {
+		return err
+	}
+	return p.refundFailedWriter.Close()
 }
 
 // EnsureTopicExists creates the topic if it doesn't exist
@@ -86,13 +86,26 @@ func main() {
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicPaymentRequested)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicPaymentCompleted)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicPaymentFailed)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicRefundRequested)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicRefunded)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicRefundFailed)
 
 	// Initialize producer
 	kafkaProducer = kafka.NewProducer(kafkaBrokers)
 	defer kafkaProducer.Close()
 
+	// Bank revenue account credited with transfer fees and merchant service charges
+	revenueAccount, err := accountRepo.GetByAccountNumber(ctx, models.RevenueAccountNumber)
+	if err != nil {
+		log.Fatalf("Revenue account %s is not provisioned: %v", models.RevenueAccountNumber, err)
+	}
+	if revenueAccount.AccountType != models.AccountTypeRevenue {
+		log.Fatalf("Account %s is not the revenue account", models.RevenueAccountNumber)
+	}
+	feeAccountID := revenueAccount.ID
+
 	// Initialize consumer
-	kafkaConsumer = kafka.NewConsumer(kafkaBrokers, "account-service", accountRepo, kafkaProducer)
+	kafkaConsumer = kafka.NewConsumer(kafkaBrokers, "account-service", accountRepo, kafkaProducer, feeAccountID)
 	go kafkaConsumer.Start(ctx)
 	defer kafkaConsumer.Close()
 
@@ -107,6 +120,9 @@ func main() {
 	{
 		api.GET("", listAccounts)
 		api.GET("/directory", listAccountDirectory)
+		api.GET("/operations/:referenceId", getOperation)
+		api.POST("/operations/:referenceId/cancel", cancelOperation)
+		api.GET("/by-number/:number", getAccountByNumber)
 		api.GET("/:id", getAccount)
 		api.POST("", createAccount)
 		api.PUT("/:id", updateAccount)
@@ -114,6 +130,7 @@ func main() {
 		api.GET("/:id/balance", getBalance)
 		api.POST("/:id/deposit", deposit)
 		api.POST("/:id/withdraw", withdraw)
+		api.POST("/:id/credit", credit)
 	}
 
 	// Get port from environment or use default
@@ -369,6 +386,97 @@ func deleteAccount(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "account closed successfully"})
 }
 
+// getOperation returns the recorded outcome of a transfer or payment request (admin only).
+// The transfer and payment services use it to recover sagas whose result event was lost.
+func getOperation(c *gin.Context) {
+	_, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	op, err := accountRepo.GetOperation(c.Request.Context(), c.Param("referenceId"))
+	if err != nil {
+		if errors.Is(err, repository.ErrOperationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "operation not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get operation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, op)
+}
+
+// cancelOperation fences off a transfer or payment request its service has stopped waiting for
+// (admin only). Unless the request was already processed, a failed outcome is recorded so the
+// consumers refuse it if it is delivered later. The response is the outcome now recorded, which
+// the caller must apply: a request processed in the meantime keeps its own outcome.
+func cancelOperation(c *gin.Context) {
+	_, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	var req models.CancelOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	op := &models.Operation{
+		ReferenceID:   c.Param("referenceId"),
+		OperationType: req.OperationType,
+		SourceID:      req.SourceID,
+	}
+	recorded, err := accountRepo.CancelOperation(c.Request.Context(), op, req.Reason)
+	if err != nil {
+		log.Printf("Failed to cancel operation %s: %v", op.ReferenceID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel operation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recorded)
+}
+
+// getAccountByNumber looks an account up by its account number (admin only). The payment service
+// uses it to match the creditor of an incoming credit transfer.
+func getAccountByNumber(c *gin.Context) {
+	_, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	account, err := accountRepo.GetByAccountNumber(c.Request.Context(), c.Param("number"))
+	if err != nil {
+		if errors.Is(err, repository.ErrAccountNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
 func getBalance(c *gin.Context) {
 	userID, role, err := getUserContext(c)
 	if err != nil {
@@ -400,6 +508,7 @@ func getBalance(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.BalanceResponse{
 		AccountID:     account.ID,
+		UserID:        account.UserID,
 		AccountNumber: account.AccountNumber,
 		Balance:       account.Balance,
 		Currency:      account.Currency,
@@ -511,7 +620,7 @@ func withdraw(c *gin.Context) {
 		return
 	}
 
-	account, err := accountRepo.Withdraw(c.Request.Context(), accountID, req.Amount)
+	account, err := accountRepo.Withdraw(c.Request.Context(), accountID, req.Amount, nil)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrAccountNotFound):
@@ -537,3 +646,89 @@ func withdraw(c *gin.Context) {
 		"account": account,
 	})
 }
+
+// credit applies funds arriving from outside the bank, such as an incoming credit transfer or a
+// returned outgoing payment (admin only). Repeating a reference ID returns the recorded operation
+// without crediting again, or 409 if it was recorded for another account or amount.
+func credit(c *gin.Context) {
+	_, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+
+	var req models.CreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	existingAccount, err := accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAccountNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+		return
+	}
+
+	if req.Currency != "" && req.Currency != existingAccount.Currency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency does not match account"})
+		return
+	}
+
+	op := &models.Operation{ReferenceID: req.ReferenceID, OperationType: req.OperationType, SourceID: req.SourceID}
+	account, err := accountRepo.Credit(ctx, accountID, req.Amount, op)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOperationExists):
+			recorded, err := accountRepo.GetOperation(ctx, req.ReferenceID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get operation"})
+				return
+			}
+			// A reference ID reused for a different credit must not pass for the one applied
+			if recorded.OperationType != req.OperationType || recorded.AccountID == nil ||
+				*recorded.AccountID != accountID || !recorded.Amount.Equal(req.Amount) {
+				c.JSON(http.StatusConflict, gin.H{"error": "reference ID already used for a different operation", "operation": recorded})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "credit already applied", "operation": recorded})
+		case errors.Is(err, repository.ErrAccountNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		case errors.Is(err, repository.ErrAccountFrozen):
+			c.JSON(http.StatusForbidden, gin.H{"error": "account is frozen"})
+		case errors.Is(err, repository.ErrAccountClosed):
+			c.JSON(http.StatusForbidden, gin.H{"error": "account is closed"})
+		case errors.Is(err, repository.ErrInvalidAmount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to credit"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "credit successful",
+		"account":   account,
+		"operation": op,
+	})
+}
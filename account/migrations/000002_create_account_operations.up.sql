@@ -0,0 +1,18 @@
+-- Record the outcome of every saga step applied to accounts, keyed by the requesting service's reference ID
+CREATE TABLE IF NOT EXISTS account_operations (
+    reference_id VARCHAR(64) PRIMARY KEY,
+    operation_type VARCHAR(20) NOT NULL,  -- 'transfer', 'reversal', 'payment'
+    source_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,          -- 'completed', 'failed'
+    amount DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    failure_reason TEXT,
+    processed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_account_operations_source ON account_operations(operation_type, source_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE account_operations IS 'Outcome of each transfer/payment request, used for idempotency and saga recovery';
+COMMENT ON COLUMN account_operations.reference_id IS 'Reference ID of the transfer or payment in the requesting service';
+COMMENT ON COLUMN account_operations.source_id IS 'Transfer or payment ID in the requesting service';
+COMMENT ON COLUMN account_operations.amount IS 'Amount actually moved (zero for failed operations)';
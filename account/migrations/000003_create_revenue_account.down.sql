@@ -0,0 +1,2 @@
+DELETE FROM accounts WHERE account_number = '1000000000000001' AND account_type = 'revenue';
+COMMENT ON COLUMN accounts.account_type IS 'Account type: checking or savings';
@@ -0,0 +1,8 @@
+-- The bank's own revenue account, credited with transfer fees and merchant service charges. It
+-- belongs to no customer (user_id 0) and its number is outside the range issued to customers.
+INSERT INTO accounts (user_id, account_number, account_type, currency)
+VALUES (0, '1000000000000001', 'revenue', 'USD')
+ON CONFLICT (account_number) DO NOTHING;
+
+-- Add comments for documentation
+COMMENT ON COLUMN accounts.account_type IS 'Account type: checking or savings, or revenue for the bank''s own revenue account';
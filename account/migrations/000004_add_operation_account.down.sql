@@ -0,0 +1 @@
+ALTER TABLE account_operations DROP COLUMN IF EXISTS account_id;
@@ -0,0 +1,6 @@
+-- Record the account a single-account operation (a credit or withdrawal) was applied to, so a
+-- repeated request can be checked against the one that was applied
+ALTER TABLE account_operations ADD COLUMN IF NOT EXISTS account_id BIGINT;
+
+-- Add comments for documentation
+COMMENT ON COLUMN account_operations.account_id IS 'Account credited or debited by a single-account operation (NULL for transfers and reversals)';
@@ -0,0 +1,26 @@
+-- Claims on an account's incoming funds for reversals it could not cover when they were processed
+CREATE TABLE IF NOT EXISTS account_liens (
+    id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    beneficiary_account_id BIGINT NOT NULL REFERENCES accounts(id),
+    reference_id VARCHAR(64) NOT NULL UNIQUE REFERENCES account_operations(reference_id),
+    source_id BIGINT NOT NULL,
+    reversal_of BIGINT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    fee_refund DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    fee_account_id BIGINT REFERENCES accounts(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',  -- 'active', 'collected'
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    collected_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_liens_active ON account_liens(account_id, id) WHERE status = 'active';
+
+-- Add comments for documentation
+COMMENT ON TABLE account_liens IS 'Shortfall of a held reversal, collected from the account as funds arrive';
+COMMENT ON COLUMN account_liens.account_id IS 'Account the shortfall is owed from (the recipient of the reversed transfer)';
+COMMENT ON COLUMN account_liens.beneficiary_account_id IS 'Account the shortfall is paid to (the sender of the reversed transfer)';
+COMMENT ON COLUMN account_liens.reference_id IS 'Reference ID of the reversal, whose operation stays held until the lien is collected';
+COMMENT ON COLUMN account_liens.amount IS 'Shortfall still owed; the account cannot spend below it while the lien is active';
+COMMENT ON COLUMN account_liens.fee_refund IS 'Share of the reversed transfer''s fee returned to the beneficiary when the lien is collected';
+COMMENT ON COLUMN account_operations.status IS 'Operation status: completed, failed, or held for a reversal waiting on a lien';
@@ -10,8 +10,12 @@ import (
 const (
 	AccountTypeChecking = "checking"
 	AccountTypeSavings  = "savings"
+	AccountTypeRevenue  = "revenue" // the bank's own revenue account, provisioned by migration
 )
 
+// RevenueAccountNumber is the account number of the bank's revenue account
+const RevenueAccountNumber = "1000000000000001"
+
 // Account statuses
 const (
 	AccountStatusActive = "active"
@@ -19,6 +23,37 @@ const (
 	AccountStatusClosed = "closed"
 )
 
+// Reversal policies applied when the recipient of a reversed transfer can't cover the full amount
+const (
+	ReversalPolicyPartial = "partial" // reverse whatever balance is left
+	ReversalPolicyHold    = "hold"    // reverse what is left and place a lien on the recipient account for the rest
+)
+
+// Operation types recorded for saga steps
+const (
+	OperationTypeTransfer = "transfer"
+	OperationTypeReversal = "reversal"
+	OperationTypePayment  = "payment"
+
+	// Credits applied on behalf of the payment service
+	OperationTypeInboundCredit = "inbound_credit" // incoming credit transfer from another bank
+	OperationTypePaymentReturn = "payment_return" // outgoing payment returned by the receiving bank
+	OperationTypePaymentRefund = "payment_refund" // debited payment the payee's provider did not accept, or a refund of a paid one
+)
+
+// Operation statuses
+const (
+	OperationStatusCompleted = "completed"
+	OperationStatusFailed    = "failed"
+	OperationStatusHeld      = "held" // reversal waiting for its lien to be collected
+)
+
+// Lien statuses
+const (
+	LienStatusActive    = "active"
+	LienStatusCollected = "collected"
+)
+
 // Savings account withdrawal limit
 const SavingsDailyWithdrawalLimit = 5000.00
 
@@ -51,6 +86,24 @@ type DepositRequest struct {
 	Currency string          `json:"currency" binding:"omitempty,len=3"`
 }
 
+// CreditRequest credits funds arriving from outside the bank. The reference ID makes the credit
+// idempotent: a second request with the same reference is not applied again.
+type CreditRequest struct {
+	ReferenceID   string          `json:"reference_id" binding:"required,max=64"`
+	OperationType string          `json:"operation_type" binding:"required,oneof=inbound_credit payment_return payment_refund"`
+	SourceID      int64           `json:"source_id"`
+	Amount        decimal.Decimal `json:"amount" binding:"required"`
+	Currency      string          `json:"currency" binding:"omitempty,len=3"`
+}
+
+// CancelOperationRequest fences off a request the requesting service has given up on: a failed
+// outcome is recorded for its reference so the request is never applied if it arrives later
+type CancelOperationRequest struct {
+	OperationType string `json:"operation_type" binding:"required,oneof=transfer reversal payment"`
+	SourceID      int64  `json:"source_id" binding:"required"`
+	Reason        string `json:"reason" binding:"required,max=500"`
+}
+
 type WithdrawRequest struct {
 	Amount   decimal.Decimal `json:"amount" binding:"required"`
 	Currency string          `json:"currency" binding:"omitempty,len=3"`
@@ -58,6 +111,7 @@ type WithdrawRequest struct {
 
 type BalanceResponse struct {
 	AccountID     int64           `json:"account_id"`
+	UserID        int64           `json:"user_id"`
 	AccountNumber string          `json:"account_number"`
 	Balance       decimal.Decimal `json:"balance"`
 	Currency      string          `json:"currency"`
@@ -65,6 +119,41 @@ type BalanceResponse struct {
 	Status        string          `json:"status"`
 }
 
+// Fee is charged to the source of a transfer and credited to the bank's revenue account
+type Fee struct {
+	Amount    decimal.Decimal
+	AccountID int64
+}
+
+// Lien is the shortfall of a held reversal, owed from AccountID to BeneficiaryAccountID. While it is
+// active the account cannot spend below the amount, and it is collected once the balance covers it.
+type Lien struct {
+	ID                   int64           `json:"id"`
+	AccountID            int64           `json:"account_id"`
+	BeneficiaryAccountID int64           `json:"beneficiary_account_id"`
+	ReferenceID          string          `json:"reference_id"`
+	SourceID             int64           `json:"source_id"`
+	ReversalOf           int64           `json:"reversal_of"`
+	Amount               decimal.Decimal `json:"amount"`
+	FeeRefund            decimal.Decimal `json:"fee_refund"`
+	FeeAccountID         *int64          `json:"fee_account_id,omitempty"`
+	Status               string          `json:"status"`
+	CreatedAt            time.Time       `json:"created_at"`
+	CollectedAt          *time.Time      `json:"collected_at,omitempty"`
+}
+
+// Operation is the recorded outcome of a transfer or payment request, keyed by its reference ID
+type Operation struct {
+	ReferenceID   string          `json:"reference_id"`
+	OperationType string          `json:"operation_type"`
+	SourceID      int64           `json:"source_id"`
+	AccountID     *int64          `json:"account_id,omitempty"`
+	Status        string          `json:"status"`
+	Amount        decimal.Decimal `json:"amount"`
+	FailureReason *string         `json:"failure_reason,omitempty"`
+	ProcessedAt   time.Time       `json:"processed_at"`
+}
+
 type AccountListResponse struct {
 	Accounts []Account `json:"accounts"`
 	Total    int64     `json:"total"`
@@ -78,18 +167,27 @@ type TransferEvent struct {
 	ToAccountID   int64           `json:"to_account_id"`
 	Amount        decimal.Decimal `json:"amount"`
 	Currency      string          `json:"currency"`
+	Fee           decimal.Decimal `json:"fee"`
+	// Set when the transfer reverses an earlier one; FeeRefund is the share of its fee returned
+	// to the sender if the reversal settles in full
+	ReversalOf     int64           `json:"reversal_of,omitempty"`
+	ReversalPolicy string          `json:"reversal_policy,omitempty"`
+	FeeRefund      decimal.Decimal `json:"fee_refund,omitempty"`
 }
 
 // TransferResultEvent represents the result of a transfer
 type TransferResultEvent struct {
-	TransferID    int64  `json:"transfer_id"`
-	ReferenceID   string `json:"reference_id"`
-	Status        string `json:"status"` // "completed" or "failed"
-	FailureReason string `json:"failure_reason,omitempty"`
-	FromAccountID int64  `json:"from_account_id,omitempty"`
-	ToAccountID   int64  `json:"to_account_id,omitempty"`
-	FromUserID    int64  `json:"from_user_id,omitempty"`
-	ToUserID      int64  `json:"to_user_id,omitempty"`
+	TransferID    int64           `json:"transfer_id"`
+	ReferenceID   string          `json:"reference_id"`
+	Status        string          `json:"status"` // "completed" or "failed"
+	FailureReason string          `json:"failure_reason,omitempty"`
+	FromAccountID int64           `json:"from_account_id,omitempty"`
+	ToAccountID   int64           `json:"to_account_id,omitempty"`
+	FromUserID    int64           `json:"from_user_id,omitempty"`
+	ToUserID      int64           `json:"to_user_id,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`                // amount actually moved
+	Fee           decimal.Decimal `json:"fee"`                   // fee charged to the sender
+	ReversalOf    int64           `json:"reversal_of,omitempty"` // set for reversal transfers
 }
 
 // PaymentEvent represents a Kafka event for payments
@@ -103,6 +201,11 @@ type PaymentEvent struct {
 	RecipientAccount string          `json:"recipient_account,omitempty"`
 	Amount           decimal.Decimal `json:"amount"`
 	Currency         string          `json:"currency"`
+
+	// SettlementAccountID, when set, is credited with the amount in the same transaction as the debit,
+	// less Fee, which is credited to the bank's fee revenue account
+	SettlementAccountID int64           `json:"settlement_account_id,omitempty"`
+	Fee                 decimal.Decimal `json:"fee"`
 }
 
 // PaymentResultEvent represents the result of a payment
@@ -114,3 +217,38 @@ type PaymentResultEvent struct {
 	AccountID     int64  `json:"account_id,omitempty"`
 	UserID        int64  `json:"user_id,omitempty"`
 }
+
+// RefundEvent represents a Kafka event for a refund of all or part of a payment
+type RefundEvent struct {
+	RefundID         int64           `json:"refund_id"`
+	ReferenceID      string          `json:"reference_id"`
+	PaymentID        int64           `json:"payment_id"`
+	PaymentReference string          `json:"payment_reference"`
+	AccountID        int64           `json:"account_id"`
+	UserID           int64           `json:"user_id"`
+	PaymentType      string          `json:"payment_type"`
+	RecipientName    string          `json:"recipient_name,omitempty"`
+	Amount           decimal.Decimal `json:"amount"`
+	Currency         string          `json:"currency"`
+	Reason           string          `json:"reason,omitempty"`
+
+	// SettlementAccountID, when set, is debited with the amount less Fee in the same transaction as
+	// the credit; Fee, the refunded part of the service charge, comes out of the fee revenue account
+	SettlementAccountID int64           `json:"settlement_account_id,omitempty"`
+	Fee                 decimal.Decimal `json:"fee"`
+}
+
+// RefundResultEvent represents the result of a refund
+type RefundResultEvent struct {
+	RefundID      int64           `json:"refund_id"`
+	ReferenceID   string          `json:"reference_id"`
+	PaymentID     int64           `json:"payment_id"`
+	Status        string          `json:"status"` // "completed" or "failed"
+	FailureReason string          `json:"failure_reason,omitempty"`
+	AccountID     int64           `json:"account_id"`
+	UserID        int64           `json:"user_id"`
+	PaymentType   string          `json:"payment_type"`
+	RecipientName string          `json:"recipient_name,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+}
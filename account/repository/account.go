@@ -6,11 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"time"
 
 	"account/models"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 )
@@ -23,6 +25,10 @@ var (
 	ErrWithdrawalLimitExceed = errors.New("daily withdrawal limit exceeded for savings account")
 	ErrInvalidAmount         = errors.New("invalid amount")
 	ErrInvalidInput          = errors.New("invalid input")
+	ErrReversalHeld          = errors.New("insufficient funds for reversal, lien placed on the account for the rest")
+	ErrOperationNotFound     = errors.New("operation not found")
+	ErrOperationExists       = errors.New("operation already processed")
+	ErrFeeAccountUnavailable = errors.New("fee revenue account is unavailable")
 )
 
 type AccountRepository struct {
@@ -186,13 +192,13 @@ func (r *AccountRepository) ListByUserID(ctx context.Context, userID int64, limi
 	}, nil
 }
 
-// ListAllActive retrieves all active accounts (for transfer directory)
+// ListAllActive retrieves all active customer accounts (for transfer directory)
 func (r *AccountRepository) ListAllActive(ctx context.Context) (*models.AccountListResponse, error) {
 	query := `
 		SELECT id, user_id, account_number, account_type, balance, currency, status,
 		       daily_withdrawal_used, last_withdrawal_date, created_at, updated_at
 		FROM accounts
-		WHERE status = 'active'
+		WHERE status = 'active' AND account_type <> 'revenue'
 		ORDER BY account_number ASC
 	`
 
@@ -363,8 +369,70 @@ func (r *AccountRepository) Deposit(ctx context.Context, id int64, amount decima
 	return account, nil
 }
 
+// Credit adds funds arriving from outside the bank, recording the operation in the same transaction
+// so the credit is applied at most once per reference ID (ErrOperationExists otherwise)
+func (r *AccountRepository) Credit(ctx context.Context, id int64, amount decimal.Decimal, op *models.Operation) (*models.Account, error) {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrInvalidAmount
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM accounts WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if status == models.AccountStatusFrozen {
+		return nil, ErrAccountFrozen
+	}
+	if status == models.AccountStatusClosed {
+		return nil, ErrAccountClosed
+	}
+
+	query := `
+		UPDATE accounts
+		SET balance = balance + $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING id, user_id, account_number, account_type, balance, currency, status,
+		          daily_withdrawal_used, last_withdrawal_date, created_at, updated_at
+	`
+
+	account := &models.Account{}
+	err = tx.QueryRow(ctx, query, amount, id).Scan(
+		&account.ID, &account.UserID, &account.AccountNumber, &account.AccountType,
+		&account.Balance, &account.Currency, &account.Status,
+		&account.DailyWithdrawalUsed, &account.LastWithdrawalDate,
+		&account.CreatedAt, &account.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to credit: %w", err)
+	}
+
+	if op != nil {
+		op.AccountID = &id
+	}
+	if err := recordOperation(ctx, tx, op, amount); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return account, nil
+}
+
 // Withdraw removes funds from an account
-func (r *AccountRepository) Withdraw(ctx context.Context, id int64, amount decimal.Decimal) (*models.Account, error) {
+func (r *AccountRepository) Withdraw(ctx context.Context, id int64, amount decimal.Decimal, op *models.Operation) (*models.Account, error) {
 	if amount.LessThanOrEqual(decimal.Zero) {
 		return nil, ErrInvalidAmount
 	}
@@ -407,7 +475,11 @@ func (r *AccountRepository) Withdraw(ctx context.Context, id int64, amount decim
 		return nil, ErrAccountClosed
 	}
 
-	if account.Balance.LessThan(amount) {
+	liened, err := lienedAmount(ctx, tx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+	if account.Balance.Sub(liened).LessThan(amount) {
 		return nil, ErrInsufficientFunds
 	}
 
@@ -466,6 +538,13 @@ func (r *AccountRepository) Withdraw(ctx context.Context, id int64, amount decim
 		return nil, fmt.Errorf("failed to withdraw: %w", err)
 	}
 
+	if op != nil {
+		op.AccountID = &id
+	}
+	if err := recordOperation(ctx, tx, op, amount); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -473,68 +552,61 @@ func (r *AccountRepository) Withdraw(ctx context.Context, id int64, amount decim
 	return account, nil
 }
 
-// Transfer moves funds between accounts atomically
-func (r *AccountRepository) Transfer(ctx context.Context, fromID, toID int64, amount decimal.Decimal) error {
+// Transfer moves amount from fromID to toID. A non-zero fee is debited from the source in the same
+// transaction and credited to the fee's revenue account.
+func (r *AccountRepository) Transfer(ctx context.Context, fromID, toID int64, amount decimal.Decimal, fee *models.Fee, op *models.Operation) error {
 	if amount.LessThanOrEqual(decimal.Zero) {
 		return ErrInvalidAmount
 	}
 
+	feeAmount := decimal.Zero
+	if fee != nil && fee.Amount.IsPositive() {
+		if fee.AccountID == 0 {
+			return ErrFeeAccountUnavailable
+		}
+		feeAmount = fee.Amount
+	}
+	debit := amount.Add(feeAmount)
+
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	// Lock both accounts in consistent order to prevent deadlocks
-	var firstID, secondID int64
-	if fromID < toID {
-		firstID, secondID = fromID, toID
-	} else {
-		firstID, secondID = toID, fromID
+	// Lock every account involved in ascending ID order to prevent deadlocks
+	ids := []int64{fromID, toID}
+	if feeAmount.IsPositive() && fee.AccountID != fromID && fee.AccountID != toID {
+		ids = append(ids, fee.AccountID)
 	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
 
-	// Lock first account
-	var firstAccount models.Account
 	lockQuery := `
 		SELECT id, user_id, account_number, account_type, balance, currency, status,
 		       daily_withdrawal_used, last_withdrawal_date, created_at, updated_at
 		FROM accounts WHERE id = $1 FOR UPDATE
 	`
-	err = tx.QueryRow(ctx, lockQuery, firstID).Scan(
-		&firstAccount.ID, &firstAccount.UserID, &firstAccount.AccountNumber, &firstAccount.AccountType,
-		&firstAccount.Balance, &firstAccount.Currency, &firstAccount.Status,
-		&firstAccount.DailyWithdrawalUsed, &firstAccount.LastWithdrawalDate,
-		&firstAccount.CreatedAt, &firstAccount.UpdatedAt,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrAccountNotFound
-		}
-		return fmt.Errorf("failed to lock first account: %w", err)
-	}
-
-	// Lock second account
-	var secondAccount models.Account
-	err = tx.QueryRow(ctx, lockQuery, secondID).Scan(
-		&secondAccount.ID, &secondAccount.UserID, &secondAccount.AccountNumber, &secondAccount.AccountType,
-		&secondAccount.Balance, &secondAccount.Currency, &secondAccount.Status,
-		&secondAccount.DailyWithdrawalUsed, &secondAccount.LastWithdrawalDate,
-		&secondAccount.CreatedAt, &secondAccount.UpdatedAt,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrAccountNotFound
+	locked := make(map[int64]*models.Account, len(ids))
+	for _, id := range ids {
+		var account models.Account
+		err = tx.QueryRow(ctx, lockQuery, id).Scan(
+			&account.ID, &account.UserID, &account.AccountNumber, &account.AccountType,
+			&account.Balance, &account.Currency, &account.Status,
+			&account.DailyWithdrawalUsed, &account.LastWithdrawalDate,
+			&account.CreatedAt, &account.UpdatedAt,
+		)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				if id == fromID || id == toID {
+					return ErrAccountNotFound
+				}
+				return ErrFeeAccountUnavailable
+			}
+			return fmt.Errorf("failed to lock account %d: %w", id, err)
 		}
-		return fmt.Errorf("failed to lock second account: %w", err)
-	}
-
-	// Identify source and destination
-	var fromAccount, toAccount *models.Account
-	if firstID == fromID {
-		fromAccount, toAccount = &firstAccount, &secondAccount
-	} else {
-		fromAccount, toAccount = &secondAccount, &firstAccount
+		locked[id] = &account
 	}
+	fromAccount, toAccount := locked[fromID], locked[toID]
 
 	// Validate source account
 	if fromAccount.Status == models.AccountStatusFrozen {
@@ -543,7 +615,11 @@ func (r *AccountRepository) Transfer(ctx context.Context, fromID, toID int64, am
 	if fromAccount.Status == models.AccountStatusClosed {
 		return ErrAccountClosed
 	}
-	if fromAccount.Balance.LessThan(amount) {
+	liened, err := lienedAmount(ctx, tx, fromID, 0)
+	if err != nil {
+		return err
+	}
+	if fromAccount.Balance.Sub(liened).LessThan(debit) {
 		return ErrInsufficientFunds
 	}
 
@@ -555,7 +631,7 @@ func (r *AccountRepository) Transfer(ctx context.Context, fromID, toID int64, am
 		return fmt.Errorf("destination account is closed")
 	}
 
-	// Check savings withdrawal limit for source
+	// Check savings withdrawal limit for source; the fee counts towards it
 	if fromAccount.AccountType == models.AccountTypeSavings {
 		today := time.Now().Truncate(24 * time.Hour)
 		dailyUsed := fromAccount.DailyWithdrawalUsed
@@ -565,7 +641,7 @@ func (r *AccountRepository) Transfer(ctx context.Context, fromID, toID int64, am
 		}
 
 		limitDecimal := decimal.NewFromFloat(models.SavingsDailyWithdrawalLimit)
-		if dailyUsed.Add(amount).GreaterThan(limitDecimal) {
+		if dailyUsed.Add(debit).GreaterThan(limitDecimal) {
 			return ErrWithdrawalLimitExceed
 		}
 
@@ -574,10 +650,10 @@ func (r *AccountRepository) Transfer(ctx context.Context, fromID, toID int64, am
 			UPDATE accounts
 			SET balance = balance - $1, daily_withdrawal_used = $2, last_withdrawal_date = $3, updated_at = NOW()
 			WHERE id = $4
-		`, amount, dailyUsed.Add(amount), today, fromID)
+		`, debit, dailyUsed.Add(debit), today, fromID)
 	} else {
 		// Debit source
-		_, err = tx.Exec(ctx, `UPDATE accounts SET balance = balance - $1, updated_at = NOW() WHERE id = $2`, amount, fromID)
+		_, err = tx.Exec(ctx, `UPDATE accounts SET balance = balance - $1, updated_at = NOW() WHERE id = $2`, debit, fromID)
 	}
 	if err != nil {
 		return fmt.Errorf("failed to debit source account: %w", err)
@@ -589,9 +665,366 @@ func (r *AccountRepository) Transfer(ctx context.Context, fromID, toID int64, am
 		return fmt.Errorf("failed to credit destination account: %w", err)
 	}
 
+	// Credit the fee to the bank's revenue account
+	if feeAmount.IsPositive() {
+		if locked[fee.AccountID].Status == models.AccountStatusClosed {
+			return ErrFeeAccountUnavailable
+		}
+		_, err = tx.Exec(ctx, `UPDATE accounts SET balance = balance + $1, updated_at = NOW() WHERE id = $2`, feeAmount, fee.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to credit fee account: %w", err)
+		}
+	}
+
+	if err := recordOperation(ctx, tx, op, amount); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transfer: %w", err)
 	}
 
 	return nil
 }
+
+// ReturnPayment refunds amount of a payment collected inside the bank to the payer's account: the
+// collecting account pays back the amount less the service charge it was never credited, and the
+// fee revenue account pays back that charge, all in one transaction
+func (r *AccountRepository) ReturnPayment(ctx context.Context, fromID, toID int64, amount decimal.Decimal, fee *models.Fee, op *models.Operation) error {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return ErrInvalidAmount
+	}
+
+	feeAmount := decimal.Zero
+	if fee != nil && fee.Amount.IsPositive() {
+		if fee.AccountID == 0 {
+			return ErrFeeAccountUnavailable
+		}
+		feeAmount = decimal.Min(fee.Amount, amount)
+	}
+	net := amount.Sub(feeAmount)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock every account involved in ascending ID order to prevent deadlocks
+	ids := []int64{fromID, toID}
+	if feeAmount.IsPositive() && fee.AccountID != fromID && fee.AccountID != toID {
+		ids = append(ids, fee.AccountID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	locked := make(map[int64]*models.Account, len(ids))
+	for _, id := range ids {
+		var account models.Account
+		err = tx.QueryRow(ctx, `SELECT id, balance, status FROM accounts WHERE id = $1 FOR UPDATE`, id).Scan(
+			&account.ID, &account.Balance, &account.Status,
+		)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				if id == fromID || id == toID {
+					return ErrAccountNotFound
+				}
+				return ErrFeeAccountUnavailable
+			}
+			return fmt.Errorf("failed to lock account %d: %w", id, err)
+		}
+		locked[id] = &account
+	}
+	fromAccount, toAccount := locked[fromID], locked[toID]
+
+	if fromAccount.Status == models.AccountStatusFrozen {
+		return ErrAccountFrozen
+	}
+	if fromAccount.Status == models.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+	liened, err := lienedAmount(ctx, tx, fromID, 0)
+	if err != nil {
+		return err
+	}
+	if fromAccount.Balance.Sub(liened).LessThan(net) {
+		return ErrInsufficientFunds
+	}
+	if toAccount.Status == models.AccountStatusFrozen {
+		return fmt.Errorf("destination account is frozen")
+	}
+	if toAccount.Status == models.AccountStatusClosed {
+		return fmt.Errorf("destination account is closed")
+	}
+	if feeAmount.IsPositive() && locked[fee.AccountID].Status == models.AccountStatusClosed {
+		return ErrFeeAccountUnavailable
+	}
+
+	if err := moveFunds(ctx, tx, fromID, toID, net); err != nil {
+		return err
+	}
+	if feeAmount.IsPositive() {
+		if err := moveFunds(ctx, tx, fee.AccountID, toID, feeAmount); err != nil {
+			return fmt.Errorf("failed to refund fee: %w", err)
+		}
+	}
+
+	if err := recordOperation(ctx, tx, op, amount); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit payment return: %w", err)
+	}
+
+	return nil
+}
+
+// reversalAmount decides how much of a reversal can be settled from the recipient's available
+// balance. Under the hold policy a shortfall is reported with ErrReversalHeld alongside what can
+// be settled now, and is collected later through a lien.
+func reversalAmount(available, amount decimal.Decimal, policy string) (decimal.Decimal, error) {
+	if available.GreaterThanOrEqual(amount) {
+		return amount, nil
+	}
+
+	settled := decimal.Max(available, decimal.Zero)
+	if policy == models.ReversalPolicyHold {
+		return settled, ErrReversalHeld
+	}
+
+	if settled.IsZero() {
+		return decimal.Zero, ErrInsufficientFunds
+	}
+
+	return settled, nil
+}
+
+// feeRefundFor scales the fee refund of a reversal down to the amount it actually settled for
+func feeRefundFor(refund, amount, settled decimal.Decimal) decimal.Decimal {
+	if settled.GreaterThanOrEqual(amount) {
+		return refund
+	}
+	return refund.Mul(settled).Div(amount).Round(2)
+}
+
+// Reverse moves funds back from the recipient of an earlier transfer (reversalOf, in the transfer
+// service) to its sender: from fromID to toID,
+// and pays the sender's share of the original fee back from the fee's revenue account. Unlike
+// Transfer it ignores frozen status and savings limits on the recipient. When the recipient has
+// already spent part of the funds, the partial policy reverses what is left and refunds the fee in
+// proportion. The hold policy reverses what is left too, places a lien on the recipient account for
+// the shortfall, records the operation as held and returns ErrReversalHeld; CollectLien completes it.
+// Returns the amount reversed so far.
+func (r *AccountRepository) Reverse(ctx context.Context, reversalOf, fromID, toID int64, amount decimal.Decimal, policy string, feeRefund *models.Fee, op *models.Operation) (decimal.Decimal, error) {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return decimal.Zero, ErrInvalidAmount
+	}
+
+	refund := decimal.Zero
+	if feeRefund != nil && feeRefund.Amount.IsPositive() {
+		if feeRefund.AccountID == 0 {
+			return decimal.Zero, ErrFeeAccountUnavailable
+		}
+		refund = feeRefund.Amount
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock every account involved in ascending ID order to prevent deadlocks
+	ids := []int64{fromID, toID}
+	if refund.IsPositive() && feeRefund.AccountID != fromID && feeRefund.AccountID != toID {
+		ids = append(ids, feeRefund.AccountID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	lockQuery := `SELECT balance, status FROM accounts WHERE id = $1 FOR UPDATE`
+	balances := make(map[int64]decimal.Decimal, len(ids))
+	statuses := make(map[int64]string, len(ids))
+	for _, id := range ids {
+		var balance decimal.Decimal
+		var status string
+		if err := tx.QueryRow(ctx, lockQuery, id).Scan(&balance, &status); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				if id == fromID || id == toID {
+					return decimal.Zero, ErrAccountNotFound
+				}
+				return decimal.Zero, ErrFeeAccountUnavailable
+			}
+			return decimal.Zero, fmt.Errorf("failed to lock account %d: %w", id, err)
+		}
+		balances[id] = balance
+		statuses[id] = status
+	}
+
+	if statuses[fromID] == models.AccountStatusClosed {
+		return decimal.Zero, ErrAccountClosed
+	}
+	if statuses[toID] == models.AccountStatusClosed {
+		return decimal.Zero, fmt.Errorf("destination account is closed")
+	}
+	if refund.IsPositive() && statuses[feeRefund.AccountID] == models.AccountStatusClosed {
+		return decimal.Zero, ErrFeeAccountUnavailable
+	}
+
+	liened, err := lienedAmount(ctx, tx, fromID, 0)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	settled, err := reversalAmount(balances[fromID].Sub(liened), amount, policy)
+	held := errors.Is(err, ErrReversalHeld)
+	if err != nil && !held {
+		return decimal.Zero, err
+	}
+
+	if err := moveFunds(ctx, tx, fromID, toID, settled); err != nil {
+		return decimal.Zero, err
+	}
+
+	if held {
+		// The rest, and the whole fee refund, are collected with the lien
+		if err := holdOperation(ctx, tx, op, settled); err != nil {
+			return decimal.Zero, err
+		}
+		if err := placeLien(ctx, tx, reversalOf, fromID, toID, amount.Sub(settled), feeRefund, op); err != nil {
+			return decimal.Zero, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return decimal.Zero, fmt.Errorf("failed to commit hold: %w", err)
+		}
+		return settled, ErrReversalHeld
+	}
+
+	if refund.IsPositive() {
+		if err := moveFunds(ctx, tx, feeRefund.AccountID, toID, feeRefundFor(refund, amount, settled)); err != nil {
+			return decimal.Zero, fmt.Errorf("failed to refund fee: %w", err)
+		}
+	}
+
+	if err := recordOperation(ctx, tx, op, settled); err != nil {
+		return decimal.Zero, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to commit reversal: %w", err)
+	}
+
+	return settled, nil
+}
+
+// moveFunds debits fromID and credits toID with amount inside tx, with no checks of its own
+func moveFunds(ctx context.Context, tx pgx.Tx, fromID, toID int64, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `UPDATE accounts SET balance = balance - $1, updated_at = NOW() WHERE id = $2`, amount, fromID)
+	if err != nil {
+		return fmt.Errorf("failed to debit account %d: %w", fromID, err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE accounts SET balance = balance + $1, updated_at = NOW() WHERE id = $2`, amount, toID)
+	if err != nil {
+		return fmt.Errorf("failed to credit account %d: %w", toID, err)
+	}
+
+	return nil
+}
+
+// recordOperation stores the successful outcome of a saga step in the step's own transaction, so a
+// redelivered or re-published request can never move funds twice. A nil op records nothing.
+func recordOperation(ctx context.Context, tx pgx.Tx, op *models.Operation, amount decimal.Decimal) error {
+	if op == nil {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO account_operations (reference_id, operation_type, source_id, account_id, status, amount)
+		VALUES ($1, $2, $3, $4, 'completed', $5)
+	`, op.ReferenceID, op.OperationType, op.SourceID, op.AccountID, amount)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrOperationExists
+		}
+		return fmt.Errorf("failed to record operation: %w", err)
+	}
+
+	op.Status = models.OperationStatusCompleted
+	op.Amount = amount
+	return nil
+}
+
+// holdOperation records a reversal that is waiting for its lien to be collected, with the amount
+// reversed so far. Like a recorded outcome it stops the request from being applied twice.
+func holdOperation(ctx context.Context, tx pgx.Tx, op *models.Operation, amount decimal.Decimal) error {
+	if op == nil {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO account_operations (reference_id, operation_type, source_id, status, amount)
+		VALUES ($1, $2, $3, 'held', $4)
+	`, op.ReferenceID, op.OperationType, op.SourceID, amount)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrOperationExists
+		}
+		return fmt.Errorf("failed to record held operation: %w", err)
+	}
+
+	op.Status = models.OperationStatusHeld
+	op.Amount = amount
+	return nil
+}
+
+// RecordFailedOperation stores a failed outcome; an outcome already recorded for the reference is kept
+func (r *AccountRepository) RecordFailedOperation(ctx context.Context, op *models.Operation, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO account_operations (reference_id, operation_type, source_id, status, failure_reason)
+		VALUES ($1, $2, $3, 'failed', $4)
+		ON CONFLICT (reference_id) DO NOTHING
+	`, op.ReferenceID, op.OperationType, op.SourceID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to record operation: %w", err)
+	}
+
+	return nil
+}
+
+// CancelOperation records a failed outcome for a request that has not been processed, so that it is
+// refused if it is delivered later, and returns the outcome now recorded for the reference: the
+// cancellation, or whatever the request was already processed to
+func (r *AccountRepository) CancelOperation(ctx context.Context, op *models.Operation, reason string) (*models.Operation, error) {
+	if err := r.RecordFailedOperation(ctx, op, reason); err != nil {
+		return nil, err
+	}
+	return r.GetOperation(ctx, op.ReferenceID)
+}
+
+// GetOperation retrieves the recorded outcome of a transfer or payment request by its reference ID
+func (r *AccountRepository) GetOperation(ctx context.Context, referenceID string) (*models.Operation, error) {
+	query := `
+		SELECT reference_id, operation_type, source_id, account_id, status, amount, failure_reason, processed_at
+		FROM account_operations
+		WHERE reference_id = $1
+	`
+
+	op := &models.Operation{}
+	err := r.db.QueryRow(ctx, query, referenceID).Scan(
+		&op.ReferenceID, &op.OperationType, &op.SourceID, &op.AccountID, &op.Status, &op.Amount, &op.FailureReason, &op.ProcessedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOperationNotFound
+		}
+		return nil, fmt.Errorf("failed to get operation: %w", err)
+	}
+
+	return op, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"account/models"
@@ -274,6 +275,63 @@ func TestAccountStatusValidation(t *testing.T) {
 	}
 }
 
+func TestReversalAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance string
+		amount  string
+		policy  string
+		want    string
+		wantErr error
+	}{
+		{name: "full reversal", balance: "500.00", amount: "200.00", policy: models.ReversalPolicyPartial, want: "200.00"},
+		{name: "partial reversal of remaining balance", balance: "80.00", amount: "200.00", policy: models.ReversalPolicyPartial, want: "80.00"},
+		{name: "partial reversal with nothing left", balance: "0.00", amount: "200.00", policy: models.ReversalPolicyPartial, wantErr: ErrInsufficientFunds},
+		{name: "hold reverses what is left when short", balance: "80.00", amount: "200.00", policy: models.ReversalPolicyHold, want: "80.00", wantErr: ErrReversalHeld},
+		{name: "hold with the balance already under a lien", balance: "-20.00", amount: "200.00", policy: models.ReversalPolicyHold, want: "0.00", wantErr: ErrReversalHeld},
+		{name: "hold policy with enough funds", balance: "200.00", amount: "200.00", policy: models.ReversalPolicyHold, want: "200.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balance, _ := decimal.NewFromString(tt.balance)
+			amount, _ := decimal.NewFromString(tt.amount)
+
+			got, err := reversalAmount(balance, amount, tt.policy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("reversalAmount() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.want != "" && !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("reversalAmount() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeeRefundFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		refund  string
+		amount  string
+		settled string
+		want    string
+	}{
+		{name: "settled in full", refund: "2.50", amount: "200.00", settled: "200.00", want: "2.50"},
+		{name: "settled in part", refund: "2.50", amount: "200.00", settled: "80.00", want: "1.00"},
+		{name: "rounded to cents", refund: "1.00", amount: "300.00", settled: "100.00", want: "0.33"},
+		{name: "nothing settled", refund: "2.50", amount: "200.00", settled: "0.00", want: "0.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := feeRefundFor(decimal.RequireFromString(tt.refund), decimal.RequireFromString(tt.amount), decimal.RequireFromString(tt.settled))
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("feeRefundFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 // Integration test example (requires database)
 func TestAccountRepository_Integration(t *testing.T) {
 	// Skip if not in integration test mode
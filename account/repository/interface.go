@@ -19,6 +19,14 @@ type AccountRepo interface {
 	Update(ctx context.Context, id int64, req *models.UpdateAccountRequest) (*models.Account, error)
 	Delete(ctx context.Context, id int64) error
 	Deposit(ctx context.Context, id int64, amount decimal.Decimal) (*models.Account, error)
-	Withdraw(ctx context.Context, id int64, amount decimal.Decimal) (*models.Account, error)
-	Transfer(ctx context.Context, fromID, toID int64, amount decimal.Decimal) error
+	Credit(ctx context.Context, id int64, amount decimal.Decimal, op *models.Operation) (*models.Account, error)
+	Withdraw(ctx context.Context, id int64, amount decimal.Decimal, op *models.Operation) (*models.Account, error)
+	Transfer(ctx context.Context, fromID, toID int64, amount decimal.Decimal, fee *models.Fee, op *models.Operation) error
+	ReturnPayment(ctx context.Context, fromID, toID int64, amount decimal.Decimal, fee *models.Fee, op *models.Operation) error
+	Reverse(ctx context.Context, reversalOf, fromID, toID int64, amount decimal.Decimal, policy string, feeRefund *models.Fee, op *models.Operation) (decimal.Decimal, error)
+	ListActiveLiens(ctx context.Context) ([]models.Lien, error)
+	CollectLien(ctx context.Context, id int64) (*models.Lien, error)
+	RecordFailedOperation(ctx context.Context, op *models.Operation, reason string) error
+	CancelOperation(ctx context.Context, op *models.Operation, reason string) (*models.Operation, error)
+	GetOperation(ctx context.Context, referenceID string) (*models.Operation, error)
 }
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"account/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+var ErrLienNotFound = errors.New("active lien not found")
+
+const lienColumns = `id, account_id, beneficiary_account_id, reference_id, source_id, reversal_of,
+		       amount, fee_refund, fee_account_id, status, created_at, collected_at`
+
+func scanLien(row pgx.Row, lien *models.Lien) error {
+	return row.Scan(
+		&lien.ID, &lien.AccountID, &lien.BeneficiaryAccountID, &lien.ReferenceID, &lien.SourceID, &lien.ReversalOf,
+		&lien.Amount, &lien.FeeRefund, &lien.FeeAccountID, &lien.Status, &lien.CreatedAt, &lien.CollectedAt,
+	)
+}
+
+// lienedAmount sums the active liens on an account, only those placed before beforeID when it is set.
+// The account's row must already be locked by tx.
+func lienedAmount(ctx context.Context, tx pgx.Tx, accountID, beforeID int64) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM account_liens
+		WHERE account_id = $1 AND status = 'active' AND ($2 = 0 OR id < $2)
+	`, accountID, beforeID).Scan(&total)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to sum liens on account %d: %w", accountID, err)
+	}
+	return total, nil
+}
+
+// placeLien records the shortfall of a held reversal against the recipient account, together with
+// the fee refund to pay when it is collected
+func placeLien(ctx context.Context, tx pgx.Tx, reversalOf, accountID, beneficiaryID int64, amount decimal.Decimal, feeRefund *models.Fee, op *models.Operation) error {
+	refund := decimal.Zero
+	var feeAccountID *int64
+	if feeRefund != nil && feeRefund.Amount.IsPositive() {
+		refund = feeRefund.Amount
+		feeAccountID = &feeRefund.AccountID
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO account_liens (account_id, beneficiary_account_id, reference_id, source_id, reversal_of, amount, fee_refund, fee_account_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, accountID, beneficiaryID, op.ReferenceID, op.SourceID, reversalOf, amount, refund, feeAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to place lien: %w", err)
+	}
+	return nil
+}
+
+// ListActiveLiens returns the liens still to be collected, oldest first
+func (r *AccountRepository) ListActiveLiens(ctx context.Context) ([]models.Lien, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+lienColumns+` FROM account_liens WHERE status = 'active' ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list liens: %w", err)
+	}
+	defer rows.Close()
+
+	liens := []models.Lien{}
+	for rows.Next() {
+		var lien models.Lien
+		if err := scanLien(rows, &lien); err != nil {
+			return nil, fmt.Errorf("failed to scan lien: %w", err)
+		}
+		liens = append(liens, lien)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating liens: %w", err)
+	}
+
+	return liens, nil
+}
+
+// CollectLien moves the shortfall of a held reversal to its beneficiary, with the fee refund, once
+// the account's balance covers it after any older liens, and completes the held operation for the
+// full reversal amount. Returns ErrInsufficientFunds while the balance falls short.
+func (r *AccountRepository) CollectLien(ctx context.Context, id int64) (*models.Lien, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	lien := &models.Lien{}
+	err = scanLien(tx.QueryRow(ctx, `SELECT `+lienColumns+` FROM account_liens WHERE id = $1 AND status = 'active' FOR UPDATE`, id), lien)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLienNotFound
+		}
+		return nil, fmt.Errorf("failed to get lien: %w", err)
+	}
+
+	// Lock every account involved in ascending ID order to prevent deadlocks
+	ids := []int64{lien.AccountID, lien.BeneficiaryAccountID}
+	if lien.FeeAccountID != nil && *lien.FeeAccountID != lien.AccountID && *lien.FeeAccountID != lien.BeneficiaryAccountID {
+		ids = append(ids, *lien.FeeAccountID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var balance decimal.Decimal
+	for _, accountID := range ids {
+		var b decimal.Decimal
+		if err := tx.QueryRow(ctx, `SELECT balance FROM accounts WHERE id = $1 FOR UPDATE`, accountID).Scan(&b); err != nil {
+			return nil, fmt.Errorf("failed to lock account %d: %w", accountID, err)
+		}
+		if accountID == lien.AccountID {
+			balance = b
+		}
+	}
+
+	older, err := lienedAmount(ctx, tx, lien.AccountID, lien.ID)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Sub(older).LessThan(lien.Amount) {
+		return nil, ErrInsufficientFunds
+	}
+
+	if err := moveFunds(ctx, tx, lien.AccountID, lien.BeneficiaryAccountID, lien.Amount); err != nil {
+		return nil, err
+	}
+	if lien.FeeAccountID != nil {
+		if err := moveFunds(ctx, tx, *lien.FeeAccountID, lien.BeneficiaryAccountID, lien.FeeRefund); err != nil {
+			return nil, fmt.Errorf("failed to refund fee: %w", err)
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE account_liens SET status = 'collected', collected_at = NOW()
+		WHERE id = $1
+		RETURNING status, collected_at
+	`, lien.ID).Scan(&lien.Status, &lien.CollectedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect lien: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE account_operations SET status = 'completed', amount = amount + $2, processed_at = NOW()
+		WHERE reference_id = $1 AND status = 'held'
+	`, lien.ReferenceID, lien.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete held operation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit lien collection: %w", err)
+	}
+
+	return lien, nil
+}
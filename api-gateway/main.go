@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"log"
@@ -41,6 +42,14 @@ type LoginResponse struct {
 }
 
 func main() {
+	// Relay transfer, payment and notification events to connected clients
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streamHub := NewStreamHub()
+	kafkaBrokers := strings.Split(getEnv("KAFKA_BROKERS", "localhost:9092"), ",")
+	StartStreamConsumers(ctx, kafkaBrokers, streamHub)
+
 	// Create Gin router
 	router := gin.Default()
 
@@ -56,11 +65,21 @@ func main() {
 	public := router.Group("/api/v1")
 	{
 		public.POST("/auth/login", handleLogin)
+
+		// Payment providers report outcomes here; the payment service verifies their signatures
+		public.POST("/callbacks/payments/:provider", proxyProviderCallback)
+
+		// Receipts carry a verification hash anyone can check against the issuing service
+		public.GET("/receipts/payments/verify", proxyPaymentReceiptVerification)
+		public.GET("/receipts/transfers/verify", proxyTransferReceiptVerification)
 	}
 
-	// Protected routes (require JWT authentication)
+	// Real-time status stream; EventSource clients may pass the token as ?access_token=
+	router.GET("/api/v1/stream", tokenFromQuery(), authMiddleware(), handleStream(streamHub))
+
+	// Protected routes (require JWT authentication, plus a one-time code for sensitive operations)
 	protected := router.Group("/api/v1")
-	protected.Use(authMiddleware())
+	protected.Use(authMiddleware(), stepUpMiddleware(loadStepUpConfig()))
 	{
 		// Admin-only status endpoint
 		protected.GET("/admin/status", adminOnly(), handleStatus)
@@ -250,6 +269,34 @@ func proxyToPaymentService(c *gin.Context) {
 	proxyRequest(c, paymentServiceURL, "/api/v1/payments", "/api/payments")
 }
 
+// proxyProviderCallback forwards an unauthenticated provider callback, dropping any user context
+// headers the caller sent so they cannot reach the payment service
+func proxyProviderCallback(c *gin.Context) {
+	c.Request.Header.Del("X-User-ID")
+	c.Request.Header.Del("X-User-Role")
+
+	paymentServiceURL := getEnv("PAYMENT_SERVICE_URL", "http://payment.payment.svc.cluster.local:8080")
+	proxyRequest(c, paymentServiceURL, "/api/v1/callbacks/payments", "/api/payments/callbacks")
+}
+
+// proxyPaymentReceiptVerification forwards an unauthenticated receipt check to the payment service
+func proxyPaymentReceiptVerification(c *gin.Context) {
+	c.Request.Header.Del("X-User-ID")
+	c.Request.Header.Del("X-User-Role")
+
+	paymentServiceURL := getEnv("PAYMENT_SERVICE_URL", "http://payment.payment.svc.cluster.local:8080")
+	proxyRequest(c, paymentServiceURL, "/api/v1/receipts/payments", "/api/payments/receipts")
+}
+
+// proxyTransferReceiptVerification forwards an unauthenticated receipt check to the transfer service
+func proxyTransferReceiptVerification(c *gin.Context) {
+	c.Request.Header.Del("X-User-ID")
+	c.Request.Header.Del("X-User-Role")
+
+	transferServiceURL := getEnv("TRANSFER_SERVICE_URL", "http://transfer.transfer.svc.cluster.local:8080")
+	proxyRequest(c, transferServiceURL, "/api/v1/receipts/transfers", "/api/transfers/receipts")
+}
+
 func proxyToTransferService(c *gin.Context) {
 	transferServiceURL := getEnv("TRANSFER_SERVICE_URL", "http://transfer.transfer.svc.cluster.local:8080")
 	proxyRequest(c, transferServiceURL, "/api/v1/transfers", "/api/transfers")
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Step-up operations; the names match the user service's challenge operations
+const (
+	stepUpTransfer        = "transfer"
+	stepUpPayment         = "payment"
+	stepUpExternalPayment = "external_payment"
+	stepUpBeneficiary     = "beneficiary"
+	stepUpCardPIN         = "card_pin"
+	stepUpPassword        = "password"
+	stepUpMFA             = "mfa"
+)
+
+// Headers a client resends a challenged request with
+const (
+	headerChallengeID = "X-Challenge-ID"
+	headerOTPCode     = "X-OTP-Code"
+)
+
+// StepUpConfig decides which requests need a one-time code before they are forwarded
+type StepUpConfig struct {
+	// Operations lists the operations that are challenged at all
+	Operations map[string]bool
+	// AmountThreshold is the amount above which requests that move money are challenged
+	AmountThreshold *big.Rat
+}
+
+// loadStepUpConfig reads STEP_UP_OPERATIONS (comma separated, "none" to disable) and
+// STEP_UP_AMOUNT_THRESHOLD
+func loadStepUpConfig() StepUpConfig {
+	cfg := StepUpConfig{Operations: map[string]bool{}}
+
+	ops := getEnv("STEP_UP_OPERATIONS", strings.Join([]string{
+		stepUpTransfer, stepUpPayment, stepUpExternalPayment, stepUpBeneficiary, stepUpCardPIN, stepUpPassword, stepUpMFA,
+	}, ","))
+	for _, op := range strings.Split(ops, ",") {
+		if op = strings.TrimSpace(op); op != "" && op != "none" {
+			cfg.Operations[op] = true
+		}
+	}
+
+	threshold, ok := new(big.Rat).SetString(getEnv("STEP_UP_AMOUNT_THRESHOLD", "1000"))
+	if !ok {
+		log.Fatalf("Invalid STEP_UP_AMOUNT_THRESHOLD: %q", getEnv("STEP_UP_AMOUNT_THRESHOLD", "1000"))
+	}
+	cfg.AmountThreshold = threshold
+
+	return cfg
+}
+
+// stepUpFields are the request body fields the rules look at. Decoding into a struct matches
+// keys the same (case-insensitive) way the backends bind them, so a request can't dodge a rule
+// by spelling a field differently.
+type stepUpFields struct {
+	Amount      json.RawMessage `json:"amount"`
+	PaymentType string          `json:"payment_type"`
+	TemplateID  json.RawMessage `json:"template_id"`
+	Password    *string         `json:"password"`
+	Payload     string          `json:"payload"`
+	Items       []struct {
+		Amount json.RawMessage `json:"amount"`
+	} `json:"items"`
+}
+
+// What decides whether a matched request is challenged
+const (
+	ruleAlways   = iota // always challenged
+	rulePassword        // challenged when it sets a password
+	ruleAmount          // challenged above the threshold: the body's amount
+	ruleItems           // above the threshold: the sum of the body's items, as in a transfer batch
+	ruleQR              // above the threshold: the body's amount, or the one in the QR code payload
+)
+
+// stepUpRoute is a request the gateway may challenge and the rule that decides it
+type stepUpRoute struct {
+	method  string
+	pattern []string
+	op      string
+	rule    int
+}
+
+// stepUpRoutes lists every request that may be challenged. Every route of the transfer and
+// payment services that moves a customer's money must be here. Paying a money request or a bill
+// split share moves an amount stored with it that the gateway can't see, so those are always
+// challenged.
+var stepUpRoutes = []stepUpRoute{
+	{http.MethodPost, []string{"transfers"}, stepUpTransfer, ruleAmount},
+	{http.MethodPost, []string{"transfers", "batches"}, stepUpTransfer, ruleItems},
+	{http.MethodPost, []string{"transfers", "requests", "*", "accept"}, stepUpTransfer, ruleAlways},
+	{http.MethodPost, []string{"transfers", "splits", "*", "pay"}, stepUpTransfer, ruleAlways},
+	{http.MethodPost, []string{"payments"}, stepUpPayment, ruleAmount},
+	{http.MethodPost, []string{"payments", "qr"}, stepUpPayment, ruleQR},
+	{http.MethodPost, []string{"payments", "schedules"}, stepUpPayment, ruleAmount},
+	{http.MethodPost, []string{"transfers", "beneficiaries"}, stepUpBeneficiary, ruleAlways},
+	{http.MethodPost, []string{"cards", "*", "pin"}, stepUpCardPIN, ruleAlways},
+	{http.MethodPut, []string{"users", "*"}, stepUpPassword, rulePassword},
+	{http.MethodPost, []string{"users", "*", "totp", "confirm"}, stepUpMFA, ruleAlways},
+	{http.MethodDelete, []string{"users", "*", "totp"}, stepUpMFA, ruleAlways},
+}
+
+// operationFor returns the step-up operation a request performs, or "" if it needs no code.
+// segments is the path below /api/v1; body is only read for routes whose rule depends on it.
+func (cfg StepUpConfig) operationFor(method string, segments []string, body func() (*stepUpFields, error)) string {
+	var route *stepUpRoute
+	for i := range stepUpRoutes {
+		if stepUpRoutes[i].method == method && pathMatches(segments, stepUpRoutes[i].pattern...) {
+			route = &stepUpRoutes[i]
+			break
+		}
+	}
+	if route == nil {
+		return ""
+	}
+
+	op := route.op
+	if route.rule == ruleAlways {
+		return cfg.enabled(op)
+	}
+
+	fields, err := body()
+	if err != nil {
+		// Fail closed: a body we can't read is challenged rather than waved through
+		return cfg.enabled(op)
+	}
+
+	if route.rule == rulePassword {
+		if fields.Password == nil || *fields.Password == "" {
+			return ""
+		}
+		return cfg.enabled(op)
+	}
+
+	// External payments are configured apart from other payments. A payment from a template takes
+	// the template's type unless it names one, and its amount unless it gives one, so it is taken
+	// for an external payment and, without an amount of its own, challenged whatever it pays.
+	amount := route.amountOf(fields)
+	if op == stepUpPayment {
+		templated := len(fields.TemplateID) > 0 && string(fields.TemplateID) != "null"
+		if fields.PaymentType == "external" || templated && fields.PaymentType == "" {
+			op = stepUpExternalPayment
+		}
+		if value, ok := parseAmount(amount); templated && ok && value.Sign() == 0 {
+			return cfg.enabled(op)
+		}
+	}
+	if !cfg.exceedsThreshold(amount) {
+		return ""
+	}
+	return cfg.enabled(op)
+}
+
+// enabled returns op if it is challenged at all, "" otherwise
+func (cfg StepUpConfig) enabled(op string) string {
+	if !cfg.Operations[op] {
+		return ""
+	}
+	return op
+}
+
+// amountOf finds the amount a request moves, as JSON, or nil if it names none
+func (route *stepUpRoute) amountOf(fields *stepUpFields) json.RawMessage {
+	switch route.rule {
+	case ruleItems:
+		total := new(big.Rat)
+		for _, item := range fields.Items {
+			amount, ok := parseAmount(item.Amount)
+			if !ok {
+				return json.RawMessage(`"unparseable"`)
+			}
+			total.Add(total, amount)
+		}
+		return json.RawMessage(strconv.Quote(total.FloatString(2)))
+	case ruleQR:
+		if len(fields.Amount) > 0 && string(fields.Amount) != "null" {
+			return fields.Amount
+		}
+		if amount, ok := emvAmount(fields.Payload); ok {
+			return json.RawMessage(strconv.Quote(amount))
+		}
+		return nil
+	default:
+		return fields.Amount
+	}
+}
+
+// exceedsThreshold reports whether a JSON amount (number or string) is above the threshold.
+// Amounts that can't be parsed count as exceeding it.
+func (cfg StepUpConfig) exceedsThreshold(raw json.RawMessage) bool {
+	if len(raw) == 0 || string(raw) == "null" {
+		return false
+	}
+
+	amount, ok := parseAmount(raw)
+	if !ok {
+		return true
+	}
+	return amount.Cmp(cfg.AmountThreshold) > 0
+}
+
+// parseAmount reads a JSON amount given as a number or a string
+func parseAmount(raw json.RawMessage) (*big.Rat, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return new(big.Rat), true
+	}
+
+	text := string(raw)
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		text = s
+	}
+
+	return new(big.Rat).SetString(strings.TrimSpace(text))
+}
+
+// emvAmount reads the transaction amount (tag 54) from the top level of an EMVCo QR payload,
+// whose data objects are a two-digit tag, a two-digit length and the value
+func emvAmount(payload string) (string, bool) {
+	for i := 0; i+4 <= len(payload); {
+		length, err := strconv.Atoi(payload[i+2 : i+4])
+		if err != nil || i+4+length > len(payload) {
+			return "", false
+		}
+		if payload[i:i+2] == "54" {
+			return payload[i+4 : i+4+length], true
+		}
+		i += 4 + length
+	}
+	return "", false
+}
+
+// pathMatches compares path segments to a pattern where "*" matches any single segment
+func pathMatches(segments []string, pattern ...string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFingerprint identifies the exact request a challenge was issued for
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s?%s\n", r.Method, r.URL.Path, r.URL.RawQuery)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// stepUpMiddleware challenges sensitive requests. The first attempt is answered with 401 and a
+// challenge_id while the user service sends the customer a code; the client then repeats the
+// identical request with the X-Challenge-ID and X-OTP-Code headers, and it is forwarded once
+// the code checks out.
+func stepUpMiddleware(cfg StepUpConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Never forward step-up headers to the backends
+		challengeID := c.GetHeader(headerChallengeID)
+		code := c.GetHeader(headerOTPCode)
+		c.Request.Header.Del(headerChallengeID)
+		c.Request.Header.Del(headerOTPCode)
+
+		segments := strings.Split(strings.Trim(strings.TrimPrefix(c.Request.URL.Path, "/api/v1"), "/"), "/")
+
+		var body []byte
+		bodyRead := false
+		readBody := func() ([]byte, error) {
+			if bodyRead {
+				return body, nil
+			}
+			bodyRead = true
+			if c.Request.Body == nil {
+				return nil, nil
+			}
+			data, err := io.ReadAll(c.Request.Body)
+			c.Request.Body.Close()
+			body = data
+			// Put the body back for the proxy
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			return body, err
+		}
+
+		op := cfg.operationFor(c.Request.Method, segments, func() (*stepUpFields, error) {
+			data, err := readBody()
+			if err != nil {
+				return nil, err
+			}
+			var fields stepUpFields
+			if err := json.Unmarshal(data, &fields); err != nil {
+				return nil, err
+			}
+			return &fields, nil
+		})
+		if op == "" {
+			c.Next()
+			return
+		}
+
+		data, err := readBody()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		fingerprint := requestFingerprint(c.Request, data)
+
+		if challengeID == "" || code == "" {
+			issueChallenge(c, formatInt64(userID), op, fingerprint)
+			return
+		}
+
+		if !verifyChallenge(c, formatInt64(userID), challengeID, code, fingerprint) {
+			return
+		}
+
+		log.Printf("Step-up %s verified for user %v (challenge %s)", op, userID, challengeID)
+		c.Next()
+	}
+}
+
+// issueChallenge asks the user service to start a challenge and answers the request with it
+func issueChallenge(c *gin.Context, userID, operation, fingerprint string) {
+	var challenge struct {
+		ChallengeID string    `json:"challenge_id"`
+		Operation   string    `json:"operation"`
+		Channel     string    `json:"channel"`
+		Methods     []string  `json:"methods"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}
+
+	status, err := callUserService(fmt.Sprintf("/api/users/%s/challenges", userID), gin.H{
+		"operation":   operation,
+		"fingerprint": fingerprint,
+	}, &challenge)
+	if err != nil || status != http.StatusCreated {
+		log.Printf("Failed to create step-up challenge for user %s: status %d, %v", userID, status, err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Step-up authentication unavailable",
+		})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":        "Step-up authentication required",
+		"challenge_id": challenge.ChallengeID,
+		"operation":    challenge.Operation,
+		"channel":      challenge.Channel,
+		"methods":      challenge.Methods,
+		"expires_at":   challenge.ExpiresAt,
+	})
+}
+
+// verifyChallenge checks the code with the user service, aborting the request if it fails
+func verifyChallenge(c *gin.Context, userID, challengeID, code, fingerprint string) bool {
+	var result map[string]interface{}
+	status, err := callUserService(fmt.Sprintf("/api/users/%s/challenges/%s/verify", userID, challengeID), gin.H{
+		"code":        code,
+		"fingerprint": fingerprint,
+	}, &result)
+	if err != nil {
+		log.Printf("Failed to verify step-up challenge %s: %v", challengeID, err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Step-up authentication unavailable",
+		})
+		return false
+	}
+
+	switch status {
+	case http.StatusOK:
+		return true
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp := gin.H{"error": result["error"], "challenge_id": challengeID}
+		if remaining, ok := result["attempts_remaining"]; ok {
+			resp["attempts_remaining"] = remaining
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, resp)
+	default:
+		log.Printf("User service returned %d verifying challenge %s", status, challengeID)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Step-up authentication unavailable",
+		})
+	}
+	return false
+}
+
+// callUserService POSTs to the user service as the admin service user and decodes the reply
+func callUserService(path string, body interface{}, result interface{}) (int, error) {
+	userServiceURL := getEnv("USER_SERVICE_URL", "http://user.user.svc.cluster.local:8080")
+
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, userServiceURL+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", "0")
+	req.Header.Set("X-User-Role", "admin")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call user service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
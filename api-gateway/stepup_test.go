@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testStepUpConfig() StepUpConfig {
+	return StepUpConfig{
+		Operations: map[string]bool{
+			stepUpTransfer: true, stepUpPayment: true, stepUpExternalPayment: true, stepUpBeneficiary: true,
+			stepUpCardPIN: true, stepUpPassword: true, stepUpMFA: true,
+		},
+		AmountThreshold: big.NewRat(1000, 1),
+	}
+}
+
+func operationForBody(cfg StepUpConfig, method, path, body string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	return cfg.operationFor(method, segments, func() (*stepUpFields, error) {
+		var fields stepUpFields
+		if err := json.Unmarshal([]byte(body), &fields); err != nil {
+			return nil, err
+		}
+		return &fields, nil
+	})
+}
+
+// TestStepUpTransferAndPaymentRoutes enumerates every state-changing route of the transfer and
+// payment services with a body worth well over the threshold: each one that moves a customer's
+// money must be challenged.
+func TestStepUpTransferAndPaymentRoutes(t *testing.T) {
+	const large = `{"amount": 5000, "items": [{"amount": 5000}], "payment_type": "bill"}`
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		// Transfer service
+		{http.MethodPost, "/transfers", stepUpTransfer},
+		{http.MethodPost, "/transfers/batches", stepUpTransfer},
+		{http.MethodPost, "/transfers/quote", ""},
+		{http.MethodPost, "/transfers/requests", ""},
+		{http.MethodPost, "/transfers/requests/7/accept", stepUpTransfer},
+		{http.MethodPost, "/transfers/requests/7/decline", ""},
+		{http.MethodPost, "/transfers/requests/7/cancel", ""},
+		{http.MethodPost, "/transfers/splits", ""},
+		{http.MethodPost, "/transfers/splits/7/pay", stepUpTransfer},
+		{http.MethodPost, "/transfers/splits/7/remind", ""},
+		{http.MethodPost, "/transfers/splits/7/cancel", ""},
+		{http.MethodPost, "/transfers/beneficiaries", stepUpBeneficiary},
+		{http.MethodPut, "/transfers/beneficiaries/7", ""},
+		{http.MethodDelete, "/transfers/beneficiaries/7", ""},
+		{http.MethodPut, "/transfers/7/category", ""},
+		{http.MethodPost, "/transfers/7/approve", ""},
+		{http.MethodPost, "/transfers/7/reject", ""},
+		{http.MethodPost, "/transfers/7/reverse", ""},
+		{http.MethodPut, "/transfers/limits/segments/standard", ""},
+		{http.MethodPut, "/transfers/limits/users/7", ""},
+		{http.MethodDelete, "/transfers/limits/users/7", ""},
+		{http.MethodPost, "/transfers/fees", ""},
+		{http.MethodPut, "/transfers/fees/7", ""},
+		{http.MethodDelete, "/transfers/fees/7", ""},
+
+		// Payment service
+		{http.MethodPost, "/payments", stepUpPayment},
+		{http.MethodPost, "/payments/qr", stepUpPayment},
+		{http.MethodPost, "/payments/schedules", stepUpPayment},
+		{http.MethodPost, "/payments/schedules/7/pause", ""},
+		{http.MethodPost, "/payments/schedules/7/resume", ""},
+		{http.MethodDelete, "/payments/schedules/7", ""},
+		{http.MethodPut, "/payments/7/category", ""},
+		{http.MethodPost, "/payments/merchants/7/qr", ""},
+		{http.MethodPost, "/payments/templates", ""},
+		{http.MethodPut, "/payments/templates/7", ""},
+		{http.MethodDelete, "/payments/templates/7", ""},
+		{http.MethodPost, "/payments/7/refund", ""},
+		{http.MethodPost, "/payments/mobile-operators", ""},
+		{http.MethodPost, "/payments/merchants", ""},
+		{http.MethodPost, "/payments/billers", ""},
+		{http.MethodPost, "/payments/clearing/cutoff", ""},
+		{http.MethodPost, "/payments/clearing/status-reports", ""},
+		{http.MethodPost, "/payments/inbound/files", ""},
+		{http.MethodPost, "/payments/inbound/credits/7/resolve", ""},
+		{http.MethodPost, "/payments/inbound/credits/7/reject", ""},
+	}
+
+	cfg := testStepUpConfig()
+	for _, tt := range tests {
+		if got := operationForBody(cfg, tt.method, tt.path, large); got != tt.want {
+			t.Errorf("%s %s: operation = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestStepUpAmounts(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		want string
+	}{
+		{name: "small transfer", path: "/transfers", body: `{"amount": 999.99}`, want: ""},
+		{name: "transfer at threshold", path: "/transfers", body: `{"amount": "1000.00"}`, want: ""},
+		{name: "large transfer", path: "/transfers", body: `{"amount": "1000.01"}`, want: stepUpTransfer},
+		{name: "unparseable amount", path: "/transfers", body: `{"amount": "lots"}`, want: stepUpTransfer},
+		{name: "unreadable body", path: "/transfers", body: `not json`, want: stepUpTransfer},
+		{name: "batch of small items over threshold in total", path: "/transfers/batches",
+			body: `{"items": [{"amount": 600}, {"amount": "600"}]}`, want: stepUpTransfer},
+		{name: "batch under threshold", path: "/transfers/batches",
+			body: `{"items": [{"amount": 400}, {"amount": 500}]}`, want: ""},
+		{name: "small external payment", path: "/payments", body: `{"payment_type": "external", "amount": 10}`, want: ""},
+		{name: "large external payment", path: "/payments", body: `{"payment_type": "external", "amount": 5000}`, want: stepUpExternalPayment},
+		{name: "large bill payment", path: "/payments", body: `{"payment_type": "bill", "amount": 5000}`, want: stepUpPayment},
+		{name: "templated payment with large amount", path: "/payments", body: `{"template_id": 3, "amount": 5000}`, want: stepUpExternalPayment},
+		{name: "templated payment with small amount", path: "/payments", body: `{"template_id": 3, "amount": 5}`, want: ""},
+		{name: "templated payment with the template's amount", path: "/payments", body: `{"template_id": 3}`, want: stepUpExternalPayment},
+		{name: "templated bill payment with the template's amount", path: "/payments",
+			body: `{"template_id": 3, "payment_type": "bill", "amount": 0}`, want: stepUpPayment},
+		{name: "templated bill payment with large amount", path: "/payments",
+			body: `{"template_id": 3, "payment_type": "bill", "amount": "5000"}`, want: stepUpPayment},
+		{name: "static QR code with amount", path: "/payments/qr", body: `{"payload": "000201010211", "amount": 5000}`, want: stepUpPayment},
+		{name: "dynamic QR code with large amount", path: "/payments/qr",
+			body: `{"payload": "00020101021253038405407` + `5000.006304ABCD"}`, want: stepUpPayment},
+		{name: "dynamic QR code with small amount", path: "/payments/qr",
+			body: `{"payload": "000201010212530384054045.006304ABCD"}`, want: ""},
+	}
+
+	cfg := testStepUpConfig()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := operationForBody(cfg, http.MethodPost, tt.path, tt.body); got != tt.want {
+				t.Errorf("operation = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepUpDisabledOperations(t *testing.T) {
+	cfg := testStepUpConfig()
+	delete(cfg.Operations, stepUpTransfer)
+
+	if got := operationForBody(cfg, http.MethodPost, "/transfers", `{"amount": 5000}`); got != "" {
+		t.Errorf("disabled transfer operation = %q, want none", got)
+	}
+	if got := operationForBody(cfg, http.MethodPost, "/transfers/splits/7/pay", `{}`); got != "" {
+		t.Errorf("disabled split payment operation = %q, want none", got)
+	}
+	if got := operationForBody(cfg, http.MethodPost, "/payments", `{"amount": 5000}`); got != stepUpPayment {
+		t.Errorf("payment operation = %q, want %q", got, stepUpPayment)
+	}
+}
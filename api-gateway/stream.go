@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/segmentio/kafka-go"
+)
+
+// Topics relayed to connected clients over the status stream
+var streamTopics = []string{
+	"transfer.completed",
+	"transfer.failed",
+	"payment.completed",
+	"payment.failed",
+	"notification.created",
+}
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	streamSubscriberBuffer  = 32
+	streamRetryInterval     = 5 * time.Second
+)
+
+// StreamEvent is a single event pushed to a user's stream
+type StreamEvent struct {
+	Topic string
+	Data  json.RawMessage
+}
+
+// streamRecipients carries the user fields of the relayed events; transfer results name both
+// sides of the transfer, payment results and notifications name a single user
+type streamRecipients struct {
+	UserID     int64 `json:"user_id"`
+	FromUserID int64 `json:"from_user_id"`
+	ToUserID   int64 `json:"to_user_id"`
+}
+
+// StreamHub fans events out to the open streams of each user
+type StreamHub struct {
+	mu          sync.RWMutex
+	subscribers map[int64]map[chan StreamEvent]struct{}
+}
+
+func NewStreamHub() *StreamHub {
+	return &StreamHub{subscribers: make(map[int64]map[chan StreamEvent]struct{})}
+}
+
+// Subscribe registers a new stream for the user; the returned function unregisters it
+func (h *StreamHub) Subscribe(userID int64) (<-chan StreamEvent, func()) {
+	ch := make(chan StreamEvent, streamSubscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan StreamEvent]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Publish delivers an event to every stream the user has open. A client that is not keeping up
+// misses the event rather than stalling delivery to everyone else.
+func (h *StreamHub) Publish(userID int64, event StreamEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[userID] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Stream buffer full for user %d, dropping %s event", userID, event.Topic)
+		}
+	}
+}
+
+// Dispatch routes a raw Kafka event to the users it concerns
+func (h *StreamHub) Dispatch(topic string, value []byte) {
+	var recipients streamRecipients
+	if err := json.Unmarshal(value, &recipients); err != nil {
+		log.Printf("Error unmarshaling %s event for stream: %v", topic, err)
+		return
+	}
+
+	event := StreamEvent{Topic: topic, Data: json.RawMessage(value)}
+	seen := make(map[int64]bool, 3)
+	for _, userID := range []int64{recipients.UserID, recipients.FromUserID, recipients.ToUserID} {
+		if userID <= 0 || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		h.Publish(userID, event)
+	}
+}
+
+// StartStreamConsumers reads the stream topics into the hub until the context is cancelled.
+// Every gateway instance must see every event, so rather than sharing a consumer group each one
+// reads every partition of the topics itself, from the latest offset: the stream is live-only,
+// history stays with the services, and a replaced pod leaves no consumer group behind.
+func StartStreamConsumers(ctx context.Context, brokers []string, hub *StreamHub) {
+	for _, topic := range streamTopics {
+		go streamTopic(ctx, brokers, topic, hub)
+	}
+}
+
+// streamTopic relays every partition of a topic to the hub, waiting until its partitions can be
+// looked up, e.g. while the brokers start or before the topic is first written to
+func streamTopic(ctx context.Context, brokers []string, topic string, hub *StreamHub) {
+	var partitions []kafka.Partition
+	for {
+		var err error
+		if partitions, err = lookupPartitions(ctx, brokers, topic); err == nil {
+			break
+		}
+		log.Printf("Error looking up %s partitions for stream: %v", topic, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamRetryInterval):
+		}
+	}
+
+	for _, partition := range partitions {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   brokers,
+			Topic:     topic,
+			Partition: partition.ID,
+			MinBytes:  1,
+			MaxBytes:  10e6,
+			MaxWait:   500 * time.Millisecond,
+		})
+		if err := reader.SetOffset(kafka.LastOffset); err != nil {
+			log.Printf("Error seeking %s partition %d for stream: %v", topic, partition.ID, err)
+			reader.Close()
+			continue
+		}
+		go consumeStreamPartition(ctx, reader, hub)
+	}
+}
+
+// lookupPartitions asks the first broker that answers for the partitions of a topic
+func lookupPartitions(ctx context.Context, brokers []string, topic string) ([]kafka.Partition, error) {
+	var lastErr error
+	for _, broker := range brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		partitions, err := conn.ReadPartitions(topic)
+		conn.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(partitions) == 0 {
+			lastErr = fmt.Errorf("topic %s has no partitions", topic)
+			continue
+		}
+		return partitions, nil
+	}
+	return nil, lastErr
+}
+
+func consumeStreamPartition(ctx context.Context, reader *kafka.Reader, hub *StreamHub) {
+	defer reader.Close()
+
+	topic, partition := reader.Config().Topic, reader.Config().Partition
+	log.Printf("Starting %s partition %d consumer for status stream", topic, partition)
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error reading %s message for stream: %v", topic, err)
+			time.Sleep(time.Second)
+			continue
+		}
+		hub.Dispatch(topic, msg.Value)
+	}
+}
+
+// tokenFromQuery lets browser EventSource clients, which cannot set headers, authenticate with
+// an access_token query parameter. The token is still validated by authMiddleware.
+func tokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
+
+// handleStream serves the caller's transfer, payment and notification events as Server-Sent Events
+func handleStream(hub *StreamHub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		uid := userID.(int64)
+
+		events, unsubscribe := hub.Subscribe(uid)
+		defer unsubscribe()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+
+		log.Printf("Stream opened for user %d", uid)
+		defer log.Printf("Stream closed for user %d", uid)
+
+		c.SSEvent("ready", gin.H{"user_id": uid, "topics": streamTopics})
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case event := <-events:
+				c.SSEvent(event.Topic, string(event.Data))
+			case <-heartbeat.C:
+				if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+					return false
+				}
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import "testing"
+
+// received drains whatever is waiting on a stream without blocking
+func received(events <-chan StreamEvent) []StreamEvent {
+	var got []StreamEvent
+	for {
+		select {
+		case event := <-events:
+			got = append(got, event)
+		default:
+			return got
+		}
+	}
+}
+
+func TestStreamHubDispatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		topic string
+		value string
+		want  map[int64]int // events each subscribed user receives
+	}{
+		{
+			name:  "payment result reaches its user",
+			topic: "payment.completed",
+			value: `{"payment_id": 9, "user_id": 1}`,
+			want:  map[int64]int{1: 1},
+		},
+		{
+			name:  "transfer result reaches both sides",
+			topic: "transfer.completed",
+			value: `{"transfer_id": 4, "from_user_id": 1, "to_user_id": 2}`,
+			want:  map[int64]int{1: 1, 2: 1},
+		},
+		{
+			name:  "transfer between a user's own accounts is delivered once",
+			topic: "transfer.completed",
+			value: `{"transfer_id": 5, "from_user_id": 2, "to_user_id": 2}`,
+			want:  map[int64]int{2: 1},
+		},
+		{
+			name:  "user named in several fields is delivered once",
+			topic: "notification.created",
+			value: `{"user_id": 3, "from_user_id": 3}`,
+			want:  map[int64]int{3: 1},
+		},
+		{
+			name:  "event naming nobody reaches nobody",
+			topic: "transfer.failed",
+			value: `{"transfer_id": 6, "from_user_id": 0}`,
+			want:  map[int64]int{},
+		},
+		{
+			name:  "malformed event is dropped",
+			topic: "payment.failed",
+			value: `{"user_id": "1"`,
+			want:  map[int64]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewStreamHub()
+			streams := make(map[int64]<-chan StreamEvent)
+			for _, userID := range []int64{1, 2, 3} {
+				events, unsubscribe := hub.Subscribe(userID)
+				defer unsubscribe()
+				streams[userID] = events
+			}
+
+			hub.Dispatch(tt.topic, []byte(tt.value))
+
+			for userID, events := range streams {
+				got := received(events)
+				if len(got) != tt.want[userID] {
+					t.Errorf("user %d received %d events, want %d", userID, len(got), tt.want[userID])
+					continue
+				}
+				for _, event := range got {
+					if event.Topic != tt.topic || string(event.Data) != tt.value {
+						t.Errorf("user %d received %s %s, want %s %s", userID, event.Topic, event.Data, tt.topic, tt.value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStreamHubUnsubscribe(t *testing.T) {
+	hub := NewStreamHub()
+	kept, unsubscribeKept := hub.Subscribe(1)
+	defer unsubscribeKept()
+	closed, unsubscribe := hub.Subscribe(1)
+	unsubscribe()
+
+	hub.Dispatch("payment.completed", []byte(`{"user_id": 1}`))
+
+	if got := received(kept); len(got) != 1 {
+		t.Errorf("open stream received %d events, want 1", len(got))
+	}
+	if got := received(closed); len(got) != 0 {
+		t.Errorf("closed stream received %d events, want 0", len(got))
+	}
+}
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"dbank/config"
@@ -15,8 +19,26 @@ type Client struct {
 	httpClient *http.Client
 	baseURL    string
 	token      string
+
+	// OnChallenge is asked for a one-time code when the gateway challenges a sensitive request.
+	// Without it, challenged requests fail with the gateway's error.
+	OnChallenge func(ch *Challenge) (string, error)
+}
+
+// Challenge is the gateway's step-up challenge for a sensitive request
+type Challenge struct {
+	Error             string   `json:"error"`
+	ChallengeID       string   `json:"challenge_id"`
+	Operation         string   `json:"operation"`
+	Channel           string   `json:"channel"`
+	Methods           []string `json:"methods"`
+	ExpiresAt         string   `json:"expires_at"`
+	AttemptsRemaining *int     `json:"attempts_remaining,omitempty"`
 }
 
+// maxChallengeAttempts bounds how many codes are prompted for before giving up on a request
+const maxChallengeAttempts = 3
+
 func NewClient(cfg *config.Config) *Client {
 	return &Client{
 		httpClient: &http.Client{
@@ -28,53 +50,99 @@ func NewClient(cfg *config.Config) *Client {
 }
 
 func (c *Client) doRequest(method, path string, body interface{}, result interface{}) error {
-	var bodyReader io.Reader
+	var data []byte
 	if body != nil {
-		data, err := json.Marshal(body)
+		var err error
+		data, err = json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request: %w", err)
 		}
-		bodyReader = bytes.NewReader(data)
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+	var challenge *Challenge
+	var code string
+	for attempt := 0; ; attempt++ {
+		var bodyReader io.Reader
+		if data != nil {
+			bodyReader = bytes.NewReader(data)
+		}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
+		req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+		req.Header.Set("Content-Type", "application/json")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		if challenge != nil {
+			req.Header.Set("X-Challenge-ID", challenge.ChallengeID)
+			req.Header.Set("X-OTP-Code", code)
+		}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
 
-	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Error string `json:"error"`
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
 		}
-		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
-			return fmt.Errorf("API error: %s", errResp.Error)
+
+		// A challenged request is repeated unchanged with the code the user enters
+		if resp.StatusCode == http.StatusUnauthorized && c.OnChallenge != nil && attempt < maxChallengeAttempts {
+			var ch Challenge
+			if json.Unmarshal(respBody, &ch) == nil && ch.ChallengeID != "" {
+				retry := true
+				switch {
+				case ch.Methods != nil:
+					// A new challenge
+					challenge = &ch
+				case challenge != nil && ch.AttemptsRemaining != nil && *ch.AttemptsRemaining > 0:
+					// A wrong code; keep the original challenge's details for the prompt
+					challenge.Error = ch.Error
+					challenge.AttemptsRemaining = ch.AttemptsRemaining
+				default:
+					// The challenge expired or ran out of attempts
+					retry = false
+				}
+				if retry {
+					code, err = c.OnChallenge(challenge)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			}
 		}
-		return fmt.Errorf("API error: %s (status %d)", string(respBody), resp.StatusCode)
-	}
 
-	if result != nil {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
+		if resp.StatusCode >= 400 {
+			var errResp struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+				return fmt.Errorf("API error: %s", errResp.Error)
+			}
+			return fmt.Errorf("API error: %s (status %d)", string(respBody), resp.StatusCode)
 		}
-	}
 
-	return nil
+		// Documents such as PDF receipts are returned as they are
+		if raw, ok := result.(*[]byte); ok {
+			*raw = respBody
+			return nil
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(respBody, result); err != nil {
+				return fmt.Errorf("failed to parse response: %w", err)
+			}
+		}
+
+		return nil
+	}
 }
 
 // Auth endpoints
@@ -166,6 +234,9 @@ type Transfer struct {
 	ToAccountID   int64  `json:"to_account_id"`
 	Amount        string `json:"amount"`
 	Currency      string `json:"currency"`
+	Fee           string `json:"fee"`
+	Memo          string `json:"memo,omitempty"`
+	Category      string `json:"category"`
 	Status        string `json:"status"`
 	FailureReason string `json:"failure_reason,omitempty"`
 	CreatedAt     string `json:"created_at"`
@@ -177,17 +248,51 @@ type TransferListResponse struct {
 	Total     int64      `json:"total"`
 }
 
-func (c *Client) ListTransfers() (*TransferListResponse, error) {
+// TransferFilter narrows a transfer listing; empty fields don't filter
+type TransferFilter struct {
+	Query        string
+	Category     string
+	Counterparty string
+	MinAmount    string
+	MaxAmount    string
+	From         string
+	To           string
+}
+
+func (c *Client) ListTransfers(filter TransferFilter) (*TransferListResponse, error) {
+	params := url.Values{}
+	for key, value := range map[string]string{
+		"q":            filter.Query,
+		"category":     filter.Category,
+		"counterparty": filter.Counterparty,
+		"min_amount":   filter.MinAmount,
+		"max_amount":   filter.MaxAmount,
+		"from":         filter.From,
+		"to":           filter.To,
+	} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+
+	path := "/transfers"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
 	var resp TransferListResponse
-	err := c.doRequest("GET", "/transfers", nil, &resp)
+	err := c.doRequest("GET", path, nil, &resp)
 	return &resp, err
 }
 
 type CreateTransferRequest struct {
 	FromAccountID int64  `json:"from_account_id"`
-	ToAccountID   int64  `json:"to_account_id"`
+	ToAccountID   int64  `json:"to_account_id,omitempty"`
+	BeneficiaryID int64  `json:"beneficiary_id,omitempty"`
 	Amount        string `json:"amount"`
 	Currency      string `json:"currency,omitempty"`
+	Memo          string `json:"memo,omitempty"`
+	Category      string `json:"category,omitempty"`
 }
 
 type CreateTransferResponse struct {
@@ -195,16 +300,28 @@ type CreateTransferResponse struct {
 	TransferID  int64  `json:"transfer_id"`
 	ReferenceID string `json:"reference_id"`
 	Status      string `json:"status"`
+	Fee         string `json:"fee"`
+	TotalDebit  string `json:"total_debit"`
+}
+
+type TransferQuote struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        string `json:"amount"`
+	Fee           string `json:"fee"`
+	TotalDebit    string `json:"total_debit"`
+	Currency      string `json:"currency"`
+	Channel       string `json:"channel"`
 }
 
-func (c *Client) CreateTransfer(fromID, toID int64, amount, currency string) (*CreateTransferResponse, error) {
+func (c *Client) QuoteTransfer(req *CreateTransferRequest) (*TransferQuote, error) {
+	var resp TransferQuote
+	err := c.doRequest("POST", "/transfers/quote", req, &resp)
+	return &resp, err
+}
+
+func (c *Client) CreateTransfer(req *CreateTransferRequest) (*CreateTransferResponse, error) {
 	var resp CreateTransferResponse
-	req := CreateTransferRequest{
-		FromAccountID: fromID,
-		ToAccountID:   toID,
-		Amount:        amount,
-		Currency:      currency,
-	}
 	err := c.doRequest("POST", "/transfers", req, &resp)
 	return &resp, err
 }
@@ -215,6 +332,360 @@ func (c *Client) GetTransfer(id int64) (*Transfer, error) {
 	return &resp, err
 }
 
+// Receipt endpoints
+
+// Receipt is a signed proof of a payment or transfer; its verification hash can be checked with the bank
+type Receipt struct {
+	Kind             string `json:"kind"`
+	ReferenceID      string `json:"reference_id"`
+	Type             string `json:"type"`
+	Status           string `json:"status"`
+	FromAccount      string `json:"from_account"`
+	ToAccount        string `json:"to_account"`
+	Recipient        string `json:"recipient"`
+	Amount           string `json:"amount"`
+	Fee              string `json:"fee"`
+	Total            string `json:"total"`
+	Currency         string `json:"currency"`
+	Description      string `json:"description"`
+	CreatedAt        string `json:"created_at"`
+	CompletedAt      string `json:"completed_at"`
+	VerificationHash string `json:"verification_hash"`
+}
+
+// GetReceipt fetches the receipt of a payment or transfer; kind is "payments" or "transfers"
+func (c *Client) GetReceipt(kind string, id int64) (*Receipt, error) {
+	var resp Receipt
+	err := c.doRequest("GET", fmt.Sprintf("/%s/%d/receipt", kind, id), nil, &resp)
+	return &resp, err
+}
+
+// GetReceiptPDF downloads the receipt of a payment or transfer as a PDF document
+func (c *Client) GetReceiptPDF(kind string, id int64) ([]byte, error) {
+	var doc []byte
+	err := c.doRequest("GET", fmt.Sprintf("/%s/%d/receipt?format=pdf", kind, id), nil, &doc)
+	return doc, err
+}
+
+// Spending insight endpoints
+
+type CategorySpending struct {
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+	Total    string `json:"total"`
+	Share    string `json:"share"`
+}
+
+type MonthSpending struct {
+	Month         string             `json:"month"`
+	Currency      string             `json:"currency"`
+	Count         int64              `json:"count"`
+	Total         string             `json:"total"`
+	Change        string             `json:"change"`
+	ChangePercent *string            `json:"change_percent"`
+	Categories    []CategorySpending `json:"categories"`
+}
+
+type PeriodSpending struct {
+	Currency   string             `json:"currency"`
+	Count      int64              `json:"count"`
+	Total      string             `json:"total"`
+	Categories []CategorySpending `json:"categories"`
+}
+
+type MerchantSpending struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Currency string `json:"currency"`
+	Count    int64  `json:"count"`
+	Total    string `json:"total"`
+}
+
+type RecipientSpending struct {
+	Source      string `json:"source"`
+	PaymentType string `json:"payment_type"`
+	Name        string `json:"name"`
+	Account     string `json:"account"`
+	Currency    string `json:"currency"`
+	Count       int64  `json:"count"`
+	Total       string `json:"total"`
+}
+
+// SpendingInsights summarizes completed payments and outgoing transfers over a range of months
+type SpendingInsights struct {
+	From          string              `json:"from"`
+	To            string              `json:"to"`
+	Totals        []PeriodSpending    `json:"totals"`
+	Months        []MonthSpending     `json:"months"`
+	Merchants     []MerchantSpending  `json:"merchants"`
+	TopRecipients []RecipientSpending `json:"top_recipients"`
+	Incomplete    bool                `json:"incomplete"`
+}
+
+// GetSpendingInsights fetches spending insights for the months from to to (YYYY-MM); empty
+// values leave the range to the server
+func (c *Client) GetSpendingInsights(from, to string, limit int) (*SpendingInsights, error) {
+	params := url.Values{}
+	if from != "" {
+		params.Set("from", from)
+	}
+	if to != "" {
+		params.Set("to", to)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	path := "/payments/insights"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var resp SpendingInsights
+	err := c.doRequest("GET", path, nil, &resp)
+	return &resp, err
+}
+
+// Money request endpoints
+
+type MoneyRequest struct {
+	ID                 int64  `json:"id"`
+	ReferenceID        string `json:"reference_id"`
+	RequesterUserID    int64  `json:"requester_user_id"`
+	RequesterAccountID int64  `json:"requester_account_id"`
+	PayerUserID        int64  `json:"payer_user_id"`
+	PayerAccountID     int64  `json:"payer_account_id"`
+	Amount             string `json:"amount"`
+	Currency           string `json:"currency"`
+	Note               string `json:"note,omitempty"`
+	Status             string `json:"status"`
+	TransferID         int64  `json:"transfer_id,omitempty"`
+	DeclineReason      string `json:"decline_reason,omitempty"`
+	ExpiresAt          string `json:"expires_at"`
+	CreatedAt          string `json:"created_at"`
+}
+
+type MoneyRequestListResponse struct {
+	Requests []MoneyRequest `json:"requests"`
+	Total    int64          `json:"total"`
+}
+
+type CreateMoneyRequestRequest struct {
+	ToAccountID    int64  `json:"to_account_id"`
+	PayerAccountID int64  `json:"payer_account_id"`
+	Amount         string `json:"amount"`
+	Note           string `json:"note,omitempty"`
+}
+
+type AcceptMoneyRequestResponse struct {
+	Message        string       `json:"message"`
+	Request        MoneyRequest `json:"request"`
+	TransferID     int64        `json:"transfer_id"`
+	TransferStatus string       `json:"transfer_status"`
+	Fee            string       `json:"fee"`
+	TotalDebit     string       `json:"total_debit"`
+}
+
+func (c *Client) ListMoneyRequests(direction, status string) (*MoneyRequestListResponse, error) {
+	params := url.Values{}
+	params.Set("direction", direction)
+	if status != "" {
+		params.Set("status", status)
+	}
+
+	var resp MoneyRequestListResponse
+	err := c.doRequest("GET", "/transfers/requests?"+params.Encode(), nil, &resp)
+	return &resp, err
+}
+
+func (c *Client) CreateMoneyRequest(req *CreateMoneyRequestRequest) (*MoneyRequest, error) {
+	var resp MoneyRequest
+	err := c.doRequest("POST", "/transfers/requests", req, &resp)
+	return &resp, err
+}
+
+func (c *Client) AcceptMoneyRequest(id, fromAccountID int64) (*AcceptMoneyRequestResponse, error) {
+	var resp AcceptMoneyRequestResponse
+	body := map[string]int64{}
+	if fromAccountID != 0 {
+		body["from_account_id"] = fromAccountID
+	}
+	err := c.doRequest("POST", fmt.Sprintf("/transfers/requests/%d/accept", id), body, &resp)
+	return &resp, err
+}
+
+func (c *Client) DeclineMoneyRequest(id int64, reason string) (*MoneyRequest, error) {
+	var resp MoneyRequest
+	body := map[string]string{}
+	if reason != "" {
+		body["reason"] = reason
+	}
+	err := c.doRequest("POST", fmt.Sprintf("/transfers/requests/%d/decline", id), body, &resp)
+	return &resp, err
+}
+
+func (c *Client) CancelMoneyRequest(id int64) (*MoneyRequest, error) {
+	var resp MoneyRequest
+	err := c.doRequest("POST", fmt.Sprintf("/transfers/requests/%d/cancel", id), nil, &resp)
+	return &resp, err
+}
+
+// Bill split endpoints
+
+type SplitShare struct {
+	ID            int64  `json:"id"`
+	UserID        int64  `json:"user_id"`
+	AccountID     int64  `json:"account_id"`
+	Amount        string `json:"amount"`
+	Status        string `json:"status"`
+	TransferID    int64  `json:"transfer_id,omitempty"`
+	PaidAt        string `json:"paid_at,omitempty"`
+	RemindersSent int    `json:"reminders_sent"`
+}
+
+type BillSplit struct {
+	ID          int64        `json:"id"`
+	ReferenceID string       `json:"reference_id"`
+	OwnerUserID int64        `json:"owner_user_id"`
+	AccountID   int64        `json:"account_id"`
+	TotalAmount string       `json:"total_amount"`
+	Currency    string       `json:"currency"`
+	SplitMethod string       `json:"split_method"`
+	Description string       `json:"description,omitempty"`
+	Status      string       `json:"status"`
+	CreatedAt   string       `json:"created_at"`
+	SettledAt   string       `json:"settled_at,omitempty"`
+	Shares      []SplitShare `json:"shares,omitempty"`
+}
+
+type SplitListResponse struct {
+	Splits []BillSplit `json:"splits"`
+	Total  int64       `json:"total"`
+}
+
+type SplitParticipant struct {
+	AccountID int64  `json:"account_id"`
+	Amount    string `json:"amount,omitempty"`
+}
+
+type CreateSplitRequest struct {
+	AccountID    int64              `json:"account_id"`
+	TotalAmount  string             `json:"total_amount"`
+	Method       string             `json:"method,omitempty"`
+	Description  string             `json:"description,omitempty"`
+	Participants []SplitParticipant `json:"participants"`
+}
+
+type PaySplitShareResponse struct {
+	Message        string     `json:"message"`
+	SplitID        int64      `json:"split_id"`
+	Share          SplitShare `json:"share"`
+	TransferID     int64      `json:"transfer_id"`
+	TransferStatus string     `json:"transfer_status"`
+	Fee            string     `json:"fee"`
+	TotalDebit     string     `json:"total_debit"`
+}
+
+func (c *Client) ListSplits() (*SplitListResponse, error) {
+	var resp SplitListResponse
+	err := c.doRequest("GET", "/transfers/splits", nil, &resp)
+	return &resp, err
+}
+
+func (c *Client) GetSplit(id int64) (*BillSplit, error) {
+	var resp BillSplit
+	err := c.doRequest("GET", fmt.Sprintf("/transfers/splits/%d", id), nil, &resp)
+	return &resp, err
+}
+
+func (c *Client) CreateSplit(req *CreateSplitRequest) (*BillSplit, error) {
+	var resp BillSplit
+	err := c.doRequest("POST", "/transfers/splits", req, &resp)
+	return &resp, err
+}
+
+func (c *Client) PaySplitShare(id, fromAccountID int64) (*PaySplitShareResponse, error) {
+	var resp PaySplitShareResponse
+	body := map[string]int64{}
+	if fromAccountID != 0 {
+		body["from_account_id"] = fromAccountID
+	}
+	err := c.doRequest("POST", fmt.Sprintf("/transfers/splits/%d/pay", id), body, &resp)
+	return &resp, err
+}
+
+func (c *Client) RemindSplit(id int64) (int, error) {
+	var resp struct {
+		RemindersSent int `json:"reminders_sent"`
+	}
+	err := c.doRequest("POST", fmt.Sprintf("/transfers/splits/%d/remind", id), nil, &resp)
+	return resp.RemindersSent, err
+}
+
+func (c *Client) CancelSplit(id int64) (*BillSplit, error) {
+	var resp BillSplit
+	err := c.doRequest("POST", fmt.Sprintf("/transfers/splits/%d/cancel", id), nil, &resp)
+	return &resp, err
+}
+
+// Beneficiary endpoints
+
+type Beneficiary struct {
+	ID              int64  `json:"id"`
+	UserID          int64  `json:"user_id"`
+	Nickname        string `json:"nickname"`
+	Type            string `json:"beneficiary_type"`
+	AccountID       int64  `json:"account_id,omitempty"`
+	IBAN            string `json:"iban,omitempty"`
+	AccountNumber   string `json:"account_number,omitempty"`
+	BankName        string `json:"bank_name,omitempty"`
+	BankCode        string `json:"bank_code,omitempty"`
+	RecipientName   string `json:"recipient_name,omitempty"`
+	CoolingOffUntil string `json:"cooling_off_until"`
+	InCoolingOff    bool   `json:"in_cooling_off"`
+	MaxAmount       string `json:"max_amount,omitempty"`
+	CreatedAt       string `json:"created_at"`
+}
+
+type BeneficiaryListResponse struct {
+	Beneficiaries []Beneficiary `json:"beneficiaries"`
+	Total         int64         `json:"total"`
+}
+
+type CreateBeneficiaryRequest struct {
+	Nickname      string `json:"nickname"`
+	Type          string `json:"beneficiary_type"`
+	AccountID     int64  `json:"account_id,omitempty"`
+	IBAN          string `json:"iban,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	BankName      string `json:"bank_name,omitempty"`
+	BankCode      string `json:"bank_code,omitempty"`
+	RecipientName string `json:"recipient_name,omitempty"`
+}
+
+func (c *Client) ListBeneficiaries() (*BeneficiaryListResponse, error) {
+	var resp BeneficiaryListResponse
+	err := c.doRequest("GET", "/transfers/beneficiaries", nil, &resp)
+	return &resp, err
+}
+
+func (c *Client) CreateBeneficiary(req *CreateBeneficiaryRequest) (*Beneficiary, error) {
+	var resp Beneficiary
+	err := c.doRequest("POST", "/transfers/beneficiaries", req, &resp)
+	return &resp, err
+}
+
+func (c *Client) RenameBeneficiary(id int64, nickname string) (*Beneficiary, error) {
+	var resp Beneficiary
+	body := map[string]string{"nickname": nickname}
+	err := c.doRequest("PUT", fmt.Sprintf("/transfers/beneficiaries/%d", id), body, &resp)
+	return &resp, err
+}
+
+func (c *Client) DeleteBeneficiary(id int64) error {
+	return c.doRequest("DELETE", fmt.Sprintf("/transfers/beneficiaries/%d", id), nil, nil)
+}
+
 // Payment endpoints
 
 type Payment struct {
@@ -242,14 +713,20 @@ func (c *Client) ListPayments() (*PaymentListResponse, error) {
 	return &resp, err
 }
 
+// CreatePaymentRequest describes a payment. A payment made from a template only needs the
+// template ID; any other field set overrides the template's.
 type CreatePaymentRequest struct {
-	AccountID        int64  `json:"account_id"`
-	PaymentType      string `json:"payment_type"`
+	AccountID        int64  `json:"account_id,omitempty"`
+	PaymentType      string `json:"payment_type,omitempty"`
 	RecipientName    string `json:"recipient_name,omitempty"`
 	RecipientAccount string `json:"recipient_account,omitempty"`
-	Amount           string `json:"amount"`
+	Amount           string `json:"amount,omitempty"`
 	Currency         string `json:"currency,omitempty"`
 	Description      string `json:"description,omitempty"`
+	BeneficiaryID    int64  `json:"beneficiary_id,omitempty"`
+	BillerID         int64  `json:"biller_id,omitempty"`
+	MerchantID       int64  `json:"merchant_id,omitempty"`
+	TemplateID       int64  `json:"template_id,omitempty"`
 }
 
 func (c *Client) CreatePayment(req *CreatePaymentRequest) (*Payment, error) {
@@ -258,6 +735,101 @@ func (c *Client) CreatePayment(req *CreatePaymentRequest) (*Payment, error) {
 	return &resp, err
 }
 
+// QRPaymentRequest pays a merchant by a scanned EMVCo QR payload. The amount is only given for
+// codes that do not carry one.
+type QRPaymentRequest struct {
+	Payload     string `json:"payload"`
+	AccountID   int64  `json:"account_id"`
+	Amount      string `json:"amount,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (c *Client) PayQRCode(req *QRPaymentRequest) (*Payment, error) {
+	var resp Payment
+	err := c.doRequest("POST", "/payments/qr", req, &resp)
+	return &resp, err
+}
+
+type PaymentTemplate struct {
+	ID               int64  `json:"id"`
+	Name             string `json:"name"`
+	PaymentType      string `json:"payment_type"`
+	AccountID        int64  `json:"account_id,omitempty"`
+	BeneficiaryID    int64  `json:"beneficiary_id,omitempty"`
+	BillerID         int64  `json:"biller_id,omitempty"`
+	MerchantID       int64  `json:"merchant_id,omitempty"`
+	RecipientName    string `json:"recipient_name,omitempty"`
+	RecipientAccount string `json:"recipient_account,omitempty"`
+	Amount           string `json:"amount,omitempty"`
+	Currency         string `json:"currency,omitempty"`
+	Description      string `json:"description,omitempty"`
+	LastUsedAt       string `json:"last_used_at,omitempty"`
+}
+
+type PaymentTemplateListResponse struct {
+	Templates []PaymentTemplate `json:"templates"`
+	Total     int64             `json:"total"`
+}
+
+type CreatePaymentTemplateRequest struct {
+	Name             string `json:"name"`
+	PaymentType      string `json:"payment_type"`
+	AccountID        int64  `json:"account_id,omitempty"`
+	BeneficiaryID    int64  `json:"beneficiary_id,omitempty"`
+	BillerID         int64  `json:"biller_id,omitempty"`
+	MerchantID       int64  `json:"merchant_id,omitempty"`
+	RecipientName    string `json:"recipient_name,omitempty"`
+	RecipientAccount string `json:"recipient_account,omitempty"`
+	Amount           string `json:"amount,omitempty"`
+	Currency         string `json:"currency,omitempty"`
+	Description      string `json:"description,omitempty"`
+}
+
+func (c *Client) ListPaymentTemplates() (*PaymentTemplateListResponse, error) {
+	var resp PaymentTemplateListResponse
+	err := c.doRequest("GET", "/payments/templates", nil, &resp)
+	return &resp, err
+}
+
+func (c *Client) CreatePaymentTemplate(req *CreatePaymentTemplateRequest) (*PaymentTemplate, error) {
+	var resp PaymentTemplate
+	err := c.doRequest("POST", "/payments/templates", req, &resp)
+	return &resp, err
+}
+
+func (c *Client) DeletePaymentTemplate(id int64) error {
+	return c.doRequest("DELETE", fmt.Sprintf("/payments/templates/%d", id), nil, nil)
+}
+
+type Biller struct {
+	ID               int64   `json:"id"`
+	Code             string  `json:"code"`
+	Name             string  `json:"name"`
+	Category         string  `json:"category"`
+	ReferenceLabel   string  `json:"reference_label"`
+	ReferencePattern string  `json:"reference_pattern"`
+	CheckDigit       string  `json:"check_digit"`
+	MinAmount        *string `json:"min_amount,omitempty"`
+	MaxAmount        *string `json:"max_amount,omitempty"`
+	Currency         string  `json:"currency"`
+	Active           bool    `json:"active"`
+}
+
+type BillerListResponse struct {
+	Billers []Biller `json:"billers"`
+	Total   int64    `json:"total"`
+}
+
+func (c *Client) ListBillers(category string) (*BillerListResponse, error) {
+	path := "/payments/billers"
+	if category != "" {
+		path += "?category=" + url.QueryEscape(category)
+	}
+	var resp BillerListResponse
+	err := c.doRequest("GET", path, nil, &resp)
+	return &resp, err
+}
+
 // Notification endpoints
 
 type Notification struct {
@@ -292,6 +864,66 @@ func (c *Client) MarkAllNotificationsRead() error {
 	return c.doRequest("PUT", "/notifications/read-all", nil, nil)
 }
 
+// Status stream
+
+type StreamEvent struct {
+	Event string
+	Data  json.RawMessage
+}
+
+// Stream follows the caller's server-sent event stream, calling handle for every event until
+// the connection closes or handle returns an error
+func (c *Client) Stream(handle func(StreamEvent) error) error {
+	req, err := http.NewRequest("GET", c.baseURL+"/stream", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// The stream is long-lived, so it must not inherit the client's request timeout
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event StreamEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event.Event != "" || len(event.Data) > 0 {
+				if err := handle(event); err != nil {
+					return err
+				}
+			}
+			event = StreamEvent{}
+		case strings.HasPrefix(line, ":"):
+			// heartbeat comment
+		case strings.HasPrefix(line, "event:"):
+			event.Event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			event.Data = append(event.Data, strings.TrimSpace(strings.TrimPrefix(line, "data:"))...)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("stream interrupted: %w", err)
+	}
+	return nil
+}
+
 // User endpoints (admin)
 
 type User struct {
@@ -315,3 +947,36 @@ func (c *Client) ListUsers() (*UserListResponse, error) {
 	err := c.doRequest("GET", "/users", nil, &resp)
 	return &resp, err
 }
+
+// Authenticator app (TOTP) endpoints
+
+type TOTPStatus struct {
+	Enabled     bool   `json:"enabled"`
+	ConfirmedAt string `json:"confirmed_at,omitempty"`
+}
+
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauth_url"`
+}
+
+func (c *Client) GetTOTP(userID int64) (*TOTPStatus, error) {
+	var resp TOTPStatus
+	err := c.doRequest("GET", fmt.Sprintf("/users/%d/totp", userID), nil, &resp)
+	return &resp, err
+}
+
+func (c *Client) SetupTOTP(userID int64) (*TOTPSetup, error) {
+	var resp TOTPSetup
+	err := c.doRequest("POST", fmt.Sprintf("/users/%d/totp", userID), nil, &resp)
+	return &resp, err
+}
+
+func (c *Client) ConfirmTOTP(userID int64, code string) error {
+	body := map[string]string{"code": code}
+	return c.doRequest("POST", fmt.Sprintf("/users/%d/totp/confirm", userID), body, nil)
+}
+
+func (c *Client) DeleteTOTP(userID int64) error {
+	return c.doRequest("DELETE", fmt.Sprintf("/users/%d/totp", userID), nil, nil)
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"dbank/api"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var beneficiaryReq api.CreateBeneficiaryRequest
+
+var beneficiariesCmd = &cobra.Command{
+	Use:   "beneficiaries",
+	Short: "Manage saved beneficiaries",
+	Long: `Save the accounts you pay regularly and send transfers or external payments to them by ID.
+
+Payments to a newly added beneficiary are capped until its cooling-off period ends.`,
+}
+
+var beneficiariesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your beneficiaries",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		resp, err := client.ListBeneficiaries()
+		if err != nil {
+			return fmt.Errorf("failed to list beneficiaries: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(resp)
+			return nil
+		}
+
+		if len(resp.Beneficiaries) == 0 {
+			fmt.Println("No beneficiaries found")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Nickname", "Type", "Destination", "Recipient", "Cap"})
+		table.SetBorder(false)
+
+		for _, b := range resp.Beneficiaries {
+			limit := "-"
+			if b.InCoolingOff {
+				limit = b.MaxAmount + " until " + b.CoolingOffUntil
+			}
+			table.Append([]string{
+				strconv.FormatInt(b.ID, 10),
+				b.Nickname,
+				b.Type,
+				beneficiaryDestination(b),
+				truncate(b.RecipientName, 25),
+				limit,
+			})
+		}
+
+		table.Render()
+		return nil
+	},
+}
+
+var beneficiariesAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Save a new beneficiary",
+	Long: `Save a new beneficiary.
+
+Examples:
+  dbank beneficiaries add --nickname rent --account 42
+  dbank beneficiaries add --nickname mum --iban "GB82 WEST 1234 5698 7654 32" --recipient "Jane Doe"
+  dbank beneficiaries add --nickname plumber --account-number 12345678 --bank "Other Bank" --recipient "Joe Pipes"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if beneficiaryReq.Nickname == "" {
+			return fmt.Errorf("nickname is required (--nickname)")
+		}
+
+		switch {
+		case beneficiaryReq.AccountID != 0:
+			beneficiaryReq.Type = "internal"
+		case beneficiaryReq.IBAN != "":
+			beneficiaryReq.Type = "iban"
+		case beneficiaryReq.AccountNumber != "":
+			beneficiaryReq.Type = "external"
+		default:
+			return fmt.Errorf("a destination is required (--account, --iban or --account-number)")
+		}
+
+		b, err := client.CreateBeneficiary(&beneficiaryReq)
+		if err != nil {
+			return fmt.Errorf("failed to add beneficiary: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(b)
+			return nil
+		}
+
+		fmt.Printf("Beneficiary %d (%s) saved\n", b.ID, b.Nickname)
+		if b.InCoolingOff {
+			fmt.Printf("Payments are capped at %s until %s\n", b.MaxAmount, b.CoolingOffUntil)
+		}
+		return nil
+	},
+}
+
+var beneficiariesRenameCmd = &cobra.Command{
+	Use:   "rename <beneficiary_id> <nickname>",
+	Short: "Rename a beneficiary",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid beneficiary ID: %s", args[0])
+		}
+
+		b, err := client.RenameBeneficiary(id, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to rename beneficiary: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(b)
+			return nil
+		}
+
+		fmt.Printf("Beneficiary %d renamed to %s\n", b.ID, b.Nickname)
+		return nil
+	},
+}
+
+var beneficiariesDeleteCmd = &cobra.Command{
+	Use:   "delete <beneficiary_id>",
+	Short: "Delete a beneficiary",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid beneficiary ID: %s", args[0])
+		}
+
+		if err := client.DeleteBeneficiary(id); err != nil {
+			return fmt.Errorf("failed to delete beneficiary: %w", err)
+		}
+
+		fmt.Printf("Beneficiary %d deleted\n", id)
+		return nil
+	},
+}
+
+// beneficiaryDestination describes where payments to a beneficiary go
+func beneficiaryDestination(b api.Beneficiary) string {
+	switch b.Type {
+	case "internal":
+		return "account " + strconv.FormatInt(b.AccountID, 10)
+	case "iban":
+		return b.IBAN
+	default:
+		return b.AccountNumber + " at " + b.BankName
+	}
+}
+
+func init() {
+	beneficiariesAddCmd.Flags().StringVar(&beneficiaryReq.Nickname, "nickname", "", "Name to save the beneficiary under")
+	beneficiariesAddCmd.Flags().Int64Var(&beneficiaryReq.AccountID, "account", 0, "Account ID at this bank")
+	beneficiariesAddCmd.Flags().StringVar(&beneficiaryReq.IBAN, "iban", "", "IBAN at another bank")
+	beneficiariesAddCmd.Flags().StringVar(&beneficiaryReq.AccountNumber, "account-number", "", "Account number at another bank")
+	beneficiariesAddCmd.Flags().StringVar(&beneficiaryReq.BankName, "bank", "", "Bank name (with --account-number)")
+	beneficiariesAddCmd.Flags().StringVar(&beneficiaryReq.BankCode, "bank-code", "", "Bank BIC/SWIFT code")
+	beneficiariesAddCmd.Flags().StringVar(&beneficiaryReq.RecipientName, "recipient", "", "Account holder's name")
+
+	beneficiariesCmd.AddCommand(beneficiariesListCmd)
+	beneficiariesCmd.AddCommand(beneficiariesAddCmd)
+	beneficiariesCmd.AddCommand(beneficiariesRenameCmd)
+	beneficiariesCmd.AddCommand(beneficiariesDeleteCmd)
+
+	rootCmd.AddCommand(beneficiariesCmd)
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	insightsFrom  string
+	insightsTo    string
+	insightsLimit int
+)
+
+var insightsCmd = &cobra.Command{
+	Use:   "insights",
+	Short: "Show where your money went",
+	Long: `Show your spending from completed payments and transfers to other people,
+per month and category with the change from the month before, your top
+merchants and the recipients you sent the most to.
+
+Bills, mobile top-ups and merchant payments are categorized automatically.
+
+Examples:
+  dbank insights
+  dbank insights --from 2024-01 --to 2024-06`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		insights, err := client.GetSpendingInsights(insightsFrom, insightsTo, insightsLimit)
+		if err != nil {
+			return fmt.Errorf("failed to get spending insights: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(insights)
+			return nil
+		}
+
+		fmt.Printf("Spending %s to %s\n", insights.From, insights.To)
+		if insights.Incomplete {
+			fmt.Println("Transfers are unavailable right now; only payments are shown.")
+		}
+		if len(insights.Totals) == 0 {
+			fmt.Println("\nNo spending in this period")
+			return nil
+		}
+
+		fmt.Println("\nBy month")
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Month", "Spent", "Change", "Top category"})
+		table.SetBorder(false)
+		for _, m := range insights.Months {
+			change := m.Change
+			if m.ChangePercent != nil {
+				change += " (" + *m.ChangePercent + "%)"
+			}
+			top := "-"
+			if len(m.Categories) > 0 {
+				top = m.Categories[0].Category
+			}
+			table.Append([]string{m.Month, m.Total + " " + m.Currency, change, top})
+		}
+		table.Render()
+
+		fmt.Println("\nBy category")
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Category", "Spent", "Share", "Count"})
+		table.SetBorder(false)
+		for _, total := range insights.Totals {
+			for _, category := range total.Categories {
+				table.Append([]string{
+					category.Category,
+					category.Total + " " + total.Currency,
+					category.Share + "%",
+					strconv.FormatInt(category.Count, 10),
+				})
+			}
+		}
+		table.Render()
+
+		if len(insights.Merchants) > 0 {
+			fmt.Println("\nTop merchants")
+			table = tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Merchant", "Category", "Spent", "Count"})
+			table.SetBorder(false)
+			for _, m := range insights.Merchants {
+				table.Append([]string{m.Name, m.Category, m.Total + " " + m.Currency, strconv.FormatInt(m.Count, 10)})
+			}
+			table.Render()
+		}
+
+		if len(insights.TopRecipients) > 0 {
+			fmt.Println("\nTop recipients")
+			table = tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Recipient", "Account", "Via", "Sent", "Count"})
+			table.SetBorder(false)
+			for _, r := range insights.TopRecipients {
+				name, account := r.Name, r.Account
+				if name == "" {
+					name = "-"
+				}
+				if account == "" {
+					account = "-"
+				}
+				via := r.Source
+				if r.PaymentType != "" {
+					via += " (" + r.PaymentType + ")"
+				}
+				table.Append([]string{name, account, via, r.Total + " " + r.Currency, strconv.FormatInt(r.Count, 10)})
+			}
+			table.Render()
+		}
+		return nil
+	},
+}
+
+func init() {
+	insightsCmd.Flags().StringVar(&insightsFrom, "from", "", "First month (YYYY-MM); defaults to the six months ending at --to")
+	insightsCmd.Flags().StringVar(&insightsTo, "to", "", "Last month (YYYY-MM); defaults to the current month")
+	insightsCmd.Flags().IntVar(&insightsLimit, "limit", 0, "Number of merchants and recipients to show (default 10)")
+
+	rootCmd.AddCommand(insightsCmd)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"dbank/api"
 
@@ -78,6 +79,12 @@ var (
 	paymentAmount        string
 	paymentCurrency      string
 	paymentDescription   string
+	paymentBeneficiary   int64
+	paymentBiller        int64
+	paymentMerchant      int64
+	paymentTemplate      string
+	templateName         string
+	billerCategory       string
 )
 
 var paymentsCreateCmd = &cobra.Command{
@@ -85,25 +92,44 @@ var paymentsCreateCmd = &cobra.Command{
 	Short: "Create a new payment",
 	Long: `Create a new payment.
 
-Payment types: bill, merchant, external
+Payment types: bill, merchant, external, mobile
+
+Bill payments name a biller (see 'dbank payments billers') and pass the customer
+reference printed on the bill as --recipient-account.
+
+A payment can be made from a saved template (see 'dbank payments templates') by ID
+or name. Flags given alongside --template override the template's values.
 
 Examples:
-  dbank payments create --account 1 --type bill --recipient "Electric Company" --amount 150
+  dbank payments create --account 1 --type bill --biller 3 --recipient-account 79927398713 --amount 150
   dbank payments create --account 1 --type merchant --recipient "Amazon" --amount 50
-  dbank payments create --account 1 --type external --recipient "John Doe" --recipient-account "123456789" --amount 200`,
+  dbank payments create --account 1 --type merchant --merchant 12 --amount 18.40
+  dbank payments create --account 1 --type external --recipient "John Doe" --recipient-account "123456789" --amount 200
+  dbank payments create --account 1 --type external --beneficiary 4 --amount 200
+  dbank payments create --template electricity
+  dbank payments create --template 2 --amount 75`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := requireAuth(); err != nil {
 			return err
 		}
 
-		if paymentAccountID == 0 {
-			return fmt.Errorf("account ID is required (--account)")
-		}
-		if paymentType == "" {
-			return fmt.Errorf("payment type is required (--type: bill, merchant, external)")
-		}
-		if paymentAmount == "" {
-			return fmt.Errorf("amount is required (--amount)")
+		var templateID int64
+		if paymentTemplate != "" {
+			id, err := resolvePaymentTemplate(paymentTemplate)
+			if err != nil {
+				return err
+			}
+			templateID = id
+		} else {
+			if paymentAccountID == 0 {
+				return fmt.Errorf("account ID is required (--account)")
+			}
+			if paymentType == "" {
+				return fmt.Errorf("payment type is required (--type: bill, merchant, external)")
+			}
+			if paymentAmount == "" {
+				return fmt.Errorf("amount is required (--amount)")
+			}
 		}
 
 		req := &api.CreatePaymentRequest{
@@ -114,6 +140,10 @@ Examples:
 			Amount:           paymentAmount,
 			Currency:         paymentCurrency,
 			Description:      paymentDescription,
+			BeneficiaryID:    paymentBeneficiary,
+			BillerID:         paymentBiller,
+			MerchantID:       paymentMerchant,
+			TemplateID:       templateID,
 		}
 
 		payment, err := client.CreatePayment(req)
@@ -134,17 +164,287 @@ Examples:
 	},
 }
 
+var paymentsQRCmd = &cobra.Command{
+	Use:   "qr <payload>",
+	Short: "Pay a merchant by a scanned QR code",
+	Long: `Pay a merchant by the payload of a scanned EMVCo QR code.
+
+Codes that carry an amount are paid that amount; for codes without one, give --amount.
+
+Examples:
+  dbank payments qr "00020101021226230009COM.DBANK0106SHOP01..." --account 1 --amount 12.50`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if paymentAccountID == 0 {
+			return fmt.Errorf("account ID is required (--account)")
+		}
+
+		payment, err := client.PayQRCode(&api.QRPaymentRequest{
+			Payload:     args[0],
+			AccountID:   paymentAccountID,
+			Amount:      paymentAmount,
+			Description: paymentDescription,
+		})
+		if err != nil {
+			return fmt.Errorf("payment failed: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(payment)
+			return nil
+		}
+
+		fmt.Printf("Payment created successfully\n")
+		fmt.Printf("Payment ID:   %d\n", payment.ID)
+		fmt.Printf("Reference:    %s\n", payment.ReferenceID)
+		fmt.Printf("Status:       %s\n", payment.Status)
+		return nil
+	},
+}
+
+var paymentsBillersCmd = &cobra.Command{
+	Use:   "billers",
+	Short: "List billers that accept bill payments",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		resp, err := client.ListBillers(billerCategory)
+		if err != nil {
+			return fmt.Errorf("failed to list billers: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(resp)
+			return nil
+		}
+
+		if len(resp.Billers) == 0 {
+			fmt.Println("No billers found")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Code", "Name", "Category", "Reference", "Limits"})
+		table.SetBorder(false)
+
+		for _, b := range resp.Billers {
+			label := b.ReferenceLabel
+			if label == "" {
+				label = "reference"
+			}
+			limits := "-"
+			if b.MinAmount != nil || b.MaxAmount != nil {
+				min, max := "", ""
+				if b.MinAmount != nil {
+					min = *b.MinAmount
+				}
+				if b.MaxAmount != nil {
+					max = *b.MaxAmount
+				}
+				limits = min + " - " + max + " " + b.Currency
+			}
+			table.Append([]string{
+				strconv.FormatInt(b.ID, 10),
+				b.Code,
+				b.Name,
+				b.Category,
+				label,
+				limits,
+			})
+		}
+
+		table.Render()
+		fmt.Printf("\nTotal: %d billers\n", resp.Total)
+		return nil
+	},
+}
+
+var paymentsTemplatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List your saved payment templates",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		resp, err := client.ListPaymentTemplates()
+		if err != nil {
+			return fmt.Errorf("failed to list payment templates: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(resp)
+			return nil
+		}
+
+		if len(resp.Templates) == 0 {
+			fmt.Println("No payment templates found")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Name", "Type", "Account", "Recipient", "Amount"})
+		table.SetBorder(false)
+
+		for _, t := range resp.Templates {
+			account := "-"
+			if t.AccountID != 0 {
+				account = strconv.FormatInt(t.AccountID, 10)
+			}
+			recipient := t.RecipientName
+			if recipient == "" {
+				recipient = t.RecipientAccount
+			}
+			if recipient == "" && t.BeneficiaryID != 0 {
+				recipient = "beneficiary " + strconv.FormatInt(t.BeneficiaryID, 10)
+			}
+			amount := "-"
+			if t.Amount != "" {
+				amount = strings.TrimSpace(t.Amount + " " + t.Currency)
+			}
+			table.Append([]string{
+				strconv.FormatInt(t.ID, 10),
+				t.Name,
+				t.PaymentType,
+				account,
+				truncate(recipient, 25),
+				amount,
+			})
+		}
+
+		table.Render()
+		return nil
+	},
+}
+
+var paymentsTemplatesSaveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "Save a payment template",
+	Long: `Save a payment you make regularly as a template. Only the name and type are
+required; anything left out is given when a payment is made from the template.
+
+Examples:
+  dbank payments templates save --name electricity --account 1 --type bill --biller 3 --recipient-account 79927398713
+  dbank payments templates save --name "mum's phone" --type mobile --recipient Vodafone --recipient-account +447700900123 --amount 20`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if templateName == "" {
+			return fmt.Errorf("name is required (--name)")
+		}
+		if paymentType == "" {
+			return fmt.Errorf("payment type is required (--type: bill, merchant, external, mobile)")
+		}
+
+		t, err := client.CreatePaymentTemplate(&api.CreatePaymentTemplateRequest{
+			Name:             templateName,
+			PaymentType:      paymentType,
+			AccountID:        paymentAccountID,
+			BeneficiaryID:    paymentBeneficiary,
+			BillerID:         paymentBiller,
+			MerchantID:       paymentMerchant,
+			RecipientName:    paymentRecipient,
+			RecipientAccount: paymentRecipientAcct,
+			Amount:           paymentAmount,
+			Currency:         paymentCurrency,
+			Description:      paymentDescription,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save payment template: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(t)
+			return nil
+		}
+
+		fmt.Printf("Payment template %d (%s) saved\n", t.ID, t.Name)
+		return nil
+	},
+}
+
+var paymentsTemplatesDeleteCmd = &cobra.Command{
+	Use:   "delete <template>",
+	Short: "Delete a payment template by ID or name",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := resolvePaymentTemplate(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := client.DeletePaymentTemplate(id); err != nil {
+			return fmt.Errorf("failed to delete payment template: %w", err)
+		}
+
+		fmt.Printf("Payment template %d deleted\n", id)
+		return nil
+	},
+}
+
+// resolvePaymentTemplate returns the ID of the caller's template with the given ID or name
+func resolvePaymentTemplate(value string) (int64, error) {
+	if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return id, nil
+	}
+
+	resp, err := client.ListPaymentTemplates()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list payment templates: %w", err)
+	}
+	for _, t := range resp.Templates {
+		if strings.EqualFold(t.Name, value) {
+			return t.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("no payment template named %q", value)
+}
+
+// addPaymentFlags registers the flags describing a payment, shared by payments and templates
+func addPaymentFlags(cmd *cobra.Command) {
+	cmd.Flags().Int64Var(&paymentAccountID, "account", 0, "Source account ID")
+	cmd.Flags().StringVar(&paymentType, "type", "", "Payment type (bill, merchant, external, mobile)")
+	cmd.Flags().StringVar(&paymentRecipient, "recipient", "", "Recipient name (the operator for mobile top-ups)")
+	cmd.Flags().StringVar(&paymentRecipientAcct, "recipient-account", "", "Recipient account number (for external transfers), customer reference (for bills) or phone number (for mobile top-ups)")
+	cmd.Flags().StringVar(&paymentAmount, "amount", "", "Payment amount")
+	cmd.Flags().StringVar(&paymentCurrency, "currency", "", "Currency (default: the biller's currency for bills, otherwise USD)")
+	cmd.Flags().StringVar(&paymentDescription, "description", "", "Payment description")
+	cmd.Flags().Int64Var(&paymentBeneficiary, "beneficiary", 0, "Saved beneficiary ID (for external transfers)")
+	cmd.Flags().Int64Var(&paymentBiller, "biller", 0, "Biller ID (for bill payments)")
+	cmd.Flags().Int64Var(&paymentMerchant, "merchant", 0, "Merchant ID (for merchant payments to merchants the bank acquires)")
+}
+
 func init() {
-	paymentsCreateCmd.Flags().Int64Var(&paymentAccountID, "account", 0, "Source account ID")
-	paymentsCreateCmd.Flags().StringVar(&paymentType, "type", "", "Payment type (bill, merchant, external)")
-	paymentsCreateCmd.Flags().StringVar(&paymentRecipient, "recipient", "", "Recipient name")
-	paymentsCreateCmd.Flags().StringVar(&paymentRecipientAcct, "recipient-account", "", "Recipient account number (for external transfers)")
-	paymentsCreateCmd.Flags().StringVar(&paymentAmount, "amount", "", "Payment amount")
-	paymentsCreateCmd.Flags().StringVar(&paymentCurrency, "currency", "USD", "Currency (default: USD)")
-	paymentsCreateCmd.Flags().StringVar(&paymentDescription, "description", "", "Payment description")
+	addPaymentFlags(paymentsCreateCmd)
+	paymentsCreateCmd.Flags().StringVar(&paymentTemplate, "template", "", "Saved payment template ID or name")
+	addPaymentFlags(paymentsTemplatesSaveCmd)
+	paymentsTemplatesSaveCmd.Flags().StringVar(&templateName, "name", "", "Name to save the template under")
+	paymentsQRCmd.Flags().Int64Var(&paymentAccountID, "account", 0, "Source account ID")
+	paymentsQRCmd.Flags().StringVar(&paymentAmount, "amount", "", "Payment amount (for QR codes without one)")
+	paymentsQRCmd.Flags().StringVar(&paymentDescription, "description", "", "Payment description")
+	paymentsBillersCmd.Flags().StringVar(&billerCategory, "category", "", "Filter by category (utility, telecom, tax)")
+
+	paymentsTemplatesCmd.AddCommand(paymentsTemplatesSaveCmd)
+	paymentsTemplatesCmd.AddCommand(paymentsTemplatesDeleteCmd)
 
 	paymentsCmd.AddCommand(paymentsListCmd)
 	paymentsCmd.AddCommand(paymentsCreateCmd)
+	paymentsCmd.AddCommand(paymentsQRCmd)
+	paymentsCmd.AddCommand(newReceiptCmd("payments", "payment"))
+	paymentsCmd.AddCommand(paymentsBillersCmd)
+	paymentsCmd.AddCommand(paymentsTemplatesCmd)
 
 	rootCmd.AddCommand(paymentsCmd)
 }
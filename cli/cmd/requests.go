@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"dbank/api"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	requestTo       int64
+	requestPayer    int64
+	requestAmount   string
+	requestNote     string
+	requestOutgoing bool
+	requestStatus   string
+	requestFrom     int64
+	requestReason   string
+)
+
+var requestsCmd = &cobra.Command{
+	Use:     "requests",
+	Aliases: []string{"req"},
+	Short:   "Request money from other customers",
+	Long:    `Ask another customer to pay you, and accept or decline requests sent to you.`,
+}
+
+var requestsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List requests sent to you (or by you with --outgoing)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		direction := "incoming"
+		if requestOutgoing {
+			direction = "outgoing"
+		}
+
+		resp, err := client.ListMoneyRequests(direction, requestStatus)
+		if err != nil {
+			return fmt.Errorf("failed to list money requests: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(resp)
+			return nil
+		}
+
+		if len(resp.Requests) == 0 {
+			fmt.Println("No money requests found")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "From User", "To User", "Amount", "Note", "Status", "Expires"})
+		table.SetBorder(false)
+
+		for _, r := range resp.Requests {
+			expires := r.ExpiresAt
+			if len(expires) > 10 {
+				expires = expires[:10]
+			}
+			table.Append([]string{
+				strconv.FormatInt(r.ID, 10),
+				strconv.FormatInt(r.RequesterUserID, 10),
+				strconv.FormatInt(r.PayerUserID, 10),
+				r.Amount + " " + r.Currency,
+				truncate(r.Note, 30),
+				r.Status,
+				expires,
+			})
+		}
+
+		table.Render()
+		fmt.Printf("\nTotal: %d\n", resp.Total)
+		return nil
+	},
+}
+
+var requestsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Ask another customer to pay you",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if requestTo == 0 {
+			return fmt.Errorf("your receiving account is required (--to)")
+		}
+		if requestPayer == 0 {
+			return fmt.Errorf("the payer's account is required (--payer)")
+		}
+		if requestAmount == "" {
+			return fmt.Errorf("amount is required (--amount)")
+		}
+
+		request, err := client.CreateMoneyRequest(&api.CreateMoneyRequestRequest{
+			ToAccountID:    requestTo,
+			PayerAccountID: requestPayer,
+			Amount:         requestAmount,
+			Note:           requestNote,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create money request: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(request)
+			return nil
+		}
+
+		fmt.Printf("Money request %d sent for %s %s (expires %s)\n", request.ID, request.Amount, request.Currency, request.ExpiresAt)
+		return nil
+	},
+}
+
+var requestsAcceptCmd = &cobra.Command{
+	Use:   "accept <request_id>",
+	Short: "Pay a money request",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid request ID: %s", args[0])
+		}
+
+		resp, err := client.AcceptMoneyRequest(id, requestFrom)
+		if err != nil {
+			return fmt.Errorf("failed to accept money request: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(resp)
+			return nil
+		}
+
+		fmt.Printf("Money request %d accepted\n", id)
+		fmt.Printf("Transfer ID:  %d (%s)\n", resp.TransferID, resp.TransferStatus)
+		fmt.Printf("Fee:          %s\n", resp.Fee)
+		fmt.Printf("Total Debit:  %s\n", resp.TotalDebit)
+		return nil
+	},
+}
+
+var requestsDeclineCmd = &cobra.Command{
+	Use:   "decline <request_id>",
+	Short: "Decline a money request",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid request ID: %s", args[0])
+		}
+
+		request, err := client.DeclineMoneyRequest(id, requestReason)
+		if err != nil {
+			return fmt.Errorf("failed to decline money request: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(request)
+			return nil
+		}
+
+		fmt.Printf("Money request %d declined\n", id)
+		return nil
+	},
+}
+
+var requestsCancelCmd = &cobra.Command{
+	Use:   "cancel <request_id>",
+	Short: "Cancel a money request you sent",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid request ID: %s", args[0])
+		}
+
+		request, err := client.CancelMoneyRequest(id)
+		if err != nil {
+			return fmt.Errorf("failed to cancel money request: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(request)
+			return nil
+		}
+
+		fmt.Printf("Money request %d cancelled\n", id)
+		return nil
+	},
+}
+
+func init() {
+	requestsListCmd.Flags().BoolVar(&requestOutgoing, "outgoing", false, "List requests you sent instead of requests sent to you")
+	requestsListCmd.Flags().StringVar(&requestStatus, "status", "", "Filter by status (pending, accepted, declined, cancelled, expired)")
+
+	requestsCreateCmd.Flags().Int64Var(&requestTo, "to", 0, "Your account to be paid into")
+	requestsCreateCmd.Flags().Int64Var(&requestPayer, "payer", 0, "Account of the customer you are asking to pay")
+	requestsCreateCmd.Flags().StringVar(&requestAmount, "amount", "", "Amount to request")
+	requestsCreateCmd.Flags().StringVar(&requestNote, "note", "", "What the money is for (max 140 characters)")
+
+	requestsAcceptCmd.Flags().Int64Var(&requestFrom, "from", 0, "Account to pay from (default: the account the request was sent to)")
+	requestsDeclineCmd.Flags().StringVar(&requestReason, "reason", "", "Reason for declining")
+
+	requestsCmd.AddCommand(requestsListCmd)
+	requestsCmd.AddCommand(requestsCreateCmd)
+	requestsCmd.AddCommand(requestsAcceptCmd)
+	requestsCmd.AddCommand(requestsDeclineCmd)
+	requestsCmd.AddCommand(requestsCancelCmd)
+
+	rootCmd.AddCommand(requestsCmd)
+}
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"dbank/api"
 	"dbank/config"
@@ -45,6 +47,7 @@ Then run commands like:
 		}
 
 		client = api.NewClient(cfg)
+		client.OnChallenge = promptForCode
 		return nil
 	},
 }
@@ -66,6 +69,37 @@ func printJSON(v interface{}) {
 	fmt.Println(string(data))
 }
 
+// promptForCode asks for the one-time code that confirms a sensitive operation
+func promptForCode(ch *api.Challenge) (string, error) {
+	if ch.AttemptsRemaining != nil {
+		fmt.Fprintf(os.Stderr, "%s (%d attempts left)\n", ch.Error, *ch.AttemptsRemaining)
+	} else {
+		where := "by " + ch.Channel
+		if ch.Channel == "" {
+			where = "to you"
+		}
+		fmt.Fprintf(os.Stderr, "This %s needs confirming. A verification code was sent %s", strings.ReplaceAll(ch.Operation, "_", " "), where)
+		for _, m := range ch.Methods {
+			if m == "totp" {
+				fmt.Fprint(os.Stderr, "; a code from your authenticator app also works")
+			}
+		}
+		fmt.Fprintln(os.Stderr, ".")
+	}
+
+	fmt.Fprint(os.Stderr, "Verification code: ")
+	code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read verification code: %w", err)
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", fmt.Errorf("verification cancelled")
+	}
+	return code, nil
+}
+
 func requireAuth() error {
 	if !cfg.IsLoggedIn() {
 		return fmt.Errorf("not logged in. Run: dbank login -u <username> -p <password>")
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"dbank/api"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	splitAccount      int64
+	splitTotal        string
+	splitDescription  string
+	splitParticipants []string
+	splitPayFrom      int64
+)
+
+var splitsCmd = &cobra.Command{
+	Use:   "splits",
+	Short: "Split bills with other customers",
+	Long:  `Share a bill among other customers, track who has paid and pay your own shares.`,
+}
+
+var splitsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List splits you own or have a share in",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		resp, err := client.ListSplits()
+		if err != nil {
+			return fmt.Errorf("failed to list splits: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(resp)
+			return nil
+		}
+
+		if len(resp.Splits) == 0 {
+			fmt.Println("No splits found")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Owner", "Total", "Method", "Description", "Status", "Created"})
+		table.SetBorder(false)
+
+		for _, s := range resp.Splits {
+			created := s.CreatedAt
+			if len(created) > 10 {
+				created = created[:10]
+			}
+			table.Append([]string{
+				strconv.FormatInt(s.ID, 10),
+				strconv.FormatInt(s.OwnerUserID, 10),
+				s.TotalAmount + " " + s.Currency,
+				s.SplitMethod,
+				truncate(s.Description, 30),
+				s.Status,
+				created,
+			})
+		}
+
+		table.Render()
+		fmt.Printf("\nTotal: %d\n", resp.Total)
+		return nil
+	},
+}
+
+var splitsViewCmd = &cobra.Command{
+	Use:   "view <split_id>",
+	Short: "Show a split and who has paid",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid split ID: %s", args[0])
+		}
+
+		split, err := client.GetSplit(id)
+		if err != nil {
+			return fmt.Errorf("failed to get split: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(split)
+			return nil
+		}
+
+		fmt.Printf("Split ID:     %d\n", split.ID)
+		fmt.Printf("Total:        %s %s (%s)\n", split.TotalAmount, split.Currency, split.SplitMethod)
+		if split.Description != "" {
+			fmt.Printf("Description:  %s\n", split.Description)
+		}
+		fmt.Printf("Pay into:     account %d\n", split.AccountID)
+		fmt.Printf("Status:       %s\n\n", split.Status)
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"User", "Account", "Amount", "Status", "Transfer", "Reminders"})
+		table.SetBorder(false)
+
+		for _, s := range split.Shares {
+			transfer := ""
+			if s.TransferID != 0 {
+				transfer = strconv.FormatInt(s.TransferID, 10)
+			}
+			table.Append([]string{
+				strconv.FormatInt(s.UserID, 10),
+				strconv.FormatInt(s.AccountID, 10),
+				s.Amount,
+				s.Status,
+				transfer,
+				strconv.Itoa(s.RemindersSent),
+			})
+		}
+
+		table.Render()
+		return nil
+	},
+}
+
+var splitsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Split a bill among other customers",
+	Long: `Split a bill among other customers. Name each participant by one of their accounts with
+--participant; add =AMOUNT to give everyone a custom share, otherwise the total is split evenly.
+
+  dbank splits create --account 1 --total 90 --participant 7 --participant 9
+  dbank splits create --account 1 --total 90 --participant 7=60 --participant 9=30`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if splitAccount == 0 {
+			return fmt.Errorf("your receiving account is required (--account)")
+		}
+		if splitTotal == "" {
+			return fmt.Errorf("total amount is required (--total)")
+		}
+		if len(splitParticipants) == 0 {
+			return fmt.Errorf("at least one participant is required (--participant)")
+		}
+
+		req := &api.CreateSplitRequest{
+			AccountID:   splitAccount,
+			TotalAmount: splitTotal,
+			Method:      "even",
+			Description: splitDescription,
+		}
+		for _, p := range splitParticipants {
+			account, amount, custom := strings.Cut(p, "=")
+			accountID, err := strconv.ParseInt(account, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid participant account: %s", p)
+			}
+			if custom {
+				req.Method = "custom"
+			}
+			req.Participants = append(req.Participants, api.SplitParticipant{AccountID: accountID, Amount: amount})
+		}
+
+		split, err := client.CreateSplit(req)
+		if err != nil {
+			return fmt.Errorf("failed to create split: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(split)
+			return nil
+		}
+
+		fmt.Printf("Split %d created for %s %s across %d participants\n", split.ID, split.TotalAmount, split.Currency, len(split.Shares))
+		return nil
+	},
+}
+
+var splitsPayCmd = &cobra.Command{
+	Use:   "pay <split_id>",
+	Short: "Pay your share of a split",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid split ID: %s", args[0])
+		}
+
+		resp, err := client.PaySplitShare(id, splitPayFrom)
+		if err != nil {
+			return fmt.Errorf("failed to pay split share: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(resp)
+			return nil
+		}
+
+		fmt.Printf("Paying %s for split %d\n", resp.Share.Amount, id)
+		fmt.Printf("Transfer ID:  %d (%s)\n", resp.TransferID, resp.TransferStatus)
+		fmt.Printf("Fee:          %s\n", resp.Fee)
+		fmt.Printf("Total Debit:  %s\n", resp.TotalDebit)
+		return nil
+	},
+}
+
+var splitsRemindCmd = &cobra.Command{
+	Use:   "remind <split_id>",
+	Short: "Remind everyone who hasn't paid their share",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid split ID: %s", args[0])
+		}
+
+		sent, err := client.RemindSplit(id)
+		if err != nil {
+			return fmt.Errorf("failed to send reminders: %w", err)
+		}
+
+		fmt.Printf("Sent %d reminders\n", sent)
+		return nil
+	},
+}
+
+var splitsCancelCmd = &cobra.Command{
+	Use:   "cancel <split_id>",
+	Short: "Cancel a split you own",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid split ID: %s", args[0])
+		}
+
+		split, err := client.CancelSplit(id)
+		if err != nil {
+			return fmt.Errorf("failed to cancel split: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(split)
+			return nil
+		}
+
+		fmt.Printf("Split %d cancelled\n", id)
+		return nil
+	},
+}
+
+func init() {
+	splitsCreateCmd.Flags().Int64Var(&splitAccount, "account", 0, "Your account that shares are paid into")
+	splitsCreateCmd.Flags().StringVar(&splitTotal, "total", "", "Total amount to split")
+	splitsCreateCmd.Flags().StringVar(&splitDescription, "description", "", "What the bill is for (max 140 characters)")
+	splitsCreateCmd.Flags().StringArrayVar(&splitParticipants, "participant", nil, "Participant account ID, optionally ACCOUNT=AMOUNT (repeatable)")
+
+	splitsPayCmd.Flags().Int64Var(&splitPayFrom, "from", 0, "Account to pay from (default: the account you were added with)")
+
+	splitsCmd.AddCommand(splitsListCmd)
+	splitsCmd.AddCommand(splitsViewCmd)
+	splitsCmd.AddCommand(splitsCreateCmd)
+	splitsCmd.AddCommand(splitsPayCmd)
+	splitsCmd.AddCommand(splitsRemindCmd)
+	splitsCmd.AddCommand(splitsCancelCmd)
+
+	rootCmd.AddCommand(splitsCmd)
+}
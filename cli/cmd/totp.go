@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var totpCmd = &cobra.Command{
+	Use:   "totp",
+	Short: "Manage your authenticator app",
+	Long: `Enrol an authenticator app (Google Authenticator, Authy, ...) whose codes can confirm
+sensitive operations instead of a code sent by SMS or email.`,
+}
+
+var totpStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether an authenticator app is enabled",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		status, err := client.GetTOTP(cfg.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get authenticator app status: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(status)
+			return nil
+		}
+
+		if status.Enabled {
+			fmt.Printf("Authenticator app enabled since %s\n", status.ConfirmedAt)
+		} else {
+			fmt.Println("No authenticator app enabled")
+		}
+		return nil
+	},
+}
+
+var totpSetupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Start enrolling an authenticator app",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		setup, err := client.SetupTOTP(cfg.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to set up authenticator app: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(setup)
+			return nil
+		}
+
+		fmt.Printf("Add this key to your authenticator app: %s\n", setup.Secret)
+		fmt.Printf("Or open: %s\n\n", setup.URL)
+		fmt.Println("Then finish with: dbank totp confirm <code>")
+		return nil
+	},
+}
+
+var totpConfirmCmd = &cobra.Command{
+	Use:   "confirm <code>",
+	Short: "Enable the authenticator app with a code from it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if err := client.ConfirmTOTP(cfg.UserID, args[0]); err != nil {
+			return fmt.Errorf("failed to confirm authenticator app: %w", err)
+		}
+
+		fmt.Println("Authenticator app enabled")
+		return nil
+	},
+}
+
+var totpRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove your authenticator app",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if err := client.DeleteTOTP(cfg.UserID); err != nil {
+			return fmt.Errorf("failed to remove authenticator app: %w", err)
+		}
+
+		fmt.Println("Authenticator app removed")
+		return nil
+	},
+}
+
+func init() {
+	totpCmd.AddCommand(totpStatusCmd)
+	totpCmd.AddCommand(totpSetupCmd)
+	totpCmd.AddCommand(totpConfirmCmd)
+	totpCmd.AddCommand(totpRemoveCmd)
+
+	rootCmd.AddCommand(totpCmd)
+}
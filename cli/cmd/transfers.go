@@ -5,6 +5,8 @@ import (
 	"os"
 	"strconv"
 
+	"dbank/api"
+
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -23,7 +25,7 @@ var transfersListCmd = &cobra.Command{
 			return err
 		}
 
-		resp, err := client.ListTransfers()
+		resp, err := client.ListTransfers(transferFilter)
 		if err != nil {
 			return fmt.Errorf("failed to list transfers: %w", err)
 		}
@@ -39,7 +41,7 @@ var transfersListCmd = &cobra.Command{
 		}
 
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"ID", "Reference", "From", "To", "Amount", "Status", "Created"})
+		table.SetHeader([]string{"ID", "Reference", "From", "To", "Amount", "Category", "Status", "Created"})
 		table.SetBorder(false)
 
 		for _, t := range resp.Transfers {
@@ -57,6 +59,7 @@ var transfersListCmd = &cobra.Command{
 				strconv.FormatInt(t.FromAccountID, 10),
 				strconv.FormatInt(t.ToAccountID, 10),
 				t.Amount + " " + t.Currency,
+				t.Category,
 				t.Status,
 				created,
 			})
@@ -97,6 +100,11 @@ var transfersViewCmd = &cobra.Command{
 		fmt.Printf("From Account:     %d\n", transfer.FromAccountID)
 		fmt.Printf("To Account:       %d\n", transfer.ToAccountID)
 		fmt.Printf("Amount:           %s %s\n", transfer.Amount, transfer.Currency)
+		fmt.Printf("Fee:              %s %s\n", transfer.Fee, transfer.Currency)
+		if transfer.Memo != "" {
+			fmt.Printf("Memo:             %s\n", transfer.Memo)
+		}
+		fmt.Printf("Category:         %s\n", transfer.Category)
 		fmt.Printf("Status:           %s\n", transfer.Status)
 		if transfer.FailureReason != "" {
 			fmt.Printf("Failure Reason:   %s\n", transfer.FailureReason)
@@ -110,10 +118,14 @@ var transfersViewCmd = &cobra.Command{
 }
 
 var (
-	transferFrom     int64
-	transferTo       int64
-	transferAmount   string
-	transferCurrency string
+	transferFrom        int64
+	transferTo          int64
+	transferBeneficiary int64
+	transferAmount      string
+	transferCurrency    string
+	transferMemo        string
+	transferCategory    string
+	transferFilter      api.TransferFilter
 )
 
 var transfersCreateCmd = &cobra.Command{
@@ -127,14 +139,22 @@ var transfersCreateCmd = &cobra.Command{
 		if transferFrom == 0 {
 			return fmt.Errorf("source account is required (--from)")
 		}
-		if transferTo == 0 {
-			return fmt.Errorf("destination account is required (--to)")
+		if transferTo == 0 && transferBeneficiary == 0 {
+			return fmt.Errorf("destination is required (--to or --beneficiary)")
 		}
 		if transferAmount == "" {
 			return fmt.Errorf("amount is required (--amount)")
 		}
 
-		resp, err := client.CreateTransfer(transferFrom, transferTo, transferAmount, transferCurrency)
+		resp, err := client.CreateTransfer(&api.CreateTransferRequest{
+			FromAccountID: transferFrom,
+			ToAccountID:   transferTo,
+			BeneficiaryID: transferBeneficiary,
+			Amount:        transferAmount,
+			Currency:      transferCurrency,
+			Memo:          transferMemo,
+			Category:      transferCategory,
+		})
 		if err != nil {
 			return fmt.Errorf("transfer failed: %w", err)
 		}
@@ -148,6 +168,50 @@ var transfersCreateCmd = &cobra.Command{
 		fmt.Printf("Transfer ID:  %d\n", resp.TransferID)
 		fmt.Printf("Reference:    %s\n", resp.ReferenceID)
 		fmt.Printf("Status:       %s\n", resp.Status)
+		fmt.Printf("Fee:          %s\n", resp.Fee)
+		fmt.Printf("Total Debit:  %s\n", resp.TotalDebit)
+		return nil
+	},
+}
+
+var transfersQuoteCmd = &cobra.Command{
+	Use:   "quote",
+	Short: "Quote the fee for a transfer without submitting it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if transferFrom == 0 {
+			return fmt.Errorf("source account is required (--from)")
+		}
+		if transferTo == 0 && transferBeneficiary == 0 {
+			return fmt.Errorf("destination is required (--to or --beneficiary)")
+		}
+		if transferAmount == "" {
+			return fmt.Errorf("amount is required (--amount)")
+		}
+
+		quote, err := client.QuoteTransfer(&api.CreateTransferRequest{
+			FromAccountID: transferFrom,
+			ToAccountID:   transferTo,
+			BeneficiaryID: transferBeneficiary,
+			Amount:        transferAmount,
+			Currency:      transferCurrency,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to quote transfer: %w", err)
+		}
+
+		if jsonOutput {
+			printJSON(quote)
+			return nil
+		}
+
+		fmt.Printf("Amount:       %s %s\n", quote.Amount, quote.Currency)
+		fmt.Printf("Fee:          %s %s\n", quote.Fee, quote.Currency)
+		fmt.Printf("Total Debit:  %s %s\n", quote.TotalDebit, quote.Currency)
+		fmt.Printf("Channel:      %s\n", quote.Channel)
 		return nil
 	},
 }
@@ -155,12 +219,30 @@ var transfersCreateCmd = &cobra.Command{
 func init() {
 	transfersCreateCmd.Flags().Int64Var(&transferFrom, "from", 0, "Source account ID")
 	transfersCreateCmd.Flags().Int64Var(&transferTo, "to", 0, "Destination account ID")
+	transfersCreateCmd.Flags().Int64Var(&transferBeneficiary, "beneficiary", 0, "Saved beneficiary ID (instead of --to)")
 	transfersCreateCmd.Flags().StringVar(&transferAmount, "amount", "", "Amount to transfer")
 	transfersCreateCmd.Flags().StringVar(&transferCurrency, "currency", "USD", "Currency (default: USD)")
+	transfersCreateCmd.Flags().StringVar(&transferMemo, "memo", "", "What the transfer is for (max 140 characters)")
+	transfersCreateCmd.Flags().StringVar(&transferCategory, "category", "", "Category (default: derived from the memo)")
+	transfersQuoteCmd.Flags().Int64Var(&transferFrom, "from", 0, "Source account ID")
+	transfersQuoteCmd.Flags().Int64Var(&transferTo, "to", 0, "Destination account ID")
+	transfersQuoteCmd.Flags().Int64Var(&transferBeneficiary, "beneficiary", 0, "Saved beneficiary ID (instead of --to)")
+	transfersQuoteCmd.Flags().StringVar(&transferAmount, "amount", "", "Amount to transfer")
+	transfersQuoteCmd.Flags().StringVar(&transferCurrency, "currency", "USD", "Currency (default: USD)")
+
+	transfersListCmd.Flags().StringVar(&transferFilter.Query, "search", "", "Search memo text")
+	transfersListCmd.Flags().StringVar(&transferFilter.Category, "category", "", "Filter by category")
+	transfersListCmd.Flags().StringVar(&transferFilter.Counterparty, "counterparty", "", "Filter by counterparty account ID")
+	transfersListCmd.Flags().StringVar(&transferFilter.MinAmount, "min-amount", "", "Minimum amount")
+	transfersListCmd.Flags().StringVar(&transferFilter.MaxAmount, "max-amount", "", "Maximum amount")
+	transfersListCmd.Flags().StringVar(&transferFilter.From, "from-date", "", "Created on or after (YYYY-MM-DD)")
+	transfersListCmd.Flags().StringVar(&transferFilter.To, "to-date", "", "Created on or before (YYYY-MM-DD)")
 
 	transfersCmd.AddCommand(transfersListCmd)
 	transfersCmd.AddCommand(transfersViewCmd)
 	transfersCmd.AddCommand(transfersCreateCmd)
+	transfersCmd.AddCommand(transfersQuoteCmd)
+	transfersCmd.AddCommand(newReceiptCmd("transfers", "transfer"))
 
 	rootCmd.AddCommand(transfersCmd)
 }
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dbank/api"
+
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Follow transfer, payment and notification updates live",
+	Long:  `Stream status changes for your transfers and payments, and new notifications, as they happen.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		fmt.Println("Watching for updates (Ctrl+C to stop)...")
+		return client.Stream(func(event api.StreamEvent) error {
+			if jsonOutput {
+				fmt.Printf("{\"event\":%q,\"data\":%s}\n", event.Event, string(event.Data))
+				return nil
+			}
+			fmt.Println(formatStreamEvent(event))
+			return nil
+		})
+	},
+}
+
+// formatStreamEvent renders a stream event as a single line
+func formatStreamEvent(event api.StreamEvent) string {
+	var data struct {
+		TransferID    int64  `json:"transfer_id"`
+		PaymentID     int64  `json:"payment_id"`
+		ReferenceID   string `json:"reference_id"`
+		Amount        string `json:"amount"`
+		FailureReason string `json:"failure_reason"`
+		Title         string `json:"title"`
+		Content       string `json:"content"`
+	}
+	_ = json.Unmarshal(event.Data, &data)
+
+	now := time.Now().Format("15:04:05")
+	switch event.Event {
+	case "ready":
+		return fmt.Sprintf("[%s] connected", now)
+	case "transfer.completed":
+		return fmt.Sprintf("[%s] Transfer %d completed (amount %s, ref %s)", now, data.TransferID, data.Amount, data.ReferenceID)
+	case "transfer.failed":
+		return fmt.Sprintf("[%s] Transfer %d failed: %s", now, data.TransferID, data.FailureReason)
+	case "payment.completed":
+		return fmt.Sprintf("[%s] Payment %d completed (ref %s)", now, data.PaymentID, data.ReferenceID)
+	case "payment.failed":
+		return fmt.Sprintf("[%s] Payment %d failed: %s", now, data.PaymentID, data.FailureReason)
+	case "notification.created":
+		return fmt.Sprintf("[%s] %s: %s", now, data.Title, data.Content)
+	default:
+		return fmt.Sprintf("[%s] %s %s", now, event.Event, string(event.Data))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
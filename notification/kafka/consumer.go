@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"notification-service/models"
 	"notification-service/repository"
@@ -17,6 +19,11 @@ const (
 	TopicTransferFailed    = "transfer.failed"
 	TopicPaymentCompleted  = "payment.completed"
 	TopicPaymentFailed     = "payment.failed"
+	TopicMoneyRequest      = "money_request.updated"
+	TopicSplitReminder     = "bill_split.reminder"
+	TopicOTPRequested      = "auth.otp_requested"
+	TopicInboundCredited   = "payment.inbound_credited"
+	TopicRefunded          = "payment.refunded"
 )
 
 type Consumer struct {
@@ -24,12 +31,18 @@ type Consumer struct {
 	transferFailedReader    *kafka.Reader
 	paymentCompletedReader  *kafka.Reader
 	paymentFailedReader     *kafka.Reader
+	moneyRequestReader      *kafka.Reader
+	splitReminderReader     *kafka.Reader
+	otpReader               *kafka.Reader
+	inboundCreditReader     *kafka.Reader
+	refundReader            *kafka.Reader
 	repo                    repository.NotificationRepo
+	producer                *Producer
 	// In a real system, we would have a user lookup service
 	// For now, we'll simulate with placeholder user IDs
 }
 
-func NewConsumer(brokers []string, groupID string, repo repository.NotificationRepo) *Consumer {
+func NewConsumer(brokers []string, groupID string, repo repository.NotificationRepo, producer *Producer) *Consumer {
 	transferCompletedReader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:     brokers,
 		Topic:       TopicTransferCompleted,
@@ -66,12 +79,63 @@ func NewConsumer(brokers []string, groupID string, repo repository.NotificationR
 		StartOffset: kafka.FirstOffset,
 	})
 
+	moneyRequestReader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       TopicMoneyRequest,
+		GroupID:     groupID,
+		MinBytes:    10e3,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	splitReminderReader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       TopicSplitReminder,
+		GroupID:     groupID,
+		MinBytes:    10e3,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	otpReader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       TopicOTPRequested,
+		GroupID:     groupID,
+		MinBytes:    10e3,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	inboundCreditReader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       TopicInboundCredited,
+		GroupID:     groupID,
+		MinBytes:    10e3,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	refundReader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       TopicRefunded,
+		GroupID:     groupID,
+		MinBytes:    10e3,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+
 	return &Consumer{
 		transferCompletedReader: transferCompletedReader,
 		transferFailedReader:    transferFailedReader,
 		paymentCompletedReader:  paymentCompletedReader,
 		paymentFailedReader:     paymentFailedReader,
+		moneyRequestReader:      moneyRequestReader,
+		splitReminderReader:     splitReminderReader,
+		otpReader:               otpReader,
+		inboundCreditReader:     inboundCreditReader,
+		refundReader:            refundReader,
 		repo:                    repo,
+		producer:                producer,
 	}
 }
 
@@ -81,6 +145,11 @@ func (c *Consumer) Start(ctx context.Context) {
 	go c.consumeTransferFailed(ctx)
 	go c.consumePaymentCompleted(ctx)
 	go c.consumePaymentFailed(ctx)
+	go c.consumeMoneyRequests(ctx)
+	go c.consumeSplitReminders(ctx)
+	go c.consumeOTPRequests(ctx)
+	go c.consumeInboundCredits(ctx)
+	go c.consumeRefunds(ctx)
 }
 
 func (c *Consumer) consumeTransferCompleted(ctx context.Context) {
@@ -106,6 +175,12 @@ func (c *Consumer) consumeTransferCompleted(ctx context.Context) {
 				continue
 			}
 
+			if event.ReversalOf != 0 {
+				c.notifyTransferReversed(ctx, event)
+				c.transferCompletedReader.CommitMessages(ctx, msg)
+				continue
+			}
+
 			log.Printf("Creating notifications for transfer %d completed (from user %d to user %d)",
 				event.TransferID, event.FromUserID, event.ToUserID)
 
@@ -114,15 +189,27 @@ func (c *Consumer) consumeTransferCompleted(ctx context.Context) {
 				"reference_id": event.ReferenceID,
 			}
 
-			// Create notification for sender
+			// Create notification for sender, including any fee charged
 			if event.FromUserID > 0 {
-				_, err = c.repo.CreateFromEvent(ctx,
+				message := fmt.Sprintf("Your transfer (ref: %s) has been completed successfully.", event.ReferenceID)
+				senderMetadata := metadata
+				if event.Fee.IsPositive() {
+					message = fmt.Sprintf("Your transfer of %s (ref: %s) has been completed successfully. A fee of %s was charged.",
+						event.Amount.StringFixed(2), event.ReferenceID, event.Fee.StringFixed(2))
+					senderMetadata = map[string]interface{}{
+						"transfer_id":  event.TransferID,
+						"reference_id": event.ReferenceID,
+						"fee":          event.Fee.StringFixed(2),
+					}
+				}
+
+				_, err = c.createNotification(ctx,
 					event.FromUserID,
 					models.NotificationTypeTransferSent,
 					models.ChannelEmail,
 					"Transfer Completed",
-					fmt.Sprintf("Your transfer (ref: %s) has been completed successfully.", event.ReferenceID),
-					metadata,
+					message,
+					senderMetadata,
 				)
 				if err != nil {
 					log.Printf("Error creating sender notification: %v", err)
@@ -132,7 +219,7 @@ func (c *Consumer) consumeTransferCompleted(ctx context.Context) {
 
 			// Create notification for receiver
 			if event.ToUserID > 0 {
-				_, err = c.repo.CreateFromEvent(ctx,
+				_, err = c.createNotification(ctx,
 					event.ToUserID,
 					models.NotificationTypeTransferReceived,
 					models.ChannelEmail,
@@ -174,6 +261,12 @@ func (c *Consumer) consumeTransferFailed(ctx context.Context) {
 				continue
 			}
 
+			if event.ReversalOf != 0 {
+				c.notifyReversalFailed(ctx, event)
+				c.transferFailedReader.CommitMessages(ctx, msg)
+				continue
+			}
+
 			log.Printf("Creating notification for transfer %d failed (user %d): %s",
 				event.TransferID, event.FromUserID, event.FailureReason)
 
@@ -185,7 +278,7 @@ func (c *Consumer) consumeTransferFailed(ctx context.Context) {
 
 			// Create notification for sender about the failure
 			if event.FromUserID > 0 {
-				_, err = c.repo.CreateFromEvent(ctx,
+				_, err = c.createNotification(ctx,
 					event.FromUserID,
 					models.NotificationTypeTransferFailed,
 					models.ChannelEmail,
@@ -204,6 +297,84 @@ func (c *Consumer) consumeTransferFailed(ctx context.Context) {
 	}
 }
 
+// notifyTransferReversed tells both parties about a completed reversal. The reversal runs from the
+// original recipient (FromUserID) back to the original sender (ToUserID).
+func (c *Consumer) notifyTransferReversed(ctx context.Context, event models.TransferResultEvent) {
+	log.Printf("Creating notifications for reversal %d of transfer %d (from user %d to user %d)",
+		event.TransferID, event.ReversalOf, event.FromUserID, event.ToUserID)
+
+	metadata := map[string]interface{}{
+		"transfer_id":  event.ReversalOf,
+		"reversal_id":  event.TransferID,
+		"reference_id": event.ReferenceID,
+		"amount":       event.Amount,
+	}
+
+	if event.ToUserID > 0 {
+		_, err := c.createNotification(ctx,
+			event.ToUserID,
+			models.NotificationTypeTransferReversed,
+			models.ChannelEmail,
+			"Transfer Reversed",
+			fmt.Sprintf("Your transfer has been reversed and %s has been returned to your account (ref: %s).",
+				event.Amount.StringFixed(2), event.ReferenceID),
+			metadata,
+		)
+		if err != nil {
+			log.Printf("Error creating reversal notification for sender: %v", err)
+		}
+		c.simulateSendNotification("email", fmt.Sprintf("Transfer reversed notification for user %d", event.ToUserID))
+	}
+
+	if event.FromUserID > 0 {
+		_, err := c.createNotification(ctx,
+			event.FromUserID,
+			models.NotificationTypeTransferReversed,
+			models.ChannelEmail,
+			"Transfer Reversed",
+			fmt.Sprintf("A transfer you received has been reversed and %s has been debited from your account (ref: %s).",
+				event.Amount.StringFixed(2), event.ReferenceID),
+			metadata,
+		)
+		if err != nil {
+			log.Printf("Error creating reversal notification for recipient: %v", err)
+		}
+		c.simulateSendNotification("email", fmt.Sprintf("Transfer reversed notification for user %d", event.FromUserID))
+	}
+}
+
+// notifyReversalFailed tells the original recipient that a reversal against their account did not go
+// through, which includes their account being placed on hold
+func (c *Consumer) notifyReversalFailed(ctx context.Context, event models.TransferResultEvent) {
+	log.Printf("Creating notification for reversal %d of transfer %d failed (user %d): %s",
+		event.TransferID, event.ReversalOf, event.FromUserID, event.FailureReason)
+
+	if event.FromUserID <= 0 {
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"transfer_id":    event.ReversalOf,
+		"reversal_id":    event.TransferID,
+		"reference_id":   event.ReferenceID,
+		"failure_reason": event.FailureReason,
+	}
+
+	_, err := c.createNotification(ctx,
+		event.FromUserID,
+		models.NotificationTypeTransferReversed,
+		models.ChannelEmail,
+		"Transfer Reversal Not Completed",
+		fmt.Sprintf("A reversal of a transfer you received (ref: %s) could not be completed: %s",
+			event.ReferenceID, event.FailureReason),
+		metadata,
+	)
+	if err != nil {
+		log.Printf("Error creating reversal failure notification: %v", err)
+	}
+	c.simulateSendNotification("email", fmt.Sprintf("Transfer reversal failed notification for user %d", event.FromUserID))
+}
+
 func (c *Consumer) consumePaymentCompleted(ctx context.Context) {
 	log.Println("Starting payment.completed consumer for notifications")
 	for {
@@ -234,7 +405,7 @@ func (c *Consumer) consumePaymentCompleted(ctx context.Context) {
 				"reference_id": event.ReferenceID,
 			}
 
-			_, err = c.repo.CreateFromEvent(ctx,
+			_, err = c.createNotification(ctx,
 				1, // Placeholder: would be payer's user_id
 				models.NotificationTypePaymentProcessed,
 				models.ChannelEmail,
@@ -284,7 +455,7 @@ func (c *Consumer) consumePaymentFailed(ctx context.Context) {
 				"failure_reason": event.FailureReason,
 			}
 
-			_, err = c.repo.CreateFromEvent(ctx,
+			_, err = c.createNotification(ctx,
 				1, // Placeholder: would be payer's user_id
 				models.NotificationTypePaymentFailed,
 				models.ChannelEmail,
@@ -303,12 +474,391 @@ func (c *Consumer) consumePaymentFailed(ctx context.Context) {
 	}
 }
 
+func (c *Consumer) consumeMoneyRequests(ctx context.Context) {
+	log.Println("Starting money_request.updated consumer for notifications")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			msg, err := c.moneyRequestReader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Error fetching money request message: %v", err)
+				continue
+			}
+
+			var event models.MoneyRequestEvent
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("Error unmarshaling money request event: %v", err)
+				c.moneyRequestReader.CommitMessages(ctx, msg)
+				continue
+			}
+
+			c.notifyMoneyRequest(ctx, event)
+
+			c.moneyRequestReader.CommitMessages(ctx, msg)
+		}
+	}
+}
+
+// notifyMoneyRequest tells the customer who has to act, or who is waiting, about a money request change
+func (c *Consumer) notifyMoneyRequest(ctx context.Context, event models.MoneyRequestEvent) {
+	log.Printf("Creating notifications for money request %d %s (requester %d, payer %d)",
+		event.RequestID, event.Status, event.RequesterUserID, event.PayerUserID)
+
+	metadata := map[string]interface{}{
+		"request_id":   event.RequestID,
+		"reference_id": event.ReferenceID,
+		"amount":       event.Amount.StringFixed(2),
+		"currency":     event.Currency,
+	}
+	if event.TransferID != 0 {
+		metadata["transfer_id"] = event.TransferID
+	}
+
+	amount := event.Amount.StringFixed(2) + " " + event.Currency
+	note := ""
+	if event.Note != "" {
+		note = fmt.Sprintf(" for \"%s\"", event.Note)
+	}
+
+	type recipient struct {
+		userID  int64
+		title   string
+		content string
+	}
+	var recipients []recipient
+
+	switch event.Status {
+	case models.MoneyRequestStatusPending:
+		recipients = append(recipients, recipient{event.PayerUserID, "Payment Request Received",
+			fmt.Sprintf("You have been asked to pay %s%s. The request expires on %s.",
+				amount, note, event.ExpiresAt.Format("2006-01-02"))})
+	case models.MoneyRequestStatusAccepted:
+		recipients = append(recipients, recipient{event.RequesterUserID, "Payment Request Accepted",
+			fmt.Sprintf("Your request for %s%s was accepted and the transfer is on its way.", amount, note)})
+	case models.MoneyRequestStatusDeclined:
+		content := fmt.Sprintf("Your request for %s%s was declined.", amount, note)
+		if event.DeclineReason != "" {
+			content = fmt.Sprintf("Your request for %s%s was declined: %s", amount, note, event.DeclineReason)
+		}
+		recipients = append(recipients, recipient{event.RequesterUserID, "Payment Request Declined", content})
+	case models.MoneyRequestStatusCancelled:
+		recipients = append(recipients, recipient{event.PayerUserID, "Payment Request Cancelled",
+			fmt.Sprintf("A request for you to pay %s%s was cancelled.", amount, note)})
+	case models.MoneyRequestStatusExpired:
+		recipients = append(recipients,
+			recipient{event.RequesterUserID, "Payment Request Expired",
+				fmt.Sprintf("Your request for %s%s expired without a response.", amount, note)},
+			recipient{event.PayerUserID, "Payment Request Expired",
+				fmt.Sprintf("A request for you to pay %s%s has expired.", amount, note)})
+	default:
+		log.Printf("Ignoring money request %d event with unknown status %q", event.RequestID, event.Status)
+		return
+	}
+
+	for _, r := range recipients {
+		if r.userID <= 0 {
+			continue
+		}
+		_, err := c.createNotification(ctx,
+			r.userID,
+			models.NotificationTypeMoneyRequest,
+			models.ChannelPush,
+			r.title,
+			r.content,
+			metadata,
+		)
+		if err != nil {
+			log.Printf("Error creating money request notification for user %d: %v", r.userID, err)
+		}
+		c.simulateSendNotification("push", fmt.Sprintf("%s notification for user %d", r.title, r.userID))
+	}
+}
+
+func (c *Consumer) consumeSplitReminders(ctx context.Context) {
+	log.Println("Starting bill_split.reminder consumer for notifications")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			msg, err := c.splitReminderReader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Error fetching split reminder message: %v", err)
+				continue
+			}
+
+			var event models.SplitReminderEvent
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("Error unmarshaling split reminder event: %v", err)
+				c.splitReminderReader.CommitMessages(ctx, msg)
+				continue
+			}
+
+			log.Printf("Creating reminder for share %d of split %d (user %d)", event.ShareID, event.SplitID, event.UserID)
+
+			metadata := map[string]interface{}{
+				"split_id": event.SplitID,
+				"share_id": event.ShareID,
+				"amount":   event.Amount.StringFixed(2),
+				"currency": event.Currency,
+			}
+
+			what := "a shared bill"
+			if event.Description != "" {
+				what = fmt.Sprintf("\"%s\"", event.Description)
+			}
+
+			_, err = c.createNotification(ctx,
+				event.UserID,
+				models.NotificationTypeSplitReminder,
+				models.ChannelPush,
+				"Payment Reminder",
+				fmt.Sprintf("You still owe %s %s for %s. Pay your share from the split to settle up.",
+					event.Amount.StringFixed(2), event.Currency, what),
+				metadata,
+			)
+			if err != nil {
+				log.Printf("Error creating split reminder notification: %v", err)
+			}
+
+			c.simulateSendNotification("push", fmt.Sprintf("Split reminder for user %d", event.UserID))
+
+			c.splitReminderReader.CommitMessages(ctx, msg)
+		}
+	}
+}
+
+func (c *Consumer) consumeInboundCredits(ctx context.Context) {
+	log.Println("Starting payment.inbound_credited consumer for notifications")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			msg, err := c.inboundCreditReader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Error fetching inbound credit message: %v", err)
+				continue
+			}
+
+			var event models.InboundCreditEvent
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("Error unmarshaling inbound credit event: %v", err)
+				c.inboundCreditReader.CommitMessages(ctx, msg)
+				continue
+			}
+
+			log.Printf("Creating notification for inbound credit %d to account %d (user %d)", event.CreditID, event.AccountID, event.UserID)
+
+			metadata := map[string]interface{}{
+				"credit_id":    event.CreditID,
+				"reference_id": event.ReferenceID,
+				"account_id":   event.AccountID,
+				"amount":       event.Amount.StringFixed(2),
+				"currency":     event.Currency,
+			}
+
+			from := "another bank"
+			if event.DebtorName != "" {
+				from = event.DebtorName
+			}
+			content := fmt.Sprintf("You received %s %s from %s.", event.Amount.StringFixed(2), event.Currency, from)
+			if event.Remittance != "" {
+				content = fmt.Sprintf("You received %s %s from %s: \"%s\"", event.Amount.StringFixed(2), event.Currency, from, event.Remittance)
+			}
+
+			_, err = c.createNotification(ctx,
+				event.UserID,
+				models.NotificationTypeTransferReceived,
+				models.ChannelPush,
+				"Incoming Transfer",
+				content,
+				metadata,
+			)
+			if err != nil {
+				log.Printf("Error creating inbound credit notification: %v", err)
+			}
+
+			c.simulateSendNotification("push", fmt.Sprintf("Incoming transfer notification for user %d", event.UserID))
+
+			c.inboundCreditReader.CommitMessages(ctx, msg)
+		}
+	}
+}
+
+func (c *Consumer) consumeRefunds(ctx context.Context) {
+	log.Println("Starting payment.refunded consumer for notifications")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			msg, err := c.refundReader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Error fetching refund message: %v", err)
+				continue
+			}
+
+			var event models.RefundEvent
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("Error unmarshaling refund event: %v", err)
+				c.refundReader.CommitMessages(ctx, msg)
+				continue
+			}
+
+			log.Printf("Creating notification for refund %d of payment %d (user %d)", event.RefundID, event.PaymentID, event.UserID)
+
+			metadata := map[string]interface{}{
+				"refund_id":    event.RefundID,
+				"payment_id":   event.PaymentID,
+				"reference_id": event.ReferenceID,
+				"account_id":   event.AccountID,
+				"amount":       event.Amount.StringFixed(2),
+				"currency":     event.Currency,
+			}
+
+			content := fmt.Sprintf("%s %s from your %s payment has been refunded to your account.",
+				event.Amount.StringFixed(2), event.Currency, event.PaymentType)
+			if event.RecipientName != "" {
+				content = fmt.Sprintf("%s %s from your %s payment to %s has been refunded to your account.",
+					event.Amount.StringFixed(2), event.Currency, event.PaymentType, event.RecipientName)
+			}
+
+			_, err = c.createNotification(ctx,
+				event.UserID,
+				models.NotificationTypePaymentRefunded,
+				models.ChannelPush,
+				"Payment Refunded",
+				content,
+				metadata,
+			)
+			if err != nil {
+				log.Printf("Error creating refund notification: %v", err)
+			}
+
+			c.simulateSendNotification("push", fmt.Sprintf("Refund notification for user %d", event.UserID))
+
+			c.refundReader.CommitMessages(ctx, msg)
+		}
+	}
+}
+
+func (c *Consumer) consumeOTPRequests(ctx context.Context) {
+	log.Println("Starting auth.otp_requested consumer for notifications")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			msg, err := c.otpReader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Error fetching OTP request message: %v", err)
+				continue
+			}
+
+			var event models.OTPRequestedEvent
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("Error unmarshaling OTP request event: %v", err)
+				c.otpReader.CommitMessages(ctx, msg)
+				continue
+			}
+
+			// A code that has already expired (e.g. replayed after a restart) is useless to the customer
+			if time.Now().After(event.ExpiresAt) {
+				log.Printf("Skipping expired verification code for challenge %s", event.ChallengeID)
+				c.otpReader.CommitMessages(ctx, msg)
+				continue
+			}
+
+			c.deliverOTP(ctx, event)
+
+			c.otpReader.CommitMessages(ctx, msg)
+		}
+	}
+}
+
+// deliverOTP sends a step-up code out of band. The stored notification only records that a code
+// was sent: anyone holding the customer's session can read notifications and the live stream,
+// so the code itself must never reach either.
+func (c *Consumer) deliverOTP(ctx context.Context, event models.OTPRequestedEvent) {
+	channel := models.ChannelEmail
+	if event.Channel == models.ChannelSMS {
+		channel = models.ChannelSMS
+	}
+
+	c.simulateSendNotification(channel, fmt.Sprintf("To %s: your verification code is %s. It expires at %s. Never share it with anyone.",
+		event.Destination, event.Code, event.ExpiresAt.Format("15:04 MST")))
+
+	metadata := map[string]interface{}{
+		"challenge_id": event.ChallengeID,
+		"operation":    event.Operation,
+	}
+
+	_, err := c.createNotification(ctx,
+		event.UserID,
+		models.NotificationTypeVerificationCode,
+		channel,
+		"Verification Code Sent",
+		fmt.Sprintf("A verification code was sent to %s to confirm a %s request. If this wasn't you, change your password.",
+			maskDestination(event.Destination), strings.ReplaceAll(event.Operation, "_", " ")),
+		metadata,
+	)
+	if err != nil {
+		log.Printf("Error creating verification code notification: %v", err)
+	}
+}
+
+// maskDestination hides all but the last few characters of a phone number or email address
+func maskDestination(destination string) string {
+	if at := strings.Index(destination, "@"); at > 0 {
+		return destination[:1] + "***" + destination[at:]
+	}
+	if len(destination) <= 4 {
+		return "***"
+	}
+	return "***" + destination[len(destination)-4:]
+}
+
 // simulateSendNotification simulates sending a notification via a channel
 func (c *Consumer) simulateSendNotification(channel, message string) {
 	log.Printf("[SIMULATED %s] Sending: %s", channel, message)
 	// In a real system, this would call an email/SMS service
 }
 
+// createNotification stores a notification and announces it on notification.created. A failed
+// publish only costs the real-time stream its update, so it is logged rather than returned.
+func (c *Consumer) createNotification(ctx context.Context, userID int64, notifType, channel, title, content string, metadata map[string]interface{}) (*models.Notification, error) {
+	notification, err := c.repo.CreateFromEvent(ctx, userID, notifType, channel, title, content, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.producer != nil {
+		if err := c.producer.PublishNotificationCreated(ctx, notification); err != nil {
+			log.Printf("Failed to publish notification.created for notification %d: %v", notification.ID, err)
+		}
+	}
+
+	return notification, nil
+}
+
 // Close closes all readers
 func (c *Consumer) Close() error {
 	if err := c.transferCompletedReader.Close(); err != nil {
@@ -320,7 +870,22 @@ func (c *Consumer) Close() error {
 	if err := c.paymentCompletedReader.Close(); err != nil {
 		return err
 	}
-	return c.paymentFailedReader.Close()
+	if err := c.paymentFailedReader.Close(); err != nil {
+		return err
+	}
+	if err := c.moneyRequestReader.Close(); err != nil {
+		return err
+	}
+	if err := c.splitReminderReader.Close(); err != nil {
+		return err
+	}
+	if err := c.otpReader.Close(); err != nil {
+		return err
+	}
+	if err := c.inboundCreditReader.Close(); err != nil {
+		return err
+	}
+	return c.refundReader.Close()
 }
 
 // EnsureTopicExists creates the topic if it doesn't exist
@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"notification-service/models"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const TopicNotificationCreated = "notification.created"
+
+// Producer announces newly created notifications so the API gateway can stream them
+type Producer struct {
+	writer *kafka.Writer
+}
+
+func NewProducer(brokers []string) *Producer {
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicNotificationCreated,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireOne,
+		Async:        false,
+	}
+
+	return &Producer{writer: writer}
+}
+
+// PublishNotificationCreated publishes a notification created event
+func (p *Producer) PublishNotificationCreated(ctx context.Context, notification *models.Notification) error {
+	event := models.NotificationCreatedEvent{
+		NotificationID: notification.ID,
+		UserID:         notification.UserID,
+		Type:           notification.Type,
+		Title:          notification.Title,
+		Content:        notification.Content,
+		CreatedAt:      notification.CreatedAt,
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(fmt.Sprintf("%d", notification.UserID)),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(TopicNotificationCreated)},
+		},
+	}
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Producer) Close() error {
+	return p.writer.Close()
+}
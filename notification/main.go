@@ -77,14 +77,24 @@ func main() {
 	// Initialize Kafka
 	kafkaBrokers := strings.Split(getEnv("KAFKA_BROKERS", "localhost:9092"), ",")
 
-	// Ensure topics exist (the notification service only consumes, doesn't produce)
+	// Ensure topics exist
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicTransferCompleted)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicTransferFailed)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicPaymentCompleted)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicPaymentFailed)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicMoneyRequest)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicSplitReminder)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicOTPRequested)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicInboundCredited)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicRefunded)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicNotificationCreated)
+
+	// Initialize producer for notification.created, consumed by the API gateway stream
+	kafkaProducer := kafka.NewProducer(kafkaBrokers)
+	defer kafkaProducer.Close()
 
 	// Initialize consumer
-	kafkaConsumer = kafka.NewConsumer(kafkaBrokers, "notification-service", notificationRepo)
+	kafkaConsumer = kafka.NewConsumer(kafkaBrokers, "notification-service", notificationRepo, kafkaProducer)
 	kafkaConsumer.Start(ctx)
 	defer kafkaConsumer.Close()
 
@@ -12,8 +12,13 @@ const (
 	NotificationTypeTransferSent       = "transfer_sent"
 	NotificationTypeTransferReceived   = "transfer_received"
 	NotificationTypeTransferFailed     = "transfer_failed"
+	NotificationTypeTransferReversed   = "transfer_reversed"
 	NotificationTypePaymentProcessed   = "payment_processed"
 	NotificationTypePaymentFailed      = "payment_failed"
+	NotificationTypePaymentRefunded    = "payment_refunded"
+	NotificationTypeMoneyRequest       = "money_request"
+	NotificationTypeSplitReminder      = "split_reminder"
+	NotificationTypeVerificationCode   = "verification_code"
 	NotificationTypeAccountCreated     = "account_created"
 	NotificationTypeAccountFrozen      = "account_frozen"
 	NotificationTypeLowBalance         = "low_balance"
@@ -76,14 +81,17 @@ type TransferEvent struct {
 
 // TransferResultEvent represents the result of a transfer
 type TransferResultEvent struct {
-	TransferID    int64  `json:"transfer_id"`
-	ReferenceID   string `json:"reference_id"`
-	Status        string `json:"status"`
-	FailureReason string `json:"failure_reason,omitempty"`
-	FromAccountID int64  `json:"from_account_id,omitempty"`
-	ToAccountID   int64  `json:"to_account_id,omitempty"`
-	FromUserID    int64  `json:"from_user_id,omitempty"`
-	ToUserID      int64  `json:"to_user_id,omitempty"`
+	TransferID    int64           `json:"transfer_id"`
+	ReferenceID   string          `json:"reference_id"`
+	Status        string          `json:"status"`
+	FailureReason string          `json:"failure_reason,omitempty"`
+	FromAccountID int64           `json:"from_account_id,omitempty"`
+	ToAccountID   int64           `json:"to_account_id,omitempty"`
+	FromUserID    int64           `json:"from_user_id,omitempty"`
+	ToUserID      int64           `json:"to_user_id,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	ReversalOf    int64           `json:"reversal_of,omitempty"`
 }
 
 // PaymentEvent represents a Kafka event for payments
@@ -106,3 +114,88 @@ type PaymentResultEvent struct {
 	Status        string `json:"status"`
 	FailureReason string `json:"failure_reason,omitempty"`
 }
+
+// Money request statuses carried by money request events
+const (
+	MoneyRequestStatusPending   = "pending"
+	MoneyRequestStatusAccepted  = "accepted"
+	MoneyRequestStatusDeclined  = "declined"
+	MoneyRequestStatusCancelled = "cancelled"
+	MoneyRequestStatusExpired   = "expired"
+)
+
+// MoneyRequestEvent is published by the transfer service when a money request is created or settled
+type MoneyRequestEvent struct {
+	RequestID       int64           `json:"request_id"`
+	ReferenceID     string          `json:"reference_id"`
+	Status          string          `json:"status"`
+	RequesterUserID int64           `json:"requester_user_id"`
+	PayerUserID     int64           `json:"payer_user_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        string          `json:"currency"`
+	Note            string          `json:"note,omitempty"`
+	DeclineReason   string          `json:"decline_reason,omitempty"`
+	TransferID      int64           `json:"transfer_id,omitempty"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+}
+
+// InboundCreditEvent is published by the payment service when an incoming credit transfer from
+// another bank reaches a customer's account
+type InboundCreditEvent struct {
+	CreditID    int64           `json:"credit_id"`
+	ReferenceID string          `json:"reference_id"`
+	AccountID   int64           `json:"account_id"`
+	UserID      int64           `json:"user_id"`
+	Amount      decimal.Decimal `json:"amount"`
+	Currency    string          `json:"currency"`
+	DebtorName  string          `json:"debtor_name,omitempty"`
+	Remittance  string          `json:"remittance_info,omitempty"`
+	CreditedAt  time.Time       `json:"credited_at"`
+}
+
+// RefundEvent is published by the account service when a refund of a payment is credited back
+// to the payer's account
+type RefundEvent struct {
+	RefundID      int64           `json:"refund_id"`
+	ReferenceID   string          `json:"reference_id"`
+	PaymentID     int64           `json:"payment_id"`
+	AccountID     int64           `json:"account_id"`
+	UserID        int64           `json:"user_id"`
+	PaymentType   string          `json:"payment_type"`
+	RecipientName string          `json:"recipient_name,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+}
+
+// SplitReminderEvent is published by the transfer service for an unpaid share of a bill split
+type SplitReminderEvent struct {
+	SplitID       int64           `json:"split_id"`
+	ShareID       int64           `json:"share_id"`
+	UserID        int64           `json:"user_id"`
+	OwnerUserID   int64           `json:"owner_user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	Description   string          `json:"description,omitempty"`
+	RemindersSent int             `json:"reminders_sent"`
+}
+
+// OTPRequestedEvent is published by the user service when a step-up challenge needs a code delivered
+type OTPRequestedEvent struct {
+	ChallengeID string    `json:"challenge_id"`
+	UserID      int64     `json:"user_id"`
+	Operation   string    `json:"operation"`
+	Channel     string    `json:"channel"`
+	Destination string    `json:"destination"`
+	Code        string    `json:"code"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// NotificationCreatedEvent is published for every notification created from an event
+type NotificationCreatedEvent struct {
+	NotificationID int64     `json:"notification_id"`
+	UserID         int64     `json:"user_id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
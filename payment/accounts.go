@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"payment/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// Operation types the account service records credits from the payment service under
+const (
+	accountOperationInboundCredit = "inbound_credit"
+	accountOperationPaymentReturn = "payment_return"
+	accountOperationPaymentRefund = "payment_refund"
+)
+
+// getAccountByNumber looks an account up by account number. The account service's HTTP status
+// is returned alongside any error.
+func getAccountByNumber(number string) (*models.AccountSummary, int, error) {
+	return getAccountSummary("/api/accounts/by-number/" + url.PathEscape(number))
+}
+
+// getAccountByID fetches an account. The account service's HTTP status is returned alongside any error.
+func getAccountByID(accountID int64) (*models.AccountSummary, int, error) {
+	return getAccountSummary(fmt.Sprintf("/api/accounts/%d", accountID))
+}
+
+// requireOwnAccount checks that the account a payment would debit belongs to the caller,
+// responding with an error if it does not
+func requireOwnAccount(c *gin.Context, userID, accountID int64) bool {
+	account, status, err := getAccountByID(accountID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+			return false
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to look up account"})
+		return false
+	}
+	if account.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return false
+	}
+	return true
+}
+
+func getAccountSummary(path string) (*models.AccountSummary, int, error) {
+	accountServiceURL := getEnv("ACCOUNT_SERVICE_URL", "http://account.account.svc.cluster.local:8080")
+
+	req, err := http.NewRequest("GET", accountServiceURL+path, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-User-ID", "0")
+	req.Header.Set("X-User-Role", "admin")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to call account service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("account service returned status %d", resp.StatusCode)
+	}
+
+	var account models.AccountSummary
+	if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &account, resp.StatusCode, nil
+}
+
+// creditAccount credits funds arriving from clearing, or refunded, to an account. The account service applies a
+// reference ID at most once, so a retried credit is safe. Its HTTP status is returned alongside any error.
+func creditAccount(accountID int64, amount decimal.Decimal, currency, referenceID, operationType string, sourceID int64) (int, error) {
+	accountServiceURL := getEnv("ACCOUNT_SERVICE_URL", "http://account.account.svc.cluster.local:8080")
+
+	body, err := json.Marshal(gin.H{
+		"reference_id":   referenceID,
+		"operation_type": operationType,
+		"source_id":      sourceID,
+		"amount":         amount,
+		"currency":       currency,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal credit: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/accounts/%d/credit", accountServiceURL, accountID), bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", "0")
+	req.Header.Set("X-User-Role", "admin")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call account service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error != "" {
+			return resp.StatusCode, fmt.Errorf("account service: %s", errResp.Error)
+		}
+		return resp.StatusCode, fmt.Errorf("account service returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
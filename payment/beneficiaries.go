@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"payment/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// getBeneficiary fetches a saved beneficiary from the transfer service as the given user, so the
+// transfer service enforces ownership. Its HTTP status is returned alongside any error.
+func getBeneficiary(beneficiaryID, userID int64, role string) (*models.Beneficiary, int, error) {
+	transferServiceURL := getEnv("TRANSFER_SERVICE_URL", "http://transfer.transfer.svc.cluster.local:8080")
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/transfers/beneficiaries/%d", transferServiceURL, beneficiaryID), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("X-User-ID", strconv.FormatInt(userID, 10))
+	req.Header.Set("X-User-Role", role)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to call transfer service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("transfer service returned status %d", resp.StatusCode)
+	}
+
+	var beneficiary models.Beneficiary
+	if err := json.NewDecoder(resp.Body).Decode(&beneficiary); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &beneficiary, resp.StatusCode, nil
+}
+
+// applyBeneficiary fills an external payment's recipient details from the saved beneficiary it
+// names. Requests without a beneficiary pass through; applyCoolingOff caps both kinds.
+func applyBeneficiary(c *gin.Context, userID int64, role string, req *models.CreatePaymentRequest) bool {
+	if req.BeneficiaryID == nil {
+		return true
+	}
+
+	if req.PaymentType != models.PaymentTypeExternal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "beneficiary_id is only supported for external payments"})
+		return false
+	}
+
+	beneficiary, status, err := getBeneficiary(*req.BeneficiaryID, userID, role)
+	if err != nil {
+		log.Printf("Failed to look up beneficiary %d: %v", *req.BeneficiaryID, err)
+		switch status {
+		case http.StatusForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case http.StatusNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "beneficiary not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up beneficiary"})
+		}
+		return false
+	}
+
+	switch beneficiary.Type {
+	case "iban":
+		req.RecipientAccount = beneficiary.IBAN
+		req.RecipientBank = beneficiary.BankCode
+	case "external":
+		req.RecipientAccount = beneficiary.AccountNumber
+		req.RecipientBank = beneficiary.BankName
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "internal beneficiaries are paid with a transfer, not an external payment"})
+		return false
+	}
+	req.RecipientName = beneficiary.RecipientName
+
+	return true
+}
+
+// getCoolingOff asks the transfer service, as the given user, whether an account at another bank
+// is a beneficiary of theirs still in its cooling-off period
+func getCoolingOff(account string, userID int64, role string) (*models.CoolingOffStatus, error) {
+	transferServiceURL := getEnv("TRANSFER_SERVICE_URL", "http://transfer.transfer.svc.cluster.local:8080")
+
+	req, err := http.NewRequest("GET", transferServiceURL+"/api/transfers/beneficiaries/cooling-off?account="+url.QueryEscape(account), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("X-User-ID", strconv.FormatInt(userID, 10))
+	req.Header.Set("X-User-Role", role)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call transfer service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transfer service returned status %d", resp.StatusCode)
+	}
+
+	var status models.CoolingOffStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &status, nil
+}
+
+// applyCoolingOff caps what an external payment may send to a destination the user saved as a
+// beneficiary while it is cooling off: everything paid to it since the beneficiary was added,
+// this payment included, must fit the cap. It applies by destination, whether or not the payment
+// names the beneficiary.
+func applyCoolingOff(c *gin.Context, userID int64, role string, req *models.CreatePaymentRequest) bool {
+	status, err := getCoolingOff(*req.RecipientAccount, userID, role)
+	if err != nil {
+		log.Printf("Failed to check beneficiary cooling-off for user %d: %v", userID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to check beneficiary cooling-off"})
+		return false
+	}
+	if !status.InCoolingOff || status.Since == nil || status.MaxAmount == nil {
+		return true
+	}
+
+	sent, err := paymentRepo.SumExternalSentTo(c.Request.Context(), userID, *req.RecipientAccount, *status.Since)
+	if err != nil {
+		log.Printf("Failed to sum payments for beneficiary cooling-off: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check beneficiary cooling-off"})
+		return false
+	}
+
+	if sent.Add(req.Amount).GreaterThan(*status.MaxAmount) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":             "amount exceeds the limit for a newly added beneficiary",
+			"code":              "beneficiary_cooling_off_limit_exceeded",
+			"max_amount":        status.MaxAmount,
+			"remaining":         decimal.Max(status.MaxAmount.Sub(sent), decimal.Zero),
+			"cooling_off_until": status.CoolingOffUntil,
+		})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listBillers lists the biller catalog, optionally filtered by category. Customers see active
+// billers only and without their settlement accounts; admins see every biller.
+func listBillers(c *gin.Context) {
+	_, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	category := c.Query("category")
+	switch category {
+	case "", models.BillerCategoryUtility, models.BillerCategoryTelecom, models.BillerCategoryTax:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category"})
+		return
+	}
+
+	isAdmin := role == "admin"
+	result, err := billerRepo.List(c.Request.Context(), category, !isAdmin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list billers"})
+		return
+	}
+
+	if !isAdmin {
+		for i := range result.Billers {
+			result.Billers[i].SettlementAccountID = 0
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getBiller returns a biller. Inactive billers are only visible to admins.
+func getBiller(c *gin.Context) {
+	_, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	biller, ok := loadBiller(c)
+	if !ok {
+		return
+	}
+
+	if role != "admin" {
+		if !biller.Active {
+			c.JSON(http.StatusNotFound, gin.H{"error": "biller not found"})
+			return
+		}
+		biller.SettlementAccountID = 0
+	}
+
+	c.JSON(http.StatusOK, biller)
+}
+
+// createBiller adds a biller to the catalog (admin only)
+func createBiller(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req models.CreateBillerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	biller := models.Biller{
+		Code:                strings.ToUpper(req.Code),
+		Name:                req.Name,
+		Category:            req.Category,
+		ReferenceLabel:      req.ReferenceLabel,
+		ReferencePattern:    req.ReferencePattern,
+		CheckDigit:          req.CheckDigit,
+		MinAmount:           req.MinAmount,
+		MaxAmount:           req.MaxAmount,
+		Currency:            strings.ToUpper(req.Currency),
+		SettlementAccountID: req.SettlementAccountID,
+		Active:              true,
+	}
+	if biller.CheckDigit == "" {
+		biller.CheckDigit = models.CheckDigitNone
+	}
+
+	if !validateBiller(c, &biller) {
+		return
+	}
+
+	created, err := billerRepo.Create(c.Request.Context(), &biller)
+	if err != nil {
+		respondBillerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// updateBiller changes a biller's details, limits or settlement account (admin only)
+func updateBiller(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	existing, ok := loadBiller(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateBillerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	biller := req.Apply(*existing)
+	if !validateBiller(c, &biller) {
+		return
+	}
+
+	updated, err := billerRepo.Update(c.Request.Context(), &biller)
+	if err != nil {
+		respondBillerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// deleteBiller removes a biller that has never been paid (admin only)
+func deleteBiller(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	billerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid biller ID"})
+		return
+	}
+
+	if err := billerRepo.Delete(c.Request.Context(), billerID); err != nil {
+		respondBillerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "biller deleted"})
+}
+
+// validateBiller checks the biller's rules and that its settlement account can receive its currency
+func validateBiller(c *gin.Context, biller *models.Biller) bool {
+	if err := biller.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	account, status, err := getAccountByID(biller.SettlementAccountID)
+	if err != nil {
+		log.Printf("Failed to look up settlement account %d: %v", biller.SettlementAccountID, err)
+		if status == http.StatusNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "settlement account not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up settlement account"})
+		}
+		return false
+	}
+
+	if account.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("settlement account is %s", account.Status)})
+		return false
+	}
+	if account.Currency != biller.Currency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("settlement account is in %s, biller collects %s", account.Currency, biller.Currency)})
+		return false
+	}
+
+	return true
+}
+
+// applyBiller validates a bill payment against its biller's rules and addresses it to the biller's
+// settlement account. The customer reference travels in recipient_account.
+func applyBiller(c *gin.Context, req *models.CreatePaymentRequest) bool {
+	if req.BillerID == nil {
+		if req.PaymentType == models.PaymentTypeBill {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "biller_id required for bill payments"})
+			return false
+		}
+		return true
+	}
+
+	if req.PaymentType != models.PaymentTypeBill {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "biller_id is only supported for bill payments"})
+		return false
+	}
+
+	if req.RecipientAccount == nil || *req.RecipientAccount == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_account (customer reference) required for bill payments"})
+		return false
+	}
+
+	biller, err := billerRepo.GetByID(c.Request.Context(), *req.BillerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBillerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "biller not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up biller"})
+		return false
+	}
+
+	if req.Currency == "" {
+		req.Currency = biller.Currency
+	}
+	reference := strings.TrimSpace(*req.RecipientAccount)
+	if err := biller.ValidatePayment(reference, req.Amount, strings.ToUpper(req.Currency)); err != nil {
+		if errors.Is(err, models.ErrBillerUnavailable) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	req.Currency = biller.Currency
+	req.RecipientAccount = &reference
+	req.RecipientName = &biller.Name
+	req.RecipientBank = nil
+	req.SettlementAccountID = &biller.SettlementAccountID
+	req.Category = models.CategoryForBiller(biller.Category)
+	return true
+}
+
+func loadBiller(c *gin.Context) (*models.Biller, bool) {
+	billerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid biller ID"})
+		return nil, false
+	}
+
+	biller, err := billerRepo.GetByID(c.Request.Context(), billerID)
+	if err != nil {
+		respondBillerError(c, err)
+		return nil, false
+	}
+
+	return biller, true
+}
+
+func respondBillerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrBillerNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "biller not found"})
+	case errors.Is(err, repository.ErrBillerCodeExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "biller code already exists"})
+	case errors.Is(err, repository.ErrBillerInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "biller has payments; deactivate it instead"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save biller"})
+	}
+}
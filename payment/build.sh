@@ -12,9 +12,9 @@ echo "====================================="
 DOCKER_USERNAME="kamilbabayev"
 IMAGE_NAME="${DOCKER_USERNAME}/demo-bank:payment"
 
-# Step 1: Build the Docker image
+# Step 1: Build the Docker image from the repository root, which holds the shared receipt module
 echo "Building Docker image..."
-docker build -t ${IMAGE_NAME} .
+docker build -f Dockerfile -t ${IMAGE_NAME} ..
 
 # Step 2: Push to Docker Hub
 echo ""
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"payment/models"
+	"payment/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Operators change rarely and are read on every mobile payment; writes invalidate the lists, so
+// the TTL only bounds how long another replica's write can go unseen if invalidation fails
+const mobileOperatorListTTL = 10 * time.Minute
+
+const (
+	keyMobileOperatorsActive = "mobile_operator:list:active"
+	keyMobileOperatorsAll    = "mobile_operator:list:all"
+)
+
+// CachedMobileOperatorRepository wraps a MobileOperatorRepository with Redis caching.
+type CachedMobileOperatorRepository struct {
+	repo  *repository.MobileOperatorRepository
+	redis *redis.Client
+}
+
+// NewCachedMobileOperatorRepository creates a new cached mobile operator repository.
+func NewCachedMobileOperatorRepository(repo *repository.MobileOperatorRepository, redisClient *redis.Client) *CachedMobileOperatorRepository {
+	return &CachedMobileOperatorRepository{
+		repo:  repo,
+		redis: redisClient,
+	}
+}
+
+// Create delegates to the underlying repo and invalidates the operator lists.
+func (c *CachedMobileOperatorRepository) Create(ctx context.Context, op *models.MobileOperator) (*models.MobileOperator, error) {
+	created, err := c.repo.Create(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx)
+	return created, nil
+}
+
+// GetByID is not cached; it is only used by the admin endpoints.
+func (c *CachedMobileOperatorRepository) GetByID(ctx context.Context, id int64) (*models.MobileOperator, error) {
+	return c.repo.GetByID(ctx, id)
+}
+
+// List checks cache first, falls back to DB.
+func (c *CachedMobileOperatorRepository) List(ctx context.Context, activeOnly bool) ([]models.MobileOperator, error) {
+	key := keyMobileOperatorsAll
+	if activeOnly {
+		key = keyMobileOperatorsActive
+	}
+
+	data, err := c.redis.Get(ctx, key).Bytes()
+	if err == nil {
+		var operators []models.MobileOperator
+		if json.Unmarshal(data, &operators) == nil {
+			return operators, nil
+		}
+	}
+
+	operators, err := c.repo.List(ctx, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(operators); err != nil {
+		log.Printf("cache: failed to marshal %s: %v", key, err)
+	} else if err := c.redis.Set(ctx, key, data, mobileOperatorListTTL).Err(); err != nil {
+		log.Printf("cache: failed to set %s: %v", key, err)
+	}
+	return operators, nil
+}
+
+// Update delegates to the underlying repo and invalidates the operator lists.
+func (c *CachedMobileOperatorRepository) Update(ctx context.Context, op *models.MobileOperator) (*models.MobileOperator, error) {
+	updated, err := c.repo.Update(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx)
+	return updated, nil
+}
+
+// Delete delegates to the underlying repo and invalidates the operator lists.
+func (c *CachedMobileOperatorRepository) Delete(ctx context.Context, id int64) error {
+	if err := c.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	c.invalidate(ctx)
+	return nil
+}
+
+func (c *CachedMobileOperatorRepository) invalidate(ctx context.Context) {
+	if err := c.redis.Del(ctx, keyMobileOperatorsActive, keyMobileOperatorsAll).Err(); err != nil {
+		log.Printf("cache: failed to delete keys: %v", err)
+	}
+}
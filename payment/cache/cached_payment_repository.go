@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 )
 
 const (
@@ -173,9 +174,57 @@ func (c *CachedPaymentRepository) ListAll(ctx context.Context, limit, offset int
 	return result, nil
 }
 
+// ListStatusHistory delegates directly; history is append-only and rarely read.
+func (c *CachedPaymentRepository) ListStatusHistory(ctx context.Context, id int64) ([]models.StatusTransition, error) {
+	return c.repo.ListStatusHistory(ctx, id)
+}
+
+// ListStale delegates directly; the sweeper must see current state.
+func (c *CachedPaymentRepository) ListStale(ctx context.Context, status string, before time.Time, limit int) ([]models.Payment, error) {
+	return c.repo.ListStale(ctx, status, before, limit)
+}
+
+// SumExternalSentTo delegates directly; cooling-off caps must see current state.
+func (c *CachedPaymentRepository) SumExternalSentTo(ctx context.Context, userID int64, recipientAccount string, since time.Time) (decimal.Decimal, error) {
+	return c.repo.SumExternalSentTo(ctx, userID, recipientAccount, since)
+}
+
+// RecordSagaRetry delegates to repo and invalidates the payment.
+func (c *CachedPaymentRepository) RecordSagaRetry(ctx context.Context, id int64) (*models.Payment, error) {
+	payment, err := c.repo.RecordSagaRetry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidatePayment(ctx, payment)
+	return payment, nil
+}
+
+// SetCategory delegates to repo and invalidates the payment.
+func (c *CachedPaymentRepository) SetCategory(ctx context.Context, id int64, category string) (*models.Payment, error) {
+	payment, err := c.repo.SetCategory(ctx, id, category)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidatePayment(ctx, payment)
+	return payment, nil
+}
+
 // UpdateStatus delegates to repo and invalidates affected caches.
-func (c *CachedPaymentRepository) UpdateStatus(ctx context.Context, id int64, status string, failureReason *string) (*models.Payment, error) {
-	payment, err := c.repo.UpdateStatus(ctx, id, status, failureReason)
+func (c *CachedPaymentRepository) UpdateStatus(ctx context.Context, id int64, status string, failureReason *string, cause string) (*models.Payment, error) {
+	payment, err := c.repo.UpdateStatus(ctx, id, status, failureReason, cause)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidatePayment(ctx, payment)
+	return payment, nil
+}
+
+// MarkSubmitted records a provider submission and invalidates caches.
+func (c *CachedPaymentRepository) MarkSubmitted(ctx context.Context, id int64, provider string, providerReference *string, cause string) (*models.Payment, error) {
+	payment, err := c.repo.MarkSubmitted(ctx, id, provider, providerReference, cause)
 	if err != nil {
 		return nil, err
 	}
@@ -196,8 +245,8 @@ func (c *CachedPaymentRepository) MarkAsProcessing(ctx context.Context, id int64
 }
 
 // MarkAsCompleted marks a payment as completed and invalidates caches.
-func (c *CachedPaymentRepository) MarkAsCompleted(ctx context.Context, id int64) (*models.Payment, error) {
-	payment, err := c.repo.MarkAsCompleted(ctx, id)
+func (c *CachedPaymentRepository) MarkAsCompleted(ctx context.Context, id int64, cause string) (*models.Payment, error) {
+	payment, err := c.repo.MarkAsCompleted(ctx, id, cause)
 	if err != nil {
 		return nil, err
 	}
@@ -207,8 +256,8 @@ func (c *CachedPaymentRepository) MarkAsCompleted(ctx context.Context, id int64)
 }
 
 // MarkAsFailed marks a payment as failed and invalidates caches.
-func (c *CachedPaymentRepository) MarkAsFailed(ctx context.Context, id int64, reason string) (*models.Payment, error) {
-	payment, err := c.repo.MarkAsFailed(ctx, id, reason)
+func (c *CachedPaymentRepository) MarkAsFailed(ctx context.Context, id int64, reason, cause string) (*models.Payment, error) {
+	payment, err := c.repo.MarkAsFailed(ctx, id, reason, cause)
 	if err != nil {
 		return nil, err
 	}
@@ -217,6 +266,24 @@ func (c *CachedPaymentRepository) MarkAsFailed(ctx context.Context, id int64, re
 	return payment, nil
 }
 
+// CreateClearingBatch delegates to repo and invalidates every payment moved into the batch.
+func (c *CachedPaymentRepository) CreateClearingBatch(ctx context.Context, messageID string, windowEnd time.Time, limit int) (*models.ClearingBatch, []models.Payment, error) {
+	batch, payments, err := c.repo.CreateClearingBatch(ctx, messageID, windowEnd, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range payments {
+		c.invalidatePayment(ctx, &payments[i])
+	}
+	return batch, payments, nil
+}
+
+// ListClearingBatchPayments delegates directly; it is only used to (re)build outbound messages.
+func (c *CachedPaymentRepository) ListClearingBatchPayments(ctx context.Context, batchID int64) ([]models.Payment, error) {
+	return c.repo.ListClearingBatchPayments(ctx, batchID)
+}
+
 // invalidatePayment invalidates all caches related to a payment.
 func (c *CachedPaymentRepository) invalidatePayment(ctx context.Context, payment *models.Payment) {
 	c.del(ctx, keyPaymentByID(payment.ID))
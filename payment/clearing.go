@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment/iso20022"
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// clearingConfig controls how external payments are submitted to interbank clearing
+type clearingConfig struct {
+	Interval      time.Duration  // length of a cut-off window
+	OutboundDir   string         // where pacs.008 messages are written for the clearing system to collect
+	Sender        iso20022.Agent // this bank, as instructing and debtor agent
+	MessagePrefix string         // prefix of generated pacs.008 message IDs
+	MaxBatchSize  int            // payments per message; a busy window produces several
+}
+
+// maxClearingMessageSize bounds an uploaded ISO 20022 document
+const maxClearingMessageSize = 10 << 20
+
+// runClearingCutoff closes a clearing window every interval, batching the external payments
+// completed during it into pacs.008 messages
+func runClearingCutoff(ctx context.Context, cfg clearingConfig) {
+	log.Printf("Starting clearing cut-off (interval %s, outbound %s)", cfg.Interval, cfg.OutboundDir)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping clearing cut-off")
+			return
+		case <-ticker.C:
+			if _, err := closeClearingWindow(ctx, cfg, time.Now()); err != nil {
+				log.Printf("Clearing cut-off failed: %v", err)
+			}
+		}
+	}
+}
+
+// closeClearingWindow first retries batches whose message was never written out, then moves every
+// external payment completed by windowEnd into new batches and writes their messages
+func closeClearingWindow(ctx context.Context, cfg clearingConfig, windowEnd time.Time) ([]models.ClearingBatch, error) {
+	resendPendingBatches(ctx, cfg)
+
+	batches := []models.ClearingBatch{}
+	for {
+		batch, payments, err := paymentRepo.CreateClearingBatch(ctx, newClearingMessageID(cfg, windowEnd), windowEnd, cfg.MaxBatchSize)
+		if errors.Is(err, repository.ErrNothingToClear) {
+			return batches, nil
+		}
+		if err != nil {
+			return batches, err
+		}
+
+		sent, err := writeClearingBatch(ctx, cfg, batch, payments)
+		if err != nil {
+			// The batch stays pending and is written on the next cut-off
+			alertClearing(ctx, "critical", batch, "pacs.008 message could not be written: "+err.Error())
+			sent = batch
+		} else {
+			log.Printf("Submitted %d payments to clearing in %s (control sum %s)", batch.PaymentCount, batch.MessageID, batch.ControlSum)
+		}
+		batches = append(batches, *sent)
+
+		if len(payments) < cfg.MaxBatchSize {
+			return batches, nil
+		}
+	}
+}
+
+// resendPendingBatches writes out batches left pending by an earlier failed cut-off
+func resendPendingBatches(ctx context.Context, cfg clearingConfig) {
+	pending, err := clearingBatchRepo.List(ctx, models.ClearingBatchStatusPending, 100, 0)
+	if err != nil {
+		log.Printf("Failed to list pending clearing batches: %v", err)
+		return
+	}
+
+	for i := range pending.Batches {
+		batch := &pending.Batches[i]
+		payments, err := paymentRepo.ListClearingBatchPayments(ctx, batch.ID)
+		if err != nil {
+			log.Printf("Failed to load payments of clearing batch %s: %v", batch.MessageID, err)
+			continue
+		}
+		if _, err := writeClearingBatch(ctx, cfg, batch, payments); err != nil {
+			alertClearing(ctx, "critical", batch, "pacs.008 message could not be written on retry: "+err.Error())
+		}
+	}
+}
+
+// writeClearingBatch renders a batch as pacs.008 and writes it to the outbound directory. The file
+// is written under a temporary name and renamed, so the clearing system never picks up half a message.
+func writeClearingBatch(ctx context.Context, cfg clearingConfig, batch *models.ClearingBatch, payments []models.Payment) (*models.ClearingBatch, error) {
+	doc, err := iso20022.BuildPacs008(batch.MessageID, batch.CreatedAt, batch.WindowEnd, cfg.Sender, payments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(cfg.OutboundDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create outbound directory: %w", err)
+	}
+
+	fileName := batch.MessageID + ".xml"
+	tmp := filepath.Join(cfg.OutboundDir, "."+fileName+".tmp")
+	if err := os.WriteFile(tmp, doc, 0o640); err != nil {
+		return nil, fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(cfg.OutboundDir, fileName)); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to move message into place: %w", err)
+	}
+
+	return clearingBatchRepo.MarkSent(ctx, batch.ID, fileName)
+}
+
+// newClearingMessageID generates a pacs.008 MsgId (Max35Text) unique to the batch
+func newClearingMessageID(cfg clearingConfig, windowEnd time.Time) string {
+	suffix := strings.ReplaceAll(uuid.New().String(), "-", "")[:8]
+	return fmt.Sprintf("%s%s%s", cfg.MessagePrefix, windowEnd.UTC().Format("20060102150405"), strings.ToUpper(suffix))
+}
+
+// applyStatusReport settles or returns the payments a pacs.002 report gives a final status. A group
+// status with no transaction details applies to every payment of the original message.
+func applyStatusReport(ctx context.Context, report *iso20022.StatusReport) (*models.ClearingReportResult, error) {
+	batch, err := clearingBatchRepo.GetByMessageID(ctx, report.OriginalMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ClearingReportResult{OriginalMessageID: report.OriginalMessageID}
+
+	if len(report.Transactions) == 0 {
+		payments, err := paymentRepo.ListClearingBatchPayments(ctx, batch.ID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range payments {
+			applyClearingOutcome(ctx, &payments[i], iso20022.Outcome(report.GroupStatus), report.GroupReason, result)
+		}
+		return result, nil
+	}
+
+	for _, tx := range report.Transactions {
+		ref, err := tx.ReferenceID()
+		if err != nil {
+			result.Unmatched = append(result.Unmatched, tx.OriginalEndToEndID)
+			continue
+		}
+		payment, err := paymentRepo.GetByReferenceID(ctx, ref)
+		if err != nil || payment.ClearingBatchID == nil || *payment.ClearingBatchID != batch.ID {
+			// Not ours, or not sent in the message the report is about
+			result.Unmatched = append(result.Unmatched, tx.OriginalEndToEndID)
+			continue
+		}
+
+		reason := tx.Reason
+		if reason == "" {
+			reason = report.GroupReason
+		}
+		applyClearingOutcome(ctx, payment, iso20022.Outcome(tx.Status), reason, result)
+	}
+
+	return result, nil
+}
+
+// applyClearingOutcome moves one payment to settled or returned. A returned payment is refunded
+// before it is marked returned, so a refund that fails is retried when the report is imported
+// again; the account service credits the refund's reference at most once.
+func applyClearingOutcome(ctx context.Context, payment *models.Payment, outcome, reason string, result *models.ClearingReportResult) {
+	switch outcome {
+	case iso20022.OutcomeSettled:
+		if _, err := paymentRepo.UpdateStatus(ctx, payment.ID, models.PaymentStatusSettled, nil, models.TransitionCauseClearingReport); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("payment %d: %v", payment.ID, err))
+			return
+		}
+		result.Settled++
+
+	case iso20022.OutcomeReturned:
+		if reason == "" {
+			reason = "returned by clearing"
+		}
+		if !models.CanTransition(payment.Status, models.PaymentStatusReturned) {
+			err := fmt.Errorf("%w: payment %d %s -> %s", repository.ErrInvalidTransition, payment.ID, payment.Status, models.PaymentStatusReturned)
+			result.Errors = append(result.Errors, fmt.Sprintf("payment %d: %v", payment.ID, err))
+			return
+		}
+
+		if _, err := creditAccount(payment.AccountID, payment.Amount, payment.Currency, "return-"+payment.ReferenceID.String(),
+			accountOperationPaymentReturn, payment.ID); err != nil {
+			alertOps(ctx, "critical", payment, "payment was returned by clearing but could not be refunded: "+err.Error())
+			result.Errors = append(result.Errors, fmt.Sprintf("payment %d: returned but not refunded: %v", payment.ID, err))
+			return
+		}
+
+		if _, err := paymentRepo.UpdateStatus(ctx, payment.ID, models.PaymentStatusReturned, &reason, models.TransitionCauseClearingReport); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("payment %d: refunded but not marked returned: %v", payment.ID, err))
+			return
+		}
+		result.Returned++
+
+	default:
+		result.Pending++
+	}
+}
+
+// alertClearing logs and publishes an operator alert about a clearing batch
+func alertClearing(ctx context.Context, severity string, batch *models.ClearingBatch, message string) {
+	log.Printf("[ALERT %s] clearing batch %d (%s): %s", severity, batch.ID, batch.MessageID, message)
+
+	alert := models.OpsAlert{
+		Service:     "payment",
+		Severity:    severity,
+		EntityType:  "clearing_batch",
+		EntityID:    batch.ID,
+		ReferenceID: batch.MessageID,
+		Message:     message,
+		CreatedAt:   time.Now(),
+	}
+	if err := kafkaProducer.PublishOpsAlert(ctx, alert); err != nil {
+		log.Printf("Failed to publish ops alert for clearing batch %d: %v", batch.ID, err)
+	}
+}
+
+// requireAdmin rejects callers without the admin role
+func requireAdmin(c *gin.Context) bool {
+	_, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+	if role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return false
+	}
+	return true
+}
+
+// listClearingBatches lists clearing batches, optionally filtered by status (admin only)
+func listClearingBatches(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+
+	result, err := clearingBatchRepo.List(c.Request.Context(), c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list clearing batches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getClearingBatch returns a clearing batch and the payments in it (admin only)
+func getClearingBatch(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	batchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batch ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	batch, err := clearingBatchRepo.GetByID(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, repository.ErrClearingBatchNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "clearing batch not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get clearing batch"})
+		return
+	}
+
+	payments, err := paymentRepo.ListClearingBatchPayments(ctx, batchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get clearing batch payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"batch": batch, "payments": payments})
+}
+
+// runClearingCutoffNow closes the current clearing window immediately (admin only)
+func runClearingCutoffNow(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	batches, err := closeClearingWindow(c.Request.Context(), clearingCfg, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to close clearing window"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"batches": batches})
+}
+
+// importStatusReport applies an uploaded pacs.002 status report (admin only)
+func importStatusReport(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	report, err := iso20022.ParsePacs002(io.LimitReader(c.Request.Body, maxClearingMessageSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := applyStatusReport(c.Request.Context(), report)
+	if err != nil {
+		if errors.Is(err, repository.ErrClearingBatchNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no clearing batch with message ID " + report.OriginalMessageID})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import status report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
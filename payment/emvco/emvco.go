@@ -0,0 +1,270 @@
+// Package emvco encodes and parses EMVCo merchant-presented QR code payloads (EMV QRCPS).
+package emvco
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Point of initiation methods
+const (
+	InitiationStatic  = "11" // reusable code; the customer enters the amount
+	InitiationDynamic = "12" // single-use code for one payment of a set amount
+)
+
+// Top-level data object IDs
+const (
+	tagPayloadFormat       = "00"
+	tagInitiationMethod    = "01"
+	tagMerchantAccountInfo = "26" // first of the 26-51 templates open to domestic schemes
+	tagMCC                 = "52"
+	tagCurrency            = "53"
+	tagAmount              = "54"
+	tagCountry             = "58"
+	tagMerchantName        = "59"
+	tagMerchantCity        = "60"
+	tagAdditionalData      = "62"
+	tagCRC                 = "63"
+
+	// Merchant account information sub-tags
+	subTagGUI          = "00"
+	subTagMerchantCode = "01"
+
+	// Additional data sub-tag
+	subTagReferenceLabel = "05"
+)
+
+const (
+	payloadFormatIndicator = "01"
+	maxMerchantName        = 25
+	maxMerchantCity        = 15
+	maxReferenceLabel      = 25
+	maxAmountLength        = 13
+)
+
+var (
+	ErrInvalidPayload  = errors.New("invalid EMVCo QR payload")
+	ErrChecksum        = errors.New("QR payload checksum does not match")
+	ErrUnknownCurrency = errors.New("currency has no ISO 4217 numeric code")
+	ErrForeignScheme   = errors.New("QR code does not belong to this bank")
+)
+
+// currencyCodes maps ISO 4217 alphabetic codes to the numeric codes EMVCo payloads carry
+var currencyCodes = map[string]string{
+	"AUD": "036", "BRL": "986", "CAD": "124", "CHF": "756", "CNY": "156", "DKK": "208", "EUR": "978",
+	"GBP": "826", "HKD": "344", "INR": "356", "JPY": "392", "KES": "404", "MXN": "484", "NGN": "566",
+	"NOK": "578", "PLN": "985", "SEK": "752", "SGD": "702", "USD": "840", "ZAR": "710",
+}
+
+// Payload is a merchant-presented QR code addressed to a merchant of the bank's scheme
+type Payload struct {
+	Initiation     string
+	GUI            string // globally unique identifier of the scheme, e.g. a reverse domain name
+	MerchantCode   string
+	MCC            string
+	Currency       string // ISO 4217 alphabetic code
+	Amount         *decimal.Decimal
+	Country        string
+	MerchantName   string
+	MerchantCity   string
+	ReferenceLabel string
+}
+
+// IsDynamic reports whether the payload is a single-use code
+func (p *Payload) IsDynamic() bool {
+	return p.Initiation == InitiationDynamic
+}
+
+// Encode builds the QR payload string, ending with its CRC
+func (p *Payload) Encode() (string, error) {
+	currency, ok := currencyCodes[strings.ToUpper(p.Currency)]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCurrency, p.Currency)
+	}
+	if len(p.MCC) != 4 || len(p.Country) != 2 || p.MerchantName == "" || p.MerchantCity == "" {
+		return "", fmt.Errorf("%w: merchant category, country, name and city are required", ErrInvalidPayload)
+	}
+
+	var b strings.Builder
+	writeField(&b, tagPayloadFormat, payloadFormatIndicator)
+	writeField(&b, tagInitiationMethod, p.Initiation)
+
+	var account strings.Builder
+	writeField(&account, subTagGUI, p.GUI)
+	writeField(&account, subTagMerchantCode, p.MerchantCode)
+	writeField(&b, tagMerchantAccountInfo, account.String())
+
+	writeField(&b, tagMCC, p.MCC)
+	writeField(&b, tagCurrency, currency)
+	if p.Amount != nil {
+		amount := p.Amount.StringFixed(2)
+		if len(amount) > maxAmountLength {
+			return "", fmt.Errorf("%w: amount too large", ErrInvalidPayload)
+		}
+		writeField(&b, tagAmount, amount)
+	}
+	writeField(&b, tagCountry, strings.ToUpper(p.Country))
+	writeField(&b, tagMerchantName, truncate(p.MerchantName, maxMerchantName))
+	writeField(&b, tagMerchantCity, truncate(p.MerchantCity, maxMerchantCity))
+	if p.ReferenceLabel != "" {
+		var additional strings.Builder
+		writeField(&additional, subTagReferenceLabel, truncate(p.ReferenceLabel, maxReferenceLabel))
+		writeField(&b, tagAdditionalData, additional.String())
+	}
+
+	// The CRC covers everything up to and including its own ID and length
+	b.WriteString(tagCRC + "04")
+	b.WriteString(fmt.Sprintf("%04X", CRC16(b.String())))
+	return b.String(), nil
+}
+
+// Parse reads a QR payload, checking its CRC and that it addresses a merchant of the scheme with
+// the given GUI
+func Parse(payload, gui string) (*Payload, error) {
+	payload = strings.TrimSpace(payload)
+	if len(payload) < 8 {
+		return nil, ErrInvalidPayload
+	}
+
+	// The CRC is always the last data object
+	body, crc := payload[:len(payload)-4], payload[len(payload)-4:]
+	if !strings.HasSuffix(body, tagCRC+"04") {
+		return nil, fmt.Errorf("%w: CRC must be the last data object", ErrInvalidPayload)
+	}
+	if fmt.Sprintf("%04X", CRC16(body)) != strings.ToUpper(crc) {
+		return nil, ErrChecksum
+	}
+
+	fields, err := parseFields(body[:len(body)-4])
+	if err != nil {
+		return nil, err
+	}
+	if fields[tagPayloadFormat] != payloadFormatIndicator {
+		return nil, fmt.Errorf("%w: unsupported payload format", ErrInvalidPayload)
+	}
+
+	p := &Payload{
+		Initiation:   fields[tagInitiationMethod],
+		MCC:          fields[tagMCC],
+		Country:      fields[tagCountry],
+		MerchantName: fields[tagMerchantName],
+		MerchantCity: fields[tagMerchantCity],
+	}
+	switch p.Initiation {
+	case InitiationStatic, InitiationDynamic:
+	case "":
+		p.Initiation = InitiationStatic
+	default:
+		return nil, fmt.Errorf("%w: unknown point of initiation method %s", ErrInvalidPayload, p.Initiation)
+	}
+
+	// Look for the scheme's template among the merchant account information templates
+	for id := 26; id <= 51; id++ {
+		value, ok := fields[strconv.Itoa(id)]
+		if !ok {
+			continue
+		}
+		account, err := parseFields(value)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(account[subTagGUI], gui) {
+			p.GUI = account[subTagGUI]
+			p.MerchantCode = account[subTagMerchantCode]
+			break
+		}
+	}
+	if p.MerchantCode == "" {
+		return nil, ErrForeignScheme
+	}
+
+	for alpha, numeric := range currencyCodes {
+		if numeric == fields[tagCurrency] {
+			p.Currency = alpha
+			break
+		}
+	}
+	if p.Currency == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCurrency, fields[tagCurrency])
+	}
+
+	if value, ok := fields[tagAmount]; ok {
+		amount, err := decimal.NewFromString(value)
+		if err != nil || !amount.IsPositive() {
+			return nil, fmt.Errorf("%w: invalid amount %q", ErrInvalidPayload, value)
+		}
+		p.Amount = &amount
+	}
+	if p.IsDynamic() && p.Amount == nil {
+		return nil, fmt.Errorf("%w: dynamic QR code without an amount", ErrInvalidPayload)
+	}
+
+	if value, ok := fields[tagAdditionalData]; ok {
+		additional, err := parseFields(value)
+		if err != nil {
+			return nil, err
+		}
+		p.ReferenceLabel = additional[subTagReferenceLabel]
+	}
+
+	return p, nil
+}
+
+// CurrencySupported reports whether QR payloads can carry the currency
+func CurrencySupported(currency string) bool {
+	_, ok := currencyCodes[strings.ToUpper(currency)]
+	return ok
+}
+
+// CRC16 computes the CRC-16/CCITT-FALSE checksum (polynomial 0x1021, initial value 0xFFFF) that
+// EMVCo QR payloads end with
+func CRC16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// parseFields splits a run of ID-length-value data objects
+func parseFields(data string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("%w: truncated data object", ErrInvalidPayload)
+		}
+		id := data[:2]
+		length, err := strconv.Atoi(data[2:4])
+		if err != nil || length < 1 || len(data) < 4+length {
+			return nil, fmt.Errorf("%w: bad length in data object %s", ErrInvalidPayload, id)
+		}
+		if _, dup := fields[id]; dup {
+			return nil, fmt.Errorf("%w: repeated data object %s", ErrInvalidPayload, id)
+		}
+		fields[id] = data[4 : 4+length]
+		data = data[4+length:]
+	}
+	return fields, nil
+}
+
+func writeField(b *strings.Builder, id, value string) {
+	fmt.Fprintf(b, "%s%02d%s", id, len(value), value)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(s[:n])
+}
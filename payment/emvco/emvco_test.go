@@ -0,0 +1,142 @@
+package emvco
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+const testGUI = "COM.DBANK"
+
+func TestCRC16(t *testing.T) {
+	// CRC-16/CCITT-FALSE check value
+	if got := CRC16("123456789"); got != 0x29B1 {
+		t.Errorf("CRC16() = %04X, want 29B1", got)
+	}
+}
+
+func TestEncodeStatic(t *testing.T) {
+	p := &Payload{
+		Initiation:   InitiationStatic,
+		GUI:          testGUI,
+		MerchantCode: "SHOP01",
+		MCC:          "5411",
+		Currency:     "USD",
+		Country:      "US",
+		MerchantName: "Corner Shop",
+		MerchantCity: "Springfield",
+	}
+
+	got, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	body := "000201010211" + "2623" + "0009COM.DBANK" + "0106SHOP01" + "52045411" + "5303840" +
+		"5802US" + "5911Corner Shop" + "6011Springfield" + "6304"
+	if !strings.HasPrefix(got, body) || len(got) != len(body)+4 {
+		t.Fatalf("Encode() = %q, want %q followed by the CRC", got, body)
+	}
+	if crc := got[len(body):]; crc != strings.ToUpper(crc) {
+		t.Errorf("CRC %q is not upper case hex", crc)
+	}
+}
+
+func TestEncodeParseRoundTrip(t *testing.T) {
+	amount := decimal.RequireFromString("12.5")
+	p := &Payload{
+		Initiation:     InitiationDynamic,
+		GUI:            testGUI,
+		MerchantCode:   "SHOP01",
+		MCC:            "5812",
+		Currency:       "EUR",
+		Amount:         &amount,
+		Country:        "de",
+		MerchantName:   "A Very Long Restaurant Name GmbH",
+		MerchantCity:   "Frankfurt am Main",
+		ReferenceLabel: "INV-42",
+	}
+
+	payload, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if !strings.Contains(payload, "540512.50") {
+		t.Errorf("Encode() = %q, want the amount with two decimals", payload)
+	}
+
+	parsed, err := Parse(payload, "com.dbank")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !parsed.IsDynamic() || parsed.MerchantCode != "SHOP01" || parsed.MCC != "5812" || parsed.Currency != "EUR" {
+		t.Errorf("Parse() = %+v", parsed)
+	}
+	if parsed.Amount == nil || !parsed.Amount.Equal(amount) {
+		t.Errorf("Parse() amount = %v, want %s", parsed.Amount, amount)
+	}
+	if parsed.Country != "DE" || parsed.MerchantName != "A Very Long Restaurant Na" || parsed.MerchantCity != "Frankfurt am Ma" {
+		t.Errorf("Parse() country %q name %q city %q", parsed.Country, parsed.MerchantName, parsed.MerchantCity)
+	}
+	if parsed.ReferenceLabel != "INV-42" {
+		t.Errorf("Parse() reference label = %q, want INV-42", parsed.ReferenceLabel)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	valid := Payload{Initiation: InitiationStatic, GUI: testGUI, MerchantCode: "SHOP01", MCC: "5411", Currency: "USD",
+		Country: "US", MerchantName: "Corner Shop", MerchantCity: "Springfield"}
+
+	unknownCurrency := valid
+	unknownCurrency.Currency = "XYZ"
+	if _, err := unknownCurrency.Encode(); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Encode() error = %v, want %v", err, ErrUnknownCurrency)
+	}
+
+	noCity := valid
+	noCity.MerchantCity = ""
+	if _, err := noCity.Encode(); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Encode() error = %v, want %v", err, ErrInvalidPayload)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	p := &Payload{Initiation: InitiationStatic, GUI: testGUI, MerchantCode: "SHOP01", MCC: "5411", Currency: "USD",
+		Country: "US", MerchantName: "Corner Shop", MerchantCity: "Springfield"}
+	valid, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	// withCRC re-signs a modified payload body so only the modification is under test
+	withCRC := func(body string) string {
+		body += "6304"
+		return fmt.Sprintf("%s%04X", body, CRC16(body))
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		gui     string
+		wantErr error
+	}{
+		{name: "tampered", payload: strings.Replace(valid, "Corner", "Korner", 1), gui: testGUI, wantErr: ErrChecksum},
+		{name: "too short", payload: "6304", gui: testGUI, wantErr: ErrInvalidPayload},
+		{name: "other scheme", payload: valid, gui: "BR.GOV.BCB.PIX", wantErr: ErrForeignScheme},
+		{name: "bad length", payload: withCRC("000201010211269900"), gui: testGUI, wantErr: ErrInvalidPayload},
+		{name: "dynamic without amount", payload: withCRC("000201010212" + "2623" + "0009COM.DBANK" + "0106SHOP01" + "5303840"), gui: testGUI, wantErr: ErrInvalidPayload},
+		{name: "unknown currency", payload: withCRC("000201010211" + "2623" + "0009COM.DBANK" + "0106SHOP01" + "5303999"), gui: testGUI, wantErr: ErrUnknownCurrency},
+		{name: "bad format indicator", payload: withCRC("000202"), gui: testGUI, wantErr: ErrInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.payload, tt.gui); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ require (
 	github.com/jackc/pgx/v5 v5.5.1
 	github.com/segmentio/kafka-go v0.4.47
 	github.com/shopspring/decimal v1.3.1
+	receipt v0.0.0-00010101000000-000000000000
 )
 
 require (
@@ -54,3 +55,6 @@ require (
 	google.golang.org/protobuf v1.34.1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+// receipt is shared with the other services that issue receipts
+replace receipt => ../receipt
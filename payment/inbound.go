@@ -0,0 +1,554 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"payment/iso20022"
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inboundConfig controls the import of incoming credit transfers
+type inboundConfig struct {
+	Dir                 string        // where the clearing system drops pacs.008 / camt.054 files
+	PollInterval        time.Duration // how often the directory is scanned
+	IBANBankCode        string        // bank code identifying this bank's IBANs
+	AccountNumberLength int           // account numbers fill the end of this bank's IBANs
+}
+
+// Sub-directories imported files are moved into
+const (
+	inboundProcessedDir = "processed"
+	inboundFailedDir    = "failed"
+)
+
+const inboundPendingBatchSize = 100
+
+// errCreditDeferred means a credit could not be applied for a reason that may pass, so it stays pending
+var errCreditDeferred = errors.New("credit deferred")
+
+// runInboundImport periodically imports files from the inbound directory and applies the credits in them
+func runInboundImport(ctx context.Context, cfg inboundConfig) {
+	log.Printf("Starting inbound import (dir %s, interval %s)", cfg.Dir, cfg.PollInterval)
+
+	ticker := time.NewTicker(cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping inbound import")
+			return
+		case <-ticker.C:
+			scanInboundDir(ctx, cfg)
+			applyPendingCredits(ctx, cfg)
+		}
+	}
+}
+
+// scanInboundDir imports every XML file in the inbound directory, moving each into processed/ or
+// failed/ so it is picked up only once
+func scanInboundDir(ctx context.Context, cfg inboundConfig) {
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Failed to read inbound directory: %v", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.EqualFold(filepath.Ext(name), ".xml") {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		path := filepath.Join(cfg.Dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Failed to read inbound file %s: %v", name, err)
+			continue
+		}
+
+		result, err := importInboundFile(ctx, name, data)
+		switch {
+		case err == nil:
+			log.Printf("Imported %s: %d credits (%d duplicates)", name, result.Imported, result.Duplicates)
+			moveInboundFile(cfg, name, inboundProcessedDir)
+		case errors.Is(err, repository.ErrInboundFileExists):
+			log.Printf("Inbound file %s was already imported", name)
+			moveInboundFile(cfg, name, inboundProcessedDir)
+		case isInvalidInboundFile(err):
+			alertInbound(ctx, "warning", name, "inbound file could not be parsed: "+err.Error())
+			moveInboundFile(cfg, name, inboundFailedDir)
+		default:
+			// Left in place and retried on the next scan
+			log.Printf("Failed to import inbound file %s: %v", name, err)
+		}
+	}
+}
+
+// invalidInboundFileError marks a file that will never import, as opposed to a database failure
+type invalidInboundFileError struct{ err error }
+
+func (e invalidInboundFileError) Error() string { return e.err.Error() }
+func (e invalidInboundFileError) Unwrap() error { return e.err }
+
+func isInvalidInboundFile(err error) bool {
+	var invalid invalidInboundFileError
+	return errors.As(err, &invalid)
+}
+
+// importInboundFile parses an incoming file and records its credits as pending
+func importInboundFile(ctx context.Context, name string, data []byte) (*models.InboundImportResult, error) {
+	msg, err := iso20022.ParseInbound(data)
+	if err != nil {
+		return nil, invalidInboundFileError{err}
+	}
+
+	sum := sha256.Sum256(data)
+	file := &models.InboundFile{
+		FileName:    name,
+		SHA256:      hex.EncodeToString(sum[:]),
+		MessageType: msg.Type,
+		MessageID:   optionalString(msg.MessageID),
+	}
+
+	credits := make([]models.InboundCredit, 0, len(msg.Credits))
+	for i, c := range msg.Credits {
+		if c.Amount.Sign() <= 0 || len(c.Currency) != 3 {
+			return nil, invalidInboundFileError{fmt.Errorf("credit %d has an invalid amount %s %q", i+1, c.Amount, c.Currency)}
+		}
+		credits = append(credits, models.InboundCredit{
+			DedupeKey:       c.DedupeKey(msg.MessageID, i),
+			EndToEndID:      optionalString(c.EndToEndID),
+			Amount:          c.Amount,
+			Currency:        c.Currency,
+			DebtorName:      optionalString(c.DebtorName),
+			DebtorAccount:   optionalString(c.DebtorAccount),
+			CreditorName:    optionalString(c.CreditorName),
+			CreditorAccount: optionalString(c.CreditorAccount),
+			RemittanceInfo:  optionalString(c.Remittance),
+		})
+	}
+
+	recorded, imported, err := inboundRepo.RecordFile(ctx, file, credits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InboundImportResult{
+		File:       *recorded,
+		Imported:   imported,
+		Duplicates: len(credits) - imported,
+	}, nil
+}
+
+func moveInboundFile(cfg inboundConfig, name, subdir string) {
+	dir := filepath.Join(cfg.Dir, subdir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		log.Printf("Failed to create %s: %v", dir, err)
+		return
+	}
+	if err := os.Rename(filepath.Join(cfg.Dir, name), filepath.Join(dir, name)); err != nil {
+		log.Printf("Failed to move inbound file %s to %s: %v", name, subdir, err)
+	}
+}
+
+// applyPendingCredits finishes crediting the items whose credit was interrupted, then credits every
+// pending item to its matched account or moves it to suspense. It returns how many were credited
+// and how many went to suspense.
+func applyPendingCredits(ctx context.Context, cfg inboundConfig) (credited, suspense int) {
+	retrying, err := inboundRepo.ListByStatus(ctx, models.InboundCreditStatusCrediting, inboundPendingBatchSize, 0)
+	if err != nil {
+		log.Printf("Failed to list inbound credits being credited: %v", err)
+		return 0, 0
+	}
+	pending, err := inboundRepo.ListByStatus(ctx, models.InboundCreditStatusPending, inboundPendingBatchSize, 0)
+	if err != nil {
+		log.Printf("Failed to list pending inbound credits: %v", err)
+		return 0, 0
+	}
+
+	credits := append(retrying.Credits, pending.Credits...)
+	for i := range credits {
+		if ctx.Err() != nil {
+			return
+		}
+		credit := &credits[i]
+
+		var account *models.AccountSummary
+		if credit.Status == models.InboundCreditStatusCrediting {
+			account, err = claimedCreditAccount(credit)
+		} else {
+			account, err = matchCreditAccount(credit, cfg)
+		}
+		if err == nil {
+			err = applyInboundCredit(ctx, credit, account, nil, nil)
+		}
+
+		switch {
+		case err == nil:
+			credited++
+		case errors.Is(err, errCreditDeferred):
+			log.Printf("Inbound credit %d deferred: %v", credit.ID, err)
+		case errors.Is(err, repository.ErrInboundCreditSettled):
+			log.Printf("Inbound credit %d was settled elsewhere", credit.ID)
+		default:
+			if _, markErr := inboundRepo.MarkSuspense(ctx, credit.ID, err.Error()); markErr != nil {
+				log.Printf("Failed to move inbound credit %d to suspense: %v", credit.ID, markErr)
+				continue
+			}
+			suspense++
+		}
+	}
+
+	return credited, suspense
+}
+
+// matchCreditAccount finds the account an incoming credit is for from its creditor IBAN or account
+// number. Errors other than errCreditDeferred explain why the credit belongs in suspense.
+func matchCreditAccount(credit *models.InboundCredit, cfg inboundConfig) (*models.AccountSummary, error) {
+	if credit.CreditorAccount == nil || strings.TrimSpace(*credit.CreditorAccount) == "" {
+		return nil, errors.New("no creditor account")
+	}
+
+	number := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(*credit.CreditorAccount), " ", ""))
+	if len(number) >= 2 && unicode.IsLetter(rune(number[0])) && unicode.IsLetter(rune(number[1])) {
+		n, err := iso20022.AccountNumberFromIBAN(number, cfg.IBANBankCode, cfg.AccountNumberLength)
+		if err != nil {
+			return nil, fmt.Errorf("creditor account %s: %w", number, err)
+		}
+		number = n
+	}
+
+	account, status, err := getAccountByNumber(number)
+	switch {
+	case status == http.StatusNotFound:
+		return nil, fmt.Errorf("no account with number %s", number)
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", errCreditDeferred, err)
+	}
+
+	return account, checkCreditable(credit, account)
+}
+
+// claimedCreditAccount looks up the account a credit was claimed for, to retry crediting it there
+func claimedCreditAccount(credit *models.InboundCredit) (*models.AccountSummary, error) {
+	if credit.AccountID == nil {
+		return nil, fmt.Errorf("%w: credit %d is being credited to no account", errCreditDeferred, credit.ID)
+	}
+
+	account, _, err := getAccountByID(*credit.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errCreditDeferred, err)
+	}
+	return account, nil
+}
+
+// checkCreditable rejects accounts a credit cannot be applied to
+func checkCreditable(credit *models.InboundCredit, account *models.AccountSummary) error {
+	if account.Status != "active" {
+		return fmt.Errorf("account %s is %s", account.AccountNumber, account.Status)
+	}
+	if account.Currency != credit.Currency {
+		return fmt.Errorf("account %s is in %s, credit is in %s", account.AccountNumber, account.Currency, credit.Currency)
+	}
+	return nil
+}
+
+// applyInboundCredit claims the credit for the account, credits it, records the credit and notifies
+// its owner. Account service rejections release the claim and are returned as suspense reasons;
+// outages leave it claimed, to be retried, and are returned as errCreditDeferred. A credit that is
+// no longer pending or in suspense returns repository.ErrInboundCreditSettled.
+func applyInboundCredit(ctx context.Context, credit *models.InboundCredit, account *models.AccountSummary, resolvedBy *int64, note *string) error {
+	claimed, err := inboundRepo.MarkCrediting(ctx, credit.ID, account.ID, account.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrInboundCreditSettled) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errCreditDeferred, err)
+	}
+
+	status, err := creditAccount(account.ID, credit.Amount, credit.Currency, credit.ReferenceID.String(), accountOperationInboundCredit, credit.ID)
+	if err != nil {
+		if status < 400 || status >= 500 {
+			return fmt.Errorf("%w: %v", errCreditDeferred, err)
+		}
+		// A credit being retried goes back to where it was imported to, not to a status it never left
+		previous := credit.Status
+		if previous == models.InboundCreditStatusCrediting {
+			previous = models.InboundCreditStatusPending
+		}
+		if _, releaseErr := inboundRepo.ReleaseCrediting(ctx, claimed.ID, previous); releaseErr != nil {
+			return fmt.Errorf("%w: %v (and failed to release it: %v)", errCreditDeferred, err, releaseErr)
+		}
+		return err
+	}
+
+	applied, err := inboundRepo.MarkCredited(ctx, claimed.ID, resolvedBy, note)
+	if err != nil {
+		// The account service has the credit under the reference ID, so retrying is safe
+		return fmt.Errorf("%w: credited but not recorded: %v", errCreditDeferred, err)
+	}
+
+	event := models.InboundCreditEvent{
+		CreditID:    applied.ID,
+		ReferenceID: applied.ReferenceID.String(),
+		AccountID:   account.ID,
+		UserID:      account.UserID,
+		Amount:      applied.Amount,
+		Currency:    applied.Currency,
+		DebtorName:  derefString(applied.DebtorName),
+		Remittance:  derefString(applied.RemittanceInfo),
+		CreditedAt:  time.Now(),
+	}
+	if applied.CreditedAt != nil {
+		event.CreditedAt = *applied.CreditedAt
+	}
+	if err := kafkaProducer.PublishInboundCredited(ctx, event); err != nil {
+		log.Printf("Failed to publish inbound credit %d: %v", applied.ID, err)
+	}
+
+	return nil
+}
+
+// alertInbound logs and publishes an operator alert about an inbound file
+func alertInbound(ctx context.Context, severity, fileName, message string) {
+	log.Printf("[ALERT %s] inbound file %s: %s", severity, fileName, message)
+
+	alert := models.OpsAlert{
+		Service:     "payment",
+		Severity:    severity,
+		EntityType:  "inbound_file",
+		ReferenceID: fileName,
+		Message:     message,
+		CreatedAt:   time.Now(),
+	}
+	if err := kafkaProducer.PublishOpsAlert(ctx, alert); err != nil {
+		log.Printf("Failed to publish ops alert for inbound file %s: %v", fileName, err)
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// uploadInboundFile imports an incoming pacs.008 or camt.054 posted as the request body, exactly as
+// if it had arrived in the inbound directory (admin only)
+func uploadInboundFile(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxClearingMessageSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+
+	name := c.DefaultQuery("file_name", "upload-"+time.Now().UTC().Format("20060102150405")+".xml")
+
+	ctx := c.Request.Context()
+	result, err := importInboundFile(ctx, name, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInboundFileExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "file already imported"})
+		case isInvalidInboundFile(err):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import file"})
+		}
+		return
+	}
+
+	result.Credited, result.Suspense = applyPendingCredits(ctx, inboundCfg)
+	c.JSON(http.StatusCreated, result)
+}
+
+// listInboundCredits lists incoming credits in a status, the suspense queue by default (admin only)
+func listInboundCredits(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	status := c.DefaultQuery("status", models.InboundCreditStatusSuspense)
+	switch status {
+	case models.InboundCreditStatusPending, models.InboundCreditStatusCrediting, models.InboundCreditStatusCredited,
+		models.InboundCreditStatusSuspense, models.InboundCreditStatusRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+
+	result, err := inboundRepo.ListByStatus(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list inbound credits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getInboundCredit returns an incoming credit (admin only)
+func getInboundCredit(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	credit, ok := loadInboundCredit(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, credit)
+}
+
+// resolveInboundCredit credits a suspense item to the account an admin identified (admin only)
+func resolveInboundCredit(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	adminID, _, _ := getUserContext(c)
+
+	var req models.ResolveInboundCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credit, ok := loadInboundCredit(c)
+	if !ok {
+		return
+	}
+	if credit.Status != models.InboundCreditStatusSuspense {
+		c.JSON(http.StatusConflict, gin.H{"error": "only suspense items can be resolved"})
+		return
+	}
+
+	account, status, err := getAccountByID(req.AccountID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to get account"})
+		return
+	}
+	if err := checkCreditable(credit, account); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := applyInboundCredit(c.Request.Context(), credit, account, &adminID, optionalString(req.Note)); err != nil {
+		switch {
+		case errors.Is(err, errCreditDeferred):
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, repository.ErrInboundCreditSettled):
+			respondInboundCreditError(c, err)
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	resolved, err := inboundRepo.GetByID(c.Request.Context(), credit.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get inbound credit"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resolved)
+}
+
+// rejectInboundCredit takes a suspense item off the queue without crediting it, for example when
+// it is to be returned to the sending bank (admin only)
+func rejectInboundCredit(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	adminID, _, _ := getUserContext(c)
+
+	var req models.RejectInboundCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	creditID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid inbound credit ID"})
+		return
+	}
+
+	credit, err := inboundRepo.MarkRejected(c.Request.Context(), creditID, adminID, req.Reason)
+	if err != nil {
+		respondInboundCreditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, credit)
+}
+
+func loadInboundCredit(c *gin.Context) (*models.InboundCredit, bool) {
+	creditID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid inbound credit ID"})
+		return nil, false
+	}
+
+	credit, err := inboundRepo.GetByID(c.Request.Context(), creditID)
+	if err != nil {
+		respondInboundCreditError(c, err)
+		return nil, false
+	}
+
+	return credit, true
+}
+
+func respondInboundCreditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrInboundCreditNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "inbound credit not found"})
+	case errors.Is(err, repository.ErrInboundCreditSettled):
+		c.JSON(http.StatusConflict, gin.H{"error": "only suspense items can be resolved or rejected"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update inbound credit"})
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"payment/models"
+	"payment/repository"
+	"receipt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setPaymentCategory lets the payer, or an admin, reassign the category a payment counts towards
+func setPaymentCategory(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+
+	var req models.SetCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	payment, err := paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment"})
+		return
+	}
+
+	if role != "admin" && payment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
+	payment, err = paymentRepo.SetCategory(ctx, paymentID, req.Category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set payment category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// getSpendingInsights summarizes the caller's spending over the months from to to (YYYY-MM,
+// inclusive; the last six months by default): completed payments from this service and transfers
+// to other people's accounts from the transfer service, per month and category with
+// month-over-month changes, per merchant, and their top recipients (?limit=, default 10).
+// If the transfer service cannot be reached the payments are still reported, flagged incomplete.
+func getSpendingInsights(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to, err := models.ParseMonthRange(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	// The month before the range is included for its month-over-month change
+	start, end := from.AddDate(0, -1, 0), to.AddDate(0, 1, 0)
+
+	ctx := c.Request.Context()
+	rows, err := insightsRepo.SpendingByMonth(ctx, userID, start, end)
+	if err != nil {
+		log.Printf("Failed to summarize spending for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to summarize spending"})
+		return
+	}
+	merchants, err := insightsRepo.SpendingByMerchant(ctx, userID, from, end, limit)
+	if err != nil {
+		log.Printf("Failed to summarize merchant spending for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to summarize spending"})
+		return
+	}
+	recipients, err := insightsRepo.TopRecipients(ctx, userID, from, end, limit)
+	if err != nil {
+		log.Printf("Failed to summarize payment recipients for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to summarize spending"})
+		return
+	}
+	for i := range recipients {
+		recipients[i].Account = receipt.MaskAccount(recipients[i].Account)
+	}
+
+	incomplete := false
+	transfers, err := getTransferSpending(userID, role, start, end, limit)
+	if err != nil {
+		log.Printf("Failed to get transfer spending for user %d: %v", userID, err)
+		incomplete = true
+	} else {
+		rows = append(rows, transfers.Months...)
+		for _, recipient := range transfers.Recipients {
+			recipient.Source = models.SpendingSourceTransfer
+			recipients = append(recipients, recipient)
+		}
+	}
+
+	insights := models.BuildInsights(from, to, rows, merchants, recipients, limit)
+	insights.Incomplete = incomplete
+	c.JSON(http.StatusOK, insights)
+}
+
+// getTransferSpending fetches the user's outgoing transfers between start and end from the
+// transfer service, as the user
+func getTransferSpending(userID int64, role string, start, end time.Time, limit int) (*models.TransferSpending, error) {
+	transferServiceURL := getEnv("TRANSFER_SERVICE_URL", "http://transfer.transfer.svc.cluster.local:8080")
+
+	// The transfer service takes inclusive dates
+	query := url.Values{}
+	query.Set("from", start.Format(models.DateLayout))
+	query.Set("to", end.AddDate(0, 0, -1).Format(models.DateLayout))
+	query.Set("recipients", strconv.Itoa(limit))
+
+	req, err := http.NewRequest("GET", transferServiceURL+"/api/transfers/spending?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("X-User-ID", strconv.FormatInt(userID, 10))
+	req.Header.Set("X-User-Role", role)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call transfer service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transfer service returned status %d", resp.StatusCode)
+	}
+
+	var spending models.TransferSpending
+	if err := json.NewDecoder(resp.Body).Decode(&spending); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &spending, nil
+}
@@ -0,0 +1,289 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Inbound message types
+const (
+	MessageTypePacs008 = "pacs.008"
+	MessageTypeCamt054 = "camt.054"
+)
+
+var (
+	ErrUnsupportedMessage = errors.New("document is neither a pacs.008 credit transfer nor a camt.054 notification")
+	ErrInvalidIBAN        = errors.New("invalid IBAN")
+	ErrForeignIBAN        = errors.New("IBAN does not belong to this bank")
+)
+
+// InboundCredit is one incoming credit transfer read from a pacs.008 or camt.054 message
+type InboundCredit struct {
+	EndToEndID      string
+	UETR            string
+	TxID            string
+	Amount          decimal.Decimal
+	Currency        string
+	DebtorName      string
+	DebtorAccount   string
+	CreditorName    string
+	CreditorAccount string
+	Remittance      string
+}
+
+// InboundMessage is an incoming ISO 20022 message and the credits it carries
+type InboundMessage struct {
+	Type      string
+	MessageID string
+	Credits   []InboundCredit
+}
+
+type inboundDocument struct {
+	CreditTransfer *customerCreditTransfer `xml:"FIToFICstmrCdtTrf"`
+	Notification   *camt054Notification    `xml:"BkToCstmrDbtCdtNtfctn"`
+}
+
+type camt054Notification struct {
+	GroupHeader struct {
+		MessageID string `xml:"MsgId"`
+	} `xml:"GrpHdr"`
+	Notifications []struct {
+		Account account `xml:"Acct"`
+		Entries []struct {
+			Amount    activeAmount `xml:"Amt"`
+			Indicator string       `xml:"CdtDbtInd"`
+			Reversal  bool         `xml:"RvslInd"`
+			Status    entryStatus  `xml:"Sts"`
+			Details   []struct {
+				Transactions []camt054Transaction `xml:"TxDtls"`
+			} `xml:"NtryDtls"`
+		} `xml:"Ntry"`
+	} `xml:"Ntfctn"`
+}
+
+// entryStatus is a code element from camt.054.001.08 on, plain text before it
+type entryStatus struct {
+	Code string `xml:"Cd"`
+	Text string `xml:",chardata"`
+}
+
+func (s entryStatus) value() string {
+	if s.Code != "" {
+		return strings.TrimSpace(s.Code)
+	}
+	return strings.TrimSpace(s.Text)
+}
+
+type camt054Transaction struct {
+	Refs struct {
+		EndToEndID string `xml:"EndToEndId"`
+		TxID       string `xml:"TxId"`
+		UETR       string `xml:"UETR"`
+	} `xml:"Refs"`
+	Amount    *activeAmount `xml:"Amt"`
+	Indicator string        `xml:"CdtDbtInd"`
+	Parties   struct {
+		Debtor          camt054Party `xml:"Dbtr"`
+		DebtorAccount   *account     `xml:"DbtrAcct"`
+		Creditor        camt054Party `xml:"Cdtr"`
+		CreditorAccount *account     `xml:"CdtrAcct"`
+	} `xml:"RltdPties"`
+	Remittance struct {
+		Unstructured []string `xml:"Ustrd"`
+	} `xml:"RmtInf"`
+}
+
+// camt054Party holds a name directly (camt.054.001.02) or under Pty (camt.054.001.08)
+type camt054Party struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+func (p camt054Party) name() string {
+	if p.PartyName != "" {
+		return strings.TrimSpace(p.PartyName)
+	}
+	return strings.TrimSpace(p.Name)
+}
+
+// ParseInbound reads the credits from an incoming pacs.008 credit transfer or camt.054 notification.
+// Only booked credit entries of a camt.054 are returned; debits, reversals and pending entries are skipped.
+func ParseInbound(data []byte) (*InboundMessage, error) {
+	var doc inboundDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid xml: %w", err)
+	}
+
+	switch {
+	case doc.CreditTransfer != nil:
+		return parseInboundPacs008(doc.CreditTransfer)
+	case doc.Notification != nil:
+		return parseCamt054(doc.Notification)
+	default:
+		return nil, ErrUnsupportedMessage
+	}
+}
+
+func parseInboundPacs008(ct *customerCreditTransfer) (*InboundMessage, error) {
+	msg := &InboundMessage{Type: MessageTypePacs008, MessageID: strings.TrimSpace(ct.GroupHeader.MessageID)}
+
+	for i, tx := range ct.Transactions {
+		amount, err := decimal.NewFromString(strings.TrimSpace(tx.Amount.Value))
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: invalid amount %q", i+1, tx.Amount.Value)
+		}
+		msg.Credits = append(msg.Credits, InboundCredit{
+			EndToEndID:      strings.TrimSpace(tx.PaymentID.EndToEndID),
+			UETR:            strings.TrimSpace(tx.PaymentID.UETR),
+			TxID:            strings.TrimSpace(tx.PaymentID.TxID),
+			Amount:          amount,
+			Currency:        strings.TrimSpace(tx.Amount.Currency),
+			DebtorName:      strings.TrimSpace(tx.Debtor.Name),
+			DebtorAccount:   tx.DebtorAccount.number(),
+			CreditorName:    strings.TrimSpace(tx.Creditor.Name),
+			CreditorAccount: tx.CreditorAcct.number(),
+			Remittance:      remittanceText(tx.Remittance),
+		})
+	}
+
+	return msg, nil
+}
+
+func parseCamt054(n *camt054Notification) (*InboundMessage, error) {
+	msg := &InboundMessage{Type: MessageTypeCamt054, MessageID: strings.TrimSpace(n.GroupHeader.MessageID)}
+
+	for _, ntfctn := range n.Notifications {
+		for i, entry := range ntfctn.Entries {
+			if entry.Indicator != "CRDT" || entry.Reversal {
+				continue
+			}
+			if status := entry.Status.value(); status != "" && status != "BOOK" {
+				continue
+			}
+
+			var txs []camt054Transaction
+			for _, d := range entry.Details {
+				txs = append(txs, d.Transactions...)
+			}
+			if len(txs) == 0 {
+				// An entry without details credits the notified account with the entry amount
+				txs = []camt054Transaction{{}}
+			}
+
+			for _, tx := range txs {
+				if tx.Indicator != "" && tx.Indicator != "CRDT" {
+					continue
+				}
+				amt := entry.Amount
+				if tx.Amount != nil {
+					amt = *tx.Amount
+				} else if len(txs) > 1 {
+					return nil, fmt.Errorf("entry %d: transaction details without an amount", i+1)
+				}
+				amount, err := decimal.NewFromString(strings.TrimSpace(amt.Value))
+				if err != nil {
+					return nil, fmt.Errorf("entry %d: invalid amount %q", i+1, amt.Value)
+				}
+
+				creditorAccount := ntfctn.Account.number()
+				if tx.Parties.CreditorAccount != nil {
+					creditorAccount = tx.Parties.CreditorAccount.number()
+				}
+				debtorAccount := ""
+				if tx.Parties.DebtorAccount != nil {
+					debtorAccount = tx.Parties.DebtorAccount.number()
+				}
+
+				msg.Credits = append(msg.Credits, InboundCredit{
+					EndToEndID:      strings.TrimSpace(tx.Refs.EndToEndID),
+					UETR:            strings.TrimSpace(tx.Refs.UETR),
+					TxID:            strings.TrimSpace(tx.Refs.TxID),
+					Amount:          amount,
+					Currency:        strings.TrimSpace(amt.Currency),
+					DebtorName:      tx.Parties.Debtor.name(),
+					DebtorAccount:   debtorAccount,
+					CreditorName:    tx.Parties.Creditor.name(),
+					CreditorAccount: creditorAccount,
+					Remittance:      strings.TrimSpace(strings.Join(tx.Remittance.Unstructured, " ")),
+				})
+			}
+		}
+	}
+
+	return msg, nil
+}
+
+// DedupeKey identifies a credit across messages, so the same transfer reported in both a pacs.008
+// and a camt.054 (or in a re-sent file) is only credited once. Credits without usable references
+// fall back to their position in the message.
+func (c InboundCredit) DedupeKey(messageID string, index int) string {
+	if c.UETR != "" {
+		return "uetr:" + strings.ToLower(c.UETR)
+	}
+	if c.EndToEndID != "" && c.EndToEndID != "NOTPROVIDED" {
+		return fmt.Sprintf("e2e:%s/%s/%s%s", c.EndToEndID, c.TxID, c.Amount.StringFixed(2), c.Currency)
+	}
+	return fmt.Sprintf("msg:%s#%d", messageID, index)
+}
+
+// NormalizeIBAN strips spaces, upper-cases and validates an IBAN's mod-97 check digits
+func NormalizeIBAN(s string) (string, error) {
+	iban := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	if !ibanPattern.MatchString(iban) {
+		return "", ErrInvalidIBAN
+	}
+
+	// Move the country code and check digits to the end and turn letters into numbers (A=10 ... Z=35)
+	rearranged := iban[4:] + iban[:4]
+	var digits strings.Builder
+	for _, r := range rearranged {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(big.NewInt(int64(r - 'A' + 10)).String())
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok || new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return "", ErrInvalidIBAN
+	}
+
+	return iban, nil
+}
+
+// AccountNumberFromIBAN extracts the account number from one of this bank's IBANs: the bank code
+// follows the check digits and the account number fills the last accountNumberLength characters
+func AccountNumberFromIBAN(s, bankCode string, accountNumberLength int) (string, error) {
+	iban, err := NormalizeIBAN(s)
+	if err != nil {
+		return "", err
+	}
+	if bankCode == "" || !strings.HasPrefix(iban[4:], strings.ToUpper(bankCode)) || len(iban)-4-len(bankCode) < accountNumberLength {
+		return "", ErrForeignIBAN
+	}
+	return iban[len(iban)-accountNumberLength:], nil
+}
+
+// number returns an account's IBAN or proprietary identifier
+func (a account) number() string {
+	if a.ID.IBAN != "" {
+		return strings.TrimSpace(a.ID.IBAN)
+	}
+	if a.ID.Other != nil {
+		return strings.TrimSpace(a.ID.Other.ID)
+	}
+	return ""
+}
+
+func remittanceText(r *remittance) string {
+	if r == nil {
+		return ""
+	}
+	return strings.TrimSpace(r.Unstructured)
+}
@@ -0,0 +1,131 @@
+package iso20022
+
+import (
+	"testing"
+	"time"
+
+	"payment/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const sampleCamt054 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.054.001.08">
+  <BkToCstmrDbtCdtNtfctn>
+    <GrpHdr><MsgId>NTF1</MsgId></GrpHdr>
+    <Ntfctn>
+      <Acct><Id><IBAN>AZ07DEMO00001001000000000001</IBAN></Id></Acct>
+      <Ntry>
+        <Amt Ccy="AZN">75.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>INV-9</EndToEndId><TxId>T9</TxId></Refs>
+          <Amt Ccy="AZN">75.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+          <RltdPties>
+            <Dbtr><Pty><Nm>Acme LLC</Nm></Pty></Dbtr>
+            <DbtrAcct><Id><IBAN>AZ87OTHR1001000000000001</IBAN></Id></DbtrAcct>
+            <CdtrAcct><Id><IBAN>AZ77DEMO00001001000000000002</IBAN></Id></CdtrAcct>
+          </RltdPties>
+          <RmtInf><Ustrd>invoice</Ustrd><Ustrd>9</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry><Amt Ccy="AZN">10.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts></Ntry>
+      <Ntry><Amt Ccy="AZN">5.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts></Ntry>
+      <Ntry><Amt Ccy="AZN">6.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts></Ntry>
+      <Ntry><Amt Ccy="AZN">7.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><RvslInd>true</RvslInd><Sts><Cd>BOOK</Cd></Sts></Ntry>
+    </Ntfctn>
+  </BkToCstmrDbtCdtNtfctn>
+</Document>`
+
+func TestParseInboundCamt054(t *testing.T) {
+	msg, err := ParseInbound([]byte(sampleCamt054))
+	if err != nil {
+		t.Fatalf("ParseInbound: %v", err)
+	}
+	if msg.Type != MessageTypeCamt054 || msg.MessageID != "NTF1" {
+		t.Errorf("message = %+v", msg)
+	}
+	if len(msg.Credits) != 2 {
+		t.Fatalf("got %d credits, want 2 (debit, pending and reversal entries skipped)", len(msg.Credits))
+	}
+
+	detailed := msg.Credits[0]
+	if !detailed.Amount.Equal(decimal.NewFromInt(75)) || detailed.Currency != "AZN" || detailed.EndToEndID != "INV-9" {
+		t.Errorf("detailed credit = %+v", detailed)
+	}
+	if detailed.DebtorName != "Acme LLC" || detailed.CreditorAccount != "AZ77DEMO00001001000000000002" || detailed.Remittance != "invoice 9" {
+		t.Errorf("detailed credit parties = %+v", detailed)
+	}
+
+	bare := msg.Credits[1]
+	if !bare.Amount.Equal(decimal.NewFromInt(10)) || bare.CreditorAccount != "AZ07DEMO00001001000000000001" {
+		t.Errorf("entry without details should credit the notified account, got %+v", bare)
+	}
+	if bare.DedupeKey("NTF1", 1) != "msg:NTF1#1" {
+		t.Errorf("DedupeKey() = %q", bare.DedupeKey("NTF1", 1))
+	}
+}
+
+func TestParseInboundPacs008(t *testing.T) {
+	ref := uuid.New()
+	payments := []models.Payment{{
+		ID: 1, ReferenceID: ref, AccountID: 9, UserID: 4, PaymentType: models.PaymentTypeExternal,
+		RecipientName: strPtr("Jane Doe"), RecipientAccount: strPtr("1001000000000002"),
+		Amount: decimal.RequireFromString("12.30"), Currency: "AZN", Description: strPtr("rent"),
+	}}
+	doc, err := BuildPacs008("IN1", time.Now(), time.Now(), Agent{BIC: "OTHRAZ22"}, payments)
+	if err != nil {
+		t.Fatalf("BuildPacs008: %v", err)
+	}
+
+	msg, err := ParseInbound(doc)
+	if err != nil {
+		t.Fatalf("ParseInbound: %v", err)
+	}
+	if msg.Type != MessageTypePacs008 || msg.MessageID != "IN1" || len(msg.Credits) != 1 {
+		t.Fatalf("message = %+v", msg)
+	}
+
+	credit := msg.Credits[0]
+	if credit.CreditorAccount != "1001000000000002" || credit.CreditorName != "Jane Doe" || credit.Remittance != "rent" {
+		t.Errorf("credit = %+v", credit)
+	}
+	if !credit.Amount.Equal(decimal.RequireFromString("12.30")) || credit.Currency != "AZN" {
+		t.Errorf("amount = %s %s", credit.Amount, credit.Currency)
+	}
+	if credit.DedupeKey("IN1", 0) != "uetr:"+ref.String() {
+		t.Errorf("DedupeKey() = %q, want the UETR", credit.DedupeKey("IN1", 0))
+	}
+}
+
+func TestParseInboundRejects(t *testing.T) {
+	if _, err := ParseInbound([]byte(`<Document><FIToFIPmtStsRpt/></Document>`)); err != ErrUnsupportedMessage {
+		t.Errorf("status report error = %v, want ErrUnsupportedMessage", err)
+	}
+	if _, err := ParseInbound([]byte("not xml")); err == nil {
+		t.Error("expected an error for invalid xml")
+	}
+}
+
+func TestAccountNumberFromIBAN(t *testing.T) {
+	tests := []struct {
+		name    string
+		iban    string
+		want    string
+		wantErr error
+	}{
+		{name: "own iban", iban: "AZ07DEMO00001001000000000001", want: "1001000000000001"},
+		{name: "spaced lower case", iban: "az07 demo 0000 1001 0000 0000 0001", want: "1001000000000001"},
+		{name: "other bank", iban: "AZ87OTHR1001000000000001", wantErr: ErrForeignIBAN},
+		{name: "bad check digits", iban: "AZ08DEMO00001001000000000001", wantErr: ErrInvalidIBAN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AccountNumberFromIBAN(tt.iban, "DEMO", 16)
+			if err != tt.wantErr || got != tt.want {
+				t.Errorf("AccountNumberFromIBAN(%q) = %q, %v; want %q, %v", tt.iban, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
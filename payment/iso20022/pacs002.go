@@ -0,0 +1,137 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotStatusReport = errors.New("document is not a pacs.002 payment status report")
+
+// Outcomes a pacs.002 status reports for a payment
+const (
+	OutcomeSettled  = "settled"
+	OutcomeReturned = "returned"
+	OutcomePending  = "pending" // accepted or received, but not yet final
+)
+
+// StatusReport is a pacs.002 FI to FI payment status report for one of our credit transfer messages
+type StatusReport struct {
+	MessageID         string
+	OriginalMessageID string
+	GroupStatus       string
+	GroupReason       string
+	Transactions      []TransactionStatus
+}
+
+// TransactionStatus is the status a report gives a single transaction of the original message
+type TransactionStatus struct {
+	OriginalEndToEndID string
+	OriginalUETR       string
+	Status             string
+	Reason             string
+}
+
+type pacs002Document struct {
+	Report *struct {
+		GroupHeader struct {
+			MessageID string `xml:"MsgId"`
+		} `xml:"GrpHdr"`
+		OriginalGroup struct {
+			MessageID string       `xml:"OrgnlMsgId"`
+			Status    string       `xml:"GrpSts"`
+			Reasons   []reasonInfo `xml:"StsRsnInf"`
+		} `xml:"OrgnlGrpInfAndSts"`
+		Transactions []struct {
+			EndToEndID string       `xml:"OrgnlEndToEndId"`
+			UETR       string       `xml:"OrgnlUETR"`
+			Status     string       `xml:"TxSts"`
+			Reasons    []reasonInfo `xml:"StsRsnInf"`
+		} `xml:"TxInfAndSts"`
+	} `xml:"FIToFIPmtStsRpt"`
+}
+
+type reasonInfo struct {
+	Code        string   `xml:"Rsn>Cd"`
+	Proprietary string   `xml:"Rsn>Prtry"`
+	Additional  []string `xml:"AddtlInf"`
+}
+
+// ParsePacs002 reads a pacs.002 status report. Element names are matched regardless of the
+// message version's namespace.
+func ParsePacs002(r io.Reader) (*StatusReport, error) {
+	var doc pacs002Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid xml: %w", err)
+	}
+	if doc.Report == nil {
+		return nil, ErrNotStatusReport
+	}
+
+	report := &StatusReport{
+		MessageID:         strings.TrimSpace(doc.Report.GroupHeader.MessageID),
+		OriginalMessageID: strings.TrimSpace(doc.Report.OriginalGroup.MessageID),
+		GroupStatus:       strings.TrimSpace(doc.Report.OriginalGroup.Status),
+		GroupReason:       describeReasons(doc.Report.OriginalGroup.Reasons),
+	}
+	if report.OriginalMessageID == "" {
+		return nil, fmt.Errorf("%w: missing OrgnlMsgId", ErrNotStatusReport)
+	}
+
+	for _, tx := range doc.Report.Transactions {
+		report.Transactions = append(report.Transactions, TransactionStatus{
+			OriginalEndToEndID: strings.TrimSpace(tx.EndToEndID),
+			OriginalUETR:       strings.TrimSpace(tx.UETR),
+			Status:             strings.TrimSpace(tx.Status),
+			Reason:             describeReasons(tx.Reasons),
+		})
+	}
+
+	return report, nil
+}
+
+// ReferenceID recovers the payment reference a transaction was sent under, from its UETR if the
+// report echoes one and from the end-to-end identification otherwise
+func (t TransactionStatus) ReferenceID() (uuid.UUID, error) {
+	if t.OriginalUETR != "" {
+		return uuid.Parse(t.OriginalUETR)
+	}
+	return uuid.Parse(t.OriginalEndToEndID)
+}
+
+// Outcome maps an ExternalPaymentTransactionStatus1Code (or group status) to what happens to the payment
+func Outcome(status string) string {
+	switch strings.ToUpper(status) {
+	case "ACSC", "ACCC":
+		return OutcomeSettled
+	case "RJCT":
+		return OutcomeReturned
+	default:
+		return OutcomePending
+	}
+}
+
+// describeReasons joins status reason codes and their additional information into one line
+func describeReasons(reasons []reasonInfo) string {
+	var parts []string
+	for _, r := range reasons {
+		code := r.Code
+		if code == "" {
+			code = r.Proprietary
+		}
+		text := strings.TrimSpace(strings.Join(r.Additional, " "))
+		switch {
+		case code != "" && text != "":
+			parts = append(parts, code+": "+text)
+		case code != "":
+			parts = append(parts, code)
+		case text != "":
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,82 @@
+package iso20022
+
+import (
+	"strings"
+	"testing"
+)
+
+const samplePacs002 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pacs.002.001.10">
+  <FIToFIPmtStsRpt>
+    <GrpHdr><MsgId>RPT1</MsgId><CreDtTm>2026-03-04T16:00:00Z</CreDtTm></GrpHdr>
+    <OrgnlGrpInfAndSts><OrgnlMsgId>MSG1</OrgnlMsgId><OrgnlMsgNmId>pacs.008.001.08</OrgnlMsgNmId><GrpSts>PART</GrpSts></OrgnlGrpInfAndSts>
+    <TxInfAndSts>
+      <OrgnlEndToEndId>6f1c2a3b4d5e4f608a7b9c0d1e2f3a4b</OrgnlEndToEndId>
+      <TxSts>ACSC</TxSts>
+    </TxInfAndSts>
+    <TxInfAndSts>
+      <OrgnlEndToEndId>ignored</OrgnlEndToEndId>
+      <OrgnlUETR>0b6e7f8a-1c2d-4e3f-9a0b-1c2d3e4f5a6b</OrgnlUETR>
+      <TxSts>RJCT</TxSts>
+      <StsRsnInf><Rsn><Cd>AC04</Cd></Rsn><AddtlInf>Account closed</AddtlInf></StsRsnInf>
+    </TxInfAndSts>
+  </FIToFIPmtStsRpt>
+</Document>`
+
+func TestParsePacs002(t *testing.T) {
+	report, err := ParsePacs002(strings.NewReader(samplePacs002))
+	if err != nil {
+		t.Fatalf("ParsePacs002: %v", err)
+	}
+	if report.MessageID != "RPT1" || report.OriginalMessageID != "MSG1" || report.GroupStatus != "PART" {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.Transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(report.Transactions))
+	}
+
+	settled := report.Transactions[0]
+	ref, err := settled.ReferenceID()
+	if err != nil || ref.String() != "6f1c2a3b-4d5e-4f60-8a7b-9c0d1e2f3a4b" {
+		t.Errorf("ReferenceID() = %v, %v", ref, err)
+	}
+	if Outcome(settled.Status) != OutcomeSettled {
+		t.Errorf("Outcome(%q) = %q", settled.Status, Outcome(settled.Status))
+	}
+
+	rejected := report.Transactions[1]
+	ref, err = rejected.ReferenceID()
+	if err != nil || ref.String() != "0b6e7f8a-1c2d-4e3f-9a0b-1c2d3e4f5a6b" {
+		t.Errorf("ReferenceID() should prefer the UETR, got %v, %v", ref, err)
+	}
+	if Outcome(rejected.Status) != OutcomeReturned || rejected.Reason != "AC04: Account closed" {
+		t.Errorf("rejected transaction = %+v", rejected)
+	}
+}
+
+func TestParsePacs002Rejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "not xml", input: "hello"},
+		{name: "other message", input: `<Document><FIToFICstmrCdtTrf/></Document>`},
+		{name: "missing original message", input: `<Document><FIToFIPmtStsRpt><GrpHdr><MsgId>R</MsgId></GrpHdr></FIToFIPmtStsRpt></Document>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePacs002(strings.NewReader(tt.input)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	for status, want := range map[string]string{"ACSC": OutcomeSettled, "accc": OutcomeSettled, "RJCT": OutcomeReturned, "ACSP": OutcomePending, "PDNG": OutcomePending} {
+		if got := Outcome(status); got != want {
+			t.Errorf("Outcome(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Pacs008Namespace is the version of the FI to FI customer credit transfer message we produce
+const Pacs008Namespace = "urn:iso:std:iso:20022:tech:xsd:pacs.008.001.08"
+
+// maxTextLength is the Max140Text limit applied to names and remittance information
+const maxTextLength = 140
+
+var ErrNoTransactions = errors.New("credit transfer message must contain at least one payment")
+
+var (
+	bicPattern  = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+)
+
+// Agent identifies the bank sending a credit transfer message
+type Agent struct {
+	BIC  string
+	Name string
+}
+
+type pacs008Document struct {
+	XMLName  xml.Name               `xml:"Document"`
+	Xmlns    string                 `xml:"xmlns,attr"`
+	Transfer customerCreditTransfer `xml:"FIToFICstmrCdtTrf"`
+}
+
+type customerCreditTransfer struct {
+	GroupHeader  groupHeader        `xml:"GrpHdr"`
+	Transactions []creditTransferTx `xml:"CdtTrfTxInf"`
+}
+
+type groupHeader struct {
+	MessageID        string         `xml:"MsgId"`
+	CreatedAt        string         `xml:"CreDtTm"`
+	NumberOfTxs      int            `xml:"NbOfTxs"`
+	ControlSum       string         `xml:"CtrlSum"`
+	SettlementDate   string         `xml:"IntrBkSttlmDt"`
+	Settlement       settlementInfo `xml:"SttlmInf"`
+	InstructingAgent agent          `xml:"InstgAgt"`
+}
+
+type settlementInfo struct {
+	Method string `xml:"SttlmMtd"`
+}
+
+type creditTransferTx struct {
+	PaymentID     paymentID    `xml:"PmtId"`
+	Amount        activeAmount `xml:"IntrBkSttlmAmt"`
+	ChargeBearer  string       `xml:"ChrgBr"`
+	Debtor        party        `xml:"Dbtr"`
+	DebtorAccount account      `xml:"DbtrAcct"`
+	DebtorAgent   agent        `xml:"DbtrAgt"`
+	CreditorAgent agent        `xml:"CdtrAgt"`
+	Creditor      party        `xml:"Cdtr"`
+	CreditorAcct  account      `xml:"CdtrAcct"`
+	Remittance    *remittance  `xml:"RmtInf,omitempty"`
+}
+
+type paymentID struct {
+	InstructionID string `xml:"InstrId"`
+	EndToEndID    string `xml:"EndToEndId"`
+	TxID          string `xml:"TxId"`
+	UETR          string `xml:"UETR"`
+}
+
+type activeAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type party struct {
+	Name string   `xml:"Nm,omitempty"`
+	ID   *partyID `xml:"Id,omitempty"`
+}
+
+type partyID struct {
+	Private privateID `xml:"PrvtId"`
+}
+
+type privateID struct {
+	Other otherID `xml:"Othr"`
+}
+
+type otherID struct {
+	ID string `xml:"Id"`
+}
+
+type account struct {
+	ID accountID `xml:"Id"`
+}
+
+type accountID struct {
+	IBAN  string   `xml:"IBAN,omitempty"`
+	Other *otherID `xml:"Othr,omitempty"`
+}
+
+type agent struct {
+	Institution institutionID `xml:"FinInstnId"`
+}
+
+type institutionID struct {
+	BIC   string   `xml:"BICFI,omitempty"`
+	Name  string   `xml:"Nm,omitempty"`
+	Other *otherID `xml:"Othr,omitempty"`
+}
+
+type remittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
+
+// EndToEndID is the identifier a payment travels under through clearing. A payment's reference
+// UUID is too long for Max35Text with its dashes, so it is sent as 32 hex digits (and in full as the UETR).
+func EndToEndID(payment *models.Payment) string {
+	return strings.ReplaceAll(payment.ReferenceID.String(), "-", "")
+}
+
+// BuildPacs008 renders external payments as a single pacs.008 credit transfer message sent by the
+// given bank for settlement on settlementDate. Amounts are totalled into CtrlSum regardless of currency.
+func BuildPacs008(messageID string, createdAt, settlementDate time.Time, sender Agent, payments []models.Payment) ([]byte, error) {
+	if len(payments) == 0 {
+		return nil, ErrNoTransactions
+	}
+
+	senderAgent := agent{Institution: institutionID{BIC: sender.BIC, Name: truncate(sender.Name)}}
+	controlSum := decimal.Zero
+	txs := make([]creditTransferTx, 0, len(payments))
+
+	for i := range payments {
+		p := &payments[i]
+		if p.PaymentType != models.PaymentTypeExternal {
+			return nil, fmt.Errorf("payment %d is not an external payment", p.ID)
+		}
+		controlSum = controlSum.Add(p.Amount)
+
+		tx := creditTransferTx{
+			PaymentID: paymentID{
+				InstructionID: strconv.FormatInt(p.ID, 10),
+				EndToEndID:    EndToEndID(p),
+				TxID:          strconv.FormatInt(p.ID, 10),
+				UETR:          p.ReferenceID.String(),
+			},
+			Amount:        activeAmount{Currency: p.Currency, Value: p.Amount.StringFixed(2)},
+			ChargeBearer:  "SLEV",
+			Debtor:        party{ID: &partyID{Private: privateID{Other: otherID{ID: strconv.FormatInt(p.UserID, 10)}}}},
+			DebtorAccount: account{ID: accountID{Other: &otherID{ID: strconv.FormatInt(p.AccountID, 10)}}},
+			DebtorAgent:   senderAgent,
+			CreditorAgent: creditorAgent(p.RecipientBank),
+			Creditor:      party{Name: truncate(deref(p.RecipientName))},
+			CreditorAcct:  creditorAccount(deref(p.RecipientAccount)),
+		}
+		if p.Description != nil && *p.Description != "" {
+			tx.Remittance = &remittance{Unstructured: truncate(*p.Description)}
+		}
+		txs = append(txs, tx)
+	}
+
+	doc := pacs008Document{
+		Xmlns: Pacs008Namespace,
+		Transfer: customerCreditTransfer{
+			GroupHeader: groupHeader{
+				MessageID:        messageID,
+				CreatedAt:        createdAt.UTC().Format("2006-01-02T15:04:05Z"),
+				NumberOfTxs:      len(txs),
+				ControlSum:       controlSum.StringFixed(2),
+				SettlementDate:   settlementDate.UTC().Format("2006-01-02"),
+				Settlement:       settlementInfo{Method: "CLRG"},
+				InstructingAgent: senderAgent,
+			},
+			Transactions: txs,
+		},
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode pacs.008: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// creditorAgent identifies the receiving bank by BIC when the recipient bank is one, by name otherwise
+func creditorAgent(bank *string) agent {
+	name := strings.TrimSpace(deref(bank))
+	switch {
+	case bicPattern.MatchString(strings.ToUpper(name)):
+		return agent{Institution: institutionID{BIC: strings.ToUpper(name)}}
+	case name != "":
+		return agent{Institution: institutionID{Name: truncate(name)}}
+	default:
+		return agent{Institution: institutionID{Other: &otherID{ID: "NOTPROVIDED"}}}
+	}
+}
+
+// creditorAccount sends IBANs as such and anything else as a proprietary account number
+func creditorAccount(number string) account {
+	compact := strings.ToUpper(strings.ReplaceAll(number, " ", ""))
+	if ibanPattern.MatchString(compact) {
+		return account{ID: accountID{IBAN: compact}}
+	}
+	return account{ID: accountID{Other: &otherID{ID: number}}}
+}
+
+func truncate(s string) string {
+	if r := []rune(s); len(r) > maxTextLength {
+		return string(r[:maxTextLength])
+	}
+	return s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
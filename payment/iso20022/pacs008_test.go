@@ -0,0 +1,80 @@
+package iso20022
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"payment/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestBuildPacs008(t *testing.T) {
+	ref := uuid.MustParse("6f1c2a3b-4d5e-4f60-8a7b-9c0d1e2f3a4b")
+	payments := []models.Payment{
+		{
+			ID: 7, ReferenceID: ref, AccountID: 3, UserID: 2, PaymentType: models.PaymentTypeExternal,
+			RecipientName: strPtr("Jane Doe"), RecipientAccount: strPtr("GB82 WEST 1234 5698 7654 32"),
+			RecipientBank: strPtr("westgb2l"), Amount: decimal.RequireFromString("100.5"), Currency: "EUR",
+			Description: strPtr("invoice 42"),
+		},
+		{
+			ID: 8, ReferenceID: uuid.New(), AccountID: 3, UserID: 2, PaymentType: models.PaymentTypeExternal,
+			RecipientName: strPtr("Acme"), RecipientAccount: strPtr("0012345"), RecipientBank: strPtr("Local Bank"),
+			Amount: decimal.RequireFromString("20"), Currency: "AZN",
+		},
+	}
+
+	created := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC)
+	out, err := BuildPacs008("MSG1", created, created, Agent{BIC: "DEMOAZ22"}, payments)
+	if err != nil {
+		t.Fatalf("BuildPacs008: %v", err)
+	}
+	if !bytes.Contains(out, []byte(`xmlns="`+Pacs008Namespace+`"`)) {
+		t.Errorf("missing namespace in %s", out)
+	}
+
+	var doc pacs008Document
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	hdr := doc.Transfer.GroupHeader
+	if hdr.NumberOfTxs != 2 || hdr.ControlSum != "120.50" || hdr.SettlementDate != "2026-03-04" {
+		t.Errorf("group header = %+v", hdr)
+	}
+
+	first := doc.Transfer.Transactions[0]
+	if first.PaymentID.EndToEndID != "6f1c2a3b4d5e4f608a7b9c0d1e2f3a4b" || first.PaymentID.UETR != ref.String() {
+		t.Errorf("payment id = %+v", first.PaymentID)
+	}
+	if first.Amount.Currency != "EUR" || first.Amount.Value != "100.50" {
+		t.Errorf("amount = %+v", first.Amount)
+	}
+	if first.CreditorAcct.ID.IBAN != "GB82WEST12345698765432" || first.CreditorAgent.Institution.BIC != "WESTGB2L" {
+		t.Errorf("creditor = %+v / %+v", first.CreditorAcct, first.CreditorAgent)
+	}
+
+	second := doc.Transfer.Transactions[1]
+	if second.CreditorAcct.ID.Other == nil || second.CreditorAcct.ID.Other.ID != "0012345" {
+		t.Errorf("non-IBAN creditor account = %+v", second.CreditorAcct)
+	}
+	if second.CreditorAgent.Institution.Name != "Local Bank" || second.Remittance != nil {
+		t.Errorf("second transaction = %+v", second)
+	}
+}
+
+func TestBuildPacs008Rejects(t *testing.T) {
+	if _, err := BuildPacs008("MSG1", time.Now(), time.Now(), Agent{}, nil); err != ErrNoTransactions {
+		t.Errorf("empty batch error = %v, want ErrNoTransactions", err)
+	}
+
+	bill := []models.Payment{{ID: 1, PaymentType: models.PaymentTypeBill, Amount: decimal.NewFromInt(1)}}
+	if _, err := BuildPacs008("MSG1", time.Now(), time.Now(), Agent{}, bill); err == nil {
+		t.Error("expected an error for a non-external payment")
+	}
+}
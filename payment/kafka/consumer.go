@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 
 	"payment/models"
@@ -12,12 +13,19 @@ import (
 )
 
 type Consumer struct {
-	completedReader *kafka.Reader
-	failedReader    *kafka.Reader
-	repo            repository.PaymentRepo
+	completedReader    *kafka.Reader
+	failedReader       *kafka.Reader
+	refundedReader     *kafka.Reader
+	refundFailedReader *kafka.Reader
+	repo               repository.PaymentRepo
+	refundRepo         repository.RefundRepo
+
+	// onDebited, if set, is called in its own goroutine for each payment that still has to be
+	// handed to a provider after its debit
+	onDebited func(ctx context.Context, payment *models.Payment)
 }
 
-func NewConsumer(brokers []string, groupID string, repo repository.PaymentRepo) *Consumer {
+func NewConsumer(brokers []string, groupID string, repo repository.PaymentRepo, refundRepo repository.RefundRepo) *Consumer {
 	completedReader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:     brokers,
 		Topic:       TopicPaymentCompleted,
@@ -36,17 +44,45 @@ func NewConsumer(brokers []string, groupID string, repo repository.PaymentRepo)
 		StartOffset: kafka.FirstOffset,
 	})
 
+	refundedReader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       TopicRefunded,
+		GroupID:     groupID,
+		MinBytes:    10e3,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	refundFailedReader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       TopicRefundFailed,
+		GroupID:     groupID,
+		MinBytes:    10e3,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+
 	return &Consumer{
-		completedReader: completedReader,
-		failedReader:    failedReader,
-		repo:            repo,
+		completedReader:    completedReader,
+		failedReader:       failedReader,
+		refundedReader:     refundedReader,
+		refundFailedReader: refundFailedReader,
+		repo:               repo,
+		refundRepo:         refundRepo,
 	}
 }
 
-// Start starts consuming messages from both topics
+// OnDebited sets the handler for payments that move to debited rather than completed
+func (c *Consumer) OnDebited(handler func(ctx context.Context, payment *models.Payment)) {
+	c.onDebited = handler
+}
+
+// Start starts consuming payment and refund result messages
 func (c *Consumer) Start(ctx context.Context) {
 	go c.consumeCompleted(ctx)
 	go c.consumeFailed(ctx)
+	go c.consumeRefundResults(ctx, c.refundedReader)
+	go c.consumeRefundResults(ctx, c.refundFailedReader)
 }
 
 func (c *Consumer) consumeCompleted(ctx context.Context) {
@@ -75,18 +111,38 @@ func (c *Consumer) consumeCompleted(ctx context.Context) {
 
 			log.Printf("Received payment.completed event for payment %d", event.PaymentID)
 
-			_, err = c.repo.MarkAsCompleted(ctx, event.PaymentID)
-			if err != nil {
-				log.Printf("Error marking payment %d as completed: %v", event.PaymentID, err)
-			} else {
-				log.Printf("Payment %d marked as completed", event.PaymentID)
-			}
+			c.applyDebit(ctx, event)
 
 			c.completedReader.CommitMessages(ctx, msg)
 		}
 	}
 }
 
+// applyDebit moves a payment the account service debited on: to completed, or to debited if a
+// provider still has to fulfil it
+func (c *Consumer) applyDebit(ctx context.Context, event models.PaymentResultEvent) {
+	payment, err := c.repo.GetByID(ctx, event.PaymentID)
+	if err != nil {
+		log.Printf("Error loading payment %d: %v", event.PaymentID, err)
+		return
+	}
+
+	status := models.DebitedStatus(payment)
+	updated, err := c.repo.UpdateStatus(ctx, event.PaymentID, status, nil, models.TransitionCauseAccountResult)
+	if errors.Is(err, repository.ErrInvalidTransition) {
+		log.Printf("Rejected payment.completed event for payment %d: %v", event.PaymentID, err)
+		return
+	} else if err != nil {
+		log.Printf("Error marking payment %d as %s: %v", event.PaymentID, status, err)
+		return
+	}
+	log.Printf("Payment %d marked as %s", event.PaymentID, status)
+
+	if status == models.PaymentStatusDebited && c.onDebited != nil {
+		go c.onDebited(ctx, updated)
+	}
+}
+
 func (c *Consumer) consumeFailed(ctx context.Context) {
 	log.Println("Starting payment.failed consumer")
 	for {
@@ -113,8 +169,10 @@ func (c *Consumer) consumeFailed(ctx context.Context) {
 
 			log.Printf("Received payment.failed event for payment %d: %s", event.PaymentID, event.FailureReason)
 
-			_, err = c.repo.MarkAsFailed(ctx, event.PaymentID, event.FailureReason)
-			if err != nil {
+			_, err = c.repo.MarkAsFailed(ctx, event.PaymentID, event.FailureReason, models.TransitionCauseAccountResult)
+			if errors.Is(err, repository.ErrInvalidTransition) {
+				log.Printf("Rejected payment.failed event for payment %d: %v", event.PaymentID, err)
+			} else if err != nil {
 				log.Printf("Error marking payment %d as failed: %v", event.PaymentID, err)
 			} else {
 				log.Printf("Payment %d marked as failed", event.PaymentID)
@@ -125,10 +183,58 @@ func (c *Consumer) consumeFailed(ctx context.Context) {
 	}
 }
 
-// Close closes both readers
+// consumeRefundResults applies payment.refunded or payment.refund_failed events to their refunds
+func (c *Consumer) consumeRefundResults(ctx context.Context, reader *kafka.Reader) {
+	topic := reader.Config().Topic
+	log.Printf("Starting %s consumer", topic)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Stopping %s consumer", topic)
+			return
+		default:
+			msg, err := reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Error fetching %s message: %v", topic, err)
+				continue
+			}
+
+			var event models.RefundResultEvent
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("Error unmarshaling %s event: %v", topic, err)
+				reader.CommitMessages(ctx, msg)
+				continue
+			}
+
+			log.Printf("Received %s event for refund %d of payment %d", topic, event.RefundID, event.PaymentID)
+
+			if event.Status == models.RefundStatusCompleted {
+				_, err = c.refundRepo.MarkCompleted(ctx, event.RefundID)
+			} else {
+				_, err = c.refundRepo.MarkFailed(ctx, event.RefundID, event.FailureReason)
+			}
+			if errors.Is(err, repository.ErrRefundSettled) {
+				log.Printf("Ignored %s event for refund %d: already settled", topic, event.RefundID)
+			} else if err != nil {
+				log.Printf("Error marking refund %d as %s: %v", event.RefundID, event.Status, err)
+			} else {
+				log.Printf("Refund %d marked as %s", event.RefundID, event.Status)
+			}
+
+			reader.CommitMessages(ctx, msg)
+		}
+	}
+}
+
+// Close closes all readers
 func (c *Consumer) Close() error {
-	if err := c.completedReader.Close(); err != nil {
-		return err
+	for _, reader := range []*kafka.Reader{c.completedReader, c.failedReader, c.refundedReader, c.refundFailedReader} {
+		if err := reader.Close(); err != nil {
+			return err
+		}
 	}
-	return c.failedReader.Close()
+	return nil
 }
@@ -10,16 +10,25 @@ import (
 	"payment/models"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/shopspring/decimal"
 )
 
 const (
 	TopicPaymentRequested = "payment.requested"
 	TopicPaymentCompleted = "payment.completed"
 	TopicPaymentFailed    = "payment.failed"
+	TopicOpsAlerts        = "ops.alerts"
+	TopicInboundCredited  = "payment.inbound_credited"
+	TopicRefundRequested  = "payment.refund_requested"
+	TopicRefunded         = "payment.refunded"
+	TopicRefundFailed     = "payment.refund_failed"
 )
 
 type Producer struct {
-	writer *kafka.Writer
+	writer        *kafka.Writer
+	alertWriter   *kafka.Writer
+	inboundWriter *kafka.Writer
+	refundWriter  *kafka.Writer
 }
 
 func NewProducer(brokers []string) *Producer {
@@ -32,7 +41,34 @@ func NewProducer(brokers []string) *Producer {
 		Async:        false,
 	}
 
-	return &Producer{writer: writer}
+	alertWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicOpsAlerts,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	inboundWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicInboundCredited,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	refundWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicRefundRequested,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	return &Producer{writer: writer, alertWriter: alertWriter, inboundWriter: inboundWriter, refundWriter: refundWriter}
 }
 
 // PublishPaymentRequested publishes a payment requested event
@@ -46,6 +82,15 @@ func (p *Producer) PublishPaymentRequested(ctx context.Context, payment *models.
 		recipientAccount = *payment.RecipientAccount
 	}
 
+	var settlementAccountID int64
+	if payment.SettlementAccountID != nil {
+		settlementAccountID = *payment.SettlementAccountID
+	}
+	fee := decimal.Zero
+	if payment.FeeAmount != nil {
+		fee = *payment.FeeAmount
+	}
+
 	event := models.PaymentRequestedEvent{
 		PaymentID:        payment.ID,
 		ReferenceID:      payment.ReferenceID.String(),
@@ -56,6 +101,9 @@ func (p *Producer) PublishPaymentRequested(ctx context.Context, payment *models.
 		RecipientAccount: recipientAccount,
 		Amount:           payment.Amount,
 		Currency:         payment.Currency,
+
+		SettlementAccountID: settlementAccountID,
+		Fee:                 fee,
 	}
 
 	value, err := json.Marshal(event)
@@ -80,9 +128,120 @@ func (p *Producer) PublishPaymentRequested(ctx context.Context, payment *models.
 	return nil
 }
 
+// PublishOpsAlert publishes an alert for operators
+func (p *Producer) PublishOpsAlert(ctx context.Context, alert models.OpsAlert) error {
+	value, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(alert.ReferenceID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte("ops.alert")},
+			{Key: "severity", Value: []byte(alert.Severity)},
+		},
+	}
+
+	if err := p.alertWriter.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish alert: %w", err)
+	}
+
+	return nil
+}
+
+// PublishInboundCredited publishes an incoming credit transfer that reached a customer's account
+func (p *Producer) PublishInboundCredited(ctx context.Context, event models.InboundCreditEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(event.ReferenceID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte("payment.inbound_credited")},
+			{Key: "credit_id", Value: []byte(fmt.Sprintf("%d", event.CreditID))},
+		},
+	}
+
+	if err := p.inboundWriter.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+// PublishRefundRequested publishes a refund for the account service to credit
+func (p *Producer) PublishRefundRequested(ctx context.Context, payment *models.Payment, refund *models.Refund) error {
+	recipientName := ""
+	if payment.RecipientName != nil {
+		recipientName = *payment.RecipientName
+	}
+	reason := ""
+	if refund.Reason != nil {
+		reason = *refund.Reason
+	}
+
+	var settlementAccountID int64
+	if payment.SettlementAccountID != nil {
+		settlementAccountID = *payment.SettlementAccountID
+	}
+
+	event := models.RefundRequestedEvent{
+		RefundID:         refund.ID,
+		ReferenceID:      refund.ReferenceID.String(),
+		PaymentID:        payment.ID,
+		PaymentReference: payment.ReferenceID.String(),
+		AccountID:        payment.AccountID,
+		UserID:           payment.UserID,
+		PaymentType:      payment.PaymentType,
+		RecipientName:    recipientName,
+		Amount:           refund.Amount,
+		Currency:         refund.Currency,
+		Reason:           reason,
+
+		SettlementAccountID: settlementAccountID,
+		Fee:                 refund.FeeAmount,
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(event.ReferenceID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte("payment.refund_requested")},
+			{Key: "payment_id", Value: []byte(fmt.Sprintf("%d", payment.ID))},
+			{Key: "refund_id", Value: []byte(fmt.Sprintf("%d", refund.ID))},
+		},
+	}
+
+	if err := p.refundWriter.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	log.Printf("Published payment.refund_requested event for refund %d of payment %d", refund.ID, payment.ID)
+	return nil
+}
+
 // Close closes the producer
 func (p *Producer) Close() error {
-	return p.writer.Close()
+	if err := p.writer.Close(); err != nil {
+		return err
+	}
+	if err := p.alertWriter.Close(); err != nil {
+		return err
+	}
+	if err := p.inboundWriter.Close(); err != nil {
+		return err
+	}
+	return p.refundWriter.Close()
 }
 
 // EnsureTopicExists creates the topic if it doesn't exist
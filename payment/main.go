@@ -7,16 +7,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"payment/cache"
 	"payment/db"
+	"payment/iso20022"
 	"payment/kafka"
 	"payment/models"
+	"payment/provider"
 	"payment/repository"
 
 	"github.com/gin-gonic/gin"
@@ -34,6 +35,23 @@ var (
 	paymentRepo   repository.PaymentRepo
 	kafkaProducer *kafka.Producer
 	kafkaConsumer *kafka.Consumer
+
+	clearingBatchRepo repository.ClearingBatchRepo
+	clearingCfg       clearingConfig
+	inboundRepo       repository.InboundCreditRepo
+	inboundCfg        inboundConfig
+	billerRepo        repository.BillerRepo
+	refundRepo        repository.RefundRepo
+	scheduleRepo      repository.ScheduleRepo
+	templateRepo      repository.TemplateRepo
+	merchantRepo      repository.MerchantRepo
+	qrCodeRepo        repository.QRCodeRepo
+	qrCfg             qrConfig
+	insightsRepo      repository.InsightsRepo
+
+	mobileOperatorRepo repository.MobileOperatorRepo
+	paymentProviders   map[string]provider.PaymentProvider
+	providerCfg        providerConfig
 )
 
 func main() {
@@ -77,6 +95,16 @@ func main() {
 	defer redisClient.Close()
 
 	paymentRepo = cache.NewCachedPaymentRepository(baseRepo, redisClient)
+	clearingBatchRepo = repository.NewClearingBatchRepository(dbPool)
+	inboundRepo = repository.NewInboundRepository(dbPool)
+	billerRepo = repository.NewBillerRepository(dbPool)
+	refundRepo = repository.NewRefundRepository(dbPool)
+	scheduleRepo = repository.NewScheduleRepository(dbPool)
+	templateRepo = repository.NewTemplateRepository(dbPool)
+	merchantRepo = repository.NewMerchantRepository(dbPool)
+	qrCodeRepo = repository.NewQRCodeRepository(dbPool)
+	insightsRepo = repository.NewInsightsRepository(dbPool)
+	mobileOperatorRepo = cache.NewCachedMobileOperatorRepository(repository.NewMobileOperatorRepository(dbPool), redisClient)
 
 	// Initialize Kafka
 	kafkaBrokers := strings.Split(getEnv("KAFKA_BROKERS", "localhost:9092"), ",")
@@ -85,29 +113,131 @@ func main() {
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicPaymentRequested)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicPaymentCompleted)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicPaymentFailed)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicOpsAlerts)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicInboundCredited)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicRefundRequested)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicRefunded)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicRefundFailed)
 
 	// Initialize producer
 	kafkaProducer = kafka.NewProducer(kafkaBrokers)
 	defer kafkaProducer.Close()
 
 	// Initialize consumer
-	kafkaConsumer = kafka.NewConsumer(kafkaBrokers, "payment-service", paymentRepo)
+	providerCfg, paymentProviders = loadProviderConfig()
+
+	kafkaConsumer = kafka.NewConsumer(kafkaBrokers, "payment-service", paymentRepo, refundRepo)
+	kafkaConsumer.OnDebited(submitToProvider)
 	kafkaConsumer.Start(ctx)
 	defer kafkaConsumer.Close()
 
+	// Recover payments and refunds whose saga stalled
+	go runSagaSweeper(ctx, loadSagaConfig())
+
+	// Follow up provider payments whose submission or callback is overdue
+	go runProviderSweeper(ctx, providerCfg)
+
+	// Submit completed external payments to interbank clearing at each cut-off
+	clearingCfg = loadClearingConfig()
+	go runClearingCutoff(ctx, clearingCfg)
+
+	// Pay due occurrences of recurring payment schedules
+	go runScheduler(ctx, scheduleConfig{Interval: getEnvDuration("PAYMENT_SCHEDULE_INTERVAL", "1m")})
+
+	// Import incoming credit transfers from the clearing inbound directory
+	inboundCfg = loadInboundConfig()
+	go runInboundImport(ctx, inboundCfg)
+
+	// Merchant QR codes
+	qrCfg = loadQRConfig()
+
+	// Anyone who knows the receipt key can forge receipts, so there is no default
+	receiptKey = []byte(os.Getenv("RECEIPT_SIGNING_KEY"))
+	if len(receiptKey) == 0 {
+		log.Fatal("RECEIPT_SIGNING_KEY must be set")
+	}
+
 	// Create Gin router
 	router := gin.Default()
 
 	// Health check endpoint
 	router.GET("/health", healthCheck)
 
+	// Provider callbacks are authenticated by the provider adapter rather than the gateway
+	router.POST("/api/payments/callbacks/:provider", handleProviderCallback)
+
+	// Anyone holding a receipt may check it
+	router.GET("/api/payments/receipts/verify", verifyPaymentReceipt)
+
 	// Payment endpoints
 	api := router.Group("/api/payments")
 	{
 		api.GET("", listPayments)
+		api.GET("/insights", getSpendingInsights)
 		api.GET("/mobile-operators", listMobileOperators)
+		api.GET("/billers", listBillers)
+		api.GET("/billers/:id", getBiller)
+		api.GET("/schedules", listPaymentSchedules)
+		api.GET("/schedules/:id", getPaymentSchedule)
+		api.GET("/schedules/:id/executions", listScheduleExecutions)
+		api.GET("/templates", listPaymentTemplates)
+		api.GET("/templates/:id", getPaymentTemplate)
+		api.GET("/merchants", listMerchants)
+		api.GET("/merchants/:id", getMerchant)
+		api.GET("/merchants/:id/transactions", listMerchantTransactions)
+		api.GET("/merchants/:id/settlements", getMerchantSettlements)
 		api.GET("/:id", getPayment)
+		api.GET("/:id/history", getPaymentHistory)
+		api.GET("/:id/refunds", listPaymentRefunds)
+		api.GET("/:id/receipt", getPaymentReceipt)
+		api.PUT("/:id/category", setPaymentCategory)
 		api.POST("", createPayment)
+		api.POST("/qr", payQRCode)
+		api.POST("/merchants/:id/qr", generateMerchantQRCode)
+		api.POST("/merchants/:id/payments/:payment_id/refund", refundMerchantPayment)
+
+		// Recurring payment schedules
+		api.POST("/schedules", createPaymentSchedule)
+		api.POST("/schedules/:id/pause", pausePaymentSchedule)
+		api.POST("/schedules/:id/resume", resumePaymentSchedule)
+		api.DELETE("/schedules/:id", cancelPaymentSchedule)
+
+		// Saved payment templates
+		api.POST("/templates", createPaymentTemplate)
+		api.PUT("/templates/:id", updatePaymentTemplate)
+		api.DELETE("/templates/:id", deletePaymentTemplate)
+
+		// Refunds (admin only)
+		api.POST("/:id/refund", refundPayment)
+
+		// Mobile operator configuration (admin only)
+		api.GET("/mobile-operators/:id", getMobileOperator)
+		api.POST("/mobile-operators", createMobileOperator)
+		api.PUT("/mobile-operators/:id", updateMobileOperator)
+		api.DELETE("/mobile-operators/:id", deleteMobileOperator)
+
+		// Acquired merchants (admin only)
+		api.POST("/merchants", createMerchant)
+		api.PUT("/merchants/:id", updateMerchant)
+		api.DELETE("/merchants/:id", deleteMerchant)
+
+		// Biller catalog (admin only)
+		api.POST("/billers", createBiller)
+		api.PUT("/billers/:id", updateBiller)
+		api.DELETE("/billers/:id", deleteBiller)
+
+		// Interbank clearing (admin only)
+		api.GET("/clearing/batches", listClearingBatches)
+		api.GET("/clearing/batches/:id", getClearingBatch)
+		api.POST("/clearing/cutoff", runClearingCutoffNow)
+		api.POST("/clearing/status-reports", importStatusReport)
+
+		// Incoming credit transfers and the suspense queue (admin only)
+		api.POST("/inbound/files", uploadInboundFile)
+		api.GET("/inbound/credits", listInboundCredits)
+		api.GET("/inbound/credits/:id", getInboundCredit)
+		api.POST("/inbound/credits/:id/resolve", resolveInboundCredit)
+		api.POST("/inbound/credits/:id/reject", rejectInboundCredit)
 	}
 
 	// Get port from environment or use default
@@ -133,6 +263,118 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvDuration reads a positive duration from the environment, exiting if it is invalid
+func getEnvDuration(key, defaultValue string) time.Duration {
+	d, err := time.ParseDuration(getEnv(key, defaultValue))
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s: %q", key, getEnv(key, defaultValue))
+	}
+	return d
+}
+
+// loadSagaConfig reads the saga recovery settings from the environment
+func loadSagaConfig() sagaConfig {
+	timeout, err := time.ParseDuration(getEnv("SAGA_TIMEOUT", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid SAGA_TIMEOUT: %v", err)
+	}
+
+	interval, err := time.ParseDuration(getEnv("SAGA_SWEEP_INTERVAL", "1m"))
+	if err != nil {
+		log.Fatalf("Invalid SAGA_SWEEP_INTERVAL: %v", err)
+	}
+
+	maxRepublish, err := strconv.Atoi(getEnv("SAGA_MAX_REPUBLISH", "3"))
+	if err != nil {
+		log.Fatalf("Invalid SAGA_MAX_REPUBLISH: %v", err)
+	}
+
+	return sagaConfig{Timeout: timeout, Interval: interval, MaxRepublish: maxRepublish}
+}
+
+// loadProviderConfig reads the payment provider settings from the environment and sets up the
+// configured providers
+func loadProviderConfig() (providerConfig, map[string]provider.PaymentProvider) {
+	// Anyone who knows the callback secret can confirm payments, so there is no default
+	callbackSecret := os.Getenv("SIMULATOR_CALLBACK_SECRET")
+	if callbackSecret == "" {
+		log.Fatal("SIMULATOR_CALLBACK_SECRET must be set")
+	}
+
+	simulator, err := provider.NewSimulator(provider.SimulatorConfig{
+		Mode:        getEnv("SIMULATOR_MODE", provider.SimulatorSucceed),
+		LateDelay:   getEnvDuration("SIMULATOR_LATE_DELAY", "30s"),
+		LateOutcome: getEnv("SIMULATOR_LATE_OUTCOME", provider.StatusConfirmed),
+		Secret:      callbackSecret,
+	})
+	if err != nil {
+		log.Fatalf("Invalid simulator configuration: %v", err)
+	}
+	providers := map[string]provider.PaymentProvider{
+		simulator.Name(): simulator,
+	}
+
+	cfg := providerConfig{
+		Default:       getEnv("PAYMENT_PROVIDER", provider.SimulatorName),
+		CallbackURL:   strings.TrimSuffix(getEnv("PROVIDER_CALLBACK_URL", "http://localhost:"+getEnv("PORT", "8080")+"/api/payments/callbacks"), "/"),
+		SubmitTimeout: getEnvDuration("PROVIDER_SUBMIT_TIMEOUT", "10s"),
+		RetryDelay:    getEnvDuration("PROVIDER_RETRY_DELAY", "1m"),
+		QueryAfter:    getEnvDuration("PROVIDER_QUERY_AFTER", "2m"),
+		MaxPending:    getEnvDuration("PROVIDER_MAX_PENDING", "30m"),
+		Interval:      getEnvDuration("PROVIDER_SWEEP_INTERVAL", "1m"),
+	}
+	if _, ok := providers[cfg.Default]; !ok {
+		log.Fatalf("Invalid PAYMENT_PROVIDER: %q", cfg.Default)
+	}
+
+	return cfg, providers
+}
+
+// loadClearingConfig reads the interbank clearing settings from the environment
+func loadClearingConfig() clearingConfig {
+	interval, err := time.ParseDuration(getEnv("CLEARING_CUTOFF_INTERVAL", "1h"))
+	if err != nil || interval <= 0 {
+		log.Fatalf("Invalid CLEARING_CUTOFF_INTERVAL: %q", getEnv("CLEARING_CUTOFF_INTERVAL", "1h"))
+	}
+
+	maxBatchSize, err := strconv.Atoi(getEnv("CLEARING_MAX_BATCH_SIZE", "1000"))
+	if err != nil || maxBatchSize <= 0 {
+		log.Fatalf("Invalid CLEARING_MAX_BATCH_SIZE: %q", getEnv("CLEARING_MAX_BATCH_SIZE", "1000"))
+	}
+
+	// MsgId is Max35Text: prefix, a 14 digit timestamp and an 8 character suffix
+	prefix := getEnv("CLEARING_MESSAGE_PREFIX", "DBNK")
+	if len(prefix) > 13 {
+		log.Fatalf("Invalid CLEARING_MESSAGE_PREFIX: %q is longer than 13 characters", prefix)
+	}
+
+	return clearingConfig{
+		Interval:    interval,
+		OutboundDir: getEnv("CLEARING_OUTBOUND_DIR", "/var/spool/clearing/outbound"),
+		Sender: iso20022.Agent{
+			BIC:  getEnv("CLEARING_BANK_BIC", "DEMOAZ22"),
+			Name: getEnv("CLEARING_BANK_NAME", "DemoBank"),
+		},
+		MessagePrefix: prefix,
+		MaxBatchSize:  maxBatchSize,
+	}
+}
+
+// loadInboundConfig reads the incoming credit transfer settings from the environment
+func loadInboundConfig() inboundConfig {
+	interval, err := time.ParseDuration(getEnv("CLEARING_INBOUND_POLL_INTERVAL", "1m"))
+	if err != nil || interval <= 0 {
+		log.Fatalf("Invalid CLEARING_INBOUND_POLL_INTERVAL: %q", getEnv("CLEARING_INBOUND_POLL_INTERVAL", "1m"))
+	}
+
+	return inboundConfig{
+		Dir:                 getEnv("CLEARING_INBOUND_DIR", "/var/spool/clearing/inbound"),
+		PollInterval:        interval,
+		IBANBankCode:        getEnv("CLEARING_IBAN_BANK_CODE", "DEMO"),
+		AccountNumberLength: 16,
+	}
+}
+
 func healthCheck(c *gin.Context) {
 	dbStatus := "connected"
 	if err := db.HealthCheck(c.Request.Context(), dbPool); err != nil {
@@ -233,8 +475,47 @@ func getPayment(c *gin.Context) {
 	c.JSON(http.StatusOK, payment)
 }
 
+// getPaymentHistory returns every status transition of a payment
+func getPaymentHistory(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	payment, err := paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment"})
+		return
+	}
+
+	if role != "admin" && payment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
+	history, err := paymentRepo.ListStatusHistory(ctx, paymentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payment_id": paymentID, "history": history})
+}
+
 func createPayment(c *gin.Context) {
-	userID, _, err := getUserContext(c)
+	userID, role, err := getUserContext(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -246,18 +527,31 @@ func createPayment(c *gin.Context) {
 		return
 	}
 
+	if !applyTemplate(c, userID, &req) {
+		return
+	}
+	// Every payment debits the caller's own account, whoever it pays
+	if !requireOwnAccount(c, userID, req.AccountID) {
+		return
+	}
+
 	if req.Amount.LessThanOrEqual(decimal.Zero) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
 		return
 	}
 
-	// Validate based on payment type
+	if !applyBeneficiary(c, userID, role, &req) {
+		return
+	}
+	if !applyBiller(c, &req) {
+		return
+	}
+	if !applyMerchant(c, &req) {
+		return
+	}
+
+	// Validate based on payment type; bill and acquired merchant payments were checked above
 	switch req.PaymentType {
-	case models.PaymentTypeBill:
-		if req.RecipientName == nil || *req.RecipientName == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_name required for bill payments"})
-			return
-		}
 	case models.PaymentTypeMerchant:
 		if req.RecipientName == nil || *req.RecipientName == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_name required for merchant payments"})
@@ -268,90 +562,71 @@ func createPayment(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_account required for external transfers"})
 			return
 		}
-	case models.PaymentTypeMobile:
-		if req.RecipientName == nil || *req.RecipientName == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_name (operator) required for mobile payments"})
-			return
-		}
-		if req.RecipientAccount == nil || *req.RecipientAccount == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_account (phone number) required for mobile payments"})
-			return
-		}
-		validPrefixes, ok := models.MobileOperators[*req.RecipientName]
-		if !ok {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mobile operator, must be one of: Azercell, Bakcell, Nar"})
-			return
-		}
-		phone := *req.RecipientAccount
-		if len(phone) != 10 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "phone number must be 10 digits (prefix + 7 digits)"})
+		if !applyCoolingOff(c, userID, role, &req) {
 			return
 		}
-		prefix := phone[:3]
-		remaining := phone[3:]
-		prefixValid := false
-		for _, p := range validPrefixes {
-			if p == prefix {
-				prefixValid = true
-				break
-			}
-		}
-		if !prefixValid {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "phone prefix does not match operator " + *req.RecipientName})
+	case models.PaymentTypeMobile:
+		if !applyMobileOperator(c, &req) {
 			return
 		}
-		if matched, _ := regexp.MatchString(`^\d{7}$`, remaining); !matched {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "phone number must end with exactly 7 digits"})
-			return
+	}
+
+	payment, ok := initiatePayment(c, userID, &req)
+	if !ok {
+		return
+	}
+
+	if req.TemplateID != nil {
+		if err := templateRepo.MarkUsed(c.Request.Context(), *req.TemplateID); err != nil {
+			log.Printf("Failed to mark payment template %d used: %v", *req.TemplateID, err)
 		}
 	}
 
-	// TODO: Verify user owns the source account by calling account service
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "payment initiated",
+		"payment_id":   payment.ID,
+		"reference_id": payment.ReferenceID,
+		"status":       payment.Status,
+	})
+}
 
-	// Create payment record
-	payment, err := paymentRepo.Create(c.Request.Context(), userID, &req)
+// initiatePayment records a validated payment request and publishes it for processing, responding
+// with an error if either fails
+func initiatePayment(c *gin.Context, userID int64, req *models.CreatePaymentRequest) (*models.Payment, bool) {
+	payment, err := paymentRepo.Create(c.Request.Context(), userID, req)
 	if err != nil {
 		if errors.Is(err, repository.ErrInvalidAmount) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
-			return
+			return nil, false
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create payment"})
-		return
+		return nil, false
 	}
 
 	// Mark as processing
-	payment, err = paymentRepo.MarkAsProcessing(c.Request.Context(), payment.ID)
-	if err != nil {
+	if processing, err := paymentRepo.MarkAsProcessing(c.Request.Context(), payment.ID); err != nil {
 		log.Printf("Failed to mark payment %d as processing: %v", payment.ID, err)
+	} else {
+		payment = processing
 	}
 
 	// Publish event to Kafka
-	if err := kafkaProducer.PublishPaymentRequested(c.Request.Context(), payment); err != nil {
-		log.Printf("Failed to publish payment event: %v", err)
-		// Mark as failed since we couldn't process it
-		paymentRepo.MarkAsFailed(c.Request.Context(), payment.ID, "failed to publish payment event")
+	if _, err := publishPayment(c.Request.Context(), payment); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate payment"})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusAccepted, gin.H{
-		"message":      "payment initiated",
-		"payment_id":   payment.ID,
-		"reference_id": payment.ReferenceID,
-		"status":       payment.Status,
-	})
+	return payment, true
 }
 
-func listMobileOperators(c *gin.Context) {
-	operators := make([]models.MobileOperator, 0, len(models.MobileOperators))
-	for name, prefixes := range models.MobileOperators {
-		operators = append(operators, models.MobileOperator{
-			Name:     name,
-			Prefixes: prefixes,
-		})
+// publishPayment publishes the payment requested event, failing the payment if that is not possible
+func publishPayment(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	if err := kafkaProducer.PublishPaymentRequested(ctx, payment); err != nil {
+		log.Printf("Failed to publish payment event: %v", err)
+		// Mark as failed since we couldn't process it
+		paymentRepo.MarkAsFailed(ctx, payment.ID, "failed to publish payment event", models.TransitionCausePublishFailed)
+		return nil, err
 	}
-	sort.Slice(operators, func(i, j int) bool {
-		return operators[i].Name < operators[j].Name
-	})
-	c.JSON(http.StatusOK, operators)
+
+	return payment, nil
 }
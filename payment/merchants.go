@@ -0,0 +1,346 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSettlementDays bounds the date range of a merchant settlement report
+const maxSettlementDays = 366
+
+// listMerchants lists acquired merchants: every merchant for admins, otherwise the merchants
+// settling into the caller's accounts
+func listMerchants(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if role == "admin" {
+		userID = 0
+	}
+	result, err := merchantRepo.List(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list merchants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getMerchant returns a merchant to its owner or an admin
+func getMerchant(c *gin.Context) {
+	merchant, ok := loadOwnMerchant(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, merchant)
+}
+
+// createMerchant registers a merchant for acquiring (admin only)
+func createMerchant(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req models.CreateMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merchant := models.Merchant{
+		Code:                strings.ToUpper(req.Code),
+		Name:                req.Name,
+		MCC:                 req.MCC,
+		SettlementAccountID: req.SettlementAccountID,
+		FeeRate:             req.FeeRate,
+		Currency:            strings.ToUpper(req.Currency),
+		City:                req.City,
+		CountryCode:         req.CountryCode,
+		Active:              true,
+	}
+	if !validateMerchant(c, &merchant) {
+		return
+	}
+
+	created, err := merchantRepo.Create(c.Request.Context(), &merchant)
+	if err != nil {
+		respondMerchantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// updateMerchant changes a merchant's details, fee rate or settlement account (admin only)
+func updateMerchant(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	existing, ok := loadMerchant(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merchant := req.Apply(*existing)
+	if !validateMerchant(c, &merchant) {
+		return
+	}
+
+	updated, err := merchantRepo.Update(c.Request.Context(), &merchant)
+	if err != nil {
+		respondMerchantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// deleteMerchant removes a merchant that has never been paid (admin only)
+func deleteMerchant(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	merchantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid merchant ID"})
+		return
+	}
+
+	if err := merchantRepo.Delete(c.Request.Context(), merchantID); err != nil {
+		respondMerchantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "merchant deleted"})
+}
+
+// listMerchantTransactions lists the payments made to a merchant with their service charge and
+// the amount credited, for the merchant's owner or an admin
+func listMerchantTransactions(c *gin.Context) {
+	merchant, ok := loadOwnMerchant(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	result, err := merchantRepo.ListTransactions(c.Request.Context(), merchant.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list merchant transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getMerchantSettlements totals what a merchant was credited each day between from and to
+// (YYYY-MM-DD, both inclusive, UTC). The range defaults to the last 30 days.
+func getMerchantSettlements(c *gin.Context) {
+	merchant, ok := loadOwnMerchant(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("to"); value != "" {
+		parsed, err := models.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD)"})
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -29)
+	if value := c.Query("from"); value != "" {
+		parsed, err := models.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD)"})
+			return
+		}
+		from = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+	if to.Sub(from) >= maxSettlementDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("date range must not exceed %d days", maxSettlementDays)})
+		return
+	}
+
+	days, err := merchantRepo.DailySettlements(c.Request.Context(), merchant.ID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to total merchant settlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MerchantSettlementResponse{
+		MerchantID: merchant.ID,
+		Currency:   merchant.Currency,
+		From:       from.Format(models.DateLayout),
+		To:         to.Format(models.DateLayout),
+		Days:       days,
+		Total:      models.TotalSettlements(days),
+	})
+}
+
+// validateMerchant checks the merchant's details and that its settlement account can receive its
+// currency. The merchant belongs to whoever owns the settlement account.
+func validateMerchant(c *gin.Context, merchant *models.Merchant) bool {
+	if merchant.CountryCode != nil {
+		country := strings.ToUpper(*merchant.CountryCode)
+		merchant.CountryCode = &country
+	}
+	if err := merchant.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	account, status, err := getAccountByID(merchant.SettlementAccountID)
+	if err != nil {
+		log.Printf("Failed to look up settlement account %d: %v", merchant.SettlementAccountID, err)
+		if status == http.StatusNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "settlement account not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up settlement account"})
+		}
+		return false
+	}
+
+	if account.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("settlement account is %s", account.Status)})
+		return false
+	}
+	if account.Currency != merchant.Currency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("settlement account is in %s, merchant accepts %s", account.Currency, merchant.Currency)})
+		return false
+	}
+
+	merchant.UserID = account.UserID
+	return true
+}
+
+// applyMerchant addresses a merchant payment to the acquired merchant it names: the merchant's
+// settlement account is credited with the amount less the service charge. Merchant payments
+// without a merchant_id pass through to be paid by the provider.
+func applyMerchant(c *gin.Context, req *models.CreatePaymentRequest) bool {
+	if req.MerchantID == nil {
+		return true
+	}
+
+	if req.PaymentType != models.PaymentTypeMerchant {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "merchant_id is only supported for merchant payments"})
+		return false
+	}
+
+	merchant, err := merchantRepo.GetByID(c.Request.Context(), *req.MerchantID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMerchantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "merchant not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up merchant"})
+		return false
+	}
+
+	if req.Currency == "" {
+		req.Currency = merchant.Currency
+	}
+	fee, err := merchant.ValidatePayment(req.Amount, strings.ToUpper(req.Currency))
+	if err != nil {
+		if errors.Is(err, models.ErrMerchantUnavailable) || errors.Is(err, models.ErrAmountBelowFee) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	req.Currency = merchant.Currency
+	req.RecipientName = &merchant.Name
+	req.RecipientAccount = &merchant.Code
+	req.RecipientBank = nil
+	req.SettlementAccountID = &merchant.SettlementAccountID
+	req.FeeAmount = &fee
+	req.Category = models.CategoryForMCC(merchant.MCC)
+	return true
+}
+
+func loadMerchant(c *gin.Context) (*models.Merchant, bool) {
+	merchantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid merchant ID"})
+		return nil, false
+	}
+
+	merchant, err := merchantRepo.GetByID(c.Request.Context(), merchantID)
+	if err != nil {
+		respondMerchantError(c, err)
+		return nil, false
+	}
+
+	return merchant, true
+}
+
+// loadOwnMerchant loads a merchant for its owner or an admin
+func loadOwnMerchant(c *gin.Context) (*models.Merchant, bool) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	merchant, ok := loadMerchant(c)
+	if !ok {
+		return nil, false
+	}
+	if role != "admin" && merchant.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return nil, false
+	}
+
+	return merchant, true
+}
+
+func respondMerchantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrMerchantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "merchant not found"})
+	case errors.Is(err, repository.ErrMerchantCodeExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "merchant code already exists"})
+	case errors.Is(err, repository.ErrMerchantInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "merchant has payments; deactivate it instead"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save merchant"})
+	}
+}
@@ -0,0 +1,8 @@
+-- Track how often the saga sweeper has re-published a stuck payment
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS saga_attempts INT NOT NULL DEFAULT 0;
+
+-- Lets the sweeper find stale in-flight payments cheaply
+CREATE INDEX IF NOT EXISTS idx_payments_status_updated_at ON payments(status, updated_at);
+
+-- Add comments for documentation
+COMMENT ON COLUMN payments.saga_attempts IS 'Number of times the saga sweeper re-published this payment';
@@ -0,0 +1,17 @@
+-- Audit trail of every payment status transition
+CREATE TABLE IF NOT EXISTS payment_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    payment_id BIGINT NOT NULL REFERENCES payments(id),
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    cause VARCHAR(50) NOT NULL,
+    detail TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_status_history_payment_id ON payment_status_history(payment_id, created_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE payment_status_history IS 'Every status transition applied to a payment';
+COMMENT ON COLUMN payment_status_history.cause IS 'What triggered the transition: dispatched, publish_failed, account_result, saga_recovery, saga_timeout';
+COMMENT ON COLUMN payment_status_history.detail IS 'Failure reason, if any';
@@ -0,0 +1,7 @@
+-- Saved beneficiary (owned by the transfer service) an external payment was sent to
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS beneficiary_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_payments_beneficiary_id ON payments(beneficiary_id);
+
+-- Add comments for documentation
+COMMENT ON COLUMN payments.beneficiary_id IS 'Transfer service beneficiary the recipient details were taken from, if any';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_clearing_batch_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS clearing_batch_id;
+DROP TABLE IF EXISTS clearing_batches;
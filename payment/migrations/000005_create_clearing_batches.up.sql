@@ -0,0 +1,27 @@
+-- Outbound ISO 20022 pacs.008 messages external payments are submitted to clearing in
+CREATE TABLE IF NOT EXISTS clearing_batches (
+    id BIGSERIAL PRIMARY KEY,
+    message_id VARCHAR(35) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    payment_count INT NOT NULL,
+    control_sum DECIMAL(18,2) NOT NULL,
+    file_name VARCHAR(255),
+    window_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_clearing_batches_status ON clearing_batches(status);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS clearing_batch_id BIGINT REFERENCES clearing_batches(id);
+
+CREATE INDEX IF NOT EXISTS idx_payments_clearing_batch_id ON payments(clearing_batch_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE clearing_batches IS 'pacs.008 credit transfer messages built at each clearing cut-off';
+COMMENT ON COLUMN clearing_batches.message_id IS 'GrpHdr/MsgId of the pacs.008 message; pacs.002 reports refer back to it';
+COMMENT ON COLUMN clearing_batches.status IS 'Batch status: pending (built, not yet written out) or sent';
+COMMENT ON COLUMN clearing_batches.control_sum IS 'Sum of the batched payment amounts (GrpHdr/CtrlSum)';
+COMMENT ON COLUMN clearing_batches.window_end IS 'Cut-off that closed the window the batch covers';
+COMMENT ON COLUMN payments.clearing_batch_id IS 'Clearing batch an external payment was submitted in, if any';
+COMMENT ON COLUMN payments.status IS 'Payment status: pending, processing, completed, failed, sent_to_clearing, settled, or returned';
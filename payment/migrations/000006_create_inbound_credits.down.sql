@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS inbound_credits;
+DROP TABLE IF EXISTS inbound_files;
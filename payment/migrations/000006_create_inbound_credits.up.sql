@@ -0,0 +1,50 @@
+-- Incoming ISO 20022 files and the credit transfers read from them
+CREATE TABLE IF NOT EXISTS inbound_files (
+    id BIGSERIAL PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL,
+    sha256 CHAR(64) NOT NULL UNIQUE,
+    message_type VARCHAR(20) NOT NULL,
+    message_id VARCHAR(35),
+    credit_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS inbound_credits (
+    id BIGSERIAL PRIMARY KEY,
+    reference_id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    file_id BIGINT NOT NULL REFERENCES inbound_files(id),
+    dedupe_key VARCHAR(255) NOT NULL UNIQUE,
+    end_to_end_id VARCHAR(35),
+    amount DECIMAL(15,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    debtor_name VARCHAR(140),
+    debtor_account VARCHAR(50),
+    creditor_name VARCHAR(140),
+    creditor_account VARCHAR(50),
+    remittance_info TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    account_id BIGINT,
+    user_id BIGINT,
+    suspense_reason TEXT,
+    resolved_by BIGINT,
+    resolution_note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    credited_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_inbound_credits_status ON inbound_credits(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_inbound_credits_file_id ON inbound_credits(file_id);
+
+CREATE TRIGGER update_inbound_credits_updated_at BEFORE UPDATE ON inbound_credits
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE inbound_files IS 'pacs.008 / camt.054 files imported from the clearing inbound directory';
+COMMENT ON COLUMN inbound_files.sha256 IS 'Content hash; the same file is never imported twice';
+COMMENT ON TABLE inbound_credits IS 'Incoming credit transfers and the suspense queue of those that could not be matched';
+COMMENT ON COLUMN inbound_credits.reference_id IS 'Reference the account service records the credit under';
+COMMENT ON COLUMN inbound_credits.dedupe_key IS 'UETR or end-to-end reference; the same transfer reported twice is credited once';
+COMMENT ON COLUMN inbound_credits.status IS 'Credit status: pending, credited, suspense, or rejected';
+COMMENT ON COLUMN inbound_credits.suspense_reason IS 'Why the credit could not be applied automatically';
+COMMENT ON COLUMN inbound_credits.resolved_by IS 'Admin who credited or rejected a suspense item';
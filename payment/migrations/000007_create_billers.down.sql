@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_biller_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS settlement_account_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS biller_id;
+DROP TABLE IF EXISTS billers;
@@ -0,0 +1,36 @@
+-- Bill payment catalog: who can be paid, how their customer references look, and where the money goes
+CREATE TABLE IF NOT EXISTS billers (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    name VARCHAR(140) NOT NULL,
+    category VARCHAR(20) NOT NULL,
+    reference_label VARCHAR(64) NOT NULL DEFAULT '',
+    reference_pattern VARCHAR(255) NOT NULL,
+    check_digit VARCHAR(10) NOT NULL DEFAULT 'none',
+    min_amount DECIMAL(15,2),
+    max_amount DECIMAL(15,2),
+    currency VARCHAR(3) NOT NULL,
+    settlement_account_id BIGINT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_billers_category ON billers(category, name);
+
+CREATE TRIGGER update_billers_updated_at BEFORE UPDATE ON billers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS biller_id BIGINT REFERENCES billers(id);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS settlement_account_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_payments_biller_id ON payments(biller_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE billers IS 'Utility, telecom and tax billers customers can pay bills to';
+COMMENT ON COLUMN billers.category IS 'Biller category: utility, telecom, or tax';
+COMMENT ON COLUMN billers.reference_pattern IS 'Regular expression the whole customer reference must match';
+COMMENT ON COLUMN billers.check_digit IS 'Check digit scheme of the customer reference: none, luhn, or mod97';
+COMMENT ON COLUMN billers.settlement_account_id IS 'Account service account collected funds are credited to';
+COMMENT ON COLUMN payments.biller_id IS 'Biller a bill payment was made to';
+COMMENT ON COLUMN payments.settlement_account_id IS 'Account the payment is credited to, fixed when the payment is created';
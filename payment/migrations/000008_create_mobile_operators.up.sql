@@ -0,0 +1,27 @@
+-- Mobile operators customers can top up numbers with
+CREATE TABLE IF NOT EXISTS mobile_operators (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    prefixes TEXT[] NOT NULL,
+    subscriber_digits INT NOT NULL DEFAULT 7,
+    denominations DECIMAL(15,2)[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_mobile_operators_updated_at BEFORE UPDATE ON mobile_operators
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- The operators previously built into the service
+INSERT INTO mobile_operators (name, prefixes, subscriber_digits) VALUES
+    ('Azercell', '{050,051}', 7),
+    ('Bakcell', '{055,099}', 7),
+    ('Nar', '{070,077}', 7)
+ON CONFLICT (name) DO NOTHING;
+
+-- Add comments for documentation
+COMMENT ON TABLE mobile_operators IS 'Mobile operators, their number prefixes and top-up denominations';
+COMMENT ON COLUMN mobile_operators.prefixes IS 'National prefixes of the operator''s numbers, e.g. 050';
+COMMENT ON COLUMN mobile_operators.subscriber_digits IS 'Number of digits following the prefix';
+COMMENT ON COLUMN mobile_operators.denominations IS 'Top-up amounts the operator accepts; empty accepts any amount';
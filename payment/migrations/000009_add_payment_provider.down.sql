@@ -0,0 +1,3 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS submitted_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS provider_reference;
+ALTER TABLE payments DROP COLUMN IF EXISTS provider;
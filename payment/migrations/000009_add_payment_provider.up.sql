@@ -0,0 +1,9 @@
+-- Provider that fulfils a mobile top-up or merchant payment after the debit
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider VARCHAR(32);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider_reference VARCHAR(64);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS submitted_at TIMESTAMP WITH TIME ZONE;
+
+-- Add comments for documentation
+COMMENT ON COLUMN payments.provider IS 'Provider adapter the payment was submitted to';
+COMMENT ON COLUMN payments.provider_reference IS 'The provider''s own reference for the payment';
+COMMENT ON COLUMN payments.submitted_at IS 'When the provider accepted the payment';
@@ -0,0 +1,27 @@
+-- Refunds of completed payments; a payment may be refunded in several parts up to its amount
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id BIGSERIAL PRIMARY KEY,
+    reference_id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    payment_id BIGINT NOT NULL REFERENCES payments(id),
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    failure_reason TEXT,
+    requested_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment_id ON payment_refunds(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_status ON payment_refunds(status, created_at);
+
+CREATE TRIGGER update_payment_refunds_updated_at BEFORE UPDATE ON payment_refunds
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE payment_refunds IS 'Full and partial refunds of payments, credited back by the account service';
+COMMENT ON COLUMN payment_refunds.reference_id IS 'Reference the account service records the refund credit under';
+COMMENT ON COLUMN payment_refunds.status IS 'Refund status: pending, completed, or failed';
+COMMENT ON COLUMN payment_refunds.requested_by IS 'User who requested the refund';
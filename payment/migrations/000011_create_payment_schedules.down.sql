@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payment_schedule_executions;
+DROP TABLE IF EXISTS payment_schedules;
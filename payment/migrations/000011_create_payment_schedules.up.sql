@@ -0,0 +1,65 @@
+-- Recurring payment schedules; each due occurrence creates an ordinary payment
+CREATE TABLE IF NOT EXISTS payment_schedules (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    account_id BIGINT NOT NULL,
+    payment_type VARCHAR(20) NOT NULL,
+    biller_id BIGINT REFERENCES billers(id),
+    recipient_name VARCHAR(100),
+    recipient_account VARCHAR(50),
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    frequency VARCHAR(10) NOT NULL,
+    day_of_week SMALLINT CHECK (day_of_week BETWEEN 0 AND 6),
+    day_of_month SMALLINT CHECK (day_of_month BETWEEN 1 AND 31),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    max_retries INT NOT NULL DEFAULT 3,
+    retry_interval_hours INT NOT NULL DEFAULT 24,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    occurrence_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    active_execution_id BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_schedules_user_id ON payment_schedules(user_id);
+CREATE INDEX IF NOT EXISTS idx_payment_schedules_due ON payment_schedules(next_run_at) WHERE status = 'active';
+
+CREATE TRIGGER update_payment_schedules_updated_at BEFORE UPDATE ON payment_schedules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- One row per attempt to pay an occurrence of a schedule
+CREATE TABLE IF NOT EXISTS payment_schedule_executions (
+    id BIGSERIAL PRIMARY KEY,
+    schedule_id BIGINT NOT NULL REFERENCES payment_schedules(id),
+    payment_id BIGINT REFERENCES payments(id),
+    occurrence_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempt INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    failure_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_schedule_executions_schedule_id ON payment_schedule_executions(schedule_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_payment_schedule_executions_pending ON payment_schedule_executions(created_at) WHERE status = 'pending';
+
+CREATE TRIGGER update_payment_schedule_executions_updated_at BEFORE UPDATE ON payment_schedule_executions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE payment_schedules IS 'Recurring bill payments and mobile top-ups paid on a daily, weekly or monthly schedule';
+COMMENT ON COLUMN payment_schedules.day_of_week IS 'Weekly schedules: day paid on, 0 = Sunday';
+COMMENT ON COLUMN payment_schedules.day_of_month IS 'Monthly schedules: day paid on; months without that day are paid on their last day';
+COMMENT ON COLUMN payment_schedules.status IS 'Schedule status: active, paused, completed, or cancelled';
+COMMENT ON COLUMN payment_schedules.occurrence_at IS 'Occurrence currently due or being paid';
+COMMENT ON COLUMN payment_schedules.next_run_at IS 'When the occurrence is next attempted: the occurrence itself or a retry';
+COMMENT ON COLUMN payment_schedules.attempts IS 'Attempts made to pay the current occurrence';
+COMMENT ON COLUMN payment_schedules.active_execution_id IS 'Execution whose payment is still in flight';
+COMMENT ON TABLE payment_schedule_executions IS 'Execution history of payment schedules';
+COMMENT ON COLUMN payment_schedule_executions.status IS 'Execution status: pending, succeeded, or failed';
@@ -0,0 +1,30 @@
+-- Saved payments a customer can repeat; every field but the name and type may be left for the payment to fill in
+CREATE TABLE IF NOT EXISTS payment_templates (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    payment_type VARCHAR(20) NOT NULL,
+    account_id BIGINT,
+    beneficiary_id BIGINT,
+    biller_id BIGINT REFERENCES billers(id) ON DELETE SET NULL,
+    recipient_name VARCHAR(100),
+    recipient_account VARCHAR(50),
+    recipient_bank VARCHAR(100),
+    amount DECIMAL(15, 2) CHECK (amount > 0),
+    currency VARCHAR(3),
+    description TEXT,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TRIGGER update_payment_templates_updated_at BEFORE UPDATE ON payment_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE payment_templates IS 'Saved payments customers can make again with optional overrides';
+COMMENT ON COLUMN payment_templates.name IS 'Name the template is known by, unique per user';
+COMMENT ON COLUMN payment_templates.beneficiary_id IS 'Saved beneficiary in the transfer service an external payment is made to';
+COMMENT ON COLUMN payment_templates.amount IS 'Default amount, used when the payment does not give one';
+COMMENT ON COLUMN payment_templates.last_used_at IS 'When a payment was last created from the template';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payments_merchant_id;
+ALTER TABLE payment_templates DROP COLUMN IF EXISTS merchant_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS fee_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS merchant_id;
+DROP TABLE IF EXISTS merchants;
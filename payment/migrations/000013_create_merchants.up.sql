@@ -0,0 +1,35 @@
+-- Merchants acquired by the bank: card-present and online merchants paid in-house rather than through a provider
+CREATE TABLE IF NOT EXISTS merchants (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    name VARCHAR(140) NOT NULL,
+    mcc CHAR(4) NOT NULL CHECK (mcc ~ '^[0-9]{4}$'),
+    user_id BIGINT NOT NULL,
+    settlement_account_id BIGINT NOT NULL,
+    fee_rate DECIMAL(6, 4) NOT NULL DEFAULT 0 CHECK (fee_rate >= 0 AND fee_rate < 1),
+    currency VARCHAR(3) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchants_user_id ON merchants(user_id);
+
+CREATE TRIGGER update_merchants_updated_at BEFORE UPDATE ON merchants
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS merchant_id BIGINT REFERENCES merchants(id);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS fee_amount DECIMAL(15, 2);
+ALTER TABLE payment_templates ADD COLUMN IF NOT EXISTS merchant_id BIGINT REFERENCES merchants(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_payments_merchant_id ON payments(merchant_id, created_at) WHERE merchant_id IS NOT NULL;
+
+-- Add comments for documentation
+COMMENT ON TABLE merchants IS 'Merchants whose card and QR payments the bank acquires and settles itself';
+COMMENT ON COLUMN merchants.code IS 'Merchant ID assigned by the bank';
+COMMENT ON COLUMN merchants.mcc IS 'ISO 18245 merchant category code';
+COMMENT ON COLUMN merchants.user_id IS 'Owner of the settlement account, who can see the merchant dashboard';
+COMMENT ON COLUMN merchants.fee_rate IS 'Share of each payment kept by the bank as the merchant service charge, e.g. 0.0150';
+COMMENT ON COLUMN payments.merchant_id IS 'Acquired merchant a merchant payment was made to';
+COMMENT ON COLUMN payments.fee_amount IS 'Merchant service charge withheld from the merchant''s credit';
+COMMENT ON COLUMN payment_templates.merchant_id IS 'Acquired merchant a merchant payment template pays';
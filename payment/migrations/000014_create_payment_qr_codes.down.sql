@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS payment_qr_codes;
+ALTER TABLE merchants DROP COLUMN IF EXISTS country_code;
+ALTER TABLE merchants DROP COLUMN IF EXISTS city;
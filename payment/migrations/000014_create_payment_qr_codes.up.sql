@@ -0,0 +1,29 @@
+-- Merchant location printed on EMVCo QR codes
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS city VARCHAR(60);
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS country_code CHAR(2) CHECK (country_code ~ '^[A-Z]{2}$');
+
+-- Single-use dynamic QR codes issued to merchants for one payment of a set amount
+CREATE TABLE IF NOT EXISTS payment_qr_codes (
+    id BIGSERIAL PRIMARY KEY,
+    merchant_id BIGINT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    reference VARCHAR(25) NOT NULL UNIQUE,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    payload TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    payment_id BIGINT REFERENCES payments(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_qr_codes_merchant_id ON payment_qr_codes(merchant_id, created_at);
+
+-- Add comments for documentation
+COMMENT ON COLUMN merchants.city IS 'Merchant city shown on its QR codes';
+COMMENT ON COLUMN merchants.country_code IS 'ISO 3166-1 alpha-2 country of the merchant shown on its QR codes';
+COMMENT ON TABLE payment_qr_codes IS 'Dynamic EMVCo QR codes, each payable once before it expires';
+COMMENT ON COLUMN payment_qr_codes.reference IS 'Reference label carried in the QR payload that identifies the code';
+COMMENT ON COLUMN payment_qr_codes.payload IS 'EMVCo QR payload string the merchant displays';
+COMMENT ON COLUMN payment_qr_codes.used_at IS 'When a customer claimed the code to pay it';
+COMMENT ON COLUMN payment_qr_codes.payment_id IS 'Payment made by scanning the code';
@@ -0,0 +1,40 @@
+-- Spending category of a payment, derived from its biller, merchant or type and overridable by the payer
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS category VARCHAR(20) NOT NULL DEFAULT 'general';
+
+-- Categorize existing payments the way new ones are
+UPDATE payments p
+SET category = CASE b.category
+    WHEN 'utility' THEN 'utilities'
+    ELSE b.category
+END
+FROM billers b
+WHERE p.biller_id = b.id;
+
+UPDATE payments p
+SET category = CASE
+    WHEN m.mcc IN ('5411', '5422', '5441', '5451', '5462', '5499') THEN 'groceries'
+    WHEN m.mcc IN ('5811', '5812', '5813', '5814') THEN 'dining'
+    WHEN m.mcc IN ('4111', '4112', '4121', '4131', '4789', '5541', '5542', '7523') THEN 'transport'
+    WHEN m.mcc BETWEEN '3000' AND '3999' OR m.mcc IN ('4411', '4511', '4722', '7011', '7012') THEN 'travel'
+    WHEN m.mcc IN ('5815', '5816', '5817', '5818', '7832', '7841', '7911', '7922', '7929', '7932', '7933', '7941')
+        OR m.mcc BETWEEN '7991' AND '7999' THEN 'entertainment'
+    WHEN m.mcc IN ('5122', '5912') OR m.mcc BETWEEN '8011' AND '8099' THEN 'health'
+    WHEN m.mcc IN ('4812', '4814', '4899') THEN 'telecom'
+    WHEN m.mcc = '4900' THEN 'utilities'
+    WHEN m.mcc = '9311' THEN 'tax'
+    WHEN m.mcc BETWEEN '5000' AND '5999' THEN 'shopping'
+    ELSE 'general'
+END
+FROM merchants m
+WHERE p.merchant_id = m.id;
+
+UPDATE payments
+SET category = CASE payment_type
+    WHEN 'bill' THEN 'utilities'
+    WHEN 'mobile' THEN 'telecom'
+    WHEN 'merchant' THEN 'shopping'
+END
+WHERE biller_id IS NULL AND merchant_id IS NULL AND payment_type IN ('bill', 'mobile', 'merchant');
+
+-- Add comments for documentation
+COMMENT ON COLUMN payments.category IS 'Spending category: from the biller, the merchant category code or the payment type unless the payer reassigned it';
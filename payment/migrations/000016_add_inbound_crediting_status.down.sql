@@ -0,0 +1,3 @@
+UPDATE inbound_credits SET status = 'pending', account_id = NULL, user_id = NULL WHERE status = 'crediting';
+COMMENT ON COLUMN inbound_credits.status IS 'Credit status: pending, credited, suspense, or rejected';
+COMMENT ON COLUMN inbound_credits.account_id IS NULL;
@@ -0,0 +1,6 @@
+-- Credits are claimed for their account (status crediting) before the account service is called,
+-- so that an admin cannot reject one that is being credited. No schema change is needed.
+
+-- Add comments for documentation
+COMMENT ON COLUMN inbound_credits.status IS 'Credit status: pending, crediting, credited, suspense, or rejected';
+COMMENT ON COLUMN inbound_credits.account_id IS 'Account the credit is being or was credited to';
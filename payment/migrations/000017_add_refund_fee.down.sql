@@ -0,0 +1 @@
+ALTER TABLE payment_refunds DROP COLUMN IF EXISTS fee_amount;
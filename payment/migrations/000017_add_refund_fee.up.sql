@@ -0,0 +1,6 @@
+-- The service charge withheld on an acquired merchant payment is refunded in proportion to the
+-- payment, out of the bank's fee revenue, rather than from the merchant
+ALTER TABLE payment_refunds ADD COLUMN IF NOT EXISTS fee_amount DECIMAL(15, 2) NOT NULL DEFAULT 0.00;
+
+-- Add comments for documentation
+COMMENT ON COLUMN payment_refunds.fee_amount IS 'Part of the refund paid back out of the fee revenue account; the rest comes from the collecting account';
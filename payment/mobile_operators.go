@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listMobileOperators lists the operators customers can top up numbers with. Admins can pass
+// include_inactive=true to see disabled operators too.
+func listMobileOperators(c *gin.Context) {
+	_, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	activeOnly := !(role == "admin" && c.Query("include_inactive") == "true")
+	operators, err := mobileOperatorRepo.List(c.Request.Context(), activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list mobile operators"})
+		return
+	}
+
+	c.JSON(http.StatusOK, operators)
+}
+
+// getMobileOperator returns a mobile operator (admin only)
+func getMobileOperator(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	op, ok := loadMobileOperator(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, op)
+}
+
+// createMobileOperator adds a mobile operator (admin only)
+func createMobileOperator(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req models.CreateMobileOperatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	op := models.MobileOperator{
+		Name:             strings.TrimSpace(req.Name),
+		Prefixes:         req.Prefixes,
+		SubscriberDigits: req.SubscriberDigits,
+		Denominations:    req.Denominations,
+		Active:           true,
+	}
+	if !validateMobileOperator(c, &op) {
+		return
+	}
+
+	created, err := mobileOperatorRepo.Create(c.Request.Context(), &op)
+	if err != nil {
+		respondMobileOperatorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// updateMobileOperator changes an operator's prefixes, number format or denominations (admin only)
+func updateMobileOperator(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	existing, ok := loadMobileOperator(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateMobileOperatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	op := req.Apply(*existing)
+	op.Name = strings.TrimSpace(op.Name)
+	if !validateMobileOperator(c, &op) {
+		return
+	}
+
+	updated, err := mobileOperatorRepo.Update(c.Request.Context(), &op)
+	if err != nil {
+		respondMobileOperatorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// deleteMobileOperator removes a mobile operator (admin only)
+func deleteMobileOperator(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	opID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mobile operator ID"})
+		return
+	}
+
+	if err := mobileOperatorRepo.Delete(c.Request.Context(), opID); err != nil {
+		respondMobileOperatorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "mobile operator deleted"})
+}
+
+// validateMobileOperator checks the operator's format and that none of its prefixes overlap
+// another operator's, active or not
+func validateMobileOperator(c *gin.Context, op *models.MobileOperator) bool {
+	if err := op.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	operators, err := mobileOperatorRepo.List(c.Request.Context(), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list mobile operators"})
+		return false
+	}
+	if prefix, other := models.PrefixConflict(operators, *op); prefix != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("prefix %s overlaps a prefix of %s", prefix, other)})
+		return false
+	}
+
+	return true
+}
+
+// applyMobileOperator validates a mobile top-up against its operator's number format and
+// denominations. The operator name travels in recipient_name and the number in recipient_account.
+func applyMobileOperator(c *gin.Context, req *models.CreatePaymentRequest) bool {
+	if req.RecipientName == nil || *req.RecipientName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_name (operator) required for mobile payments"})
+		return false
+	}
+	if req.RecipientAccount == nil || *req.RecipientAccount == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_account (phone number) required for mobile payments"})
+		return false
+	}
+
+	op, ok := findMobileOperator(c, *req.RecipientName)
+	if !ok {
+		return false
+	}
+
+	if err := op.ValidateNumber(*req.RecipientAccount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err := op.ValidateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	req.RecipientName = &op.Name
+	return true
+}
+
+// findMobileOperator looks up an active operator by name, case-insensitively
+func findMobileOperator(c *gin.Context, name string) (*models.MobileOperator, bool) {
+	operators, err := mobileOperatorRepo.List(c.Request.Context(), true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up mobile operators"})
+		return nil, false
+	}
+
+	names := make([]string, len(operators))
+	for i := range operators {
+		names[i] = operators[i].Name
+		if strings.EqualFold(operators[i].Name, name) {
+			return &operators[i], true
+		}
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mobile operator, must be one of: " + strings.Join(names, ", ")})
+	return nil, false
+}
+
+func loadMobileOperator(c *gin.Context) (*models.MobileOperator, bool) {
+	opID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mobile operator ID"})
+		return nil, false
+	}
+
+	op, err := mobileOperatorRepo.GetByID(c.Request.Context(), opID)
+	if err != nil {
+		respondMobileOperatorError(c, err)
+		return nil, false
+	}
+
+	return op, true
+}
+
+func respondMobileOperatorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrMobileOperatorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "mobile operator not found"})
+	case errors.Is(err, repository.ErrMobileOperatorExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "mobile operator already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save mobile operator"})
+	}
+}
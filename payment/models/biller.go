@@ -0,0 +1,263 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Biller categories
+const (
+	BillerCategoryUtility = "utility"
+	BillerCategoryTelecom = "telecom"
+	BillerCategoryTax     = "tax"
+)
+
+// Check digit schemes a biller's customer references can carry
+const (
+	CheckDigitNone  = "none"
+	CheckDigitLuhn  = "luhn"  // last digit is a Luhn (mod 10) check digit
+	CheckDigitMod97 = "mod97" // ISO 7064 MOD 97-10 as in IBANs and RF creditor references: check digits in positions 3-4
+)
+
+var (
+	ErrInvalidReference  = errors.New("invalid customer reference")
+	ErrAmountOutOfRange  = errors.New("amount outside the biller's limits")
+	ErrCurrencyMismatch  = errors.New("currency not accepted by biller")
+	ErrBillerUnavailable = errors.New("biller is not accepting payments")
+)
+
+// Biller is a payee in the bill payment catalog. Customers pay a biller by quoting their reference
+// with it, and the collected funds are credited to the biller's settlement account.
+type Biller struct {
+	ID                  int64            `json:"id"`
+	Code                string           `json:"code"`
+	Name                string           `json:"name"`
+	Category            string           `json:"category"`
+	ReferenceLabel      string           `json:"reference_label"`
+	ReferencePattern    string           `json:"reference_pattern"`
+	CheckDigit          string           `json:"check_digit"`
+	MinAmount           *decimal.Decimal `json:"min_amount,omitempty"`
+	MaxAmount           *decimal.Decimal `json:"max_amount,omitempty"`
+	Currency            string           `json:"currency"`
+	SettlementAccountID int64            `json:"settlement_account_id,omitempty"`
+	Active              bool             `json:"active"`
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+}
+
+type BillerListResponse struct {
+	Billers []Biller `json:"billers"`
+	Total   int64    `json:"total"`
+}
+
+type CreateBillerRequest struct {
+	Code                string           `json:"code" binding:"required,max=32"`
+	Name                string           `json:"name" binding:"required,max=140"`
+	Category            string           `json:"category" binding:"required,oneof=utility telecom tax"`
+	ReferenceLabel      string           `json:"reference_label" binding:"omitempty,max=64"`
+	ReferencePattern    string           `json:"reference_pattern" binding:"required,max=255"`
+	CheckDigit          string           `json:"check_digit" binding:"omitempty,oneof=none luhn mod97"`
+	MinAmount           *decimal.Decimal `json:"min_amount"`
+	MaxAmount           *decimal.Decimal `json:"max_amount"`
+	Currency            string           `json:"currency" binding:"required,len=3"`
+	SettlementAccountID int64            `json:"settlement_account_id" binding:"required"`
+}
+
+// UpdateBillerRequest changes the given fields of a biller; omitted fields are left as they are.
+// The biller's code and currency are fixed once created.
+type UpdateBillerRequest struct {
+	Name                *string          `json:"name" binding:"omitempty,max=140"`
+	Category            *string          `json:"category" binding:"omitempty,oneof=utility telecom tax"`
+	ReferenceLabel      *string          `json:"reference_label" binding:"omitempty,max=64"`
+	ReferencePattern    *string          `json:"reference_pattern" binding:"omitempty,max=255"`
+	CheckDigit          *string          `json:"check_digit" binding:"omitempty,oneof=none luhn mod97"`
+	MinAmount           *decimal.Decimal `json:"min_amount"`
+	MaxAmount           *decimal.Decimal `json:"max_amount"`
+	SettlementAccountID *int64           `json:"settlement_account_id"`
+	Active              *bool            `json:"active"`
+}
+
+// Apply returns a copy of the biller with the update's fields set
+func (r *UpdateBillerRequest) Apply(b Biller) Biller {
+	if r.Name != nil {
+		b.Name = *r.Name
+	}
+	if r.Category != nil {
+		b.Category = *r.Category
+	}
+	if r.ReferenceLabel != nil {
+		b.ReferenceLabel = *r.ReferenceLabel
+	}
+	if r.ReferencePattern != nil {
+		b.ReferencePattern = *r.ReferencePattern
+	}
+	if r.CheckDigit != nil {
+		b.CheckDigit = *r.CheckDigit
+	}
+	if r.MinAmount != nil {
+		b.MinAmount = r.MinAmount
+	}
+	if r.MaxAmount != nil {
+		b.MaxAmount = r.MaxAmount
+	}
+	if r.SettlementAccountID != nil {
+		b.SettlementAccountID = *r.SettlementAccountID
+	}
+	if r.Active != nil {
+		b.Active = *r.Active
+	}
+	return b
+}
+
+// Validate checks that the biller's rules are usable: the reference pattern compiles and the
+// amount limits are positive and ordered
+func (b *Biller) Validate() error {
+	if _, err := compileReferencePattern(b.ReferencePattern); err != nil {
+		return err
+	}
+
+	switch b.CheckDigit {
+	case CheckDigitNone, CheckDigitLuhn, CheckDigitMod97:
+	default:
+		return fmt.Errorf("unknown check digit scheme %q", b.CheckDigit)
+	}
+
+	if b.MinAmount != nil && !b.MinAmount.IsPositive() {
+		return errors.New("min_amount must be positive")
+	}
+	if b.MaxAmount != nil && !b.MaxAmount.IsPositive() {
+		return errors.New("max_amount must be positive")
+	}
+	if b.MinAmount != nil && b.MaxAmount != nil && b.MinAmount.GreaterThan(*b.MaxAmount) {
+		return errors.New("min_amount must not exceed max_amount")
+	}
+	return nil
+}
+
+// ValidateReference checks a customer reference against the biller's format and check digit
+func (b *Biller) ValidateReference(reference string) error {
+	pattern, err := compileReferencePattern(b.ReferencePattern)
+	if err != nil {
+		return err
+	}
+
+	if !pattern.MatchString(reference) {
+		return fmt.Errorf("%w: %s does not match the expected format", ErrInvalidReference, b.referenceLabel())
+	}
+
+	if !ValidCheckDigit(b.CheckDigit, reference) {
+		return fmt.Errorf("%w: %s check digit is wrong", ErrInvalidReference, b.referenceLabel())
+	}
+	return nil
+}
+
+// ValidatePayment checks that a payment to the biller can be accepted
+func (b *Biller) ValidatePayment(reference string, amount decimal.Decimal, currency string) error {
+	if !b.Active {
+		return ErrBillerUnavailable
+	}
+	if currency != b.Currency {
+		return fmt.Errorf("%w: %s only accepts %s", ErrCurrencyMismatch, b.Name, b.Currency)
+	}
+	if b.MinAmount != nil && amount.LessThan(*b.MinAmount) {
+		return fmt.Errorf("%w: minimum is %s %s", ErrAmountOutOfRange, b.MinAmount.StringFixed(2), b.Currency)
+	}
+	if b.MaxAmount != nil && amount.GreaterThan(*b.MaxAmount) {
+		return fmt.Errorf("%w: maximum is %s %s", ErrAmountOutOfRange, b.MaxAmount.StringFixed(2), b.Currency)
+	}
+	return b.ValidateReference(reference)
+}
+
+func (b *Biller) referenceLabel() string {
+	if b.ReferenceLabel != "" {
+		return b.ReferenceLabel
+	}
+	return "reference"
+}
+
+// compileReferencePattern compiles a reference pattern anchored to the whole reference, so a
+// pattern written as `\d{10}` does not accept a longer reference containing ten digits
+func compileReferencePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errors.New("reference_pattern is required")
+	}
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference_pattern: %w", err)
+	}
+	return re, nil
+}
+
+// ValidCheckDigit reports whether the reference carries a valid check digit under the scheme
+func ValidCheckDigit(scheme, reference string) bool {
+	switch scheme {
+	case CheckDigitNone, "":
+		return true
+	case CheckDigitLuhn:
+		return luhnValid(reference)
+	case CheckDigitMod97:
+		return mod97Valid(reference)
+	default:
+		return false
+	}
+}
+
+func luhnValid(reference string) bool {
+	if len(reference) < 2 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(reference) - 1; i >= 0; i-- {
+		c := reference[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func mod97Valid(reference string) bool {
+	// References are often written in groups of four (ISO 11649 print format)
+	reference = strings.ReplaceAll(reference, " ", "")
+	if len(reference) < 5 {
+		return false
+	}
+
+	// As in IBANs (ISO 13616) and RF creditor references (ISO 11649), the first four characters,
+	// which carry the check digits, are moved to the end, and letters count as two-digit numbers
+	// (A=10 ... Z=35). The result read as a number is 1 mod 97.
+	var digits strings.Builder
+	for _, c := range strings.ToUpper(reference[4:] + reference[:4]) {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c >= 'A' && c <= 'Z':
+			fmt.Fprintf(&digits, "%d", c-'A'+10)
+		default:
+			return false
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
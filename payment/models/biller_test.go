@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestValidCheckDigit(t *testing.T) {
+	tests := []struct {
+		name      string
+		scheme    string
+		reference string
+		want      bool
+	}{
+		{name: "no scheme", scheme: CheckDigitNone, reference: "anything", want: true},
+		{name: "luhn valid", scheme: CheckDigitLuhn, reference: "79927398713", want: true},
+		{name: "luhn wrong digit", scheme: CheckDigitLuhn, reference: "79927398710", want: false},
+		{name: "luhn non-digit", scheme: CheckDigitLuhn, reference: "7992739871A", want: false},
+		{name: "luhn too short", scheme: CheckDigitLuhn, reference: "0", want: false},
+		{name: "mod97 RF creditor reference", scheme: CheckDigitMod97, reference: "RF18539007547034", want: true},
+		{name: "mod97 RF creditor reference in groups", scheme: CheckDigitMod97, reference: "RF18 5390 0754 7034", want: true},
+		{name: "mod97 lower case", scheme: CheckDigitMod97, reference: "rf18539007547034", want: true},
+		{name: "mod97 RF wrong digit", scheme: CheckDigitMod97, reference: "RF18539007547035", want: false},
+		{name: "mod97 IBAN", scheme: CheckDigitMod97, reference: "GB82WEST12345698765432", want: true},
+		{name: "mod97 rearranged IBAN", scheme: CheckDigitMod97, reference: "WEST12345698765432GB82", want: false},
+		{name: "mod97 numeric", scheme: CheckDigitMod97, reference: "25123456789012", want: true},
+		{name: "mod97 numeric wrong digits", scheme: CheckDigitMod97, reference: "26123456789012", want: false},
+		{name: "mod97 too short", scheme: CheckDigitMod97, reference: "RF01", want: false},
+		{name: "mod97 punctuation", scheme: CheckDigitMod97, reference: "1234-5678", want: false},
+		{name: "unknown scheme", scheme: "crc", reference: "12345", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidCheckDigit(tt.scheme, tt.reference); got != tt.want {
+				t.Errorf("ValidCheckDigit(%q, %q) = %v, want %v", tt.scheme, tt.reference, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBillerValidatePayment(t *testing.T) {
+	min := decimal.NewFromInt(1)
+	max := decimal.NewFromInt(500)
+	biller := Biller{
+		Name:             "City Water",
+		ReferenceLabel:   "subscriber number",
+		ReferencePattern: `\d{11}`,
+		CheckDigit:       CheckDigitLuhn,
+		MinAmount:        &min,
+		MaxAmount:        &max,
+		Currency:         "AZN",
+		Active:           true,
+	}
+	inactive := biller
+	inactive.Active = false
+
+	tests := []struct {
+		name      string
+		biller    Biller
+		reference string
+		amount    string
+		currency  string
+		wantErr   error
+	}{
+		{name: "valid", biller: biller, reference: "79927398713", amount: "45.10", currency: "AZN"},
+		{name: "at maximum", biller: biller, reference: "79927398713", amount: "500", currency: "AZN"},
+		{name: "pattern matches only part", biller: biller, reference: "799273987130", amount: "45", currency: "AZN", wantErr: ErrInvalidReference},
+		{name: "bad check digit", biller: biller, reference: "79927398710", amount: "45", currency: "AZN", wantErr: ErrInvalidReference},
+		{name: "below minimum", biller: biller, reference: "79927398713", amount: "0.50", currency: "AZN", wantErr: ErrAmountOutOfRange},
+		{name: "above maximum", biller: biller, reference: "79927398713", amount: "500.01", currency: "AZN", wantErr: ErrAmountOutOfRange},
+		{name: "wrong currency", biller: biller, reference: "79927398713", amount: "45", currency: "USD", wantErr: ErrCurrencyMismatch},
+		{name: "inactive biller", biller: inactive, reference: "79927398713", amount: "45", currency: "AZN", wantErr: ErrBillerUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.biller.ValidatePayment(tt.reference, decimal.RequireFromString(tt.amount), tt.currency)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidatePayment() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidatePayment() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBillerValidate(t *testing.T) {
+	ten := decimal.NewFromInt(10)
+	five := decimal.NewFromInt(5)
+	zero := decimal.Zero
+
+	tests := []struct {
+		name    string
+		biller  Biller
+		wantErr bool
+	}{
+		{name: "valid", biller: Biller{ReferencePattern: `[A-Z]{2}\d{8}`, CheckDigit: CheckDigitNone, MinAmount: &five, MaxAmount: &ten}},
+		{name: "missing pattern", biller: Biller{CheckDigit: CheckDigitNone}, wantErr: true},
+		{name: "broken pattern", biller: Biller{ReferencePattern: `(\d+`, CheckDigit: CheckDigitNone}, wantErr: true},
+		{name: "unknown check digit", biller: Biller{ReferencePattern: `\d+`, CheckDigit: "crc"}, wantErr: true},
+		{name: "zero minimum", biller: Biller{ReferencePattern: `\d+`, CheckDigit: CheckDigitNone, MinAmount: &zero}, wantErr: true},
+		{name: "limits reversed", biller: Biller{ReferencePattern: `\d+`, CheckDigit: CheckDigitNone, MinAmount: &ten, MaxAmount: &five}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.biller.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import "strconv"
+
+// Spending categories. Payments are categorized when they are created, from the biller, the
+// merchant's category code or the payment type, and the payer may reassign them. The transfer
+// service's categories are a subset, so insights can merge the two.
+const (
+	CategoryGeneral       = "general"
+	CategoryShopping      = "shopping"
+	CategoryGroceries     = "groceries"
+	CategoryDining        = "dining"
+	CategoryTransport     = "transport"
+	CategoryTravel        = "travel"
+	CategoryEntertainment = "entertainment"
+	CategoryHealth        = "health"
+	CategoryUtilities     = "utilities"
+	CategoryTelecom       = "telecom"
+	CategoryTax           = "tax"
+	CategoryRent          = "rent"
+	CategoryFamily        = "family"
+	CategorySavings       = "savings"
+	CategoryLoan          = "loan"
+)
+
+// mccCategories maps individual ISO 18245 merchant category codes to a category
+var mccCategories = map[int]string{
+	5411: CategoryGroceries, 5422: CategoryGroceries, 5441: CategoryGroceries,
+	5451: CategoryGroceries, 5462: CategoryGroceries, 5499: CategoryGroceries,
+	5811: CategoryDining, 5812: CategoryDining, 5813: CategoryDining, 5814: CategoryDining,
+	4111: CategoryTransport, 4112: CategoryTransport, 4121: CategoryTransport, 4131: CategoryTransport,
+	4789: CategoryTransport, 5541: CategoryTransport, 5542: CategoryTransport, 7523: CategoryTransport,
+	4411: CategoryTravel, 4511: CategoryTravel, 4722: CategoryTravel, 7011: CategoryTravel, 7012: CategoryTravel,
+	5815: CategoryEntertainment, 5816: CategoryEntertainment, 5817: CategoryEntertainment,
+	5818: CategoryEntertainment, 7832: CategoryEntertainment, 7841: CategoryEntertainment,
+	7911: CategoryEntertainment, 7922: CategoryEntertainment, 7929: CategoryEntertainment,
+	7932: CategoryEntertainment, 7933: CategoryEntertainment, 7941: CategoryEntertainment,
+	5122: CategoryHealth, 5912: CategoryHealth,
+	4812: CategoryTelecom, 4814: CategoryTelecom, 4899: CategoryTelecom,
+	4900: CategoryUtilities,
+	9311: CategoryTax,
+}
+
+// CategoryForMCC categorizes a merchant by its category code: airlines and hotels (3000-3999),
+// recreation (7991-7999) and medical services (8011-8099) by range, retail codes without a more
+// specific category as shopping, and anything else as general
+func CategoryForMCC(mcc string) string {
+	code, err := strconv.Atoi(mcc)
+	if err != nil || len(mcc) != 4 {
+		return CategoryGeneral
+	}
+	if category, ok := mccCategories[code]; ok {
+		return category
+	}
+	switch {
+	case code >= 3000 && code <= 3999:
+		return CategoryTravel
+	case code >= 7991 && code <= 7999:
+		return CategoryEntertainment
+	case code >= 8011 && code <= 8099:
+		return CategoryHealth
+	case code >= 5000 && code <= 5999:
+		return CategoryShopping
+	default:
+		return CategoryGeneral
+	}
+}
+
+// CategoryForBiller categorizes a bill payment by its biller's category
+func CategoryForBiller(billerCategory string) string {
+	switch billerCategory {
+	case BillerCategoryTelecom:
+		return CategoryTelecom
+	case BillerCategoryTax:
+		return CategoryTax
+	default:
+		return CategoryUtilities
+	}
+}
+
+// CategoryForPaymentType is the category of a payment whose biller or merchant does not say
+// more: top-ups are telecom, bills utilities and merchant payments shopping
+func CategoryForPaymentType(paymentType string) string {
+	switch paymentType {
+	case PaymentTypeMobile:
+		return CategoryTelecom
+	case PaymentTypeBill:
+		return CategoryUtilities
+	case PaymentTypeMerchant:
+		return CategoryShopping
+	default:
+		return CategoryGeneral
+	}
+}
+
+type SetCategoryRequest struct {
+	Category string `json:"category" binding:"required,oneof=general shopping groceries dining transport travel entertainment health utilities telecom tax rent family savings loan"`
+}
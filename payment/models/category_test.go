@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestCategoryForMCC(t *testing.T) {
+	tests := []struct {
+		mcc  string
+		want string
+	}{
+		{mcc: "5411", want: CategoryGroceries},
+		{mcc: "5812", want: CategoryDining},
+		{mcc: "4121", want: CategoryTransport},
+		{mcc: "3058", want: CategoryTravel},
+		{mcc: "7011", want: CategoryTravel},
+		{mcc: "7996", want: CategoryEntertainment},
+		{mcc: "8021", want: CategoryHealth},
+		{mcc: "4814", want: CategoryTelecom},
+		{mcc: "4900", want: CategoryUtilities},
+		{mcc: "9311", want: CategoryTax},
+		{mcc: "5651", want: CategoryShopping},
+		{mcc: "6012", want: CategoryGeneral},
+		{mcc: "54A1", want: CategoryGeneral},
+		{mcc: "541", want: CategoryGeneral},
+	}
+
+	for _, tt := range tests {
+		if got := CategoryForMCC(tt.mcc); got != tt.want {
+			t.Errorf("CategoryForMCC(%q) = %q, want %q", tt.mcc, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryForBillerAndPaymentType(t *testing.T) {
+	billers := map[string]string{
+		BillerCategoryUtility: CategoryUtilities,
+		BillerCategoryTelecom: CategoryTelecom,
+		BillerCategoryTax:     CategoryTax,
+	}
+	for billerCategory, want := range billers {
+		if got := CategoryForBiller(billerCategory); got != want {
+			t.Errorf("CategoryForBiller(%q) = %q, want %q", billerCategory, got, want)
+		}
+	}
+
+	types := map[string]string{
+		PaymentTypeMobile:   CategoryTelecom,
+		PaymentTypeBill:     CategoryUtilities,
+		PaymentTypeMerchant: CategoryShopping,
+		PaymentTypeExternal: CategoryGeneral,
+	}
+	for paymentType, want := range types {
+		if got := CategoryForPaymentType(paymentType); got != want {
+			t.Errorf("CategoryForPaymentType(%q) = %q, want %q", paymentType, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Clearing batch statuses
+const (
+	ClearingBatchStatusPending = "pending" // built, waiting to be written to the outbound directory
+	ClearingBatchStatusSent    = "sent"
+)
+
+// ClearingBatch is a pacs.008 message external payments were submitted to interbank clearing in
+type ClearingBatch struct {
+	ID           int64           `json:"id"`
+	MessageID    string          `json:"message_id"`
+	Status       string          `json:"status"`
+	PaymentCount int             `json:"payment_count"`
+	ControlSum   decimal.Decimal `json:"control_sum"`
+	FileName     *string         `json:"file_name,omitempty"`
+	WindowEnd    time.Time       `json:"window_end"`
+	CreatedAt    time.Time       `json:"created_at"`
+	SentAt       *time.Time      `json:"sent_at,omitempty"`
+}
+
+type ClearingBatchListResponse struct {
+	Batches []ClearingBatch `json:"batches"`
+	Total   int64           `json:"total"`
+}
+
+// ClearingReportResult summarises what importing a pacs.002 status report did
+type ClearingReportResult struct {
+	OriginalMessageID string   `json:"original_message_id"`
+	Settled           int      `json:"settled"`
+	Returned          int      `json:"returned"`
+	Pending           int      `json:"pending"`
+	Unmatched         []string `json:"unmatched,omitempty"`
+	Errors            []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Inbound credit statuses
+const (
+	InboundCreditStatusPending   = "pending"   // imported, not yet applied
+	InboundCreditStatusCrediting = "crediting" // being credited to account_id; the account service may already have it
+	InboundCreditStatusCredited  = "credited"  // credited to the matched (or admin-chosen) account
+	InboundCreditStatusSuspense  = "suspense"  // could not be applied; waiting for an admin
+	InboundCreditStatusRejected  = "rejected"  // an admin decided not to credit it, e.g. to return it to the sender
+)
+
+// InboundFile is an incoming ISO 20022 file that has been imported
+type InboundFile struct {
+	ID          int64     `json:"id"`
+	FileName    string    `json:"file_name"`
+	SHA256      string    `json:"sha256"`
+	MessageType string    `json:"message_type"`
+	MessageID   *string   `json:"message_id,omitempty"`
+	CreditCount int       `json:"credit_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// InboundCredit is an incoming credit transfer from another bank
+type InboundCredit struct {
+	ID              int64           `json:"id"`
+	ReferenceID     uuid.UUID       `json:"reference_id"`
+	FileID          int64           `json:"file_id"`
+	DedupeKey       string          `json:"-"`
+	EndToEndID      *string         `json:"end_to_end_id,omitempty"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        string          `json:"currency"`
+	DebtorName      *string         `json:"debtor_name,omitempty"`
+	DebtorAccount   *string         `json:"debtor_account,omitempty"`
+	CreditorName    *string         `json:"creditor_name,omitempty"`
+	CreditorAccount *string         `json:"creditor_account,omitempty"`
+	RemittanceInfo  *string         `json:"remittance_info,omitempty"`
+	Status          string          `json:"status"`
+	AccountID       *int64          `json:"account_id,omitempty"`
+	UserID          *int64          `json:"user_id,omitempty"`
+	SuspenseReason  *string         `json:"suspense_reason,omitempty"`
+	ResolvedBy      *int64          `json:"resolved_by,omitempty"`
+	ResolutionNote  *string         `json:"resolution_note,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	CreditedAt      *time.Time      `json:"credited_at,omitempty"`
+}
+
+type InboundCreditListResponse struct {
+	Credits []InboundCredit `json:"credits"`
+	Total   int64           `json:"total"`
+}
+
+// InboundImportResult summarises what importing one inbound file did
+type InboundImportResult struct {
+	File       InboundFile `json:"file"`
+	Imported   int         `json:"imported"`
+	Duplicates int         `json:"duplicates"`
+	Credited   int         `json:"credited"`
+	Suspense   int         `json:"suspense"`
+}
+
+// ResolveInboundCreditRequest credits a suspense item to an account an admin has identified
+type ResolveInboundCreditRequest struct {
+	AccountID int64  `json:"account_id" binding:"required"`
+	Note      string `json:"note" binding:"max=500"`
+}
+
+// RejectInboundCreditRequest takes a suspense item off the queue without crediting it
+type RejectInboundCreditRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// AccountSummary is the part of an account service account needed to credit it
+type AccountSummary struct {
+	ID            int64  `json:"id"`
+	UserID        int64  `json:"user_id"`
+	AccountNumber string `json:"account_number"`
+	Currency      string `json:"currency"`
+	Status        string `json:"status"`
+}
+
+// InboundCreditEvent is published to Kafka when an incoming credit transfer reaches a customer's account
+type InboundCreditEvent struct {
+	CreditID    int64           `json:"credit_id"`
+	ReferenceID string          `json:"reference_id"`
+	AccountID   int64           `json:"account_id"`
+	UserID      int64           `json:"user_id"`
+	Amount      decimal.Decimal `json:"amount"`
+	Currency    string          `json:"currency"`
+	DebtorName  string          `json:"debtor_name,omitempty"`
+	Remittance  string          `json:"remittance_info,omitempty"`
+	CreditedAt  time.Time       `json:"credited_at"`
+}
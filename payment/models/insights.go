@@ -0,0 +1,280 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MonthLayout is the format of the months insights are requested and reported by
+const MonthLayout = "2006-01"
+
+// Insights cover DefaultInsightMonths months unless asked otherwise, and at most MaxInsightMonths
+const (
+	DefaultInsightMonths = 6
+	MaxInsightMonths     = 24
+)
+
+// Where a recipient's spending was paid from
+const (
+	SpendingSourcePayment  = "payment"
+	SpendingSourceTransfer = "transfer"
+)
+
+// SpendingStatuses are the statuses of payments whose money has left the payer for good
+var SpendingStatuses = []string{
+	PaymentStatusCompleted, PaymentStatusSentToClearing, PaymentStatusSettled, PaymentStatusConfirmed,
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// SpendingRow totals the spending of one category in one month and currency
+type SpendingRow struct {
+	Month    string          `json:"month"` // YYYY-MM
+	Category string          `json:"category"`
+	Currency string          `json:"currency"`
+	Count    int64           `json:"count"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+// MerchantSpending totals the payments to one merchant. MerchantID is set for merchants the bank
+// acquires; others are known by name only.
+type MerchantSpending struct {
+	MerchantID *int64          `json:"merchant_id,omitempty"`
+	Name       string          `json:"name"`
+	Category   string          `json:"category"`
+	Currency   string          `json:"currency"`
+	Count      int64           `json:"count"`
+	Total      decimal.Decimal `json:"total"`
+}
+
+// RecipientSpending totals the payments or transfers to one recipient. Account is masked.
+type RecipientSpending struct {
+	Source   string          `json:"source"`
+	Type     string          `json:"payment_type,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Account  string          `json:"account,omitempty"`
+	Currency string          `json:"currency"`
+	Count    int64           `json:"count"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+// TransferSpending is the transfer service's summary of a user's outgoing transfers
+type TransferSpending struct {
+	Months     []SpendingRow       `json:"months"`
+	Recipients []RecipientSpending `json:"recipients"`
+}
+
+// CategorySpending is one category's part of a period's spending; Share is its percentage
+type CategorySpending struct {
+	Category string          `json:"category"`
+	Count    int64           `json:"count"`
+	Total    decimal.Decimal `json:"total"`
+	Share    decimal.Decimal `json:"share"`
+}
+
+// MonthSpending is a month's spending in one currency. Change compares it with the month
+// before; ChangePercent is left out when nothing was spent that month.
+type MonthSpending struct {
+	Month         string             `json:"month"`
+	Currency      string             `json:"currency"`
+	Count         int64              `json:"count"`
+	Total         decimal.Decimal    `json:"total"`
+	Change        decimal.Decimal    `json:"change"`
+	ChangePercent *decimal.Decimal   `json:"change_percent,omitempty"`
+	Categories    []CategorySpending `json:"categories"`
+}
+
+// PeriodSpending is the spending in one currency over the whole range
+type PeriodSpending struct {
+	Currency   string             `json:"currency"`
+	Count      int64              `json:"count"`
+	Total      decimal.Decimal    `json:"total"`
+	Categories []CategorySpending `json:"categories"`
+}
+
+// SpendingInsights summarizes a user's completed payments and outgoing transfers, net of refunds
+// and reversals. Incomplete is set when transfers could not be included.
+type SpendingInsights struct {
+	From          string              `json:"from"`
+	To            string              `json:"to"`
+	Totals        []PeriodSpending    `json:"totals"`
+	Months        []MonthSpending     `json:"months"`
+	Merchants     []MerchantSpending  `json:"merchants"`
+	TopRecipients []RecipientSpending `json:"top_recipients"`
+	Incomplete    bool                `json:"incomplete,omitempty"`
+}
+
+// ParseMonthRange reads an inclusive range of months from from and to (YYYY-MM). to defaults to
+// the current month and from to DefaultInsightMonths months up to to.
+func ParseMonthRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if to != "" {
+		t, err := time.Parse(MonthLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be a month as YYYY-MM")
+		}
+		end = t
+	}
+
+	start := end.AddDate(0, 1-DefaultInsightMonths, 0)
+	if from != "" {
+		t, err := time.Parse(MonthLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be a month as YYYY-MM")
+		}
+		start = t
+	}
+
+	if start.After(end) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	if start.AddDate(0, MaxInsightMonths, 0).Before(end.AddDate(0, 1, 0)) {
+		return time.Time{}, time.Time{}, errors.New("range must not exceed 24 months")
+	}
+	return start, end, nil
+}
+
+// monthTotals accumulates one month's spending in one currency
+type monthTotals struct {
+	count      int64
+	total      decimal.Decimal
+	categories map[string]*CategorySpending
+}
+
+func (t *monthTotals) add(row SpendingRow) {
+	t.count += row.Count
+	t.total = t.total.Add(row.Total)
+	category, ok := t.categories[row.Category]
+	if !ok {
+		category = &CategorySpending{Category: row.Category}
+		t.categories[row.Category] = category
+	}
+	category.Count += row.Count
+	category.Total = category.Total.Add(row.Total)
+}
+
+// breakdown lists the categories by amount spent, largest first, with their share of the total
+func (t *monthTotals) breakdown() []CategorySpending {
+	categories := make([]CategorySpending, 0, len(t.categories))
+	for _, category := range t.categories {
+		if t.total.IsPositive() {
+			category.Share = category.Total.Mul(hundred).Div(t.total).Round(1)
+		}
+		categories = append(categories, *category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if !categories[i].Total.Equal(categories[j].Total) {
+			return categories[i].Total.GreaterThan(categories[j].Total)
+		}
+		return categories[i].Category < categories[j].Category
+	})
+	return categories
+}
+
+// BuildInsights merges spending rows from payments and transfers into insights for the months
+// from to to. Rows for the month before from only serve its month-over-month change. Every
+// currency spent in the range is reported for every month, so gaps show as zero. The merchants
+// and recipients are ranked by amount and cut to limit.
+func BuildInsights(from, to time.Time, rows []SpendingRow, merchants []MerchantSpending, recipients []RecipientSpending, limit int) *SpendingInsights {
+	type key struct{ month, currency string }
+	months := map[key]*monthTotals{}
+	periods := map[string]*monthTotals{}
+
+	first, last := from.Format(MonthLayout), to.Format(MonthLayout)
+	for _, row := range rows {
+		k := key{row.Month, row.Currency}
+		if months[k] == nil {
+			months[k] = &monthTotals{categories: map[string]*CategorySpending{}}
+		}
+		months[k].add(row)
+
+		if row.Month >= first && row.Month <= last {
+			if periods[row.Currency] == nil {
+				periods[row.Currency] = &monthTotals{categories: map[string]*CategorySpending{}}
+			}
+			periods[row.Currency].add(row)
+		}
+	}
+
+	currencies := make([]string, 0, len(periods))
+	for currency := range periods {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	insights := &SpendingInsights{
+		From:          first,
+		To:            last,
+		Totals:        []PeriodSpending{},
+		Months:        []MonthSpending{},
+		Merchants:     rankMerchants(merchants, limit),
+		TopRecipients: rankRecipients(recipients, limit),
+	}
+
+	empty := &monthTotals{categories: map[string]*CategorySpending{}}
+	for _, currency := range currencies {
+		period := periods[currency]
+		insights.Totals = append(insights.Totals, PeriodSpending{
+			Currency:   currency,
+			Count:      period.count,
+			Total:      period.total,
+			Categories: period.breakdown(),
+		})
+	}
+
+	for m := from; !m.After(to); m = m.AddDate(0, 1, 0) {
+		month, previous := m.Format(MonthLayout), m.AddDate(0, -1, 0).Format(MonthLayout)
+		for _, currency := range currencies {
+			current, ok := months[key{month, currency}]
+			if !ok {
+				current = empty
+			}
+			before, ok := months[key{previous, currency}]
+			if !ok {
+				before = empty
+			}
+
+			spending := MonthSpending{
+				Month:      month,
+				Currency:   currency,
+				Count:      current.count,
+				Total:      current.total,
+				Change:     current.total.Sub(before.total),
+				Categories: current.breakdown(),
+			}
+			if before.total.IsPositive() {
+				percent := spending.Change.Mul(hundred).Div(before.total).Round(1)
+				spending.ChangePercent = &percent
+			}
+			insights.Months = append(insights.Months, spending)
+		}
+	}
+
+	return insights
+}
+
+func rankMerchants(merchants []MerchantSpending, limit int) []MerchantSpending {
+	ranked := append([]MerchantSpending{}, merchants...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Total.GreaterThan(ranked[j].Total)
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+func rankRecipients(recipients []RecipientSpending, limit int) []RecipientSpending {
+	ranked := append([]RecipientSpending{}, recipients...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Total.GreaterThan(ranked[j].Total)
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
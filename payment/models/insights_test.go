@@ -0,0 +1,116 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func month(s string) time.Time {
+	t, err := time.Parse(MonthLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseMonthRange(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     [2]string
+		wantErr  bool
+	}{
+		{name: "defaults to the last six months", want: [2]string{"2023-10", "2024-03"}},
+		{name: "six months up to to", to: "2023-12", want: [2]string{"2023-07", "2023-12"}},
+		{name: "explicit range", from: "2024-01", to: "2024-02", want: [2]string{"2024-01", "2024-02"}},
+		{name: "single month", from: "2024-03", to: "2024-03", want: [2]string{"2024-03", "2024-03"}},
+		{name: "24 months", from: "2022-04", to: "2024-03", want: [2]string{"2022-04", "2024-03"}},
+		{name: "25 months", from: "2022-03", to: "2024-03", wantErr: true},
+		{name: "reversed", from: "2024-03", to: "2024-01", wantErr: true},
+		{name: "not a month", from: "2024-03-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := ParseMonthRange(tt.from, tt.to, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMonthRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := [2]string{from.Format(MonthLayout), to.Format(MonthLayout)}; got != tt.want {
+				t.Errorf("ParseMonthRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInsights(t *testing.T) {
+	d := decimal.RequireFromString
+	rows := []SpendingRow{
+		// The month before the range only feeds February's change
+		{Month: "2024-01", Category: CategoryGroceries, Currency: "USD", Count: 2, Total: d("200")},
+		{Month: "2024-02", Category: CategoryGroceries, Currency: "USD", Count: 1, Total: d("150")},
+		{Month: "2024-02", Category: CategoryRent, Currency: "USD", Count: 1, Total: d("100")},
+		// Transfers merged in for the same month and category
+		{Month: "2024-02", Category: CategoryGroceries, Currency: "USD", Count: 1, Total: d("50")},
+		{Month: "2024-03", Category: CategoryDining, Currency: "EUR", Count: 1, Total: d("30")},
+	}
+	recipients := []RecipientSpending{
+		{Source: SpendingSourcePayment, Name: "Landlord", Currency: "USD", Count: 1, Total: d("100")},
+		{Source: SpendingSourceTransfer, Name: "Mom", Currency: "USD", Count: 3, Total: d("300")},
+		{Source: SpendingSourcePayment, Name: "Grocer", Currency: "USD", Count: 2, Total: d("200")},
+	}
+
+	insights := BuildInsights(month("2024-02"), month("2024-03"), rows, nil, recipients, 2)
+
+	if insights.From != "2024-02" || insights.To != "2024-03" {
+		t.Errorf("range = %s..%s, want 2024-02..2024-03", insights.From, insights.To)
+	}
+
+	if len(insights.Totals) != 2 || insights.Totals[0].Currency != "EUR" || insights.Totals[1].Currency != "USD" {
+		t.Fatalf("Totals = %+v, want EUR and USD", insights.Totals)
+	}
+	usd := insights.Totals[1]
+	if !usd.Total.Equal(d("300")) || usd.Count != 3 {
+		t.Errorf("USD total = %s over %d, want 300 over 3 (January excluded)", usd.Total, usd.Count)
+	}
+
+	// Every currency is reported for every month, EUR before USD
+	if len(insights.Months) != 4 {
+		t.Fatalf("Months has %d entries, want 4", len(insights.Months))
+	}
+	feb := insights.Months[1]
+	if feb.Month != "2024-02" || feb.Currency != "USD" || !feb.Total.Equal(d("300")) {
+		t.Fatalf("Months[1] = %s %s %s, want 2024-02 USD 300", feb.Month, feb.Currency, feb.Total)
+	}
+	if !feb.Change.Equal(d("100")) || feb.ChangePercent == nil || !feb.ChangePercent.Equal(d("50")) {
+		t.Errorf("February change = %s (%v%%), want 100 (50%%)", feb.Change, feb.ChangePercent)
+	}
+	if len(feb.Categories) != 2 || feb.Categories[0].Category != CategoryGroceries ||
+		!feb.Categories[0].Total.Equal(d("200")) || !feb.Categories[0].Share.Equal(d("66.7")) {
+		t.Errorf("February categories = %+v, want groceries 200 (66.7%%) first", feb.Categories)
+	}
+
+	mar := insights.Months[3]
+	if mar.Month != "2024-03" || mar.Currency != "USD" || !mar.Total.IsZero() || !mar.Change.Equal(d("-300")) ||
+		!mar.ChangePercent.Equal(d("-100")) || len(mar.Categories) != 0 {
+		t.Errorf("March USD = %+v, want nothing spent, down 300 (-100%%)", mar)
+	}
+	marEUR := insights.Months[2]
+	if marEUR.Currency != "EUR" || !marEUR.Change.Equal(d("30")) || marEUR.ChangePercent != nil {
+		t.Errorf("March EUR = %+v, want up 30 with no percentage", marEUR)
+	}
+
+	if len(insights.TopRecipients) != 2 || insights.TopRecipients[0].Name != "Mom" || insights.TopRecipients[1].Name != "Grocer" {
+		t.Errorf("TopRecipients = %+v, want Mom then Grocer", insights.TopRecipients)
+	}
+	if insights.Merchants == nil {
+		t.Error("Merchants = nil, want an empty list")
+	}
+}
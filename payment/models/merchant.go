@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrMerchantUnavailable = errors.New("merchant is not accepting payments")
+	ErrMerchantCurrency    = errors.New("currency not accepted by merchant")
+	ErrAmountBelowFee      = errors.New("amount does not cover the merchant service charge")
+)
+
+var mccPattern = regexp.MustCompile(`^[0-9]{4}$`)
+
+// Merchant is a merchant the bank acquires: merchant payments made to it are settled in-house,
+// crediting its settlement account with the amount net of the merchant service charge.
+type Merchant struct {
+	ID                  int64           `json:"id"`
+	Code                string          `json:"code"` // merchant ID assigned by the bank
+	Name                string          `json:"name"`
+	MCC                 string          `json:"mcc"`
+	UserID              int64           `json:"user_id"` // owner of the settlement account
+	SettlementAccountID int64           `json:"settlement_account_id,omitempty"`
+	FeeRate             decimal.Decimal `json:"fee_rate"`
+	Currency            string          `json:"currency"`
+	City                *string         `json:"city,omitempty"`
+	CountryCode         *string         `json:"country_code,omitempty"` // ISO 3166-1 alpha-2
+	Active              bool            `json:"active"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
+type MerchantListResponse struct {
+	Merchants []Merchant `json:"merchants"`
+	Total     int64      `json:"total"`
+}
+
+type CreateMerchantRequest struct {
+	Code                string          `json:"code" binding:"required,max=32"`
+	Name                string          `json:"name" binding:"required,max=140"`
+	MCC                 string          `json:"mcc" binding:"required,len=4,numeric"`
+	SettlementAccountID int64           `json:"settlement_account_id" binding:"required"`
+	FeeRate             decimal.Decimal `json:"fee_rate"`
+	Currency            string          `json:"currency" binding:"required,len=3"`
+	City                *string         `json:"city" binding:"omitempty,max=60"`
+	CountryCode         *string         `json:"country_code" binding:"omitempty,len=2,alpha"`
+}
+
+// UpdateMerchantRequest changes the given fields of a merchant; omitted fields are left as they
+// are. The merchant's code and currency are fixed once created.
+type UpdateMerchantRequest struct {
+	Name                *string          `json:"name" binding:"omitempty,max=140"`
+	MCC                 *string          `json:"mcc" binding:"omitempty,len=4,numeric"`
+	SettlementAccountID *int64           `json:"settlement_account_id"`
+	FeeRate             *decimal.Decimal `json:"fee_rate"`
+	City                *string          `json:"city" binding:"omitempty,max=60"`
+	CountryCode         *string          `json:"country_code" binding:"omitempty,len=2,alpha"`
+	Active              *bool            `json:"active"`
+}
+
+// Apply returns a copy of the merchant with the update's fields set
+func (r *UpdateMerchantRequest) Apply(m Merchant) Merchant {
+	if r.Name != nil {
+		m.Name = *r.Name
+	}
+	if r.MCC != nil {
+		m.MCC = *r.MCC
+	}
+	if r.SettlementAccountID != nil {
+		m.SettlementAccountID = *r.SettlementAccountID
+	}
+	if r.FeeRate != nil {
+		m.FeeRate = *r.FeeRate
+	}
+	if r.City != nil {
+		m.City = r.City
+	}
+	if r.CountryCode != nil {
+		m.CountryCode = r.CountryCode
+	}
+	if r.Active != nil {
+		m.Active = *r.Active
+	}
+	return m
+}
+
+// Validate checks the merchant's category code and that its fee rate is a fraction below one
+// with at most four decimal places
+func (m *Merchant) Validate() error {
+	if !mccPattern.MatchString(m.MCC) {
+		return errors.New("mcc must be four digits")
+	}
+	if m.FeeRate.IsNegative() || m.FeeRate.GreaterThanOrEqual(decimal.NewFromInt(1)) || m.FeeRate.Exponent() < -4 {
+		return errors.New("fee_rate must be at least 0 and below 1, with at most 4 decimal places")
+	}
+	return nil
+}
+
+// Fee returns the merchant service charge on a payment, rounded to the cent
+func (m *Merchant) Fee(amount decimal.Decimal) decimal.Decimal {
+	return amount.Mul(m.FeeRate).Round(2)
+}
+
+// ValidatePayment checks that the merchant can be paid the amount and returns its service charge
+func (m *Merchant) ValidatePayment(amount decimal.Decimal, currency string) (decimal.Decimal, error) {
+	if !m.Active {
+		return decimal.Zero, ErrMerchantUnavailable
+	}
+	if currency != m.Currency {
+		return decimal.Zero, fmt.Errorf("%w: %s only accepts %s", ErrMerchantCurrency, m.Name, m.Currency)
+	}
+	fee := m.Fee(amount)
+	if fee.GreaterThanOrEqual(amount) {
+		return decimal.Zero, ErrAmountBelowFee
+	}
+	return fee, nil
+}
+
+// MerchantTransaction is a payment to a merchant as the merchant sees it
+type MerchantTransaction struct {
+	PaymentID   int64           `json:"payment_id"`
+	ReferenceID uuid.UUID       `json:"reference_id"`
+	Status      string          `json:"status"`
+	Amount      decimal.Decimal `json:"amount"`
+	Fee         decimal.Decimal `json:"fee"`
+	Net         decimal.Decimal `json:"net"`
+	Refunded    decimal.Decimal `json:"refunded"`
+	Currency    string          `json:"currency"`
+	Description *string         `json:"description,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ProcessedAt *time.Time      `json:"processed_at,omitempty"`
+}
+
+type MerchantTransactionListResponse struct {
+	Transactions []MerchantTransaction `json:"transactions"`
+	Total        int64                 `json:"total"`
+}
+
+// MerchantSettlementDay totals what a merchant was credited on one day (UTC): completed payments
+// net of the service charge, less what it paid back for refunds completed that day
+type MerchantSettlementDay struct {
+	Date     string          `json:"date"`
+	Payments int64           `json:"payments"`
+	Gross    decimal.Decimal `json:"gross"`
+	Fees     decimal.Decimal `json:"fees"`
+	Net      decimal.Decimal `json:"net"`
+	Refunds  decimal.Decimal `json:"refunds"`
+	Settled  decimal.Decimal `json:"settled"`
+}
+
+type MerchantSettlementResponse struct {
+	MerchantID int64                   `json:"merchant_id"`
+	Currency   string                  `json:"currency"`
+	From       string                  `json:"from"`
+	To         string                  `json:"to"`
+	Days       []MerchantSettlementDay `json:"days"`
+	Total      MerchantSettlementDay   `json:"total"`
+}
+
+// TotalSettlements sums daily settlements; the total's date is left empty
+func TotalSettlements(days []MerchantSettlementDay) MerchantSettlementDay {
+	var total MerchantSettlementDay
+	for _, d := range days {
+		total.Payments += d.Payments
+		total.Gross = total.Gross.Add(d.Gross)
+		total.Fees = total.Fees.Add(d.Fees)
+		total.Net = total.Net.Add(d.Net)
+		total.Refunds = total.Refunds.Add(d.Refunds)
+		total.Settled = total.Settled.Add(d.Settled)
+	}
+	return total
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMerchantValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mcc     string
+		feeRate string
+		wantErr bool
+	}{
+		{name: "valid", mcc: "5411", feeRate: "0.015", wantErr: false},
+		{name: "no fee", mcc: "5812", feeRate: "0", wantErr: false},
+		{name: "short mcc", mcc: "541", feeRate: "0.01", wantErr: true},
+		{name: "letters in mcc", mcc: "54A1", feeRate: "0.01", wantErr: true},
+		{name: "negative fee", mcc: "5411", feeRate: "-0.01", wantErr: true},
+		{name: "whole amount as fee", mcc: "5411", feeRate: "1", wantErr: true},
+		{name: "too precise", mcc: "5411", feeRate: "0.01234", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Merchant{MCC: tt.mcc, FeeRate: decimal.RequireFromString(tt.feeRate)}
+			if err := m.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMerchantValidatePayment(t *testing.T) {
+	merchant := &Merchant{Name: "Corner Shop", FeeRate: decimal.RequireFromString("0.015"), Currency: "USD", Active: true}
+
+	tests := []struct {
+		name     string
+		merchant *Merchant
+		amount   string
+		currency string
+		wantFee  string
+		wantErr  error
+	}{
+		{name: "fee rounded to the cent", merchant: merchant, amount: "33.33", currency: "USD", wantFee: "0.5"},
+		{name: "small amount", merchant: merchant, amount: "0.10", currency: "USD", wantFee: "0"},
+		{name: "wrong currency", merchant: merchant, amount: "10", currency: "EUR", wantErr: ErrMerchantCurrency},
+		{name: "inactive", merchant: &Merchant{Currency: "USD"}, amount: "10", currency: "USD", wantErr: ErrMerchantUnavailable},
+		{name: "fee swallows amount", merchant: &Merchant{FeeRate: decimal.RequireFromString("0.9"), Currency: "USD", Active: true}, amount: "0.01", currency: "USD", wantErr: ErrAmountBelowFee},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, err := tt.merchant.ValidatePayment(decimal.RequireFromString(tt.amount), tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ValidatePayment() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidatePayment() error = %v", err)
+			}
+			if !fee.Equal(decimal.RequireFromString(tt.wantFee)) {
+				t.Errorf("ValidatePayment() fee = %s, want %s", fee, tt.wantFee)
+			}
+		})
+	}
+}
+
+func TestTotalSettlements(t *testing.T) {
+	days := []MerchantSettlementDay{
+		{Date: "2024-03-01", Payments: 2, Gross: decimal.NewFromInt(100), Fees: decimal.NewFromInt(2), Net: decimal.NewFromInt(98), Settled: decimal.NewFromInt(98)},
+		{Date: "2024-03-02", Payments: 1, Gross: decimal.NewFromInt(50), Fees: decimal.NewFromInt(1), Net: decimal.NewFromInt(49),
+			Refunds: decimal.NewFromInt(20), Settled: decimal.NewFromInt(29)},
+	}
+
+	total := TotalSettlements(days)
+	if total.Payments != 3 || !total.Gross.Equal(decimal.NewFromInt(150)) || !total.Fees.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("TotalSettlements() = %+v, want 3 payments, 150 gross, 3 fees", total)
+	}
+	if !total.Refunds.Equal(decimal.NewFromInt(20)) || !total.Settled.Equal(decimal.NewFromInt(127)) {
+		t.Errorf("TotalSettlements() refunds %s settled %s, want 20 and 127", total.Refunds, total.Settled)
+	}
+}
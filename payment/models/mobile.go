@@ -0,0 +1,164 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidPhoneNumber  = errors.New("invalid phone number")
+	ErrInvalidDenomination = errors.New("amount is not an available top-up denomination")
+)
+
+// MobileOperator is a mobile operator customers can top up numbers with. A number is the operator's
+// prefix followed by SubscriberDigits digits. Operators with denominations only accept those
+// amounts; operators without accept any amount.
+type MobileOperator struct {
+	ID               int64             `json:"id"`
+	Name             string            `json:"name"`
+	Prefixes         []string          `json:"prefixes"`
+	SubscriberDigits int               `json:"subscriber_digits"`
+	Denominations    []decimal.Decimal `json:"denominations"`
+	Active           bool              `json:"active"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+type CreateMobileOperatorRequest struct {
+	Name             string            `json:"name" binding:"required,max=64"`
+	Prefixes         []string          `json:"prefixes" binding:"required,min=1"`
+	SubscriberDigits int               `json:"subscriber_digits" binding:"required"`
+	Denominations    []decimal.Decimal `json:"denominations"`
+}
+
+// UpdateMobileOperatorRequest changes the given fields of an operator; omitted fields are left as
+// they are. An empty denominations list opens the operator to any amount.
+type UpdateMobileOperatorRequest struct {
+	Name             *string            `json:"name" binding:"omitempty,max=64"`
+	Prefixes         *[]string          `json:"prefixes"`
+	SubscriberDigits *int               `json:"subscriber_digits"`
+	Denominations    *[]decimal.Decimal `json:"denominations"`
+	Active           *bool              `json:"active"`
+}
+
+// Apply returns a copy of the operator with the update's fields set
+func (r *UpdateMobileOperatorRequest) Apply(op MobileOperator) MobileOperator {
+	if r.Name != nil {
+		op.Name = *r.Name
+	}
+	if r.Prefixes != nil {
+		op.Prefixes = *r.Prefixes
+	}
+	if r.SubscriberDigits != nil {
+		op.SubscriberDigits = *r.SubscriberDigits
+	}
+	if r.Denominations != nil {
+		op.Denominations = *r.Denominations
+	}
+	if r.Active != nil {
+		op.Active = *r.Active
+	}
+	return op
+}
+
+// Validate checks the operator's prefixes, number length and denominations
+func (op *MobileOperator) Validate() error {
+	if strings.TrimSpace(op.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(op.Prefixes) == 0 {
+		return errors.New("at least one prefix is required")
+	}
+
+	seen := make(map[string]bool, len(op.Prefixes))
+	for _, p := range op.Prefixes {
+		if len(p) < 2 || len(p) > 5 || !isDigits(p) {
+			return fmt.Errorf("prefix %q must be 2 to 5 digits", p)
+		}
+		if seen[p] {
+			return fmt.Errorf("prefix %s is listed twice", p)
+		}
+		seen[p] = true
+	}
+
+	if op.SubscriberDigits < 4 || op.SubscriberDigits > 12 {
+		return errors.New("subscriber_digits must be between 4 and 12")
+	}
+
+	for _, d := range op.Denominations {
+		if !d.IsPositive() {
+			return errors.New("denominations must be positive")
+		}
+	}
+	return nil
+}
+
+// ValidateNumber checks that the phone number is one of the operator's prefixes followed by the
+// subscriber number
+func (op *MobileOperator) ValidateNumber(phone string) error {
+	prefixMatched := false
+	for _, p := range op.Prefixes {
+		if !strings.HasPrefix(phone, p) {
+			continue
+		}
+		prefixMatched = true
+		if subscriber := phone[len(p):]; len(subscriber) == op.SubscriberDigits && isDigits(subscriber) {
+			return nil
+		}
+	}
+
+	if prefixMatched {
+		return fmt.Errorf("%w: %s numbers are a prefix followed by %d digits", ErrInvalidPhoneNumber, op.Name, op.SubscriberDigits)
+	}
+	return fmt.Errorf("%w: prefix does not match operator %s (%s)", ErrInvalidPhoneNumber, op.Name, strings.Join(op.Prefixes, ", "))
+}
+
+// ValidateAmount checks the amount against the operator's denominations, if it has any
+func (op *MobileOperator) ValidateAmount(amount decimal.Decimal) error {
+	if len(op.Denominations) == 0 {
+		return nil
+	}
+
+	options := make([]string, len(op.Denominations))
+	for i, d := range op.Denominations {
+		if amount.Equal(d) {
+			return nil
+		}
+		options[i] = d.StringFixed(2)
+	}
+	return fmt.Errorf("%w: %s accepts %s", ErrInvalidDenomination, op.Name, strings.Join(options, ", "))
+}
+
+// PrefixConflict returns the first prefix of op that overlaps a prefix of another operator, so
+// that every number belongs to exactly one operator
+func PrefixConflict(operators []MobileOperator, op MobileOperator) (prefix, other string) {
+	for _, existing := range operators {
+		if existing.ID == op.ID {
+			continue
+		}
+		for _, p := range op.Prefixes {
+			for _, q := range existing.Prefixes {
+				if strings.HasPrefix(p, q) || strings.HasPrefix(q, p) {
+					return p, existing.Name
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMobileOperatorValidateNumber(t *testing.T) {
+	op := MobileOperator{Name: "Azercell", Prefixes: []string{"050", "051"}, SubscriberDigits: 7}
+
+	tests := []struct {
+		name    string
+		phone   string
+		wantErr bool
+	}{
+		{name: "valid", phone: "0501234567"},
+		{name: "second prefix", phone: "0519876543"},
+		{name: "other operator prefix", phone: "0551234567", wantErr: true},
+		{name: "too short", phone: "050123456", wantErr: true},
+		{name: "too long", phone: "05012345678", wantErr: true},
+		{name: "letters", phone: "050123456a", wantErr: true},
+		{name: "empty", phone: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := op.ValidateNumber(tt.phone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateNumber(%q) error = %v, wantErr %v", tt.phone, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPhoneNumber) {
+				t.Errorf("ValidateNumber(%q) error = %v, want ErrInvalidPhoneNumber", tt.phone, err)
+			}
+		})
+	}
+}
+
+func TestMobileOperatorValidateAmount(t *testing.T) {
+	open := MobileOperator{Name: "Nar"}
+	fixed := MobileOperator{Name: "Bakcell", Denominations: []decimal.Decimal{decimal.NewFromInt(5), decimal.NewFromInt(10)}}
+
+	tests := []struct {
+		name    string
+		op      MobileOperator
+		amount  string
+		wantErr bool
+	}{
+		{name: "any amount without denominations", op: open, amount: "3.17"},
+		{name: "listed denomination", op: fixed, amount: "10.00"},
+		{name: "unlisted amount", op: fixed, amount: "7", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op.ValidateAmount(decimal.RequireFromString(tt.amount))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAmount(%s) error = %v, wantErr %v", tt.amount, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrefixConflict(t *testing.T) {
+	operators := []MobileOperator{
+		{ID: 1, Name: "Azercell", Prefixes: []string{"050", "051"}},
+		{ID: 2, Name: "Bakcell", Prefixes: []string{"055", "099"}},
+	}
+
+	tests := []struct {
+		name       string
+		op         MobileOperator
+		wantPrefix string
+		wantOther  string
+	}{
+		{name: "distinct prefixes", op: MobileOperator{Name: "Nar", Prefixes: []string{"070", "077"}}},
+		{name: "same prefix", op: MobileOperator{Name: "Nar", Prefixes: []string{"070", "055"}}, wantPrefix: "055", wantOther: "Bakcell"},
+		{name: "shorter prefix covers another", op: MobileOperator{Name: "Nar", Prefixes: []string{"09"}}, wantPrefix: "09", wantOther: "Bakcell"},
+		{name: "operator's own prefixes", op: MobileOperator{ID: 1, Name: "Azercell", Prefixes: []string{"050", "051", "010"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, other := PrefixConflict(operators, tt.op)
+			if prefix != tt.wantPrefix || other != tt.wantOther {
+				t.Errorf("PrefixConflict() = (%q, %q), want (%q, %q)", prefix, other, tt.wantPrefix, tt.wantOther)
+			}
+		})
+	}
+}
@@ -15,27 +15,48 @@ const (
 	PaymentTypeMobile   = "mobile"
 )
 
-// MobileOperator represents a mobile operator with its valid prefixes
-type MobileOperator struct {
-	Name     string   `json:"name"`
-	Prefixes []string `json:"prefixes"`
-}
-
-// MobileOperators defines valid Azerbaijani mobile operators and their prefixes
-var MobileOperators = map[string][]string{
-	"Azercell": {"050", "051"},
-	"Bakcell":  {"055", "099"},
-	"Nar":      {"070", "077"},
-}
-
 // Payment statuses
 const (
 	PaymentStatusPending    = "pending"
 	PaymentStatusProcessing = "processing"
 	PaymentStatusCompleted  = "completed"
 	PaymentStatusFailed     = "failed"
+
+	// External payments continue past completed once the debit is taken: they are submitted to
+	// interbank clearing and finally settled or returned by the receiving bank
+	PaymentStatusSentToClearing = "sent_to_clearing"
+	PaymentStatusSettled        = "settled"
+	PaymentStatusReturned       = "returned"
+
+	// Mobile top-ups and merchant payments are handed to a provider once the debit is taken: the
+	// provider confirms them, or declines them and the debit is refunded
+	PaymentStatusDebited   = "debited"
+	PaymentStatusSubmitted = "submitted"
+	PaymentStatusConfirmed = "confirmed"
+	PaymentStatusRefunded  = "refunded"
 )
 
+// UsesProvider reports whether a payment is fulfilled by an external provider after the account is
+// debited. Merchant payments to a merchant the bank acquires are settled in-house instead.
+func UsesProvider(payment *Payment) bool {
+	switch payment.PaymentType {
+	case PaymentTypeMobile:
+		return true
+	case PaymentTypeMerchant:
+		return payment.MerchantID == nil
+	default:
+		return false
+	}
+}
+
+// DebitedStatus is the status a payment moves to once the account service has debited it
+func DebitedStatus(payment *Payment) string {
+	if UsesProvider(payment) {
+		return PaymentStatusDebited
+	}
+	return PaymentStatusCompleted
+}
+
 type Payment struct {
 	ID               int64           `json:"id"`
 	ReferenceID      uuid.UUID       `json:"reference_id"`
@@ -48,22 +69,82 @@ type Payment struct {
 	Amount           decimal.Decimal `json:"amount"`
 	Currency         string          `json:"currency"`
 	Description      *string         `json:"description,omitempty"`
+	BeneficiaryID    *int64          `json:"beneficiary_id,omitempty"`
+	BillerID         *int64          `json:"biller_id,omitempty"`
+	ClearingBatchID  *int64          `json:"clearing_batch_id,omitempty"`
 	Status           string          `json:"status"`
 	FailureReason    *string         `json:"failure_reason,omitempty"`
+	SagaAttempts     int             `json:"saga_attempts,omitempty"`
 	CreatedAt        time.Time       `json:"created_at"`
 	UpdatedAt        time.Time       `json:"updated_at"`
 	ProcessedAt      *time.Time      `json:"processed_at,omitempty"`
+
+	// Provider the payment was handed to, and the provider's own reference for it
+	Provider          *string    `json:"provider,omitempty"`
+	ProviderReference *string    `json:"provider_reference,omitempty"`
+	SubmittedAt       *time.Time `json:"submitted_at,omitempty"`
+
+	// SettlementAccountID is the account the payment is credited to; payments to billers are
+	// collected into the biller's settlement account rather than leaving the bank
+	SettlementAccountID *int64 `json:"settlement_account_id,omitempty"`
+
+	// MerchantID is the acquired merchant a merchant payment was made to, and FeeAmount the service
+	// charge withheld from the merchant's credit
+	MerchantID *int64           `json:"merchant_id,omitempty"`
+	FeeAmount  *decimal.Decimal `json:"fee_amount,omitempty"`
+
+	// Category is the spending category the payment counts towards in insights
+	Category string `json:"category"`
 }
 
+// CreatePaymentRequest describes a payment. External payments may name a saved beneficiary
+// instead of spelling out the recipient details. Bill payments name a biller and carry the
+// customer's reference with it in recipient_account. Merchant payments either name an acquired
+// merchant or give the merchant's name for the provider to pay. A payment made from a saved template takes
+// every field it does not set from the template.
 type CreatePaymentRequest struct {
-	AccountID        int64           `json:"account_id" binding:"required"`
-	PaymentType      string          `json:"payment_type" binding:"required,oneof=bill merchant external mobile"`
+	AccountID        int64           `json:"account_id" binding:"required_without=TemplateID"`
+	PaymentType      string          `json:"payment_type" binding:"required_without=TemplateID,omitempty,oneof=bill merchant external mobile"`
 	RecipientName    *string         `json:"recipient_name"`
 	RecipientAccount *string         `json:"recipient_account"`
 	RecipientBank    *string         `json:"recipient_bank"`
 	Amount           decimal.Decimal `json:"amount" binding:"required"`
 	Currency         string          `json:"currency" binding:"omitempty,len=3"`
 	Description      *string         `json:"description"`
+	BeneficiaryID    *int64          `json:"beneficiary_id"`
+	BillerID         *int64          `json:"biller_id"`
+	MerchantID       *int64          `json:"merchant_id"`
+	TemplateID       *int64          `json:"template_id"`
+
+	// SettlementAccountID, FeeAmount and Category are set from the biller or merchant, never by
+	// the caller; without them the category follows the payment type
+	SettlementAccountID *int64           `json:"-"`
+	FeeAmount           *decimal.Decimal `json:"-"`
+	Category            string           `json:"-"`
+}
+
+// Beneficiary is the part of a transfer service beneficiary needed to address an external payment
+type Beneficiary struct {
+	ID              int64            `json:"id"`
+	UserID          int64            `json:"user_id"`
+	Type            string           `json:"beneficiary_type"`
+	IBAN            *string          `json:"iban,omitempty"`
+	AccountNumber   *string          `json:"account_number,omitempty"`
+	BankName        *string          `json:"bank_name,omitempty"`
+	BankCode        *string          `json:"bank_code,omitempty"`
+	RecipientName   *string          `json:"recipient_name,omitempty"`
+	InCoolingOff    bool             `json:"in_cooling_off"`
+	MaxAmount       *decimal.Decimal `json:"max_amount,omitempty"`
+	CoolingOffUntil time.Time        `json:"cooling_off_until"`
+}
+
+// CoolingOffStatus is the transfer service's cooling-off window for an external destination the
+// user has saved as a beneficiary: while it lasts, the total paid to it since Since is capped at MaxAmount
+type CoolingOffStatus struct {
+	InCoolingOff    bool             `json:"in_cooling_off"`
+	Since           *time.Time       `json:"since,omitempty"`
+	CoolingOffUntil *time.Time       `json:"cooling_off_until,omitempty"`
+	MaxAmount       *decimal.Decimal `json:"max_amount,omitempty"`
 }
 
 type PaymentListResponse struct {
@@ -82,6 +163,11 @@ type PaymentRequestedEvent struct {
 	RecipientAccount string          `json:"recipient_account,omitempty"`
 	Amount           decimal.Decimal `json:"amount"`
 	Currency         string          `json:"currency"`
+
+	// SettlementAccountID, when set, is credited with the amount in the same operation as the debit,
+	// less Fee, which is credited to the bank's fee revenue account
+	SettlementAccountID int64           `json:"settlement_account_id,omitempty"`
+	Fee                 decimal.Decimal `json:"fee"`
 }
 
 // PaymentResultEvent is consumed from Kafka after processing
@@ -91,3 +177,22 @@ type PaymentResultEvent struct {
 	Status        string `json:"status"` // "completed" or "failed"
 	FailureReason string `json:"failure_reason,omitempty"`
 }
+
+// AccountOperation is the account service's record of how it processed a payment request
+type AccountOperation struct {
+	ReferenceID   string          `json:"reference_id"`
+	Status        string          `json:"status"` // "completed" or "failed"
+	Amount        decimal.Decimal `json:"amount"`
+	FailureReason *string         `json:"failure_reason,omitempty"`
+}
+
+// OpsAlert is published for conditions that need an operator's attention
+type OpsAlert struct {
+	Service     string    `json:"service"`
+	Severity    string    `json:"severity"` // "warning" or "critical"
+	EntityType  string    `json:"entity_type"`
+	EntityID    int64     `json:"entity_id"`
+	ReferenceID string    `json:"reference_id"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"created_at"`
+}
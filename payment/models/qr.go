@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// QR code types
+const (
+	QRCodeStatic  = "static"
+	QRCodeDynamic = "dynamic"
+)
+
+var (
+	ErrQRCodeExpired = errors.New("QR code has expired")
+	ErrQRCodeUsed    = errors.New("QR code has already been paid")
+)
+
+// ReleasesQRCode reports whether a payment reaching the status leaves the merchant unpaid, so the
+// dynamic QR code it was made with can be paid again
+func ReleasesQRCode(status string) bool {
+	return status == PaymentStatusFailed || status == PaymentStatusRefunded
+}
+
+// QRCode is a dynamic EMVCo QR code issued to a merchant for one payment of a set amount
+type QRCode struct {
+	ID          int64           `json:"id"`
+	MerchantID  int64           `json:"merchant_id"`
+	Reference   string          `json:"reference"`
+	Amount      decimal.Decimal `json:"amount"`
+	Currency    string          `json:"currency"`
+	Description *string         `json:"description,omitempty"`
+	Payload     string          `json:"payload"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	UsedAt      *time.Time      `json:"used_at,omitempty"`
+	PaymentID   *int64          `json:"payment_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Payable reports why the QR code cannot be paid at the given time, if it cannot
+func (q *QRCode) Payable(now time.Time) error {
+	if q.UsedAt != nil {
+		return ErrQRCodeUsed
+	}
+	if !now.Before(q.ExpiresAt) {
+		return ErrQRCodeExpired
+	}
+	return nil
+}
+
+// MaxQRCodeExpiresIn is the longest a dynamic QR code can be made payable for, in minutes
+const MaxQRCodeExpiresIn = 24 * 60
+
+// GenerateQRCodeRequest asks for a merchant QR code: a reusable static code when no amount is
+// given, otherwise a single-use dynamic code for that amount
+type GenerateQRCodeRequest struct {
+	Amount      *decimal.Decimal `json:"amount"`
+	Description *string          `json:"description" binding:"omitempty,max=140"`
+	ExpiresIn   *int             `json:"expires_in" binding:"omitempty,min=1,max=1440"` // minutes, dynamic codes only
+}
+
+type QRCodeResponse struct {
+	Type       string           `json:"type"`
+	MerchantID int64            `json:"merchant_id"`
+	Payload    string           `json:"payload"`
+	Reference  string           `json:"reference,omitempty"`
+	Amount     *decimal.Decimal `json:"amount,omitempty"`
+	Currency   string           `json:"currency"`
+	ExpiresAt  *time.Time       `json:"expires_at,omitempty"`
+}
+
+// QRPaymentRequest pays a merchant by a scanned QR payload. The amount is entered by the customer
+// for static codes and must be omitted for dynamic codes, which carry their own.
+type QRPaymentRequest struct {
+	Payload     string           `json:"payload" binding:"required,max=512"`
+	AccountID   int64            `json:"account_id" binding:"required"`
+	Amount      *decimal.Decimal `json:"amount"`
+	Description *string          `json:"description"`
+}
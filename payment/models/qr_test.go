@@ -0,0 +1,30 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQRCodePayable(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	used := now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		qr      QRCode
+		wantErr error
+	}{
+		{name: "unused", qr: QRCode{ExpiresAt: now.Add(time.Minute)}},
+		{name: "expired", qr: QRCode{ExpiresAt: now}, wantErr: ErrQRCodeExpired},
+		{name: "used", qr: QRCode{ExpiresAt: now.Add(time.Minute), UsedAt: &used}, wantErr: ErrQRCodeUsed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.qr.Payable(now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Payable() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Refund statuses
+const (
+	RefundStatusPending   = "pending"   // published to the account service, credit not yet confirmed
+	RefundStatusCompleted = "completed" // credited back to the payer's account
+	RefundStatusFailed    = "failed"    // the account service could not credit it; the amount is refundable again
+)
+
+var (
+	ErrPaymentNotRefundable = errors.New("payment cannot be refunded in its current status")
+	ErrRefundExceedsPayment = errors.New("refund exceeds the amount left to refund")
+	ErrInvalidRefundAmount  = errors.New("refund amount must be positive")
+)
+
+// IsRefundable reports whether money has reached the payee of a payment, so that it can be
+// given back: bill and acquired merchant payments once completed, provider payments once
+// confirmed and external payments once settled by the receiving bank
+func IsRefundable(payment *Payment) bool {
+	switch payment.Status {
+	case PaymentStatusCompleted:
+		return payment.PaymentType != PaymentTypeExternal && !UsesProvider(payment)
+	case PaymentStatusConfirmed, PaymentStatusSettled:
+		return true
+	default:
+		return false
+	}
+}
+
+// Refund returns all or part of a payment to the account it was paid from
+type Refund struct {
+	ID            int64           `json:"id"`
+	ReferenceID   uuid.UUID       `json:"reference_id"`
+	PaymentID     int64           `json:"payment_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	FeeAmount     decimal.Decimal `json:"fee_amount"` // part of Amount paid back from the service charge
+	Currency      string          `json:"currency"`
+	Reason        *string         `json:"reason,omitempty"`
+	Status        string          `json:"status"`
+	FailureReason *string         `json:"failure_reason,omitempty"`
+	RequestedBy   int64           `json:"requested_by"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+}
+
+// RefundFee is the part of a refund of amount paid back out of the payment's service charge rather
+// than by the merchant: the charge in proportion to the refund, or whatever is left of it once
+// feeRefunded has been paid back when the refund returns the rest of the payment
+func RefundFee(payment *Payment, amount, remaining, feeRefunded decimal.Decimal) decimal.Decimal {
+	if payment.FeeAmount == nil || !payment.FeeAmount.IsPositive() {
+		return decimal.Zero
+	}
+	if amount.GreaterThanOrEqual(remaining) {
+		return payment.FeeAmount.Sub(feeRefunded)
+	}
+	return payment.FeeAmount.Mul(amount).Div(payment.Amount).Round(2)
+}
+
+// RefundListResponse lists a payment's refunds with how much of it is refunded and still refundable
+type RefundListResponse struct {
+	Refunds    []Refund        `json:"refunds"`
+	Refunded   decimal.Decimal `json:"refunded"`   // completed refunds
+	Refundable decimal.Decimal `json:"refundable"` // amount not yet refunded or being refunded
+}
+
+// CreateRefundRequest refunds part or all of a payment; without an amount the rest of it is refunded
+type CreateRefundRequest struct {
+	Amount *decimal.Decimal `json:"amount"`
+	Reason string           `json:"reason" binding:"max=500"`
+}
+
+// RefundRequestedEvent is published to Kafka for the account service to credit a refund
+type RefundRequestedEvent struct {
+	RefundID         int64           `json:"refund_id"`
+	ReferenceID      string          `json:"reference_id"`
+	PaymentID        int64           `json:"payment_id"`
+	PaymentReference string          `json:"payment_reference"`
+	AccountID        int64           `json:"account_id"`
+	UserID           int64           `json:"user_id"`
+	PaymentType      string          `json:"payment_type"`
+	RecipientName    string          `json:"recipient_name,omitempty"`
+	Amount           decimal.Decimal `json:"amount"`
+	Currency         string          `json:"currency"`
+	Reason           string          `json:"reason,omitempty"`
+
+	// SettlementAccountID, when set, is debited with the amount in the same operation as the credit;
+	// refunds of payments collected inside the bank come back out of the collecting account, less
+	// Fee, which comes back out of the fee revenue account
+	SettlementAccountID int64           `json:"settlement_account_id,omitempty"`
+	Fee                 decimal.Decimal `json:"fee"`
+}
+
+// RefundResultEvent is consumed from Kafka once the account service has processed a refund
+type RefundResultEvent struct {
+	RefundID      int64  `json:"refund_id"`
+	ReferenceID   string `json:"reference_id"`
+	PaymentID     int64  `json:"payment_id"`
+	Status        string `json:"status"` // "completed" or "failed"
+	FailureReason string `json:"failure_reason,omitempty"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestIsRefundable(t *testing.T) {
+	tests := []struct {
+		name        string
+		paymentType string
+		status      string
+		want        bool
+	}{
+		{name: "completed bill", paymentType: PaymentTypeBill, status: PaymentStatusCompleted, want: true},
+		{name: "pending bill", paymentType: PaymentTypeBill, status: PaymentStatusPending, want: false},
+		{name: "failed bill", paymentType: PaymentTypeBill, status: PaymentStatusFailed, want: false},
+		{name: "confirmed merchant", paymentType: PaymentTypeMerchant, status: PaymentStatusConfirmed, want: true},
+		{name: "submitted merchant", paymentType: PaymentTypeMerchant, status: PaymentStatusSubmitted, want: false},
+		{name: "debited mobile", paymentType: PaymentTypeMobile, status: PaymentStatusDebited, want: false},
+		{name: "already refunded mobile", paymentType: PaymentTypeMobile, status: PaymentStatusRefunded, want: false},
+		{name: "completed external", paymentType: PaymentTypeExternal, status: PaymentStatusCompleted, want: false},
+		{name: "settled external", paymentType: PaymentTypeExternal, status: PaymentStatusSettled, want: true},
+		{name: "returned external", paymentType: PaymentTypeExternal, status: PaymentStatusReturned, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := &Payment{PaymentType: tt.paymentType, Status: tt.status}
+			if got := IsRefundable(payment); got != tt.want {
+				t.Errorf("IsRefundable(%s %s) = %v, want %v", tt.paymentType, tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefundFee(t *testing.T) {
+	fee := decimal.RequireFromString("3.00")
+	payment := &Payment{Amount: decimal.RequireFromString("100.00"), FeeAmount: &fee}
+
+	tests := []struct {
+		name        string
+		payment     *Payment
+		amount      string
+		remaining   string
+		feeRefunded string
+		want        string
+	}{
+		{name: "no service charge", payment: &Payment{Amount: decimal.RequireFromString("100.00")}, amount: "40.00", remaining: "100.00", feeRefunded: "0", want: "0"},
+		{name: "full refund", payment: payment, amount: "100.00", remaining: "100.00", feeRefunded: "0", want: "3.00"},
+		{name: "partial refund", payment: payment, amount: "33.00", remaining: "100.00", feeRefunded: "0", want: "0.99"},
+		{name: "partial refund rounds", payment: payment, amount: "33.33", remaining: "100.00", feeRefunded: "0", want: "1.00"},
+		{name: "last refund takes the rest", payment: payment, amount: "66.67", remaining: "66.67", feeRefunded: "1.00", want: "2.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RefundFee(tt.payment, decimal.RequireFromString(tt.amount), decimal.RequireFromString(tt.remaining), decimal.RequireFromString(tt.feeRefunded))
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("RefundFee(%s) = %s, want %s", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
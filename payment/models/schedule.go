@@ -0,0 +1,247 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Schedule frequencies
+const (
+	ScheduleFrequencyDaily   = "daily"
+	ScheduleFrequencyWeekly  = "weekly"
+	ScheduleFrequencyMonthly = "monthly"
+)
+
+// Schedule statuses
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusPaused    = "paused"
+	ScheduleStatusCompleted = "completed" // no occurrences left before the end date
+	ScheduleStatusCancelled = "cancelled"
+)
+
+// Schedule execution statuses
+const (
+	ExecutionStatusPending   = "pending" // payment created, outcome not known yet
+	ExecutionStatusSucceeded = "succeeded"
+	ExecutionStatusFailed    = "failed"
+)
+
+// Defaults for a schedule's retry policy
+const (
+	DefaultScheduleMaxRetries    = 3
+	DefaultScheduleRetryInterval = 24 // hours
+)
+
+// DateLayout is the format of schedule start and end dates
+const DateLayout = "2006-01-02"
+
+// ErrScheduleEnded means a schedule has no occurrences left before its end date
+var ErrScheduleEnded = errors.New("schedule has no occurrences left before its end date")
+
+// PaymentSchedule pays a bill or tops up a phone on a recurring schedule. Occurrences fall at
+// midnight UTC on the days the schedule names.
+type PaymentSchedule struct {
+	ID                 int64           `json:"id"`
+	UserID             int64           `json:"user_id"`
+	AccountID          int64           `json:"account_id"`
+	PaymentType        string          `json:"payment_type"`
+	BillerID           *int64          `json:"biller_id,omitempty"`
+	RecipientName      *string         `json:"recipient_name,omitempty"`
+	RecipientAccount   *string         `json:"recipient_account,omitempty"`
+	Amount             decimal.Decimal `json:"amount"`
+	Currency           string          `json:"currency"`
+	Description        *string         `json:"description,omitempty"`
+	Frequency          string          `json:"frequency"`
+	DayOfWeek          *int            `json:"day_of_week,omitempty"`  // weekly: 0 = Sunday
+	DayOfMonth         *int            `json:"day_of_month,omitempty"` // monthly: 1-31, short months use their last day
+	StartDate          time.Time       `json:"start_date"`
+	EndDate            *time.Time      `json:"end_date,omitempty"`
+	MaxRetries         int             `json:"max_retries"`
+	RetryIntervalHours int             `json:"retry_interval_hours"`
+	Status             string          `json:"status"`
+	OccurrenceAt       time.Time       `json:"occurrence_at"` // occurrence currently due or being paid
+	NextRunAt          time.Time       `json:"next_run_at"`   // the occurrence itself, or a retry of it
+	Attempts           int             `json:"attempts"`      // attempts made at the current occurrence
+	ActiveExecutionID  *int64          `json:"active_execution_id,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// ScheduleExecution is one attempt at paying an occurrence of a schedule
+type ScheduleExecution struct {
+	ID            int64      `json:"id"`
+	ScheduleID    int64      `json:"schedule_id"`
+	PaymentID     *int64     `json:"payment_id,omitempty"`
+	OccurrenceAt  time.Time  `json:"occurrence_at"`
+	Attempt       int        `json:"attempt"`
+	Status        string     `json:"status"`
+	FailureReason *string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+type PaymentScheduleListResponse struct {
+	Schedules []PaymentSchedule `json:"schedules"`
+	Total     int64             `json:"total"`
+}
+
+// CreatePaymentScheduleRequest describes a recurring bill payment or mobile top-up. The payee is
+// given as for a one-off payment. The day of the week or month defaults to that of start_date,
+// which defaults to today.
+type CreatePaymentScheduleRequest struct {
+	AccountID          int64           `json:"account_id" binding:"required"`
+	PaymentType        string          `json:"payment_type" binding:"required,oneof=bill mobile"`
+	BillerID           *int64          `json:"biller_id"`
+	RecipientName      *string         `json:"recipient_name"`
+	RecipientAccount   *string         `json:"recipient_account"`
+	Amount             decimal.Decimal `json:"amount" binding:"required"`
+	Currency           string          `json:"currency" binding:"omitempty,len=3"`
+	Description        *string         `json:"description"`
+	Frequency          string          `json:"frequency" binding:"required,oneof=daily weekly monthly"`
+	DayOfWeek          *int            `json:"day_of_week" binding:"omitempty,min=0,max=6"`
+	DayOfMonth         *int            `json:"day_of_month" binding:"omitempty,min=1,max=31"`
+	StartDate          string          `json:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate            string          `json:"end_date" binding:"omitempty,datetime=2006-01-02"`
+	MaxRetries         *int            `json:"max_retries" binding:"omitempty,min=0,max=10"`
+	RetryIntervalHours *int            `json:"retry_interval_hours" binding:"omitempty,min=1,max=168"`
+}
+
+// PaymentRequest returns the one-off payment an occurrence of the schedule is paid with
+func (s *PaymentSchedule) PaymentRequest() *CreatePaymentRequest {
+	return &CreatePaymentRequest{
+		AccountID:        s.AccountID,
+		PaymentType:      s.PaymentType,
+		RecipientName:    s.RecipientName,
+		RecipientAccount: s.RecipientAccount,
+		Amount:           s.Amount,
+		Currency:         s.Currency,
+		Description:      s.Description,
+		BillerID:         s.BillerID,
+	}
+}
+
+// Validate checks that the schedule names the days it pays on and that its end follows its start
+func (s *PaymentSchedule) Validate() error {
+	switch s.Frequency {
+	case ScheduleFrequencyDaily:
+		if s.DayOfWeek != nil || s.DayOfMonth != nil {
+			return errors.New("daily schedules take neither day_of_week nor day_of_month")
+		}
+	case ScheduleFrequencyWeekly:
+		if s.DayOfWeek == nil || *s.DayOfWeek < 0 || *s.DayOfWeek > 6 || s.DayOfMonth != nil {
+			return errors.New("weekly schedules need a day_of_week from 0 (Sunday) to 6 and no day_of_month")
+		}
+	case ScheduleFrequencyMonthly:
+		if s.DayOfMonth == nil || *s.DayOfMonth < 1 || *s.DayOfMonth > 31 || s.DayOfWeek != nil {
+			return errors.New("monthly schedules need a day_of_month from 1 to 31 and no day_of_week")
+		}
+	default:
+		return errors.New("frequency must be daily, weekly or monthly")
+	}
+
+	if s.EndDate != nil && s.EndDate.Before(s.StartDate) {
+		return errors.New("end_date must not be before start_date")
+	}
+	if s.MaxRetries < 0 || s.RetryIntervalHours < 1 {
+		return errors.New("max_retries must not be negative and retry_interval_hours must be positive")
+	}
+	return nil
+}
+
+// FirstOccurrence returns the schedule's first occurrence on or after the given day
+func (s *PaymentSchedule) FirstOccurrence(from time.Time) time.Time {
+	return s.NextOccurrence(startOfDay(from).AddDate(0, 0, -1))
+}
+
+// NextOccurrence returns the schedule's first occurrence after the given time
+func (s *PaymentSchedule) NextOccurrence(after time.Time) time.Time {
+	day := startOfDay(after).AddDate(0, 0, 1)
+
+	switch s.Frequency {
+	case ScheduleFrequencyWeekly:
+		return day.AddDate(0, 0, (*s.DayOfWeek-int(day.Weekday())+7)%7)
+	case ScheduleFrequencyMonthly:
+		// Work month by month so a day_of_month of 31 returns to the 31st after a short month
+		for month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC); ; month = month.AddDate(0, 1, 0) {
+			dom := *s.DayOfMonth
+			if last := month.AddDate(0, 1, -1).Day(); dom > last {
+				dom = last
+			}
+			if candidate := month.AddDate(0, 0, dom-1); !candidate.Before(day) {
+				return candidate
+			}
+		}
+	default:
+		return day
+	}
+}
+
+// Ends reports whether an occurrence falls after the schedule's end date
+func (s *PaymentSchedule) Ends(occurrence time.Time) bool {
+	return s.EndDate != nil && occurrence.After(*s.EndDate)
+}
+
+// Start points the schedule at its first occurrence on or after the given day
+func (s *PaymentSchedule) Start(from time.Time) error {
+	if s.StartDate.After(from) {
+		from = s.StartDate
+	}
+	occurrence := s.FirstOccurrence(from)
+	if s.Ends(occurrence) {
+		return ErrScheduleEnded
+	}
+
+	s.OccurrenceAt = occurrence
+	s.NextRunAt = occurrence
+	s.Attempts = 0
+	return nil
+}
+
+// AfterExecution moves the schedule on once an execution has finished. A retryable failure, such
+// as insufficient funds, is tried again after the retry interval while retries remain and the retry
+// falls before the next occurrence; otherwise the schedule moves to its next occurrence, or
+// completes if it has none left. It reports whether a retry was scheduled.
+func (s *PaymentSchedule) AfterExecution(now time.Time, succeeded, retryable bool) bool {
+	next := s.NextOccurrence(s.OccurrenceAt)
+
+	if !succeeded && retryable && s.Attempts <= s.MaxRetries {
+		retryAt := now.Add(time.Duration(s.RetryIntervalHours) * time.Hour)
+		if retryAt.Before(next) {
+			s.NextRunAt = retryAt
+			return true
+		}
+	}
+
+	// Occurrences missed while the schedule waited on a slow payment are skipped, not paid late
+	for next.Before(startOfDay(now)) && !s.Ends(next) {
+		next = s.NextOccurrence(next)
+	}
+
+	s.OccurrenceAt = next
+	s.NextRunAt = next
+	s.Attempts = 0
+	if s.Ends(next) {
+		s.Status = ScheduleStatusCompleted
+	}
+	return false
+}
+
+// IsInsufficientFunds reports whether a payment failed for lack of funds, which a later retry may cure
+func IsInsufficientFunds(failureReason string) bool {
+	return strings.Contains(strings.ToLower(failureReason), "insufficient funds")
+}
+
+// ParseDate parses a schedule start or end date as midnight UTC
+func ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, value, time.UTC)
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestNextOccurrence(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule PaymentSchedule
+		after    string
+		want     string
+	}{
+		{name: "daily", schedule: PaymentSchedule{Frequency: ScheduleFrequencyDaily}, after: "2026-01-31", want: "2026-02-01"},
+		{name: "weekly later this week", schedule: PaymentSchedule{Frequency: ScheduleFrequencyWeekly, DayOfWeek: intPtr(5)}, after: "2026-03-02", want: "2026-03-06"},
+		{name: "weekly same weekday", schedule: PaymentSchedule{Frequency: ScheduleFrequencyWeekly, DayOfWeek: intPtr(1)}, after: "2026-03-02", want: "2026-03-09"},
+		{name: "monthly this month", schedule: PaymentSchedule{Frequency: ScheduleFrequencyMonthly, DayOfMonth: intPtr(5)}, after: "2026-03-02", want: "2026-03-05"},
+		{name: "monthly next month", schedule: PaymentSchedule{Frequency: ScheduleFrequencyMonthly, DayOfMonth: intPtr(5)}, after: "2026-03-05", want: "2026-04-05"},
+		{name: "monthly short month", schedule: PaymentSchedule{Frequency: ScheduleFrequencyMonthly, DayOfMonth: intPtr(31)}, after: "2026-01-31", want: "2026-02-28"},
+		{name: "monthly back to the 31st", schedule: PaymentSchedule{Frequency: ScheduleFrequencyMonthly, DayOfMonth: intPtr(31)}, after: "2026-02-28", want: "2026-03-31"},
+		{name: "monthly leap year", schedule: PaymentSchedule{Frequency: ScheduleFrequencyMonthly, DayOfMonth: intPtr(30)}, after: "2028-01-30", want: "2028-02-29"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.NextOccurrence(date(tt.after)); !got.Equal(date(tt.want)) {
+				t.Errorf("NextOccurrence(%s) = %s, want %s", tt.after, got.Format(DateLayout), tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleStart(t *testing.T) {
+	end := date("2026-03-31")
+	s := PaymentSchedule{Frequency: ScheduleFrequencyMonthly, DayOfMonth: intPtr(5), StartDate: date("2026-03-01"), EndDate: &end}
+
+	if err := s.Start(date("2026-02-10")); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if !s.OccurrenceAt.Equal(date("2026-03-05")) || !s.NextRunAt.Equal(s.OccurrenceAt) {
+		t.Errorf("Start() occurrence = %s, next run = %s, want 2026-03-05", s.OccurrenceAt, s.NextRunAt)
+	}
+
+	if err := s.Start(date("2026-03-06")); err != ErrScheduleEnded {
+		t.Errorf("Start() after the last occurrence error = %v, want ErrScheduleEnded", err)
+	}
+}
+
+func TestAfterExecution(t *testing.T) {
+	newSchedule := func() *PaymentSchedule {
+		return &PaymentSchedule{
+			Frequency:          ScheduleFrequencyWeekly,
+			DayOfWeek:          intPtr(4),
+			MaxRetries:         2,
+			RetryIntervalHours: 24,
+			Status:             ScheduleStatusActive,
+			OccurrenceAt:       date("2026-03-05"),
+			NextRunAt:          date("2026-03-05"),
+			Attempts:           1,
+		}
+	}
+	now := date("2026-03-05").Add(time.Minute)
+
+	t.Run("success moves to the next occurrence", func(t *testing.T) {
+		s := newSchedule()
+		if s.AfterExecution(now, true, false) {
+			t.Fatal("AfterExecution() scheduled a retry after a success")
+		}
+		if !s.OccurrenceAt.Equal(date("2026-03-12")) || !s.NextRunAt.Equal(s.OccurrenceAt) || s.Attempts != 0 {
+			t.Errorf("schedule = occurrence %s, next run %s, attempts %d, want 2026-03-12 with no attempts", s.OccurrenceAt, s.NextRunAt, s.Attempts)
+		}
+	})
+
+	t.Run("insufficient funds is retried", func(t *testing.T) {
+		s := newSchedule()
+		if !s.AfterExecution(now, false, true) {
+			t.Fatal("AfterExecution() did not schedule a retry")
+		}
+		if !s.OccurrenceAt.Equal(date("2026-03-05")) || !s.NextRunAt.Equal(now.Add(24*time.Hour)) {
+			t.Errorf("schedule = occurrence %s, next run %s, want a retry of 2026-03-05 a day later", s.OccurrenceAt, s.NextRunAt)
+		}
+	})
+
+	t.Run("retries run out", func(t *testing.T) {
+		s := newSchedule()
+		s.Attempts = 3
+		if s.AfterExecution(now, false, true) {
+			t.Fatal("AfterExecution() retried past max_retries")
+		}
+		if !s.OccurrenceAt.Equal(date("2026-03-12")) {
+			t.Errorf("occurrence = %s, want 2026-03-12", s.OccurrenceAt)
+		}
+	})
+
+	t.Run("no retry past the next occurrence", func(t *testing.T) {
+		s := newSchedule()
+		s.RetryIntervalHours = 24 * 7
+		if s.AfterExecution(now, false, true) {
+			t.Fatal("AfterExecution() scheduled a retry after the next occurrence")
+		}
+	})
+
+	t.Run("other failures are not retried", func(t *testing.T) {
+		s := newSchedule()
+		if s.AfterExecution(now, false, false) {
+			t.Fatal("AfterExecution() retried a failure that is not retryable")
+		}
+	})
+
+	t.Run("missed occurrences are skipped", func(t *testing.T) {
+		s := newSchedule()
+		s.AfterExecution(date("2026-03-20").Add(time.Hour), true, false)
+		if !s.OccurrenceAt.Equal(date("2026-03-26")) {
+			t.Errorf("occurrence = %s, want 2026-03-26", s.OccurrenceAt)
+		}
+	})
+
+	t.Run("completes at the end date", func(t *testing.T) {
+		s := newSchedule()
+		end := date("2026-03-10")
+		s.EndDate = &end
+		s.AfterExecution(now, true, false)
+		if s.Status != ScheduleStatusCompleted {
+			t.Errorf("status = %s, want completed", s.Status)
+		}
+	})
+}
+
+func TestIsInsufficientFunds(t *testing.T) {
+	if !IsInsufficientFunds("insufficient funds") {
+		t.Error("IsInsufficientFunds(insufficient funds) = false")
+	}
+	if IsInsufficientFunds("account is frozen") {
+		t.Error("IsInsufficientFunds(account is frozen) = true")
+	}
+}
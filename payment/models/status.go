@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// paymentTransitions lists, for each status, the statuses a payment may move into it from
+var paymentTransitions = map[string][]string{
+	PaymentStatusProcessing: {PaymentStatusPending},
+	PaymentStatusCompleted:  {PaymentStatusProcessing},
+	PaymentStatusFailed:     {PaymentStatusPending, PaymentStatusProcessing},
+
+	PaymentStatusSentToClearing: {PaymentStatusCompleted},
+	PaymentStatusSettled:        {PaymentStatusSentToClearing},
+	PaymentStatusReturned:       {PaymentStatusSentToClearing},
+
+	PaymentStatusDebited:   {PaymentStatusProcessing},
+	PaymentStatusSubmitted: {PaymentStatusDebited},
+	PaymentStatusConfirmed: {PaymentStatusSubmitted},
+	PaymentStatusRefunded:  {PaymentStatusDebited, PaymentStatusSubmitted},
+}
+
+// finalStatuses are the statuses that finish processing a payment; processed_at is set on entering them
+var finalStatuses = map[string]bool{
+	PaymentStatusCompleted: true,
+	PaymentStatusFailed:    true,
+	PaymentStatusConfirmed: true,
+	PaymentStatusRefunded:  true,
+}
+
+// IsProcessedStatus reports whether reaching the status finishes processing a payment
+func IsProcessedStatus(status string) bool {
+	return finalStatuses[status]
+}
+
+// AllowedFromStatuses returns the statuses a payment may move to the given status from
+func AllowedFromStatuses(to string) []string {
+	return paymentTransitions[to]
+}
+
+// CanTransition reports whether a payment may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, s := range paymentTransitions[to] {
+		if s == from {
+			return true
+		}
+	}
+	return false
+}
+
+// Causes recorded against status transitions
+const (
+	TransitionCauseDispatched     = "dispatched"      // submitted to the account service
+	TransitionCausePublishFailed  = "publish_failed"  // payment.requested could not be published
+	TransitionCauseAccountResult  = "account_result"  // payment.completed / payment.failed event
+	TransitionCauseSagaRecovery   = "saga_recovery"   // settled by the saga sweeper
+	TransitionCauseSagaTimeout    = "saga_timeout"    // failed by the saga sweeper after retries
+	TransitionCauseClearingCutoff = "clearing_cutoff" // batched into a pacs.008 message at cut-off
+	TransitionCauseClearingReport = "clearing_report" // pacs.002 status report imported
+	TransitionCauseScheduleLink   = "schedule_link"   // a scheduled payment could not be linked to its execution
+
+	TransitionCauseProviderSubmit   = "provider_submit"   // reply to the submission to the provider
+	TransitionCauseProviderCallback = "provider_callback" // asynchronous callback from the provider
+	TransitionCauseProviderQuery    = "provider_query"    // status query by the provider sweeper
+)
+
+// StatusTransition is a row of a payment's status history
+type StatusTransition struct {
+	ID         int64     `json:"id"`
+	PaymentID  int64     `json:"payment_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Cause      string    `json:"cause"`
+	Detail     *string   `json:"detail,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
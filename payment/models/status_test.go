@@ -0,0 +1,66 @@
+package models
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want bool
+	}{
+		{name: "dispatch pending", from: PaymentStatusPending, to: PaymentStatusProcessing, want: true},
+		{name: "complete processing", from: PaymentStatusProcessing, to: PaymentStatusCompleted, want: true},
+		{name: "fail processing", from: PaymentStatusProcessing, to: PaymentStatusFailed, want: true},
+		{name: "fail unpublished", from: PaymentStatusPending, to: PaymentStatusFailed, want: true},
+		{name: "late failure after completion", from: PaymentStatusCompleted, to: PaymentStatusFailed, want: false},
+		{name: "late completion after failure", from: PaymentStatusFailed, to: PaymentStatusCompleted, want: false},
+		{name: "complete without processing", from: PaymentStatusPending, to: PaymentStatusCompleted, want: false},
+		{name: "submit completed to clearing", from: PaymentStatusCompleted, to: PaymentStatusSentToClearing, want: true},
+		{name: "settle in clearing", from: PaymentStatusSentToClearing, to: PaymentStatusSettled, want: true},
+		{name: "return in clearing", from: PaymentStatusSentToClearing, to: PaymentStatusReturned, want: true},
+		{name: "clear before debit", from: PaymentStatusProcessing, to: PaymentStatusSentToClearing, want: false},
+		{name: "return after settlement", from: PaymentStatusSettled, to: PaymentStatusReturned, want: false},
+		{name: "settle without clearing", from: PaymentStatusCompleted, to: PaymentStatusSettled, want: false},
+		{name: "debit provider payment", from: PaymentStatusProcessing, to: PaymentStatusDebited, want: true},
+		{name: "submit to provider", from: PaymentStatusDebited, to: PaymentStatusSubmitted, want: true},
+		{name: "provider confirms", from: PaymentStatusSubmitted, to: PaymentStatusConfirmed, want: true},
+		{name: "provider declines", from: PaymentStatusSubmitted, to: PaymentStatusRefunded, want: true},
+		{name: "refund unsubmitted", from: PaymentStatusDebited, to: PaymentStatusRefunded, want: true},
+		{name: "confirm without submission", from: PaymentStatusDebited, to: PaymentStatusConfirmed, want: false},
+		{name: "refund after confirmation", from: PaymentStatusConfirmed, to: PaymentStatusRefunded, want: false},
+		{name: "refund before debit", from: PaymentStatusProcessing, to: PaymentStatusRefunded, want: false},
+		{name: "same status", from: PaymentStatusProcessing, to: PaymentStatusProcessing, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebitedStatus(t *testing.T) {
+	merchantID := int64(1)
+	tests := []struct {
+		name    string
+		payment Payment
+		want    string
+	}{
+		{name: "mobile", payment: Payment{PaymentType: PaymentTypeMobile}, want: PaymentStatusDebited},
+		{name: "merchant", payment: Payment{PaymentType: PaymentTypeMerchant}, want: PaymentStatusDebited},
+		{name: "acquired merchant", payment: Payment{PaymentType: PaymentTypeMerchant, MerchantID: &merchantID}, want: PaymentStatusCompleted},
+		{name: "bill", payment: Payment{PaymentType: PaymentTypeBill}, want: PaymentStatusCompleted},
+		{name: "external", payment: Payment{PaymentType: PaymentTypeExternal}, want: PaymentStatusCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DebitedStatus(&tt.payment); got != tt.want {
+				t.Errorf("DebitedStatus(%s) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrTemplateTypeMismatch = errors.New("payment_type does not match the template")
+	ErrTemplateNoAccount    = errors.New("account_id required: the template does not name a source account")
+)
+
+// PaymentTemplate is a payment a customer has saved to repeat, such as a utility bill or a phone
+// top-up. Everything but the name and type is optional and can be given when the template is used.
+type PaymentTemplate struct {
+	ID               int64            `json:"id"`
+	UserID           int64            `json:"user_id"`
+	Name             string           `json:"name"`
+	PaymentType      string           `json:"payment_type"`
+	AccountID        *int64           `json:"account_id,omitempty"`
+	BeneficiaryID    *int64           `json:"beneficiary_id,omitempty"`
+	BillerID         *int64           `json:"biller_id,omitempty"`
+	MerchantID       *int64           `json:"merchant_id,omitempty"`
+	RecipientName    *string          `json:"recipient_name,omitempty"`
+	RecipientAccount *string          `json:"recipient_account,omitempty"`
+	RecipientBank    *string          `json:"recipient_bank,omitempty"`
+	Amount           *decimal.Decimal `json:"amount,omitempty"`
+	Currency         *string          `json:"currency,omitempty"`
+	Description      *string          `json:"description,omitempty"`
+	LastUsedAt       *time.Time       `json:"last_used_at,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
+
+type PaymentTemplateListResponse struct {
+	Templates []PaymentTemplate `json:"templates"`
+	Total     int64             `json:"total"`
+}
+
+// CreatePaymentTemplateRequest saves a payment template
+type CreatePaymentTemplateRequest struct {
+	Name             string           `json:"name" binding:"required,max=64"`
+	PaymentType      string           `json:"payment_type" binding:"required,oneof=bill merchant external mobile"`
+	AccountID        *int64           `json:"account_id"`
+	BeneficiaryID    *int64           `json:"beneficiary_id"`
+	BillerID         *int64           `json:"biller_id"`
+	MerchantID       *int64           `json:"merchant_id"`
+	RecipientName    *string          `json:"recipient_name"`
+	RecipientAccount *string          `json:"recipient_account"`
+	RecipientBank    *string          `json:"recipient_bank"`
+	Amount           *decimal.Decimal `json:"amount"`
+	Currency         *string          `json:"currency" binding:"omitempty,len=3"`
+	Description      *string          `json:"description"`
+}
+
+// UpdatePaymentTemplateRequest changes a template; its payment type is fixed
+type UpdatePaymentTemplateRequest struct {
+	Name             *string          `json:"name" binding:"omitempty,max=64"`
+	AccountID        *int64           `json:"account_id"`
+	BeneficiaryID    *int64           `json:"beneficiary_id"`
+	BillerID         *int64           `json:"biller_id"`
+	MerchantID       *int64           `json:"merchant_id"`
+	RecipientName    *string          `json:"recipient_name"`
+	RecipientAccount *string          `json:"recipient_account"`
+	RecipientBank    *string          `json:"recipient_bank"`
+	Amount           *decimal.Decimal `json:"amount"`
+	Currency         *string          `json:"currency" binding:"omitempty,len=3"`
+	Description      *string          `json:"description"`
+}
+
+// Apply returns a copy of the template with the update's fields set
+func (r *UpdatePaymentTemplateRequest) Apply(t PaymentTemplate) PaymentTemplate {
+	if r.Name != nil {
+		t.Name = *r.Name
+	}
+	if r.AccountID != nil {
+		t.AccountID = r.AccountID
+	}
+	if r.BeneficiaryID != nil {
+		t.BeneficiaryID = r.BeneficiaryID
+	}
+	if r.BillerID != nil {
+		t.BillerID = r.BillerID
+	}
+	if r.MerchantID != nil {
+		t.MerchantID = r.MerchantID
+	}
+	if r.RecipientName != nil {
+		t.RecipientName = r.RecipientName
+	}
+	if r.RecipientAccount != nil {
+		t.RecipientAccount = r.RecipientAccount
+	}
+	if r.RecipientBank != nil {
+		t.RecipientBank = r.RecipientBank
+	}
+	if r.Amount != nil {
+		t.Amount = r.Amount
+	}
+	if r.Currency != nil {
+		t.Currency = r.Currency
+	}
+	if r.Description != nil {
+		t.Description = r.Description
+	}
+	return t
+}
+
+// Fill completes a payment request from the template. Fields the request sets are kept as
+// overrides; the payment type, if given, must be the template's.
+func (t *PaymentTemplate) Fill(req *CreatePaymentRequest) error {
+	if req.PaymentType != "" && req.PaymentType != t.PaymentType {
+		return ErrTemplateTypeMismatch
+	}
+	req.PaymentType = t.PaymentType
+
+	if req.AccountID == 0 {
+		if t.AccountID == nil {
+			return ErrTemplateNoAccount
+		}
+		req.AccountID = *t.AccountID
+	}
+	if req.BeneficiaryID == nil {
+		req.BeneficiaryID = t.BeneficiaryID
+	}
+	if req.BillerID == nil {
+		req.BillerID = t.BillerID
+	}
+	if req.MerchantID == nil {
+		req.MerchantID = t.MerchantID
+	}
+	if req.RecipientName == nil {
+		req.RecipientName = t.RecipientName
+	}
+	if req.RecipientAccount == nil {
+		req.RecipientAccount = t.RecipientAccount
+	}
+	if req.RecipientBank == nil {
+		req.RecipientBank = t.RecipientBank
+	}
+	if req.Amount.IsZero() && t.Amount != nil {
+		req.Amount = *t.Amount
+	}
+	if req.Currency == "" && t.Currency != nil {
+		req.Currency = *t.Currency
+	}
+	if req.Description == nil {
+		req.Description = t.Description
+	}
+	return nil
+}
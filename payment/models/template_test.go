@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPaymentTemplateFill(t *testing.T) {
+	accountID := int64(7)
+	billerID := int64(3)
+	reference := "4111111111111111"
+	description := "Electricity"
+	amount := decimal.NewFromInt(40)
+	currency := "EUR"
+	template := PaymentTemplate{
+		PaymentType:      PaymentTypeBill,
+		AccountID:        &accountID,
+		BillerID:         &billerID,
+		RecipientAccount: &reference,
+		Amount:           &amount,
+		Currency:         &currency,
+		Description:      &description,
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		req := CreatePaymentRequest{}
+		if err := template.Fill(&req); err != nil {
+			t.Fatalf("Fill() error = %v", err)
+		}
+		if req.PaymentType != PaymentTypeBill || req.AccountID != accountID || *req.BillerID != billerID {
+			t.Errorf("Fill() = %+v, want the template's type, account and biller", req)
+		}
+		if !req.Amount.Equal(amount) || req.Currency != currency || *req.Description != description {
+			t.Errorf("Fill() amount %s %s %q, want 40 EUR %q", req.Amount, req.Currency, *req.Description, description)
+		}
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		otherAccount := int64(8)
+		otherDescription := "Electricity, March"
+		req := CreatePaymentRequest{
+			AccountID:   otherAccount,
+			PaymentType: PaymentTypeBill,
+			Amount:      decimal.NewFromInt(55),
+			Description: &otherDescription,
+		}
+		if err := template.Fill(&req); err != nil {
+			t.Fatalf("Fill() error = %v", err)
+		}
+		if req.AccountID != otherAccount || !req.Amount.Equal(decimal.NewFromInt(55)) || *req.Description != otherDescription {
+			t.Errorf("Fill() = %+v, want the request's account, amount and description kept", req)
+		}
+		if *req.RecipientAccount != reference {
+			t.Errorf("Fill() reference = %q, want %q", *req.RecipientAccount, reference)
+		}
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		req := CreatePaymentRequest{PaymentType: PaymentTypeMobile}
+		if err := template.Fill(&req); !errors.Is(err, ErrTemplateTypeMismatch) {
+			t.Errorf("Fill() error = %v, want %v", err, ErrTemplateTypeMismatch)
+		}
+	})
+
+	t.Run("no account", func(t *testing.T) {
+		noAccount := template
+		noAccount.AccountID = nil
+		req := CreatePaymentRequest{}
+		if err := noAccount.Fill(&req); !errors.Is(err, ErrTemplateNoAccount) {
+			t.Errorf("Fill() error = %v, want %v", err, ErrTemplateNoAccount)
+		}
+	})
+}
+
+func TestUpdatePaymentTemplateRequestApply(t *testing.T) {
+	amount := decimal.NewFromInt(10)
+	name := "Mum's phone"
+	existing := PaymentTemplate{Name: "Phone", PaymentType: PaymentTypeMobile, Amount: &amount}
+
+	newAmount := decimal.NewFromInt(20)
+	got := (&UpdatePaymentTemplateRequest{Name: &name, Amount: &newAmount}).Apply(existing)
+	if got.Name != name || !got.Amount.Equal(newAmount) || got.PaymentType != PaymentTypeMobile {
+		t.Errorf("Apply() = %+v, want name %q, amount 20 and type kept", got, name)
+	}
+	if !existing.Amount.Equal(amount) || existing.Name != "Phone" {
+		t.Errorf("Apply() changed the template it was given: %+v", existing)
+	}
+}
@@ -0,0 +1,54 @@
+// Package provider defines the adapters the payment service hands debited payments to, such as a
+// mobile operator's top-up gateway or a merchant acquirer, and ships a local simulator.
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+)
+
+// Outcomes a provider reports for a payment
+const (
+	StatusPending   = "pending"   // accepted; the final outcome follows by callback or status query
+	StatusConfirmed = "confirmed" // the payee received the funds
+	StatusFailed    = "failed"    // declined; the debit must be refunded
+)
+
+var (
+	// ErrUnknownTransaction means the provider has no record of the payment, so it was never
+	// submitted and may be submitted again or refunded
+	ErrUnknownTransaction = errors.New("provider has no record of the transaction")
+	ErrInvalidSignature   = errors.New("invalid callback signature")
+)
+
+// SubmitRequest is a debited payment handed to a provider
+type SubmitRequest struct {
+	ReferenceID      string
+	PaymentType      string
+	RecipientName    string
+	RecipientAccount string
+	Amount           decimal.Decimal
+	Currency         string
+	CallbackURL      string
+}
+
+// Result is a provider's view of a payment
+type Result struct {
+	ReferenceID       string `json:"reference_id"`
+	ProviderReference string `json:"provider_reference,omitempty"`
+	Status            string `json:"status"`
+	Reason            string `json:"reason,omitempty"`
+}
+
+// PaymentProvider submits payments to an external provider and interprets its replies.
+// Submit must be idempotent by reference ID: submitting a payment again returns the outcome of
+// the first submission rather than paying twice.
+type PaymentProvider interface {
+	Name() string
+	Submit(ctx context.Context, req SubmitRequest) (*Result, error)
+	QueryStatus(ctx context.Context, referenceID string) (*Result, error)
+	HandleCallback(header http.Header, body []byte) (*Result, error)
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Simulator modes
+const (
+	SimulatorSucceed = "succeed" // confirm every payment synchronously
+	SimulatorFail    = "fail"    // decline every payment synchronously
+	SimulatorTimeout = "timeout" // process the payment but never answer the submission
+	SimulatorLate    = "late"    // accept the payment and report the outcome by callback later
+)
+
+// SimulatorName is the name the simulator is registered and recorded on payments under
+const SimulatorName = "simulator"
+
+// SignatureHeader carries the hex HMAC-SHA256 of a simulator callback body
+const SignatureHeader = "X-Simulator-Signature"
+
+// SimulatorConfig configures the local simulator provider
+type SimulatorConfig struct {
+	Mode        string
+	LateDelay   time.Duration // how long a late reply takes to arrive
+	LateOutcome string        // outcome a late reply reports: confirmed or failed
+	Secret      string        // key callbacks are signed with
+}
+
+// Simulator is an in-memory provider for local development and tests. Its records do not survive
+// a restart, after which it reports earlier payments as unknown.
+type Simulator struct {
+	cfg    SimulatorConfig
+	client *http.Client
+
+	mu   sync.Mutex
+	txns map[string]*Result
+}
+
+// NewSimulator creates a simulator provider
+func NewSimulator(cfg SimulatorConfig) (*Simulator, error) {
+	switch cfg.Mode {
+	case SimulatorSucceed, SimulatorFail, SimulatorTimeout, SimulatorLate:
+	default:
+		return nil, fmt.Errorf("unknown simulator mode %q", cfg.Mode)
+	}
+	if cfg.LateOutcome == "" {
+		cfg.LateOutcome = StatusConfirmed
+	}
+	if cfg.LateOutcome != StatusConfirmed && cfg.LateOutcome != StatusFailed {
+		return nil, fmt.Errorf("unknown simulator late outcome %q", cfg.LateOutcome)
+	}
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("simulator callback secret is required")
+	}
+
+	return &Simulator{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		txns:   make(map[string]*Result),
+	}, nil
+}
+
+func (s *Simulator) Name() string {
+	return SimulatorName
+}
+
+// Submit processes a payment according to the simulator's mode. In timeout mode it blocks until
+// ctx is done, so callers must bound ctx.
+func (s *Simulator) Submit(ctx context.Context, req SubmitRequest) (*Result, error) {
+	s.mu.Lock()
+	if existing, ok := s.txns[req.ReferenceID]; ok {
+		result := *existing
+		s.mu.Unlock()
+		return &result, nil
+	}
+
+	result := &Result{
+		ReferenceID:       req.ReferenceID,
+		ProviderReference: "SIM-" + strings.ToUpper(uuid.NewString()[:8]),
+	}
+	switch s.cfg.Mode {
+	case SimulatorSucceed, SimulatorTimeout:
+		result.Status = StatusConfirmed
+	case SimulatorFail:
+		result.Status = StatusFailed
+		result.Reason = "declined by simulator"
+	case SimulatorLate:
+		result.Status = StatusPending
+	}
+	s.txns[req.ReferenceID] = result
+	reply := *result
+	s.mu.Unlock()
+
+	switch s.cfg.Mode {
+	case SimulatorTimeout:
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case SimulatorLate:
+		time.AfterFunc(s.cfg.LateDelay, func() { s.replyLate(req.ReferenceID, req.CallbackURL) })
+	}
+
+	return &reply, nil
+}
+
+// QueryStatus returns the simulator's record of a payment
+func (s *Simulator) QueryStatus(ctx context.Context, referenceID string) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.txns[referenceID]
+	if !ok {
+		return nil, ErrUnknownTransaction
+	}
+	result := *existing
+	return &result, nil
+}
+
+// HandleCallback verifies a callback's signature and returns the outcome it reports
+func (s *Simulator) HandleCallback(header http.Header, body []byte) (*Result, error) {
+	signature, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil || !hmac.Equal(signature, s.sign(body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var result Result
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid callback body: %w", err)
+	}
+	if result.ReferenceID == "" {
+		return nil, fmt.Errorf("invalid callback body: reference_id is required")
+	}
+	switch result.Status {
+	case StatusPending, StatusConfirmed, StatusFailed:
+	default:
+		return nil, fmt.Errorf("invalid callback body: unknown status %q", result.Status)
+	}
+
+	return &result, nil
+}
+
+// replyLate settles a late payment and reports the outcome to its callback URL
+func (s *Simulator) replyLate(referenceID, callbackURL string) {
+	s.mu.Lock()
+	existing, ok := s.txns[referenceID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	existing.Status = s.cfg.LateOutcome
+	if existing.Status == StatusFailed {
+		existing.Reason = "declined by simulator"
+	}
+	result := *existing
+	s.mu.Unlock()
+
+	if callbackURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Simulator failed to marshal callback for %s: %v", referenceID, err)
+		return
+	}
+
+	req, err := http.NewRequest("POST", callbackURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Simulator failed to create callback for %s: %v", referenceID, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, hex.EncodeToString(s.sign(body)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("Simulator callback for %s failed: %v", referenceID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Simulator callback for %s returned status %d", referenceID, resp.StatusCode)
+	}
+}
+
+func (s *Simulator) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(s.cfg.Secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
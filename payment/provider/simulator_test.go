@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestSimulator(t *testing.T, mode, lateOutcome string) *Simulator {
+	t.Helper()
+	sim, err := NewSimulator(SimulatorConfig{Mode: mode, LateDelay: 10 * time.Millisecond, LateOutcome: lateOutcome, Secret: "test-secret"})
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	return sim
+}
+
+func testSubmitRequest(ref string) SubmitRequest {
+	return SubmitRequest{
+		ReferenceID:      ref,
+		PaymentType:      "mobile",
+		RecipientName:    "Azercell",
+		RecipientAccount: "0501234567",
+		Amount:           decimal.NewFromInt(10),
+		Currency:         "AZN",
+	}
+}
+
+func TestSimulatorSubmit(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		wantStatus string
+		wantErr    bool
+	}{
+		{name: "succeed", mode: SimulatorSucceed, wantStatus: StatusConfirmed},
+		{name: "fail", mode: SimulatorFail, wantStatus: StatusFailed},
+		{name: "late", mode: SimulatorLate, wantStatus: StatusPending},
+		{name: "timeout", mode: SimulatorTimeout, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := newTestSimulator(t, tt.mode, "")
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			result, err := sim.Submit(ctx, testSubmitRequest("ref-1"))
+			if tt.wantErr {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("Submit() error = %v, want deadline exceeded", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Submit() status = %q, want %q", result.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSimulatorTimeoutIsProcessed(t *testing.T) {
+	sim := newTestSimulator(t, SimulatorTimeout, "")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	sim.Submit(ctx, testSubmitRequest("ref-1"))
+
+	// The reply was lost, but the payment went through; a status query and a resubmission say so
+	result, err := sim.QueryStatus(context.Background(), "ref-1")
+	if err != nil || result.Status != StatusConfirmed {
+		t.Fatalf("QueryStatus() = %+v, %v, want confirmed", result, err)
+	}
+	again, err := sim.Submit(context.Background(), testSubmitRequest("ref-1"))
+	if err != nil || again.ProviderReference != result.ProviderReference {
+		t.Fatalf("resubmit = %+v, %v, want the original result %+v", again, err, result)
+	}
+}
+
+func TestSimulatorQueryUnknown(t *testing.T) {
+	sim := newTestSimulator(t, SimulatorSucceed, "")
+	if _, err := sim.QueryStatus(context.Background(), "missing"); !errors.Is(err, ErrUnknownTransaction) {
+		t.Fatalf("QueryStatus() error = %v, want ErrUnknownTransaction", err)
+	}
+}
+
+func TestSimulatorLateCallback(t *testing.T) {
+	sim := newTestSimulator(t, SimulatorLate, StatusFailed)
+
+	received := make(chan *Result, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		result, err := sim.HandleCallback(r.Header, body)
+		if err != nil {
+			t.Errorf("HandleCallback() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- result
+	}))
+	defer server.Close()
+
+	req := testSubmitRequest("ref-late")
+	req.CallbackURL = server.URL
+	if _, err := sim.Submit(context.Background(), req); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	select {
+	case result := <-received:
+		if result.ReferenceID != "ref-late" || result.Status != StatusFailed {
+			t.Errorf("callback = %+v, want ref-late failed", result)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback was not delivered")
+	}
+}
+
+func TestSimulatorHandleCallbackSignature(t *testing.T) {
+	sim := newTestSimulator(t, SimulatorLate, "")
+	other, _ := NewSimulator(SimulatorConfig{Mode: SimulatorLate, Secret: "other-secret"})
+	body := []byte(`{"reference_id":"ref-1","status":"confirmed"}`)
+
+	tests := []struct {
+		name      string
+		signature string
+		wantErr   error
+	}{
+		{name: "valid", signature: hex.EncodeToString(sim.sign(body))},
+		{name: "wrong key", signature: hex.EncodeToString(other.sign(body)), wantErr: ErrInvalidSignature},
+		{name: "missing", signature: "", wantErr: ErrInvalidSignature},
+		{name: "not hex", signature: "zz", wantErr: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(SignatureHeader, tt.signature)
+			_, err := sim.HandleCallback(header, body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("HandleCallback() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"payment/models"
+	"payment/provider"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// providerConfig controls how debited payments are handed to providers and followed up
+type providerConfig struct {
+	Default       string        // provider new payments are submitted to
+	CallbackURL   string        // base URL providers post callbacks to; the provider name is appended
+	SubmitTimeout time.Duration // how long a submission may take before it is left for a retry
+	RetryDelay    time.Duration // how long a debited payment waits before its submission is retried
+	QueryAfter    time.Duration // how long a submitted payment waits for a callback before its status is queried
+	MaxPending    time.Duration // how long a payment may stay unresolved before it is refunded or escalated
+	Interval      time.Duration // how often the sweeper runs
+}
+
+const (
+	providerSweepBatchSize = 100
+
+	// maxProviderCallbackSize bounds a callback body
+	maxProviderCallbackSize = 64 << 10
+)
+
+// runProviderSweeper periodically retries submissions and queries payments whose callback is overdue
+func runProviderSweeper(ctx context.Context, cfg providerConfig) {
+	log.Printf("Starting provider sweeper (provider %s, query after %s, max pending %s, interval %s)",
+		cfg.Default, cfg.QueryAfter, cfg.MaxPending, cfg.Interval)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping provider sweeper")
+			return
+		case <-ticker.C:
+			sweepProviderPayments(ctx, cfg)
+		}
+	}
+}
+
+func sweepProviderPayments(ctx context.Context, cfg providerConfig) {
+	debited, err := paymentRepo.ListStale(ctx, models.PaymentStatusDebited, time.Now().Add(-cfg.RetryDelay), providerSweepBatchSize)
+	if err != nil {
+		log.Printf("Provider sweeper failed to list debited payments: %v", err)
+	} else {
+		for i := range debited {
+			if ctx.Err() != nil {
+				return
+			}
+			retryProviderSubmission(ctx, cfg, &debited[i])
+		}
+	}
+
+	submitted, err := paymentRepo.ListStale(ctx, models.PaymentStatusSubmitted, time.Now().Add(-cfg.QueryAfter), providerSweepBatchSize)
+	if err != nil {
+		log.Printf("Provider sweeper failed to list submitted payments: %v", err)
+		return
+	}
+	for i := range submitted {
+		if ctx.Err() != nil {
+			return
+		}
+		queryProviderPayment(ctx, cfg, &submitted[i])
+	}
+}
+
+// submitToProvider hands a debited payment to its provider and applies the reply. A submission that
+// errors or times out leaves the payment debited for the sweeper to retry.
+func submitToProvider(ctx context.Context, payment *models.Payment) {
+	p, err := providerFor(payment)
+	if err != nil {
+		alertOps(ctx, "critical", payment, err.Error())
+		return
+	}
+
+	result, err := submitPayment(ctx, p, payment)
+	if err != nil {
+		log.Printf("Submitting payment %d to %s failed: %v", payment.ID, p.Name(), err)
+		return
+	}
+
+	if _, err := applyProviderResult(ctx, p.Name(), payment, result, models.TransitionCauseProviderSubmit); err != nil {
+		log.Printf("Failed to apply %s reply for payment %d: %v", p.Name(), payment.ID, err)
+	}
+}
+
+func submitPayment(ctx context.Context, p provider.PaymentProvider, payment *models.Payment) (*provider.Result, error) {
+	req := provider.SubmitRequest{
+		ReferenceID: payment.ReferenceID.String(),
+		PaymentType: payment.PaymentType,
+		Amount:      payment.Amount,
+		Currency:    payment.Currency,
+		CallbackURL: providerCfg.CallbackURL + "/" + p.Name(),
+	}
+	if payment.RecipientName != nil {
+		req.RecipientName = *payment.RecipientName
+	}
+	if payment.RecipientAccount != nil {
+		req.RecipientAccount = *payment.RecipientAccount
+	}
+
+	submitCtx, cancel := context.WithTimeout(ctx, providerCfg.SubmitTimeout)
+	defer cancel()
+	return p.Submit(submitCtx, req)
+}
+
+// retryProviderSubmission submits a payment that is still debited again. Once it has waited
+// MaxPending the provider is asked instead, and a payment it never received is refunded.
+func retryProviderSubmission(ctx context.Context, cfg providerConfig, payment *models.Payment) {
+	if time.Since(payment.UpdatedAt) < cfg.MaxPending {
+		submitToProvider(ctx, payment)
+		return
+	}
+
+	p, err := providerFor(payment)
+	if err != nil {
+		alertOps(ctx, "critical", payment, err.Error())
+		return
+	}
+
+	result, err := p.QueryStatus(ctx, payment.ReferenceID.String())
+	switch {
+	case errors.Is(err, provider.ErrUnknownTransaction):
+		if _, err := refundProviderPayment(ctx, payment, "provider unavailable", models.TransitionCauseProviderQuery); err == nil {
+			alertOps(ctx, "warning", payment, fmt.Sprintf("payment could not be submitted to %s within %s and was refunded", p.Name(), cfg.MaxPending))
+		}
+	case err != nil:
+		alertOps(ctx, "critical", payment, fmt.Sprintf("payment could not be submitted to %s and its status could not be queried: %v", p.Name(), err))
+	default:
+		if _, err := applyProviderResult(ctx, p.Name(), payment, result, models.TransitionCauseProviderQuery); err != nil {
+			log.Printf("Failed to apply %s status for payment %d: %v", p.Name(), payment.ID, err)
+		}
+	}
+}
+
+// queryProviderPayment asks the provider about a submitted payment whose callback is overdue
+func queryProviderPayment(ctx context.Context, cfg providerConfig, payment *models.Payment) {
+	p, err := providerFor(payment)
+	if err != nil {
+		alertOps(ctx, "critical", payment, err.Error())
+		return
+	}
+
+	result, err := p.QueryStatus(ctx, payment.ReferenceID.String())
+	if errors.Is(err, provider.ErrUnknownTransaction) {
+		// The provider lost the payment; submissions are idempotent, so hand it over again
+		result, err = submitPayment(ctx, p, payment)
+	}
+	if err != nil {
+		log.Printf("Provider sweeper could not query %s for payment %d: %v", p.Name(), payment.ID, err)
+		return
+	}
+
+	if result.Status == provider.StatusPending {
+		// Alert once, on the sweep that first finds the payment past MaxPending
+		since := payment.UpdatedAt
+		if payment.SubmittedAt != nil {
+			since = *payment.SubmittedAt
+		}
+		if age := time.Since(since); age >= cfg.MaxPending && age < cfg.MaxPending+cfg.Interval {
+			alertOps(ctx, "warning", payment, fmt.Sprintf("payment has been pending at %s for %s", p.Name(), age.Round(time.Second)))
+		}
+		return
+	}
+
+	if _, err := applyProviderResult(ctx, p.Name(), payment, result, models.TransitionCauseProviderQuery); err != nil {
+		log.Printf("Failed to apply %s status for payment %d: %v", p.Name(), payment.ID, err)
+	}
+}
+
+// applyProviderResult moves a payment on according to its provider's reply: pending and confirmed
+// payments are marked submitted, confirmed ones then confirmed, and failed ones are refunded
+func applyProviderResult(ctx context.Context, providerName string, payment *models.Payment, result *provider.Result, cause string) (*models.Payment, error) {
+	switch result.Status {
+	case provider.StatusPending, provider.StatusConfirmed:
+		payment, err := markProviderSubmitted(ctx, providerName, payment, result, cause)
+		if err != nil || result.Status == provider.StatusPending {
+			return payment, err
+		}
+		return paymentRepo.UpdateStatus(ctx, payment.ID, models.PaymentStatusConfirmed, nil, cause)
+
+	case provider.StatusFailed:
+		reason := result.Reason
+		if reason == "" {
+			reason = "declined by " + providerName
+		}
+		return refundProviderPayment(ctx, payment, reason, cause)
+
+	default:
+		return nil, fmt.Errorf("unknown provider status %q", result.Status)
+	}
+}
+
+// markProviderSubmitted records the submission of a payment that is still debited. A concurrent
+// reply may have recorded it first, in which case the payment is reloaded.
+func markProviderSubmitted(ctx context.Context, providerName string, payment *models.Payment, result *provider.Result, cause string) (*models.Payment, error) {
+	if payment.Status != models.PaymentStatusDebited {
+		return payment, nil
+	}
+
+	var providerRef *string
+	if result.ProviderReference != "" {
+		providerRef = &result.ProviderReference
+	}
+
+	submitted, err := paymentRepo.MarkSubmitted(ctx, payment.ID, providerName, providerRef, cause)
+	if errors.Is(err, repository.ErrInvalidTransition) {
+		return paymentRepo.GetByID(ctx, payment.ID)
+	}
+	return submitted, err
+}
+
+// refundProviderPayment credits a debited payment back to its account and marks it refunded. The
+// account service applies the refund reference once, so a refund interrupted between the two steps
+// is completed safely by a later callback or sweep.
+func refundProviderPayment(ctx context.Context, payment *models.Payment, reason, cause string) (*models.Payment, error) {
+	if !models.CanTransition(payment.Status, models.PaymentStatusRefunded) {
+		return nil, fmt.Errorf("%w: payment %d is %s", repository.ErrInvalidTransition, payment.ID, payment.Status)
+	}
+
+	if _, err := creditAccount(payment.AccountID, payment.Amount, payment.Currency, "refund-"+payment.ReferenceID.String(),
+		accountOperationPaymentRefund, payment.ID); err != nil {
+		alertOps(ctx, "critical", payment, "provider declined the payment but the refund failed: "+err.Error())
+		return nil, err
+	}
+
+	refunded, err := paymentRepo.UpdateStatus(ctx, payment.ID, models.PaymentStatusRefunded, &reason, cause)
+	if err != nil {
+		return nil, fmt.Errorf("refunded but not recorded: %w", err)
+	}
+	log.Printf("Payment %d refunded: %s", payment.ID, reason)
+	return refunded, nil
+}
+
+// providerFor returns the provider a payment was submitted to, or the default for new submissions
+func providerFor(payment *models.Payment) (provider.PaymentProvider, error) {
+	name := providerCfg.Default
+	if payment.Provider != nil {
+		name = *payment.Provider
+	}
+
+	p, ok := paymentProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("payment provider %q is not configured", name)
+	}
+	return p, nil
+}
+
+// handleProviderCallback applies an asynchronous outcome reported by a provider. Callbacks are
+// authenticated by the provider adapter, not by user headers.
+func handleProviderCallback(c *gin.Context) {
+	name := c.Param("provider")
+	p, ok := paymentProviders[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown provider"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxProviderCallbackSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read callback"})
+		return
+	}
+
+	result, err := p.HandleCallback(c.Request.Header, body)
+	if err != nil {
+		if errors.Is(err, provider.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	referenceID, err := uuid.Parse(result.ReferenceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reference_id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	payment, err := paymentRepo.GetByReferenceID(ctx, referenceID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment"})
+		return
+	}
+
+	if !models.UsesProvider(payment) || (payment.Provider != nil && *payment.Provider != name) {
+		c.JSON(http.StatusConflict, gin.H{"error": "payment was not submitted to this provider"})
+		return
+	}
+
+	switch payment.Status {
+	case models.PaymentStatusConfirmed, models.PaymentStatusRefunded:
+		if (payment.Status == models.PaymentStatusConfirmed) == (result.Status == provider.StatusConfirmed) {
+			c.JSON(http.StatusOK, gin.H{"message": "callback already applied", "status": payment.Status})
+			return
+		}
+		alertOps(ctx, "critical", payment, fmt.Sprintf("%s reported %s after the payment was %s", name, result.Status, payment.Status))
+		c.JSON(http.StatusConflict, gin.H{"error": "payment is already " + payment.Status})
+		return
+	case models.PaymentStatusDebited, models.PaymentStatusSubmitted:
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "payment is " + payment.Status})
+		return
+	}
+
+	updated, err := applyProviderResult(ctx, name, payment, result, models.TransitionCauseProviderCallback)
+	if err != nil {
+		log.Printf("Failed to apply %s callback for payment %d: %v", name, payment.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply callback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "callback applied", "status": updated.Status})
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"payment/emvco"
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type qrConfig struct {
+	GUI        string        // identifies this bank's merchants in the merchant account information of a QR payload
+	DynamicTTL time.Duration // how long a dynamic QR code can be paid when the merchant does not say
+}
+
+func loadQRConfig() qrConfig {
+	return qrConfig{
+		GUI:        strings.ToUpper(getEnv("QR_MERCHANT_GUI", "COM.DBANK")),
+		DynamicTTL: getEnvDuration("QR_DYNAMIC_TTL", "15m"),
+	}
+}
+
+// generateMerchantQRCode issues an EMVCo QR code for a merchant to display, for its owner or an
+// admin. Without an amount the code is static and reusable, with the customer entering the amount;
+// with one it is a dynamic code that can be paid once before it expires.
+func generateMerchantQRCode(c *gin.Context) {
+	merchant, ok := loadOwnMerchant(c)
+	if !ok {
+		return
+	}
+
+	var req models.GenerateQRCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !merchant.Active {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": models.ErrMerchantUnavailable.Error()})
+		return
+	}
+	if merchant.City == nil || merchant.CountryCode == nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "merchant needs a city and country_code before it can issue QR codes"})
+		return
+	}
+	if !emvco.CurrencySupported(merchant.Currency) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("QR codes are not available in %s", merchant.Currency)})
+		return
+	}
+
+	payload := emvco.Payload{
+		Initiation:   emvco.InitiationStatic,
+		GUI:          qrCfg.GUI,
+		MerchantCode: merchant.Code,
+		MCC:          merchant.MCC,
+		Currency:     merchant.Currency,
+		Country:      *merchant.CountryCode,
+		MerchantName: merchant.Name,
+		MerchantCity: *merchant.City,
+	}
+
+	if req.Amount == nil {
+		if req.ExpiresIn != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in is only supported for QR codes with an amount"})
+			return
+		}
+		encoded, err := payload.Encode()
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, models.QRCodeResponse{
+			Type:       models.QRCodeStatic,
+			MerchantID: merchant.ID,
+			Payload:    encoded,
+			Currency:   merchant.Currency,
+		})
+		return
+	}
+
+	amount := *req.Amount
+	if !amount.IsPositive() || amount.Exponent() < -2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive with at most 2 decimal places"})
+		return
+	}
+	if _, err := merchant.ValidatePayment(amount, merchant.Currency); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	ttl := qrCfg.DynamicTTL
+	if req.ExpiresIn != nil {
+		// Capped before converting, so no value can overflow into an expiry in the past or far off
+		ttl = time.Duration(min(*req.ExpiresIn, models.MaxQRCodeExpiresIn)) * time.Minute
+	}
+
+	payload.Initiation = emvco.InitiationDynamic
+	payload.Amount = &amount
+	payload.ReferenceLabel = newQRReference()
+	encoded, err := payload.Encode()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	qr, err := qrCodeRepo.Create(c.Request.Context(), &models.QRCode{
+		MerchantID:  merchant.ID,
+		Reference:   payload.ReferenceLabel,
+		Amount:      amount,
+		Currency:    merchant.Currency,
+		Description: req.Description,
+		Payload:     encoded,
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+	if err != nil {
+		log.Printf("Failed to save QR code for merchant %d: %v", merchant.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create QR code"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.QRCodeResponse{
+		Type:       models.QRCodeDynamic,
+		MerchantID: merchant.ID,
+		Payload:    qr.Payload,
+		Reference:  qr.Reference,
+		Amount:     &qr.Amount,
+		Currency:   qr.Currency,
+		ExpiresAt:  &qr.ExpiresAt,
+	})
+}
+
+// payQRCode pays an acquired merchant from a scanned QR payload. The payload's checksum and its
+// merchant details are checked against the merchant it names; a dynamic code is used up by the
+// payment made with it.
+func payQRCode(c *gin.Context) {
+	userID, _, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.QRPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !requireOwnAccount(c, userID, req.AccountID) {
+		return
+	}
+
+	payload, err := emvco.Parse(req.Payload, qrCfg.GUI)
+	if err != nil {
+		if errors.Is(err, emvco.ErrForeignScheme) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merchant, err := merchantRepo.GetByCode(c.Request.Context(), payload.MerchantCode)
+	if err != nil {
+		respondMerchantError(c, err)
+		return
+	}
+	if payload.MCC != merchant.MCC || payload.Currency != merchant.Currency {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "QR code does not match the merchant's details"})
+		return
+	}
+
+	paymentReq := models.CreatePaymentRequest{
+		AccountID:   req.AccountID,
+		PaymentType: models.PaymentTypeMerchant,
+		Currency:    payload.Currency,
+		Description: req.Description,
+		MerchantID:  &merchant.ID,
+	}
+
+	// The amount comes from the code when it carries one; the customer may not change it
+	switch {
+	case payload.Amount != nil:
+		if req.Amount != nil && !req.Amount.Equal(*payload.Amount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount is set by the QR code"})
+			return
+		}
+		paymentReq.Amount = *payload.Amount
+	case req.Amount != nil:
+		paymentReq.Amount = *req.Amount
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount required for QR codes without one"})
+		return
+	}
+	if !paymentReq.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+
+	var qr *models.QRCode
+	if payload.IsDynamic() {
+		var ok bool
+		if qr, ok = loadPayableQRCode(c, payload, merchant); !ok {
+			return
+		}
+		if paymentReq.Description == nil {
+			paymentReq.Description = qr.Description
+		}
+	}
+
+	if !applyMerchant(c, &paymentReq) {
+		return
+	}
+
+	// Claim the dynamic code last so a rejected payment does not use it up
+	if qr != nil {
+		if _, err := qrCodeRepo.Claim(c.Request.Context(), qr.ID); err != nil {
+			respondQRCodeError(c, err)
+			return
+		}
+	}
+
+	payment, ok := initiatePayment(c, userID, &paymentReq)
+	if !ok {
+		if qr != nil {
+			if err := qrCodeRepo.Release(c.Request.Context(), qr.ID); err != nil {
+				log.Printf("Failed to release QR code %d: %v", qr.ID, err)
+			}
+		}
+		return
+	}
+
+	if qr != nil {
+		if err := qrCodeRepo.AttachPayment(c.Request.Context(), qr.ID, payment.ID); err != nil {
+			log.Printf("Failed to attach payment %d to QR code %d: %v", payment.ID, qr.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "payment initiated",
+		"payment_id":   payment.ID,
+		"reference_id": payment.ReferenceID,
+		"status":       payment.Status,
+		"merchant":     merchant.Name,
+		"amount":       payment.Amount,
+		"currency":     payment.Currency,
+	})
+}
+
+// loadPayableQRCode finds the dynamic QR code a payload was generated as and checks that it can
+// still be paid. The stored code must match the payload, so an edited payload is rejected even if
+// its checksum was recomputed.
+func loadPayableQRCode(c *gin.Context, payload *emvco.Payload, merchant *models.Merchant) (*models.QRCode, bool) {
+	qr, err := qrCodeRepo.GetByReference(c.Request.Context(), payload.ReferenceLabel)
+	if err != nil {
+		respondQRCodeError(c, err)
+		return nil, false
+	}
+
+	if qr.MerchantID != merchant.ID || !qr.Amount.Equal(*payload.Amount) || qr.Currency != payload.Currency {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "QR code does not match the one issued by the merchant"})
+		return nil, false
+	}
+	if err := qr.Payable(time.Now()); err != nil {
+		respondQRCodeError(c, err)
+		return nil, false
+	}
+
+	return qr, true
+}
+
+// newQRReference returns a reference label identifying a dynamic QR code
+func newQRReference() string {
+	return "QR" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:20])
+}
+
+func respondQRCodeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrQRCodeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "QR code not found"})
+	case errors.Is(err, models.ErrQRCodeExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrQRCodeUsed), errors.Is(err, repository.ErrQRCodeNotPayable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up QR code"})
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment/models"
+	"payment/repository"
+	"receipt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// receiptKey signs payment receipts; anyone holding a receipt can check it through the public
+// verify endpoint, which recomputes the hash from the payment
+var receiptKey []byte
+
+// getPaymentReceipt renders a signed receipt for a payment, to its owner or an admin, as JSON or,
+// with ?format=pdf, as a PDF download
+func getPaymentReceipt(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+
+	format, ok := receiptFormat(c)
+	if !ok {
+		return
+	}
+
+	payment, err := paymentRepo.GetByID(c.Request.Context(), paymentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment"})
+		return
+	}
+
+	if role != "admin" && payment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
+	r, err := buildPaymentReceipt(payment)
+	if err != nil {
+		log.Printf("Failed to build receipt for payment %d: %v", payment.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to build receipt"})
+		return
+	}
+
+	if format == "pdf" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="receipt-%s.pdf"`, r.ReferenceID))
+		c.Data(http.StatusOK, "application/pdf", r.PDF("Payment receipt", time.Now()))
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// verifyPaymentReceipt checks a receipt's verification hash against the payment it names. It is
+// public: a valid receipt only discloses what is already printed on it.
+func verifyPaymentReceipt(c *gin.Context) {
+	referenceID, err := uuid.Parse(c.Query("reference_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reference_id must be a payment reference"})
+		return
+	}
+	hash := c.Query("hash")
+	if hash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hash is required"})
+		return
+	}
+
+	payment, err := paymentRepo.GetByReferenceID(c.Request.Context(), referenceID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			c.JSON(http.StatusOK, gin.H{"valid": false})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment"})
+		return
+	}
+
+	r, err := buildPaymentReceipt(payment)
+	if err != nil {
+		log.Printf("Failed to build receipt for payment %d: %v", payment.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to verify receipt"})
+		return
+	}
+
+	if !r.Verify(receiptKey, hash) {
+		c.JSON(http.StatusOK, gin.H{"valid": false})
+		return
+	}
+	// The receipt's facts are as signed; its status is the current one, which may differ from
+	// the status printed on the receipt
+	c.JSON(http.StatusOK, gin.H{"valid": true, "status": r.Status, "receipt": r})
+}
+
+// buildPaymentReceipt describes a payment as its receipt. Payers are not charged a fee on
+// payments; a merchant's service charge is withheld from the merchant's credit instead.
+func buildPaymentReceipt(payment *models.Payment) (*receipt.Receipt, error) {
+	account, _, err := getAccountByID(payment.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up account %d: %w", payment.AccountID, err)
+	}
+
+	r := &receipt.Receipt{
+		Kind:        "payment",
+		ReferenceID: payment.ReferenceID.String(),
+		Type:        payment.PaymentType,
+		Status:      payment.Status,
+		FromAccount: receipt.MaskAccount(account.AccountNumber),
+		Currency:    payment.Currency,
+	}
+	if payment.RecipientAccount != nil {
+		r.ToAccount = receipt.MaskAccount(*payment.RecipientAccount)
+	}
+	if payment.RecipientName != nil {
+		r.Recipient = *payment.RecipientName
+	}
+	if payment.Description != nil {
+		r.Description = *payment.Description
+	}
+	r.SetAmounts(payment.Amount, decimal.Zero)
+
+	// processed_at also records when a payment failed or was refunded, which is not a completion
+	var completedAt *time.Time
+	if payment.Status != models.PaymentStatusFailed && payment.Status != models.PaymentStatusRefunded {
+		completedAt = payment.ProcessedAt
+	}
+	r.SetTimes(payment.CreatedAt, completedAt)
+
+	r.Sign(receiptKey)
+	return r, nil
+}
+
+// receiptFormat reads the requested receipt format: json (the default) or pdf, from ?format= or
+// an Accept header asking for a PDF
+func receiptFormat(c *gin.Context) (string, bool) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.GetHeader("Accept"), "application/pdf") {
+			format = "pdf"
+		}
+	}
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or pdf"})
+		return "", false
+	}
+	return format, true
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// refundPayment returns all or part of a completed payment to the account it was paid from
+// (admin only). A payment can be refunded several times until its whole amount is returned.
+func refundPayment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	userID, _, _ := getUserContext(c)
+
+	payment, ok := loadPayment(c)
+	if !ok {
+		return
+	}
+
+	issueRefund(c, payment, userID)
+}
+
+// refundMerchantPayment lets a merchant's owner (or an admin) refund a payment made to it. The
+// merchant pays back the refund less its share of the service charge, which the bank refunds.
+func refundMerchantPayment(c *gin.Context) {
+	userID, _, _ := getUserContext(c)
+
+	merchant, ok := loadOwnMerchant(c)
+	if !ok {
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(c.Param("payment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+
+	payment, err := paymentRepo.GetByID(c.Request.Context(), paymentID)
+	if err != nil && !errors.Is(err, repository.ErrPaymentNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment"})
+		return
+	}
+	if err != nil || payment.MerchantID == nil || *payment.MerchantID != merchant.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return
+	}
+
+	issueRefund(c, payment, userID)
+}
+
+// issueRefund records and publishes a refund of payment requested by userID
+func issueRefund(c *gin.Context, payment *models.Payment, userID int64) {
+	var req models.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount != nil && (req.Amount.LessThanOrEqual(decimal.Zero) || req.Amount.Exponent() < -2) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive with at most 2 decimal places"})
+		return
+	}
+
+	var reason *string
+	if trimmed := strings.TrimSpace(req.Reason); trimmed != "" {
+		reason = &trimmed
+	}
+
+	refund, err := refundRepo.Create(c.Request.Context(), payment.ID, req.Amount, reason, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPaymentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		case errors.Is(err, models.ErrPaymentNotRefundable):
+			c.JSON(http.StatusConflict, gin.H{"error": "payment cannot be refunded in status " + payment.Status})
+		case errors.Is(err, models.ErrRefundExceedsPayment), errors.Is(err, models.ErrInvalidRefundAmount):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create refund"})
+		}
+		return
+	}
+
+	if err := publishRefund(c.Request.Context(), payment, refund); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate refund"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, refund)
+}
+
+// listPaymentRefunds lists a payment's refunds with the amounts refunded and still refundable
+func listPaymentRefunds(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, ok := loadPayment(c)
+	if !ok {
+		return
+	}
+	if role != "admin" && payment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
+	refunds, err := refundRepo.ListByPayment(c.Request.Context(), payment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list refunds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+// publishRefund publishes the refund requested event, failing the refund if that is not possible
+// so its amount can be refunded again
+func publishRefund(ctx context.Context, payment *models.Payment, refund *models.Refund) error {
+	if err := kafkaProducer.PublishRefundRequested(ctx, payment, refund); err != nil {
+		log.Printf("Failed to publish refund event: %v", err)
+		refundRepo.MarkFailed(ctx, refund.ID, "failed to publish refund event")
+		return err
+	}
+
+	return nil
+}
+
+// sweepStuckRefunds re-publishes refunds the account service has not answered for. The account
+// service credits each refund reference at most once, so a re-published refund is never paid twice.
+func sweepStuckRefunds(ctx context.Context, cfg sagaConfig) {
+	stuck, err := refundRepo.ListStale(ctx, time.Now().Add(-cfg.Timeout), sagaSweepBatchSize)
+	if err != nil {
+		log.Printf("Saga sweeper failed to list stuck refunds: %v", err)
+		return
+	}
+
+	for i := range stuck {
+		if ctx.Err() != nil {
+			return
+		}
+		refund := &stuck[i]
+		payment, err := paymentRepo.GetByID(ctx, refund.PaymentID)
+		if err != nil {
+			log.Printf("Saga sweeper failed to load payment %d for refund %d: %v", refund.PaymentID, refund.ID, err)
+			continue
+		}
+		if err := kafkaProducer.PublishRefundRequested(ctx, payment, refund); err != nil {
+			log.Printf("Saga sweeper failed to re-publish refund %d: %v", refund.ID, err)
+			continue
+		}
+		log.Printf("Saga sweeper re-published refund %d of payment %d", refund.ID, refund.PaymentID)
+	}
+}
+
+func loadPayment(c *gin.Context) (*models.Payment, bool) {
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return nil, false
+	}
+
+	payment, err := paymentRepo.GetByID(c.Request.Context(), paymentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payment"})
+		return nil, false
+	}
+
+	return payment, true
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrBillerNotFound   = errors.New("biller not found")
+	ErrBillerCodeExists = errors.New("biller code already exists")
+	ErrBillerInUse      = errors.New("biller has payments")
+)
+
+// billerColumns is the column list selected for every biller query
+const billerColumns = `id, code, name, category, reference_label, reference_pattern, check_digit, min_amount,
+		       max_amount, currency, settlement_account_id, active, created_at, updated_at`
+
+func scanBiller(row rowScanner, b *models.Biller) error {
+	return row.Scan(
+		&b.ID, &b.Code, &b.Name, &b.Category, &b.ReferenceLabel, &b.ReferencePattern, &b.CheckDigit, &b.MinAmount,
+		&b.MaxAmount, &b.Currency, &b.SettlementAccountID, &b.Active, &b.CreatedAt, &b.UpdatedAt,
+	)
+}
+
+type BillerRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBillerRepository(db *pgxpool.Pool) *BillerRepository {
+	return &BillerRepository{db: db}
+}
+
+// Create adds a biller to the catalog
+func (r *BillerRepository) Create(ctx context.Context, b *models.Biller) (*models.Biller, error) {
+	query := `
+		INSERT INTO billers (code, name, category, reference_label, reference_pattern, check_digit,
+		                     min_amount, max_amount, currency, settlement_account_id, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + billerColumns
+
+	biller := &models.Biller{}
+	err := scanBiller(r.db.QueryRow(
+		ctx, query,
+		b.Code, b.Name, b.Category, b.ReferenceLabel, b.ReferencePattern, b.CheckDigit,
+		b.MinAmount, b.MaxAmount, b.Currency, b.SettlementAccountID, b.Active,
+	), biller)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrBillerCodeExists
+		}
+		return nil, fmt.Errorf("failed to create biller: %w", err)
+	}
+
+	return biller, nil
+}
+
+// GetByID retrieves a biller by ID
+func (r *BillerRepository) GetByID(ctx context.Context, id int64) (*models.Biller, error) {
+	query := `SELECT ` + billerColumns + ` FROM billers WHERE id = $1`
+
+	biller := &models.Biller{}
+	if err := scanBiller(r.db.QueryRow(ctx, query, id), biller); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBillerNotFound
+		}
+		return nil, fmt.Errorf("failed to get biller: %w", err)
+	}
+
+	return biller, nil
+}
+
+// List retrieves billers by name, optionally filtered by category and limited to active billers
+func (r *BillerRepository) List(ctx context.Context, category string, activeOnly bool) (*models.BillerListResponse, error) {
+	query := `
+		SELECT ` + billerColumns + `
+		FROM billers
+		WHERE ($1 = '' OR category = $1) AND (NOT $2 OR active)
+		ORDER BY category, name, id
+	`
+
+	rows, err := r.db.Query(ctx, query, category, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billers: %w", err)
+	}
+	defer rows.Close()
+
+	billers := []models.Biller{}
+	for rows.Next() {
+		var b models.Biller
+		if err := scanBiller(rows, &b); err != nil {
+			return nil, fmt.Errorf("failed to scan biller: %w", err)
+		}
+		billers = append(billers, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating billers: %w", err)
+	}
+
+	return &models.BillerListResponse{Billers: billers, Total: int64(len(billers))}, nil
+}
+
+// Update writes a biller's editable fields. Payments already created keep the settlement account
+// they were created with.
+func (r *BillerRepository) Update(ctx context.Context, b *models.Biller) (*models.Biller, error) {
+	query := `
+		UPDATE billers
+		SET name = $1, category = $2, reference_label = $3, reference_pattern = $4, check_digit = $5,
+		    min_amount = $6, max_amount = $7, settlement_account_id = $8, active = $9
+		WHERE id = $10
+		RETURNING ` + billerColumns
+
+	biller := &models.Biller{}
+	err := scanBiller(r.db.QueryRow(
+		ctx, query,
+		b.Name, b.Category, b.ReferenceLabel, b.ReferencePattern, b.CheckDigit,
+		b.MinAmount, b.MaxAmount, b.SettlementAccountID, b.Active, b.ID,
+	), biller)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBillerNotFound
+		}
+		return nil, fmt.Errorf("failed to update biller: %w", err)
+	}
+
+	return biller, nil
+}
+
+// Delete removes a biller that has never been paid; billers with payments can only be deactivated
+func (r *BillerRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM billers WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrBillerInUse
+		}
+		return fmt.Errorf("failed to delete biller: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrBillerNotFound
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrNothingToClear        = errors.New("no external payments waiting for clearing")
+	ErrClearingBatchNotFound = errors.New("clearing batch not found")
+)
+
+// clearingBatchColumns is the column list selected for every clearing batch query
+const clearingBatchColumns = `id, message_id, status, payment_count, control_sum, file_name, window_end, created_at, sent_at`
+
+func scanClearingBatch(row rowScanner, b *models.ClearingBatch) error {
+	return row.Scan(&b.ID, &b.MessageID, &b.Status, &b.PaymentCount, &b.ControlSum, &b.FileName,
+		&b.WindowEnd, &b.CreatedAt, &b.SentAt)
+}
+
+// CreateClearingBatch closes a clearing window: completed external payments last updated before
+// windowEnd that are not yet in a batch (up to limit, oldest first) are moved to sent_to_clearing
+// under a new pending batch, in one transaction. Returns ErrNothingToClear if none are waiting.
+func (r *PaymentRepository) CreateClearingBatch(ctx context.Context, messageID string, windowEnd time.Time, limit int) (*models.ClearingBatch, []models.Payment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, amount
+		FROM payments
+		WHERE payment_type = $1 AND status = $2 AND clearing_batch_id IS NULL AND updated_at <= $3
+		ORDER BY id
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	`, models.PaymentTypeExternal, models.PaymentStatusCompleted, windowEnd, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to select payments for clearing: %w", err)
+	}
+
+	var ids []int64
+	controlSum := decimal.Zero
+	for rows.Next() {
+		var id int64
+		var amount decimal.Decimal
+		if err := rows.Scan(&id, &amount); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		ids = append(ids, id)
+		controlSum = controlSum.Add(amount)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating payments: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil, ErrNothingToClear
+	}
+
+	batch := &models.ClearingBatch{}
+	err = scanClearingBatch(tx.QueryRow(ctx, `
+		INSERT INTO clearing_batches (message_id, status, payment_count, control_sum, window_end)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+clearingBatchColumns,
+		messageID, models.ClearingBatchStatusPending, len(ids), controlSum, windowEnd), batch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create clearing batch: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		UPDATE payments
+		SET status = $1, clearing_batch_id = $2, updated_at = NOW()
+		WHERE id = ANY($3)
+		RETURNING `+paymentColumns,
+		models.PaymentStatusSentToClearing, batch.ID, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to submit payments to clearing: %w", err)
+	}
+	payments, err := collectPayments(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO payment_status_history (payment_id, from_status, to_status, cause, detail)
+		SELECT id, $1, $2, $3, $4 FROM payments WHERE id = ANY($5)
+	`, models.PaymentStatusCompleted, models.PaymentStatusSentToClearing, models.TransitionCauseClearingCutoff,
+		"pacs.008 "+messageID, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to record status transitions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit clearing batch: %w", err)
+	}
+
+	return batch, payments, nil
+}
+
+// ListClearingBatchPayments returns the payments submitted in a clearing batch, in message order
+func (r *PaymentRepository) ListClearingBatchPayments(ctx context.Context, batchID int64) ([]models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE clearing_batch_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clearing batch payments: %w", err)
+	}
+
+	return collectPayments(rows)
+}
+
+// ClearingBatchRepository handles clearing batch bookkeeping
+type ClearingBatchRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewClearingBatchRepository creates a new clearing batch repository
+func NewClearingBatchRepository(db *pgxpool.Pool) *ClearingBatchRepository {
+	return &ClearingBatchRepository{db: db}
+}
+
+// GetByID retrieves a clearing batch by ID
+func (r *ClearingBatchRepository) GetByID(ctx context.Context, id int64) (*models.ClearingBatch, error) {
+	return r.getOne(ctx, `SELECT `+clearingBatchColumns+` FROM clearing_batches WHERE id = $1`, id)
+}
+
+// GetByMessageID retrieves a clearing batch by its pacs.008 message ID
+func (r *ClearingBatchRepository) GetByMessageID(ctx context.Context, messageID string) (*models.ClearingBatch, error) {
+	return r.getOne(ctx, `SELECT `+clearingBatchColumns+` FROM clearing_batches WHERE message_id = $1`, messageID)
+}
+
+func (r *ClearingBatchRepository) getOne(ctx context.Context, query string, arg interface{}) (*models.ClearingBatch, error) {
+	batch := &models.ClearingBatch{}
+	if err := scanClearingBatch(r.db.QueryRow(ctx, query, arg), batch); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClearingBatchNotFound
+		}
+		return nil, fmt.Errorf("failed to get clearing batch: %w", err)
+	}
+	return batch, nil
+}
+
+// List retrieves clearing batches, newest first, optionally filtered by status
+func (r *ClearingBatchRepository) List(ctx context.Context, status string, limit, offset int) (*models.ClearingBatchListResponse, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM clearing_batches WHERE $1 = '' OR status = $1`
+	if err := r.db.QueryRow(ctx, countQuery, status).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count clearing batches: %w", err)
+	}
+
+	query := `
+		SELECT ` + clearingBatchColumns + `
+		FROM clearing_batches
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clearing batches: %w", err)
+	}
+	defer rows.Close()
+
+	batches := []models.ClearingBatch{}
+	for rows.Next() {
+		var b models.ClearingBatch
+		if err := scanClearingBatch(rows, &b); err != nil {
+			return nil, fmt.Errorf("failed to scan clearing batch: %w", err)
+		}
+		batches = append(batches, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clearing batches: %w", err)
+	}
+
+	return &models.ClearingBatchListResponse{Batches: batches, Total: total}, nil
+}
+
+// MarkSent records that a pending batch's message was written out under the given file name
+func (r *ClearingBatchRepository) MarkSent(ctx context.Context, id int64, fileName string) (*models.ClearingBatch, error) {
+	query := `
+		UPDATE clearing_batches
+		SET status = $1, file_name = $2, sent_at = NOW()
+		WHERE id = $3 AND status = $4
+		RETURNING ` + clearingBatchColumns
+
+	batch := &models.ClearingBatch{}
+	err := scanClearingBatch(r.db.QueryRow(ctx, query, models.ClearingBatchStatusSent, fileName, id, models.ClearingBatchStatusPending), batch)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClearingBatchNotFound
+		}
+		return nil, fmt.Errorf("failed to mark clearing batch sent: %w", err)
+	}
+	return batch, nil
+}
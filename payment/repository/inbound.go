@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInboundFileExists     = errors.New("inbound file already imported")
+	ErrInboundCreditNotFound = errors.New("inbound credit not found")
+	ErrInboundCreditSettled  = errors.New("inbound credit is not in a state that allows this")
+)
+
+// inboundCreditColumns is the column list selected for every inbound credit query
+const inboundCreditColumns = `id, reference_id, file_id, dedupe_key, end_to_end_id, amount, currency, debtor_name,
+		       debtor_account, creditor_name, creditor_account, remittance_info, status, account_id, user_id,
+		       suspense_reason, resolved_by, resolution_note, created_at, updated_at, credited_at`
+
+func scanInboundCredit(row rowScanner, c *models.InboundCredit) error {
+	return row.Scan(
+		&c.ID, &c.ReferenceID, &c.FileID, &c.DedupeKey, &c.EndToEndID, &c.Amount, &c.Currency, &c.DebtorName,
+		&c.DebtorAccount, &c.CreditorName, &c.CreditorAccount, &c.RemittanceInfo, &c.Status, &c.AccountID, &c.UserID,
+		&c.SuspenseReason, &c.ResolvedBy, &c.ResolutionNote, &c.CreatedAt, &c.UpdatedAt, &c.CreditedAt,
+	)
+}
+
+func collectInboundCredits(rows pgx.Rows) ([]models.InboundCredit, error) {
+	defer rows.Close()
+
+	credits := []models.InboundCredit{}
+	for rows.Next() {
+		var c models.InboundCredit
+		if err := scanInboundCredit(rows, &c); err != nil {
+			return nil, fmt.Errorf("failed to scan inbound credit: %w", err)
+		}
+		credits = append(credits, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inbound credits: %w", err)
+	}
+
+	return credits, nil
+}
+
+// InboundRepository handles incoming credit transfer data
+type InboundRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewInboundRepository creates a new inbound repository
+func NewInboundRepository(db *pgxpool.Pool) *InboundRepository {
+	return &InboundRepository{db: db}
+}
+
+// RecordFile stores an imported file and its credits as pending in one transaction. A file whose
+// hash was seen before returns ErrInboundFileExists; credits already imported from another file
+// are skipped and not counted.
+func (r *InboundRepository) RecordFile(ctx context.Context, file *models.InboundFile, credits []models.InboundCredit) (*models.InboundFile, int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	recorded := &models.InboundFile{}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO inbound_files (file_name, sha256, message_type, message_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, file_name, sha256, message_type, message_id, credit_count, created_at
+	`, file.FileName, file.SHA256, file.MessageType, file.MessageID).Scan(
+		&recorded.ID, &recorded.FileName, &recorded.SHA256, &recorded.MessageType, &recorded.MessageID,
+		&recorded.CreditCount, &recorded.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, 0, ErrInboundFileExists
+		}
+		return nil, 0, fmt.Errorf("failed to record inbound file: %w", err)
+	}
+
+	imported := 0
+	for _, c := range credits {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO inbound_credits (file_id, dedupe_key, end_to_end_id, amount, currency, debtor_name,
+			                             debtor_account, creditor_name, creditor_account, remittance_info, status)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (dedupe_key) DO NOTHING
+		`, recorded.ID, c.DedupeKey, c.EndToEndID, c.Amount, c.Currency, c.DebtorName,
+			c.DebtorAccount, c.CreditorName, c.CreditorAccount, c.RemittanceInfo, models.InboundCreditStatusPending)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to record inbound credit: %w", err)
+		}
+		imported += int(tag.RowsAffected())
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE inbound_files SET credit_count = $1 WHERE id = $2`, imported, recorded.ID); err != nil {
+		return nil, 0, fmt.Errorf("failed to update inbound file: %w", err)
+	}
+	recorded.CreditCount = imported
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit inbound file: %w", err)
+	}
+
+	return recorded, imported, nil
+}
+
+// GetByID retrieves an inbound credit by ID
+func (r *InboundRepository) GetByID(ctx context.Context, id int64) (*models.InboundCredit, error) {
+	query := `SELECT ` + inboundCreditColumns + ` FROM inbound_credits WHERE id = $1`
+
+	credit := &models.InboundCredit{}
+	if err := scanInboundCredit(r.db.QueryRow(ctx, query, id), credit); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInboundCreditNotFound
+		}
+		return nil, fmt.Errorf("failed to get inbound credit: %w", err)
+	}
+
+	return credit, nil
+}
+
+// ListByStatus retrieves inbound credits in a status, oldest first
+func (r *InboundRepository) ListByStatus(ctx context.Context, status string, limit, offset int) (*models.InboundCreditListResponse, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM inbound_credits WHERE status = $1`
+	if err := r.db.QueryRow(ctx, countQuery, status).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count inbound credits: %w", err)
+	}
+
+	query := `
+		SELECT ` + inboundCreditColumns + `
+		FROM inbound_credits
+		WHERE status = $1
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inbound credits: %w", err)
+	}
+
+	credits, err := collectInboundCredits(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InboundCreditListResponse{Credits: credits, Total: total}, nil
+}
+
+// MarkCrediting claims a pending or suspense credit for the account about to be credited, so that it
+// cannot be rejected while the account service applies it. A credit already being applied to the
+// same account can be claimed again to retry it.
+func (r *InboundRepository) MarkCrediting(ctx context.Context, id, accountID, userID int64) (*models.InboundCredit, error) {
+	query := `
+		UPDATE inbound_credits
+		SET status = $1, account_id = $2, user_id = $3
+		WHERE id = $4 AND (status = ANY($5) OR (status = $1 AND account_id = $2))
+		RETURNING ` + inboundCreditColumns
+
+	return r.update(ctx, query, id, models.InboundCreditStatusCrediting, accountID, userID, id,
+		[]string{models.InboundCreditStatusPending, models.InboundCreditStatusSuspense})
+}
+
+// ReleaseCrediting returns a credit the account service refused to the status it was claimed from
+func (r *InboundRepository) ReleaseCrediting(ctx context.Context, id int64, status string) (*models.InboundCredit, error) {
+	query := `
+		UPDATE inbound_credits
+		SET status = $1, account_id = NULL, user_id = NULL
+		WHERE id = $2 AND status = $3
+		RETURNING ` + inboundCreditColumns
+
+	return r.update(ctx, query, id, status, id, models.InboundCreditStatusCrediting)
+}
+
+// MarkCredited records that a credit being applied was applied to its account. resolvedBy and note
+// are set when an admin resolved it from the suspense queue.
+func (r *InboundRepository) MarkCredited(ctx context.Context, id int64, resolvedBy *int64, note *string) (*models.InboundCredit, error) {
+	query := `
+		UPDATE inbound_credits
+		SET status = $1, resolved_by = $2, resolution_note = $3, credited_at = NOW()
+		WHERE id = $4 AND status = $5
+		RETURNING ` + inboundCreditColumns
+
+	return r.update(ctx, query, id, models.InboundCreditStatusCredited, resolvedBy, note, id, models.InboundCreditStatusCrediting)
+}
+
+// MarkSuspense moves a pending credit that could not be applied to the suspense queue
+func (r *InboundRepository) MarkSuspense(ctx context.Context, id int64, reason string) (*models.InboundCredit, error) {
+	query := `
+		UPDATE inbound_credits
+		SET status = $1, suspense_reason = $2
+		WHERE id = $3 AND status = $4
+		RETURNING ` + inboundCreditColumns
+
+	return r.update(ctx, query, id, models.InboundCreditStatusSuspense, reason, id, models.InboundCreditStatusPending)
+}
+
+// MarkRejected takes a suspense item off the queue without crediting it
+func (r *InboundRepository) MarkRejected(ctx context.Context, id, resolvedBy int64, reason string) (*models.InboundCredit, error) {
+	query := `
+		UPDATE inbound_credits
+		SET status = $1, resolved_by = $2, resolution_note = $3
+		WHERE id = $4 AND status = $5
+		RETURNING ` + inboundCreditColumns
+
+	return r.update(ctx, query, id, models.InboundCreditStatusRejected, resolvedBy, reason, id, models.InboundCreditStatusSuspense)
+}
+
+// update runs a guarded status update, telling a missing credit apart from one in the wrong state
+func (r *InboundRepository) update(ctx context.Context, query string, id int64, args ...interface{}) (*models.InboundCredit, error) {
+	credit := &models.InboundCredit{}
+	err := scanInboundCredit(r.db.QueryRow(ctx, query, args...), credit)
+	if err == nil {
+		return credit, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to update inbound credit: %w", err)
+	}
+
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrInboundCreditSettled
+}
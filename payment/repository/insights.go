@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// spendingCTE selects the user's payments that completed in [$3, $4) in one of the statuses $2,
+// net of completed refunds. A payment counts from when it was processed.
+const spendingCTE = `
+	WITH spent AS (
+		SELECT p.payment_type, p.category, p.currency, p.merchant_id, p.recipient_name, p.recipient_account,
+		       COALESCE(p.processed_at, p.created_at) AS spent_at,
+		       p.amount - COALESCE((
+		           SELECT SUM(r.amount) FROM payment_refunds r
+		           WHERE r.payment_id = p.id AND r.status = 'completed'
+		       ), 0) AS net
+		FROM payments p
+		WHERE p.user_id = $1
+		  AND p.status = ANY($2)
+		  AND COALESCE(p.processed_at, p.created_at) >= $3
+		  AND COALESCE(p.processed_at, p.created_at) < $4
+	)
+`
+
+type InsightsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewInsightsRepository(db *pgxpool.Pool) *InsightsRepository {
+	return &InsightsRepository{db: db}
+}
+
+// SpendingByMonth totals the user's spending between from and to per month, category and currency
+func (r *InsightsRepository) SpendingByMonth(ctx context.Context, userID int64, from, to time.Time) ([]models.SpendingRow, error) {
+	query := spendingCTE + `
+		SELECT to_char(date_trunc('month', spent_at AT TIME ZONE 'UTC'), 'YYYY-MM'),
+		       category, currency, COUNT(*), SUM(net)
+		FROM spent
+		WHERE net > 0
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, models.SpendingStatuses, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize spending: %w", err)
+	}
+	defer rows.Close()
+
+	spending := []models.SpendingRow{}
+	for rows.Next() {
+		var row models.SpendingRow
+		if err := rows.Scan(&row.Month, &row.Category, &row.Currency, &row.Count, &row.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan spending: %w", err)
+		}
+		spending = append(spending, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating spending: %w", err)
+	}
+
+	return spending, nil
+}
+
+// SpendingByMerchant totals the user's merchant payments between from and to per merchant, largest
+// first. A merchant's category is the one most of its payments carry.
+func (r *InsightsRepository) SpendingByMerchant(ctx context.Context, userID int64, from, to time.Time, limit int) ([]models.MerchantSpending, error) {
+	query := spendingCTE + `
+		SELECT merchant_id, COALESCE(recipient_name, ''), mode() WITHIN GROUP (ORDER BY category),
+		       currency, COUNT(*), SUM(net)
+		FROM spent
+		WHERE net > 0 AND payment_type = 'merchant'
+		GROUP BY merchant_id, recipient_name, currency
+		ORDER BY SUM(net) DESC, recipient_name
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, models.SpendingStatuses, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize merchant spending: %w", err)
+	}
+	defer rows.Close()
+
+	merchants := []models.MerchantSpending{}
+	for rows.Next() {
+		var m models.MerchantSpending
+		if err := rows.Scan(&m.MerchantID, &m.Name, &m.Category, &m.Currency, &m.Count, &m.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan merchant spending: %w", err)
+		}
+		merchants = append(merchants, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating merchant spending: %w", err)
+	}
+
+	return merchants, nil
+}
+
+// TopRecipients totals the user's payments between from and to per payment type and recipient,
+// largest first. Recipient accounts are returned unmasked.
+func (r *InsightsRepository) TopRecipients(ctx context.Context, userID int64, from, to time.Time, limit int) ([]models.RecipientSpending, error) {
+	query := spendingCTE + `
+		SELECT payment_type, COALESCE(recipient_name, ''), COALESCE(recipient_account, ''),
+		       currency, COUNT(*), SUM(net)
+		FROM spent
+		WHERE net > 0
+		GROUP BY payment_type, recipient_name, recipient_account, currency
+		ORDER BY SUM(net) DESC, recipient_name
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, models.SpendingStatuses, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize payment recipients: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := []models.RecipientSpending{}
+	for rows.Next() {
+		recipient := models.RecipientSpending{Source: models.SpendingSourcePayment}
+		if err := rows.Scan(&recipient.Type, &recipient.Name, &recipient.Account, &recipient.Currency, &recipient.Count, &recipient.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan payment recipient: %w", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment recipients: %w", err)
+	}
+
+	return recipients, nil
+}
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"payment/models"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // PaymentRepo defines the interface for payment data access.
@@ -16,8 +18,118 @@ type PaymentRepo interface {
 	ListByUserID(ctx context.Context, userID int64, limit, offset int) (*models.PaymentListResponse, error)
 	ListByAccountID(ctx context.Context, accountID int64, limit, offset int) (*models.PaymentListResponse, error)
 	ListAll(ctx context.Context, limit, offset int) (*models.PaymentListResponse, error)
-	UpdateStatus(ctx context.Context, id int64, status string, failureReason *string) (*models.Payment, error)
+	ListStale(ctx context.Context, status string, before time.Time, limit int) ([]models.Payment, error)
+	SumExternalSentTo(ctx context.Context, userID int64, recipientAccount string, since time.Time) (decimal.Decimal, error)
+	RecordSagaRetry(ctx context.Context, id int64) (*models.Payment, error)
+	SetCategory(ctx context.Context, id int64, category string) (*models.Payment, error)
+	UpdateStatus(ctx context.Context, id int64, status string, failureReason *string, cause string) (*models.Payment, error)
+	MarkSubmitted(ctx context.Context, id int64, provider string, providerReference *string, cause string) (*models.Payment, error)
 	MarkAsProcessing(ctx context.Context, id int64) (*models.Payment, error)
-	MarkAsCompleted(ctx context.Context, id int64) (*models.Payment, error)
-	MarkAsFailed(ctx context.Context, id int64, reason string) (*models.Payment, error)
+	MarkAsCompleted(ctx context.Context, id int64, cause string) (*models.Payment, error)
+	MarkAsFailed(ctx context.Context, id int64, reason, cause string) (*models.Payment, error)
+	ListStatusHistory(ctx context.Context, id int64) ([]models.StatusTransition, error)
+	CreateClearingBatch(ctx context.Context, messageID string, windowEnd time.Time, limit int) (*models.ClearingBatch, []models.Payment, error)
+	ListClearingBatchPayments(ctx context.Context, batchID int64) ([]models.Payment, error)
+}
+
+// ClearingBatchRepo defines the interface for clearing batch data access.
+type ClearingBatchRepo interface {
+	GetByID(ctx context.Context, id int64) (*models.ClearingBatch, error)
+	GetByMessageID(ctx context.Context, messageID string) (*models.ClearingBatch, error)
+	List(ctx context.Context, status string, limit, offset int) (*models.ClearingBatchListResponse, error)
+	MarkSent(ctx context.Context, id int64, fileName string) (*models.ClearingBatch, error)
+}
+
+// InboundCreditRepo defines the interface for incoming credit transfer data access.
+type InboundCreditRepo interface {
+	RecordFile(ctx context.Context, file *models.InboundFile, credits []models.InboundCredit) (*models.InboundFile, int, error)
+	GetByID(ctx context.Context, id int64) (*models.InboundCredit, error)
+	ListByStatus(ctx context.Context, status string, limit, offset int) (*models.InboundCreditListResponse, error)
+	MarkCrediting(ctx context.Context, id, accountID, userID int64) (*models.InboundCredit, error)
+	ReleaseCrediting(ctx context.Context, id int64, status string) (*models.InboundCredit, error)
+	MarkCredited(ctx context.Context, id int64, resolvedBy *int64, note *string) (*models.InboundCredit, error)
+	MarkSuspense(ctx context.Context, id int64, reason string) (*models.InboundCredit, error)
+	MarkRejected(ctx context.Context, id, resolvedBy int64, reason string) (*models.InboundCredit, error)
+}
+
+// BillerRepo defines the interface for biller catalog data access.
+type BillerRepo interface {
+	Create(ctx context.Context, b *models.Biller) (*models.Biller, error)
+	GetByID(ctx context.Context, id int64) (*models.Biller, error)
+	List(ctx context.Context, category string, activeOnly bool) (*models.BillerListResponse, error)
+	Update(ctx context.Context, b *models.Biller) (*models.Biller, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// MobileOperatorRepo defines the interface for mobile operator data access.
+type MobileOperatorRepo interface {
+	Create(ctx context.Context, op *models.MobileOperator) (*models.MobileOperator, error)
+	GetByID(ctx context.Context, id int64) (*models.MobileOperator, error)
+	List(ctx context.Context, activeOnly bool) ([]models.MobileOperator, error)
+	Update(ctx context.Context, op *models.MobileOperator) (*models.MobileOperator, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// RefundRepo defines the interface for payment refund data access.
+type RefundRepo interface {
+	Create(ctx context.Context, paymentID int64, amount *decimal.Decimal, reason *string, requestedBy int64) (*models.Refund, error)
+	GetByID(ctx context.Context, id int64) (*models.Refund, error)
+	ListByPayment(ctx context.Context, payment *models.Payment) (*models.RefundListResponse, error)
+	ListStale(ctx context.Context, before time.Time, limit int) ([]models.Refund, error)
+	MarkCompleted(ctx context.Context, id int64) (*models.Refund, error)
+	MarkFailed(ctx context.Context, id int64, reason string) (*models.Refund, error)
+}
+
+// ScheduleRepo defines the interface for recurring payment schedule data access.
+type ScheduleRepo interface {
+	Create(ctx context.Context, s *models.PaymentSchedule) (*models.PaymentSchedule, error)
+	GetByID(ctx context.Context, id int64) (*models.PaymentSchedule, error)
+	List(ctx context.Context, userID int64, limit, offset int) (*models.PaymentScheduleListResponse, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.PaymentSchedule, error)
+	Pause(ctx context.Context, id int64) (*models.PaymentSchedule, error)
+	Resume(ctx context.Context, s *models.PaymentSchedule) (*models.PaymentSchedule, error)
+	Cancel(ctx context.Context, id int64) (*models.PaymentSchedule, error)
+	StartExecution(ctx context.Context, id int64, now time.Time) (*models.ScheduleExecution, error)
+	AttachPayment(ctx context.Context, executionID, paymentID int64) error
+	ListPendingExecutions(ctx context.Context, limit int) ([]models.ScheduleExecution, error)
+	ListExecutions(ctx context.Context, scheduleID int64, limit, offset int) ([]models.ScheduleExecution, error)
+	FinishExecution(ctx context.Context, executionID int64, status string, failureReason *string, advance func(s *models.PaymentSchedule)) (*models.ScheduleExecution, error)
+}
+
+// TemplateRepo defines the interface for saved payment template data access.
+type TemplateRepo interface {
+	Create(ctx context.Context, t *models.PaymentTemplate) (*models.PaymentTemplate, error)
+	GetByID(ctx context.Context, id int64) (*models.PaymentTemplate, error)
+	ListByUser(ctx context.Context, userID int64) (*models.PaymentTemplateListResponse, error)
+	Update(ctx context.Context, t *models.PaymentTemplate) (*models.PaymentTemplate, error)
+	Delete(ctx context.Context, id int64) error
+	MarkUsed(ctx context.Context, id int64) error
+}
+
+// MerchantRepo defines the interface for acquired merchant data access.
+type MerchantRepo interface {
+	Create(ctx context.Context, m *models.Merchant) (*models.Merchant, error)
+	GetByID(ctx context.Context, id int64) (*models.Merchant, error)
+	GetByCode(ctx context.Context, code string) (*models.Merchant, error)
+	List(ctx context.Context, userID int64) (*models.MerchantListResponse, error)
+	Update(ctx context.Context, m *models.Merchant) (*models.Merchant, error)
+	Delete(ctx context.Context, id int64) error
+	ListTransactions(ctx context.Context, merchantID int64, limit, offset int) (*models.MerchantTransactionListResponse, error)
+	DailySettlements(ctx context.Context, merchantID int64, from, to time.Time) ([]models.MerchantSettlementDay, error)
+}
+
+// QRCodeRepo defines the interface for dynamic merchant QR code data access.
+type QRCodeRepo interface {
+	Create(ctx context.Context, q *models.QRCode) (*models.QRCode, error)
+	GetByReference(ctx context.Context, reference string) (*models.QRCode, error)
+	Claim(ctx context.Context, id int64) (*models.QRCode, error)
+	Release(ctx context.Context, id int64) error
+	AttachPayment(ctx context.Context, id, paymentID int64) error
+}
+
+// InsightsRepo defines the interface for spending summaries over payments.
+type InsightsRepo interface {
+	SpendingByMonth(ctx context.Context, userID int64, from, to time.Time) ([]models.SpendingRow, error)
+	SpendingByMerchant(ctx context.Context, userID int64, from, to time.Time, limit int) ([]models.MerchantSpending, error)
+	TopRecipients(ctx context.Context, userID int64, from, to time.Time, limit int) ([]models.RecipientSpending, error)
 }
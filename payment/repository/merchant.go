@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrMerchantNotFound   = errors.New("merchant not found")
+	ErrMerchantCodeExists = errors.New("merchant code already exists")
+	ErrMerchantInUse      = errors.New("merchant has payments")
+)
+
+// merchantColumns is the column list selected for every merchant query
+const merchantColumns = `id, code, name, mcc, user_id, settlement_account_id, fee_rate, currency, city, country_code,
+		       active, created_at, updated_at`
+
+func scanMerchant(row rowScanner, m *models.Merchant) error {
+	return row.Scan(
+		&m.ID, &m.Code, &m.Name, &m.MCC, &m.UserID, &m.SettlementAccountID, &m.FeeRate, &m.Currency, &m.City, &m.CountryCode,
+		&m.Active, &m.CreatedAt, &m.UpdatedAt,
+	)
+}
+
+type MerchantRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMerchantRepository(db *pgxpool.Pool) *MerchantRepository {
+	return &MerchantRepository{db: db}
+}
+
+// Create registers a merchant
+func (r *MerchantRepository) Create(ctx context.Context, m *models.Merchant) (*models.Merchant, error) {
+	query := `
+		INSERT INTO merchants (code, name, mcc, user_id, settlement_account_id, fee_rate, currency, city, country_code, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + merchantColumns
+
+	merchant := &models.Merchant{}
+	err := scanMerchant(r.db.QueryRow(
+		ctx, query,
+		m.Code, m.Name, m.MCC, m.UserID, m.SettlementAccountID, m.FeeRate, m.Currency, m.City, m.CountryCode, m.Active,
+	), merchant)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrMerchantCodeExists
+		}
+		return nil, fmt.Errorf("failed to create merchant: %w", err)
+	}
+
+	return merchant, nil
+}
+
+// GetByID retrieves a merchant by ID
+func (r *MerchantRepository) GetByID(ctx context.Context, id int64) (*models.Merchant, error) {
+	query := `SELECT ` + merchantColumns + ` FROM merchants WHERE id = $1`
+
+	merchant := &models.Merchant{}
+	if err := scanMerchant(r.db.QueryRow(ctx, query, id), merchant); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, fmt.Errorf("failed to get merchant: %w", err)
+	}
+
+	return merchant, nil
+}
+
+// GetByCode retrieves a merchant by the merchant ID the bank assigned it
+func (r *MerchantRepository) GetByCode(ctx context.Context, code string) (*models.Merchant, error) {
+	query := `SELECT ` + merchantColumns + ` FROM merchants WHERE code = $1`
+
+	merchant := &models.Merchant{}
+	if err := scanMerchant(r.db.QueryRow(ctx, query, code), merchant); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, fmt.Errorf("failed to get merchant: %w", err)
+	}
+
+	return merchant, nil
+}
+
+// List retrieves merchants by name; a userID of 0 lists every merchant, otherwise only the user's
+func (r *MerchantRepository) List(ctx context.Context, userID int64) (*models.MerchantListResponse, error) {
+	query := `
+		SELECT ` + merchantColumns + `
+		FROM merchants
+		WHERE $1 = 0 OR user_id = $1
+		ORDER BY name, id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merchants: %w", err)
+	}
+	defer rows.Close()
+
+	merchants := []models.Merchant{}
+	for rows.Next() {
+		var m models.Merchant
+		if err := scanMerchant(rows, &m); err != nil {
+			return nil, fmt.Errorf("failed to scan merchant: %w", err)
+		}
+		merchants = append(merchants, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating merchants: %w", err)
+	}
+
+	return &models.MerchantListResponse{Merchants: merchants, Total: int64(len(merchants))}, nil
+}
+
+// Update writes a merchant's editable fields. Payments already created keep the settlement
+// account and service charge they were created with.
+func (r *MerchantRepository) Update(ctx context.Context, m *models.Merchant) (*models.Merchant, error) {
+	query := `
+		UPDATE merchants
+		SET name = $1, mcc = $2, user_id = $3, settlement_account_id = $4, fee_rate = $5, city = $6,
+		    country_code = $7, active = $8
+		WHERE id = $9
+		RETURNING ` + merchantColumns
+
+	merchant := &models.Merchant{}
+	err := scanMerchant(r.db.QueryRow(
+		ctx, query,
+		m.Name, m.MCC, m.UserID, m.SettlementAccountID, m.FeeRate, m.City, m.CountryCode, m.Active, m.ID,
+	), merchant)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, fmt.Errorf("failed to update merchant: %w", err)
+	}
+
+	return merchant, nil
+}
+
+// Delete removes a merchant that has never been paid; merchants with payments can only be deactivated
+func (r *MerchantRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM merchants WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrMerchantInUse
+		}
+		return fmt.Errorf("failed to delete merchant: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMerchantNotFound
+	}
+	return nil
+}
+
+// ListTransactions retrieves a merchant's payments, newest first, with the amount refunded on each
+func (r *MerchantRepository) ListTransactions(ctx context.Context, merchantID int64, limit, offset int) (*models.MerchantTransactionListResponse, error) {
+	query := `
+		SELECT p.id, p.reference_id, p.status, p.amount, COALESCE(p.fee_amount, 0), p.currency, p.description,
+		       p.created_at, p.processed_at,
+		       COALESCE((SELECT SUM(r.amount) FROM payment_refunds r
+		                 WHERE r.payment_id = p.id AND r.status = 'completed'), 0)
+		FROM payments p
+		WHERE p.merchant_id = $1
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, merchantID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merchant transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := []models.MerchantTransaction{}
+	for rows.Next() {
+		var t models.MerchantTransaction
+		if err := rows.Scan(
+			&t.PaymentID, &t.ReferenceID, &t.Status, &t.Amount, &t.Fee, &t.Currency, &t.Description,
+			&t.CreatedAt, &t.ProcessedAt, &t.Refunded,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan merchant transaction: %w", err)
+		}
+		t.Net = t.Amount.Sub(t.Fee)
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating merchant transactions: %w", err)
+	}
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM payments WHERE merchant_id = $1`, merchantID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count merchant transactions: %w", err)
+	}
+
+	return &models.MerchantTransactionListResponse{Transactions: transactions, Total: total}, nil
+}
+
+// DailySettlements totals what a merchant was credited on each day (UTC) in [from, to): completed
+// payments by the day they completed and, by the day they were paid back, the merchant's part of
+// completed refunds; their share of the service charge is refunded by the bank
+func (r *MerchantRepository) DailySettlements(ctx context.Context, merchantID int64, from, to time.Time) ([]models.MerchantSettlementDay, error) {
+	query := `
+		WITH paid AS (
+			SELECT (COALESCE(processed_at, created_at) AT TIME ZONE 'UTC')::date AS day,
+			       COUNT(*) AS payments, SUM(amount) AS gross, SUM(COALESCE(fee_amount, 0)) AS fees
+			FROM payments
+			WHERE merchant_id = $1 AND status = 'completed'
+			  AND COALESCE(processed_at, created_at) >= $2 AND COALESCE(processed_at, created_at) < $3
+			GROUP BY 1
+		), refunded AS (
+			SELECT (r.completed_at AT TIME ZONE 'UTC')::date AS day, SUM(r.amount - r.fee_amount) AS refunds
+			FROM payment_refunds r
+			JOIN payments p ON p.id = r.payment_id
+			WHERE p.merchant_id = $1 AND r.status = 'completed'
+			  AND r.completed_at >= $2 AND r.completed_at < $3
+			GROUP BY 1
+		)
+		SELECT COALESCE(paid.day, refunded.day), COALESCE(paid.payments, 0), COALESCE(paid.gross, 0),
+		       COALESCE(paid.fees, 0), COALESCE(refunded.refunds, 0)
+		FROM paid
+		FULL OUTER JOIN refunded ON refunded.day = paid.day
+		ORDER BY 1
+	`
+
+	rows, err := r.db.Query(ctx, query, merchantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total merchant settlements: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.MerchantSettlementDay{}
+	for rows.Next() {
+		var d models.MerchantSettlementDay
+		var day time.Time
+		if err := rows.Scan(&day, &d.Payments, &d.Gross, &d.Fees, &d.Refunds); err != nil {
+			return nil, fmt.Errorf("failed to scan merchant settlement: %w", err)
+		}
+		d.Date = day.Format(models.DateLayout)
+		d.Net = d.Gross.Sub(d.Fees)
+		d.Settled = d.Net.Sub(d.Refunds)
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating merchant settlements: %w", err)
+	}
+
+	return days, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrMobileOperatorNotFound = errors.New("mobile operator not found")
+	ErrMobileOperatorExists   = errors.New("mobile operator already exists")
+)
+
+// mobileOperatorColumns is the column list selected for every mobile operator query. Denominations
+// are read as text so they scan into decimals without a numeric array codec.
+const mobileOperatorColumns = `id, name, prefixes, subscriber_digits, denominations::text[], active, created_at, updated_at`
+
+func scanMobileOperator(row rowScanner, op *models.MobileOperator) error {
+	var denominations []string
+	if err := row.Scan(&op.ID, &op.Name, &op.Prefixes, &op.SubscriberDigits, &denominations,
+		&op.Active, &op.CreatedAt, &op.UpdatedAt); err != nil {
+		return err
+	}
+
+	op.Denominations = make([]decimal.Decimal, 0, len(denominations))
+	for _, d := range denominations {
+		amount, err := decimal.NewFromString(d)
+		if err != nil {
+			return fmt.Errorf("invalid denomination %q: %w", d, err)
+		}
+		op.Denominations = append(op.Denominations, amount)
+	}
+	return nil
+}
+
+func denominationStrings(denominations []decimal.Decimal) []string {
+	out := make([]string, len(denominations))
+	for i, d := range denominations {
+		out[i] = d.String()
+	}
+	return out
+}
+
+type MobileOperatorRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMobileOperatorRepository(db *pgxpool.Pool) *MobileOperatorRepository {
+	return &MobileOperatorRepository{db: db}
+}
+
+// Create adds a mobile operator
+func (r *MobileOperatorRepository) Create(ctx context.Context, op *models.MobileOperator) (*models.MobileOperator, error) {
+	query := `
+		INSERT INTO mobile_operators (name, prefixes, subscriber_digits, denominations, active)
+		VALUES ($1, $2, $3, $4::text[]::numeric[], $5)
+		RETURNING ` + mobileOperatorColumns
+
+	created := &models.MobileOperator{}
+	err := scanMobileOperator(r.db.QueryRow(
+		ctx, query, op.Name, op.Prefixes, op.SubscriberDigits, denominationStrings(op.Denominations), op.Active,
+	), created)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrMobileOperatorExists
+		}
+		return nil, fmt.Errorf("failed to create mobile operator: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a mobile operator by ID
+func (r *MobileOperatorRepository) GetByID(ctx context.Context, id int64) (*models.MobileOperator, error) {
+	query := `SELECT ` + mobileOperatorColumns + ` FROM mobile_operators WHERE id = $1`
+
+	op := &models.MobileOperator{}
+	if err := scanMobileOperator(r.db.QueryRow(ctx, query, id), op); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMobileOperatorNotFound
+		}
+		return nil, fmt.Errorf("failed to get mobile operator: %w", err)
+	}
+
+	return op, nil
+}
+
+// List retrieves mobile operators by name, optionally only the active ones
+func (r *MobileOperatorRepository) List(ctx context.Context, activeOnly bool) ([]models.MobileOperator, error) {
+	query := `
+		SELECT ` + mobileOperatorColumns + `
+		FROM mobile_operators
+		WHERE NOT $1 OR active
+		ORDER BY name, id
+	`
+
+	rows, err := r.db.Query(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mobile operators: %w", err)
+	}
+	defer rows.Close()
+
+	operators := []models.MobileOperator{}
+	for rows.Next() {
+		var op models.MobileOperator
+		if err := scanMobileOperator(rows, &op); err != nil {
+			return nil, fmt.Errorf("failed to scan mobile operator: %w", err)
+		}
+		operators = append(operators, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating mobile operators: %w", err)
+	}
+
+	return operators, nil
+}
+
+// Update writes a mobile operator's fields
+func (r *MobileOperatorRepository) Update(ctx context.Context, op *models.MobileOperator) (*models.MobileOperator, error) {
+	query := `
+		UPDATE mobile_operators
+		SET name = $1, prefixes = $2, subscriber_digits = $3, denominations = $4::text[]::numeric[], active = $5
+		WHERE id = $6
+		RETURNING ` + mobileOperatorColumns
+
+	updated := &models.MobileOperator{}
+	err := scanMobileOperator(r.db.QueryRow(
+		ctx, query, op.Name, op.Prefixes, op.SubscriberDigits, denominationStrings(op.Denominations), op.Active, op.ID,
+	), updated)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMobileOperatorNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrMobileOperatorExists
+		}
+		return nil, fmt.Errorf("failed to update mobile operator: %w", err)
+	}
+
+	return updated, nil
+}
+
+// Delete removes a mobile operator. Past payments keep the operator name they were made with.
+func (r *MobileOperatorRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM mobile_operators WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete mobile operator: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMobileOperatorNotFound
+	}
+	return nil
+}
@@ -18,8 +18,51 @@ var (
 	ErrPaymentNotFound = errors.New("payment not found")
 	ErrInvalidAmount   = errors.New("invalid amount")
 	ErrInvalidInput    = errors.New("invalid input")
+
+	ErrInvalidTransition = errors.New("illegal status transition")
 )
 
+// paymentColumns is the column list selected for every payment query
+const paymentColumns = `id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
+		       recipient_bank, amount, currency, description, beneficiary_id, biller_id, clearing_batch_id, status, failure_reason, saga_attempts,
+		       created_at, updated_at, processed_at, provider, provider_reference, submitted_at, settlement_account_id,
+		       merchant_id, fee_amount, category`
+
+// rowScanner is satisfied by both pgx.Row and pgx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPayment(row rowScanner, payment *models.Payment) error {
+	return row.Scan(
+		&payment.ID, &payment.ReferenceID, &payment.AccountID, &payment.UserID,
+		&payment.PaymentType, &payment.RecipientName, &payment.RecipientAccount,
+		&payment.RecipientBank, &payment.Amount, &payment.Currency, &payment.Description,
+		&payment.BeneficiaryID, &payment.BillerID, &payment.ClearingBatchID, &payment.Status, &payment.FailureReason, &payment.SagaAttempts, &payment.CreatedAt, &payment.UpdatedAt,
+		&payment.ProcessedAt, &payment.Provider, &payment.ProviderReference, &payment.SubmittedAt, &payment.SettlementAccountID,
+		&payment.MerchantID, &payment.FeeAmount, &payment.Category,
+	)
+}
+
+func collectPayments(rows pgx.Rows) ([]models.Payment, error) {
+	defer rows.Close()
+
+	payments := []models.Payment{}
+	for rows.Next() {
+		var payment models.Payment
+		if err := scanPayment(rows, &payment); err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payments: %w", err)
+	}
+
+	return payments, nil
+}
+
 type PaymentRepository struct {
 	db *pgxpool.Pool
 }
@@ -39,27 +82,25 @@ func (r *PaymentRepository) Create(ctx context.Context, userID int64, req *model
 		currency = "USD"
 	}
 
+	category := req.Category
+	if category == "" {
+		category = models.CategoryForPaymentType(req.PaymentType)
+	}
+
 	query := `
 		INSERT INTO payments (account_id, user_id, payment_type, recipient_name, recipient_account,
-		                      recipient_bank, amount, currency, description, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'pending')
-		RETURNING id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
-		          recipient_bank, amount, currency, description, status, failure_reason,
-		          created_at, updated_at, processed_at
-	`
+		                      recipient_bank, amount, currency, description, beneficiary_id, biller_id,
+		                      settlement_account_id, merchant_id, fee_amount, category, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, 'pending')
+		RETURNING ` + paymentColumns
 
 	payment := &models.Payment{}
-	err := r.db.QueryRow(
+	err := scanPayment(r.db.QueryRow(
 		ctx, query,
 		req.AccountID, userID, req.PaymentType, req.RecipientName, req.RecipientAccount,
-		req.RecipientBank, req.Amount, currency, req.Description,
-	).Scan(
-		&payment.ID, &payment.ReferenceID, &payment.AccountID, &payment.UserID,
-		&payment.PaymentType, &payment.RecipientName, &payment.RecipientAccount,
-		&payment.RecipientBank, &payment.Amount, &payment.Currency, &payment.Description,
-		&payment.Status, &payment.FailureReason, &payment.CreatedAt, &payment.UpdatedAt,
-		&payment.ProcessedAt,
-	)
+		req.RecipientBank, req.Amount, currency, req.Description, req.BeneficiaryID, req.BillerID,
+		req.SettlementAccountID, req.MerchantID, req.FeeAmount, category,
+	), payment)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create payment: %w", err)
@@ -71,21 +112,13 @@ func (r *PaymentRepository) Create(ctx context.Context, userID int64, req *model
 // GetByID retrieves a payment by ID
 func (r *PaymentRepository) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
 	query := `
-		SELECT id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
-		       recipient_bank, amount, currency, description, status, failure_reason,
-		       created_at, updated_at, processed_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE id = $1
 	`
 
 	payment := &models.Payment{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&payment.ID, &payment.ReferenceID, &payment.AccountID, &payment.UserID,
-		&payment.PaymentType, &payment.RecipientName, &payment.RecipientAccount,
-		&payment.RecipientBank, &payment.Amount, &payment.Currency, &payment.Description,
-		&payment.Status, &payment.FailureReason, &payment.CreatedAt, &payment.UpdatedAt,
-		&payment.ProcessedAt,
-	)
+	err := scanPayment(r.db.QueryRow(ctx, query, id), payment)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -100,21 +133,13 @@ func (r *PaymentRepository) GetByID(ctx context.Context, id int64) (*models.Paym
 // GetByReferenceID retrieves a payment by reference ID
 func (r *PaymentRepository) GetByReferenceID(ctx context.Context, referenceID uuid.UUID) (*models.Payment, error) {
 	query := `
-		SELECT id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
-		       recipient_bank, amount, currency, description, status, failure_reason,
-		       created_at, updated_at, processed_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE reference_id = $1
 	`
 
 	payment := &models.Payment{}
-	err := r.db.QueryRow(ctx, query, referenceID).Scan(
-		&payment.ID, &payment.ReferenceID, &payment.AccountID, &payment.UserID,
-		&payment.PaymentType, &payment.RecipientName, &payment.RecipientAccount,
-		&payment.RecipientBank, &payment.Amount, &payment.Currency, &payment.Description,
-		&payment.Status, &payment.FailureReason, &payment.CreatedAt, &payment.UpdatedAt,
-		&payment.ProcessedAt,
-	)
+	err := scanPayment(r.db.QueryRow(ctx, query, referenceID), payment)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -135,9 +160,7 @@ func (r *PaymentRepository) ListByUserID(ctx context.Context, userID int64, limi
 	}
 
 	query := `
-		SELECT id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
-		       recipient_bank, amount, currency, description, status, failure_reason,
-		       created_at, updated_at, processed_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -148,26 +171,10 @@ func (r *PaymentRepository) ListByUserID(ctx context.Context, userID int64, limi
 	if err != nil {
 		return nil, fmt.Errorf("failed to list payments: %w", err)
 	}
-	defer rows.Close()
-
-	payments := []models.Payment{}
-	for rows.Next() {
-		var payment models.Payment
-		err := rows.Scan(
-			&payment.ID, &payment.ReferenceID, &payment.AccountID, &payment.UserID,
-			&payment.PaymentType, &payment.RecipientName, &payment.RecipientAccount,
-			&payment.RecipientBank, &payment.Amount, &payment.Currency, &payment.Description,
-			&payment.Status, &payment.FailureReason, &payment.CreatedAt, &payment.UpdatedAt,
-			&payment.ProcessedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan payment: %w", err)
-		}
-		payments = append(payments, payment)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating payments: %w", err)
+	payments, err := collectPayments(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.PaymentListResponse{
@@ -185,9 +192,7 @@ func (r *PaymentRepository) ListByAccountID(ctx context.Context, accountID int64
 	}
 
 	query := `
-		SELECT id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
-		       recipient_bank, amount, currency, description, status, failure_reason,
-		       created_at, updated_at, processed_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		WHERE account_id = $1
 		ORDER BY created_at DESC
@@ -198,26 +203,10 @@ func (r *PaymentRepository) ListByAccountID(ctx context.Context, accountID int64
 	if err != nil {
 		return nil, fmt.Errorf("failed to list payments: %w", err)
 	}
-	defer rows.Close()
-
-	payments := []models.Payment{}
-	for rows.Next() {
-		var payment models.Payment
-		err := rows.Scan(
-			&payment.ID, &payment.ReferenceID, &payment.AccountID, &payment.UserID,
-			&payment.PaymentType, &payment.RecipientName, &payment.RecipientAccount,
-			&payment.RecipientBank, &payment.Amount, &payment.Currency, &payment.Description,
-			&payment.Status, &payment.FailureReason, &payment.CreatedAt, &payment.UpdatedAt,
-			&payment.ProcessedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan payment: %w", err)
-		}
-		payments = append(payments, payment)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating payments: %w", err)
+	payments, err := collectPayments(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.PaymentListResponse{
@@ -235,9 +224,7 @@ func (r *PaymentRepository) ListAll(ctx context.Context, limit, offset int) (*mo
 	}
 
 	query := `
-		SELECT id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
-		       recipient_bank, amount, currency, description, status, failure_reason,
-		       created_at, updated_at, processed_at
+		SELECT ` + paymentColumns + `
 		FROM payments
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -247,26 +234,10 @@ func (r *PaymentRepository) ListAll(ctx context.Context, limit, offset int) (*mo
 	if err != nil {
 		return nil, fmt.Errorf("failed to list payments: %w", err)
 	}
-	defer rows.Close()
 
-	payments := []models.Payment{}
-	for rows.Next() {
-		var payment models.Payment
-		err := rows.Scan(
-			&payment.ID, &payment.ReferenceID, &payment.AccountID, &payment.UserID,
-			&payment.PaymentType, &payment.RecipientName, &payment.RecipientAccount,
-			&payment.RecipientBank, &payment.Amount, &payment.Currency, &payment.Description,
-			&payment.Status, &payment.FailureReason, &payment.CreatedAt, &payment.UpdatedAt,
-			&payment.ProcessedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan payment: %w", err)
-		}
-		payments = append(payments, payment)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating payments: %w", err)
+	payments, err := collectPayments(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.PaymentListResponse{
@@ -275,63 +246,222 @@ func (r *PaymentRepository) ListAll(ctx context.Context, limit, offset int) (*mo
 	}, nil
 }
 
-// UpdateStatus updates the payment status
-func (r *PaymentRepository) UpdateStatus(ctx context.Context, id int64, status string, failureReason *string) (*models.Payment, error) {
+// ListStale retrieves payments that have been in the given status since before the cutoff, oldest first
+func (r *PaymentRepository) ListStale(ctx context.Context, status string, before time.Time, limit int) ([]models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE status = $1 AND updated_at < $2
+		ORDER BY updated_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, status, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale payments: %w", err)
+	}
+
+	return collectPayments(rows)
+}
+
+// SumExternalSentTo totals the user's external payments to recipientAccount created since the given
+// time, matching the account without spaces or case. Payments that failed or whose money came back
+// don't count.
+func (r *PaymentRepository) SumExternalSentTo(ctx context.Context, userID int64, recipientAccount string, since time.Time) (decimal.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM payments
+		WHERE user_id = $1 AND payment_type = $2
+		  AND UPPER(REPLACE(recipient_account, ' ', '')) = UPPER(REPLACE($3, ' ', ''))
+		  AND created_at >= $4
+		  AND status <> ALL($5)
+	`
+
+	var sent decimal.Decimal
+	err := r.db.QueryRow(ctx, query, userID, models.PaymentTypeExternal, recipientAccount, since,
+		[]string{models.PaymentStatusFailed, models.PaymentStatusReturned, models.PaymentStatusRefunded}).Scan(&sent)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to sum payments to %s: %w", recipientAccount, err)
+	}
+
+	return sent, nil
+}
+
+// RecordSagaRetry counts a re-publish of a processing payment and restarts its timeout
+func (r *PaymentRepository) RecordSagaRetry(ctx context.Context, id int64) (*models.Payment, error) {
+	query := `
+		UPDATE payments
+		SET saga_attempts = saga_attempts + 1, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing'
+		RETURNING ` + paymentColumns
+
+	payment := &models.Payment{}
+	if err := scanPayment(r.db.QueryRow(ctx, query, id), payment); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to record saga retry: %w", err)
+	}
+
+	return payment, nil
+}
+
+// SetCategory reassigns the spending category of a payment
+func (r *PaymentRepository) SetCategory(ctx context.Context, id int64, category string) (*models.Payment, error) {
+	query := `
+		UPDATE payments
+		SET category = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + paymentColumns
+
+	payment := &models.Payment{}
+	if err := scanPayment(r.db.QueryRow(ctx, query, id, category), payment); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to set payment category: %w", err)
+	}
+
+	return payment, nil
+}
+
+// UpdateStatus moves a payment to a new status if the state machine allows it from the current one,
+// recording the transition and its cause. Illegal transitions return ErrInvalidTransition.
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, id int64, status string, failureReason *string, cause string) (*models.Payment, error) {
+	return r.updateStatus(ctx, id, status, failureReason, cause, nil)
+}
+
+// MarkSubmitted records that a debited payment was accepted by a provider. The provider reference
+// is kept from an earlier reply if the provider did not send one.
+func (r *PaymentRepository) MarkSubmitted(ctx context.Context, id int64, provider string, providerReference *string, cause string) (*models.Payment, error) {
+	return r.updateStatus(ctx, id, models.PaymentStatusSubmitted, nil, cause, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE payments
+			SET provider = $1, provider_reference = COALESCE($2, provider_reference), submitted_at = NOW()
+			WHERE id = $3
+		`, provider, providerReference, id)
+		if err != nil {
+			return fmt.Errorf("failed to record provider submission: %w", err)
+		}
+		return nil
+	})
+}
+
+// updateStatus applies a status transition. apply, if given, runs in the same transaction once the
+// payment is locked, to write any columns that go with the transition.
+func (r *PaymentRepository) updateStatus(ctx context.Context, id int64, status string, failureReason *string, cause string, apply func(tx pgx.Tx) error) (*models.Payment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var from string
+	err = tx.QueryRow(ctx, `SELECT status FROM payments WHERE id = $1 FOR UPDATE`, id).Scan(&from)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to lock payment: %w", err)
+	}
+
+	if apply != nil && models.CanTransition(from, status) {
+		if err := apply(tx); err != nil {
+			return nil, err
+		}
+	}
+
 	var query string
 	var args []interface{}
 
-	if status == models.PaymentStatusCompleted || status == models.PaymentStatusFailed {
+	if models.IsProcessedStatus(status) {
 		query = `
 			UPDATE payments
 			SET status = $1, failure_reason = $2, processed_at = $3, updated_at = NOW()
-			WHERE id = $4
-			RETURNING id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
-			          recipient_bank, amount, currency, description, status, failure_reason,
-			          created_at, updated_at, processed_at
-		`
-		args = []interface{}{status, failureReason, time.Now(), id}
+			WHERE id = $4 AND status = ANY($5)
+			RETURNING ` + paymentColumns
+		args = []interface{}{status, failureReason, time.Now(), id, models.AllowedFromStatuses(status)}
 	} else {
 		query = `
 			UPDATE payments
-			SET status = $1, updated_at = NOW()
-			WHERE id = $2
-			RETURNING id, reference_id, account_id, user_id, payment_type, recipient_name, recipient_account,
-			          recipient_bank, amount, currency, description, status, failure_reason,
-			          created_at, updated_at, processed_at
-		`
-		args = []interface{}{status, id}
+			SET status = $1, failure_reason = COALESCE($2, failure_reason), updated_at = NOW()
+			WHERE id = $3 AND status = ANY($4)
+			RETURNING ` + paymentColumns
+		args = []interface{}{status, failureReason, id, models.AllowedFromStatuses(status)}
 	}
 
 	payment := &models.Payment{}
-	err := r.db.QueryRow(ctx, query, args...).Scan(
-		&payment.ID, &payment.ReferenceID, &payment.AccountID, &payment.UserID,
-		&payment.PaymentType, &payment.RecipientName, &payment.RecipientAccount,
-		&payment.RecipientBank, &payment.Amount, &payment.Currency, &payment.Description,
-		&payment.Status, &payment.FailureReason, &payment.CreatedAt, &payment.UpdatedAt,
-		&payment.ProcessedAt,
-	)
+	err = scanPayment(tx.QueryRow(ctx, query, args...), payment)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrPaymentNotFound
+			return nil, fmt.Errorf("%w: payment %d %s -> %s", ErrInvalidTransition, id, from, status)
 		}
 		return nil, fmt.Errorf("failed to update payment status: %w", err)
 	}
 
+	_, err = tx.Exec(ctx, `
+		INSERT INTO payment_status_history (payment_id, from_status, to_status, cause, detail)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, from, status, cause, failureReason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record status transition: %w", err)
+	}
+
+	if models.ReleasesQRCode(status) {
+		if err := releaseQRCodeOf(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit status update: %w", err)
+	}
+
 	return payment, nil
 }
 
 // MarkAsProcessing marks a payment as processing
 func (r *PaymentRepository) MarkAsProcessing(ctx context.Context, id int64) (*models.Payment, error) {
-	return r.UpdateStatus(ctx, id, models.PaymentStatusProcessing, nil)
+	return r.UpdateStatus(ctx, id, models.PaymentStatusProcessing, nil, models.TransitionCauseDispatched)
 }
 
 // MarkAsCompleted marks a payment as completed
-func (r *PaymentRepository) MarkAsCompleted(ctx context.Context, id int64) (*models.Payment, error) {
-	return r.UpdateStatus(ctx, id, models.PaymentStatusCompleted, nil)
+func (r *PaymentRepository) MarkAsCompleted(ctx context.Context, id int64, cause string) (*models.Payment, error) {
+	return r.UpdateStatus(ctx, id, models.PaymentStatusCompleted, nil, cause)
 }
 
 // MarkAsFailed marks a payment as failed
-func (r *PaymentRepository) MarkAsFailed(ctx context.Context, id int64, reason string) (*models.Payment, error) {
-	return r.UpdateStatus(ctx, id, models.PaymentStatusFailed, &reason)
+func (r *PaymentRepository) MarkAsFailed(ctx context.Context, id int64, reason, cause string) (*models.Payment, error) {
+	return r.UpdateStatus(ctx, id, models.PaymentStatusFailed, &reason, cause)
+}
+
+// ListStatusHistory returns a payment's status transitions, oldest first
+func (r *PaymentRepository) ListStatusHistory(ctx context.Context, id int64) ([]models.StatusTransition, error) {
+	query := `
+		SELECT id, payment_id, from_status, to_status, cause, detail, created_at
+		FROM payment_status_history
+		WHERE payment_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.StatusTransition{}
+	for rows.Next() {
+		var t models.StatusTransition
+		if err := rows.Scan(&t.ID, &t.PaymentID, &t.FromStatus, &t.ToStatus, &t.Cause, &t.Detail, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status transition: %w", err)
+		}
+		history = append(history, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status history: %w", err)
+	}
+
+	return history, nil
 }
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrQRCodeNotFound   = errors.New("QR code not found")
+	ErrQRCodeNotPayable = errors.New("QR code has been paid or has expired")
+)
+
+// qrCodeColumns is the column list selected for every QR code query
+const qrCodeColumns = `id, merchant_id, reference, amount, currency, description, payload, expires_at, used_at, payment_id,
+		       created_at`
+
+func scanQRCode(row rowScanner, q *models.QRCode) error {
+	return row.Scan(
+		&q.ID, &q.MerchantID, &q.Reference, &q.Amount, &q.Currency, &q.Description, &q.Payload, &q.ExpiresAt, &q.UsedAt,
+		&q.PaymentID, &q.CreatedAt,
+	)
+}
+
+// QRCodeRepository handles dynamic merchant QR code data
+type QRCodeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewQRCodeRepository creates a new QR code repository
+func NewQRCodeRepository(db *pgxpool.Pool) *QRCodeRepository {
+	return &QRCodeRepository{db: db}
+}
+
+// Create saves a dynamic QR code
+func (r *QRCodeRepository) Create(ctx context.Context, q *models.QRCode) (*models.QRCode, error) {
+	query := `
+		INSERT INTO payment_qr_codes (merchant_id, reference, amount, currency, description, payload, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + qrCodeColumns
+
+	qr := &models.QRCode{}
+	err := scanQRCode(r.db.QueryRow(
+		ctx, query,
+		q.MerchantID, q.Reference, q.Amount, q.Currency, q.Description, q.Payload, q.ExpiresAt,
+	), qr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create QR code: %w", err)
+	}
+
+	return qr, nil
+}
+
+// GetByReference retrieves a QR code by the reference label its payload carries
+func (r *QRCodeRepository) GetByReference(ctx context.Context, reference string) (*models.QRCode, error) {
+	query := `SELECT ` + qrCodeColumns + ` FROM payment_qr_codes WHERE reference = $1`
+
+	qr := &models.QRCode{}
+	if err := scanQRCode(r.db.QueryRow(ctx, query, reference), qr); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrQRCodeNotFound
+		}
+		return nil, fmt.Errorf("failed to get QR code: %w", err)
+	}
+
+	return qr, nil
+}
+
+// Claim marks an unexpired QR code as used so that only one payment can be made with it
+func (r *QRCodeRepository) Claim(ctx context.Context, id int64) (*models.QRCode, error) {
+	query := `
+		UPDATE payment_qr_codes
+		SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING ` + qrCodeColumns
+
+	qr := &models.QRCode{}
+	if err := scanQRCode(r.db.QueryRow(ctx, query, id), qr); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrQRCodeNotPayable
+		}
+		return nil, fmt.Errorf("failed to claim QR code: %w", err)
+	}
+
+	return qr, nil
+}
+
+// Release returns a claimed QR code that no payment was made with, so it can be scanned again
+func (r *QRCodeRepository) Release(ctx context.Context, id int64) error {
+	query := `UPDATE payment_qr_codes SET used_at = NULL WHERE id = $1 AND payment_id IS NULL`
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to release QR code: %w", err)
+	}
+	return nil
+}
+
+// AttachPayment records the payment made with a claimed QR code. The payment is locked so that it
+// cannot fail unseen in between; if it has already failed, the code is released instead.
+func (r *QRCodeRepository) AttachPayment(ctx context.Context, id, paymentID int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	if err := tx.QueryRow(ctx, `SELECT status FROM payments WHERE id = $1 FOR UPDATE`, paymentID).Scan(&status); err != nil {
+		return fmt.Errorf("failed to lock payment: %w", err)
+	}
+
+	if models.ReleasesQRCode(status) {
+		_, err = tx.Exec(ctx, `UPDATE payment_qr_codes SET used_at = NULL WHERE id = $1 AND payment_id IS NULL`, id)
+	} else {
+		_, err = tx.Exec(ctx, `UPDATE payment_qr_codes SET payment_id = $1 WHERE id = $2`, paymentID, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to attach payment to QR code: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit QR code payment: %w", err)
+	}
+	return nil
+}
+
+// releaseQRCodeOf frees the QR code a payment that left the merchant unpaid was made with, in the
+// payment's status transaction
+func releaseQRCodeOf(ctx context.Context, tx pgx.Tx, paymentID int64) error {
+	_, err := tx.Exec(ctx, `UPDATE payment_qr_codes SET used_at = NULL, payment_id = NULL WHERE payment_id = $1`, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to release QR code: %w", err)
+	}
+	return nil
+}
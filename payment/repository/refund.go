@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrRefundNotFound = errors.New("refund not found")
+	ErrRefundSettled  = errors.New("refund is no longer pending")
+)
+
+// refundColumns is the column list selected for every refund query
+const refundColumns = `id, reference_id, payment_id, amount, fee_amount, currency, reason, status, failure_reason, requested_by,
+		       created_at, updated_at, completed_at`
+
+func scanRefund(row rowScanner, rf *models.Refund) error {
+	return row.Scan(
+		&rf.ID, &rf.ReferenceID, &rf.PaymentID, &rf.Amount, &rf.FeeAmount, &rf.Currency, &rf.Reason, &rf.Status, &rf.FailureReason,
+		&rf.RequestedBy, &rf.CreatedAt, &rf.UpdatedAt, &rf.CompletedAt,
+	)
+}
+
+func collectRefunds(rows pgx.Rows) ([]models.Refund, error) {
+	defer rows.Close()
+
+	refunds := []models.Refund{}
+	for rows.Next() {
+		var rf models.Refund
+		if err := scanRefund(rows, &rf); err != nil {
+			return nil, fmt.Errorf("failed to scan refund: %w", err)
+		}
+		refunds = append(refunds, rf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating refunds: %w", err)
+	}
+
+	return refunds, nil
+}
+
+// RefundRepository handles payment refund data
+type RefundRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRefundRepository creates a new refund repository
+func NewRefundRepository(db *pgxpool.Pool) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+// Create records a pending refund of a payment. The payment is locked while the refunds already
+// requested against it are totalled, so concurrent refunds can never exceed the payment amount.
+// A nil amount refunds whatever is left. The refund carries its share of the service charge, as
+// models.RefundFee works it out.
+func (r *RefundRepository) Create(ctx context.Context, paymentID int64, amount *decimal.Decimal, reason *string, requestedBy int64) (*models.Refund, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	payment := &models.Payment{}
+	err = scanPayment(tx.QueryRow(ctx, `SELECT `+paymentColumns+` FROM payments WHERE id = $1 FOR UPDATE`, paymentID), payment)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to lock payment: %w", err)
+	}
+	if !models.IsRefundable(payment) {
+		return nil, models.ErrPaymentNotRefundable
+	}
+
+	var requested, feeRequested decimal.Decimal
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(fee_amount), 0)
+		FROM payment_refunds WHERE payment_id = $1 AND status <> $2
+	`, paymentID, models.RefundStatusFailed).Scan(&requested, &feeRequested)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total refunds: %w", err)
+	}
+
+	remaining := payment.Amount.Sub(requested)
+	refundAmount := remaining
+	if amount != nil {
+		refundAmount = *amount
+	}
+	if refundAmount.LessThanOrEqual(decimal.Zero) {
+		if amount == nil {
+			return nil, models.ErrRefundExceedsPayment
+		}
+		return nil, models.ErrInvalidRefundAmount
+	}
+	if refundAmount.GreaterThan(remaining) {
+		return nil, models.ErrRefundExceedsPayment
+	}
+
+	refund := &models.Refund{}
+	err = scanRefund(tx.QueryRow(ctx, `
+		INSERT INTO payment_refunds (payment_id, amount, fee_amount, currency, reason, status, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+refundColumns,
+		paymentID, refundAmount, models.RefundFee(payment, refundAmount, remaining, feeRequested),
+		payment.Currency, reason, models.RefundStatusPending, requestedBy,
+	), refund)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit refund: %w", err)
+	}
+
+	return refund, nil
+}
+
+// GetByID retrieves a refund by ID
+func (r *RefundRepository) GetByID(ctx context.Context, id int64) (*models.Refund, error) {
+	query := `SELECT ` + refundColumns + ` FROM payment_refunds WHERE id = $1`
+
+	refund := &models.Refund{}
+	if err := scanRefund(r.db.QueryRow(ctx, query, id), refund); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+
+	return refund, nil
+}
+
+// ListByPayment retrieves a payment's refunds, oldest first, with the totals refunded and still refundable
+func (r *RefundRepository) ListByPayment(ctx context.Context, payment *models.Payment) (*models.RefundListResponse, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM payment_refunds
+		WHERE payment_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, payment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	refunds, err := collectRefunds(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.RefundListResponse{Refunds: refunds, Refundable: payment.Amount}
+	for _, rf := range refunds {
+		switch rf.Status {
+		case models.RefundStatusCompleted:
+			resp.Refunded = resp.Refunded.Add(rf.Amount)
+			resp.Refundable = resp.Refundable.Sub(rf.Amount)
+		case models.RefundStatusPending:
+			resp.Refundable = resp.Refundable.Sub(rf.Amount)
+		}
+	}
+	if !models.IsRefundable(payment) {
+		resp.Refundable = decimal.Zero
+	}
+
+	return resp, nil
+}
+
+// ListStale retrieves pending refunds created before the given time, oldest first
+func (r *RefundRepository) ListStale(ctx context.Context, before time.Time, limit int) ([]models.Refund, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM payment_refunds
+		WHERE status = $1 AND created_at < $2
+		ORDER BY created_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, models.RefundStatusPending, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale refunds: %w", err)
+	}
+
+	return collectRefunds(rows)
+}
+
+// MarkCompleted records that the account service credited a pending refund
+func (r *RefundRepository) MarkCompleted(ctx context.Context, id int64) (*models.Refund, error) {
+	query := `
+		UPDATE payment_refunds
+		SET status = $1, completed_at = NOW()
+		WHERE id = $2 AND status = $3
+		RETURNING ` + refundColumns
+
+	return r.update(ctx, query, id, models.RefundStatusCompleted, id, models.RefundStatusPending)
+}
+
+// MarkFailed records that a pending refund could not be credited, freeing its amount for another refund
+func (r *RefundRepository) MarkFailed(ctx context.Context, id int64, reason string) (*models.Refund, error) {
+	query := `
+		UPDATE payment_refunds
+		SET status = $1, failure_reason = $2
+		WHERE id = $3 AND status = $4
+		RETURNING ` + refundColumns
+
+	return r.update(ctx, query, id, models.RefundStatusFailed, reason, id, models.RefundStatusPending)
+}
+
+// update runs a guarded status update, telling a missing refund apart from one already settled
+func (r *RefundRepository) update(ctx context.Context, query string, id int64, args ...interface{}) (*models.Refund, error) {
+	refund := &models.Refund{}
+	err := scanRefund(r.db.QueryRow(ctx, query, args...), refund)
+	if err == nil {
+		return refund, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to update refund: %w", err)
+	}
+
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrRefundSettled
+}
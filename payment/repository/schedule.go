@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("payment schedule not found")
+	ErrScheduleState    = errors.New("payment schedule is not in a state that allows this")
+	ErrScheduleNotDue   = errors.New("payment schedule is not due")
+	ErrExecutionSettled = errors.New("schedule execution is no longer pending")
+)
+
+// scheduleColumns is the column list selected for every payment schedule query
+const scheduleColumns = `id, user_id, account_id, payment_type, biller_id, recipient_name, recipient_account, amount,
+		       currency, description, frequency, day_of_week, day_of_month, start_date, end_date, max_retries,
+		       retry_interval_hours, status, occurrence_at, next_run_at, attempts, active_execution_id,
+		       created_at, updated_at`
+
+func scanSchedule(row rowScanner, s *models.PaymentSchedule) error {
+	return row.Scan(
+		&s.ID, &s.UserID, &s.AccountID, &s.PaymentType, &s.BillerID, &s.RecipientName, &s.RecipientAccount, &s.Amount,
+		&s.Currency, &s.Description, &s.Frequency, &s.DayOfWeek, &s.DayOfMonth, &s.StartDate, &s.EndDate, &s.MaxRetries,
+		&s.RetryIntervalHours, &s.Status, &s.OccurrenceAt, &s.NextRunAt, &s.Attempts, &s.ActiveExecutionID,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+}
+
+func collectSchedules(rows pgx.Rows) ([]models.PaymentSchedule, error) {
+	defer rows.Close()
+
+	schedules := []models.PaymentSchedule{}
+	for rows.Next() {
+		var s models.PaymentSchedule
+		if err := scanSchedule(rows, &s); err != nil {
+			return nil, fmt.Errorf("failed to scan payment schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// executionColumns is the column list selected for every schedule execution query
+const executionColumns = `id, schedule_id, payment_id, occurrence_at, attempt, status, failure_reason,
+		       created_at, updated_at, finished_at`
+
+func scanExecution(row rowScanner, e *models.ScheduleExecution) error {
+	return row.Scan(
+		&e.ID, &e.ScheduleID, &e.PaymentID, &e.OccurrenceAt, &e.Attempt, &e.Status, &e.FailureReason,
+		&e.CreatedAt, &e.UpdatedAt, &e.FinishedAt,
+	)
+}
+
+func collectExecutions(rows pgx.Rows) ([]models.ScheduleExecution, error) {
+	defer rows.Close()
+
+	executions := []models.ScheduleExecution{}
+	for rows.Next() {
+		var e models.ScheduleExecution
+		if err := scanExecution(rows, &e); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule execution: %w", err)
+		}
+		executions = append(executions, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schedule executions: %w", err)
+	}
+
+	return executions, nil
+}
+
+// ScheduleRepository handles recurring payment schedules and their execution history
+type ScheduleRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewScheduleRepository creates a new payment schedule repository
+func NewScheduleRepository(db *pgxpool.Pool) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// Create stores a new schedule, already pointed at its first occurrence
+func (r *ScheduleRepository) Create(ctx context.Context, s *models.PaymentSchedule) (*models.PaymentSchedule, error) {
+	query := `
+		INSERT INTO payment_schedules (user_id, account_id, payment_type, biller_id, recipient_name, recipient_account,
+		                               amount, currency, description, frequency, day_of_week, day_of_month, start_date,
+		                               end_date, max_retries, retry_interval_hours, status, occurrence_at, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING ` + scheduleColumns
+
+	created := &models.PaymentSchedule{}
+	err := scanSchedule(r.db.QueryRow(ctx, query,
+		s.UserID, s.AccountID, s.PaymentType, s.BillerID, s.RecipientName, s.RecipientAccount,
+		s.Amount, s.Currency, s.Description, s.Frequency, s.DayOfWeek, s.DayOfMonth, s.StartDate,
+		s.EndDate, s.MaxRetries, s.RetryIntervalHours, models.ScheduleStatusActive, s.OccurrenceAt, s.NextRunAt,
+	), created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment schedule: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a payment schedule by ID
+func (r *ScheduleRepository) GetByID(ctx context.Context, id int64) (*models.PaymentSchedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM payment_schedules WHERE id = $1`
+
+	s := &models.PaymentSchedule{}
+	if err := scanSchedule(r.db.QueryRow(ctx, query, id), s); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment schedule: %w", err)
+	}
+
+	return s, nil
+}
+
+// List retrieves payment schedules, newest first; a userID of zero lists every user's
+func (r *ScheduleRepository) List(ctx context.Context, userID int64, limit, offset int) (*models.PaymentScheduleListResponse, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM payment_schedules WHERE $1 = 0 OR user_id = $1`
+	if err := r.db.QueryRow(ctx, countQuery, userID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count payment schedules: %w", err)
+	}
+
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM payment_schedules
+		WHERE $1 = 0 OR user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment schedules: %w", err)
+	}
+
+	schedules, err := collectSchedules(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaymentScheduleListResponse{Schedules: schedules, Total: total}, nil
+}
+
+// ListDue retrieves active schedules due to run by the given time with no payment in flight
+func (r *ScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.PaymentSchedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM payment_schedules
+		WHERE status = $1 AND next_run_at <= $2 AND active_execution_id IS NULL
+		ORDER BY next_run_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, models.ScheduleStatusActive, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due payment schedules: %w", err)
+	}
+
+	return collectSchedules(rows)
+}
+
+// Pause stops an active schedule from running until it is resumed
+func (r *ScheduleRepository) Pause(ctx context.Context, id int64) (*models.PaymentSchedule, error) {
+	query := `
+		UPDATE payment_schedules
+		SET status = $1
+		WHERE id = $2 AND status = $3
+		RETURNING ` + scheduleColumns
+
+	return r.update(ctx, query, id, models.ScheduleStatusPaused, id, models.ScheduleStatusActive)
+}
+
+// Resume reactivates a paused schedule at the occurrence it was pointed at, dropping any retry in progress
+func (r *ScheduleRepository) Resume(ctx context.Context, s *models.PaymentSchedule) (*models.PaymentSchedule, error) {
+	query := `
+		UPDATE payment_schedules
+		SET status = $1, occurrence_at = $2, next_run_at = $3, attempts = 0
+		WHERE id = $4 AND status = $5
+		RETURNING ` + scheduleColumns
+
+	return r.update(ctx, query, s.ID, models.ScheduleStatusActive, s.OccurrenceAt, s.NextRunAt, s.ID, models.ScheduleStatusPaused)
+}
+
+// Cancel ends an active or paused schedule for good. A payment already in flight is not affected.
+func (r *ScheduleRepository) Cancel(ctx context.Context, id int64) (*models.PaymentSchedule, error) {
+	query := `
+		UPDATE payment_schedules
+		SET status = $1
+		WHERE id = $2 AND status = ANY($3)
+		RETURNING ` + scheduleColumns
+
+	return r.update(ctx, query, id, models.ScheduleStatusCancelled, id,
+		[]string{models.ScheduleStatusActive, models.ScheduleStatusPaused})
+}
+
+// update runs a guarded status update, telling a missing schedule apart from one in the wrong state
+func (r *ScheduleRepository) update(ctx context.Context, query string, id int64, args ...interface{}) (*models.PaymentSchedule, error) {
+	s := &models.PaymentSchedule{}
+	err := scanSchedule(r.db.QueryRow(ctx, query, args...), s)
+	if err == nil {
+		return s, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to update payment schedule: %w", err)
+	}
+
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrScheduleState
+}
+
+// StartExecution claims a due schedule and records a pending execution of its current occurrence.
+// Until the execution finishes the schedule is not due again, so an occurrence is never paid twice.
+// A schedule that was paused, cancelled or claimed since it was listed returns ErrScheduleNotDue.
+func (r *ScheduleRepository) StartExecution(ctx context.Context, id int64, now time.Time) (*models.ScheduleExecution, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	s := &models.PaymentSchedule{}
+	err = scanSchedule(tx.QueryRow(ctx, `SELECT `+scheduleColumns+` FROM payment_schedules WHERE id = $1 FOR UPDATE`, id), s)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to lock payment schedule: %w", err)
+	}
+	if s.Status != models.ScheduleStatusActive || s.ActiveExecutionID != nil || s.NextRunAt.After(now) {
+		return nil, ErrScheduleNotDue
+	}
+
+	execution := &models.ScheduleExecution{}
+	err = scanExecution(tx.QueryRow(ctx, `
+		INSERT INTO payment_schedule_executions (schedule_id, occurrence_at, attempt, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+executionColumns,
+		id, s.OccurrenceAt, s.Attempts+1, models.ExecutionStatusPending,
+	), execution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record schedule execution: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE payment_schedules SET attempts = attempts + 1, active_execution_id = $1 WHERE id = $2
+	`, execution.ID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim payment schedule: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit schedule execution: %w", err)
+	}
+
+	return execution, nil
+}
+
+// AttachPayment links a pending execution to the payment created for it
+func (r *ScheduleRepository) AttachPayment(ctx context.Context, executionID, paymentID int64) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE payment_schedule_executions SET payment_id = $1 WHERE id = $2 AND status = $3
+	`, paymentID, executionID, models.ExecutionStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to attach payment to schedule execution: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrExecutionSettled
+	}
+	return nil
+}
+
+// ListPendingExecutions retrieves executions whose outcome is not known yet, oldest first
+func (r *ScheduleRepository) ListPendingExecutions(ctx context.Context, limit int) ([]models.ScheduleExecution, error) {
+	query := `
+		SELECT ` + executionColumns + `
+		FROM payment_schedule_executions
+		WHERE status = $1
+		ORDER BY created_at ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, models.ExecutionStatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending schedule executions: %w", err)
+	}
+
+	return collectExecutions(rows)
+}
+
+// ListExecutions retrieves a schedule's execution history, newest first
+func (r *ScheduleRepository) ListExecutions(ctx context.Context, scheduleID int64, limit, offset int) ([]models.ScheduleExecution, error) {
+	query := `
+		SELECT ` + executionColumns + `
+		FROM payment_schedule_executions
+		WHERE schedule_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, scheduleID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule executions: %w", err)
+	}
+
+	return collectExecutions(rows)
+}
+
+// FinishExecution records the outcome of a pending execution and releases its schedule. advance is
+// called with the locked schedule to move it to a retry or its next occurrence; the status it sets
+// is only kept while the schedule is still active, so a pause or cancellation made meanwhile stands.
+func (r *ScheduleRepository) FinishExecution(ctx context.Context, executionID int64, status string, failureReason *string, advance func(s *models.PaymentSchedule)) (*models.ScheduleExecution, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	execution := &models.ScheduleExecution{}
+	err = scanExecution(tx.QueryRow(ctx, `
+		UPDATE payment_schedule_executions
+		SET status = $1, failure_reason = $2, finished_at = NOW()
+		WHERE id = $3 AND status = $4
+		RETURNING `+executionColumns,
+		status, failureReason, executionID, models.ExecutionStatusPending,
+	), execution)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExecutionSettled
+		}
+		return nil, fmt.Errorf("failed to finish schedule execution: %w", err)
+	}
+
+	s := &models.PaymentSchedule{}
+	err = scanSchedule(tx.QueryRow(ctx, `SELECT `+scheduleColumns+` FROM payment_schedules WHERE id = $1 FOR UPDATE`, execution.ScheduleID), s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock payment schedule: %w", err)
+	}
+
+	advance(s)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE payment_schedules
+		SET status = CASE WHEN status = $1 THEN $2 ELSE status END,
+		    occurrence_at = $3, next_run_at = $4, attempts = $5, active_execution_id = NULL
+		WHERE id = $6
+	`, models.ScheduleStatusActive, s.Status, s.OccurrenceAt, s.NextRunAt, s.Attempts, s.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to advance payment schedule: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit schedule execution: %w", err)
+	}
+
+	return execution, nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payment/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrTemplateNotFound   = errors.New("payment template not found")
+	ErrTemplateNameExists = errors.New("payment template name already exists")
+)
+
+// templateColumns is the column list selected for every payment template query
+const templateColumns = `id, user_id, name, payment_type, account_id, beneficiary_id, biller_id, merchant_id,
+		       recipient_name, recipient_account, recipient_bank, amount, currency, description, last_used_at,
+		       created_at, updated_at`
+
+func scanTemplate(row rowScanner, t *models.PaymentTemplate) error {
+	return row.Scan(
+		&t.ID, &t.UserID, &t.Name, &t.PaymentType, &t.AccountID, &t.BeneficiaryID, &t.BillerID, &t.MerchantID,
+		&t.RecipientName, &t.RecipientAccount, &t.RecipientBank, &t.Amount, &t.Currency, &t.Description, &t.LastUsedAt,
+		&t.CreatedAt, &t.UpdatedAt,
+	)
+}
+
+type TemplateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTemplateRepository(db *pgxpool.Pool) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+// Create saves a payment template
+func (r *TemplateRepository) Create(ctx context.Context, t *models.PaymentTemplate) (*models.PaymentTemplate, error) {
+	query := `
+		INSERT INTO payment_templates (user_id, name, payment_type, account_id, beneficiary_id, biller_id, merchant_id,
+		                               recipient_name, recipient_account, recipient_bank, amount, currency, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING ` + templateColumns
+
+	template := &models.PaymentTemplate{}
+	err := scanTemplate(r.db.QueryRow(
+		ctx, query,
+		t.UserID, t.Name, t.PaymentType, t.AccountID, t.BeneficiaryID, t.BillerID, t.MerchantID,
+		t.RecipientName, t.RecipientAccount, t.RecipientBank, t.Amount, t.Currency, t.Description,
+	), template)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrTemplateNameExists
+		}
+		return nil, fmt.Errorf("failed to create payment template: %w", err)
+	}
+
+	return template, nil
+}
+
+// GetByID retrieves a payment template by ID
+func (r *TemplateRepository) GetByID(ctx context.Context, id int64) (*models.PaymentTemplate, error) {
+	query := `SELECT ` + templateColumns + ` FROM payment_templates WHERE id = $1`
+
+	template := &models.PaymentTemplate{}
+	if err := scanTemplate(r.db.QueryRow(ctx, query, id), template); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment template: %w", err)
+	}
+
+	return template, nil
+}
+
+// ListByUser retrieves a user's payment templates by name
+func (r *TemplateRepository) ListByUser(ctx context.Context, userID int64) (*models.PaymentTemplateListResponse, error) {
+	query := `SELECT ` + templateColumns + ` FROM payment_templates WHERE user_id = $1 ORDER BY name, id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []models.PaymentTemplate{}
+	for rows.Next() {
+		var t models.PaymentTemplate
+		if err := scanTemplate(rows, &t); err != nil {
+			return nil, fmt.Errorf("failed to scan payment template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment templates: %w", err)
+	}
+
+	return &models.PaymentTemplateListResponse{Templates: templates, Total: int64(len(templates))}, nil
+}
+
+// Update writes a payment template's editable fields; its owner and type are fixed
+func (r *TemplateRepository) Update(ctx context.Context, t *models.PaymentTemplate) (*models.PaymentTemplate, error) {
+	query := `
+		UPDATE payment_templates
+		SET name = $1, account_id = $2, beneficiary_id = $3, biller_id = $4, merchant_id = $5, recipient_name = $6,
+		    recipient_account = $7, recipient_bank = $8, amount = $9, currency = $10, description = $11
+		WHERE id = $12
+		RETURNING ` + templateColumns
+
+	template := &models.PaymentTemplate{}
+	err := scanTemplate(r.db.QueryRow(
+		ctx, query,
+		t.Name, t.AccountID, t.BeneficiaryID, t.BillerID, t.MerchantID, t.RecipientName,
+		t.RecipientAccount, t.RecipientBank, t.Amount, t.Currency, t.Description, t.ID,
+	), template)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTemplateNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrTemplateNameExists
+		}
+		return nil, fmt.Errorf("failed to update payment template: %w", err)
+	}
+
+	return template, nil
+}
+
+// Delete removes a payment template. Payments made from it are not affected.
+func (r *TemplateRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM payment_templates WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete payment template: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// MarkUsed records that a payment was made from a template
+func (r *TemplateRepository) MarkUsed(ctx context.Context, id int64) error {
+	if _, err := r.db.Exec(ctx, `UPDATE payment_templates SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to mark payment template used: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"payment/models"
+)
+
+// errOperationUnknown means the account service has no record of a payment request
+var errOperationUnknown = errors.New("account service has no record of the operation")
+
+// sagaConfig controls recovery of payments stuck waiting for the account service
+type sagaConfig struct {
+	Timeout      time.Duration // how long a payment may stay processing before it is checked
+	Interval     time.Duration // how often the sweeper runs
+	MaxRepublish int           // re-publish attempts before the payment is failed
+}
+
+const sagaSweepBatchSize = 100
+
+// runSagaSweeper periodically recovers payments and refunds whose result event never arrived
+func runSagaSweeper(ctx context.Context, cfg sagaConfig) {
+	log.Printf("Starting saga sweeper (timeout %s, interval %s, max republish %d)", cfg.Timeout, cfg.Interval, cfg.MaxRepublish)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping saga sweeper")
+			return
+		case <-ticker.C:
+			sweepStuckPayments(ctx, cfg)
+			sweepStuckRefunds(ctx, cfg)
+		}
+	}
+}
+
+func sweepStuckPayments(ctx context.Context, cfg sagaConfig) {
+	stuck, err := paymentRepo.ListStale(ctx, models.PaymentStatusProcessing, time.Now().Add(-cfg.Timeout), sagaSweepBatchSize)
+	if err != nil {
+		log.Printf("Saga sweeper failed to list stuck payments: %v", err)
+		return
+	}
+
+	for i := range stuck {
+		if ctx.Err() != nil {
+			return
+		}
+		recoverPayment(ctx, &stuck[i], cfg)
+	}
+}
+
+// recoverPayment asks the account service what happened to a stuck payment and settles it:
+// a recorded outcome is applied directly, an unknown one is re-published until the retry budget runs out.
+func recoverPayment(ctx context.Context, payment *models.Payment, cfg sagaConfig) {
+	op, err := getAccountOperation(payment.ReferenceID.String())
+	switch {
+	case err == nil && op.Status == models.PaymentStatusCompleted:
+		// Debited provider payments are submitted by the provider sweeper
+		status := models.DebitedStatus(payment)
+		if _, err := paymentRepo.UpdateStatus(ctx, payment.ID, status, nil, models.TransitionCauseSagaRecovery); err != nil {
+			log.Printf("Saga sweeper failed to mark payment %d %s: %v", payment.ID, status, err)
+			return
+		}
+		alertOps(ctx, "warning", payment, "payment result event was lost; marked "+status+" from account service record")
+
+	case err == nil:
+		reason := "failed in account service"
+		if op.FailureReason != nil {
+			reason = *op.FailureReason
+		}
+		if _, err := paymentRepo.MarkAsFailed(ctx, payment.ID, reason, models.TransitionCauseSagaRecovery); err != nil {
+			log.Printf("Saga sweeper failed to fail payment %d: %v", payment.ID, err)
+			return
+		}
+		alertOps(ctx, "warning", payment, "payment result event was lost; marked failed from account service record")
+
+	case errors.Is(err, errOperationUnknown) && payment.SagaAttempts < cfg.MaxRepublish:
+		// The request never reached the account service (or was dropped); the account service is
+		// idempotent by reference, so publishing again is safe
+		retried, err := paymentRepo.RecordSagaRetry(ctx, payment.ID)
+		if err != nil {
+			log.Printf("Saga sweeper failed to record retry for payment %d: %v", payment.ID, err)
+			return
+		}
+		if _, err := publishPayment(ctx, retried); err != nil {
+			alertOps(ctx, "critical", payment, "payment could not be re-published and was marked failed")
+			return
+		}
+		alertOps(ctx, "warning", payment, fmt.Sprintf("payment timed out with no account service record; re-published (attempt %d of %d)",
+			retried.SagaAttempts, cfg.MaxRepublish))
+
+	case errors.Is(err, errOperationUnknown):
+		// Fence the reference off first so a request still in flight is refused by the account
+		// service; if it got there first, its outcome stands
+		op, err := postAccountCancel(payment.ReferenceID.String(), "payment", payment.ID, "payment timed out")
+		if err != nil {
+			log.Printf("Saga sweeper failed to cancel payment %d in account service: %v", payment.ID, err)
+			alertOps(ctx, "critical", payment, "payment timed out and could not be cancelled in the account service: "+err.Error())
+			return
+		}
+		if op.Status == models.PaymentStatusCompleted {
+			status := models.DebitedStatus(payment)
+			if _, err := paymentRepo.UpdateStatus(ctx, payment.ID, status, nil, models.TransitionCauseSagaRecovery); err != nil {
+				log.Printf("Saga sweeper failed to mark payment %d %s: %v", payment.ID, status, err)
+				return
+			}
+			alertOps(ctx, "warning", payment, "payment was processed by the account service just before it timed out; marked "+status)
+			return
+		}
+		if _, err := paymentRepo.MarkAsFailed(ctx, payment.ID, "payment timed out", models.TransitionCauseSagaTimeout); err != nil {
+			log.Printf("Saga sweeper failed to fail payment %d: %v", payment.ID, err)
+			return
+		}
+		alertOps(ctx, "critical", payment, fmt.Sprintf("payment timed out after %d re-publish attempts and was marked failed", payment.SagaAttempts))
+
+	default:
+		// Leave the payment alone; it will be picked up again on the next sweep
+		log.Printf("Saga sweeper could not query account service for payment %d: %v", payment.ID, err)
+		alertOps(ctx, "critical", payment, "payment is stuck and the account service could not be queried: "+err.Error())
+	}
+}
+
+// getAccountOperation asks the account service how it processed a payment request
+func getAccountOperation(referenceID string) (*models.AccountOperation, error) {
+	accountServiceURL := getEnv("ACCOUNT_SERVICE_URL", "http://account.account.svc.cluster.local:8080")
+
+	req, err := http.NewRequest("GET", accountServiceURL+"/api/accounts/operations/"+referenceID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// The operations endpoint is admin only
+	req.Header.Set("X-User-ID", "0")
+	req.Header.Set("X-User-Role", "admin")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call account service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errOperationUnknown
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("account service returned status %d", resp.StatusCode)
+	}
+
+	var op models.AccountOperation
+	if err := json.NewDecoder(resp.Body).Decode(&op); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &op, nil
+}
+
+// postAccountCancel asks the account service to fence off a request by its reference ID. The
+// outcome it returns is the one now on record: the cancellation, or the request's own if it was
+// processed first.
+func postAccountCancel(referenceID, operationType string, sourceID int64, reason string) (*models.AccountOperation, error) {
+	accountServiceURL := getEnv("ACCOUNT_SERVICE_URL", "http://account.account.svc.cluster.local:8080")
+
+	body, err := json.Marshal(map[string]interface{}{
+		"operation_type": operationType,
+		"source_id":      sourceID,
+		"reason":         reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cancellation: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", accountServiceURL+"/api/accounts/operations/"+referenceID+"/cancel", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// The operations endpoint is admin only
+	req.Header.Set("X-User-ID", "0")
+	req.Header.Set("X-User-Role", "admin")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call account service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("account service returned status %d", resp.StatusCode)
+	}
+
+	var op models.AccountOperation
+	if err := json.NewDecoder(resp.Body).Decode(&op); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &op, nil
+}
+
+// alertOps logs and publishes an operator alert about a payment
+func alertOps(ctx context.Context, severity string, payment *models.Payment, message string) {
+	log.Printf("[ALERT %s] payment %d (ref: %s): %s", severity, payment.ID, payment.ReferenceID, message)
+
+	alert := models.OpsAlert{
+		Service:     "payment",
+		Severity:    severity,
+		EntityType:  "payment",
+		EntityID:    payment.ID,
+		ReferenceID: payment.ReferenceID.String(),
+		Message:     message,
+		CreatedAt:   time.Now(),
+	}
+	if err := kafkaProducer.PublishOpsAlert(ctx, alert); err != nil {
+		log.Printf("Failed to publish ops alert for payment %d: %v", payment.ID, err)
+	}
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// scheduleConfig controls the recurring payment scheduler
+type scheduleConfig struct {
+	Interval time.Duration // how often due schedules are run and pending executions checked
+}
+
+const (
+	scheduleBatchSize = 100
+
+	// orphanedExecutionAge is how long an execution may go without a payment before it is taken to
+	// have been interrupted, e.g. by a restart between claiming the schedule and creating the payment
+	orphanedExecutionAge = 10 * time.Minute
+)
+
+// listPaymentSchedules lists the caller's payment schedules; admins see everyone's
+func listPaymentSchedules(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+
+	if role == "admin" {
+		userID = 0
+	}
+	result, err := scheduleRepo.List(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payment schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getPaymentSchedule returns a payment schedule
+func getPaymentSchedule(c *gin.Context) {
+	s, ok := loadPaymentSchedule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
+
+// createPaymentSchedule sets up a recurring bill payment or mobile top-up from one of the
+// caller's accounts. The payee is validated now as for a one-off payment, and again before
+// every occurrence is paid.
+func createPaymentSchedule(c *gin.Context) {
+	userID, _, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreatePaymentScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+
+	account, status, err := getAccountByID(req.AccountID)
+	if err != nil {
+		if status == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to look up account"})
+		return
+	}
+	if account.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+	if account.Status != "active" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "account is not active"})
+		return
+	}
+
+	payment := models.CreatePaymentRequest{
+		AccountID:        req.AccountID,
+		PaymentType:      req.PaymentType,
+		RecipientName:    req.RecipientName,
+		RecipientAccount: req.RecipientAccount,
+		Amount:           req.Amount,
+		Currency:         strings.ToUpper(req.Currency),
+		Description:      req.Description,
+		BillerID:         req.BillerID,
+	}
+	if !applyBiller(c, &payment) {
+		return
+	}
+	if payment.PaymentType == models.PaymentTypeMobile && !applyMobileOperator(c, &payment) {
+		return
+	}
+	if payment.Currency == "" {
+		payment.Currency = account.Currency
+	}
+
+	today := time.Now().UTC()
+	s := models.PaymentSchedule{
+		UserID:             userID,
+		AccountID:          payment.AccountID,
+		PaymentType:        payment.PaymentType,
+		BillerID:           payment.BillerID,
+		RecipientName:      payment.RecipientName,
+		RecipientAccount:   payment.RecipientAccount,
+		Amount:             payment.Amount,
+		Currency:           payment.Currency,
+		Description:        payment.Description,
+		Frequency:          req.Frequency,
+		DayOfWeek:          req.DayOfWeek,
+		DayOfMonth:         req.DayOfMonth,
+		StartDate:          time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC),
+		MaxRetries:         models.DefaultScheduleMaxRetries,
+		RetryIntervalHours: models.DefaultScheduleRetryInterval,
+	}
+	if req.StartDate != "" {
+		s.StartDate, _ = models.ParseDate(req.StartDate)
+	}
+	if req.EndDate != "" {
+		endDate, _ := models.ParseDate(req.EndDate)
+		s.EndDate = &endDate
+	}
+	if req.MaxRetries != nil {
+		s.MaxRetries = *req.MaxRetries
+	}
+	if req.RetryIntervalHours != nil {
+		s.RetryIntervalHours = *req.RetryIntervalHours
+	}
+
+	// The day of the week or month defaults to the start date's
+	switch {
+	case s.Frequency == models.ScheduleFrequencyWeekly && s.DayOfWeek == nil:
+		day := int(s.StartDate.Weekday())
+		s.DayOfWeek = &day
+	case s.Frequency == models.ScheduleFrequencyMonthly && s.DayOfMonth == nil:
+		day := s.StartDate.Day()
+		s.DayOfMonth = &day
+	}
+
+	if err := s.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.Start(today); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := scheduleRepo.Create(c.Request.Context(), &s)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create payment schedule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// pausePaymentSchedule stops a schedule from paying until it is resumed
+func pausePaymentSchedule(c *gin.Context) {
+	s, ok := loadPaymentSchedule(c)
+	if !ok {
+		return
+	}
+
+	paused, err := scheduleRepo.Pause(c.Request.Context(), s.ID)
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, paused)
+}
+
+// resumePaymentSchedule reactivates a paused schedule. Occurrences that fell while it was paused
+// are skipped; it next pays on its first occurrence from today.
+func resumePaymentSchedule(c *gin.Context) {
+	s, ok := loadPaymentSchedule(c)
+	if !ok {
+		return
+	}
+	if s.Status != models.ScheduleStatusPaused {
+		respondScheduleError(c, repository.ErrScheduleState)
+		return
+	}
+
+	if err := s.Start(time.Now()); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	resumed, err := scheduleRepo.Resume(c.Request.Context(), s)
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resumed)
+}
+
+// cancelPaymentSchedule ends a schedule for good
+func cancelPaymentSchedule(c *gin.Context) {
+	s, ok := loadPaymentSchedule(c)
+	if !ok {
+		return
+	}
+
+	cancelled, err := scheduleRepo.Cancel(c.Request.Context(), s.ID)
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// listScheduleExecutions returns a schedule's execution history, newest first
+func listScheduleExecutions(c *gin.Context) {
+	s, ok := loadPaymentSchedule(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+
+	executions, err := scheduleRepo.ListExecutions(c.Request.Context(), s.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list schedule executions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, executions)
+}
+
+// loadPaymentSchedule loads the schedule named in the path, which only its owner and admins may see
+func loadPaymentSchedule(c *gin.Context) (*models.PaymentSchedule, bool) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	scheduleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule ID"})
+		return nil, false
+	}
+
+	s, err := scheduleRepo.GetByID(c.Request.Context(), scheduleID)
+	if err != nil {
+		respondScheduleError(c, err)
+		return nil, false
+	}
+	if role != "admin" && s.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return nil, false
+	}
+
+	return s, true
+}
+
+func respondScheduleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment schedule not found"})
+	case errors.Is(err, repository.ErrScheduleState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update payment schedule"})
+	}
+}
+
+// runScheduler periodically settles finished schedule executions and pays due occurrences
+func runScheduler(ctx context.Context, cfg scheduleConfig) {
+	log.Printf("Starting payment scheduler (interval %s)", cfg.Interval)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping payment scheduler")
+			return
+		case <-ticker.C:
+			settleScheduleExecutions(ctx)
+			runDueSchedules(ctx)
+		}
+	}
+}
+
+// settleScheduleExecutions records the outcome of executions whose payments have finished and
+// moves their schedules on to a retry or the next occurrence
+func settleScheduleExecutions(ctx context.Context) {
+	pending, err := scheduleRepo.ListPendingExecutions(ctx, scheduleBatchSize)
+	if err != nil {
+		log.Printf("Payment scheduler failed to list pending executions: %v", err)
+		return
+	}
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		execution := &pending[i]
+
+		if execution.PaymentID == nil {
+			if time.Since(execution.CreatedAt) > orphanedExecutionAge {
+				finishScheduleExecution(ctx, execution, false, true, "interrupted before the payment was created")
+			}
+			continue
+		}
+
+		payment, err := paymentRepo.GetByID(ctx, *execution.PaymentID)
+		if err != nil {
+			log.Printf("Payment scheduler failed to load payment %d of execution %d: %v", *execution.PaymentID, execution.ID, err)
+			continue
+		}
+
+		switch payment.Status {
+		case models.PaymentStatusCompleted, models.PaymentStatusConfirmed:
+			finishScheduleExecution(ctx, execution, true, false, "")
+		case models.PaymentStatusFailed:
+			reason := "payment failed"
+			if payment.FailureReason != nil {
+				reason = *payment.FailureReason
+			}
+			finishScheduleExecution(ctx, execution, false, models.IsInsufficientFunds(reason), reason)
+		case models.PaymentStatusRefunded:
+			finishScheduleExecution(ctx, execution, false, false, "refunded: the payee's provider did not accept the payment")
+		}
+	}
+}
+
+func finishScheduleExecution(ctx context.Context, execution *models.ScheduleExecution, succeeded, retryable bool, reason string) {
+	status := models.ExecutionStatusSucceeded
+	var failureReason *string
+	if !succeeded {
+		status = models.ExecutionStatusFailed
+		failureReason = &reason
+	}
+
+	now := time.Now()
+	var retry bool
+	_, err := scheduleRepo.FinishExecution(ctx, execution.ID, status, failureReason, func(s *models.PaymentSchedule) {
+		retry = s.AfterExecution(now, succeeded, retryable)
+	})
+	if errors.Is(err, repository.ErrExecutionSettled) {
+		return
+	} else if err != nil {
+		log.Printf("Payment scheduler failed to finish execution %d: %v", execution.ID, err)
+		return
+	}
+
+	switch {
+	case succeeded:
+		log.Printf("Schedule %d paid its %s occurrence", execution.ScheduleID, execution.OccurrenceAt.Format(models.DateLayout))
+	case retry:
+		log.Printf("Schedule %d attempt %d failed (%s); will retry", execution.ScheduleID, execution.Attempt, reason)
+	default:
+		log.Printf("Schedule %d missed its %s occurrence: %s", execution.ScheduleID, execution.OccurrenceAt.Format(models.DateLayout), reason)
+	}
+}
+
+// runDueSchedules creates and dispatches a payment for every schedule that is due
+func runDueSchedules(ctx context.Context) {
+	now := time.Now()
+	due, err := scheduleRepo.ListDue(ctx, now, scheduleBatchSize)
+	if err != nil {
+		log.Printf("Payment scheduler failed to list due schedules: %v", err)
+		return
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		runSchedule(ctx, &due[i], now)
+	}
+}
+
+// runSchedule pays the current occurrence of a schedule with an ordinary payment
+func runSchedule(ctx context.Context, s *models.PaymentSchedule, now time.Time) {
+	execution, err := scheduleRepo.StartExecution(ctx, s.ID, now)
+	if errors.Is(err, repository.ErrScheduleNotDue) {
+		return
+	} else if err != nil {
+		log.Printf("Payment scheduler failed to start schedule %d: %v", s.ID, err)
+		return
+	}
+
+	req, retryable, err := prepareScheduledPayment(ctx, s)
+	if err != nil {
+		finishScheduleExecution(ctx, execution, false, retryable, err.Error())
+		return
+	}
+
+	payment, err := paymentRepo.Create(ctx, s.UserID, req)
+	if err != nil {
+		log.Printf("Payment scheduler failed to create payment for schedule %d: %v", s.ID, err)
+		finishScheduleExecution(ctx, execution, false, true, "failed to create payment")
+		return
+	}
+	// An execution's outcome is followed through its payment, so an unlinked payment is never sent
+	if err := scheduleRepo.AttachPayment(ctx, execution.ID, payment.ID); err != nil {
+		log.Printf("Payment scheduler failed to link payment %d to execution %d: %v", payment.ID, execution.ID, err)
+		if _, err := paymentRepo.MarkAsFailed(ctx, payment.ID, "failed to link payment to its schedule", models.TransitionCauseScheduleLink); err != nil {
+			log.Printf("Failed to mark payment %d as failed: %v", payment.ID, err)
+		}
+		finishScheduleExecution(ctx, execution, false, true, "failed to link payment")
+		return
+	}
+
+	if processing, err := paymentRepo.MarkAsProcessing(ctx, payment.ID); err != nil {
+		log.Printf("Failed to mark payment %d as processing: %v", payment.ID, err)
+	} else {
+		payment = processing
+	}
+
+	// publishPayment fails a payment it cannot publish; that is no fault of the schedule's, so retry it
+	if _, err := publishPayment(ctx, payment); err != nil {
+		finishScheduleExecution(ctx, execution, false, true, "failed to dispatch payment")
+		return
+	}
+	log.Printf("Schedule %d dispatched payment %d (attempt %d)", s.ID, payment.ID, execution.Attempt)
+}
+
+// prepareScheduledPayment checks a schedule's payee against its biller or mobile operator as they
+// are now and returns the payment to make. It reports whether a failure may pass on a retry.
+func prepareScheduledPayment(ctx context.Context, s *models.PaymentSchedule) (*models.CreatePaymentRequest, bool, error) {
+	req := s.PaymentRequest()
+
+	switch s.PaymentType {
+	case models.PaymentTypeBill:
+		biller, err := billerRepo.GetByID(ctx, *s.BillerID)
+		if errors.Is(err, repository.ErrBillerNotFound) {
+			return nil, false, errors.New("biller no longer exists")
+		} else if err != nil {
+			return nil, true, errors.New("failed to look up biller")
+		}
+		if err := biller.ValidatePayment(*s.RecipientAccount, s.Amount, s.Currency); err != nil {
+			return nil, false, err
+		}
+		req.RecipientName = &biller.Name
+		req.SettlementAccountID = &biller.SettlementAccountID
+		req.Category = models.CategoryForBiller(biller.Category)
+
+	case models.PaymentTypeMobile:
+		operators, err := mobileOperatorRepo.List(ctx, true)
+		if err != nil {
+			return nil, true, errors.New("failed to look up mobile operators")
+		}
+		var op *models.MobileOperator
+		for i := range operators {
+			if strings.EqualFold(operators[i].Name, *s.RecipientName) {
+				op = &operators[i]
+			}
+		}
+		if op == nil {
+			return nil, false, fmt.Errorf("mobile operator %s is no longer available", *s.RecipientName)
+		}
+		if err := op.ValidateNumber(*s.RecipientAccount); err != nil {
+			return nil, false, err
+		}
+		if err := op.ValidateAmount(s.Amount); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return req, false, nil
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payment/models"
+	"payment/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// listPaymentTemplates lists the caller's saved payment templates
+func listPaymentTemplates(c *gin.Context) {
+	userID, _, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := templateRepo.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payment templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getPaymentTemplate returns one of the caller's payment templates
+func getPaymentTemplate(c *gin.Context) {
+	t, ok := loadPaymentTemplate(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// createPaymentTemplate saves a payment the caller can make again. What the template names is
+// validated now as for a payment, and again each time a payment is made from it.
+func createPaymentTemplate(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreatePaymentTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t := models.PaymentTemplate{
+		UserID:           userID,
+		Name:             strings.TrimSpace(req.Name),
+		PaymentType:      req.PaymentType,
+		AccountID:        req.AccountID,
+		BeneficiaryID:    req.BeneficiaryID,
+		BillerID:         req.BillerID,
+		MerchantID:       req.MerchantID,
+		RecipientName:    req.RecipientName,
+		RecipientAccount: req.RecipientAccount,
+		RecipientBank:    req.RecipientBank,
+		Amount:           req.Amount,
+		Currency:         req.Currency,
+		Description:      req.Description,
+	}
+	if !validateTemplate(c, userID, role, &t) {
+		return
+	}
+
+	created, err := templateRepo.Create(c.Request.Context(), &t)
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// updatePaymentTemplate changes one of the caller's payment templates
+func updatePaymentTemplate(c *gin.Context) {
+	existing, ok := loadPaymentTemplate(c)
+	if !ok {
+		return
+	}
+	_, role, _ := getUserContext(c)
+
+	var req models.UpdatePaymentTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t := req.Apply(*existing)
+	t.Name = strings.TrimSpace(t.Name)
+	if !validateTemplate(c, t.UserID, role, &t) {
+		return
+	}
+
+	updated, err := templateRepo.Update(c.Request.Context(), &t)
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// deletePaymentTemplate removes one of the caller's payment templates
+func deletePaymentTemplate(c *gin.Context) {
+	t, ok := loadPaymentTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := templateRepo.Delete(c.Request.Context(), t.ID); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "payment template deleted"})
+}
+
+// validateTemplate checks a template against the rules a payment made from it must meet. Fields
+// a template leaves out are checked when the payment supplies them instead.
+func validateTemplate(c *gin.Context, userID int64, role string, t *models.PaymentTemplate) bool {
+	if t.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+		return false
+	}
+	if t.Amount != nil && (t.Amount.LessThanOrEqual(decimal.Zero) || t.Amount.Exponent() < -2) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive with at most 2 decimal places"})
+		return false
+	}
+	if t.Currency != nil {
+		currency := strings.ToUpper(*t.Currency)
+		t.Currency = &currency
+	}
+	if t.BillerID != nil && t.PaymentType != models.PaymentTypeBill {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "biller_id is only supported for bill payments"})
+		return false
+	}
+	if t.BeneficiaryID != nil && t.PaymentType != models.PaymentTypeExternal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "beneficiary_id is only supported for external payments"})
+		return false
+	}
+	if t.MerchantID != nil && t.PaymentType != models.PaymentTypeMerchant {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "merchant_id is only supported for merchant payments"})
+		return false
+	}
+
+	if t.AccountID != nil {
+		account, status, err := getAccountByID(*t.AccountID)
+		if err != nil {
+			if status == http.StatusNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+				return false
+			}
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to look up account"})
+			return false
+		}
+		if account.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return false
+		}
+	}
+
+	switch t.PaymentType {
+	case models.PaymentTypeBill:
+		return validateBillTemplate(c, t)
+	case models.PaymentTypeMobile:
+		return validateMobileTemplate(c, t)
+	case models.PaymentTypeMerchant:
+		return validateMerchantTemplate(c, t)
+	case models.PaymentTypeExternal:
+		if t.BeneficiaryID != nil {
+			if _, status, err := getBeneficiary(*t.BeneficiaryID, userID, role); err != nil {
+				log.Printf("Failed to look up beneficiary %d: %v", *t.BeneficiaryID, err)
+				switch status {
+				case http.StatusForbidden:
+					c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+				case http.StatusNotFound:
+					c.JSON(http.StatusNotFound, gin.H{"error": "beneficiary not found"})
+				default:
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up beneficiary"})
+				}
+				return false
+			}
+		} else if t.RecipientAccount == nil || *t.RecipientAccount == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_account or beneficiary_id required for external transfers"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateBillTemplate checks a bill template's customer reference, and its amount if it has
+// one, against the biller
+func validateBillTemplate(c *gin.Context, t *models.PaymentTemplate) bool {
+	if t.BillerID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "biller_id required for bill payments"})
+		return false
+	}
+	if t.RecipientAccount == nil || strings.TrimSpace(*t.RecipientAccount) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_account (customer reference) required for bill payments"})
+		return false
+	}
+
+	biller, err := billerRepo.GetByID(c.Request.Context(), *t.BillerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBillerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "biller not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up biller"})
+		return false
+	}
+
+	reference := strings.TrimSpace(*t.RecipientAccount)
+	currency := biller.Currency
+	if t.Currency != nil {
+		currency = *t.Currency
+	}
+	// A template without a default amount is checked with an amount the biller accepts, so only
+	// the reference, currency and biller status can fail
+	amount := decimal.NewFromInt(1)
+	switch {
+	case t.Amount != nil:
+		amount = *t.Amount
+	case biller.MinAmount != nil:
+		amount = *biller.MinAmount
+	case biller.MaxAmount != nil && biller.MaxAmount.LessThan(amount):
+		amount = *biller.MaxAmount
+	}
+	if err := biller.ValidatePayment(reference, amount, currency); err != nil {
+		if errors.Is(err, models.ErrBillerUnavailable) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	t.RecipientAccount = &reference
+	t.RecipientName = &biller.Name
+	t.RecipientBank = nil
+	return true
+}
+
+// validateMerchantTemplate checks that a merchant template names an acquired merchant that is
+// accepting payments, or else the merchant's name for the provider
+func validateMerchantTemplate(c *gin.Context, t *models.PaymentTemplate) bool {
+	if t.MerchantID == nil {
+		if t.RecipientName == nil || *t.RecipientName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_name or merchant_id required for merchant payments"})
+			return false
+		}
+		return true
+	}
+
+	merchant, err := merchantRepo.GetByID(c.Request.Context(), *t.MerchantID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMerchantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "merchant not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up merchant"})
+		return false
+	}
+	if !merchant.Active {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": models.ErrMerchantUnavailable.Error()})
+		return false
+	}
+	if t.Currency != nil && *t.Currency != merchant.Currency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s only accepts %s", models.ErrMerchantCurrency, merchant.Name, merchant.Currency)})
+		return false
+	}
+
+	t.RecipientName = &merchant.Name
+	t.RecipientAccount = &merchant.Code
+	t.RecipientBank = nil
+	return true
+}
+
+// validateMobileTemplate checks a top-up template's number, and its amount if it has one,
+// against the operator
+func validateMobileTemplate(c *gin.Context, t *models.PaymentTemplate) bool {
+	if t.RecipientName == nil || *t.RecipientName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_name (operator) required for mobile payments"})
+		return false
+	}
+	if t.RecipientAccount == nil || *t.RecipientAccount == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "recipient_account (phone number) required for mobile payments"})
+		return false
+	}
+
+	op, ok := findMobileOperator(c, *t.RecipientName)
+	if !ok {
+		return false
+	}
+	if err := op.ValidateNumber(*t.RecipientAccount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if t.Amount != nil {
+		if err := op.ValidateAmount(*t.Amount); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+	}
+
+	t.RecipientName = &op.Name
+	return true
+}
+
+// applyTemplate fills a payment request from the saved template it names. The payment is then
+// validated like any other, so a template is checked against the current biller and operator
+// rules each time it is used.
+func applyTemplate(c *gin.Context, userID int64, req *models.CreatePaymentRequest) bool {
+	if req.TemplateID == nil {
+		return true
+	}
+
+	t, err := templateRepo.GetByID(c.Request.Context(), *req.TemplateID)
+	if err != nil {
+		respondTemplateError(c, err)
+		return false
+	}
+	if t.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return false
+	}
+
+	if err := t.Fill(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func loadPaymentTemplate(c *gin.Context) (*models.PaymentTemplate, bool) {
+	userID, _, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	templateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template ID"})
+		return nil, false
+	}
+
+	t, err := templateRepo.GetByID(c.Request.Context(), templateID)
+	if err != nil {
+		respondTemplateError(c, err)
+		return nil, false
+	}
+	if t.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return nil, false
+	}
+
+	return t, true
+}
+
+func respondTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment template not found"})
+	case errors.Is(err, repository.ErrTemplateNameExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "a payment template with this name already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save payment template"})
+	}
+}
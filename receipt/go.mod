@@ -0,0 +1,5 @@
+module receipt
+
+go 1.23
+
+require github.com/shopspring/decimal v1.3.1
@@ -0,0 +1,117 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Page layout in points on an A4 page
+const (
+	pageWidth   = 595
+	pageHeight  = 842
+	marginLeft  = 56
+	valueColumn = 200
+	lineHeight  = 20
+)
+
+// PDF renders the receipt as a single-page PDF document headed by title
+func (r *Receipt) PDF(title string, generatedAt time.Time) []byte {
+	var content strings.Builder
+	y := pageHeight - 80
+
+	text(&content, "F2", 18, marginLeft, y, title)
+	y -= 2 * lineHeight
+
+	completed := "-"
+	if r.CompletedAt != nil {
+		completed = r.CompletedAt.Format(time.RFC1123)
+	}
+	rows := [][2]string{
+		{"Reference", r.ReferenceID},
+		{"Type", r.Type},
+		{"Status", strings.ToUpper(r.Status)},
+		{"From account", r.FromAccount},
+		{"To account", r.ToAccount},
+		{"Recipient", r.Recipient},
+		{"Description", r.Description},
+		{"Amount", r.Amount + " " + r.Currency},
+		{"Fee", r.Fee + " " + r.Currency},
+		{"Total debited", r.Total + " " + r.Currency},
+		{"Created", r.CreatedAt.Format(time.RFC1123)},
+		{"Completed", completed},
+	}
+	for _, row := range rows {
+		if row[1] == "" {
+			continue
+		}
+		text(&content, "F2", 11, marginLeft, y, row[0])
+		text(&content, "F1", 11, valueColumn, y, row[1])
+		y -= lineHeight
+	}
+
+	y -= lineHeight
+	text(&content, "F2", 11, marginLeft, y, "Verification hash")
+	y -= lineHeight
+	text(&content, "F3", 9, marginLeft, y, r.Hash)
+	y -= lineHeight
+	text(&content, "F1", 9, marginLeft, y, "Check this receipt with the bank using its reference and verification hash.")
+
+	text(&content, "F1", 8, marginLeft, 40, "Generated "+generatedAt.UTC().Format(time.RFC1123))
+
+	return document(content.String())
+}
+
+// text writes one line of text at (x, y)
+func text(b *strings.Builder, font string, size, x, y int, s string) {
+	fmt.Fprintf(b, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
+
+// escape makes s safe inside a PDF string literal; characters outside printable ASCII are
+// replaced since the standard fonts are used without embedding
+func escape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '\\' || c == '(' || c == ')':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c < 0x20 || c > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// document wraps a page content stream in a minimal PDF file
+func document(content string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R /F3 6 0 R >> >> /Contents 7 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
@@ -0,0 +1,160 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var testKey = []byte("test-receipt-key")
+
+func testReceipt() *Receipt {
+	r := &Receipt{
+		Kind:        "payment",
+		ReferenceID: "7d1f0c1e-2f7c-4a3b-9b52-0c6f4f1f5e10",
+		Type:        "merchant",
+		Status:      "completed",
+		FromAccount: MaskAccount("ACC0012345678"),
+		Recipient:   "Corner Shop (Café)",
+		Currency:    "USD",
+	}
+	r.SetAmounts(decimal.RequireFromString("12.5"), decimal.RequireFromString("0.25"))
+	completed := time.Date(2024, 3, 1, 12, 0, 5, 999, time.FixedZone("CET", 3600))
+	r.SetTimes(time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC), &completed)
+	return r
+}
+
+func testTransferReceipt() *Receipt {
+	r := &Receipt{
+		Kind:        "transfer",
+		ReferenceID: "0b8e6f3a-91c4-4d2e-8a7f-5c3d2e1f0a9b",
+		Type:        "web",
+		Status:      "completed",
+		FromAccount: MaskAccount("ACC0012345678"),
+		ToAccount:   MaskAccount("ACC0087654321"),
+		Description: "Rent (March) – flat 2",
+		Currency:    "USD",
+	}
+	r.SetAmounts(decimal.RequireFromString("12.5"), decimal.RequireFromString("0.25"))
+	r.SetTimes(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), nil)
+	return r
+}
+
+func TestSignVerify(t *testing.T) {
+	r := testReceipt()
+	r.Sign(testKey)
+
+	if len(r.Hash) != 64 {
+		t.Fatalf("Sign() hash = %q, want 64 hex characters", r.Hash)
+	}
+	if !r.Verify(testKey, r.Hash) {
+		t.Error("Verify() = false for the receipt's own hash")
+	}
+	if r.Verify([]byte("other-key"), r.Hash) {
+		t.Error("Verify() = true with a different key")
+	}
+
+	// Rebuilding the receipt from the same record gives the same hash
+	rebuilt := testReceipt()
+	if !rebuilt.Verify(testKey, r.Hash) {
+		t.Error("Verify() = false for a receipt rebuilt from the same record")
+	}
+
+	// A receipt issued while processing still verifies once the status has moved on
+	settled := testReceipt()
+	settled.Status = "refunded"
+	settled.CompletedAt = nil
+	if !settled.Verify(testKey, r.Hash) {
+		t.Error("Verify() = false for a receipt whose status has changed since")
+	}
+
+	tampered := testReceipt()
+	tampered.Amount = "125.00"
+	if tampered.Verify(testKey, r.Hash) {
+		t.Error("Verify() = true for a receipt with a changed amount")
+	}
+}
+
+func TestSetAmountsAndTimes(t *testing.T) {
+	r := testReceipt()
+	if r.Amount != "12.50" || r.Fee != "0.25" || r.Total != "12.75" {
+		t.Errorf("SetAmounts() amount %s fee %s total %s, want 12.50, 0.25 and 12.75", r.Amount, r.Fee, r.Total)
+	}
+	if r.CreatedAt.Nanosecond() != 0 || r.CompletedAt.Location() != time.UTC || r.CompletedAt.Hour() != 11 {
+		t.Errorf("SetTimes() created %v completed %v, want whole seconds in UTC", r.CreatedAt, r.CompletedAt)
+	}
+}
+
+func TestMaskAccount(t *testing.T) {
+	tests := []struct {
+		account string
+		want    string
+	}{
+		{account: "ACC0012345678", want: "****5678"},
+		{account: "DE89 3704 0044 0532 0130 00", want: "****3000"},
+		{account: "1234", want: "****"},
+		{account: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := MaskAccount(tt.account); got != tt.want {
+			t.Errorf("MaskAccount(%q) = %q, want %q", tt.account, got, tt.want)
+		}
+	}
+}
+
+func TestPDF(t *testing.T) {
+	tests := []struct {
+		title   string
+		receipt *Receipt
+		want    string // text that is escaped or has non-Latin-1 characters replaced
+	}{
+		{title: "Payment receipt", receipt: testReceipt(), want: `Corner Shop \(Caf?\)`},
+		{title: "Transfer receipt", receipt: testTransferReceipt(), want: `Rent \(March\) ? flat 2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			testPDF(t, tt.title, tt.receipt, tt.want)
+		})
+	}
+}
+
+func testPDF(t *testing.T, title string, r *Receipt, text string) {
+	r.Sign(testKey)
+	doc := r.PDF(title, time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC))
+
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(doc, []byte("%%EOF\n")) {
+		t.Fatal("PDF() is not framed as a PDF document")
+	}
+	for _, want := range []string{title, r.ReferenceID, r.Hash, "12.75 USD", text} {
+		if !bytes.Contains(doc, []byte(want)) {
+			t.Errorf("PDF() does not contain %q", want)
+		}
+	}
+
+	// startxref must point at the cross-reference table, whose entries point at each object
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(doc)
+	if match == nil {
+		t.Fatal("PDF() has no startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(doc[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(doc[xref:], -1)
+	if len(entries) != 7 {
+		t.Fatalf("xref has %d objects, want 7", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(doc[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, doc[offset:offset+len(want)], want)
+		}
+	}
+}
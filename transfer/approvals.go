@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requiresApproval reports whether a new transfer is held for four-eyes review instead of being
+// dispatched: transfers above the approval threshold are; one at the threshold is not
+func requiresApproval(transfer *models.Transfer) bool {
+	return transfer.Amount.GreaterThan(approvalThreshold)
+}
+
+// listPendingApprovals returns transfers waiting for four-eyes review (admin only)
+func listPendingApprovals(c *gin.Context) {
+	if _, ok := requireAdmin(c); !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	result, err := transferRepo.ListByStatus(c.Request.Context(), models.TransferStatusPendingApproval, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list pending approvals: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list pending approvals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// approveTransfer releases a held transfer to the account service (admin only)
+func approveTransfer(c *gin.Context) {
+	adminID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	transferID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer ID"})
+		return
+	}
+
+	transfer, err := transferRepo.Review(c.Request.Context(), transferID, adminID, true, nil)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	log.Printf("Transfer %d approved by admin %d", transfer.ID, adminID)
+
+	// Publish event to Kafka
+	if _, err := publishTransfer(c.Request.Context(), transfer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate transfer"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "transfer approved",
+		"transfer_id":  transfer.ID,
+		"reference_id": transfer.ReferenceID,
+		"status":       transfer.Status,
+	})
+}
+
+// rejectTransfer closes a held transfer without moving any funds (admin only)
+func rejectTransfer(c *gin.Context) {
+	adminID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	transferID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer ID"})
+		return
+	}
+
+	var req models.RejectTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := transferRepo.Review(c.Request.Context(), transferID, adminID, false, &req.Reason)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	log.Printf("Transfer %d rejected by admin %d: %s", transfer.ID, adminID, req.Reason)
+	c.JSON(http.StatusOK, transfer)
+}
+
+func respondReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrTransferNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+	case errors.Is(err, repository.ErrNotPendingReview):
+		c.JSON(http.StatusConflict, gin.H{"error": "transfer is not pending approval"})
+	case errors.Is(err, repository.ErrSelfApproval):
+		c.JSON(http.StatusForbidden, gin.H{"error": "approver must differ from the initiator"})
+	default:
+		log.Printf("Failed to review transfer: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to review transfer"})
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"transfer/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRequiresApproval(t *testing.T) {
+	approvalThreshold = decimal.NewFromInt(10000)
+
+	tests := []struct {
+		amount string
+		want   bool
+	}{
+		{amount: "9999.99", want: false},
+		{amount: "10000", want: false},
+		{amount: "10000.01", want: true},
+		{amount: "250000", want: true},
+	}
+
+	for _, tt := range tests {
+		transfer := &models.Transfer{Amount: decimal.RequireFromString(tt.amount)}
+		if got := requiresApproval(transfer); got != tt.want {
+			t.Errorf("requiresApproval(%s) = %v, want %v", tt.amount, got, tt.want)
+		}
+	}
+}
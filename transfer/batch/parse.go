@@ -0,0 +1,222 @@
+package batch
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"transfer/models"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrEmptyBatch    = errors.New("batch contains no instructions")
+	ErrTooManyItems  = fmt.Errorf("batch exceeds the maximum of %d instructions", models.MaxBatchItems)
+	ErrMissingColumn = errors.New("csv header must contain to_account_id and amount columns")
+)
+
+// ParseCSV parses transfer instructions from CSV. The header row is optional; without it
+// columns are read positionally as to_account_id, amount, reference.
+// Lines that cannot be parsed are reported as line errors rather than failing the whole file.
+func ParseCSV(r io.Reader) ([]models.BatchInstruction, []models.BatchLineError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, ErrEmptyBatch
+	}
+
+	toCol, amountCol, refCol := 0, 1, 2
+	start := 0
+	if isHeader(records[0]) {
+		toCol, amountCol, refCol = -1, -1, -1
+		for i, name := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "to_account_id":
+				toCol = i
+			case "amount":
+				amountCol = i
+			case "reference":
+				refCol = i
+			}
+		}
+		if toCol < 0 || amountCol < 0 {
+			return nil, nil, ErrMissingColumn
+		}
+		start = 1
+	}
+
+	if len(records)-start > models.MaxBatchItems {
+		return nil, nil, ErrTooManyItems
+	}
+
+	items := []models.BatchInstruction{}
+	lineErrors := []models.BatchLineError{}
+	for i := start; i < len(records); i++ {
+		record := records[i]
+		line := i + 1
+
+		if isBlank(record) {
+			continue
+		}
+
+		if len(record) <= toCol || len(record) <= amountCol {
+			lineErrors = append(lineErrors, models.BatchLineError{Line: line, Error: "missing columns"})
+			continue
+		}
+
+		toAccountID, err := strconv.ParseInt(strings.TrimSpace(record[toCol]), 10, 64)
+		if err != nil {
+			lineErrors = append(lineErrors, models.BatchLineError{Line: line, Field: "to_account_id", Error: "must be an integer"})
+			continue
+		}
+
+		amount, err := decimal.NewFromString(strings.TrimSpace(record[amountCol]))
+		if err != nil {
+			lineErrors = append(lineErrors, models.BatchLineError{Line: line, Field: "amount", Error: "must be a decimal number"})
+			continue
+		}
+
+		reference := ""
+		if refCol >= 0 && len(record) > refCol {
+			reference = strings.TrimSpace(record[refCol])
+		}
+
+		items = append(items, models.BatchInstruction{
+			LineNumber:  line,
+			ToAccountID: toAccountID,
+			Amount:      amount,
+			Reference:   reference,
+		})
+	}
+
+	if len(items) == 0 && len(lineErrors) == 0 {
+		return nil, nil, ErrEmptyBatch
+	}
+
+	return items, lineErrors, nil
+}
+
+// ParseJSON parses transfer instructions from a JSON array or an object with an "items" array.
+// Line numbers default to the 1-based position in the array.
+func ParseJSON(data []byte) ([]models.BatchInstruction, error) {
+	var items []models.BatchInstruction
+	if err := json.Unmarshal(data, &items); err != nil {
+		var wrapped struct {
+			Items []models.BatchInstruction `json:"items"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("invalid json: %w", err)
+		}
+		items = wrapped.Items
+	}
+
+	if len(items) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(items) > models.MaxBatchItems {
+		return nil, ErrTooManyItems
+	}
+
+	NumberLines(items)
+	return items, nil
+}
+
+// NumberLines assigns 1-based line numbers to instructions that don't have one
+func NumberLines(items []models.BatchInstruction) {
+	for i := range items {
+		if items[i].LineNumber == 0 {
+			items[i].LineNumber = i + 1
+		}
+	}
+}
+
+// Validate checks every instruction and reports all problems, including duplicate lines
+func Validate(fromAccountID int64, items []models.BatchInstruction) []models.BatchLineError {
+	lineErrors := []models.BatchLineError{}
+	seen := make(map[string]int, len(items))
+
+	for _, item := range items {
+		switch {
+		case item.ToAccountID <= 0:
+			lineErrors = append(lineErrors, models.BatchLineError{Line: item.LineNumber, Field: "to_account_id", Error: "must be positive"})
+			continue
+		case item.ToAccountID == fromAccountID:
+			lineErrors = append(lineErrors, models.BatchLineError{Line: item.LineNumber, Field: "to_account_id", Error: "cannot be the source account"})
+			continue
+		case item.Amount.LessThanOrEqual(decimal.Zero):
+			lineErrors = append(lineErrors, models.BatchLineError{Line: item.LineNumber, Field: "amount", Error: "must be positive"})
+			continue
+		case !item.Amount.Equal(item.Amount.Round(2)):
+			lineErrors = append(lineErrors, models.BatchLineError{Line: item.LineNumber, Field: "amount", Error: "must have at most 2 decimal places"})
+			continue
+		case len(item.Reference) > 140:
+			lineErrors = append(lineErrors, models.BatchLineError{Line: item.LineNumber, Field: "reference", Error: "must be at most 140 characters"})
+			continue
+		}
+
+		key := dedupeKey(item)
+		if first, ok := seen[key]; ok {
+			lineErrors = append(lineErrors, models.BatchLineError{
+				Line:  item.LineNumber,
+				Error: fmt.Sprintf("duplicate of line %d", first),
+			})
+			continue
+		}
+		seen[key] = item.LineNumber
+	}
+
+	return lineErrors
+}
+
+// Total returns the sum of all instruction amounts
+func Total(items []models.BatchInstruction) decimal.Decimal {
+	total := decimal.Zero
+	for _, item := range items {
+		total = total.Add(item.Amount)
+	}
+	return total
+}
+
+// Checksum fingerprints a batch so that re-uploads of the same instructions can be detected
+func Checksum(fromAccountID int64, currency string, items []models.BatchInstruction) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d|%s\n", fromAccountID, currency)
+	for _, item := range items {
+		fmt.Fprintf(h, "%s\n", dedupeKey(item))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func dedupeKey(item models.BatchInstruction) string {
+	return fmt.Sprintf("%d|%s|%s", item.ToAccountID, item.Amount.StringFixed(2), item.Reference)
+}
+
+func isHeader(record []string) bool {
+	if len(record) == 0 {
+		return false
+	}
+	_, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+	return err != nil
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
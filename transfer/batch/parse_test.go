@@ -0,0 +1,111 @@
+package batch
+
+import (
+	"strings"
+	"testing"
+
+	"transfer/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantItems      int
+		wantLineErrors int
+		wantErr        bool
+	}{
+		{
+			name:      "positional columns",
+			input:     "2,100.00,salary\n3,250.50,salary\n",
+			wantItems: 2,
+		},
+		{
+			name:      "header with reordered columns",
+			input:     "amount,reference,to_account_id\n100,jan,2\n200,jan,3\n",
+			wantItems: 2,
+		},
+		{
+			name:           "unparseable lines are reported",
+			input:          "2,100\nabc,100\n3,xyz\n",
+			wantItems:      1,
+			wantLineErrors: 2,
+		},
+		{
+			name:    "header missing amount",
+			input:   "to_account_id,reference\n2,jan\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty file",
+			input:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, lineErrors, err := ParseCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(items) != tt.wantItems {
+				t.Errorf("items = %d, want %d", len(items), tt.wantItems)
+			}
+			if len(lineErrors) != tt.wantLineErrors {
+				t.Errorf("line errors = %d, want %d", len(lineErrors), tt.wantLineErrors)
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	items, err := ParseJSON([]byte(`{"items":[{"to_account_id":2,"amount":"10"},{"to_account_id":3,"amount":"20"}]}`))
+	if err != nil {
+		t.Fatalf("ParseJSON() error = %v", err)
+	}
+	if len(items) != 2 || items[1].LineNumber != 2 {
+		t.Errorf("unexpected items: %+v", items)
+	}
+
+	if _, err := ParseJSON([]byte(`[]`)); err == nil {
+		t.Error("expected error for empty batch")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	items := []models.BatchInstruction{
+		{LineNumber: 1, ToAccountID: 2, Amount: decimal.NewFromInt(100), Reference: "jan"},
+		{LineNumber: 2, ToAccountID: 1, Amount: decimal.NewFromInt(100)},
+		{LineNumber: 3, ToAccountID: 3, Amount: decimal.Zero},
+		{LineNumber: 4, ToAccountID: 4, Amount: decimal.RequireFromString("1.005")},
+		{LineNumber: 5, ToAccountID: 2, Amount: decimal.RequireFromString("100.00"), Reference: "jan"},
+		{LineNumber: 6, ToAccountID: 2, Amount: decimal.NewFromInt(100), Reference: "feb"},
+	}
+
+	lineErrors := Validate(1, items)
+
+	wantLines := []int{2, 3, 4, 5}
+	if len(lineErrors) != len(wantLines) {
+		t.Fatalf("line errors = %+v, want lines %v", lineErrors, wantLines)
+	}
+	for i, line := range wantLines {
+		if lineErrors[i].Line != line {
+			t.Errorf("error %d on line %d, want line %d", i, lineErrors[i].Line, line)
+		}
+	}
+}
+
+func TestChecksum_IgnoresAmountFormatting(t *testing.T) {
+	a := []models.BatchInstruction{{ToAccountID: 2, Amount: decimal.RequireFromString("100")}}
+	b := []models.BatchInstruction{{ToAccountID: 2, Amount: decimal.RequireFromString("100.00")}}
+
+	if Checksum(1, "USD", a) != Checksum(1, "USD", b) {
+		t.Error("checksums should match for equal amounts")
+	}
+	if Checksum(1, "USD", a) == Checksum(1, "EUR", a) {
+		t.Error("checksums should differ by currency")
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"transfer/batch"
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// maxBatchUploadBytes caps the size of a batch upload body
+	maxBatchUploadBytes = 5 << 20
+
+	// batchDuplicateWindow is how far back identical batches are treated as accidental re-uploads
+	batchDuplicateWindow = 24 * time.Hour
+
+	// batchRetryInterval is how often batches left processing after a failure are resumed
+	batchRetryInterval = time.Minute
+
+	// batchRetryWindow is how long after it was accepted a failing batch is retried before it is stopped
+	batchRetryWindow = 24 * time.Hour
+)
+
+// runningBatches holds the IDs of batches being run by this instance, so the retry sweep does not
+// start a second run alongside one still in progress
+var runningBatches sync.Map
+
+// createBatch accepts a CSV or JSON file of transfer instructions from one source account.
+// Multipart uploads carry the file in "file" with from_account_id and currency form fields;
+// a JSON body may be sent directly as a CreateBatchRequest. The currency defaults to the source
+// account's and must match it.
+func createBatch(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchUploadBytes)
+
+	var (
+		req        models.CreateBatchRequest
+		format     string
+		lineErrors []models.BatchLineError
+	)
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		format, lineErrors, err = bindBatchUpload(c, &req)
+	} else {
+		format = models.BatchFormatJSON
+		if err = c.ShouldBindJSON(&req); err == nil {
+			if len(req.Items) == 0 {
+				err = batch.ErrEmptyBatch
+			} else if len(req.Items) > models.MaxBatchItems {
+				err = batch.ErrTooManyItems
+			}
+			batch.NumberLines(req.Items)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lineErrors = append(lineErrors, batch.Validate(req.FromAccountID, req.Items)...)
+	if len(lineErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":       "batch contains invalid lines",
+			"line_errors": lineErrors,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	total := batch.Total(req.Items)
+
+	// The whole batch, with the fee charged on every line, must be covered by the source
+	// account's balance up front
+	source, status, err := getAccountSummary(req.FromAccountID, userID, role)
+	if err != nil {
+		log.Printf("Failed to get balance for account %d: %v", req.FromAccountID, err)
+		switch status {
+		case http.StatusForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case http.StatusNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "source account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify source account balance"})
+		}
+		return
+	}
+
+	req.Currency = strings.ToUpper(req.Currency)
+	if req.Currency == "" {
+		req.Currency = source.Currency
+	} else if req.Currency != source.Currency {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "batch currency does not match the source account"})
+		return
+	}
+
+	fees, err := batchFees(ctx, req.Currency, source, req.Items)
+	if errors.Is(err, errCurrencyMismatch) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to price batch from account %d: %v", req.FromAccountID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to quote batch fees"})
+		return
+	}
+	if source.Balance.LessThan(total.Add(fees)) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "insufficient funds for batch total",
+			"total_amount": total,
+			"total_fees":   fees,
+			"balance":      source.Balance,
+		})
+		return
+	}
+
+	checksum := batch.Checksum(req.FromAccountID, req.Currency, req.Items)
+	if c.Query("allow_duplicate") != "true" {
+		existing, err := batchRepo.FindByChecksum(ctx, req.FromAccountID, checksum, time.Now().Add(-batchDuplicateWindow))
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "an identical batch was submitted in the last 24 hours",
+				"batch_id": existing.ID,
+			})
+			return
+		}
+		if !errors.Is(err, repository.ErrBatchNotFound) {
+			log.Printf("Failed to check for duplicate batch: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create batch"})
+			return
+		}
+	}
+
+	limits, err := effectiveLimits(ctx, userID)
+	if err != nil {
+		respondLimitError(c, err)
+		return
+	}
+
+	created, err := batchRepo.Create(ctx, &models.TransferBatch{
+		FromAccountID: req.FromAccountID,
+		Currency:      req.Currency,
+		InitiatedBy:   userID,
+		TotalAmount:   total,
+		Checksum:      checksum,
+		SourceFormat:  format,
+	}, req.Items, limits)
+	if err != nil {
+		if repository.IsLimitError(err) {
+			respondLimitError(c, err)
+			return
+		}
+		log.Printf("Failed to create batch: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create batch"})
+		return
+	}
+
+	log.Printf("Batch %d created by user %d: %d items totalling %s", created.ID, userID, created.TotalItems, created.TotalAmount)
+
+	// Child transfers are created in the background; the batch outlives this request
+	go runBatch(serviceCtx, created)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "batch accepted",
+		"batch_id":     created.ID,
+		"reference_id": created.ReferenceID,
+		"total_items":  created.TotalItems,
+		"total_amount": created.TotalAmount,
+		"status":       created.Status,
+	})
+}
+
+// bindBatchUpload reads a multipart batch upload, choosing the parser by file extension or content type
+func bindBatchUpload(c *gin.Context, req *models.CreateBatchRequest) (string, []models.BatchLineError, error) {
+	fromAccountID, err := strconv.ParseInt(c.PostForm("from_account_id"), 10, 64)
+	if err != nil {
+		return "", nil, errors.New("invalid from_account_id")
+	}
+	req.FromAccountID = fromAccountID
+
+	req.Currency = strings.ToUpper(strings.TrimSpace(c.PostForm("currency")))
+	if req.Currency != "" && len(req.Currency) != 3 {
+		return "", nil, errors.New("currency must be a 3-letter code")
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return "", nil, errors.New("file is required")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	format := models.BatchFormatCSV
+	if strings.EqualFold(filepath.Ext(fileHeader.Filename), ".json") ||
+		strings.Contains(fileHeader.Header.Get("Content-Type"), "json") {
+		format = models.BatchFormatJSON
+	}
+
+	if format == models.BatchFormatJSON {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		req.Items, err = batch.ParseJSON(data)
+		return format, nil, err
+	}
+
+	var lineErrors []models.BatchLineError
+	req.Items, lineErrors, err = batch.ParseCSV(file)
+	return format, lineErrors, err
+}
+
+// accountSummary is the part of the account service's balance response the transfer service uses
+type accountSummary struct {
+	UserID      int64           `json:"user_id"`
+	Balance     decimal.Decimal `json:"balance"`
+	Currency    string          `json:"currency"`
+	AccountType string          `json:"account_type"`
+	Status      string          `json:"status"`
+}
+
+// batchFees totals the fees runBatch will charge for the lines of a batch, looking each destination
+// and fee rule up once. Lines to unknown destinations are free, as runBatch charges them nothing;
+// a line to an account in another currency fails the whole batch.
+func batchFees(ctx context.Context, currency string, source *accountSummary, items []models.BatchInstruction) (decimal.Decimal, error) {
+	channels := make(map[int64]string)
+	rules := make(map[string]*models.FeeRule)
+
+	total := decimal.Zero
+	for _, item := range items {
+		channel, seen := channels[item.ToAccountID]
+		if !seen {
+			var err error
+			channel, err = feeChannel(item.ToAccountID, source)
+			if errors.Is(err, errCurrencyMismatch) {
+				return decimal.Zero, fmt.Errorf("line %d: %w", item.LineNumber, err)
+			}
+			if err != nil && !errors.Is(err, errDestinationNotFound) {
+				return decimal.Zero, err
+			}
+			channels[item.ToAccountID] = channel
+		}
+		if channel == "" {
+			continue
+		}
+
+		rule, seen := rules[channel]
+		if !seen {
+			var err error
+			rule, err = feeRepo.FindRule(ctx, channel, source.AccountType, currency)
+			if err != nil && !errors.Is(err, repository.ErrFeeRuleNotFound) {
+				return decimal.Zero, err
+			}
+			rules[channel] = rule
+		}
+
+		total = total.Add(repository.CalculateFee(rule, item.Amount))
+	}
+
+	return total, nil
+}
+
+// getAccountSummary calls the account service for the balance, currency and product of an account,
+// as the given user. The account service's HTTP status is returned so callers can distinguish
+// ownership failures.
+func getAccountSummary(accountID, userID int64, role string) (*accountSummary, int, error) {
+	accountServiceURL := getEnv("ACCOUNT_SERVICE_URL", "http://account.account.svc.cluster.local:8080")
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/accounts/%d/balance", accountServiceURL, accountID), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Pass user context headers so the account service enforces ownership
+	req.Header.Set("X-User-ID", strconv.FormatInt(userID, 10))
+	req.Header.Set("X-User-Role", role)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to call account service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("account service returned status %d", resp.StatusCode)
+	}
+
+	var summary accountSummary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &summary, resp.StatusCode, nil
+}
+
+// runBatch creates and dispatches a child transfer for every line that doesn't have one yet.
+// It is safe to run again for a batch interrupted part way through. A batch that hits an error is
+// left processing for the retry sweep to resume, until batchRetryWindow has passed since it was
+// accepted; then it is stopped, so it is not left processing with lines that never run.
+func runBatch(ctx context.Context, b *models.TransferBatch) {
+	if _, running := runningBatches.LoadOrStore(b.ID, struct{}{}); running {
+		return
+	}
+	defer runningBatches.Delete(b.ID)
+
+	reason, err := submitBatchItems(ctx, b)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	if time.Since(b.CreatedAt) > batchRetryWindow {
+		stopBatch(ctx, b, reason)
+		return
+	}
+	log.Printf("Batch %d paused, remaining lines will be retried: %s: %v", b.ID, reason, err)
+}
+
+// submitBatchItems does the work of runBatch. On failure it returns the reason to record if the
+// batch is stopped along with the error.
+func submitBatchItems(ctx context.Context, b *models.TransferBatch) (string, error) {
+	items, err := batchRepo.ListUnsubmittedItems(ctx, b.ID)
+	if err != nil {
+		return "failed to load batch items", err
+	}
+
+	// Child transfers are priced as the service since the batch runs in the background
+	source, _, err := getAccountSummary(b.FromAccountID, serviceUserID, "admin")
+	if err != nil {
+		return "failed to look up source account", err
+	}
+
+	dispatched := 0
+	for i := range items {
+		if ctx.Err() != nil {
+			log.Printf("Batch %d interrupted with %d items left", b.ID, len(items)-i)
+			return "", ctx.Err()
+		}
+
+		quote, err := quoteFee(ctx, b.FromAccountID, items[i].ToAccountID, items[i].Amount, b.Currency, source)
+		if errors.Is(err, errDestinationNotFound) {
+			// The account service will fail the transfer; there is nothing to charge
+			quote = &models.FeeQuote{Channel: models.FeeChannelInternal}
+		} else if err != nil {
+			return fmt.Sprintf("failed to price line %d", items[i].LineNumber), err
+		}
+
+		transfer, err := transferRepo.CreateForBatchItem(ctx, b, &items[i], quote)
+		if errors.Is(err, repository.ErrBatchItemSubmitted) {
+			// A concurrent run of the batch got to this line first
+			continue
+		} else if err != nil {
+			return fmt.Sprintf("failed to create transfer for line %d", items[i].LineNumber), err
+		}
+
+		// Dispatch failures mark the child transfer failed, which shows up in the batch progress
+		if _, err := dispatchTransfer(ctx, transfer); err != nil {
+			log.Printf("Failed to dispatch transfer %d for batch %d line %d: %v", transfer.ID, b.ID, items[i].LineNumber, err)
+		}
+		dispatched++
+	}
+
+	if err := batchRepo.MarkSubmitted(ctx, b.ID); err != nil {
+		return "failed to mark batch submitted", err
+	}
+
+	log.Printf("Batch %d submitted: %d transfers dispatched", b.ID, dispatched)
+	return "", nil
+}
+
+// stopBatch ends a batch's execution after an error, unless the service is shutting down, in
+// which case the batch stays processing to be resumed
+func stopBatch(ctx context.Context, b *models.TransferBatch, reason string) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	stopped, err := batchRepo.MarkStopped(ctx, b.ID, reason)
+	if err != nil {
+		log.Printf("Failed to stop batch %d: %v", b.ID, err)
+		return
+	}
+	log.Printf("Batch %d stopped as %s: %s", b.ID, stopped.Status, reason)
+}
+
+// runBatchSweeper resumes batches left processing, by a previous instance or after a failure,
+// at startup and then every batchRetryInterval
+func runBatchSweeper(ctx context.Context) {
+	ticker := time.NewTicker(batchRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		resumeBatches(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resumeBatches restarts batches left processing
+func resumeBatches(ctx context.Context) {
+	batches, err := batchRepo.ListProcessing(ctx)
+	if err != nil {
+		log.Printf("Failed to list processing batches: %v", err)
+		return
+	}
+
+	for i := range batches {
+		if _, running := runningBatches.Load(batches[i].ID); running {
+			continue
+		}
+		log.Printf("Resuming batch %d", batches[i].ID)
+		runBatch(ctx, &batches[i])
+	}
+}
+
+func listBatches(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	// Admin sees every batch, everyone else only their own
+	initiatedBy := userID
+	if role == "admin" {
+		initiatedBy = 0
+	}
+
+	result, err := batchRepo.List(c.Request.Context(), initiatedBy, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list batches: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list batches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getBatch returns a batch with per-item progress (paginated with limit/offset)
+func getBatch(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	batchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batch ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	ctx := c.Request.Context()
+	b, err := batchRepo.GetByID(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBatchNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "batch not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get batch"})
+		return
+	}
+
+	if role != "admin" && b.InitiatedBy != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "batch not found"})
+		return
+	}
+
+	progress, err := batchRepo.GetProgress(ctx, batchID)
+	if err != nil {
+		log.Printf("Failed to get progress for batch %d: %v", batchID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get batch"})
+		return
+	}
+
+	items, err := batchRepo.ListItems(ctx, batchID, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list items for batch %d: %v", batchID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get batch"})
+		return
+	}
+
+	// Completion is derived from the children rather than stored
+	if b.Status == models.BatchStatusSubmitted && progress.InFlight() == 0 {
+		b.Status = models.BatchStatusCompleted
+		if progress.Failed+progress.Rejected > 0 {
+			b.Status = models.BatchStatusCompletedWithFailures
+		}
+	}
+	if b.Status == models.BatchStatusPartiallySubmitted && progress.InFlight() == 0 {
+		b.Status = models.BatchStatusPartiallyCompleted
+	}
+
+	c.JSON(http.StatusOK, models.TransferBatchResponse{
+		Batch:    b,
+		Progress: progress,
+		Items:    items,
+		Total:    int64(b.TotalItems),
+	})
+}
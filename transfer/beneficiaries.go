@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createBeneficiary saves a payee for the caller; it starts in its cooling-off period
+func createBeneficiary(c *gin.Context) {
+	userID, _, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateBeneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Internal beneficiaries must point at a real account; the lookup runs as the service
+	// since the account belongs to someone else
+	if req.Type == models.BeneficiaryTypeInternal {
+		if _, status, err := getAccountSummary(req.AccountID, serviceUserID, "admin"); err != nil {
+			log.Printf("Failed to look up beneficiary account %d: %v", req.AccountID, err)
+			if status == http.StatusNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up account"})
+			return
+		}
+	}
+
+	beneficiary, err := beneficiaryRepo.Create(c.Request.Context(), userID, &req, time.Now().Add(beneficiaryCoolingOff))
+	if err != nil {
+		respondBeneficiaryError(c, err)
+		return
+	}
+
+	beneficiary.ApplyCoolingOff(time.Now(), beneficiaryCoolingOffLimit)
+	c.JSON(http.StatusCreated, beneficiary)
+}
+
+// listBeneficiaries lists the caller's beneficiaries (admin may pass ?user_id=)
+func listBeneficiaries(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if role == "admin" && c.Query("user_id") != "" {
+		userID, err = strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+			return
+		}
+	}
+
+	result, err := beneficiaryRepo.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Failed to list beneficiaries for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list beneficiaries"})
+		return
+	}
+
+	now := time.Now()
+	for i := range result.Beneficiaries {
+		result.Beneficiaries[i].ApplyCoolingOff(now, beneficiaryCoolingOffLimit)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getCoolingOff reports whether the caller has a beneficiary for the account at another bank
+// named by ?account= (an IBAN or account number) that is still cooling off. The payment service
+// uses it, on the customer's behalf, to cap external payments to a new beneficiary however they
+// name the destination.
+func getCoolingOff(c *gin.Context) {
+	userID, _, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	account := c.Query("account")
+	if account == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account is required"})
+		return
+	}
+
+	since, until, err := beneficiaryRepo.CoolingOffWindow(c.Request.Context(), userID, account, time.Now())
+	if err != nil {
+		log.Printf("Failed to check cooling-off for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check beneficiary cooling-off"})
+		return
+	}
+
+	status := models.CoolingOffStatus{}
+	if since != nil {
+		limit := beneficiaryCoolingOffLimit
+		status = models.CoolingOffStatus{InCoolingOff: true, Since: since, CoolingOffUntil: until, MaxAmount: &limit}
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// getBeneficiary returns a beneficiary to its owner or an admin; the payment service also
+// uses it, on the customer's behalf, to resolve external payment destinations
+func getBeneficiary(c *gin.Context) {
+	beneficiary, ok := loadBeneficiary(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, beneficiary)
+}
+
+// updateBeneficiary renames a beneficiary
+func updateBeneficiary(c *gin.Context) {
+	beneficiary, ok := loadBeneficiary(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateBeneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := beneficiaryRepo.Update(c.Request.Context(), beneficiary.ID, &req)
+	if err != nil {
+		respondBeneficiaryError(c, err)
+		return
+	}
+
+	updated.ApplyCoolingOff(time.Now(), beneficiaryCoolingOffLimit)
+	c.JSON(http.StatusOK, updated)
+}
+
+// deleteBeneficiary removes a beneficiary
+func deleteBeneficiary(c *gin.Context) {
+	beneficiary, ok := loadBeneficiary(c)
+	if !ok {
+		return
+	}
+
+	if err := beneficiaryRepo.Delete(c.Request.Context(), beneficiary.ID); err != nil {
+		respondBeneficiaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "beneficiary deleted"})
+}
+
+// loadBeneficiary fetches the beneficiary named in the path, checking the caller owns it
+func loadBeneficiary(c *gin.Context) (*models.Beneficiary, bool) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	beneficiaryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid beneficiary ID"})
+		return nil, false
+	}
+
+	beneficiary, err := beneficiaryRepo.GetByID(c.Request.Context(), beneficiaryID)
+	if err != nil {
+		respondBeneficiaryError(c, err)
+		return nil, false
+	}
+
+	if role != "admin" && beneficiary.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return nil, false
+	}
+
+	beneficiary.ApplyCoolingOff(time.Now(), beneficiaryCoolingOffLimit)
+	return beneficiary, true
+}
+
+func respondBeneficiaryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrBeneficiaryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "beneficiary not found"})
+	case errors.Is(err, repository.ErrBeneficiaryExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Beneficiary error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update beneficiary"})
+	}
+}
+
+// resolveTransferRecipient fills ToAccountID from the request's beneficiary, if it names one. The
+// cooling-off cap is enforced as the transfer is recorded, whether or not it names the beneficiary.
+func resolveTransferRecipient(c *gin.Context, userID int64, role string, req *models.CreateTransferRequest) bool {
+	if req.BeneficiaryID == 0 {
+		if req.ToAccountID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to_account_id or beneficiary_id is required"})
+			return false
+		}
+		return true
+	}
+
+	beneficiary, err := beneficiaryRepo.GetByID(c.Request.Context(), req.BeneficiaryID)
+	if err != nil {
+		respondBeneficiaryError(c, err)
+		return false
+	}
+
+	if role != "admin" && beneficiary.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return false
+	}
+
+	if beneficiary.Type != models.BeneficiaryTypeInternal || beneficiary.AccountID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only internal beneficiaries can receive transfers; use an external payment instead"})
+		return false
+	}
+
+	if req.ToAccountID != 0 && req.ToAccountID != *beneficiary.AccountID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_account_id does not match the beneficiary's account"})
+		return false
+	}
+	req.ToAccountID = *beneficiary.AccountID
+
+	return true
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	effectiveLimitsTTL = 5 * time.Minute
+)
+
+// CachedLimitRepository wraps a LimitRepository with Redis caching.
+// Usage figures are never cached since they change with every transfer.
+type CachedLimitRepository struct {
+	repo  *repository.LimitRepository
+	redis *redis.Client
+}
+
+// NewCachedLimitRepository creates a new cached limit repository.
+func NewCachedLimitRepository(repo *repository.LimitRepository, redisClient *redis.Client) *CachedLimitRepository {
+	return &CachedLimitRepository{
+		repo:  repo,
+		redis: redisClient,
+	}
+}
+
+func keyEffectiveLimits(userID int64) string {
+	return fmt.Sprintf("transfer:limits:user:%d", userID)
+}
+
+// GetEffectiveLimits checks cache first, falls back to DB.
+func (c *CachedLimitRepository) GetEffectiveLimits(ctx context.Context, userID int64) (*models.EffectiveLimits, error) {
+	key := keyEffectiveLimits(userID)
+
+	data, err := c.redis.Get(ctx, key).Bytes()
+	if err == nil {
+		var limits models.EffectiveLimits
+		if json.Unmarshal(data, &limits) == nil {
+			return &limits, nil
+		}
+	}
+
+	limits, err := c.repo.GetEffectiveLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setCache(ctx, key, limits, effectiveLimitsTTL)
+	return limits, nil
+}
+
+// GetUsage delegates directly (always fresh).
+func (c *CachedLimitRepository) GetUsage(ctx context.Context, userID int64, now time.Time) (*models.LimitUsage, error) {
+	return c.repo.GetUsage(ctx, userID, now)
+}
+
+// ListSegments delegates directly (admin only, rarely called).
+func (c *CachedLimitRepository) ListSegments(ctx context.Context) ([]models.SegmentLimits, error) {
+	return c.repo.ListSegments(ctx)
+}
+
+// UpsertSegment delegates to repo and invalidates all cached effective limits.
+func (c *CachedLimitRepository) UpsertSegment(ctx context.Context, segment string, req *models.UpdateSegmentLimitsRequest) (*models.SegmentLimits, error) {
+	s, err := c.repo.UpsertSegment(ctx, segment, req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.deleteByPattern(ctx, "transfer:limits:user:*")
+	return s, nil
+}
+
+// GetOverride delegates directly (admin only, rarely called).
+func (c *CachedLimitRepository) GetOverride(ctx context.Context, userID int64) (*models.LimitOverride, error) {
+	return c.repo.GetOverride(ctx, userID)
+}
+
+// SetOverride delegates to repo and invalidates the user's effective limits.
+func (c *CachedLimitRepository) SetOverride(ctx context.Context, userID, adminID int64, req *models.SetLimitOverrideRequest) (*models.LimitOverride, error) {
+	o, err := c.repo.SetOverride(ctx, userID, adminID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.del(ctx, keyEffectiveLimits(userID))
+	return o, nil
+}
+
+// DeleteOverride delegates to repo and invalidates the user's effective limits.
+func (c *CachedLimitRepository) DeleteOverride(ctx context.Context, userID int64) error {
+	if err := c.repo.DeleteOverride(ctx, userID); err != nil {
+		return err
+	}
+
+	c.del(ctx, keyEffectiveLimits(userID))
+	return nil
+}
+
+// setCache marshals the value and stores it in Redis. Errors are logged, never returned.
+func (c *CachedLimitRepository) setCache(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("cache: failed to marshal %s: %v", key, err)
+		return
+	}
+	if err := c.redis.Set(ctx, key, data, ttl).Err(); err != nil {
+		log.Printf("cache: failed to set %s: %v", key, err)
+	}
+}
+
+// del deletes keys from Redis. Errors are logged, never returned.
+func (c *CachedLimitRepository) del(ctx context.Context, keys ...string) {
+	if err := c.redis.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("cache: failed to delete keys: %v", err)
+	}
+}
+
+// deleteByPattern scans and deletes keys matching a pattern.
+func (c *CachedLimitRepository) deleteByPattern(ctx context.Context, pattern string) {
+	iter := c.redis.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		c.del(ctx, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("cache: failed to scan pattern %s: %v", pattern, err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 )
 
 const (
@@ -52,8 +53,20 @@ func keyTransferAll(limit, offset int) string {
 }
 
 // Create delegates to the underlying repo and invalidates list caches.
-func (c *CachedTransferRepository) Create(ctx context.Context, req *models.CreateTransferRequest) (*models.Transfer, error) {
-	transfer, err := c.repo.Create(ctx, req)
+func (c *CachedTransferRepository) Create(ctx context.Context, userID int64, req *models.CreateTransferRequest, quote *models.FeeQuote, limits *models.EffectiveLimits) (*models.Transfer, error) {
+	transfer, err := c.repo.Create(ctx, userID, req, quote, limits)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidateAccountLists(ctx, transfer.FromAccountID)
+	c.invalidateAccountLists(ctx, transfer.ToAccountID)
+	c.invalidateGlobalLists(ctx)
+	return transfer, nil
+}
+
+// CreateForBatchItem delegates to the underlying repo and invalidates list caches.
+func (c *CachedTransferRepository) CreateForBatchItem(ctx context.Context, batch *models.TransferBatch, item *models.TransferBatchItem, quote *models.FeeQuote) (*models.Transfer, error) {
+	transfer, err := c.repo.CreateForBatchItem(ctx, batch, item, quote)
 	if err != nil {
 		return nil, err
 	}
@@ -152,9 +165,57 @@ func (c *CachedTransferRepository) ListAll(ctx context.Context, limit, offset in
 	return result, nil
 }
 
+// ListByStatus delegates directly (review queues must always be fresh).
+func (c *CachedTransferRepository) ListByStatus(ctx context.Context, status string, limit, offset int) (*models.TransferListResponse, error) {
+	return c.repo.ListByStatus(ctx, status, limit, offset)
+}
+
+// Search delegates directly; ad hoc searches are not worth caching.
+func (c *CachedTransferRepository) Search(ctx context.Context, accountIDs []int64, search *models.TransferSearch, limit, offset int) (*models.TransferListResponse, error) {
+	return c.repo.Search(ctx, accountIDs, search, limit, offset)
+}
+
+// SetCategory delegates to repo and invalidates affected caches.
+func (c *CachedTransferRepository) SetCategory(ctx context.Context, id int64, side, category string) (*models.Transfer, error) {
+	transfer, err := c.repo.SetCategory(ctx, id, side, category)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidateTransfer(ctx, transfer)
+	return transfer, nil
+}
+
+// ListStale delegates directly; the sweeper must see current state.
+func (c *CachedTransferRepository) ListStale(ctx context.Context, status string, before time.Time, limit int) ([]models.Transfer, error) {
+	return c.repo.ListStale(ctx, status, before, limit)
+}
+
+// RecordSagaRetry delegates to repo and invalidates the transfer.
+func (c *CachedTransferRepository) RecordSagaRetry(ctx context.Context, id int64) (*models.Transfer, error) {
+	transfer, err := c.repo.RecordSagaRetry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidateTransfer(ctx, transfer)
+	return transfer, nil
+}
+
+// Review delegates to repo and invalidates affected caches.
+func (c *CachedTransferRepository) Review(ctx context.Context, id, reviewerID int64, approve bool, reason *string) (*models.Transfer, error) {
+	transfer, err := c.repo.Review(ctx, id, reviewerID, approve, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidateTransfer(ctx, transfer)
+	return transfer, nil
+}
+
 // UpdateStatus delegates to repo and invalidates affected caches.
-func (c *CachedTransferRepository) UpdateStatus(ctx context.Context, id int64, status string, failureReason *string) (*models.Transfer, error) {
-	transfer, err := c.repo.UpdateStatus(ctx, id, status, failureReason)
+func (c *CachedTransferRepository) UpdateStatus(ctx context.Context, id int64, status string, failureReason *string, cause string) (*models.Transfer, error) {
+	transfer, err := c.repo.UpdateStatus(ctx, id, status, failureReason, cause)
 	if err != nil {
 		return nil, err
 	}
@@ -174,9 +235,20 @@ func (c *CachedTransferRepository) MarkAsProcessing(ctx context.Context, id int6
 	return transfer, nil
 }
 
+// MarkAsPendingApproval marks a transfer as pending approval and invalidates caches.
+func (c *CachedTransferRepository) MarkAsPendingApproval(ctx context.Context, id int64) (*models.Transfer, error) {
+	transfer, err := c.repo.MarkAsPendingApproval(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidateTransfer(ctx, transfer)
+	return transfer, nil
+}
+
 // MarkAsCompleted marks a transfer as completed and invalidates caches.
-func (c *CachedTransferRepository) MarkAsCompleted(ctx context.Context, id int64) (*models.Transfer, error) {
-	transfer, err := c.repo.MarkAsCompleted(ctx, id)
+func (c *CachedTransferRepository) MarkAsCompleted(ctx context.Context, id int64, cause string) (*models.Transfer, error) {
+	transfer, err := c.repo.MarkAsCompleted(ctx, id, cause)
 	if err != nil {
 		return nil, err
 	}
@@ -186,8 +258,8 @@ func (c *CachedTransferRepository) MarkAsCompleted(ctx context.Context, id int64
 }
 
 // MarkAsFailed marks a transfer as failed and invalidates caches.
-func (c *CachedTransferRepository) MarkAsFailed(ctx context.Context, id int64, reason string) (*models.Transfer, error) {
-	transfer, err := c.repo.MarkAsFailed(ctx, id, reason)
+func (c *CachedTransferRepository) MarkAsFailed(ctx context.Context, id int64, reason, cause string) (*models.Transfer, error) {
+	transfer, err := c.repo.MarkAsFailed(ctx, id, reason, cause)
 	if err != nil {
 		return nil, err
 	}
@@ -196,6 +268,36 @@ func (c *CachedTransferRepository) MarkAsFailed(ctx context.Context, id int64, r
 	return transfer, nil
 }
 
+// CreateReversal delegates to the underlying repo and invalidates list caches.
+func (c *CachedTransferRepository) CreateReversal(ctx context.Context, originalID, adminID int64, amount decimal.Decimal, policy, reason string) (*models.Transfer, error) {
+	transfer, err := c.repo.CreateReversal(ctx, originalID, adminID, amount, policy, reason)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidateAccountLists(ctx, transfer.FromAccountID)
+	c.invalidateAccountLists(ctx, transfer.ToAccountID)
+	c.invalidateGlobalLists(ctx)
+	return transfer, nil
+}
+
+// CompleteReversal delegates to the underlying repo and invalidates both the reversal and the original.
+func (c *CachedTransferRepository) CompleteReversal(ctx context.Context, reversalID int64, settled decimal.Decimal, cause string) (*models.Transfer, error) {
+	reversal, err := c.repo.CompleteReversal(ctx, reversalID, settled, cause)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidateTransfer(ctx, reversal)
+	if original, err := c.repo.GetByID(ctx, *reversal.ReversalOf); err == nil {
+		c.invalidateTransfer(ctx, original)
+	}
+	return reversal, nil
+}
+
+// ListStatusHistory delegates directly; history is only read by admins and is not cached.
+func (c *CachedTransferRepository) ListStatusHistory(ctx context.Context, id int64) ([]models.StatusTransition, error) {
+	return c.repo.ListStatusHistory(ctx, id)
+}
+
 // invalidateTransfer invalidates all caches related to a transfer.
 func (c *CachedTransferRepository) invalidateTransfer(ctx context.Context, transfer *models.Transfer) {
 	c.del(ctx, keyTransferByID(transfer.ID))
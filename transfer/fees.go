@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// serviceUserID is the user the transfer service acts as when it calls other services on its own behalf
+const serviceUserID = 0
+
+var (
+	// errDestinationNotFound means the account service has no destination account to price a transfer for
+	errDestinationNotFound = errors.New("destination account not found")
+	// errCurrencyMismatch means a transfer would need a currency conversion, which the account
+	// service does not make; it moves the amount unchanged
+	errCurrencyMismatch = errors.New("transfers between accounts in different currencies are not supported")
+)
+
+// quoteFee prices a transfer from the source account's product and currency and the channel it
+// travels on. Transfers with no matching fee rule are free.
+func quoteFee(ctx context.Context, fromAccountID, toAccountID int64, amount decimal.Decimal, currency string, source *accountSummary) (*models.FeeQuote, error) {
+	channel, err := feeChannel(toAccountID, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if currency == "" {
+		currency = source.Currency
+	}
+
+	quote := &models.FeeQuote{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+		Currency:      currency,
+		Channel:       channel,
+	}
+
+	rule, err := feeRepo.FindRule(ctx, channel, source.AccountType, currency)
+	if err != nil && !errors.Is(err, repository.ErrFeeRuleNotFound) {
+		return nil, err
+	}
+	if rule != nil {
+		quote.FeeRuleID = &rule.ID
+	}
+
+	quote.Fee = repository.CalculateFee(rule, amount)
+	quote.TotalDebit = amount.Add(quote.Fee)
+	return quote, nil
+}
+
+// feeChannel looks up the destination account to work out the channel a transfer from source travels
+// on, refusing destinations in another currency
+func feeChannel(toAccountID int64, source *accountSummary) (string, error) {
+	destination, status, err := getAccountSummary(toAccountID, serviceUserID, "admin")
+	if err != nil {
+		if status == http.StatusNotFound {
+			return "", errDestinationNotFound
+		}
+		return "", fmt.Errorf("failed to look up destination account: %w", err)
+	}
+
+	if destination.Currency != source.Currency {
+		return "", errCurrencyMismatch
+	}
+	return models.FeeChannelInternal, nil
+}
+
+// priceTransfer validates a transfer request against its source account and quotes its fee,
+// writing the error response and returning false if that is not possible
+func priceTransfer(c *gin.Context, userID int64, role string, req *models.CreateTransferRequest) (*models.FeeQuote, bool) {
+	source, status, err := getAccountSummary(req.FromAccountID, userID, role)
+	if err != nil {
+		log.Printf("Failed to look up account %d: %v", req.FromAccountID, err)
+		switch status {
+		case http.StatusForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case http.StatusNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "source account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up source account"})
+		}
+		return nil, false
+	}
+
+	quote, err := quoteFee(c.Request.Context(), req.FromAccountID, req.ToAccountID, req.Amount, req.Currency, source)
+	if err != nil {
+		if errors.Is(err, errDestinationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "destination account not found"})
+			return nil, false
+		}
+		if errors.Is(err, errCurrencyMismatch) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		log.Printf("Failed to quote transfer fee: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to quote transfer fee"})
+		return nil, false
+	}
+
+	return quote, true
+}
+
+// quoteTransfer returns the fee and total debit for a transfer without submitting it
+func quoteTransfer(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+
+	if !resolveTransferRecipient(c, userID, role, &req) {
+		return
+	}
+
+	if req.FromAccountID == req.ToAccountID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source and destination accounts cannot be the same"})
+		return
+	}
+
+	quote, ok := priceTransfer(c, userID, role, &req)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func listFeeRules(c *gin.Context) {
+	if _, ok := requireAdmin(c); !ok {
+		return
+	}
+
+	rules, err := feeRepo.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list fee rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+func createFeeRule(c *gin.Context) {
+	adminID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	var req models.FeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := feeRepo.CreateRule(c.Request.Context(), &req)
+	if err != nil {
+		respondFeeRuleError(c, err, "failed to create fee rule")
+		return
+	}
+
+	log.Printf("Admin %d created fee rule %d (%s)", adminID, rule.ID, rule.Channel)
+	c.JSON(http.StatusCreated, rule)
+}
+
+func updateFeeRule(c *gin.Context) {
+	adminID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	ruleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee rule ID"})
+		return
+	}
+
+	var req models.FeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := feeRepo.UpdateRule(c.Request.Context(), ruleID, &req)
+	if err != nil {
+		respondFeeRuleError(c, err, "failed to update fee rule")
+		return
+	}
+
+	log.Printf("Admin %d updated fee rule %d", adminID, rule.ID)
+	c.JSON(http.StatusOK, rule)
+}
+
+func deleteFeeRule(c *gin.Context) {
+	adminID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	ruleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee rule ID"})
+		return
+	}
+
+	if err := feeRepo.DeleteRule(c.Request.Context(), ruleID); err != nil {
+		respondFeeRuleError(c, err, "failed to delete fee rule")
+		return
+	}
+
+	log.Printf("Admin %d deleted fee rule %d", adminID, ruleID)
+	c.JSON(http.StatusOK, gin.H{"message": "fee rule deleted"})
+}
+
+// respondFeeRuleError writes the response for an error returned by the fee rule repository
+func respondFeeRuleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrFeeRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee rule not found"})
+	case errors.Is(err, repository.ErrFeeRuleExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrInvalidFeeRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
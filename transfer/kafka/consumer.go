@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 
 	"transfer/models"
@@ -80,8 +81,14 @@ func (c *Consumer) consumeCompleted(ctx context.Context) {
 
 			log.Printf("Received transfer.completed event for transfer %d", event.TransferID)
 
-			_, err = c.repo.MarkAsCompleted(ctx, event.TransferID)
-			if err != nil {
+			if event.ReversalOf != 0 {
+				_, err = c.repo.CompleteReversal(ctx, event.TransferID, event.Amount, models.TransitionCauseAccountResult)
+			} else {
+				_, err = c.repo.MarkAsCompleted(ctx, event.TransferID, models.TransitionCauseAccountResult)
+			}
+			if errors.Is(err, repository.ErrInvalidTransition) {
+				log.Printf("Rejected transfer.completed event for transfer %d: %v", event.TransferID, err)
+			} else if err != nil {
 				log.Printf("Error marking transfer %d as completed: %v", event.TransferID, err)
 			} else {
 				log.Printf("Transfer %d marked as completed", event.TransferID)
@@ -118,8 +125,10 @@ func (c *Consumer) consumeFailed(ctx context.Context) {
 
 			log.Printf("Received transfer.failed event for transfer %d: %s", event.TransferID, event.FailureReason)
 
-			_, err = c.repo.MarkAsFailed(ctx, event.TransferID, event.FailureReason)
-			if err != nil {
+			_, err = c.repo.MarkAsFailed(ctx, event.TransferID, event.FailureReason, models.TransitionCauseAccountResult)
+			if errors.Is(err, repository.ErrInvalidTransition) {
+				log.Printf("Rejected transfer.failed event for transfer %d: %v", event.TransferID, err)
+			} else if err != nil {
 				log.Printf("Error marking transfer %d as failed: %v", event.TransferID, err)
 			} else {
 				log.Printf("Transfer %d marked as failed", event.TransferID)
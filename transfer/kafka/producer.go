@@ -14,10 +14,16 @@ import (
 
 const (
 	TopicTransferRequested = "transfer.requested"
+	TopicOpsAlerts         = "ops.alerts"
+	TopicMoneyRequest      = "money_request.updated"
+	TopicSplitReminder     = "bill_split.reminder"
 )
 
 type Producer struct {
-	writer *kafka.Writer
+	writer        *kafka.Writer
+	alertWriter   *kafka.Writer
+	requestWriter *kafka.Writer
+	splitWriter   *kafka.Writer
 }
 
 func NewProducer(brokers []string) *Producer {
@@ -30,7 +36,34 @@ func NewProducer(brokers []string) *Producer {
 		Async:        false, // Synchronous writes for reliability
 	}
 
-	return &Producer{writer: writer}
+	alertWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicOpsAlerts,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	requestWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicMoneyRequest,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	splitWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicSplitReminder,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	return &Producer{writer: writer, alertWriter: alertWriter, requestWriter: requestWriter, splitWriter: splitWriter}
 }
 
 // PublishTransferRequested publishes a transfer requested event
@@ -42,6 +75,17 @@ func (p *Producer) PublishTransferRequested(ctx context.Context, transfer *model
 		ToAccountID:   transfer.ToAccountID,
 		Amount:        transfer.Amount,
 		Currency:      transfer.Currency,
+		Fee:           transfer.Fee,
+	}
+	if transfer.ReversalOf != nil {
+		event.ReversalOf = *transfer.ReversalOf
+		event.ReversalPolicy = models.ReversalPolicyPartial
+		if transfer.ReversalPolicy != nil {
+			event.ReversalPolicy = *transfer.ReversalPolicy
+		}
+		if transfer.FeeRefunded != nil {
+			event.FeeRefund = *transfer.FeeRefunded
+		}
 	}
 
 	value, err := json.Marshal(event)
@@ -66,9 +110,108 @@ func (p *Producer) PublishTransferRequested(ctx context.Context, transfer *model
 	return nil
 }
 
+// PublishOpsAlert publishes an alert for operators
+func (p *Producer) PublishOpsAlert(ctx context.Context, alert models.OpsAlert) error {
+	value, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(alert.ReferenceID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte("ops.alert")},
+			{Key: "severity", Value: []byte(alert.Severity)},
+		},
+	}
+
+	if err := p.alertWriter.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish alert: %w", err)
+	}
+
+	return nil
+}
+
+// PublishMoneyRequest publishes a money request event so both customers are notified
+func (p *Producer) PublishMoneyRequest(ctx context.Context, request *models.MoneyRequest) error {
+	event := models.MoneyRequestEvent{
+		RequestID:       request.ID,
+		ReferenceID:     request.ReferenceID.String(),
+		Status:          request.Status,
+		RequesterUserID: request.RequesterUserID,
+		PayerUserID:     request.PayerUserID,
+		Amount:          request.Amount,
+		Currency:        request.Currency,
+		ExpiresAt:       request.ExpiresAt,
+	}
+	if request.Note != nil {
+		event.Note = *request.Note
+	}
+	if request.DeclineReason != nil {
+		event.DeclineReason = *request.DeclineReason
+	}
+	if request.TransferID != nil {
+		event.TransferID = *request.TransferID
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(request.ReferenceID.String()),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(TopicMoneyRequest)},
+			{Key: "status", Value: []byte(request.Status)},
+		},
+	}
+
+	if err := p.requestWriter.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish money request event: %w", err)
+	}
+
+	log.Printf("Published money request event for request %d (status: %s)", request.ID, request.Status)
+	return nil
+}
+
+// PublishSplitReminder reminds a participant of their unpaid share of a bill split
+func (p *Producer) PublishSplitReminder(ctx context.Context, event models.SplitReminderEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(fmt.Sprintf("%d", event.ShareID)),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(TopicSplitReminder)},
+			{Key: "split_id", Value: []byte(fmt.Sprintf("%d", event.SplitID))},
+		},
+	}
+
+	if err := p.splitWriter.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish split reminder: %w", err)
+	}
+
+	return nil
+}
+
 // Close closes the producer
 func (p *Producer) Close() error {
-	return p.writer.Close()
+	if err := p.writer.Close(); err != nil {
+		return err
+	}
+	if err := p.alertWriter.Close(); err != nil {
+		return err
+	}
+	if err := p.requestWriter.Close(); err != nil {
+		return err
+	}
+	return p.splitWriter.Close()
 }
 
 // EnsureTopicExists creates the topic if it doesn't exist
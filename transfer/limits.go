@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondLimitError writes the response for a limit check or a failure to load the limits
+func respondLimitError(c *gin.Context, err error) {
+	var code string
+	switch {
+	case errors.Is(err, repository.ErrPerTransactionLimit):
+		code = models.LimitCodePerTransaction
+	case errors.Is(err, repository.ErrDailyLimit):
+		code = models.LimitCodeDaily
+	case errors.Is(err, repository.ErrMonthlyLimit):
+		code = models.LimitCodeMonthly
+	case errors.Is(err, repository.ErrHourlyCountLimit):
+		code = models.LimitCodeHourlyCount
+	case errors.Is(err, repository.ErrBeneficiaryCoolingOff):
+		code = models.BeneficiaryCodeCoolingOff
+	default:
+		log.Printf("Failed to check transfer limits: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check transfer limits"})
+		return
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": code})
+}
+
+// getLimits returns the caller's effective limits and usage (admin may pass ?user_id=)
+func getLimits(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if role == "admin" && c.Query("user_id") != "" {
+		userID, err = strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+			return
+		}
+	}
+
+	respondWithLimits(c, userID)
+}
+
+// effectiveLimits resolves the user's transfer limits, including the new-beneficiary cap
+func effectiveLimits(ctx context.Context, userID int64) (*models.EffectiveLimits, error) {
+	limits, err := limitRepo.GetEffectiveLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	limits.NewBeneficiaryMax = beneficiaryCoolingOffLimit
+	return limits, nil
+}
+
+func respondWithLimits(c *gin.Context, userID int64) {
+	limits, err := effectiveLimits(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Failed to get limits for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer limits"})
+		return
+	}
+
+	usage, err := limitRepo.GetUsage(c.Request.Context(), userID, time.Now())
+	if err != nil {
+		log.Printf("Failed to get limit usage for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LimitsResponse{Limits: limits, Usage: usage})
+}
+
+func listLimitSegments(c *gin.Context) {
+	if _, ok := requireAdmin(c); !ok {
+		return
+	}
+
+	segments, err := limitRepo.ListSegments(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list limit segments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"segments": segments})
+}
+
+func updateLimitSegment(c *gin.Context) {
+	if _, ok := requireAdmin(c); !ok {
+		return
+	}
+
+	var req models.UpdateSegmentLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	segment, err := limitRepo.UpsertSegment(c.Request.Context(), c.Param("segment"), &req)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limits must be positive"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update limit segment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, segment)
+}
+
+func getUserLimits(c *gin.Context) {
+	if _, ok := requireAdmin(c); !ok {
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	respondWithLimits(c, userID)
+}
+
+func setUserLimitOverride(c *gin.Context) {
+	adminID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var req models.SetLimitOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override, err := limitRepo.SetOverride(c.Request.Context(), userID, adminID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidAmount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limits must be positive"})
+		case errors.Is(err, repository.ErrSegmentNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit segment not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set limit override"})
+		}
+		return
+	}
+
+	log.Printf("Admin %d set transfer limit override for user %d", adminID, userID)
+	c.JSON(http.StatusOK, override)
+}
+
+func deleteUserLimitOverride(c *gin.Context) {
+	adminID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	if err := limitRepo.DeleteOverride(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, repository.ErrLimitOverrideNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "limit override not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete limit override"})
+		return
+	}
+
+	log.Printf("Admin %d removed transfer limit override for user %d", adminID, userID)
+	c.JSON(http.StatusOK, gin.H{"message": "limit override removed"})
+}
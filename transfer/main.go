@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"transfer/cache"
 	"transfer/db"
@@ -29,16 +30,37 @@ import (
 )
 
 var (
-	dbPool        *pgxpool.Pool
-	redisClient   *redis.Client
-	transferRepo  repository.TransferRepo
-	kafkaProducer *kafka.Producer
-	kafkaConsumer *kafka.Consumer
+	dbPool           *pgxpool.Pool
+	redisClient      *redis.Client
+	transferRepo     repository.TransferRepo
+	limitRepo        repository.LimitRepo
+	batchRepo        repository.BatchRepo
+	feeRepo          repository.FeeRepo
+	moneyRequestRepo repository.MoneyRequestRepo
+	splitRepo        repository.SplitRepo
+	beneficiaryRepo  repository.BeneficiaryRepo
+	spendingRepo     repository.SpendingRepo
+	kafkaProducer    *kafka.Producer
+	kafkaConsumer    *kafka.Consumer
+
+	// approvalThreshold is the amount above which transfers require four-eyes approval
+	approvalThreshold decimal.Decimal
+
+	// moneyRequestTTL is how long a money request stays open before it expires
+	moneyRequestTTL time.Duration
+
+	// beneficiaryCoolingOff is how long a new beneficiary's payments stay capped at beneficiaryCoolingOffLimit
+	beneficiaryCoolingOff      time.Duration
+	beneficiaryCoolingOffLimit decimal.Decimal
+
+	// serviceCtx is cancelled on shutdown and bounds background work such as batch execution
+	serviceCtx context.Context
 )
 
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	serviceCtx = ctx
 
 	// Database configuration
 	dbConfig := db.Config{
@@ -77,6 +99,45 @@ func main() {
 	defer redisClient.Close()
 
 	transferRepo = cache.NewCachedTransferRepository(baseRepo, redisClient)
+	limitRepo = cache.NewCachedLimitRepository(repository.NewLimitRepository(dbPool), redisClient)
+	batchRepo = repository.NewBatchRepository(dbPool)
+	feeRepo = repository.NewFeeRepository(dbPool)
+	moneyRequestRepo = repository.NewMoneyRequestRepository(dbPool)
+	splitRepo = repository.NewSplitRepository(dbPool)
+	beneficiaryRepo = repository.NewBeneficiaryRepository(dbPool)
+	spendingRepo = repository.NewSpendingRepository(dbPool)
+
+	// Maker-checker configuration
+	approvalThreshold, err = decimal.NewFromString(getEnv("APPROVAL_THRESHOLD", "10000"))
+	if err != nil {
+		log.Fatalf("Invalid APPROVAL_THRESHOLD: %v", err)
+	}
+
+	expiryDays, err := strconv.Atoi(getEnv("MONEY_REQUEST_EXPIRY_DAYS", "7"))
+	if err != nil || expiryDays <= 0 {
+		log.Fatalf("Invalid MONEY_REQUEST_EXPIRY_DAYS: %q", getEnv("MONEY_REQUEST_EXPIRY_DAYS", "7"))
+	}
+	moneyRequestTTL = time.Duration(expiryDays) * 24 * time.Hour
+
+	splitReminderInterval, err := time.ParseDuration(getEnv("SPLIT_REMINDER_INTERVAL", "72h"))
+	if err != nil {
+		log.Fatalf("Invalid SPLIT_REMINDER_INTERVAL: %v", err)
+	}
+
+	beneficiaryCoolingOff, err = time.ParseDuration(getEnv("BENEFICIARY_COOLING_OFF", "24h"))
+	if err != nil {
+		log.Fatalf("Invalid BENEFICIARY_COOLING_OFF: %v", err)
+	}
+	beneficiaryCoolingOffLimit, err = decimal.NewFromString(getEnv("BENEFICIARY_COOLING_OFF_LIMIT", "500"))
+	if err != nil {
+		log.Fatalf("Invalid BENEFICIARY_COOLING_OFF_LIMIT: %v", err)
+	}
+
+	// Anyone who knows the receipt key can forge receipts, so there is no default
+	receiptKey = []byte(os.Getenv("RECEIPT_SIGNING_KEY"))
+	if len(receiptKey) == 0 {
+		log.Fatal("RECEIPT_SIGNING_KEY must be set")
+	}
 
 	// Initialize Kafka
 	kafkaBrokers := strings.Split(getEnv("KAFKA_BROKERS", "localhost:9092"), ",")
@@ -85,6 +146,9 @@ func main() {
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicTransferRequested)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicTransferCompleted)
 	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicTransferFailed)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicOpsAlerts)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicMoneyRequest)
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicSplitReminder)
 
 	// Initialize producer
 	kafkaProducer = kafka.NewProducer(kafkaBrokers)
@@ -95,18 +159,83 @@ func main() {
 	kafkaConsumer.Start(ctx)
 	defer kafkaConsumer.Close()
 
+	// Pick up batches interrupted by a restart or a failure
+	go runBatchSweeper(ctx)
+
+	// Recover transfers whose saga stalled
+	go runSagaSweeper(ctx, loadSagaConfig())
+
+	// Expire money requests nobody answered
+	go runMoneyRequestExpiry(ctx)
+
+	// Remind participants about unpaid split shares
+	go runSplitReminders(ctx, splitReminderInterval)
+
 	// Create Gin router
 	router := gin.Default()
 
 	// Health check endpoint
 	router.GET("/health", healthCheck)
 
+	// Anyone holding a receipt may check it
+	router.GET("/api/transfers/receipts/verify", verifyTransferReceipt)
+
 	// Transfer endpoints
 	api := router.Group("/api/transfers")
 	{
 		api.GET("", listTransfers)
+		api.GET("/limits", getLimits)
+		api.GET("/spending", getTransferSpending)
+		api.GET("/approvals", listPendingApprovals)
+		api.GET("/batches", listBatches)
+		api.GET("/batches/:id", getBatch)
+		api.POST("/batches", createBatch)
+		api.POST("/quote", quoteTransfer)
+		api.GET("/requests", listMoneyRequests)
+		api.POST("/requests", createMoneyRequest)
+		api.GET("/requests/:id", getMoneyRequest)
+		api.POST("/requests/:id/accept", acceptMoneyRequest)
+		api.POST("/requests/:id/decline", declineMoneyRequest)
+		api.POST("/requests/:id/cancel", cancelMoneyRequest)
+		api.GET("/splits", listSplits)
+		api.POST("/splits", createSplit)
+		api.GET("/splits/:id", getSplit)
+		api.POST("/splits/:id/pay", paySplitShare)
+		api.POST("/splits/:id/remind", remindSplit)
+		api.POST("/splits/:id/cancel", cancelSplit)
+		api.GET("/beneficiaries", listBeneficiaries)
+		api.POST("/beneficiaries", createBeneficiary)
+		api.GET("/beneficiaries/cooling-off", getCoolingOff)
+		api.GET("/beneficiaries/:id", getBeneficiary)
+		api.PUT("/beneficiaries/:id", updateBeneficiary)
+		api.DELETE("/beneficiaries/:id", deleteBeneficiary)
 		api.GET("/:id", getTransfer)
+		api.GET("/:id/history", getTransferHistory)
+		api.GET("/:id/receipt", getTransferReceipt)
+		api.PUT("/:id/category", setTransferCategory)
 		api.POST("", createTransfer)
+		api.POST("/:id/approve", approveTransfer)
+		api.POST("/:id/reject", rejectTransfer)
+		api.POST("/:id/reverse", reverseTransfer)
+	}
+
+	// Transfer limit administration (admin only)
+	limits := router.Group("/api/transfers/limits")
+	{
+		limits.GET("/segments", listLimitSegments)
+		limits.PUT("/segments/:segment", updateLimitSegment)
+		limits.GET("/users/:userId", getUserLimits)
+		limits.PUT("/users/:userId", setUserLimitOverride)
+		limits.DELETE("/users/:userId", deleteUserLimitOverride)
+	}
+
+	// Transfer fee administration (admin only)
+	fees := router.Group("/api/transfers/fees")
+	{
+		fees.GET("", listFeeRules)
+		fees.POST("", createFeeRule)
+		fees.PUT("/:id", updateFeeRule)
+		fees.DELETE("/:id", deleteFeeRule)
 	}
 
 	// Get port from environment or use default
@@ -132,6 +261,26 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// loadSagaConfig reads the saga recovery settings from the environment
+func loadSagaConfig() sagaConfig {
+	timeout, err := time.ParseDuration(getEnv("SAGA_TIMEOUT", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid SAGA_TIMEOUT: %v", err)
+	}
+
+	interval, err := time.ParseDuration(getEnv("SAGA_SWEEP_INTERVAL", "1m"))
+	if err != nil {
+		log.Fatalf("Invalid SAGA_SWEEP_INTERVAL: %v", err)
+	}
+
+	maxRepublish, err := strconv.Atoi(getEnv("SAGA_MAX_REPUBLISH", "3"))
+	if err != nil {
+		log.Fatalf("Invalid SAGA_MAX_REPUBLISH: %v", err)
+	}
+
+	return sagaConfig{Timeout: timeout, Interval: interval, MaxRepublish: maxRepublish}
+}
+
 func healthCheck(c *gin.Context) {
 	dbStatus := "connected"
 	if err := db.HealthCheck(c.Request.Context(), dbPool); err != nil {
@@ -168,6 +317,22 @@ func getUserContext(c *gin.Context) (userID int64, role string, err error) {
 	return userID, role, nil
 }
 
+// requireAdmin verifies the caller is an admin, writing an error response if not
+func requireAdmin(c *gin.Context) (int64, bool) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return 0, false
+	}
+
+	if role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return 0, false
+	}
+
+	return userID, true
+}
+
 func listTransfers(c *gin.Context) {
 	userID, role, err := getUserContext(c)
 	if err != nil {
@@ -182,11 +347,21 @@ func listTransfers(c *gin.Context) {
 		limit = 100
 	}
 
+	search, err := parseTransferSearch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var result *models.TransferListResponse
 
 	if role == "admin" {
 		// Admin can see all transfers
-		result, err = transferRepo.ListAll(c.Request.Context(), limit, offset)
+		if search.IsEmpty() {
+			result, err = transferRepo.ListAll(c.Request.Context(), limit, offset)
+		} else {
+			result, err = transferRepo.Search(c.Request.Context(), nil, search, limit, offset)
+		}
 	} else {
 		// Get user's accounts from account service
 		accountIDs, accErr := getUserAccountIDs(userID, role)
@@ -196,7 +371,14 @@ func listTransfers(c *gin.Context) {
 			return
 		}
 		log.Printf("User %d has accounts: %v", userID, accountIDs)
-		result, err = transferRepo.ListByAccountIDs(c.Request.Context(), accountIDs, limit, offset)
+		if search.IsEmpty() {
+			result, err = transferRepo.ListByAccountIDs(c.Request.Context(), accountIDs, limit, offset)
+		} else {
+			if accountIDs == nil {
+				accountIDs = []int64{}
+			}
+			result, err = transferRepo.Search(c.Request.Context(), accountIDs, search, limit, offset)
+		}
 	}
 
 	if err != nil {
@@ -283,6 +465,37 @@ func getTransfer(c *gin.Context) {
 	c.JSON(http.StatusOK, transfer)
 }
 
+// getTransferHistory returns every status transition of a transfer (admin only)
+func getTransferHistory(c *gin.Context) {
+	if _, ok := requireAdmin(c); !ok {
+		return
+	}
+
+	transferID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := transferRepo.GetByID(ctx, transferID); err != nil {
+		if errors.Is(err, repository.ErrTransferNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer"})
+		return
+	}
+
+	history, err := transferRepo.ListStatusHistory(ctx, transferID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfer_id": transferID, "history": history})
+}
+
 func createTransfer(c *gin.Context) {
 	userID, role, err := getUserContext(c)
 	if err != nil {
@@ -301,49 +514,112 @@ func createTransfer(c *gin.Context) {
 		return
 	}
 
+	if !resolveTransferRecipient(c, userID, role, &req) {
+		return
+	}
+
 	if req.FromAccountID == req.ToAccountID {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "source and destination accounts cannot be the same"})
 		return
 	}
 
-	// TODO: Verify user owns the source account by calling account service
-	// For now, we trust the request (in production, this must be verified)
-	_ = userID
-	_ = role
+	transfer, ok := initiateTransfer(c, userID, role, &req)
+	if !ok {
+		return
+	}
+
+	message := "transfer initiated"
+	if transfer.Status == models.TransferStatusPendingApproval {
+		message = "transfer awaiting approval"
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      message,
+		"transfer_id":  transfer.ID,
+		"reference_id": transfer.ReferenceID,
+		"status":       transfer.Status,
+		"fee":          transfer.Fee,
+		"total_debit":  transfer.Amount.Add(transfer.Fee),
+	})
+}
+
+// initiateTransfer checks limits, prices, records and dispatches a validated transfer request,
+// writing the error response and returning false if any step fails
+func initiateTransfer(c *gin.Context, userID int64, role string, req *models.CreateTransferRequest) (*models.Transfer, bool) {
+	// Per-user limits and velocity controls are enforced as the transfer is recorded
+	limits, err := effectiveLimits(c.Request.Context(), userID)
+	if err != nil {
+		respondLimitError(c, err)
+		return nil, false
+	}
+
+	// Price the transfer; looking up the source account as the caller also verifies ownership
+	quote, ok := priceTransfer(c, userID, role, req)
+	if !ok {
+		return nil, false
+	}
+	req.Currency = quote.Currency
 
 	// Create transfer record
-	transfer, err := transferRepo.Create(c.Request.Context(), &req)
+	transfer, err := transferRepo.Create(c.Request.Context(), userID, req, quote, limits)
 	if err != nil {
 		switch {
+		case repository.IsLimitError(err):
+			respondLimitError(c, err)
 		case errors.Is(err, repository.ErrInvalidAmount):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
 		case errors.Is(err, repository.ErrSameAccount):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "source and destination accounts cannot be the same"})
+		case errors.Is(err, repository.ErrMoneyRequestClosed):
+			c.JSON(http.StatusConflict, gin.H{"error": "money request is no longer pending"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create transfer"})
 		}
-		return
+		return nil, false
+	}
+
+	transfer, err = dispatchTransfer(c.Request.Context(), transfer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate transfer"})
+		return nil, false
+	}
+
+	return transfer, true
+}
+
+// dispatchTransfer moves a newly created transfer forward: high-value transfers are held
+// for four-eyes review, everything else is marked processing and published to Kafka.
+func dispatchTransfer(ctx context.Context, transfer *models.Transfer) (*models.Transfer, error) {
+	if requiresApproval(transfer) {
+		held, err := transferRepo.MarkAsPendingApproval(ctx, transfer.ID)
+		if err != nil {
+			log.Printf("Failed to hold transfer %d for approval: %v", transfer.ID, err)
+			return nil, err
+		}
+
+		log.Printf("Transfer %d (amount %s) held for approval", held.ID, held.Amount)
+		return held, nil
 	}
 
 	// Mark as processing
-	transfer, err = transferRepo.MarkAsProcessing(c.Request.Context(), transfer.ID)
+	processing, err := transferRepo.MarkAsProcessing(ctx, transfer.ID)
 	if err != nil {
 		log.Printf("Failed to mark transfer %d as processing: %v", transfer.ID, err)
+	} else {
+		transfer = processing
 	}
 
-	// Publish event to Kafka
-	if err := kafkaProducer.PublishTransferRequested(c.Request.Context(), transfer); err != nil {
+	return publishTransfer(ctx, transfer)
+}
+
+// publishTransfer publishes the transfer requested event, failing the transfer if that is not possible
+func publishTransfer(ctx context.Context, transfer *models.Transfer) (*models.Transfer, error) {
+	if err := kafkaProducer.PublishTransferRequested(ctx, transfer); err != nil {
 		log.Printf("Failed to publish transfer event: %v", err)
 		// Mark as failed since we couldn't process it
-		transferRepo.MarkAsFailed(c.Request.Context(), transfer.ID, "failed to publish transfer event")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate transfer"})
-		return
+		transferRepo.MarkAsFailed(ctx, transfer.ID, "failed to publish transfer event", models.TransitionCausePublishFailed)
+		return nil, err
 	}
 
-	c.JSON(http.StatusAccepted, gin.H{
-		"message":      "transfer initiated",
-		"transfer_id":  transfer.ID,
-		"reference_id": transfer.ReferenceID,
-		"status":       transfer.Status,
-	})
+	return transfer, nil
 }
@@ -0,0 +1,11 @@
+-- Drop triggers first
+DROP TRIGGER IF EXISTS update_transfer_limit_overrides_updated_at ON transfer_limit_overrides;
+DROP TRIGGER IF EXISTS update_transfer_limit_segments_updated_at ON transfer_limit_segments;
+
+-- Drop tables
+DROP TABLE IF EXISTS transfer_limit_overrides;
+DROP TABLE IF EXISTS transfer_limit_segments;
+
+-- Drop initiator tracking
+DROP INDEX IF EXISTS idx_transfers_initiated_by_created_at;
+ALTER TABLE transfers DROP COLUMN IF EXISTS initiated_by;
@@ -0,0 +1,48 @@
+-- Track which user initiated each transfer (used for per-user velocity controls)
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS initiated_by BIGINT;
+
+CREATE INDEX idx_transfers_initiated_by_created_at ON transfers(initiated_by, created_at);
+
+-- Create segment limits table
+CREATE TABLE IF NOT EXISTS transfer_limit_segments (
+    segment VARCHAR(30) PRIMARY KEY,
+    per_transaction_max DECIMAL(15,2) NOT NULL,
+    daily_max DECIMAL(15,2) NOT NULL,
+    monthly_max DECIMAL(15,2) NOT NULL,
+    hourly_count_max INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create per-user overrides table (NULL columns fall back to the segment value)
+CREATE TABLE IF NOT EXISTS transfer_limit_overrides (
+    user_id BIGINT PRIMARY KEY,
+    segment VARCHAR(30) REFERENCES transfer_limit_segments(segment),
+    per_transaction_max DECIMAL(15,2),
+    daily_max DECIMAL(15,2),
+    monthly_max DECIMAL(15,2),
+    hourly_count_max INTEGER,
+    reason TEXT,
+    updated_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_transfer_limit_segments_updated_at BEFORE UPDATE ON transfer_limit_segments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_transfer_limit_overrides_updated_at BEFORE UPDATE ON transfer_limit_overrides
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Seed default segments
+INSERT INTO transfer_limit_segments (segment, per_transaction_max, daily_max, monthly_max, hourly_count_max)
+VALUES
+    ('standard', 5000.00, 10000.00, 50000.00, 10),
+    ('premium', 25000.00, 50000.00, 250000.00, 30),
+    ('business', 100000.00, 250000.00, 2000000.00, 100)
+ON CONFLICT (segment) DO NOTHING;
+
+-- Add comments for documentation
+COMMENT ON TABLE transfer_limit_segments IS 'Default transfer limits per customer segment';
+COMMENT ON TABLE transfer_limit_overrides IS 'Admin overrides of transfer limits for specific customers';
+COMMENT ON COLUMN transfers.initiated_by IS 'ID of the user who initiated the transfer';
@@ -0,0 +1,2 @@
+ALTER TABLE transfers DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE transfers DROP COLUMN IF EXISTS reviewed_by;
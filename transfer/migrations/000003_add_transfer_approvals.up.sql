@@ -0,0 +1,8 @@
+-- Track four-eyes review of high-value transfers
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS reviewed_by BIGINT;
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.status IS 'Transfer status: pending, pending_approval, processing, completed, failed, or rejected';
+COMMENT ON COLUMN transfers.reviewed_by IS 'ID of the admin who approved or rejected the transfer';
+COMMENT ON COLUMN transfers.reviewed_at IS 'When the transfer was approved or rejected';
@@ -0,0 +1,10 @@
+-- Drop trigger first
+DROP TRIGGER IF EXISTS update_transfer_batches_updated_at ON transfer_batches;
+
+-- Unlink child transfers
+DROP INDEX IF EXISTS idx_transfers_batch_id;
+ALTER TABLE transfers DROP COLUMN IF EXISTS batch_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS transfer_batch_items;
+DROP TABLE IF EXISTS transfer_batches;
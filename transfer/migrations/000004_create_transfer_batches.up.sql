@@ -0,0 +1,51 @@
+-- Create transfer batches table (bulk uploads such as payroll)
+CREATE TABLE IF NOT EXISTS transfer_batches (
+    id BIGSERIAL PRIMARY KEY,
+    reference_id UUID UNIQUE DEFAULT gen_random_uuid(),
+    from_account_id BIGINT NOT NULL,
+    currency VARCHAR(3) DEFAULT 'USD',
+    initiated_by BIGINT NOT NULL,
+    total_items INTEGER NOT NULL,
+    total_amount DECIMAL(15,2) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    source_format VARCHAR(10) NOT NULL,  -- 'csv', 'json'
+    status VARCHAR(20) DEFAULT 'processing',  -- 'processing', 'submitted'
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    submitted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create transfer batch items table (one row per instruction line)
+CREATE TABLE IF NOT EXISTS transfer_batch_items (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES transfer_batches(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    to_account_id BIGINT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    reference VARCHAR(140),
+    transfer_id BIGINT REFERENCES transfers(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (batch_id, line_number)
+);
+
+-- Link child transfers to their batch
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS batch_id BIGINT REFERENCES transfer_batches(id);
+
+-- Indexes
+CREATE INDEX idx_transfer_batches_from_account_id ON transfer_batches(from_account_id);
+CREATE INDEX idx_transfer_batches_initiated_by ON transfer_batches(initiated_by);
+CREATE INDEX idx_transfer_batches_checksum ON transfer_batches(checksum);
+CREATE INDEX idx_transfer_batches_status ON transfer_batches(status);
+CREATE INDEX idx_transfer_batch_items_batch_id ON transfer_batch_items(batch_id);
+CREATE INDEX idx_transfers_batch_id ON transfers(batch_id);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_transfer_batches_updated_at BEFORE UPDATE ON transfer_batches
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE transfer_batches IS 'Bulk transfer uploads executed as individual child transfers';
+COMMENT ON COLUMN transfer_batches.checksum IS 'SHA-256 of the normalized instructions, used to detect duplicate uploads';
+COMMENT ON COLUMN transfer_batches.status IS 'Batch status: processing (child transfers being created) or submitted';
+COMMENT ON COLUMN transfer_batch_items.transfer_id IS 'Child transfer created for this line, NULL until submitted';
+COMMENT ON COLUMN transfers.batch_id IS 'Batch this transfer was created from, if any';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transfers_active_reversal;
+ALTER TABLE transfers DROP COLUMN IF EXISTS reversed_amount;
+ALTER TABLE transfers DROP COLUMN IF EXISTS reversal_reason;
+ALTER TABLE transfers DROP COLUMN IF EXISTS reversal_policy;
+ALTER TABLE transfers DROP COLUMN IF EXISTS reversal_of;
@@ -0,0 +1,16 @@
+-- Link reversal transfers to the transfer they undo
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS reversal_of BIGINT REFERENCES transfers(id);
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS reversal_policy VARCHAR(10);
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS reversal_reason TEXT;
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS reversed_amount DECIMAL(15,2);
+
+-- At most one live reversal per transfer; failed reversals may be retried
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfers_active_reversal ON transfers(reversal_of)
+    WHERE reversal_of IS NOT NULL AND status NOT IN ('failed', 'rejected');
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.status IS 'Transfer status: pending, pending_approval, processing, completed, failed, rejected, or reversed';
+COMMENT ON COLUMN transfers.reversal_of IS 'For reversal transfers, the ID of the transfer being reversed';
+COMMENT ON COLUMN transfers.reversal_policy IS 'What to do when the recipient cannot cover the reversal: partial or hold';
+COMMENT ON COLUMN transfers.reversal_reason IS 'Admin-supplied reason for the reversal';
+COMMENT ON COLUMN transfers.reversed_amount IS 'Amount actually returned to the sender, on both the reversal and the original transfer';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transfers_status_updated_at;
+ALTER TABLE transfers DROP COLUMN IF EXISTS saga_attempts;
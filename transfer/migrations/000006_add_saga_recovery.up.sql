@@ -0,0 +1,8 @@
+-- Track how often the saga sweeper has re-published a stuck transfer
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS saga_attempts INT NOT NULL DEFAULT 0;
+
+-- Lets the sweeper find stale in-flight transfers cheaply
+CREATE INDEX IF NOT EXISTS idx_transfers_status_updated_at ON transfers(status, updated_at);
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.saga_attempts IS 'Number of times the saga sweeper re-published this transfer';
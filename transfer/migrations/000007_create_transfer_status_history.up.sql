@@ -0,0 +1,17 @@
+-- Audit trail of every transfer status transition
+CREATE TABLE IF NOT EXISTS transfer_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    transfer_id BIGINT NOT NULL REFERENCES transfers(id),
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    cause VARCHAR(50) NOT NULL,
+    detail TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_status_history_transfer_id ON transfer_status_history(transfer_id, created_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE transfer_status_history IS 'Every status transition applied to a transfer';
+COMMENT ON COLUMN transfer_status_history.cause IS 'What triggered the transition: dispatched, held, approved, rejected, publish_failed, account_result, saga_recovery, saga_timeout, reversed';
+COMMENT ON COLUMN transfer_status_history.detail IS 'Failure or rejection reason, if any';
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_transfer_fee_rules_updated_at ON transfer_fee_rules;
+DROP TABLE IF EXISTS transfer_fee_rules;
+ALTER TABLE transfers DROP COLUMN IF EXISTS channel;
+ALTER TABLE transfers DROP COLUMN IF EXISTS fee;
@@ -0,0 +1,39 @@
+-- Fee charged to the sender of each transfer and the channel it was priced for
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS fee DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'internal';
+
+-- Create fee rules table (NULL account_type / currency match any value)
+CREATE TABLE IF NOT EXISTS transfer_fee_rules (
+    id BIGSERIAL PRIMARY KEY,
+    channel VARCHAR(20) NOT NULL,  -- 'internal', 'external', 'fx'
+    account_type VARCHAR(20),
+    currency VARCHAR(3),
+    flat_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    percentage DECIMAL(7,4) NOT NULL DEFAULT 0,
+    min_fee DECIMAL(15,2),
+    max_fee DECIMAL(15,2),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- One rule per channel / product / currency combination
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfer_fee_rules_scope
+    ON transfer_fee_rules(channel, COALESCE(account_type, ''), COALESCE(currency, ''));
+
+CREATE TRIGGER update_transfer_fee_rules_updated_at BEFORE UPDATE ON transfer_fee_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Seed default rules: internal transfers are free, external and FX transfers are charged
+INSERT INTO transfer_fee_rules (channel, account_type, currency, flat_amount, percentage, min_fee, max_fee)
+VALUES
+    ('internal', NULL, NULL, 0.00, 0.0000, NULL, NULL),
+    ('external', NULL, NULL, 1.00, 0.1000, 1.00, 25.00),
+    ('fx', NULL, NULL, 0.00, 0.5000, 2.00, 50.00)
+ON CONFLICT DO NOTHING;
+
+-- Add comments for documentation
+COMMENT ON TABLE transfer_fee_rules IS 'Transfer fee rules by channel, source account product and currency; the most specific active rule applies';
+COMMENT ON COLUMN transfer_fee_rules.percentage IS 'Percentage of the transfer amount, added to flat_amount before min/max are applied';
+COMMENT ON COLUMN transfers.fee IS 'Fee debited from the source account in addition to the amount';
+COMMENT ON COLUMN transfers.channel IS 'Fee channel the transfer was priced for: internal, external or fx';
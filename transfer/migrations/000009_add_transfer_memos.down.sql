@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transfers_amount;
+DROP INDEX IF EXISTS idx_transfers_category;
+DROP INDEX IF EXISTS idx_transfers_memo_search;
+ALTER TABLE transfers DROP COLUMN IF EXISTS memo_search;
+ALTER TABLE transfers DROP COLUMN IF EXISTS category;
+ALTER TABLE transfers DROP COLUMN IF EXISTS memo;
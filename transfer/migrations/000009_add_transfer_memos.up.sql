@@ -0,0 +1,16 @@
+-- Free-text memo and spending category on each transfer
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS memo VARCHAR(140);
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS category VARCHAR(30) NOT NULL DEFAULT 'general';
+
+-- Full-text index over memos ('simple' config: memos are short and often not in English)
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS memo_search TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(memo, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_transfers_memo_search ON transfers USING GIN (memo_search);
+CREATE INDEX IF NOT EXISTS idx_transfers_category ON transfers(category);
+CREATE INDEX IF NOT EXISTS idx_transfers_amount ON transfers(amount);
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.memo IS 'Optional note from the sender describing what the transfer is for';
+COMMENT ON COLUMN transfers.category IS 'Category assigned by the user, or derived from the memo';
+COMMENT ON COLUMN transfers.memo_search IS 'Full-text search vector over the memo';
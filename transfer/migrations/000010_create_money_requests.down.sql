@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS update_money_requests_updated_at ON money_requests;
+DROP INDEX IF EXISTS idx_money_requests_pending_expiry;
+DROP INDEX IF EXISTS idx_money_requests_payer_user_id;
+DROP INDEX IF EXISTS idx_money_requests_requester_user_id;
+DROP TABLE IF EXISTS money_requests;
@@ -0,0 +1,35 @@
+-- Create money requests table (a customer asking another customer to pay them)
+CREATE TABLE IF NOT EXISTS money_requests (
+    id BIGSERIAL PRIMARY KEY,
+    reference_id UUID UNIQUE DEFAULT gen_random_uuid(),
+    requester_user_id BIGINT NOT NULL,
+    requester_account_id BIGINT NOT NULL,
+    payer_user_id BIGINT NOT NULL,
+    payer_account_id BIGINT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) DEFAULT 'USD',
+    note VARCHAR(140),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- 'pending', 'accepted', 'declined', 'cancelled', 'expired'
+    transfer_id BIGINT REFERENCES transfers(id),
+    decline_reason TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    responded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Indexes
+CREATE INDEX idx_money_requests_requester_user_id ON money_requests(requester_user_id, created_at DESC);
+CREATE INDEX idx_money_requests_payer_user_id ON money_requests(payer_user_id, created_at DESC);
+CREATE INDEX idx_money_requests_pending_expiry ON money_requests(expires_at) WHERE status = 'pending';
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_money_requests_updated_at BEFORE UPDATE ON money_requests
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE money_requests IS 'Requests from one customer for another customer to pay them';
+COMMENT ON COLUMN money_requests.requester_account_id IS 'Account of the requester that is credited when the request is paid';
+COMMENT ON COLUMN money_requests.payer_account_id IS 'Account the request was addressed to, debited by default when accepted';
+COMMENT ON COLUMN money_requests.status IS 'Request status: pending, accepted, declined, cancelled or expired';
+COMMENT ON COLUMN money_requests.transfer_id IS 'Transfer created when the payer accepted the request';
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_bill_split_shares_updated_at ON bill_split_shares;
+DROP TRIGGER IF EXISTS update_bill_splits_updated_at ON bill_splits;
+DROP TABLE IF EXISTS bill_split_shares;
+DROP TABLE IF EXISTS bill_splits;
@@ -0,0 +1,53 @@
+-- Create bill splits table (a total amount shared out among other customers)
+CREATE TABLE IF NOT EXISTS bill_splits (
+    id BIGSERIAL PRIMARY KEY,
+    reference_id UUID UNIQUE DEFAULT gen_random_uuid(),
+    owner_user_id BIGINT NOT NULL,
+    account_id BIGINT NOT NULL,
+    total_amount DECIMAL(15,2) NOT NULL CHECK (total_amount > 0),
+    currency VARCHAR(3) DEFAULT 'USD',
+    split_method VARCHAR(10) NOT NULL,  -- 'even', 'custom'
+    description VARCHAR(140),
+    status VARCHAR(20) NOT NULL DEFAULT 'open',  -- 'open', 'settled', 'cancelled'
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    settled_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create bill split shares table (one row per participant)
+CREATE TABLE IF NOT EXISTS bill_split_shares (
+    id BIGSERIAL PRIMARY KEY,
+    split_id BIGINT NOT NULL REFERENCES bill_splits(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    account_id BIGINT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'unpaid',  -- 'unpaid', 'pending', 'paid'
+    transfer_id BIGINT REFERENCES transfers(id),
+    paid_at TIMESTAMP WITH TIME ZONE,
+    reminders_sent INTEGER NOT NULL DEFAULT 0,
+    last_reminded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (split_id, user_id)
+);
+
+-- Indexes
+CREATE INDEX idx_bill_splits_owner_user_id ON bill_splits(owner_user_id, created_at DESC);
+CREATE INDEX idx_bill_splits_status ON bill_splits(status);
+CREATE INDEX idx_bill_split_shares_user_id ON bill_split_shares(user_id);
+CREATE INDEX idx_bill_split_shares_transfer_id ON bill_split_shares(transfer_id);
+
+-- Create triggers to automatically update updated_at
+CREATE TRIGGER update_bill_splits_updated_at BEFORE UPDATE ON bill_splits
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_bill_split_shares_updated_at BEFORE UPDATE ON bill_split_shares
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE bill_splits IS 'A total amount split among other customers, each paying their share by transfer';
+COMMENT ON COLUMN bill_splits.account_id IS 'Account of the owner that shares are paid into';
+COMMENT ON COLUMN bill_splits.split_method IS 'How shares were allocated: even or custom';
+COMMENT ON COLUMN bill_splits.status IS 'Split status: open, settled (every share paid) or cancelled';
+COMMENT ON COLUMN bill_split_shares.status IS 'Share status: unpaid, pending (transfer in flight) or paid';
+COMMENT ON COLUMN bill_split_shares.transfer_id IS 'Transfer paying this share; cleared again if that transfer fails';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transfers_beneficiary_id;
+ALTER TABLE transfers DROP COLUMN IF EXISTS beneficiary_id;
+DROP TRIGGER IF EXISTS update_beneficiaries_updated_at ON beneficiaries;
+DROP TABLE IF EXISTS beneficiaries;
@@ -0,0 +1,39 @@
+-- Create beneficiaries table (saved payees per user)
+CREATE TABLE IF NOT EXISTS beneficiaries (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    nickname VARCHAR(50) NOT NULL,
+    beneficiary_type VARCHAR(10) NOT NULL,  -- 'internal', 'iban', 'external'
+    account_id BIGINT,
+    iban VARCHAR(34),
+    account_number VARCHAR(34),
+    bank_name VARCHAR(100),
+    bank_code VARCHAR(11),
+    recipient_name VARCHAR(100),
+    cooling_off_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, nickname),
+    CHECK (
+        (beneficiary_type = 'internal' AND account_id IS NOT NULL) OR
+        (beneficiary_type = 'iban' AND iban IS NOT NULL) OR
+        (beneficiary_type = 'external' AND account_number IS NOT NULL AND bank_name IS NOT NULL)
+    )
+);
+
+-- Link transfers to the beneficiary they were sent to
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS beneficiary_id BIGINT REFERENCES beneficiaries(id) ON DELETE SET NULL;
+
+-- Indexes
+CREATE INDEX idx_beneficiaries_user_id ON beneficiaries(user_id);
+CREATE INDEX idx_transfers_beneficiary_id ON transfers(beneficiary_id);
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_beneficiaries_updated_at BEFORE UPDATE ON beneficiaries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE beneficiaries IS 'Saved payees a user can send transfers and external payments to';
+COMMENT ON COLUMN beneficiaries.beneficiary_type IS 'Destination kind: internal (account_id), iban, or external (account_number at bank_name)';
+COMMENT ON COLUMN beneficiaries.cooling_off_until IS 'Until this time payments to the beneficiary are capped as a fraud control';
+COMMENT ON COLUMN transfers.beneficiary_id IS 'Saved beneficiary the transfer was sent to, if any';
@@ -0,0 +1,6 @@
+-- Batches that cannot create all of their child transfers are stopped rather than left processing
+ALTER TABLE transfer_batches ADD COLUMN IF NOT EXISTS failure_reason TEXT;
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfer_batches.status IS 'Batch status: processing (child transfers being created), submitted, or failed or partially_submitted when execution stopped';
+COMMENT ON COLUMN transfer_batches.failure_reason IS 'Why execution stopped before every line had a child transfer';
@@ -0,0 +1,6 @@
+UPDATE transfers SET status = 'reversed'
+WHERE status = 'completed' AND reversal_of IS NULL AND reversed_amount > 0;
+
+DROP INDEX IF EXISTS idx_transfers_active_reversal;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfers_active_reversal ON transfers(reversal_of)
+    WHERE reversal_of IS NOT NULL AND status NOT IN ('failed', 'rejected');
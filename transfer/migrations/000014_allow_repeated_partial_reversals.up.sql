@@ -0,0 +1,11 @@
+-- A transfer may be reversed in parts; only one reversal may be in flight at a time
+DROP INDEX IF EXISTS idx_transfers_active_reversal;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfers_active_reversal ON transfers(reversal_of)
+    WHERE reversal_of IS NOT NULL AND status IN ('pending', 'pending_approval', 'processing');
+
+-- Partially reversed transfers stay completed so the rest can still be reversed
+UPDATE transfers SET status = 'completed'
+WHERE status = 'reversed' AND reversal_of IS NULL AND reversed_amount < amount;
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.reversed_amount IS 'Amount actually returned to the sender: by this reversal, or in total across the reversals of the original transfer, which is marked reversed once it reaches the amount';
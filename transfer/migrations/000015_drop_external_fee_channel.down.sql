@@ -0,0 +1,5 @@
+INSERT INTO transfer_fee_rules (channel, account_type, currency, flat_amount, percentage, min_fee, max_fee)
+VALUES ('external', NULL, NULL, 1.00, 0.1000, 1.00, 25.00)
+ON CONFLICT DO NOTHING;
+
+COMMENT ON COLUMN transfers.channel IS 'Fee channel the transfer was priced for: internal, external or fx';
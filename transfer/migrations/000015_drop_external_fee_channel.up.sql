@@ -0,0 +1,6 @@
+-- Transfers only reach accounts held at this bank, so the external channel is never priced;
+-- payments to other banks are made through the payment service
+DELETE FROM transfer_fee_rules WHERE channel = 'external';
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.channel IS 'Fee channel the transfer was priced for: internal or fx';
@@ -0,0 +1,2 @@
+ALTER TABLE transfers DROP COLUMN IF EXISTS recipient_category;
+COMMENT ON COLUMN transfers.category IS 'Category assigned by the user, or derived from the memo';
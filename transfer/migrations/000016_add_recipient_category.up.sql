@@ -0,0 +1,7 @@
+-- Sender and recipient categorize a transfer independently; the recipient's category falls back to
+-- the sender's until they set their own
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS recipient_category VARCHAR(30);
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.category IS 'Sender''s category: assigned by the sender, or derived from the memo';
+COMMENT ON COLUMN transfers.recipient_category IS 'Category assigned by the recipient; NULL uses category';
@@ -0,0 +1,4 @@
+UPDATE money_requests SET status = 'accepted' WHERE status = 'paid';
+
+COMMENT ON COLUMN money_requests.status IS 'Request status: pending, accepted, declined, cancelled or expired';
+COMMENT ON COLUMN money_requests.transfer_id IS 'Transfer created when the payer accepted the request';
@@ -0,0 +1,14 @@
+-- Accepted requests follow their transfer: paid once it completes, pending again if it fails
+UPDATE money_requests m SET status = 'paid'
+FROM transfers t
+WHERE t.id = m.transfer_id AND m.status = 'accepted' AND t.status IN ('completed', 'reversed');
+
+UPDATE money_requests m
+SET status = CASE WHEN m.expires_at > NOW() THEN 'pending' ELSE 'expired' END,
+    transfer_id = NULL, responded_at = NULL
+FROM transfers t
+WHERE t.id = m.transfer_id AND m.status = 'accepted' AND t.status IN ('failed', 'rejected');
+
+-- Add comments for documentation
+COMMENT ON COLUMN money_requests.status IS 'Request status: pending, accepted (transfer on its way), paid, declined, cancelled or expired';
+COMMENT ON COLUMN money_requests.transfer_id IS 'Transfer paying the request since the payer accepted it; cleared if it fails';
@@ -0,0 +1 @@
+ALTER TABLE transfers DROP COLUMN IF EXISTS fee_refunded;
@@ -0,0 +1,5 @@
+-- Reversals return the matching share of the reversed transfer's fee to its sender
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS fee_refunded DECIMAL(15,2);
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.fee_refunded IS 'Fee returned to the sender: by this reversal (its share of the fee until it completes), or in total across the reversals of the original transfer';
@@ -0,0 +1,4 @@
+DELETE FROM transfer_status_history
+WHERE cause = 'migration' AND from_status = 'reversed' AND to_status = 'completed';
+
+COMMENT ON COLUMN transfer_status_history.cause IS 'What triggered the transition: dispatched, held, approved, rejected, publish_failed, account_result, saga_recovery, saga_timeout, reversed';
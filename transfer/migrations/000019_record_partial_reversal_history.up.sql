@@ -0,0 +1,15 @@
+-- Migration 000014 moved partially reversed transfers from reversed back to completed without
+-- recording it; add the missing entries to their status history
+INSERT INTO transfer_status_history (transfer_id, from_status, to_status, cause, detail)
+SELECT t.id, 'reversed', 'completed', 'migration', 'partially reversed transfers stay completed so the rest can still be reversed'
+FROM transfers t
+WHERE t.status = 'completed' AND t.reversal_of IS NULL AND t.reversed_amount > 0 AND t.reversed_amount < t.amount
+  AND EXISTS (
+      SELECT 1 FROM transfer_status_history h WHERE h.transfer_id = t.id AND h.to_status = 'reversed'
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM transfer_status_history h WHERE h.transfer_id = t.id AND h.from_status = 'reversed'
+  );
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfer_status_history.cause IS 'What triggered the transition: dispatched, held, approved, rejected, publish_failed, account_result, saga_recovery, saga_timeout, reversed, migration';
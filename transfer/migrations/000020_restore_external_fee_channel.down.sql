@@ -0,0 +1,3 @@
+DELETE FROM transfer_fee_rules WHERE channel = 'external';
+
+COMMENT ON COLUMN transfers.channel IS 'Fee channel the transfer was priced for: internal or fx';
@@ -0,0 +1,8 @@
+-- Bring back the external channel so fee rules for transfers to other banks can be configured
+-- ahead of those transfers; it is not priced until they are supported
+INSERT INTO transfer_fee_rules (channel, account_type, currency, flat_amount, percentage, min_fee, max_fee)
+VALUES ('external', NULL, NULL, 1.00, 0.1000, 1.00, 25.00)
+ON CONFLICT DO NOTHING;
+
+-- Add comments for documentation
+COMMENT ON COLUMN transfers.channel IS 'Fee channel the transfer was priced for: internal, external or fx';
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// MaxBatchItems is the maximum number of instructions accepted in one batch
+const MaxBatchItems = 5000
+
+// Batch statuses
+const (
+	BatchStatusProcessing            = "processing"
+	BatchStatusSubmitted             = "submitted"
+	BatchStatusCompleted             = "completed"
+	BatchStatusCompletedWithFailures = "completed_with_failures"
+
+	// A batch whose execution stopped on an error is failed if no line was submitted, and
+	// partially submitted otherwise; the remaining lines are never executed
+	BatchStatusFailed             = "failed"
+	BatchStatusPartiallySubmitted = "partially_submitted"
+	BatchStatusPartiallyCompleted = "partially_completed"
+)
+
+// Batch source formats
+const (
+	BatchFormatCSV  = "csv"
+	BatchFormatJSON = "json"
+)
+
+// Statuses reported for lines without a child transfer: queued while the batch runs, and not
+// submitted once it has stopped
+const (
+	BatchItemStatusQueued       = "queued"
+	BatchItemStatusNotSubmitted = "not_submitted"
+)
+
+type TransferBatch struct {
+	ID            int64           `json:"id"`
+	ReferenceID   uuid.UUID       `json:"reference_id"`
+	FromAccountID int64           `json:"from_account_id"`
+	Currency      string          `json:"currency"`
+	InitiatedBy   int64           `json:"initiated_by"`
+	TotalItems    int             `json:"total_items"`
+	TotalAmount   decimal.Decimal `json:"total_amount"`
+	Checksum      string          `json:"checksum"`
+	SourceFormat  string          `json:"source_format"`
+	Status        string          `json:"status"`
+	FailureReason *string         `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	SubmittedAt   *time.Time      `json:"submitted_at,omitempty"`
+}
+
+// BatchInstruction is a single parsed line of a batch upload
+type BatchInstruction struct {
+	LineNumber  int             `json:"line_number"`
+	ToAccountID int64           `json:"to_account_id"`
+	Amount      decimal.Decimal `json:"amount"`
+	Reference   string          `json:"reference,omitempty"`
+}
+
+// TransferBatchItem is a stored batch line together with the progress of its child transfer
+type TransferBatchItem struct {
+	ID            int64           `json:"id"`
+	BatchID       int64           `json:"batch_id"`
+	LineNumber    int             `json:"line_number"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Reference     *string         `json:"reference,omitempty"`
+	TransferID    *int64          `json:"transfer_id,omitempty"`
+	Status        string          `json:"status"`
+	FailureReason *string         `json:"failure_reason,omitempty"`
+}
+
+// BatchLineError describes a validation problem on one line of a batch upload
+type BatchLineError struct {
+	Line  int    `json:"line"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// CreateBatchRequest is the JSON form of a batch upload
+type CreateBatchRequest struct {
+	FromAccountID int64              `json:"from_account_id" binding:"required"`
+	Currency      string             `json:"currency" binding:"omitempty,len=3"`
+	Items         []BatchInstruction `json:"items" binding:"required"`
+}
+
+// BatchProgress summarizes child transfer statuses for a batch
+type BatchProgress struct {
+	Queued          int `json:"queued"`
+	PendingApproval int `json:"pending_approval"`
+	Processing      int `json:"processing"`
+	Completed       int `json:"completed"`
+	Failed          int `json:"failed"`
+	Rejected        int `json:"rejected"`
+	NotSubmitted    int `json:"not_submitted"`
+}
+
+// InFlight returns the number of lines that have not reached a terminal status
+func (p BatchProgress) InFlight() int {
+	return p.Queued + p.PendingApproval + p.Processing
+}
+
+type TransferBatchResponse struct {
+	Batch    *TransferBatch      `json:"batch"`
+	Progress BatchProgress       `json:"progress"`
+	Items    []TransferBatchItem `json:"items"`
+	Total    int64               `json:"total"`
+}
+
+type TransferBatchListResponse struct {
+	Batches []TransferBatch `json:"batches"`
+	Total   int64           `json:"total"`
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Beneficiary types
+const (
+	BeneficiaryTypeInternal = "internal" // an account at this bank, paid by transfer
+	BeneficiaryTypeIBAN     = "iban"     // an IBAN at another bank, paid by external payment
+	BeneficiaryTypeExternal = "external" // an account number at another bank, paid by external payment
+)
+
+// BeneficiaryCodeCoolingOff is the error code returned when a payment to a new beneficiary exceeds the cooling-off cap
+const BeneficiaryCodeCoolingOff = "beneficiary_cooling_off_limit_exceeded"
+
+var (
+	ErrInvalidIBAN              = errors.New("invalid IBAN")
+	ErrBeneficiaryAccountNeeded = errors.New("account_id is required for internal beneficiaries")
+	ErrBeneficiaryIBANNeeded    = errors.New("iban is required for iban beneficiaries")
+	ErrBeneficiaryBankNeeded    = errors.New("account_number and bank_name are required for external beneficiaries")
+)
+
+// Beneficiary is a saved payee. InCoolingOff and MaxAmount are derived from CoolingOffUntil when it is served.
+type Beneficiary struct {
+	ID              int64            `json:"id"`
+	UserID          int64            `json:"user_id"`
+	Nickname        string           `json:"nickname"`
+	Type            string           `json:"beneficiary_type"`
+	AccountID       *int64           `json:"account_id,omitempty"`
+	IBAN            *string          `json:"iban,omitempty"`
+	AccountNumber   *string          `json:"account_number,omitempty"`
+	BankName        *string          `json:"bank_name,omitempty"`
+	BankCode        *string          `json:"bank_code,omitempty"`
+	RecipientName   *string          `json:"recipient_name,omitempty"`
+	CoolingOffUntil time.Time        `json:"cooling_off_until"`
+	InCoolingOff    bool             `json:"in_cooling_off"`
+	MaxAmount       *decimal.Decimal `json:"max_amount,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// ApplyCoolingOff sets InCoolingOff and, while it lasts, the per-payment cap
+func (b *Beneficiary) ApplyCoolingOff(now time.Time, limit decimal.Decimal) {
+	b.InCoolingOff = now.Before(b.CoolingOffUntil)
+	b.MaxAmount = nil
+	if b.InCoolingOff {
+		capped := limit
+		b.MaxAmount = &capped
+	}
+}
+
+// Allows reports whether a payment of the given amount may be sent to the beneficiary
+func (b *Beneficiary) Allows(amount decimal.Decimal) bool {
+	return b.MaxAmount == nil || amount.LessThanOrEqual(*b.MaxAmount)
+}
+
+// CoolingOffStatus is the cooling-off window of a destination the user has saved as a beneficiary:
+// while it lasts, the total sent to the destination since Since may not exceed MaxAmount
+type CoolingOffStatus struct {
+	InCoolingOff    bool             `json:"in_cooling_off"`
+	Since           *time.Time       `json:"since,omitempty"`
+	CoolingOffUntil *time.Time       `json:"cooling_off_until,omitempty"`
+	MaxAmount       *decimal.Decimal `json:"max_amount,omitempty"`
+}
+
+type CreateBeneficiaryRequest struct {
+	Nickname      string `json:"nickname" binding:"required,max=50"`
+	Type          string `json:"beneficiary_type" binding:"required,oneof=internal iban external"`
+	AccountID     int64  `json:"account_id"`
+	IBAN          string `json:"iban" binding:"omitempty,max=42"`
+	AccountNumber string `json:"account_number" binding:"omitempty,max=34"`
+	BankName      string `json:"bank_name" binding:"omitempty,max=100"`
+	BankCode      string `json:"bank_code" binding:"omitempty,max=11"`
+	RecipientName string `json:"recipient_name" binding:"omitempty,max=100"`
+}
+
+// Validate checks the request carries the destination its type needs, normalizing any IBAN
+func (r *CreateBeneficiaryRequest) Validate() error {
+	switch r.Type {
+	case BeneficiaryTypeInternal:
+		if r.AccountID <= 0 {
+			return ErrBeneficiaryAccountNeeded
+		}
+	case BeneficiaryTypeIBAN:
+		if r.IBAN == "" {
+			return ErrBeneficiaryIBANNeeded
+		}
+		iban, err := NormalizeIBAN(r.IBAN)
+		if err != nil {
+			return err
+		}
+		r.IBAN = iban
+	case BeneficiaryTypeExternal:
+		if strings.TrimSpace(r.AccountNumber) == "" || strings.TrimSpace(r.BankName) == "" {
+			return ErrBeneficiaryBankNeeded
+		}
+	}
+	return nil
+}
+
+// UpdateBeneficiaryRequest renames a beneficiary; the destination itself can't be changed, a new
+// beneficiary (with its own cooling-off period) has to be added instead
+type UpdateBeneficiaryRequest struct {
+	Nickname      string  `json:"nickname" binding:"required,max=50"`
+	RecipientName *string `json:"recipient_name" binding:"omitempty,max=100"`
+}
+
+type BeneficiaryListResponse struct {
+	Beneficiaries []Beneficiary `json:"beneficiaries"`
+	Total         int64         `json:"total"`
+}
+
+var ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+
+// NormalizeIBAN strips spaces, upper-cases and verifies the ISO 13616 mod-97 check digits of an IBAN
+func NormalizeIBAN(s string) (string, error) {
+	iban := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	if !ibanPattern.MatchString(iban) {
+		return "", ErrInvalidIBAN
+	}
+
+	// Move the country code and check digits to the end and turn letters into numbers (A=10 ... Z=35)
+	rearranged := iban[4:] + iban[:4]
+	var digits strings.Builder
+	for _, r := range rearranged {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(big.NewInt(int64(r - 'A' + 10)).String())
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok || new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return "", ErrInvalidIBAN
+	}
+
+	return iban, nil
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNormalizeIBAN(t *testing.T) {
+	tests := []struct {
+		name    string
+		iban    string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid GB", iban: "GB82WEST12345698765432", want: "GB82WEST12345698765432"},
+		{name: "spaces and lower case", iban: "gb82 west 1234 5698 7654 32", want: "GB82WEST12345698765432"},
+		{name: "valid AZ", iban: "AZ21NABZ00000000137010001944", want: "AZ21NABZ00000000137010001944"},
+		{name: "valid DE", iban: "DE89370400440532013000", want: "DE89370400440532013000"},
+		{name: "wrong check digits", iban: "GB83WEST12345698765432", wantErr: true},
+		{name: "transposed digits", iban: "GB82WEST12345698765423", wantErr: true},
+		{name: "too short", iban: "GB82WEST", wantErr: true},
+		{name: "bad characters", iban: "GB82-WEST-1234-5698-7654-32", wantErr: true},
+		{name: "empty", iban: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeIBAN(tt.iban)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIBAN) {
+					t.Fatalf("NormalizeIBAN(%q) error = %v, want ErrInvalidIBAN", tt.iban, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeIBAN(%q) unexpected error: %v", tt.iban, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeIBAN(%q) = %q, want %q", tt.iban, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBeneficiaryCoolingOff(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	limit := decimal.NewFromInt(500)
+
+	tests := []struct {
+		name        string
+		until       time.Time
+		amount      string
+		wantCooling bool
+		wantAllowed bool
+	}{
+		{name: "new beneficiary under cap", until: now.Add(time.Hour), amount: "500", wantCooling: true, wantAllowed: true},
+		{name: "new beneficiary over cap", until: now.Add(time.Hour), amount: "500.01", wantCooling: true, wantAllowed: false},
+		{name: "cooling-off over", until: now.Add(-time.Hour), amount: "100000", wantCooling: false, wantAllowed: true},
+		{name: "cooling-off ends now", until: now, amount: "100000", wantCooling: false, wantAllowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Beneficiary{CoolingOffUntil: tt.until}
+			b.ApplyCoolingOff(now, limit)
+			if b.InCoolingOff != tt.wantCooling {
+				t.Errorf("InCoolingOff = %v, want %v", b.InCoolingOff, tt.wantCooling)
+			}
+			if got := b.Allows(decimal.RequireFromString(tt.amount)); got != tt.wantAllowed {
+				t.Errorf("Allows(%s) = %v, want %v", tt.amount, got, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestCreateBeneficiaryRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateBeneficiaryRequest
+		wantErr error
+	}{
+		{name: "internal", req: CreateBeneficiaryRequest{Type: BeneficiaryTypeInternal, AccountID: 7}},
+		{name: "internal without account", req: CreateBeneficiaryRequest{Type: BeneficiaryTypeInternal}, wantErr: ErrBeneficiaryAccountNeeded},
+		{name: "iban", req: CreateBeneficiaryRequest{Type: BeneficiaryTypeIBAN, IBAN: "GB82 WEST 1234 5698 7654 32"}},
+		{name: "iban missing", req: CreateBeneficiaryRequest{Type: BeneficiaryTypeIBAN}, wantErr: ErrBeneficiaryIBANNeeded},
+		{name: "iban invalid", req: CreateBeneficiaryRequest{Type: BeneficiaryTypeIBAN, IBAN: "GB00WEST12345698765432"}, wantErr: ErrInvalidIBAN},
+		{name: "external", req: CreateBeneficiaryRequest{Type: BeneficiaryTypeExternal, AccountNumber: "12345678", BankName: "Other Bank"}},
+		{name: "external without bank", req: CreateBeneficiaryRequest{Type: BeneficiaryTypeExternal, AccountNumber: "12345678"}, wantErr: ErrBeneficiaryBankNeeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
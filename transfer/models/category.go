@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transfer categories
+const (
+	CategoryGeneral   = "general"
+	CategoryRent      = "rent"
+	CategoryUtilities = "utilities"
+	CategorySalary    = "salary"
+	CategoryFamily    = "family"
+	CategorySavings   = "savings"
+	CategoryLoan      = "loan"
+	CategoryShopping  = "shopping"
+)
+
+// categoryKeywords maps memo words to the category they suggest
+var categoryKeywords = map[string]string{
+	"rent":        CategoryRent,
+	"lease":       CategoryRent,
+	"landlord":    CategoryRent,
+	"electric":    CategoryUtilities,
+	"electricity": CategoryUtilities,
+	"water":       CategoryUtilities,
+	"gas":         CategoryUtilities,
+	"internet":    CategoryUtilities,
+	"utility":     CategoryUtilities,
+	"utilities":   CategoryUtilities,
+	"salary":      CategorySalary,
+	"payroll":     CategorySalary,
+	"wage":        CategorySalary,
+	"wages":       CategorySalary,
+	"bonus":       CategorySalary,
+	"mom":         CategoryFamily,
+	"dad":         CategoryFamily,
+	"family":      CategoryFamily,
+	"gift":        CategoryFamily,
+	"birthday":    CategoryFamily,
+	"savings":     CategorySavings,
+	"saving":      CategorySavings,
+	"loan":        CategoryLoan,
+	"repayment":   CategoryLoan,
+	"debt":        CategoryLoan,
+	"installment": CategoryLoan,
+	"groceries":   CategoryShopping,
+	"grocery":     CategoryShopping,
+	"shopping":    CategoryShopping,
+	"order":       CategoryShopping,
+}
+
+// Categorize derives a category from a transfer memo: the first word with a known category wins,
+// and memos without one are general
+func Categorize(memo string) string {
+	words := strings.FieldsFunc(strings.ToLower(memo), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if category, ok := categoryKeywords[word]; ok {
+			return category
+		}
+	}
+	return CategoryGeneral
+}
+
+// Sides of a transfer that keep their own category
+const (
+	CategorySideSender    = "sender"
+	CategorySideRecipient = "recipient"
+)
+
+// SetCategoryRequest sets one side's category. Side defaults to the side the caller is on, the
+// sender's if they are on both.
+type SetCategoryRequest struct {
+	Category string `json:"category" binding:"required,oneof=general rent utilities salary family savings loan shopping"`
+	Side     string `json:"side" binding:"omitempty,oneof=sender recipient"`
+}
+
+// TransferSearch filters a transfer listing; zero values don't filter
+type TransferSearch struct {
+	Query        string // full-text search over memos
+	Counterparty int64  // account on either side of the transfer
+	Category     string
+	MinAmount    *decimal.Decimal
+	MaxAmount    *decimal.Decimal
+	From         *time.Time // created at or after
+	To           *time.Time // created before
+}
+
+// IsEmpty reports whether the search applies no filters
+func (s *TransferSearch) IsEmpty() bool {
+	return s.Query == "" && s.Counterparty == 0 && s.Category == "" &&
+		s.MinAmount == nil && s.MaxAmount == nil && s.From == nil && s.To == nil
+}
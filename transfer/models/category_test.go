@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		memo string
+		want string
+	}{
+		{memo: "", want: CategoryGeneral},
+		{memo: "Rent for March", want: CategoryRent},
+		{memo: "electricity + water bill", want: CategoryUtilities},
+		{memo: "Payroll 2024-05", want: CategorySalary},
+		{memo: "Happy birthday!", want: CategoryFamily},
+		{memo: "loan repayment #3", want: CategoryLoan},
+		{memo: "groceries", want: CategoryShopping},
+		{memo: "rent and utilities", want: CategoryRent},
+		{memo: "parental", want: CategoryGeneral},
+		{memo: "dinner", want: CategoryGeneral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.memo, func(t *testing.T) {
+			if got := Categorize(tt.memo); got != tt.want {
+				t.Errorf("Categorize(%q) = %q, want %q", tt.memo, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fee channels a transfer can be priced for. Transfers are only made between accounts held at this
+// bank in the same currency, so only the internal channel is priced for now; external and fx rules
+// can be set up ahead of transfers to other banks and currency conversion.
+const (
+	FeeChannelInternal = "internal" // both accounts held at this bank in the same currency
+	FeeChannelExternal = "external" // destination held at another bank
+	FeeChannelFX       = "fx"       // source and destination currencies differ
+)
+
+// FeeRule prices transfers on a channel. Nil AccountType or Currency match any value; the most
+// specific active rule for a transfer applies.
+type FeeRule struct {
+	ID          int64            `json:"id"`
+	Channel     string           `json:"channel"`
+	AccountType *string          `json:"account_type,omitempty"`
+	Currency    *string          `json:"currency,omitempty"`
+	FlatAmount  decimal.Decimal  `json:"flat_amount"`
+	Percentage  decimal.Decimal  `json:"percentage"`
+	MinFee      *decimal.Decimal `json:"min_fee,omitempty"`
+	MaxFee      *decimal.Decimal `json:"max_fee,omitempty"`
+	Active      bool             `json:"active"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type FeeRuleRequest struct {
+	Channel     string           `json:"channel" binding:"required,oneof=internal external fx"`
+	AccountType *string          `json:"account_type" binding:"omitempty,oneof=checking savings"`
+	Currency    *string          `json:"currency" binding:"omitempty,len=3"`
+	FlatAmount  decimal.Decimal  `json:"flat_amount"`
+	Percentage  decimal.Decimal  `json:"percentage"`
+	MinFee      *decimal.Decimal `json:"min_fee"`
+	MaxFee      *decimal.Decimal `json:"max_fee"`
+	Active      *bool            `json:"active"`
+}
+
+// FeeQuote is the price of a transfer before it is submitted
+type FeeQuote struct {
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	TotalDebit    decimal.Decimal `json:"total_debit"`
+	Currency      string          `json:"currency"`
+	Channel       string          `json:"channel"`
+	FeeRuleID     *int64          `json:"fee_rule_id,omitempty"`
+}
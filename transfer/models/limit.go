@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultLimitSegment is used for users without an explicit segment assignment
+const DefaultLimitSegment = "standard"
+
+// Limit violation codes returned to clients
+const (
+	LimitCodePerTransaction = "per_transaction_limit_exceeded"
+	LimitCodeDaily          = "daily_limit_exceeded"
+	LimitCodeMonthly        = "monthly_limit_exceeded"
+	LimitCodeHourlyCount    = "hourly_count_limit_exceeded"
+)
+
+// SegmentLimits holds the default transfer limits for a customer segment
+type SegmentLimits struct {
+	Segment           string          `json:"segment"`
+	PerTransactionMax decimal.Decimal `json:"per_transaction_max"`
+	DailyMax          decimal.Decimal `json:"daily_max"`
+	MonthlyMax        decimal.Decimal `json:"monthly_max"`
+	HourlyCountMax    int             `json:"hourly_count_max"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// LimitOverride holds admin overrides for a specific user. Nil fields fall back to the segment.
+type LimitOverride struct {
+	UserID            int64            `json:"user_id"`
+	Segment           *string          `json:"segment,omitempty"`
+	PerTransactionMax *decimal.Decimal `json:"per_transaction_max,omitempty"`
+	DailyMax          *decimal.Decimal `json:"daily_max,omitempty"`
+	MonthlyMax        *decimal.Decimal `json:"monthly_max,omitempty"`
+	HourlyCountMax    *int             `json:"hourly_count_max,omitempty"`
+	Reason            *string          `json:"reason,omitempty"`
+	UpdatedBy         int64            `json:"updated_by"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// EffectiveLimits are the limits that apply to a user after overrides are resolved
+type EffectiveLimits struct {
+	UserID            int64           `json:"user_id"`
+	Segment           string          `json:"segment"`
+	PerTransactionMax decimal.Decimal `json:"per_transaction_max"`
+	DailyMax          decimal.Decimal `json:"daily_max"`
+	MonthlyMax        decimal.Decimal `json:"monthly_max"`
+	HourlyCountMax    int             `json:"hourly_count_max"`
+	// NewBeneficiaryMax caps the total sent to an account while a beneficiary the user added for it
+	// is cooling off; it is the service-wide BENEFICIARY_COOLING_OFF_LIMIT
+	NewBeneficiaryMax decimal.Decimal `json:"new_beneficiary_max"`
+	Overridden        bool            `json:"overridden"`
+}
+
+// LimitUsage is a user's current consumption of their transfer limits
+type LimitUsage struct {
+	DailyTotal   decimal.Decimal `json:"daily_total"`
+	MonthlyTotal decimal.Decimal `json:"monthly_total"`
+	HourlyCount  int             `json:"hourly_count"`
+}
+
+type LimitsResponse struct {
+	Limits *EffectiveLimits `json:"limits"`
+	Usage  *LimitUsage      `json:"usage"`
+}
+
+type UpdateSegmentLimitsRequest struct {
+	PerTransactionMax decimal.Decimal `json:"per_transaction_max" binding:"required"`
+	DailyMax          decimal.Decimal `json:"daily_max" binding:"required"`
+	MonthlyMax        decimal.Decimal `json:"monthly_max" binding:"required"`
+	HourlyCountMax    int             `json:"hourly_count_max" binding:"required,min=1"`
+}
+
+type SetLimitOverrideRequest struct {
+	Segment           *string          `json:"segment"`
+	PerTransactionMax *decimal.Decimal `json:"per_transaction_max"`
+	DailyMax          *decimal.Decimal `json:"daily_max"`
+	MonthlyMax        *decimal.Decimal `json:"monthly_max"`
+	HourlyCountMax    *int             `json:"hourly_count_max" binding:"omitempty,min=1"`
+	Reason            *string          `json:"reason"`
+}
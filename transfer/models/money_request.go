@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Money request statuses
+const (
+	MoneyRequestStatusPending   = "pending"
+	MoneyRequestStatusAccepted  = "accepted" // the payer's transfer is on its way
+	MoneyRequestStatusPaid      = "paid"     // the payer's transfer completed
+	MoneyRequestStatusDeclined  = "declined"
+	MoneyRequestStatusCancelled = "cancelled"
+	MoneyRequestStatusExpired   = "expired"
+)
+
+// Money request list directions, relative to the caller
+const (
+	MoneyRequestDirectionIncoming = "incoming" // requests the caller has been asked to pay
+	MoneyRequestDirectionOutgoing = "outgoing" // requests the caller has sent
+)
+
+// MoneyRequest is a request from one customer for another customer to pay them
+type MoneyRequest struct {
+	ID                 int64           `json:"id"`
+	ReferenceID        uuid.UUID       `json:"reference_id"`
+	RequesterUserID    int64           `json:"requester_user_id"`
+	RequesterAccountID int64           `json:"requester_account_id"`
+	PayerUserID        int64           `json:"payer_user_id"`
+	PayerAccountID     int64           `json:"payer_account_id"`
+	Amount             decimal.Decimal `json:"amount"`
+	Currency           string          `json:"currency"`
+	Note               *string         `json:"note,omitempty"`
+	Status             string          `json:"status"`
+	TransferID         *int64          `json:"transfer_id,omitempty"`
+	DeclineReason      *string         `json:"decline_reason,omitempty"`
+	ExpiresAt          time.Time       `json:"expires_at"`
+	RespondedAt        *time.Time      `json:"responded_at,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// IsOpen reports whether the request can still be accepted, declined or cancelled
+func (r *MoneyRequest) IsOpen(now time.Time) bool {
+	return r.Status == MoneyRequestStatusPending && now.Before(r.ExpiresAt)
+}
+
+// CreateMoneyRequestRequest asks the owner of PayerAccountID to pay Amount into ToAccountID
+type CreateMoneyRequestRequest struct {
+	ToAccountID    int64           `json:"to_account_id" binding:"required"`
+	PayerAccountID int64           `json:"payer_account_id" binding:"required"`
+	Amount         decimal.Decimal `json:"amount" binding:"required"`
+	Note           string          `json:"note" binding:"omitempty,max=140"`
+}
+
+// AcceptMoneyRequestRequest pays a request; FromAccountID defaults to the account the request was addressed to
+type AcceptMoneyRequestRequest struct {
+	FromAccountID int64 `json:"from_account_id"`
+}
+
+type DeclineMoneyRequestRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=140"`
+}
+
+type MoneyRequestListResponse struct {
+	Requests []MoneyRequest `json:"requests"`
+	Total    int64          `json:"total"`
+}
+
+// MoneyRequestEvent is published to Kafka whenever a money request is created or settled
+type MoneyRequestEvent struct {
+	RequestID       int64           `json:"request_id"`
+	ReferenceID     string          `json:"reference_id"`
+	Status          string          `json:"status"`
+	RequesterUserID int64           `json:"requester_user_id"`
+	PayerUserID     int64           `json:"payer_user_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        string          `json:"currency"`
+	Note            string          `json:"note,omitempty"`
+	DeclineReason   string          `json:"decline_reason,omitempty"`
+	TransferID      int64           `json:"transfer_id,omitempty"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+}
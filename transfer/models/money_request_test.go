@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMoneyRequestIsOpen(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		status    string
+		expiresAt time.Time
+		want      bool
+	}{
+		{name: "pending before expiry", status: MoneyRequestStatusPending, expiresAt: now.Add(time.Hour), want: true},
+		{name: "pending at expiry", status: MoneyRequestStatusPending, expiresAt: now, want: false},
+		{name: "pending after expiry", status: MoneyRequestStatusPending, expiresAt: now.Add(-time.Minute), want: false},
+		{name: "accepted", status: MoneyRequestStatusAccepted, expiresAt: now.Add(time.Hour), want: false},
+		{name: "declined", status: MoneyRequestStatusDeclined, expiresAt: now.Add(time.Hour), want: false},
+		{name: "cancelled", status: MoneyRequestStatusCancelled, expiresAt: now.Add(time.Hour), want: false},
+		{name: "expired", status: MoneyRequestStatusExpired, expiresAt: now.Add(-time.Hour), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MoneyRequest{Status: tt.status, ExpiresAt: tt.expiresAt}
+			if got := r.IsOpen(now); got != tt.want {
+				t.Errorf("IsOpen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
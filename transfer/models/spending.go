@@ -0,0 +1,30 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// SpendingRow totals the outgoing transfers of one category in one month and currency
+type SpendingRow struct {
+	Month    string          `json:"month"` // YYYY-MM
+	Category string          `json:"category"`
+	Currency string          `json:"currency"`
+	Count    int64           `json:"count"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+// RecipientSpending totals the outgoing transfers to one account. Name is the sender's
+// beneficiary nickname for the account, if they saved one.
+type RecipientSpending struct {
+	AccountID int64           `json:"-"`
+	Name      string          `json:"name,omitempty"`
+	Account   string          `json:"account,omitempty"` // masked account number
+	Currency  string          `json:"currency"`
+	Count     int64           `json:"count"`
+	Total     decimal.Decimal `json:"total"`
+}
+
+// TransferSpending summarizes a user's completed transfers to accounts other than their own,
+// net of reversals, for the payment service's spending insights
+type TransferSpending struct {
+	Months     []SpendingRow       `json:"months"`
+	Recipients []RecipientSpending `json:"recipients"`
+}
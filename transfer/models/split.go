@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// MaxSplitParticipants is the maximum number of customers a bill can be split across
+const MaxSplitParticipants = 50
+
+// Split methods
+const (
+	SplitMethodEven   = "even"
+	SplitMethodCustom = "custom"
+)
+
+// Split statuses
+const (
+	SplitStatusOpen      = "open"
+	SplitStatusSettled   = "settled"
+	SplitStatusCancelled = "cancelled"
+)
+
+// Split share statuses
+const (
+	ShareStatusUnpaid  = "unpaid"
+	ShareStatusPending = "pending"
+	ShareStatusPaid    = "paid"
+)
+
+// BillSplit is a total amount shared out among other customers, each paying their share by transfer
+type BillSplit struct {
+	ID          int64           `json:"id"`
+	ReferenceID uuid.UUID       `json:"reference_id"`
+	OwnerUserID int64           `json:"owner_user_id"`
+	AccountID   int64           `json:"account_id"`
+	TotalAmount decimal.Decimal `json:"total_amount"`
+	Currency    string          `json:"currency"`
+	SplitMethod string          `json:"split_method"`
+	Description *string         `json:"description,omitempty"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	SettledAt   *time.Time      `json:"settled_at,omitempty"`
+	Shares      []SplitShare    `json:"shares,omitempty"`
+}
+
+// SplitShare is one participant's part of a bill split
+type SplitShare struct {
+	ID             int64           `json:"id"`
+	SplitID        int64           `json:"split_id"`
+	UserID         int64           `json:"user_id"`
+	AccountID      int64           `json:"account_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	Status         string          `json:"status"`
+	TransferID     *int64          `json:"transfer_id,omitempty"`
+	PaidAt         *time.Time      `json:"paid_at,omitempty"`
+	RemindersSent  int             `json:"reminders_sent"`
+	LastRemindedAt *time.Time      `json:"last_reminded_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// SplitParticipant names a customer by one of their accounts; Amount is required for custom splits only
+type SplitParticipant struct {
+	AccountID int64            `json:"account_id" binding:"required"`
+	Amount    *decimal.Decimal `json:"amount,omitempty"`
+}
+
+type CreateSplitRequest struct {
+	AccountID    int64              `json:"account_id" binding:"required"`
+	TotalAmount  decimal.Decimal    `json:"total_amount" binding:"required"`
+	Method       string             `json:"method" binding:"omitempty,oneof=even custom"`
+	Description  string             `json:"description" binding:"omitempty,max=140"`
+	Participants []SplitParticipant `json:"participants" binding:"required,min=1,dive"`
+}
+
+// PaySplitShareRequest pays the caller's share; FromAccountID defaults to the account they were added with
+type PaySplitShareRequest struct {
+	FromAccountID int64 `json:"from_account_id"`
+}
+
+type SplitListResponse struct {
+	Splits []BillSplit `json:"splits"`
+	Total  int64       `json:"total"`
+}
+
+// SplitReminderEvent is published to Kafka to remind a participant of an unpaid share
+type SplitReminderEvent struct {
+	SplitID       int64           `json:"split_id"`
+	ShareID       int64           `json:"share_id"`
+	UserID        int64           `json:"user_id"`
+	OwnerUserID   int64           `json:"owner_user_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	Description   string          `json:"description,omitempty"`
+	RemindersSent int             `json:"reminders_sent"`
+}
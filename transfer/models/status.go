@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// transferTransitions lists, for each status, the statuses a transfer may move into it from
+var transferTransitions = map[string][]string{
+	TransferStatusPendingApproval: {TransferStatusPending},
+	TransferStatusProcessing:      {TransferStatusPending, TransferStatusPendingApproval},
+	TransferStatusCompleted:       {TransferStatusProcessing},
+	TransferStatusFailed:          {TransferStatusPending, TransferStatusProcessing},
+	TransferStatusRejected:        {TransferStatusPendingApproval},
+	TransferStatusReversed:        {TransferStatusCompleted},
+}
+
+// AllowedFromStatuses returns the statuses a transfer may move to the given status from
+func AllowedFromStatuses(to string) []string {
+	return transferTransitions[to]
+}
+
+// CanTransition reports whether a transfer may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, s := range transferTransitions[to] {
+		if s == from {
+			return true
+		}
+	}
+	return false
+}
+
+// Causes recorded against status transitions
+const (
+	TransitionCauseDispatched    = "dispatched"     // submitted to the account service
+	TransitionCauseHeld          = "held"           // held above the approval threshold
+	TransitionCauseApproved      = "approved"       // approved by an admin
+	TransitionCauseRejected      = "rejected"       // rejected by an admin
+	TransitionCausePublishFailed = "publish_failed" // transfer.requested could not be published
+	TransitionCauseAccountResult = "account_result" // transfer.completed / transfer.failed event
+	TransitionCauseSagaRecovery  = "saga_recovery"  // settled by the saga sweeper
+	TransitionCauseSagaTimeout   = "saga_timeout"   // failed by the saga sweeper after retries
+	TransitionCauseReversed      = "reversed"       // a reversal of this transfer completed
+	TransitionCauseMigration     = "migration"      // backfilled by a schema migration
+)
+
+// StatusTransition is a row of a transfer's status history
+type StatusTransition struct {
+	ID         int64     `json:"id"`
+	TransferID int64     `json:"transfer_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Cause      string    `json:"cause"`
+	Detail     *string   `json:"detail,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want bool
+	}{
+		{name: "dispatch pending", from: TransferStatusPending, to: TransferStatusProcessing, want: true},
+		{name: "hold pending", from: TransferStatusPending, to: TransferStatusPendingApproval, want: true},
+		{name: "approve held", from: TransferStatusPendingApproval, to: TransferStatusProcessing, want: true},
+		{name: "reject held", from: TransferStatusPendingApproval, to: TransferStatusRejected, want: true},
+		{name: "complete processing", from: TransferStatusProcessing, to: TransferStatusCompleted, want: true},
+		{name: "fail processing", from: TransferStatusProcessing, to: TransferStatusFailed, want: true},
+		{name: "fail unpublished", from: TransferStatusPending, to: TransferStatusFailed, want: true},
+		{name: "reverse completed", from: TransferStatusCompleted, to: TransferStatusReversed, want: true},
+		{name: "late failure after completion", from: TransferStatusCompleted, to: TransferStatusFailed, want: false},
+		{name: "late completion after failure", from: TransferStatusFailed, to: TransferStatusCompleted, want: false},
+		{name: "complete without processing", from: TransferStatusPending, to: TransferStatusCompleted, want: false},
+		{name: "fail held transfer", from: TransferStatusPendingApproval, to: TransferStatusFailed, want: false},
+		{name: "reverse failed", from: TransferStatusFailed, to: TransferStatusReversed, want: false},
+		{name: "reopen rejected", from: TransferStatusRejected, to: TransferStatusProcessing, want: false},
+		{name: "same status", from: TransferStatusProcessing, to: TransferStatusProcessing, want: false},
+		{name: "unknown target", from: TransferStatusPending, to: "bogus", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -9,10 +9,19 @@ import (
 
 // Transfer statuses
 const (
-	TransferStatusPending    = "pending"
-	TransferStatusProcessing = "processing"
-	TransferStatusCompleted  = "completed"
-	TransferStatusFailed     = "failed"
+	TransferStatusPending         = "pending"
+	TransferStatusPendingApproval = "pending_approval"
+	TransferStatusProcessing      = "processing"
+	TransferStatusCompleted       = "completed"
+	TransferStatusFailed          = "failed"
+	TransferStatusRejected        = "rejected"
+	TransferStatusReversed        = "reversed"
+)
+
+// Reversal policies applied when the recipient can't cover the full reversal amount
+const (
+	ReversalPolicyPartial = "partial" // reverse whatever balance the recipient has left
+	ReversalPolicyHold    = "hold"    // reverse what is left and collect the rest as funds arrive
 )
 
 type Transfer struct {
@@ -22,18 +31,64 @@ type Transfer struct {
 	ToAccountID   int64           `json:"to_account_id"`
 	Amount        decimal.Decimal `json:"amount"`
 	Currency      string          `json:"currency"`
-	Status        string          `json:"status"`
-	FailureReason *string         `json:"failure_reason,omitempty"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
-	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	Fee           decimal.Decimal `json:"fee"`
+	Channel       string          `json:"channel"`
+	Memo          *string         `json:"memo,omitempty"`
+	Category      string          `json:"category"`
+	// RecipientCategory is the recipient's own category for the transfer, if they set one
+	RecipientCategory *string    `json:"recipient_category,omitempty"`
+	Status            string     `json:"status"`
+	FailureReason     *string    `json:"failure_reason,omitempty"`
+	InitiatedBy       *int64     `json:"initiated_by,omitempty"`
+	ReviewedBy        *int64     `json:"reviewed_by,omitempty"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty"`
+	BatchID           *int64     `json:"batch_id,omitempty"`
+	BeneficiaryID     *int64     `json:"beneficiary_id,omitempty"`
+	// Reversal details: ReversalOf is set on reversal transfers, ReversedAmount and FeeRefunded on both sides
+	ReversalOf     *int64           `json:"reversal_of,omitempty"`
+	ReversalPolicy *string          `json:"reversal_policy,omitempty"`
+	ReversalReason *string          `json:"reversal_reason,omitempty"`
+	ReversedAmount *decimal.Decimal `json:"reversed_amount,omitempty"`
+	FeeRefunded    *decimal.Decimal `json:"fee_refunded,omitempty"`
+	SagaAttempts   int              `json:"saga_attempts,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
 }
 
+// CreateTransferRequest names the recipient either directly by ToAccountID or through a saved
+// internal beneficiary, in which case ToAccountID is filled from the beneficiary
 type CreateTransferRequest struct {
 	FromAccountID int64           `json:"from_account_id" binding:"required"`
-	ToAccountID   int64           `json:"to_account_id" binding:"required"`
+	ToAccountID   int64           `json:"to_account_id"`
+	BeneficiaryID int64           `json:"beneficiary_id"`
 	Amount        decimal.Decimal `json:"amount" binding:"required"`
 	Currency      string          `json:"currency" binding:"omitempty,len=3"`
+	Memo          string          `json:"memo" binding:"omitempty,max=140"`
+	Category      string          `json:"category" binding:"omitempty,oneof=general rent utilities salary family savings loan shopping"`
+
+	// MoneyRequestID is set by the service, never by clients, for a transfer paying an accepted
+	// money request; the request is linked to the transfer as it is recorded
+	MoneyRequestID int64 `json:"-"`
+}
+
+type RejectTransferRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ReversibleAmount is how much of a transfer has not been reversed yet
+func (t *Transfer) ReversibleAmount() decimal.Decimal {
+	if t.ReversedAmount == nil {
+		return t.Amount
+	}
+	return t.Amount.Sub(*t.ReversedAmount)
+}
+
+// ReverseTransferRequest reverses a completed transfer; Amount defaults to what is left to reverse
+type ReverseTransferRequest struct {
+	Reason string           `json:"reason" binding:"required"`
+	Amount *decimal.Decimal `json:"amount,omitempty"`
+	Policy string           `json:"policy" binding:"omitempty,oneof=partial hold"`
 }
 
 type TransferListResponse struct {
@@ -49,12 +104,42 @@ type TransferRequestedEvent struct {
 	ToAccountID   int64           `json:"to_account_id"`
 	Amount        decimal.Decimal `json:"amount"`
 	Currency      string          `json:"currency"`
+	Fee           decimal.Decimal `json:"fee"`
+	// Set when the transfer reverses an earlier one; FeeRefund is the share of its fee returned
+	// to the sender if the reversal settles in full
+	ReversalOf     int64           `json:"reversal_of,omitempty"`
+	ReversalPolicy string          `json:"reversal_policy,omitempty"`
+	FeeRefund      decimal.Decimal `json:"fee_refund,omitempty"`
 }
 
 // TransferResultEvent is consumed from Kafka after account service processes
 type TransferResultEvent struct {
-	TransferID    int64  `json:"transfer_id"`
-	ReferenceID   string `json:"reference_id"`
-	Status        string `json:"status"` // "completed" or "failed"
-	FailureReason string `json:"failure_reason,omitempty"`
+	TransferID    int64           `json:"transfer_id"`
+	ReferenceID   string          `json:"reference_id"`
+	Status        string          `json:"status"` // "completed" or "failed"
+	FailureReason string          `json:"failure_reason,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`                // amount actually moved
+	ReversalOf    int64           `json:"reversal_of,omitempty"` // set for reversal transfers
+}
+
+// AccountOperationHeld is the status of a reversal the account service is still collecting
+const AccountOperationHeld = "held"
+
+// AccountOperation is the account service's record of how it processed a transfer request
+type AccountOperation struct {
+	ReferenceID   string          `json:"reference_id"`
+	Status        string          `json:"status"` // "completed", "failed" or "held"
+	Amount        decimal.Decimal `json:"amount"`
+	FailureReason *string         `json:"failure_reason,omitempty"`
+}
+
+// OpsAlert is published for conditions that need an operator's attention
+type OpsAlert struct {
+	Service     string    `json:"service"`
+	Severity    string    `json:"severity"` // "warning" or "critical"
+	EntityType  string    `json:"entity_type"`
+	EntityID    int64     `json:"entity_id"`
+	ReferenceID string    `json:"reference_id"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"created_at"`
 }
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// moneyRequestSweepInterval is how often pending money requests are checked for expiry
+const moneyRequestSweepInterval = 5 * time.Minute
+
+// createMoneyRequest asks the owner of another account to pay the caller
+func createMoneyRequest(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateMoneyRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+
+	if req.ToAccountID == req.PayerAccountID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "requesting and paying accounts cannot be the same"})
+		return
+	}
+
+	// Looking up the receiving account as the caller verifies they own it
+	receiving, status, err := getAccountSummary(req.ToAccountID, userID, role)
+	if err != nil {
+		log.Printf("Failed to look up account %d: %v", req.ToAccountID, err)
+		switch status {
+		case http.StatusForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case http.StatusNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up account"})
+		}
+		return
+	}
+
+	payer, status, err := getAccountSummary(req.PayerAccountID, serviceUserID, "admin")
+	if err != nil {
+		log.Printf("Failed to look up payer account %d: %v", req.PayerAccountID, err)
+		if status == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payer account not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up payer account"})
+		return
+	}
+
+	if payer.UserID == receiving.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot request money from your own account"})
+		return
+	}
+
+	request, err := moneyRequestRepo.Create(c.Request.Context(), receiving.UserID, payer.UserID, receiving.Currency, &req, time.Now().Add(moneyRequestTTL))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidAmount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+		case errors.Is(err, repository.ErrSameAccount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "requesting and paying accounts cannot be the same"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create money request"})
+		}
+		return
+	}
+
+	publishMoneyRequest(c.Request.Context(), request)
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// listMoneyRequests lists the caller's incoming (default) or outgoing money requests; admins see everyone's
+func listMoneyRequests(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	direction := c.DefaultQuery("direction", models.MoneyRequestDirectionIncoming)
+	if direction != models.MoneyRequestDirectionIncoming && direction != models.MoneyRequestDirectionOutgoing {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "direction must be incoming or outgoing"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	if role == "admin" {
+		userID = 0
+	}
+
+	result, err := moneyRequestRepo.List(c.Request.Context(), userID, direction, c.Query("status"), limit, offset)
+	if err != nil {
+		log.Printf("Failed to list money requests: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list money requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getMoneyRequest returns a money request to either party or an admin
+func getMoneyRequest(c *gin.Context) {
+	request, _, ok := loadMoneyRequest(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// acceptMoneyRequest pays a request with a normal transfer from one of the payer's accounts. The
+// request stays accepted while the transfer is on its way and follows its final status: paid when
+// it completes, back to pending when it fails or is rejected.
+func acceptMoneyRequest(c *gin.Context) {
+	request, userID, ok := loadMoneyRequest(c)
+	if !ok {
+		return
+	}
+
+	if request.PayerUserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the payer can accept a money request"})
+		return
+	}
+
+	var req models.AcceptMoneyRequestRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.FromAccountID == 0 {
+		req.FromAccountID = request.PayerAccountID
+	}
+
+	ctx := c.Request.Context()
+
+	// Claim the request first so it can only ever be paid once
+	claimed, err := moneyRequestRepo.Respond(ctx, request.ID, models.MoneyRequestStatusAccepted, nil)
+	if err != nil {
+		respondMoneyRequestError(c, err)
+		return
+	}
+
+	transferReq := models.CreateTransferRequest{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   claimed.RequesterAccountID,
+		Amount:        claimed.Amount,
+		Currency:      claimed.Currency,
+
+		MoneyRequestID: claimed.ID,
+	}
+	if claimed.Note != nil {
+		transferReq.Memo = *claimed.Note
+	}
+
+	_, role, _ := getUserContext(c)
+	transfer, ok := initiateTransfer(c, userID, role, &transferReq)
+	if !ok {
+		// The payer can try again, e.g. from another account
+		if err := moneyRequestRepo.Reopen(ctx, claimed.ID); err != nil {
+			log.Printf("Failed to reopen money request %d: %v", claimed.ID, err)
+		}
+		return
+	}
+
+	// The transfer was linked to the request as it was recorded, so its result moves the request on
+	accepted, err := moneyRequestRepo.GetByID(ctx, claimed.ID)
+	if err != nil {
+		log.Printf("Failed to reload money request %d: %v", claimed.ID, err)
+		accepted = claimed
+	}
+
+	publishMoneyRequest(ctx, accepted)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":         "money request accepted",
+		"request":         accepted,
+		"transfer_id":     transfer.ID,
+		"transfer_status": transfer.Status,
+		"fee":             transfer.Fee,
+		"total_debit":     transfer.Amount.Add(transfer.Fee),
+	})
+}
+
+// declineMoneyRequest lets the payer turn down a request
+func declineMoneyRequest(c *gin.Context) {
+	request, userID, ok := loadMoneyRequest(c)
+	if !ok {
+		return
+	}
+
+	if request.PayerUserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the payer can decline a money request"})
+		return
+	}
+
+	var req models.DeclineMoneyRequestRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var reason *string
+	if req.Reason != "" {
+		reason = &req.Reason
+	}
+
+	declined, err := moneyRequestRepo.Respond(c.Request.Context(), request.ID, models.MoneyRequestStatusDeclined, reason)
+	if err != nil {
+		respondMoneyRequestError(c, err)
+		return
+	}
+
+	publishMoneyRequest(c.Request.Context(), declined)
+
+	c.JSON(http.StatusOK, declined)
+}
+
+// cancelMoneyRequest lets the requester withdraw a request that has not been answered
+func cancelMoneyRequest(c *gin.Context) {
+	request, userID, ok := loadMoneyRequest(c)
+	if !ok {
+		return
+	}
+
+	if request.RequesterUserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the requester can cancel a money request"})
+		return
+	}
+
+	cancelled, err := moneyRequestRepo.Respond(c.Request.Context(), request.ID, models.MoneyRequestStatusCancelled, nil)
+	if err != nil {
+		respondMoneyRequestError(c, err)
+		return
+	}
+
+	publishMoneyRequest(c.Request.Context(), cancelled)
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// loadMoneyRequest fetches the request named in the path and checks the caller is a party to it or
+// an admin, writing the error response and returning false otherwise
+func loadMoneyRequest(c *gin.Context) (*models.MoneyRequest, int64, bool) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, 0, false
+	}
+
+	requestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid money request ID"})
+		return nil, 0, false
+	}
+
+	request, err := moneyRequestRepo.GetByID(c.Request.Context(), requestID)
+	if err != nil {
+		respondMoneyRequestError(c, err)
+		return nil, 0, false
+	}
+
+	if role != "admin" && request.RequesterUserID != userID && request.PayerUserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return nil, 0, false
+	}
+
+	return request, userID, true
+}
+
+func respondMoneyRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrMoneyRequestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "money request not found"})
+	case errors.Is(err, repository.ErrMoneyRequestClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "money request is no longer pending"})
+	default:
+		log.Printf("Money request error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update money request"})
+	}
+}
+
+// publishMoneyRequest announces a money request change; the request itself is already stored,
+// so a failed publish only costs the customers their notification
+func publishMoneyRequest(ctx context.Context, request *models.MoneyRequest) {
+	if err := kafkaProducer.PublishMoneyRequest(ctx, request); err != nil {
+		log.Printf("Failed to publish money request %d event: %v", request.ID, err)
+	}
+}
+
+// runMoneyRequestExpiry periodically expires pending money requests that were never answered
+func runMoneyRequestExpiry(ctx context.Context) {
+	log.Printf("Starting money request expiry (ttl %s, interval %s)", moneyRequestTTL, moneyRequestSweepInterval)
+
+	ticker := time.NewTicker(moneyRequestSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping money request expiry")
+			return
+		case <-ticker.C:
+			expired, err := moneyRequestRepo.ExpireDue(ctx, time.Now())
+			if err != nil {
+				log.Printf("Failed to expire money requests: %v", err)
+				continue
+			}
+			for i := range expired {
+				publishMoneyRequest(ctx, &expired[i])
+			}
+			if len(expired) > 0 {
+				log.Printf("Expired %d money requests", len(expired))
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"receipt"
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// receiptKey signs transfer receipts; anyone holding a receipt can check it through the public
+// verify endpoint, which recomputes the hash from the transfer
+var receiptKey []byte
+
+// accountDetails is the part of the account service's account response a receipt needs
+type accountDetails struct {
+	UserID        int64  `json:"user_id"`
+	AccountNumber string `json:"account_number"`
+}
+
+// getTransferReceipt renders a signed receipt for a transfer, to the owner of either account or an
+// admin, as JSON or, with ?format=pdf, as a PDF download
+func getTransferReceipt(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	transferID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer ID"})
+		return
+	}
+
+	format, ok := receiptFormat(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := transferRepo.GetByID(c.Request.Context(), transferID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransferNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer"})
+		return
+	}
+
+	from, to, err := getTransferAccounts(transfer)
+	if err != nil {
+		log.Printf("Failed to build receipt for transfer %d: %v", transfer.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to build receipt"})
+		return
+	}
+
+	if role != "admin" && from.UserID != userID && to.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
+	r := buildTransferReceipt(transfer, from, to)
+	if format == "pdf" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="receipt-%s.pdf"`, r.ReferenceID))
+		c.Data(http.StatusOK, "application/pdf", r.PDF("Transfer receipt", time.Now()))
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// verifyTransferReceipt checks a receipt's verification hash against the transfer it names. It is
+// public: a valid receipt only discloses what is already printed on it.
+func verifyTransferReceipt(c *gin.Context) {
+	referenceID, err := uuid.Parse(c.Query("reference_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reference_id must be a transfer reference"})
+		return
+	}
+	hash := c.Query("hash")
+	if hash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hash is required"})
+		return
+	}
+
+	transfer, err := transferRepo.GetByReferenceID(c.Request.Context(), referenceID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransferNotFound) {
+			c.JSON(http.StatusOK, gin.H{"valid": false})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer"})
+		return
+	}
+
+	from, to, err := getTransferAccounts(transfer)
+	if err != nil {
+		log.Printf("Failed to build receipt for transfer %d: %v", transfer.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to verify receipt"})
+		return
+	}
+
+	r := buildTransferReceipt(transfer, from, to)
+	if !r.Verify(receiptKey, hash) {
+		c.JSON(http.StatusOK, gin.H{"valid": false})
+		return
+	}
+	// The receipt's facts are as signed; its status is the current one, which may differ from
+	// the status printed on the receipt
+	c.JSON(http.StatusOK, gin.H{"valid": true, "status": r.Status, "receipt": r})
+}
+
+// buildTransferReceipt describes a transfer as its receipt; the fee is charged to the sender on
+// top of the amount
+func buildTransferReceipt(transfer *models.Transfer, from, to *accountDetails) *receipt.Receipt {
+	r := &receipt.Receipt{
+		Kind:        "transfer",
+		ReferenceID: transfer.ReferenceID.String(),
+		Type:        transfer.Channel,
+		Status:      transfer.Status,
+		FromAccount: receipt.MaskAccount(from.AccountNumber),
+		ToAccount:   receipt.MaskAccount(to.AccountNumber),
+		Currency:    transfer.Currency,
+	}
+	if transfer.ReversalOf != nil {
+		r.Type = "reversal"
+	}
+	if transfer.Memo != nil {
+		r.Description = *transfer.Memo
+	}
+	r.SetAmounts(transfer.Amount, transfer.Fee)
+	r.SetTimes(transfer.CreatedAt, transfer.CompletedAt)
+
+	r.Sign(receiptKey)
+	return r
+}
+
+// getTransferAccounts looks up both accounts of a transfer
+func getTransferAccounts(transfer *models.Transfer) (*accountDetails, *accountDetails, error) {
+	from, err := getAccountDetails(transfer.FromAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := getAccountDetails(transfer.ToAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return from, to, nil
+}
+
+// getAccountDetails calls the account service, as the service, for an account's owner and number
+func getAccountDetails(accountID int64) (*accountDetails, error) {
+	accountServiceURL := getEnv("ACCOUNT_SERVICE_URL", "http://account.account.svc.cluster.local:8080")
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/accounts/%d", accountServiceURL, accountID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-User-ID", strconv.FormatInt(serviceUserID, 10))
+	req.Header.Set("X-User-Role", "admin")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call account service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("account service returned status %d for account %d", resp.StatusCode, accountID)
+	}
+
+	var details accountDetails
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &details, nil
+}
+
+// receiptFormat reads the requested receipt format: json (the default) or pdf, from ?format= or
+// an Accept header asking for a PDF
+func receiptFormat(c *gin.Context) (string, bool) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.GetHeader("Accept"), "application/pdf") {
+			format = "pdf"
+		}
+	}
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or pdf"})
+		return "", false
+	}
+	return format, true
+}
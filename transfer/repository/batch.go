@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"transfer/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrBatchNotFound = errors.New("transfer batch not found")
+
+	// ErrBatchItemSubmitted is returned when a batch line already has a child transfer, e.g. because
+	// another run of the batch created it first
+	ErrBatchItemSubmitted = errors.New("batch item already has a transfer")
+)
+
+// batchColumns is the column list selected for every batch query
+const batchColumns = `id, reference_id, from_account_id, currency, initiated_by, total_items, total_amount,
+		       checksum, source_format, status, failure_reason, created_at, updated_at, submitted_at`
+
+func scanBatch(row rowScanner, b *models.TransferBatch) error {
+	return row.Scan(
+		&b.ID, &b.ReferenceID, &b.FromAccountID, &b.Currency, &b.InitiatedBy, &b.TotalItems, &b.TotalAmount,
+		&b.Checksum, &b.SourceFormat, &b.Status, &b.FailureReason, &b.CreatedAt, &b.UpdatedAt, &b.SubmittedAt,
+	)
+}
+
+type BatchRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBatchRepository(db *pgxpool.Pool) *BatchRepository {
+	return &BatchRepository{db: db}
+}
+
+// Create stores a validated batch and all of its instructions atomically once the batch fits within
+// its initiator's limits
+func (r *BatchRepository) Create(ctx context.Context, batch *models.TransferBatch, items []models.BatchInstruction, limits *models.EffectiveLimits) (*models.TransferBatch, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Check the batch against the initiator's limits under their usage lock; its lines count as
+	// usage from here until their child transfers replace them
+	if err := lockUsage(ctx, tx, batch.InitiatedBy); err != nil {
+		return nil, err
+	}
+	usage, err := readUsage(ctx, tx, batch.InitiatedBy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	amounts := make([]decimal.Decimal, len(items))
+	for i, item := range items {
+		amounts[i] = item.Amount
+	}
+	if err := CheckBatchLimits(limits, usage, amounts); err != nil {
+		return nil, err
+	}
+	perAccount := map[int64]decimal.Decimal{}
+	for _, item := range items {
+		perAccount[item.ToAccountID] = perAccount[item.ToAccountID].Add(item.Amount)
+	}
+	for accountID, amount := range perAccount {
+		if err := checkCoolingOff(ctx, tx, batch.InitiatedBy, accountID, amount, limits.NewBeneficiaryMax, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		INSERT INTO transfer_batches (from_account_id, currency, initiated_by, total_items, total_amount,
+		                              checksum, source_format, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'processing')
+		RETURNING ` + batchColumns
+
+	created := &models.TransferBatch{}
+	err = scanBatch(tx.QueryRow(
+		ctx, query,
+		batch.FromAccountID, batch.Currency, batch.InitiatedBy, len(items), batch.TotalAmount,
+		batch.Checksum, batch.SourceFormat,
+	), created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	rows := make([][]interface{}, len(items))
+	for i, item := range items {
+		var reference *string
+		if item.Reference != "" {
+			ref := item.Reference
+			reference = &ref
+		}
+		rows[i] = []interface{}{created.ID, item.LineNumber, item.ToAccountID, item.Amount, reference}
+	}
+
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"transfer_batch_items"},
+		[]string{"batch_id", "line_number", "to_account_id", "amount", "reference"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch items: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a batch by ID
+func (r *BatchRepository) GetByID(ctx context.Context, id int64) (*models.TransferBatch, error) {
+	query := `SELECT ` + batchColumns + ` FROM transfer_batches WHERE id = $1`
+
+	batch := &models.TransferBatch{}
+	err := scanBatch(r.db.QueryRow(ctx, query, id), batch)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+// FindByChecksum returns the most recent batch from the account with the same checksum created since the given time
+func (r *BatchRepository) FindByChecksum(ctx context.Context, fromAccountID int64, checksum string, since time.Time) (*models.TransferBatch, error) {
+	query := `
+		SELECT ` + batchColumns + `
+		FROM transfer_batches
+		WHERE from_account_id = $1 AND checksum = $2 AND created_at >= $3
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	batch := &models.TransferBatch{}
+	err := scanBatch(r.db.QueryRow(ctx, query, fromAccountID, checksum, since), batch)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, fmt.Errorf("failed to find batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+// List retrieves batches, optionally restricted to one initiator (initiatedBy = 0 lists all)
+func (r *BatchRepository) List(ctx context.Context, initiatedBy int64, limit, offset int) (*models.TransferBatchListResponse, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM transfer_batches WHERE $1 = 0 OR initiated_by = $1`
+	if err := r.db.QueryRow(ctx, countQuery, initiatedBy).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count batches: %w", err)
+	}
+
+	query := `
+		SELECT ` + batchColumns + `
+		FROM transfer_batches
+		WHERE $1 = 0 OR initiated_by = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, initiatedBy, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batches: %w", err)
+	}
+	defer rows.Close()
+
+	batches := []models.TransferBatch{}
+	for rows.Next() {
+		var batch models.TransferBatch
+		if err := scanBatch(rows, &batch); err != nil {
+			return nil, fmt.Errorf("failed to scan batch: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating batches: %w", err)
+	}
+
+	return &models.TransferBatchListResponse{
+		Batches: batches,
+		Total:   total,
+	}, nil
+}
+
+// ListProcessing retrieves batches whose child transfers have not all been created yet
+func (r *BatchRepository) ListProcessing(ctx context.Context) ([]models.TransferBatch, error) {
+	query := `SELECT ` + batchColumns + ` FROM transfer_batches WHERE status = 'processing' ORDER BY id ASC`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processing batches: %w", err)
+	}
+	defer rows.Close()
+
+	batches := []models.TransferBatch{}
+	for rows.Next() {
+		var batch models.TransferBatch
+		if err := scanBatch(rows, &batch); err != nil {
+			return nil, fmt.Errorf("failed to scan batch: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// ListItems retrieves batch lines with the status of their child transfers
+func (r *BatchRepository) ListItems(ctx context.Context, batchID int64, limit, offset int) ([]models.TransferBatchItem, error) {
+	query := `
+		SELECT i.id, i.batch_id, i.line_number, i.to_account_id, i.amount, i.reference, i.transfer_id,
+		       CASE WHEN i.transfer_id IS NULL AND b.status IN ('failed', 'partially_submitted') THEN 'not_submitted' ELSE COALESCE(t.status, 'queued') END,
+		       t.failure_reason
+		FROM transfer_batch_items i
+		JOIN transfer_batches b ON b.id = i.batch_id
+		LEFT JOIN transfers t ON t.id = i.transfer_id
+		WHERE i.batch_id = $1
+		ORDER BY i.line_number ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, batchID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batch items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.TransferBatchItem{}
+	for rows.Next() {
+		var item models.TransferBatchItem
+		err := rows.Scan(
+			&item.ID, &item.BatchID, &item.LineNumber, &item.ToAccountID, &item.Amount, &item.Reference,
+			&item.TransferID, &item.Status, &item.FailureReason,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan batch item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating batch items: %w", err)
+	}
+
+	return items, nil
+}
+
+// ListUnsubmittedItems retrieves the lines that don't have a child transfer yet
+func (r *BatchRepository) ListUnsubmittedItems(ctx context.Context, batchID int64) ([]models.TransferBatchItem, error) {
+	query := `
+		SELECT id, batch_id, line_number, to_account_id, amount, reference
+		FROM transfer_batch_items
+		WHERE batch_id = $1 AND transfer_id IS NULL
+		ORDER BY line_number ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unsubmitted items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.TransferBatchItem{}
+	for rows.Next() {
+		item := models.TransferBatchItem{Status: models.BatchItemStatusQueued}
+		err := rows.Scan(&item.ID, &item.BatchID, &item.LineNumber, &item.ToAccountID, &item.Amount, &item.Reference)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan batch item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetProgress counts batch lines by the status of their child transfers
+func (r *BatchRepository) GetProgress(ctx context.Context, batchID int64) (models.BatchProgress, error) {
+	query := `
+		SELECT CASE WHEN i.transfer_id IS NULL AND b.status IN ('failed', 'partially_submitted') THEN 'not_submitted' ELSE COALESCE(t.status, 'queued') END, COUNT(*)
+		FROM transfer_batch_items i
+		JOIN transfer_batches b ON b.id = i.batch_id
+		LEFT JOIN transfers t ON t.id = i.transfer_id
+		WHERE i.batch_id = $1
+		GROUP BY 1
+	`
+
+	var progress models.BatchProgress
+	rows, err := r.db.Query(ctx, query, batchID)
+	if err != nil {
+		return progress, fmt.Errorf("failed to get batch progress: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return progress, fmt.Errorf("failed to scan batch progress: %w", err)
+		}
+		switch status {
+		case models.BatchItemStatusQueued, models.TransferStatusPending:
+			progress.Queued += count
+		case models.TransferStatusPendingApproval:
+			progress.PendingApproval += count
+		case models.TransferStatusProcessing:
+			progress.Processing += count
+		case models.TransferStatusCompleted, models.TransferStatusReversed:
+			progress.Completed += count
+		case models.TransferStatusFailed:
+			progress.Failed += count
+		case models.TransferStatusRejected:
+			progress.Rejected += count
+		case models.BatchItemStatusNotSubmitted:
+			progress.NotSubmitted += count
+		}
+	}
+
+	return progress, rows.Err()
+}
+
+// MarkStopped ends the execution of a batch that cannot create the rest of its child transfers:
+// it is failed if no line has one and partially submitted otherwise
+func (r *BatchRepository) MarkStopped(ctx context.Context, id int64, reason string) (*models.TransferBatch, error) {
+	query := `
+		UPDATE transfer_batches b
+		SET status = CASE
+		        WHEN EXISTS (SELECT 1 FROM transfer_batch_items WHERE batch_id = b.id AND transfer_id IS NOT NULL)
+		        THEN 'partially_submitted' ELSE 'failed'
+		    END,
+		    failure_reason = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing'
+		RETURNING ` + batchColumns
+
+	batch := &models.TransferBatch{}
+	if err := scanBatch(r.db.QueryRow(ctx, query, id, reason), batch); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, fmt.Errorf("failed to stop batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+// MarkSubmitted records that a child transfer has been created for every line
+func (r *BatchRepository) MarkSubmitted(ctx context.Context, id int64) error {
+	query := `
+		UPDATE transfer_batches
+		SET status = 'submitted', submitted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'processing'
+	`
+
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark batch submitted: %w", err)
+	}
+
+	return nil
+}
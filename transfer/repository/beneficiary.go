@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"transfer/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrBeneficiaryNotFound   = errors.New("beneficiary not found")
+	ErrBeneficiaryExists     = errors.New("a beneficiary with this nickname already exists")
+	ErrBeneficiaryCoolingOff = errors.New("amount exceeds the limit for a newly added beneficiary")
+)
+
+// beneficiaryColumns is the column list selected for every beneficiary query
+const beneficiaryColumns = `id, user_id, nickname, beneficiary_type, account_id, iban, account_number, bank_name, bank_code,
+		       recipient_name, cooling_off_until, created_at, updated_at`
+
+func scanBeneficiary(row rowScanner, b *models.Beneficiary) error {
+	return row.Scan(
+		&b.ID, &b.UserID, &b.Nickname, &b.Type, &b.AccountID, &b.IBAN, &b.AccountNumber, &b.BankName, &b.BankCode,
+		&b.RecipientName, &b.CoolingOffUntil, &b.CreatedAt, &b.UpdatedAt,
+	)
+}
+
+type BeneficiaryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBeneficiaryRepository(db *pgxpool.Pool) *BeneficiaryRepository {
+	return &BeneficiaryRepository{db: db}
+}
+
+// Create stores a new beneficiary whose cooling-off period runs until coolingOffUntil
+func (r *BeneficiaryRepository) Create(ctx context.Context, userID int64, req *models.CreateBeneficiaryRequest, coolingOffUntil time.Time) (*models.Beneficiary, error) {
+	var accountID *int64
+	if req.Type == models.BeneficiaryTypeInternal {
+		accountID = &req.AccountID
+	}
+
+	query := `
+		INSERT INTO beneficiaries (user_id, nickname, beneficiary_type, account_id, iban, account_number,
+		                           bank_name, bank_code, recipient_name, cooling_off_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + beneficiaryColumns
+
+	beneficiary := &models.Beneficiary{}
+	err := scanBeneficiary(r.db.QueryRow(
+		ctx, query,
+		userID, req.Nickname, req.Type, accountID, nullIfEmpty(req.IBAN), nullIfEmpty(req.AccountNumber),
+		nullIfEmpty(req.BankName), nullIfEmpty(req.BankCode), nullIfEmpty(req.RecipientName), coolingOffUntil,
+	), beneficiary)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrBeneficiaryExists
+		}
+		return nil, fmt.Errorf("failed to create beneficiary: %w", err)
+	}
+
+	return beneficiary, nil
+}
+
+// GetByID retrieves a beneficiary by ID
+func (r *BeneficiaryRepository) GetByID(ctx context.Context, id int64) (*models.Beneficiary, error) {
+	query := `SELECT ` + beneficiaryColumns + ` FROM beneficiaries WHERE id = $1`
+
+	beneficiary := &models.Beneficiary{}
+	err := scanBeneficiary(r.db.QueryRow(ctx, query, id), beneficiary)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBeneficiaryNotFound
+		}
+		return nil, fmt.Errorf("failed to get beneficiary: %w", err)
+	}
+
+	return beneficiary, nil
+}
+
+// ListByUser retrieves a user's beneficiaries ordered by nickname
+func (r *BeneficiaryRepository) ListByUser(ctx context.Context, userID int64) (*models.BeneficiaryListResponse, error) {
+	query := `
+		SELECT ` + beneficiaryColumns + `
+		FROM beneficiaries
+		WHERE user_id = $1
+		ORDER BY nickname
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list beneficiaries: %w", err)
+	}
+	defer rows.Close()
+
+	beneficiaries := []models.Beneficiary{}
+	for rows.Next() {
+		var b models.Beneficiary
+		if err := scanBeneficiary(rows, &b); err != nil {
+			return nil, fmt.Errorf("failed to scan beneficiary: %w", err)
+		}
+		beneficiaries = append(beneficiaries, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating beneficiaries: %w", err)
+	}
+
+	return &models.BeneficiaryListResponse{
+		Beneficiaries: beneficiaries,
+		Total:         int64(len(beneficiaries)),
+	}, nil
+}
+
+// Update renames a beneficiary and optionally changes its recipient name
+func (r *BeneficiaryRepository) Update(ctx context.Context, id int64, req *models.UpdateBeneficiaryRequest) (*models.Beneficiary, error) {
+	query := `
+		UPDATE beneficiaries
+		SET nickname = $2,
+		    recipient_name = COALESCE($3, recipient_name)
+		WHERE id = $1
+		RETURNING ` + beneficiaryColumns
+
+	beneficiary := &models.Beneficiary{}
+	err := scanBeneficiary(r.db.QueryRow(ctx, query, id, req.Nickname, req.RecipientName), beneficiary)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrBeneficiaryNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			return nil, ErrBeneficiaryExists
+		}
+		return nil, fmt.Errorf("failed to update beneficiary: %w", err)
+	}
+
+	return beneficiary, nil
+}
+
+// Delete removes a beneficiary; transfers sent to it keep their history but lose the link
+func (r *BeneficiaryRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM beneficiaries WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete beneficiary: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrBeneficiaryNotFound
+	}
+	return nil
+}
+
+// coolingOffWindow finds the user's beneficiaries for a destination that are still cooling off:
+// an internal account by accountID, or an IBAN or external account number, upper-cased without
+// spaces, by account. It returns when the earliest of them was added and when the last one's
+// cooling-off ends, or nil times if none is cooling off.
+func coolingOffWindow(ctx context.Context, q queryRower, userID, accountID int64, account string, now time.Time) (since, until *time.Time, err error) {
+	err = q.QueryRow(ctx, `
+		SELECT MIN(created_at), MAX(cooling_off_until)
+		FROM beneficiaries
+		WHERE user_id = $1 AND cooling_off_until > $4
+		  AND ((beneficiary_type = 'internal' AND account_id = $2)
+		    OR (beneficiary_type = 'iban' AND iban = $3)
+		    OR (beneficiary_type = 'external' AND UPPER(REPLACE(account_number, ' ', '')) = $3))
+	`, userID, accountID, account, now).Scan(&since, &until)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check beneficiary cooling-off: %w", err)
+	}
+	return since, until, nil
+}
+
+// CoolingOffWindow is coolingOffWindow for an account at another bank, as the payment service
+// checks it before an external payment whether or not the payment names the beneficiary
+func (r *BeneficiaryRepository) CoolingOffWindow(ctx context.Context, userID int64, account string, now time.Time) (since, until *time.Time, err error) {
+	return coolingOffWindow(ctx, r.db, userID, 0, strings.ToUpper(strings.ReplaceAll(account, " ", "")), now)
+}
+
+// checkCoolingOff verifies that sending amount to accountID keeps what the user has sent it since
+// adding it as a beneficiary within max, for as long as that beneficiary is cooling off. Transfers
+// count however they name the recipient; failed and rejected ones don't, lines of batches still
+// being executed do. Callers hold the user's usage lock.
+func checkCoolingOff(ctx context.Context, q queryRower, userID, accountID int64, amount, max decimal.Decimal, now time.Time) error {
+	since, until, err := coolingOffWindow(ctx, q, userID, accountID, "", now)
+	if err != nil {
+		return err
+	}
+	if since == nil {
+		return nil
+	}
+
+	var sent decimal.Decimal
+	err = q.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM (
+			SELECT amount
+			FROM transfers
+			WHERE initiated_by = $1 AND to_account_id = $2 AND created_at >= $3
+			  AND status NOT IN ('failed', 'rejected')
+			UNION ALL
+			SELECT i.amount
+			FROM transfer_batch_items i
+			JOIN transfer_batches b ON b.id = i.batch_id
+			WHERE b.initiated_by = $1 AND i.to_account_id = $2 AND b.created_at >= $3
+			  AND b.status = 'processing' AND i.transfer_id IS NULL
+		) sent
+	`, userID, accountID, *since).Scan(&sent)
+	if err != nil {
+		return fmt.Errorf("failed to sum transfers to beneficiary: %w", err)
+	}
+
+	if sent.Add(amount).GreaterThan(max) {
+		return fmt.Errorf("%w: %s more may be sent to account %d until %s", ErrBeneficiaryCoolingOff,
+			decimal.Max(max.Sub(sent), decimal.Zero).StringFixed(2), accountID, until.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"transfer/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrFeeRuleNotFound = errors.New("fee rule not found")
+	ErrFeeRuleExists   = errors.New("a fee rule already exists for this channel, account type and currency")
+	ErrInvalidFeeRule  = errors.New("fee amounts must not be negative and min_fee must not exceed max_fee")
+)
+
+// feeRuleColumns is the column list selected for every fee rule query
+const feeRuleColumns = `id, channel, account_type, currency, flat_amount, percentage, min_fee, max_fee, active,
+		       created_at, updated_at`
+
+func scanFeeRule(row rowScanner, rule *models.FeeRule) error {
+	return row.Scan(
+		&rule.ID, &rule.Channel, &rule.AccountType, &rule.Currency, &rule.FlatAmount, &rule.Percentage,
+		&rule.MinFee, &rule.MaxFee, &rule.Active, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+}
+
+type FeeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFeeRepository(db *pgxpool.Pool) *FeeRepository {
+	return &FeeRepository{db: db}
+}
+
+// CalculateFee prices an amount under a rule: the flat amount plus the percentage of the amount,
+// clamped to the rule's minimum and maximum and rounded to cents. A nil rule is free.
+func CalculateFee(rule *models.FeeRule, amount decimal.Decimal) decimal.Decimal {
+	if rule == nil {
+		return decimal.Zero
+	}
+
+	fee := rule.FlatAmount.Add(amount.Mul(rule.Percentage).Div(decimal.NewFromInt(100)))
+	if rule.MinFee != nil && fee.LessThan(*rule.MinFee) {
+		fee = *rule.MinFee
+	}
+	if rule.MaxFee != nil && fee.GreaterThan(*rule.MaxFee) {
+		fee = *rule.MaxFee
+	}
+
+	return fee.Round(2)
+}
+
+// validateFeeRule checks the amounts of a fee rule request
+func validateFeeRule(req *models.FeeRuleRequest) error {
+	if req.FlatAmount.IsNegative() || req.Percentage.IsNegative() {
+		return ErrInvalidFeeRule
+	}
+	if req.MinFee != nil && req.MinFee.IsNegative() {
+		return ErrInvalidFeeRule
+	}
+	if req.MaxFee != nil && req.MaxFee.IsNegative() {
+		return ErrInvalidFeeRule
+	}
+	if req.MinFee != nil && req.MaxFee != nil && req.MinFee.GreaterThan(*req.MaxFee) {
+		return ErrInvalidFeeRule
+	}
+	return nil
+}
+
+// FindRule returns the most specific active rule for a channel, source account product and currency,
+// or ErrFeeRuleNotFound when none applies
+func (r *FeeRepository) FindRule(ctx context.Context, channel, accountType, currency string) (*models.FeeRule, error) {
+	query := `
+		SELECT ` + feeRuleColumns + `
+		FROM transfer_fee_rules
+		WHERE active
+		  AND channel = $1
+		  AND (account_type = $2 OR account_type IS NULL)
+		  AND (currency = $3 OR currency IS NULL)
+		ORDER BY (account_type IS NOT NULL) DESC, (currency IS NOT NULL) DESC
+		LIMIT 1
+	`
+
+	rule := &models.FeeRule{}
+	if err := scanFeeRule(r.db.QueryRow(ctx, query, channel, accountType, currency), rule); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFeeRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to find fee rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// ListRules retrieves all fee rules
+func (r *FeeRepository) ListRules(ctx context.Context) ([]models.FeeRule, error) {
+	query := `
+		SELECT ` + feeRuleColumns + `
+		FROM transfer_fee_rules
+		ORDER BY channel ASC, account_type ASC NULLS FIRST, currency ASC NULLS FIRST
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fee rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.FeeRule{}
+	for rows.Next() {
+		var rule models.FeeRule
+		if err := scanFeeRule(rows, &rule); err != nil {
+			return nil, fmt.Errorf("failed to scan fee rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fee rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// CreateRule adds a fee rule
+func (r *FeeRepository) CreateRule(ctx context.Context, req *models.FeeRuleRequest) (*models.FeeRule, error) {
+	if err := validateFeeRule(req); err != nil {
+		return nil, err
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	query := `
+		INSERT INTO transfer_fee_rules (channel, account_type, currency, flat_amount, percentage, min_fee, max_fee, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + feeRuleColumns
+
+	rule := &models.FeeRule{}
+	err := scanFeeRule(r.db.QueryRow(
+		ctx, query,
+		req.Channel, req.AccountType, req.Currency, req.FlatAmount, req.Percentage, req.MinFee, req.MaxFee, active,
+	), rule)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrFeeRuleExists
+		}
+		return nil, fmt.Errorf("failed to create fee rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// UpdateRule replaces a fee rule
+func (r *FeeRepository) UpdateRule(ctx context.Context, id int64, req *models.FeeRuleRequest) (*models.FeeRule, error) {
+	if err := validateFeeRule(req); err != nil {
+		return nil, err
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	query := `
+		UPDATE transfer_fee_rules
+		SET channel = $2, account_type = $3, currency = $4, flat_amount = $5, percentage = $6,
+		    min_fee = $7, max_fee = $8, active = $9
+		WHERE id = $1
+		RETURNING ` + feeRuleColumns
+
+	rule := &models.FeeRule{}
+	err := scanFeeRule(r.db.QueryRow(
+		ctx, query,
+		id, req.Channel, req.AccountType, req.Currency, req.FlatAmount, req.Percentage, req.MinFee, req.MaxFee, active,
+	), rule)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrFeeRuleNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			return nil, ErrFeeRuleExists
+		}
+		return nil, fmt.Errorf("failed to update fee rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// DeleteRule removes a fee rule
+func (r *FeeRepository) DeleteRule(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM transfer_fee_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete fee rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFeeRuleNotFound
+	}
+	return nil
+}
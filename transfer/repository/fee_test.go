@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"testing"
+
+	"transfer/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCalculateFee(t *testing.T) {
+	dec := func(s string) *decimal.Decimal {
+		d := decimal.RequireFromString(s)
+		return &d
+	}
+
+	tests := []struct {
+		name   string
+		rule   *models.FeeRule
+		amount string
+		want   string
+	}{
+		{
+			name:   "no rule is free",
+			rule:   nil,
+			amount: "100",
+			want:   "0",
+		},
+		{
+			name:   "flat only",
+			rule:   &models.FeeRule{FlatAmount: decimal.NewFromInt(2)},
+			amount: "100",
+			want:   "2",
+		},
+		{
+			name:   "percentage only",
+			rule:   &models.FeeRule{Percentage: decimal.RequireFromString("0.5")},
+			amount: "1000",
+			want:   "5",
+		},
+		{
+			name:   "flat plus percentage",
+			rule:   &models.FeeRule{FlatAmount: decimal.NewFromInt(1), Percentage: decimal.RequireFromString("0.1")},
+			amount: "2000",
+			want:   "3",
+		},
+		{
+			name:   "raised to minimum",
+			rule:   &models.FeeRule{Percentage: decimal.RequireFromString("0.5"), MinFee: dec("2")},
+			amount: "100",
+			want:   "2",
+		},
+		{
+			name:   "capped at maximum",
+			rule:   &models.FeeRule{Percentage: decimal.RequireFromString("0.5"), MaxFee: dec("50")},
+			amount: "100000",
+			want:   "50",
+		},
+		{
+			name:   "rounded to cents",
+			rule:   &models.FeeRule{Percentage: decimal.RequireFromString("0.25")},
+			amount: "10.10",
+			want:   "0.03",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateFee(tt.rule, decimal.RequireFromString(tt.amount))
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("CalculateFee() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
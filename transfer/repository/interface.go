@@ -2,22 +2,108 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"transfer/models"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // TransferRepo defines the interface for transfer data access.
 type TransferRepo interface {
-	Create(ctx context.Context, req *models.CreateTransferRequest) (*models.Transfer, error)
+	Create(ctx context.Context, userID int64, req *models.CreateTransferRequest, quote *models.FeeQuote, limits *models.EffectiveLimits) (*models.Transfer, error)
+	CreateForBatchItem(ctx context.Context, batch *models.TransferBatch, item *models.TransferBatchItem, quote *models.FeeQuote) (*models.Transfer, error)
 	GetByID(ctx context.Context, id int64) (*models.Transfer, error)
 	GetByReferenceID(ctx context.Context, referenceID uuid.UUID) (*models.Transfer, error)
 	ListByAccountID(ctx context.Context, accountID int64, limit, offset int) (*models.TransferListResponse, error)
 	ListByAccountIDs(ctx context.Context, accountIDs []int64, limit, offset int) (*models.TransferListResponse, error)
 	ListAll(ctx context.Context, limit, offset int) (*models.TransferListResponse, error)
-	UpdateStatus(ctx context.Context, id int64, status string, failureReason *string) (*models.Transfer, error)
+	ListByStatus(ctx context.Context, status string, limit, offset int) (*models.TransferListResponse, error)
+	Search(ctx context.Context, accountIDs []int64, search *models.TransferSearch, limit, offset int) (*models.TransferListResponse, error)
+	ListStale(ctx context.Context, status string, before time.Time, limit int) ([]models.Transfer, error)
+	SetCategory(ctx context.Context, id int64, side, category string) (*models.Transfer, error)
+	RecordSagaRetry(ctx context.Context, id int64) (*models.Transfer, error)
+	Review(ctx context.Context, id, reviewerID int64, approve bool, reason *string) (*models.Transfer, error)
+	UpdateStatus(ctx context.Context, id int64, status string, failureReason *string, cause string) (*models.Transfer, error)
 	MarkAsProcessing(ctx context.Context, id int64) (*models.Transfer, error)
-	MarkAsCompleted(ctx context.Context, id int64) (*models.Transfer, error)
-	MarkAsFailed(ctx context.Context, id int64, reason string) (*models.Transfer, error)
+	MarkAsPendingApproval(ctx context.Context, id int64) (*models.Transfer, error)
+	MarkAsCompleted(ctx context.Context, id int64, cause string) (*models.Transfer, error)
+	MarkAsFailed(ctx context.Context, id int64, reason, cause string) (*models.Transfer, error)
+	CreateReversal(ctx context.Context, originalID, adminID int64, amount decimal.Decimal, policy, reason string) (*models.Transfer, error)
+	CompleteReversal(ctx context.Context, reversalID int64, settled decimal.Decimal, cause string) (*models.Transfer, error)
+	ListStatusHistory(ctx context.Context, id int64) ([]models.StatusTransition, error)
+}
+
+// LimitRepo defines the interface for transfer limit data access.
+type LimitRepo interface {
+	GetEffectiveLimits(ctx context.Context, userID int64) (*models.EffectiveLimits, error)
+	GetUsage(ctx context.Context, userID int64, now time.Time) (*models.LimitUsage, error)
+	ListSegments(ctx context.Context) ([]models.SegmentLimits, error)
+	UpsertSegment(ctx context.Context, segment string, req *models.UpdateSegmentLimitsRequest) (*models.SegmentLimits, error)
+	GetOverride(ctx context.Context, userID int64) (*models.LimitOverride, error)
+	SetOverride(ctx context.Context, userID, adminID int64, req *models.SetLimitOverrideRequest) (*models.LimitOverride, error)
+	DeleteOverride(ctx context.Context, userID int64) error
+}
+
+// BatchRepo defines the interface for transfer batch data access.
+type BatchRepo interface {
+	Create(ctx context.Context, batch *models.TransferBatch, items []models.BatchInstruction, limits *models.EffectiveLimits) (*models.TransferBatch, error)
+	GetByID(ctx context.Context, id int64) (*models.TransferBatch, error)
+	FindByChecksum(ctx context.Context, fromAccountID int64, checksum string, since time.Time) (*models.TransferBatch, error)
+	List(ctx context.Context, initiatedBy int64, limit, offset int) (*models.TransferBatchListResponse, error)
+	ListProcessing(ctx context.Context) ([]models.TransferBatch, error)
+	ListItems(ctx context.Context, batchID int64, limit, offset int) ([]models.TransferBatchItem, error)
+	ListUnsubmittedItems(ctx context.Context, batchID int64) ([]models.TransferBatchItem, error)
+	GetProgress(ctx context.Context, batchID int64) (models.BatchProgress, error)
+	MarkSubmitted(ctx context.Context, id int64) error
+	MarkStopped(ctx context.Context, id int64, reason string) (*models.TransferBatch, error)
+}
+
+// FeeRepo defines the interface for transfer fee rule data access.
+type FeeRepo interface {
+	FindRule(ctx context.Context, channel, accountType, currency string) (*models.FeeRule, error)
+	ListRules(ctx context.Context) ([]models.FeeRule, error)
+	CreateRule(ctx context.Context, req *models.FeeRuleRequest) (*models.FeeRule, error)
+	UpdateRule(ctx context.Context, id int64, req *models.FeeRuleRequest) (*models.FeeRule, error)
+	DeleteRule(ctx context.Context, id int64) error
+}
+
+// MoneyRequestRepo defines the interface for money request data access.
+type MoneyRequestRepo interface {
+	Create(ctx context.Context, requesterUserID, payerUserID int64, currency string, req *models.CreateMoneyRequestRequest, expiresAt time.Time) (*models.MoneyRequest, error)
+	GetByID(ctx context.Context, id int64) (*models.MoneyRequest, error)
+	List(ctx context.Context, userID int64, direction, status string, limit, offset int) (*models.MoneyRequestListResponse, error)
+	Respond(ctx context.Context, id int64, status string, reason *string) (*models.MoneyRequest, error)
+	Reopen(ctx context.Context, id int64) error
+	ExpireDue(ctx context.Context, now time.Time) ([]models.MoneyRequest, error)
+}
+
+// SplitRepo defines the interface for bill split data access.
+type SplitRepo interface {
+	Create(ctx context.Context, split *models.BillSplit, shares []models.SplitShare) (*models.BillSplit, error)
+	GetByID(ctx context.Context, id int64) (*models.BillSplit, error)
+	List(ctx context.Context, userID int64, limit, offset int) (*models.SplitListResponse, error)
+	ClaimShare(ctx context.Context, splitID, userID int64) (*models.SplitShare, error)
+	AttachShareTransfer(ctx context.Context, shareID, transferID int64) (*models.SplitShare, error)
+	ReleaseShare(ctx context.Context, shareID int64) error
+	SyncShare(ctx context.Context, transferID int64, status string) error
+	Cancel(ctx context.Context, id int64) (*models.BillSplit, error)
+	ListSharesDueReminder(ctx context.Context, remindedBefore time.Time, maxReminders, limit int) ([]models.SplitShare, error)
+	MarkReminded(ctx context.Context, shareID int64) (*models.SplitShare, error)
+}
+
+// BeneficiaryRepo defines the interface for beneficiary data access.
+type BeneficiaryRepo interface {
+	Create(ctx context.Context, userID int64, req *models.CreateBeneficiaryRequest, coolingOffUntil time.Time) (*models.Beneficiary, error)
+	GetByID(ctx context.Context, id int64) (*models.Beneficiary, error)
+	ListByUser(ctx context.Context, userID int64) (*models.BeneficiaryListResponse, error)
+	Update(ctx context.Context, id int64, req *models.UpdateBeneficiaryRequest) (*models.Beneficiary, error)
+	Delete(ctx context.Context, id int64) error
+	CoolingOffWindow(ctx context.Context, userID int64, account string, now time.Time) (since, until *time.Time, err error)
+}
+
+// SpendingRepo defines the interface for spending summaries over transfers.
+type SpendingRepo interface {
+	Summarize(ctx context.Context, userID int64, accountIDs []int64, from, to time.Time, recipients int) (*models.TransferSpending, error)
 }
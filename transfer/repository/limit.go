@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"transfer/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrSegmentNotFound       = errors.New("limit segment not found")
+	ErrLimitOverrideNotFound = errors.New("limit override not found")
+	ErrPerTransactionLimit   = errors.New("amount exceeds per-transaction limit")
+	ErrDailyLimit            = errors.New("amount exceeds daily transfer limit")
+	ErrMonthlyLimit          = errors.New("amount exceeds monthly transfer limit")
+	ErrHourlyCountLimit      = errors.New("too many transfers in the last hour")
+)
+
+type LimitRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLimitRepository(db *pgxpool.Pool) *LimitRepository {
+	return &LimitRepository{db: db}
+}
+
+// CheckLimits verifies that a transfer of the given amount fits within the limits and current usage
+func CheckLimits(limits *models.EffectiveLimits, usage *models.LimitUsage, amount decimal.Decimal) error {
+	if amount.GreaterThan(limits.PerTransactionMax) {
+		return ErrPerTransactionLimit
+	}
+	if usage.HourlyCount+1 > limits.HourlyCountMax {
+		return ErrHourlyCountLimit
+	}
+	if usage.DailyTotal.Add(amount).GreaterThan(limits.DailyMax) {
+		return ErrDailyLimit
+	}
+	if usage.MonthlyTotal.Add(amount).GreaterThan(limits.MonthlyMax) {
+		return ErrMonthlyLimit
+	}
+	return nil
+}
+
+// CheckBatchLimits verifies a batch of transfers: every amount must fit the per-transaction limit,
+// the batch total must fit the daily and monthly limits, and the batch counts as a single operation
+// towards the hourly count.
+func CheckBatchLimits(limits *models.EffectiveLimits, usage *models.LimitUsage, amounts []decimal.Decimal) error {
+	total := decimal.Zero
+	for _, amount := range amounts {
+		if amount.GreaterThan(limits.PerTransactionMax) {
+			return ErrPerTransactionLimit
+		}
+		total = total.Add(amount)
+	}
+
+	return CheckLimits(
+		&models.EffectiveLimits{
+			PerTransactionMax: total,
+			DailyMax:          limits.DailyMax,
+			MonthlyMax:        limits.MonthlyMax,
+			HourlyCountMax:    limits.HourlyCountMax,
+		},
+		usage,
+		total,
+	)
+}
+
+// GetEffectiveLimits resolves the user's segment limits with any admin overrides applied
+func (r *LimitRepository) GetEffectiveLimits(ctx context.Context, userID int64) (*models.EffectiveLimits, error) {
+	query := `
+		SELECT s.segment,
+		       COALESCE(o.per_transaction_max, s.per_transaction_max),
+		       COALESCE(o.daily_max, s.daily_max),
+		       COALESCE(o.monthly_max, s.monthly_max),
+		       COALESCE(o.hourly_count_max, s.hourly_count_max),
+		       o.user_id IS NOT NULL
+		FROM transfer_limit_segments s
+		LEFT JOIN transfer_limit_overrides o ON o.user_id = $1
+		WHERE s.segment = COALESCE(o.segment, $2)
+	`
+
+	limits := &models.EffectiveLimits{UserID: userID}
+	err := r.db.QueryRow(ctx, query, userID, models.DefaultLimitSegment).Scan(
+		&limits.Segment, &limits.PerTransactionMax, &limits.DailyMax,
+		&limits.MonthlyMax, &limits.HourlyCountMax, &limits.Overridden,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSegmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get effective limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+// GetUsage sums the user's non-failed, non-rejected transfers for the current day, month and last hour.
+// Reversals return money rather than send it, so they never count. Batch children count towards the amount totals individually, but each batch counts as a single
+// operation towards the hourly count.
+func (r *LimitRepository) GetUsage(ctx context.Context, userID int64, now time.Time) (*models.LimitUsage, error) {
+	return readUsage(ctx, r.db, userID, now)
+}
+
+// queryRower is satisfied by both the pool and a transaction
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// readUsage sums the user's usage as GetUsage describes. Lines of a batch still being executed
+// count from when the batch was accepted, as their child transfers do not exist yet.
+func readUsage(ctx context.Context, q queryRower, userID int64, now time.Time) (*models.LimitUsage, error) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	hourAgo := now.Add(-time.Hour)
+
+	query := `
+		WITH used AS (
+			SELECT amount, created_at, batch_id
+			FROM transfers
+			WHERE initiated_by = $1
+			  AND reversal_of IS NULL
+			  AND status NOT IN ('failed', 'rejected')
+			  AND created_at >= LEAST($2::timestamptz, $3::timestamptz, $4::timestamptz)
+			UNION ALL
+			SELECT i.amount, b.created_at, b.id
+			FROM transfer_batch_items i
+			JOIN transfer_batches b ON b.id = i.batch_id
+			WHERE b.initiated_by = $1
+			  AND b.status = 'processing'
+			  AND i.transfer_id IS NULL
+			  AND b.created_at >= LEAST($2::timestamptz, $3::timestamptz, $4::timestamptz)
+		)
+		SELECT COALESCE(SUM(amount) FILTER (WHERE created_at >= $2), 0),
+		       COALESCE(SUM(amount) FILTER (WHERE created_at >= $3), 0),
+		       COUNT(*) FILTER (WHERE created_at >= $4 AND batch_id IS NULL)
+		         + COUNT(DISTINCT batch_id) FILTER (WHERE created_at >= $4)
+		FROM used
+	`
+
+	usage := &models.LimitUsage{}
+	err := q.QueryRow(ctx, query, userID, dayStart, monthStart, hourAgo).Scan(
+		&usage.DailyTotal, &usage.MonthlyTotal, &usage.HourlyCount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get limit usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// lockUsage serializes limit checks for a user until tx ends, so concurrent transfers cannot each
+// pass against the same usage and together exceed a limit
+func lockUsage(ctx context.Context, tx pgx.Tx, userID int64) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('transfer_limits', $1))`, userID); err != nil {
+		return fmt.Errorf("failed to lock limit usage: %w", err)
+	}
+	return nil
+}
+
+// IsLimitError reports whether err is a transfer limit being exceeded
+func IsLimitError(err error) bool {
+	return errors.Is(err, ErrPerTransactionLimit) || errors.Is(err, ErrDailyLimit) ||
+		errors.Is(err, ErrMonthlyLimit) || errors.Is(err, ErrHourlyCountLimit) ||
+		errors.Is(err, ErrBeneficiaryCoolingOff)
+}
+
+// ListSegments retrieves all segment limits
+func (r *LimitRepository) ListSegments(ctx context.Context) ([]models.SegmentLimits, error) {
+	query := `
+		SELECT segment, per_transaction_max, daily_max, monthly_max, hourly_count_max,
+		       created_at, updated_at
+		FROM transfer_limit_segments
+		ORDER BY segment ASC
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list segments: %w", err)
+	}
+	defer rows.Close()
+
+	segments := []models.SegmentLimits{}
+	for rows.Next() {
+		var s models.SegmentLimits
+		err := rows.Scan(
+			&s.Segment, &s.PerTransactionMax, &s.DailyMax, &s.MonthlyMax, &s.HourlyCountMax,
+			&s.CreatedAt, &s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan segment: %w", err)
+		}
+		segments = append(segments, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating segments: %w", err)
+	}
+
+	return segments, nil
+}
+
+// UpsertSegment creates or replaces the limits for a segment
+func (r *LimitRepository) UpsertSegment(ctx context.Context, segment string, req *models.UpdateSegmentLimitsRequest) (*models.SegmentLimits, error) {
+	if req.PerTransactionMax.LessThanOrEqual(decimal.Zero) || req.DailyMax.LessThanOrEqual(decimal.Zero) ||
+		req.MonthlyMax.LessThanOrEqual(decimal.Zero) || req.HourlyCountMax <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	query := `
+		INSERT INTO transfer_limit_segments (segment, per_transaction_max, daily_max, monthly_max, hourly_count_max)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (segment) DO UPDATE
+		SET per_transaction_max = EXCLUDED.per_transaction_max,
+		    daily_max = EXCLUDED.daily_max,
+		    monthly_max = EXCLUDED.monthly_max,
+		    hourly_count_max = EXCLUDED.hourly_count_max
+		RETURNING segment, per_transaction_max, daily_max, monthly_max, hourly_count_max,
+		          created_at, updated_at
+	`
+
+	s := &models.SegmentLimits{}
+	err := r.db.QueryRow(
+		ctx, query,
+		segment, req.PerTransactionMax, req.DailyMax, req.MonthlyMax, req.HourlyCountMax,
+	).Scan(
+		&s.Segment, &s.PerTransactionMax, &s.DailyMax, &s.MonthlyMax, &s.HourlyCountMax,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert segment: %w", err)
+	}
+
+	return s, nil
+}
+
+// GetOverride retrieves the admin override for a user
+func (r *LimitRepository) GetOverride(ctx context.Context, userID int64) (*models.LimitOverride, error) {
+	query := `
+		SELECT user_id, segment, per_transaction_max, daily_max, monthly_max, hourly_count_max,
+		       reason, updated_by, created_at, updated_at
+		FROM transfer_limit_overrides
+		WHERE user_id = $1
+	`
+
+	o := &models.LimitOverride{}
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&o.UserID, &o.Segment, &o.PerTransactionMax, &o.DailyMax, &o.MonthlyMax, &o.HourlyCountMax,
+		&o.Reason, &o.UpdatedBy, &o.CreatedAt, &o.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLimitOverrideNotFound
+		}
+		return nil, fmt.Errorf("failed to get limit override: %w", err)
+	}
+
+	return o, nil
+}
+
+// SetOverride creates or replaces the admin override for a user
+func (r *LimitRepository) SetOverride(ctx context.Context, userID, adminID int64, req *models.SetLimitOverrideRequest) (*models.LimitOverride, error) {
+	for _, v := range []*decimal.Decimal{req.PerTransactionMax, req.DailyMax, req.MonthlyMax} {
+		if v != nil && v.LessThanOrEqual(decimal.Zero) {
+			return nil, ErrInvalidAmount
+		}
+	}
+
+	if req.Segment != nil {
+		var exists bool
+		err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM transfer_limit_segments WHERE segment = $1)`, *req.Segment).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check segment: %w", err)
+		}
+		if !exists {
+			return nil, ErrSegmentNotFound
+		}
+	}
+
+	query := `
+		INSERT INTO transfer_limit_overrides (user_id, segment, per_transaction_max, daily_max, monthly_max,
+		                                      hourly_count_max, reason, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE
+		SET segment = EXCLUDED.segment,
+		    per_transaction_max = EXCLUDED.per_transaction_max,
+		    daily_max = EXCLUDED.daily_max,
+		    monthly_max = EXCLUDED.monthly_max,
+		    hourly_count_max = EXCLUDED.hourly_count_max,
+		    reason = EXCLUDED.reason,
+		    updated_by = EXCLUDED.updated_by
+		RETURNING user_id, segment, per_transaction_max, daily_max, monthly_max, hourly_count_max,
+		          reason, updated_by, created_at, updated_at
+	`
+
+	o := &models.LimitOverride{}
+	err := r.db.QueryRow(
+		ctx, query,
+		userID, req.Segment, req.PerTransactionMax, req.DailyMax, req.MonthlyMax,
+		req.HourlyCountMax, req.Reason, adminID,
+	).Scan(
+		&o.UserID, &o.Segment, &o.PerTransactionMax, &o.DailyMax, &o.MonthlyMax, &o.HourlyCountMax,
+		&o.Reason, &o.UpdatedBy, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set limit override: %w", err)
+	}
+
+	return o, nil
+}
+
+// DeleteOverride removes the admin override for a user
+func (r *LimitRepository) DeleteOverride(ctx context.Context, userID int64) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM transfer_limit_overrides WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete limit override: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrLimitOverrideNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"transfer/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCheckLimits(t *testing.T) {
+	limits := &models.EffectiveLimits{
+		Segment:           models.DefaultLimitSegment,
+		PerTransactionMax: decimal.NewFromInt(5000),
+		DailyMax:          decimal.NewFromInt(10000),
+		MonthlyMax:        decimal.NewFromInt(50000),
+		HourlyCountMax:    10,
+	}
+
+	tests := []struct {
+		name    string
+		usage   models.LimitUsage
+		amount  decimal.Decimal
+		wantErr error
+	}{
+		{
+			name:   "within all limits",
+			usage:  models.LimitUsage{DailyTotal: decimal.NewFromInt(1000), MonthlyTotal: decimal.NewFromInt(2000), HourlyCount: 1},
+			amount: decimal.NewFromInt(500),
+		},
+		{
+			name:   "exactly at per-transaction limit",
+			usage:  models.LimitUsage{},
+			amount: decimal.NewFromInt(5000),
+		},
+		{
+			name:    "exceeds per-transaction limit",
+			usage:   models.LimitUsage{},
+			amount:  decimal.NewFromInt(5001),
+			wantErr: ErrPerTransactionLimit,
+		},
+		{
+			name:    "cumulative exceeds daily limit",
+			usage:   models.LimitUsage{DailyTotal: decimal.NewFromInt(9000), MonthlyTotal: decimal.NewFromInt(9000)},
+			amount:  decimal.NewFromInt(1500),
+			wantErr: ErrDailyLimit,
+		},
+		{
+			name:    "cumulative exceeds monthly limit",
+			usage:   models.LimitUsage{DailyTotal: decimal.Zero, MonthlyTotal: decimal.NewFromInt(49000)},
+			amount:  decimal.NewFromInt(1500),
+			wantErr: ErrMonthlyLimit,
+		},
+		{
+			name:    "hourly count reached",
+			usage:   models.LimitUsage{HourlyCount: 10},
+			amount:  decimal.NewFromInt(1),
+			wantErr: ErrHourlyCountLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckLimits(limits, &tt.usage, tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckLimits() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckBatchLimits(t *testing.T) {
+	limits := &models.EffectiveLimits{
+		Segment:           models.DefaultLimitSegment,
+		PerTransactionMax: decimal.NewFromInt(5000),
+		DailyMax:          decimal.NewFromInt(10000),
+		MonthlyMax:        decimal.NewFromInt(50000),
+		HourlyCountMax:    10,
+	}
+
+	tests := []struct {
+		name    string
+		usage   models.LimitUsage
+		amounts []decimal.Decimal
+		wantErr error
+	}{
+		{
+			name:    "batch within limits",
+			usage:   models.LimitUsage{HourlyCount: 9},
+			amounts: []decimal.Decimal{decimal.NewFromInt(4000), decimal.NewFromInt(4000)},
+		},
+		{
+			name:    "single line exceeds per-transaction limit",
+			amounts: []decimal.Decimal{decimal.NewFromInt(100), decimal.NewFromInt(5001)},
+			wantErr: ErrPerTransactionLimit,
+		},
+		{
+			name:    "batch total exceeds daily limit",
+			usage:   models.LimitUsage{DailyTotal: decimal.NewFromInt(3000), MonthlyTotal: decimal.NewFromInt(3000)},
+			amounts: []decimal.Decimal{decimal.NewFromInt(4000), decimal.NewFromInt(4000)},
+			wantErr: ErrDailyLimit,
+		},
+		{
+			name:    "hourly count reached",
+			usage:   models.LimitUsage{HourlyCount: 10},
+			amounts: []decimal.Decimal{decimal.NewFromInt(1)},
+			wantErr: ErrHourlyCountLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckBatchLimits(limits, &tt.usage, tt.amounts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckBatchLimits() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"transfer/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrMoneyRequestNotFound = errors.New("money request not found")
+	ErrMoneyRequestClosed   = errors.New("money request is no longer pending")
+)
+
+// moneyRequestColumns is the column list selected for every money request query
+const moneyRequestColumns = `id, reference_id, requester_user_id, requester_account_id, payer_user_id, payer_account_id,
+		       amount, currency, note, status, transfer_id, decline_reason, expires_at, responded_at,
+		       created_at, updated_at`
+
+func scanMoneyRequest(row rowScanner, r *models.MoneyRequest) error {
+	return row.Scan(
+		&r.ID, &r.ReferenceID, &r.RequesterUserID, &r.RequesterAccountID, &r.PayerUserID, &r.PayerAccountID,
+		&r.Amount, &r.Currency, &r.Note, &r.Status, &r.TransferID, &r.DeclineReason, &r.ExpiresAt, &r.RespondedAt,
+		&r.CreatedAt, &r.UpdatedAt,
+	)
+}
+
+func collectMoneyRequests(rows pgx.Rows) ([]models.MoneyRequest, error) {
+	defer rows.Close()
+
+	requests := []models.MoneyRequest{}
+	for rows.Next() {
+		var r models.MoneyRequest
+		if err := scanMoneyRequest(rows, &r); err != nil {
+			return nil, fmt.Errorf("failed to scan money request: %w", err)
+		}
+		requests = append(requests, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating money requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+type MoneyRequestRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMoneyRequestRepository(db *pgxpool.Pool) *MoneyRequestRepository {
+	return &MoneyRequestRepository{db: db}
+}
+
+// Create stores a new pending money request
+func (r *MoneyRequestRepository) Create(ctx context.Context, requesterUserID, payerUserID int64, currency string, req *models.CreateMoneyRequestRequest, expiresAt time.Time) (*models.MoneyRequest, error) {
+	if !req.Amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	if req.ToAccountID == req.PayerAccountID {
+		return nil, ErrSameAccount
+	}
+
+	query := `
+		INSERT INTO money_requests (requester_user_id, requester_account_id, payer_user_id, payer_account_id,
+		                            amount, currency, note, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending', $8)
+		RETURNING ` + moneyRequestColumns
+
+	request := &models.MoneyRequest{}
+	err := scanMoneyRequest(r.db.QueryRow(
+		ctx, query,
+		requesterUserID, req.ToAccountID, payerUserID, req.PayerAccountID,
+		req.Amount, currency, nullIfEmpty(req.Note), expiresAt,
+	), request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create money request: %w", err)
+	}
+
+	return request, nil
+}
+
+// GetByID retrieves a money request by ID
+func (r *MoneyRequestRepository) GetByID(ctx context.Context, id int64) (*models.MoneyRequest, error) {
+	query := `SELECT ` + moneyRequestColumns + ` FROM money_requests WHERE id = $1`
+
+	request := &models.MoneyRequest{}
+	err := scanMoneyRequest(r.db.QueryRow(ctx, query, id), request)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMoneyRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get money request: %w", err)
+	}
+
+	return request, nil
+}
+
+// List retrieves the money requests a user sent (outgoing) or was asked to pay (incoming),
+// optionally filtered by status. A userID of 0 lists every request.
+func (r *MoneyRequestRepository) List(ctx context.Context, userID int64, direction, status string, limit, offset int) (*models.MoneyRequestListResponse, error) {
+	userColumn := "payer_user_id"
+	if direction == models.MoneyRequestDirectionOutgoing {
+		userColumn = "requester_user_id"
+	}
+	where := `($1 = 0 OR ` + userColumn + ` = $1) AND ($2 = '' OR status = $2)`
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM money_requests WHERE ` + where
+	if err := r.db.QueryRow(ctx, countQuery, userID, status).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count money requests: %w", err)
+	}
+
+	query := `
+		SELECT ` + moneyRequestColumns + `
+		FROM money_requests
+		WHERE ` + where + `
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list money requests: %w", err)
+	}
+
+	requests, err := collectMoneyRequests(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MoneyRequestListResponse{
+		Requests: requests,
+		Total:    total,
+	}, nil
+}
+
+// Respond moves a pending, unexpired request to accepted, declined or cancelled. Two responses
+// racing for the same request are serialized by the conditional update: the loser gets
+// ErrMoneyRequestClosed.
+func (r *MoneyRequestRepository) Respond(ctx context.Context, id int64, status string, reason *string) (*models.MoneyRequest, error) {
+	query := `
+		UPDATE money_requests
+		SET status = $2, decline_reason = $3, responded_at = NOW()
+		WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+		RETURNING ` + moneyRequestColumns
+
+	request := &models.MoneyRequest{}
+	err := scanMoneyRequest(r.db.QueryRow(ctx, query, id, status, reason), request)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.closedOrMissing(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to update money request: %w", err)
+	}
+
+	return request, nil
+}
+
+// Reopen returns an accepted request to pending when its transfer could not be created
+func (r *MoneyRequestRepository) Reopen(ctx context.Context, id int64) error {
+	query := `
+		UPDATE money_requests
+		SET status = 'pending', responded_at = NULL
+		WHERE id = $1 AND status = 'accepted' AND transfer_id IS NULL
+	`
+
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to reopen money request: %w", err)
+	}
+
+	return nil
+}
+
+// syncMoneyRequest applies a transfer's final status to the accepted money request it pays, if
+// any, within the transfer's status transaction: a completed transfer pays the request, a failed
+// or rejected one returns it to pending so the payer can try again, or expires it if it is due.
+func syncMoneyRequest(ctx context.Context, tx pgx.Tx, transferID int64, status string) error {
+	switch linkedOutcome(status) {
+	case linkedPaid:
+		_, err := tx.Exec(ctx, `
+			UPDATE money_requests
+			SET status = 'paid'
+			WHERE transfer_id = $1 AND status = 'accepted'
+		`, transferID)
+		if err != nil {
+			return fmt.Errorf("failed to mark money request paid: %w", err)
+		}
+
+	case linkedReleased:
+		_, err := tx.Exec(ctx, `
+			UPDATE money_requests
+			SET status = CASE WHEN expires_at > NOW() THEN 'pending' ELSE 'expired' END,
+			    transfer_id = NULL, responded_at = NULL
+			WHERE transfer_id = $1 AND status = 'accepted'
+		`, transferID)
+		if err != nil {
+			return fmt.Errorf("failed to reopen money request: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ExpireDue marks every pending request past its expiry as expired and returns them
+func (r *MoneyRequestRepository) ExpireDue(ctx context.Context, now time.Time) ([]models.MoneyRequest, error) {
+	query := `
+		UPDATE money_requests
+		SET status = 'expired'
+		WHERE status = 'pending' AND expires_at <= $1
+		RETURNING ` + moneyRequestColumns
+
+	rows, err := r.db.Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire money requests: %w", err)
+	}
+
+	return collectMoneyRequests(rows)
+}
+
+// closedOrMissing distinguishes a request that does not exist from one that was already settled
+func (r *MoneyRequestRepository) closedOrMissing(ctx context.Context, id int64) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrMoneyRequestClosed
+}
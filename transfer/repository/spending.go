@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"transfer/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// spendingCTE selects the sender's outgoing transfers completed in [$2, $3), net of any amount
+// reversed back to them. Reversals themselves are money coming back, not spending.
+const spendingCTE = `
+	WITH spent AS (
+		SELECT t.to_account_id, t.category, t.currency,
+		       COALESCE(t.completed_at, t.created_at) AS spent_at,
+		       t.amount - COALESCE(t.reversed_amount, 0) AS net
+		FROM transfers t
+		WHERE t.from_account_id = ANY($1)
+		  AND NOT (t.to_account_id = ANY($1))
+		  AND t.reversal_of IS NULL
+		  AND t.status IN ('completed', 'reversed')
+		  AND COALESCE(t.completed_at, t.created_at) >= $2
+		  AND COALESCE(t.completed_at, t.created_at) < $3
+	)
+`
+
+type SpendingRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSpendingRepository(db *pgxpool.Pool) *SpendingRepository {
+	return &SpendingRepository{db: db}
+}
+
+// Summarize totals a user's transfers out of their accounts to anyone else between from and to,
+// per month and category, and per recipient for the top recipients
+func (r *SpendingRepository) Summarize(ctx context.Context, userID int64, accountIDs []int64, from, to time.Time, recipients int) (*models.TransferSpending, error) {
+	spending := &models.TransferSpending{
+		Months:     []models.SpendingRow{},
+		Recipients: []models.RecipientSpending{},
+	}
+
+	monthQuery := spendingCTE + `
+		SELECT to_char(date_trunc('month', spent_at AT TIME ZONE 'UTC'), 'YYYY-MM'),
+		       category, currency, COUNT(*), SUM(net)
+		FROM spent
+		WHERE net > 0
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3
+	`
+
+	rows, err := r.db.Query(ctx, monthQuery, accountIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize transfer spending: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.SpendingRow
+		if err := rows.Scan(&row.Month, &row.Category, &row.Currency, &row.Count, &row.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer spending: %w", err)
+		}
+		spending.Months = append(spending.Months, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transfer spending: %w", err)
+	}
+
+	recipientQuery := spendingCTE + `
+		SELECT s.to_account_id, COALESCE(b.nickname, ''), s.currency, COUNT(*), SUM(s.net)
+		FROM spent s
+		LEFT JOIN LATERAL (
+			SELECT nickname FROM beneficiaries
+			WHERE user_id = $4 AND account_id = s.to_account_id
+			ORDER BY id
+			LIMIT 1
+		) b ON TRUE
+		WHERE s.net > 0
+		GROUP BY s.to_account_id, b.nickname, s.currency
+		ORDER BY SUM(s.net) DESC, s.to_account_id
+		LIMIT $5
+	`
+
+	rows, err = r.db.Query(ctx, recipientQuery, accountIDs, from, to, userID, recipients)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize transfer recipients: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recipient models.RecipientSpending
+		if err := rows.Scan(&recipient.AccountID, &recipient.Name, &recipient.Currency, &recipient.Count, &recipient.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer recipient: %w", err)
+		}
+		spending.Recipients = append(spending.Recipients, recipient)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transfer recipients: %w", err)
+	}
+
+	return spending, nil
+}
@@ -0,0 +1,443 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"transfer/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrSplitNotFound    = errors.New("bill split not found")
+	ErrSplitClosed      = errors.New("bill split is not open")
+	ErrShareNotFound    = errors.New("no share in this bill split for the user")
+	ErrShareNotPayable  = errors.New("share is already paid or being paid")
+	ErrInvalidShares    = errors.New("invalid split shares")
+	ErrDuplicateSharing = errors.New("a customer can only have one share in a bill split")
+)
+
+// splitColumns is the column list selected for every bill split query
+const splitColumns = `id, reference_id, owner_user_id, account_id, total_amount, currency, split_method,
+		       description, status, created_at, updated_at, settled_at`
+
+// shareColumns is the column list selected for every split share query
+const shareColumns = `id, split_id, user_id, account_id, amount, status, transfer_id, paid_at,
+		       reminders_sent, last_reminded_at, created_at, updated_at`
+
+func scanSplit(row rowScanner, s *models.BillSplit) error {
+	return row.Scan(
+		&s.ID, &s.ReferenceID, &s.OwnerUserID, &s.AccountID, &s.TotalAmount, &s.Currency, &s.SplitMethod,
+		&s.Description, &s.Status, &s.CreatedAt, &s.UpdatedAt, &s.SettledAt,
+	)
+}
+
+func scanShare(row rowScanner, s *models.SplitShare) error {
+	return row.Scan(
+		&s.ID, &s.SplitID, &s.UserID, &s.AccountID, &s.Amount, &s.Status, &s.TransferID, &s.PaidAt,
+		&s.RemindersSent, &s.LastRemindedAt, &s.CreatedAt, &s.UpdatedAt,
+	)
+}
+
+func collectShares(rows pgx.Rows) ([]models.SplitShare, error) {
+	defer rows.Close()
+
+	shares := []models.SplitShare{}
+	for rows.Next() {
+		var share models.SplitShare
+		if err := scanShare(rows, &share); err != nil {
+			return nil, fmt.Errorf("failed to scan split share: %w", err)
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating split shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+// AllocateShares works out what each participant owes. Even splits divide the total to the cent
+// and hand any remaining cents to the first participants; custom shares must add up to the total.
+func AllocateShares(total decimal.Decimal, method string, participants []models.SplitParticipant) ([]decimal.Decimal, error) {
+	if !total.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("%w: at least one participant is required", ErrInvalidShares)
+	}
+	if len(participants) > models.MaxSplitParticipants {
+		return nil, fmt.Errorf("%w: at most %d participants are allowed", ErrInvalidShares, models.MaxSplitParticipants)
+	}
+
+	amounts := make([]decimal.Decimal, len(participants))
+
+	switch method {
+	case models.SplitMethodEven, "":
+		if total.Exponent() < -2 {
+			return nil, fmt.Errorf("%w: total has more than two decimal places", ErrInvalidShares)
+		}
+		cents := total.Shift(2).IntPart()
+		n := int64(len(participants))
+		if cents < n {
+			return nil, fmt.Errorf("%w: total is too small to split %d ways", ErrInvalidShares, n)
+		}
+		for i := range amounts {
+			share := cents / n
+			if int64(i) < cents%n {
+				share++
+			}
+			amounts[i] = decimal.New(share, -2)
+		}
+
+	case models.SplitMethodCustom:
+		sum := decimal.Zero
+		for i, p := range participants {
+			if p.Amount == nil || !p.Amount.IsPositive() {
+				return nil, fmt.Errorf("%w: participant %d needs a positive amount", ErrInvalidShares, i+1)
+			}
+			if p.Amount.Exponent() < -2 {
+				return nil, fmt.Errorf("%w: participant %d amount has more than two decimal places", ErrInvalidShares, i+1)
+			}
+			amounts[i] = *p.Amount
+			sum = sum.Add(*p.Amount)
+		}
+		if !sum.Equal(total) {
+			return nil, fmt.Errorf("%w: shares add up to %s, not %s", ErrInvalidShares, sum.StringFixed(2), total.StringFixed(2))
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unknown split method %q", ErrInvalidShares, method)
+	}
+
+	return amounts, nil
+}
+
+type SplitRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSplitRepository(db *pgxpool.Pool) *SplitRepository {
+	return &SplitRepository{db: db}
+}
+
+// Create stores a bill split and its shares atomically
+func (r *SplitRepository) Create(ctx context.Context, split *models.BillSplit, shares []models.SplitShare) (*models.BillSplit, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO bill_splits (owner_user_id, account_id, total_amount, currency, split_method, description, status)
+		VALUES ($1, $2, $3, $4, $5, $6, 'open')
+		RETURNING ` + splitColumns
+
+	created := &models.BillSplit{}
+	err = scanSplit(tx.QueryRow(
+		ctx, query,
+		split.OwnerUserID, split.AccountID, split.TotalAmount, split.Currency, split.SplitMethod, split.Description,
+	), created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bill split: %w", err)
+	}
+
+	created.Shares = make([]models.SplitShare, 0, len(shares))
+	for _, share := range shares {
+		var stored models.SplitShare
+		err := scanShare(tx.QueryRow(ctx, `
+			INSERT INTO bill_split_shares (split_id, user_id, account_id, amount, status)
+			VALUES ($1, $2, $3, $4, 'unpaid')
+			RETURNING `+shareColumns,
+			created.ID, share.UserID, share.AccountID, share.Amount,
+		), &stored)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return nil, ErrDuplicateSharing
+			}
+			return nil, fmt.Errorf("failed to create split share: %w", err)
+		}
+		created.Shares = append(created.Shares, stored)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit bill split: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a bill split together with its shares
+func (r *SplitRepository) GetByID(ctx context.Context, id int64) (*models.BillSplit, error) {
+	query := `SELECT ` + splitColumns + ` FROM bill_splits WHERE id = $1`
+
+	split := &models.BillSplit{}
+	err := scanSplit(r.db.QueryRow(ctx, query, id), split)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSplitNotFound
+		}
+		return nil, fmt.Errorf("failed to get bill split: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT `+shareColumns+` FROM bill_split_shares WHERE split_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get split shares: %w", err)
+	}
+	split.Shares, err = collectShares(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return split, nil
+}
+
+// List retrieves the bill splits a user owns or has a share in. A userID of 0 lists every split.
+func (r *SplitRepository) List(ctx context.Context, userID int64, limit, offset int) (*models.SplitListResponse, error) {
+	where := `$1 = 0 OR owner_user_id = $1 OR id IN (SELECT split_id FROM bill_split_shares WHERE user_id = $1)`
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM bill_splits WHERE `+where, userID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count bill splits: %w", err)
+	}
+
+	query := `
+		SELECT ` + splitColumns + `
+		FROM bill_splits
+		WHERE ` + where + `
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bill splits: %w", err)
+	}
+	defer rows.Close()
+
+	splits := []models.BillSplit{}
+	for rows.Next() {
+		var split models.BillSplit
+		if err := scanSplit(rows, &split); err != nil {
+			return nil, fmt.Errorf("failed to scan bill split: %w", err)
+		}
+		splits = append(splits, split)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bill splits: %w", err)
+	}
+
+	return &models.SplitListResponse{
+		Splits: splits,
+		Total:  total,
+	}, nil
+}
+
+// ClaimShare marks the user's unpaid share of an open split as pending payment, so that it can
+// only be paid once
+func (r *SplitRepository) ClaimShare(ctx context.Context, splitID, userID int64) (*models.SplitShare, error) {
+	query := `
+		UPDATE bill_split_shares
+		SET status = 'pending'
+		WHERE split_id = $1 AND user_id = $2 AND status = 'unpaid'
+		  AND EXISTS (SELECT 1 FROM bill_splits WHERE id = $1 AND status = 'open')
+		RETURNING ` + shareColumns
+
+	share := &models.SplitShare{}
+	err := scanShare(r.db.QueryRow(ctx, query, splitID, userID), share)
+	if err == nil {
+		return share, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to claim split share: %w", err)
+	}
+
+	// Nothing was claimed: work out why
+	split, getErr := r.GetByID(ctx, splitID)
+	if getErr != nil {
+		return nil, getErr
+	}
+	if split.Status != models.SplitStatusOpen {
+		return nil, ErrSplitClosed
+	}
+	for _, s := range split.Shares {
+		if s.UserID == userID {
+			return nil, ErrShareNotPayable
+		}
+	}
+	return nil, ErrShareNotFound
+}
+
+// AttachShareTransfer records the transfer paying a claimed share
+func (r *SplitRepository) AttachShareTransfer(ctx context.Context, shareID, transferID int64) (*models.SplitShare, error) {
+	query := `
+		UPDATE bill_split_shares
+		SET transfer_id = $2
+		WHERE id = $1 AND status = 'pending' AND transfer_id IS NULL
+		RETURNING ` + shareColumns
+
+	share := &models.SplitShare{}
+	err := scanShare(r.db.QueryRow(ctx, query, shareID, transferID), share)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrShareNotPayable
+		}
+		return nil, fmt.Errorf("failed to attach transfer to split share: %w", err)
+	}
+
+	return share, nil
+}
+
+// ReleaseShare returns a claimed share to unpaid when its transfer could not be created
+func (r *SplitRepository) ReleaseShare(ctx context.Context, shareID int64) error {
+	query := `
+		UPDATE bill_split_shares
+		SET status = 'unpaid'
+		WHERE id = $1 AND status = 'pending' AND transfer_id IS NULL
+	`
+
+	if _, err := r.db.Exec(ctx, query, shareID); err != nil {
+		return fmt.Errorf("failed to release split share: %w", err)
+	}
+
+	return nil
+}
+
+// Cancel closes an open split; shares already paid stay paid
+func (r *SplitRepository) Cancel(ctx context.Context, id int64) (*models.BillSplit, error) {
+	query := `
+		UPDATE bill_splits
+		SET status = 'cancelled'
+		WHERE id = $1 AND status = 'open'
+		RETURNING ` + splitColumns
+
+	split := &models.BillSplit{}
+	err := scanSplit(r.db.QueryRow(ctx, query, id), split)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, getErr := r.GetByID(ctx, id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrSplitClosed
+		}
+		return nil, fmt.Errorf("failed to cancel bill split: %w", err)
+	}
+
+	return split, nil
+}
+
+// ListSharesDueReminder retrieves unpaid shares of open splits that have not been reminded about
+// since the given time and have had fewer than maxReminders reminders
+func (r *SplitRepository) ListSharesDueReminder(ctx context.Context, remindedBefore time.Time, maxReminders, limit int) ([]models.SplitShare, error) {
+	query := `
+		SELECT ` + shareColumns + `
+		FROM bill_split_shares s
+		WHERE s.status = 'unpaid'
+		  AND s.reminders_sent < $2
+		  AND COALESCE(s.last_reminded_at, s.created_at) <= $1
+		  AND EXISTS (SELECT 1 FROM bill_splits b WHERE b.id = s.split_id AND b.status = 'open')
+		ORDER BY COALESCE(s.last_reminded_at, s.created_at)
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, remindedBefore, maxReminders, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list split shares due a reminder: %w", err)
+	}
+
+	return collectShares(rows)
+}
+
+// MarkReminded records that a reminder was sent for a share
+func (r *SplitRepository) MarkReminded(ctx context.Context, shareID int64) (*models.SplitShare, error) {
+	query := `
+		UPDATE bill_split_shares
+		SET reminders_sent = reminders_sent + 1, last_reminded_at = NOW()
+		WHERE id = $1
+		RETURNING ` + shareColumns
+
+	share := &models.SplitShare{}
+	err := scanShare(r.db.QueryRow(ctx, query, shareID), share)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrShareNotFound
+		}
+		return nil, fmt.Errorf("failed to record split reminder: %w", err)
+	}
+
+	return share, nil
+}
+
+// SyncShare applies a transfer's status to its split share outside of a status update, for a
+// transfer that reached its final status before the share was linked to it
+func (r *SplitRepository) SyncShare(ctx context.Context, transferID int64, status string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := syncSplitShare(ctx, tx, transferID, status); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit split share sync: %w", err)
+	}
+
+	return nil
+}
+
+// syncSplitShare applies a transfer's final status to the split share it pays, if any, within the
+// transfer's status transaction: a completed transfer pays the share and settles the split once
+// every share is paid, a failed or rejected one returns the share to unpaid.
+func syncSplitShare(ctx context.Context, tx pgx.Tx, transferID int64, status string) error {
+	switch linkedOutcome(status) {
+	case linkedPaid:
+		var splitID int64
+		err := tx.QueryRow(ctx, `
+			UPDATE bill_split_shares
+			SET status = 'paid', paid_at = NOW()
+			WHERE transfer_id = $1 AND status = 'pending'
+			RETURNING split_id
+		`, transferID).Scan(&splitID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mark split share paid: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE bill_splits
+			SET status = 'settled', settled_at = NOW()
+			WHERE id = $1 AND status = 'open'
+			  AND NOT EXISTS (SELECT 1 FROM bill_split_shares WHERE split_id = $1 AND status <> 'paid')
+		`, splitID)
+		if err != nil {
+			return fmt.Errorf("failed to settle bill split: %w", err)
+		}
+
+	case linkedReleased:
+		_, err := tx.Exec(ctx, `
+			UPDATE bill_split_shares
+			SET status = 'unpaid', transfer_id = NULL
+			WHERE transfer_id = $1 AND status = 'pending'
+		`, transferID)
+		if err != nil {
+			return fmt.Errorf("failed to release split share: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"transfer/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAllocateShares(t *testing.T) {
+	dec := func(s string) *decimal.Decimal {
+		d := decimal.RequireFromString(s)
+		return &d
+	}
+	participants := func(amounts ...*decimal.Decimal) []models.SplitParticipant {
+		ps := make([]models.SplitParticipant, len(amounts))
+		for i, a := range amounts {
+			ps[i] = models.SplitParticipant{AccountID: int64(i + 1), Amount: a}
+		}
+		return ps
+	}
+
+	tests := []struct {
+		name         string
+		total        string
+		method       string
+		participants []models.SplitParticipant
+		want         []string
+		wantErr      error
+	}{
+		{
+			name:         "even divides exactly",
+			total:        "90",
+			method:       models.SplitMethodEven,
+			participants: participants(nil, nil, nil),
+			want:         []string{"30", "30", "30"},
+		},
+		{
+			name:         "even hands remainder cents to the first participants",
+			total:        "100",
+			method:       models.SplitMethodEven,
+			participants: participants(nil, nil, nil),
+			want:         []string{"33.34", "33.33", "33.33"},
+		},
+		{
+			name:         "method defaults to even",
+			total:        "10.01",
+			method:       "",
+			participants: participants(nil, nil),
+			want:         []string{"5.01", "5"},
+		},
+		{
+			name:         "even total too small",
+			total:        "0.02",
+			method:       models.SplitMethodEven,
+			participants: participants(nil, nil, nil),
+			wantErr:      ErrInvalidShares,
+		},
+		{
+			name:         "custom shares add up",
+			total:        "100",
+			method:       models.SplitMethodCustom,
+			participants: participants(dec("60"), dec("25.50"), dec("14.50")),
+			want:         []string{"60", "25.5", "14.5"},
+		},
+		{
+			name:         "custom shares short of total",
+			total:        "100",
+			method:       models.SplitMethodCustom,
+			participants: participants(dec("60"), dec("30")),
+			wantErr:      ErrInvalidShares,
+		},
+		{
+			name:         "custom share missing",
+			total:        "100",
+			method:       models.SplitMethodCustom,
+			participants: participants(dec("100"), nil),
+			wantErr:      ErrInvalidShares,
+		},
+		{
+			name:         "custom share with fractional cents",
+			total:        "100",
+			method:       models.SplitMethodCustom,
+			participants: participants(dec("50.005"), dec("49.995")),
+			wantErr:      ErrInvalidShares,
+		},
+		{
+			name:         "non-positive total",
+			total:        "0",
+			method:       models.SplitMethodEven,
+			participants: participants(nil),
+			wantErr:      ErrInvalidAmount,
+		},
+		{
+			name:         "no participants",
+			total:        "10",
+			method:       models.SplitMethodEven,
+			participants: nil,
+			wantErr:      ErrInvalidShares,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AllocateShares(decimal.RequireFromString(tt.total), tt.method, tt.participants)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("AllocateShares() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AllocateShares() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("AllocateShares() returned %d shares, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !got[i].Equal(decimal.RequireFromString(want)) {
+					t.Errorf("share %d = %s, want %s", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"transfer/models"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 )
@@ -19,8 +21,65 @@ var (
 	ErrInvalidAmount    = errors.New("invalid amount")
 	ErrInvalidInput     = errors.New("invalid input")
 	ErrSameAccount      = errors.New("source and destination accounts cannot be the same")
+	ErrNotPendingReview = errors.New("transfer is not pending approval")
+	ErrSelfApproval     = errors.New("approver must differ from the initiator")
+
+	ErrInvalidTransition = errors.New("illegal status transition")
+
+	ErrNotReversible      = errors.New("only completed transfers can be reversed, up to the amount not yet reversed")
+	ErrReversalInProgress = errors.New("transfer already has a reversal in progress")
 )
 
+// transferColumns is the column list selected for every transfer query
+const transferColumns = `id, reference_id, from_account_id, to_account_id, amount, currency, fee, channel, memo, category, recipient_category, status,
+		       failure_reason, initiated_by, reviewed_by, reviewed_at, batch_id, beneficiary_id,
+		       reversal_of, reversal_policy, reversal_reason, reversed_amount, fee_refunded, saga_attempts,
+		       created_at, updated_at, completed_at`
+
+// nullIfEmpty stores empty optional text as NULL
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// rowScanner is satisfied by both pgx.Row and pgx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTransfer scans a row selected with transferColumns into a transfer
+func scanTransfer(row rowScanner, transfer *models.Transfer) error {
+	return row.Scan(
+		&transfer.ID, &transfer.ReferenceID, &transfer.FromAccountID, &transfer.ToAccountID,
+		&transfer.Amount, &transfer.Currency, &transfer.Fee, &transfer.Channel, &transfer.Memo, &transfer.Category, &transfer.RecipientCategory, &transfer.Status,
+		&transfer.FailureReason, &transfer.InitiatedBy, &transfer.ReviewedBy, &transfer.ReviewedAt, &transfer.BatchID, &transfer.BeneficiaryID,
+		&transfer.ReversalOf, &transfer.ReversalPolicy, &transfer.ReversalReason, &transfer.ReversedAmount, &transfer.FeeRefunded, &transfer.SagaAttempts,
+		&transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt,
+	)
+}
+
+// collectTransfers scans all rows into a slice of transfers
+func collectTransfers(rows pgx.Rows) ([]models.Transfer, error) {
+	defer rows.Close()
+
+	transfers := []models.Transfer{}
+	for rows.Next() {
+		var transfer models.Transfer
+		if err := scanTransfer(rows, &transfer); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer: %w", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transfers: %w", err)
+	}
+
+	return transfers, nil
+}
+
 type TransferRepository struct {
 	db *pgxpool.Pool
 }
@@ -29,8 +88,12 @@ func NewTransferRepository(db *pgxpool.Pool) *TransferRepository {
 	return &TransferRepository{db: db}
 }
 
-// Create creates a new transfer record
-func (r *TransferRepository) Create(ctx context.Context, req *models.CreateTransferRequest) (*models.Transfer, error) {
+// Create creates a new transfer record initiated by the given user once it fits within their limits
+// and the cap on accounts they recently added as beneficiaries. The checks and the insert hold the
+// user's usage lock, so concurrent transfers are counted in turn. A transfer paying a money request
+// is linked to the accepted request in the same transaction; ErrMoneyRequestClosed means the
+// request was no longer accepted and nothing was recorded.
+func (r *TransferRepository) Create(ctx context.Context, userID int64, req *models.CreateTransferRequest, quote *models.FeeQuote, limits *models.EffectiveLimits) (*models.Transfer, error) {
 	if req.Amount.LessThanOrEqual(decimal.Zero) {
 		return nil, ErrInvalidAmount
 	}
@@ -44,45 +107,124 @@ func (r *TransferRepository) Create(ctx context.Context, req *models.CreateTrans
 		currency = "USD"
 	}
 
+	category := req.Category
+	if category == "" {
+		category = models.Categorize(req.Memo)
+	}
+
+	var beneficiaryID *int64
+	if req.BeneficiaryID != 0 {
+		beneficiaryID = &req.BeneficiaryID
+	}
+
 	query := `
-		INSERT INTO transfers (from_account_id, to_account_id, amount, currency, status)
-		VALUES ($1, $2, $3, $4, 'pending')
-		RETURNING id, reference_id, from_account_id, to_account_id, amount, currency, status,
-		          failure_reason, created_at, updated_at, completed_at
-	`
+		INSERT INTO transfers (from_account_id, to_account_id, amount, currency, fee, channel, memo, category, status,
+		                       initiated_by, beneficiary_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9, $10)
+		RETURNING ` + transferColumns
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockUsage(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	usage, err := readUsage(ctx, tx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckLimits(limits, usage, req.Amount); err != nil {
+		return nil, err
+	}
+	if err := checkCoolingOff(ctx, tx, userID, req.ToAccountID, req.Amount, limits.NewBeneficiaryMax, time.Now()); err != nil {
+		return nil, err
+	}
 
 	transfer := &models.Transfer{}
-	err := r.db.QueryRow(
+	err = scanTransfer(tx.QueryRow(
 		ctx, query,
-		req.FromAccountID, req.ToAccountID, req.Amount, currency,
-	).Scan(
-		&transfer.ID, &transfer.ReferenceID, &transfer.FromAccountID, &transfer.ToAccountID,
-		&transfer.Amount, &transfer.Currency, &transfer.Status,
-		&transfer.FailureReason, &transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt,
-	)
-
+		req.FromAccountID, req.ToAccountID, req.Amount, currency, quote.Fee, quote.Channel, nullIfEmpty(req.Memo), category,
+		userID, beneficiaryID,
+	), transfer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transfer: %w", err)
 	}
 
+	if req.MoneyRequestID != 0 {
+		tag, err := tx.Exec(ctx, `
+			UPDATE money_requests SET transfer_id = $2
+			WHERE id = $1 AND status = 'accepted' AND transfer_id IS NULL
+		`, req.MoneyRequestID, transfer.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link money request: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return nil, ErrMoneyRequestClosed
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// CreateForBatchItem creates the child transfer for a batch line and links it to the line atomically
+func (r *TransferRepository) CreateForBatchItem(ctx context.Context, batch *models.TransferBatch, item *models.TransferBatchItem, quote *models.FeeQuote) (*models.Transfer, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The line's reference becomes the child transfer's memo
+	memo := ""
+	if item.Reference != nil {
+		memo = *item.Reference
+	}
+
+	query := `
+		INSERT INTO transfers (from_account_id, to_account_id, amount, currency, fee, channel, memo, category,
+		                       status, initiated_by, batch_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9, $10)
+		RETURNING ` + transferColumns
+
+	transfer := &models.Transfer{}
+	err = scanTransfer(tx.QueryRow(
+		ctx, query,
+		batch.FromAccountID, item.ToAccountID, item.Amount, batch.Currency, quote.Fee, quote.Channel,
+		nullIfEmpty(memo), models.Categorize(memo), batch.InitiatedBy, batch.ID,
+	), transfer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch transfer: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE transfer_batch_items SET transfer_id = $1 WHERE id = $2 AND transfer_id IS NULL`, transfer.ID, item.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link batch item: %w", err)
+	}
+	// Another run linked the line first; rolling back discards this duplicate child
+	if tag.RowsAffected() == 0 {
+		return nil, ErrBatchItemSubmitted
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit batch transfer: %w", err)
+	}
+
 	return transfer, nil
 }
 
 // GetByID retrieves a transfer by ID
 func (r *TransferRepository) GetByID(ctx context.Context, id int64) (*models.Transfer, error) {
-	query := `
-		SELECT id, reference_id, from_account_id, to_account_id, amount, currency, status,
-		       failure_reason, created_at, updated_at, completed_at
-		FROM transfers
-		WHERE id = $1
-	`
+	query := `SELECT ` + transferColumns + ` FROM transfers WHERE id = $1`
 
 	transfer := &models.Transfer{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&transfer.ID, &transfer.ReferenceID, &transfer.FromAccountID, &transfer.ToAccountID,
-		&transfer.Amount, &transfer.Currency, &transfer.Status,
-		&transfer.FailureReason, &transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt,
-	)
+	err := scanTransfer(r.db.QueryRow(ctx, query, id), transfer)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -96,19 +238,10 @@ func (r *TransferRepository) GetByID(ctx context.Context, id int64) (*models.Tra
 
 // GetByReferenceID retrieves a transfer by reference ID
 func (r *TransferRepository) GetByReferenceID(ctx context.Context, referenceID uuid.UUID) (*models.Transfer, error) {
-	query := `
-		SELECT id, reference_id, from_account_id, to_account_id, amount, currency, status,
-		       failure_reason, created_at, updated_at, completed_at
-		FROM transfers
-		WHERE reference_id = $1
-	`
+	query := `SELECT ` + transferColumns + ` FROM transfers WHERE reference_id = $1`
 
 	transfer := &models.Transfer{}
-	err := r.db.QueryRow(ctx, query, referenceID).Scan(
-		&transfer.ID, &transfer.ReferenceID, &transfer.FromAccountID, &transfer.ToAccountID,
-		&transfer.Amount, &transfer.Currency, &transfer.Status,
-		&transfer.FailureReason, &transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt,
-	)
+	err := scanTransfer(r.db.QueryRow(ctx, query, referenceID), transfer)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -129,8 +262,7 @@ func (r *TransferRepository) ListByAccountID(ctx context.Context, accountID int6
 	}
 
 	query := `
-		SELECT id, reference_id, from_account_id, to_account_id, amount, currency, status,
-		       failure_reason, created_at, updated_at, completed_at
+		SELECT ` + transferColumns + `
 		FROM transfers
 		WHERE from_account_id = $1 OR to_account_id = $1
 		ORDER BY created_at DESC
@@ -141,24 +273,10 @@ func (r *TransferRepository) ListByAccountID(ctx context.Context, accountID int6
 	if err != nil {
 		return nil, fmt.Errorf("failed to list transfers: %w", err)
 	}
-	defer rows.Close()
-
-	transfers := []models.Transfer{}
-	for rows.Next() {
-		var transfer models.Transfer
-		err := rows.Scan(
-			&transfer.ID, &transfer.ReferenceID, &transfer.FromAccountID, &transfer.ToAccountID,
-			&transfer.Amount, &transfer.Currency, &transfer.Status,
-			&transfer.FailureReason, &transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transfer: %w", err)
-		}
-		transfers = append(transfers, transfer)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transfers: %w", err)
+	transfers, err := collectTransfers(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.TransferListResponse{
@@ -192,37 +310,22 @@ func (r *TransferRepository) ListByAccountIDs(ctx context.Context, accountIDs []
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, reference_id, from_account_id, to_account_id, amount, currency, status,
-		       failure_reason, created_at, updated_at, completed_at
+		SELECT %s
 		FROM transfers
 		WHERE from_account_id IN (%s) OR to_account_id IN (%s)
 		ORDER BY created_at DESC
 		LIMIT $%d OFFSET $%d
-	`, placeholders, placeholders, len(accountIDs)+1, len(accountIDs)+2)
+	`, transferColumns, placeholders, placeholders, len(accountIDs)+1, len(accountIDs)+2)
 
 	queryArgs := append(args, limit, offset)
 	rows, err := r.db.Query(ctx, query, queryArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list transfers: %w", err)
 	}
-	defer rows.Close()
 
-	transfers := []models.Transfer{}
-	for rows.Next() {
-		var transfer models.Transfer
-		err := rows.Scan(
-			&transfer.ID, &transfer.ReferenceID, &transfer.FromAccountID, &transfer.ToAccountID,
-			&transfer.Amount, &transfer.Currency, &transfer.Status,
-			&transfer.FailureReason, &transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transfer: %w", err)
-		}
-		transfers = append(transfers, transfer)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transfers: %w", err)
+	transfers, err := collectTransfers(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.TransferListResponse{
@@ -240,8 +343,7 @@ func (r *TransferRepository) ListAll(ctx context.Context, limit, offset int) (*m
 	}
 
 	query := `
-		SELECT id, reference_id, from_account_id, to_account_id, amount, currency, status,
-		       failure_reason, created_at, updated_at, completed_at
+		SELECT ` + transferColumns + `
 		FROM transfers
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -251,24 +353,150 @@ func (r *TransferRepository) ListAll(ctx context.Context, limit, offset int) (*m
 	if err != nil {
 		return nil, fmt.Errorf("failed to list transfers: %w", err)
 	}
-	defer rows.Close()
 
-	transfers := []models.Transfer{}
-	for rows.Next() {
-		var transfer models.Transfer
-		err := rows.Scan(
-			&transfer.ID, &transfer.ReferenceID, &transfer.FromAccountID, &transfer.ToAccountID,
-			&transfer.Amount, &transfer.Currency, &transfer.Status,
-			&transfer.FailureReason, &transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transfer: %w", err)
+	transfers, err := collectTransfers(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TransferListResponse{
+		Transfers: transfers,
+		Total:     total,
+	}, nil
+}
+
+// Search retrieves transfers matching the search, newest first. A nil accountIDs searches all
+// transfers; otherwise only transfers touching one of the accounts are returned.
+func (r *TransferRepository) Search(ctx context.Context, accountIDs []int64, search *models.TransferSearch, limit, offset int) (*models.TransferListResponse, error) {
+	if accountIDs != nil && len(accountIDs) == 0 {
+		return &models.TransferListResponse{Transfers: []models.Transfer{}, Total: 0}, nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if accountIDs != nil {
+		p := arg(accountIDs)
+		conditions = append(conditions, fmt.Sprintf("(from_account_id = ANY(%s) OR to_account_id = ANY(%s))", p, p))
+	}
+	if search.Query != "" {
+		conditions = append(conditions, "memo_search @@ websearch_to_tsquery('simple', "+arg(search.Query)+")")
+	}
+	if search.Counterparty != 0 {
+		p := arg(search.Counterparty)
+		conditions = append(conditions, fmt.Sprintf("(from_account_id = %s OR to_account_id = %s)", p, p))
+	}
+	if search.Category != "" {
+		if accountIDs != nil {
+			// Each side searches by its own category
+			p := arg(accountIDs)
+			conditions = append(conditions, fmt.Sprintf(
+				"CASE WHEN from_account_id = ANY(%s) THEN category ELSE COALESCE(recipient_category, category) END = %s",
+				p, arg(search.Category)))
+		} else {
+			conditions = append(conditions, "category = "+arg(search.Category))
 		}
-		transfers = append(transfers, transfer)
+	}
+	if search.MinAmount != nil {
+		conditions = append(conditions, "amount >= "+arg(*search.MinAmount))
+	}
+	if search.MaxAmount != nil {
+		conditions = append(conditions, "amount <= "+arg(*search.MaxAmount))
+	}
+	if search.From != nil {
+		conditions = append(conditions, "created_at >= "+arg(*search.From))
+	}
+	if search.To != nil {
+		conditions = append(conditions, "created_at < "+arg(*search.To))
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transfers: %w", err)
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM transfers `+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count transfers: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM transfers
+		%s
+		ORDER BY created_at DESC
+		LIMIT %s OFFSET %s
+	`, transferColumns, where, arg(limit), arg(offset))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transfers: %w", err)
+	}
+
+	transfers, err := collectTransfers(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TransferListResponse{
+		Transfers: transfers,
+		Total:     total,
+	}, nil
+}
+
+// SetCategory reassigns the sender's or the recipient's category of a transfer. updated_at is left
+// alone: the saga sweeper judges staleness by it.
+func (r *TransferRepository) SetCategory(ctx context.Context, id int64, side, category string) (*models.Transfer, error) {
+	column := "category"
+	if side == models.CategorySideRecipient {
+		column = "recipient_category"
+	}
+
+	query := `
+		UPDATE transfers
+		SET ` + column + ` = $2
+		WHERE id = $1
+		RETURNING ` + transferColumns
+
+	transfer := &models.Transfer{}
+	if err := scanTransfer(r.db.QueryRow(ctx, query, id, category), transfer); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to set transfer category: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// ListByStatus retrieves all transfers with the given status, oldest first
+func (r *TransferRepository) ListByStatus(ctx context.Context, status string, limit, offset int) (*models.TransferListResponse, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM transfers WHERE status = $1`
+	if err := r.db.QueryRow(ctx, countQuery, status).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count transfers: %w", err)
+	}
+
+	query := `
+		SELECT ` + transferColumns + `
+		FROM transfers
+		WHERE status = $1
+		ORDER BY created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+
+	transfers, err := collectTransfers(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.TransferListResponse{
@@ -277,59 +505,383 @@ func (r *TransferRepository) ListAll(ctx context.Context, limit, offset int) (*m
 	}, nil
 }
 
-// UpdateStatus updates the transfer status
-func (r *TransferRepository) UpdateStatus(ctx context.Context, id int64, status string, failureReason *string) (*models.Transfer, error) {
+// ListStale retrieves transfers that have been in the given status since before the cutoff, oldest first
+func (r *TransferRepository) ListStale(ctx context.Context, status string, before time.Time, limit int) ([]models.Transfer, error) {
+	query := `
+		SELECT ` + transferColumns + `
+		FROM transfers
+		WHERE status = $1 AND updated_at < $2
+		ORDER BY updated_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, status, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale transfers: %w", err)
+	}
+
+	return collectTransfers(rows)
+}
+
+// RecordSagaRetry counts a re-publish of a processing transfer and restarts its timeout
+func (r *TransferRepository) RecordSagaRetry(ctx context.Context, id int64) (*models.Transfer, error) {
+	query := `
+		UPDATE transfers
+		SET saga_attempts = saga_attempts + 1, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing'
+		RETURNING ` + transferColumns
+
+	transfer := &models.Transfer{}
+	if err := scanTransfer(r.db.QueryRow(ctx, query, id), transfer); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to record saga retry: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// What a transfer's status means for the money request or split share it pays
+const (
+	linkedPaid     = "paid"     // the transfer completed, so the request or share is paid
+	linkedReleased = "released" // the transfer failed or was rejected, so it can be paid again
+)
+
+// linkedOutcome is what a transfer moving to status means for the money request or split share
+// it pays, or "" when the request or share stays as it is
+func linkedOutcome(status string) string {
+	switch status {
+	case models.TransferStatusCompleted:
+		return linkedPaid
+	case models.TransferStatusFailed, models.TransferStatusRejected:
+		return linkedReleased
+	default:
+		return ""
+	}
+}
+
+// reviewTransition is the status a reviewed transfer moves to and the cause recorded for it
+func reviewTransition(approve bool) (status, cause string) {
+	if approve {
+		return models.TransferStatusProcessing, models.TransitionCauseApproved
+	}
+	return models.TransferStatusRejected, models.TransitionCauseRejected
+}
+
+// checkReview applies the four-eyes rule to a transfer in status, initiated by initiatedBy: only a
+// transfer still pending approval can be reviewed, once, and never by whoever initiated it.
+// Transfers with no recorded initiator can be reviewed by any admin.
+func checkReview(status string, initiatedBy *int64, reviewerID int64) error {
+	if status != models.TransferStatusPendingApproval {
+		return ErrNotPendingReview
+	}
+	if initiatedBy != nil && *initiatedBy == reviewerID {
+		return ErrSelfApproval
+	}
+	return nil
+}
+
+// Review approves or rejects a transfer that is pending approval, as checkReview allows.
+// Approved transfers move to processing; rejected transfers are closed with the given reason.
+func (r *TransferRepository) Review(ctx context.Context, id, reviewerID int64, approve bool, reason *string) (*models.Transfer, error) {
+	status, cause := reviewTransition(approve)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the transfer so a concurrent review sees this one's outcome
+	var current string
+	var initiatedBy *int64
+	err = tx.QueryRow(ctx, `SELECT status, initiated_by FROM transfers WHERE id = $1 FOR UPDATE`, id).Scan(&current, &initiatedBy)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to lock transfer: %w", err)
+	}
+	if err := checkReview(current, initiatedBy, reviewerID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE transfers
+		SET status = $1,
+		    failure_reason = $2,
+		    reviewed_by = $3,
+		    reviewed_at = NOW(),
+		    completed_at = CASE WHEN $1 = 'rejected' THEN NOW() ELSE completed_at END,
+		    updated_at = NOW()
+		WHERE id = $4
+		  AND status = 'pending_approval'
+		  AND initiated_by IS DISTINCT FROM $3
+		RETURNING ` + transferColumns
+
+	transfer := &models.Transfer{}
+	if err := scanTransfer(tx.QueryRow(ctx, query, status, reason, reviewerID, id), transfer); err != nil {
+		return nil, fmt.Errorf("failed to review transfer: %w", err)
+	}
+
+	if err := recordTransition(ctx, tx, id, models.TransferStatusPendingApproval, status, cause, reason); err != nil {
+		return nil, err
+	}
+	if err := syncSplitShare(ctx, tx, id, status); err != nil {
+		return nil, err
+	}
+	if err := syncMoneyRequest(ctx, tx, id, status); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit review: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// UpdateStatus moves a transfer to a new status if the state machine allows it from the current one,
+// recording the transition and its cause. Illegal transitions return ErrInvalidTransition.
+func (r *TransferRepository) UpdateStatus(ctx context.Context, id int64, status string, failureReason *string, cause string) (*models.Transfer, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	from, err := lockStatus(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	var query string
 	var args []interface{}
 
-	if status == models.TransferStatusCompleted || status == models.TransferStatusFailed {
+	if status == models.TransferStatusCompleted || status == models.TransferStatusFailed || status == models.TransferStatusRejected {
 		query = `
 			UPDATE transfers
 			SET status = $1, failure_reason = $2, completed_at = $3, updated_at = NOW()
-			WHERE id = $4
-			RETURNING id, reference_id, from_account_id, to_account_id, amount, currency, status,
-			          failure_reason, created_at, updated_at, completed_at
-		`
-		args = []interface{}{status, failureReason, time.Now(), id}
+			WHERE id = $4 AND status = ANY($5)
+			RETURNING ` + transferColumns
+		args = []interface{}{status, failureReason, time.Now(), id, models.AllowedFromStatuses(status)}
 	} else {
 		query = `
 			UPDATE transfers
 			SET status = $1, updated_at = NOW()
-			WHERE id = $2
-			RETURNING id, reference_id, from_account_id, to_account_id, amount, currency, status,
-			          failure_reason, created_at, updated_at, completed_at
-		`
-		args = []interface{}{status, id}
+			WHERE id = $2 AND status = ANY($3)
+			RETURNING ` + transferColumns
+		args = []interface{}{status, id, models.AllowedFromStatuses(status)}
 	}
 
 	transfer := &models.Transfer{}
-	err := r.db.QueryRow(ctx, query, args...).Scan(
-		&transfer.ID, &transfer.ReferenceID, &transfer.FromAccountID, &transfer.ToAccountID,
-		&transfer.Amount, &transfer.Currency, &transfer.Status,
-		&transfer.FailureReason, &transfer.CreatedAt, &transfer.UpdatedAt, &transfer.CompletedAt,
-	)
+	err = scanTransfer(tx.QueryRow(ctx, query, args...), transfer)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrTransferNotFound
+			return nil, fmt.Errorf("%w: transfer %d %s -> %s", ErrInvalidTransition, id, from, status)
 		}
 		return nil, fmt.Errorf("failed to update transfer status: %w", err)
 	}
 
+	if err := recordTransition(ctx, tx, id, from, status, cause, failureReason); err != nil {
+		return nil, err
+	}
+
+	if err := syncSplitShare(ctx, tx, id, status); err != nil {
+		return nil, err
+	}
+
+	if err := syncMoneyRequest(ctx, tx, id, status); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit status update: %w", err)
+	}
+
 	return transfer, nil
 }
 
 // MarkAsProcessing marks a transfer as processing
 func (r *TransferRepository) MarkAsProcessing(ctx context.Context, id int64) (*models.Transfer, error) {
-	return r.UpdateStatus(ctx, id, models.TransferStatusProcessing, nil)
+	return r.UpdateStatus(ctx, id, models.TransferStatusProcessing, nil, models.TransitionCauseDispatched)
+}
+
+// MarkAsPendingApproval holds a transfer for four-eyes review
+func (r *TransferRepository) MarkAsPendingApproval(ctx context.Context, id int64) (*models.Transfer, error) {
+	return r.UpdateStatus(ctx, id, models.TransferStatusPendingApproval, nil, models.TransitionCauseHeld)
 }
 
 // MarkAsCompleted marks a transfer as completed
-func (r *TransferRepository) MarkAsCompleted(ctx context.Context, id int64) (*models.Transfer, error) {
-	return r.UpdateStatus(ctx, id, models.TransferStatusCompleted, nil)
+func (r *TransferRepository) MarkAsCompleted(ctx context.Context, id int64, cause string) (*models.Transfer, error) {
+	return r.UpdateStatus(ctx, id, models.TransferStatusCompleted, nil, cause)
 }
 
 // MarkAsFailed marks a transfer as failed
-func (r *TransferRepository) MarkAsFailed(ctx context.Context, id int64, reason string) (*models.Transfer, error) {
-	return r.UpdateStatus(ctx, id, models.TransferStatusFailed, &reason)
+func (r *TransferRepository) MarkAsFailed(ctx context.Context, id int64, reason, cause string) (*models.Transfer, error) {
+	return r.UpdateStatus(ctx, id, models.TransferStatusFailed, &reason, cause)
+}
+
+// lockStatus returns a transfer's current status, locking its row until tx ends
+func lockStatus(ctx context.Context, tx pgx.Tx, id int64) (string, error) {
+	var status string
+	err := tx.QueryRow(ctx, `SELECT status FROM transfers WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrTransferNotFound
+		}
+		return "", fmt.Errorf("failed to lock transfer: %w", err)
+	}
+	return status, nil
+}
+
+// recordTransition appends a status transition to the transfer's history within tx
+func recordTransition(ctx context.Context, tx pgx.Tx, id int64, from, to, cause string, detail *string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO transfer_status_history (transfer_id, from_status, to_status, cause, detail)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, from, to, cause, detail)
+	if err != nil {
+		return fmt.Errorf("failed to record status transition: %w", err)
+	}
+	return nil
+}
+
+// ListStatusHistory returns a transfer's status transitions, oldest first
+func (r *TransferRepository) ListStatusHistory(ctx context.Context, id int64) ([]models.StatusTransition, error) {
+	query := `
+		SELECT id, transfer_id, from_status, to_status, cause, detail, created_at
+		FROM transfer_status_history
+		WHERE transfer_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.StatusTransition{}
+	for rows.Next() {
+		var t models.StatusTransition
+		if err := rows.Scan(&t.ID, &t.TransferID, &t.FromStatus, &t.ToStatus, &t.Cause, &t.Detail, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status transition: %w", err)
+		}
+		history = append(history, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status history: %w", err)
+	}
+
+	return history, nil
+}
+
+// CreateReversal records a pending reversal of a completed transfer, moving funds from its recipient back to its sender.
+// The reversal carries its share of the original fee to refund: the proportional share, or whatever
+// is left of the fee when it reverses the rest of the transfer.
+func (r *TransferRepository) CreateReversal(ctx context.Context, originalID, adminID int64, amount decimal.Decimal, policy, reason string) (*models.Transfer, error) {
+	query := `
+		INSERT INTO transfers (from_account_id, to_account_id, amount, currency, status, initiated_by,
+		                       reversal_of, reversal_policy, reversal_reason, fee_refunded)
+		SELECT to_account_id, from_account_id, $2, currency, 'pending', $3, id, $4, $5,
+		       CASE WHEN COALESCE(reversed_amount, 0) + $2 >= amount THEN fee - COALESCE(fee_refunded, 0)
+		            ELSE ROUND(fee * $2 / amount, 2) END
+		FROM transfers
+		WHERE id = $1 AND status = 'completed' AND reversal_of IS NULL
+		  AND amount - COALESCE(reversed_amount, 0) >= $2
+		RETURNING ` + transferColumns
+
+	transfer := &models.Transfer{}
+	err := scanTransfer(r.db.QueryRow(ctx, query, originalID, amount, adminID, policy, reason), transfer)
+	if err == nil {
+		return transfer, nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrReversalInProgress
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to create reversal: %w", err)
+	}
+
+	// Nothing was inserted: work out why
+	if _, getErr := r.GetByID(ctx, originalID); getErr != nil {
+		return nil, getErr
+	}
+	return nil, ErrNotReversible
+}
+
+// CompleteReversal marks a reversal completed with the amount actually returned and adds it to the
+// original's reversed amount. The fee refund is scaled down the same way as the amount when the
+// reversal settled for less, as the account service does when it pays it. The original is flagged
+// reversed once nothing is left to reverse; until then it stays completed so the rest can still be reversed.
+func (r *TransferRepository) CompleteReversal(ctx context.Context, reversalID int64, settled decimal.Decimal, cause string) (*models.Transfer, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	from, err := lockStatus(ctx, tx, reversalID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE transfers
+		SET status = 'completed', reversed_amount = $2,
+		    fee_refunded = ROUND(COALESCE(fee_refunded, 0) * $2 / amount, 2),
+		    completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND reversal_of IS NOT NULL AND status = ANY($3)
+		RETURNING ` + transferColumns
+
+	reversal := &models.Transfer{}
+	err = scanTransfer(tx.QueryRow(ctx, query, reversalID, settled, models.AllowedFromStatuses(models.TransferStatusCompleted)), reversal)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: reversal %d %s -> %s", ErrInvalidTransition, reversalID, from, models.TransferStatusCompleted)
+		}
+		return nil, fmt.Errorf("failed to complete reversal: %w", err)
+	}
+	if err := recordTransition(ctx, tx, reversalID, from, models.TransferStatusCompleted, cause, nil); err != nil {
+		return nil, err
+	}
+
+	feeRefunded := decimal.Zero
+	if reversal.FeeRefunded != nil {
+		feeRefunded = *reversal.FeeRefunded
+	}
+
+	var fullyReversed bool
+	err = tx.QueryRow(ctx, `
+		UPDATE transfers
+		SET reversed_amount = COALESCE(reversed_amount, 0) + $2,
+		    fee_refunded = COALESCE(fee_refunded, 0) + $4,
+		    status = CASE WHEN COALESCE(reversed_amount, 0) + $2 >= amount THEN 'reversed' ELSE status END,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = ANY($3)
+		RETURNING status = 'reversed'
+	`, *reversal.ReversalOf, settled, models.AllowedFromStatuses(models.TransferStatusReversed), feeRefunded).Scan(&fullyReversed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: transfer %d cannot be marked reversed", ErrInvalidTransition, *reversal.ReversalOf)
+		}
+		return nil, fmt.Errorf("failed to mark transfer reversed: %w", err)
+	}
+	if fullyReversed {
+		if err := recordTransition(ctx, tx, *reversal.ReversalOf, models.TransferStatusCompleted, models.TransferStatusReversed,
+			models.TransitionCauseReversed, reversal.ReversalReason); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit reversal: %w", err)
+	}
+
+	return reversal, nil
 }
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"transfer/models"
+)
+
+func TestCheckReview(t *testing.T) {
+	initiator := int64(7)
+
+	tests := []struct {
+		name        string
+		status      string
+		initiatedBy *int64
+		reviewerID  int64
+		wantErr     error
+	}{
+		{name: "second admin reviews", status: models.TransferStatusPendingApproval, initiatedBy: &initiator, reviewerID: 8},
+		{name: "initiator reviews own transfer", status: models.TransferStatusPendingApproval, initiatedBy: &initiator, reviewerID: 7, wantErr: ErrSelfApproval},
+		{name: "no recorded initiator", status: models.TransferStatusPendingApproval, initiatedBy: nil, reviewerID: 7},
+		{name: "already approved", status: models.TransferStatusProcessing, initiatedBy: &initiator, reviewerID: 8, wantErr: ErrNotPendingReview},
+		{name: "already rejected", status: models.TransferStatusRejected, initiatedBy: &initiator, reviewerID: 8, wantErr: ErrNotPendingReview},
+		{name: "already reviewed, by the initiator", status: models.TransferStatusProcessing, initiatedBy: &initiator, reviewerID: 7, wantErr: ErrNotPendingReview},
+		{name: "never held", status: models.TransferStatusPending, initiatedBy: &initiator, reviewerID: 8, wantErr: ErrNotPendingReview},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReview(tt.status, tt.initiatedBy, tt.reviewerID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkReview(%q, reviewer %d) = %v, want %v", tt.status, tt.reviewerID, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLinkedOutcome(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{status: models.TransferStatusCompleted, want: linkedPaid},
+		{status: models.TransferStatusFailed, want: linkedReleased},
+		{status: models.TransferStatusRejected, want: linkedReleased},
+		{status: models.TransferStatusPending, want: ""},
+		{status: models.TransferStatusPendingApproval, want: ""},
+		{status: models.TransferStatusProcessing, want: ""},
+		{status: models.TransferStatusReversed, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := linkedOutcome(tt.status); got != tt.want {
+			t.Errorf("linkedOutcome(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+// TestReviewSync checks what a review does to the money request or split share a held transfer
+// pays: approving leaves it waiting for the transfer's result, rejecting lets it be paid again
+func TestReviewSync(t *testing.T) {
+	tests := []struct {
+		approve    bool
+		wantStatus string
+		wantCause  string
+		wantLinked string
+	}{
+		{approve: true, wantStatus: models.TransferStatusProcessing, wantCause: models.TransitionCauseApproved, wantLinked: ""},
+		{approve: false, wantStatus: models.TransferStatusRejected, wantCause: models.TransitionCauseRejected, wantLinked: linkedReleased},
+	}
+
+	for _, tt := range tests {
+		status, cause := reviewTransition(tt.approve)
+		if status != tt.wantStatus || cause != tt.wantCause {
+			t.Errorf("reviewTransition(%v) = %q, %q, want %q, %q", tt.approve, status, cause, tt.wantStatus, tt.wantCause)
+		}
+		if !models.CanTransition(models.TransferStatusPendingApproval, status) {
+			t.Errorf("reviewTransition(%v) moves a held transfer to %q, which the state machine forbids", tt.approve, status)
+		}
+		if got := linkedOutcome(status); got != tt.wantLinked {
+			t.Errorf("linkedOutcome after review (approve %v) = %q, want %q", tt.approve, got, tt.wantLinked)
+		}
+	}
+}
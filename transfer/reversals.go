@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// reverseTransfer sends the funds of a completed transfer back to its sender through the
+// normal transfer saga (admin only). Reversals bypass user limits, but like any transfer one
+// above the approval threshold is held until a different admin approves it.
+func reverseTransfer(c *gin.Context) {
+	adminID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	transferID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer ID"})
+		return
+	}
+
+	var req models.ReverseTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	original, err := transferRepo.GetByID(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransferNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer"})
+		return
+	}
+
+	// Partial reversals leave the rest of the transfer reversible
+	remaining := original.ReversibleAmount()
+	amount := remaining
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount.LessThanOrEqual(decimal.Zero) || amount.GreaterThan(remaining) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "amount must be positive and no more than the amount not yet reversed",
+			"reversible": remaining,
+		})
+		return
+	}
+	if !amount.Equal(amount.Round(2)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must have at most 2 decimal places"})
+		return
+	}
+
+	policy := req.Policy
+	if policy == "" {
+		policy = models.ReversalPolicyPartial
+	}
+
+	reversal, err := transferRepo.CreateReversal(ctx, transferID, adminID, amount, policy, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTransferNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+		case errors.Is(err, repository.ErrNotReversible), errors.Is(err, repository.ErrReversalInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Failed to create reversal of transfer %d: %v", transferID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reversal"})
+		}
+		return
+	}
+
+	log.Printf("Transfer %d reversal %d (amount %s, policy %s) requested by admin %d",
+		transferID, reversal.ID, amount, policy, adminID)
+
+	// The reversal is initiated by the admin, so the admin cannot also approve it
+	reversal, err = dispatchTransfer(ctx, reversal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate reversal"})
+		return
+	}
+
+	message := "reversal initiated"
+	if reversal.Status == models.TransferStatusPendingApproval {
+		message = "reversal awaiting approval"
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      message,
+		"transfer_id":  transferID,
+		"reversal_id":  reversal.ID,
+		"reference_id": reversal.ReferenceID,
+		"amount":       reversal.Amount,
+		"policy":       policy,
+		"status":       reversal.Status,
+	})
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"transfer/models"
+)
+
+// errOperationUnknown means the account service has no record of a transfer request
+var errOperationUnknown = errors.New("account service has no record of the operation")
+
+// sagaConfig controls recovery of transfers stuck waiting for the account service
+type sagaConfig struct {
+	Timeout      time.Duration // how long a transfer may stay processing before it is checked
+	Interval     time.Duration // how often the sweeper runs
+	MaxRepublish int           // re-publish attempts before the transfer is failed
+}
+
+const sagaSweepBatchSize = 100
+
+// runSagaSweeper periodically recovers transfers whose result event never arrived
+func runSagaSweeper(ctx context.Context, cfg sagaConfig) {
+	log.Printf("Starting saga sweeper (timeout %s, interval %s, max republish %d)", cfg.Timeout, cfg.Interval, cfg.MaxRepublish)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping saga sweeper")
+			return
+		case <-ticker.C:
+			sweepStuckTransfers(ctx, cfg)
+		}
+	}
+}
+
+func sweepStuckTransfers(ctx context.Context, cfg sagaConfig) {
+	stuck, err := transferRepo.ListStale(ctx, models.TransferStatusProcessing, time.Now().Add(-cfg.Timeout), sagaSweepBatchSize)
+	if err != nil {
+		log.Printf("Saga sweeper failed to list stuck transfers: %v", err)
+		return
+	}
+
+	for i := range stuck {
+		if ctx.Err() != nil {
+			return
+		}
+		recoverTransfer(ctx, &stuck[i], cfg)
+	}
+}
+
+// Actions the saga sweeper takes for a stuck transfer
+const (
+	recoveryComplete  = "complete"  // the account service completed it
+	recoveryHold      = "hold"      // a reversal held until the recipient can cover it; left alone
+	recoveryFail      = "fail"      // the account service failed or cancelled it
+	recoveryRepublish = "republish" // the account service never saw it; publish it again
+	recoveryFence     = "fence"     // never seen and out of re-publish attempts; cancel it first
+	recoveryWait      = "wait"      // the account service could not be asked; try on the next sweep
+)
+
+// recoveryAction decides what to do with a stuck transfer given what the account service
+// answered when asked about it
+func recoveryAction(transfer *models.Transfer, op *models.AccountOperation, err error, cfg sagaConfig) string {
+	switch {
+	case err == nil:
+		return outcomeAction(op)
+	case errors.Is(err, errOperationUnknown) && transfer.SagaAttempts < cfg.MaxRepublish:
+		return recoveryRepublish
+	case errors.Is(err, errOperationUnknown):
+		return recoveryFence
+	default:
+		return recoveryWait
+	}
+}
+
+// outcomeAction applies an outcome the account service has on record, including the one it
+// returns when a transfer is fenced off
+func outcomeAction(op *models.AccountOperation) string {
+	switch op.Status {
+	case models.TransferStatusCompleted:
+		return recoveryComplete
+	case models.AccountOperationHeld:
+		return recoveryHold
+	default:
+		return recoveryFail
+	}
+}
+
+// recoverTransfer asks the account service what happened to a stuck transfer and settles it:
+// a recorded outcome is applied directly, an unknown one is re-published until the retry budget runs out.
+func recoverTransfer(ctx context.Context, transfer *models.Transfer, cfg sagaConfig) {
+	op, err := getAccountOperation(transfer.ReferenceID.String())
+	switch recoveryAction(transfer, op, err, cfg) {
+	case recoveryComplete:
+		if err := completeRecoveredTransfer(ctx, transfer, op); err != nil {
+			log.Printf("Saga sweeper failed to complete transfer %d: %v", transfer.ID, err)
+			return
+		}
+		alertOps(ctx, "warning", transfer, "transfer result event was lost; marked completed from account service record")
+
+	case recoveryHold:
+		// A reversal the recipient could not cover yet; the account service completes it once the
+		// lien on the recipient's account is collected, so it must be neither failed nor cancelled
+		log.Printf("Saga sweeper: reversal %d is held until the recipient can cover it", transfer.ID)
+
+	case recoveryFail:
+		reason := "failed in account service"
+		if op.FailureReason != nil {
+			reason = *op.FailureReason
+		}
+		if _, err := transferRepo.MarkAsFailed(ctx, transfer.ID, reason, models.TransitionCauseSagaRecovery); err != nil {
+			log.Printf("Saga sweeper failed to fail transfer %d: %v", transfer.ID, err)
+			return
+		}
+		alertOps(ctx, "warning", transfer, "transfer result event was lost; marked failed from account service record")
+
+	case recoveryRepublish:
+		// The request never reached the account service (or was dropped); the account service is
+		// idempotent by reference, so publishing again is safe
+		retried, err := transferRepo.RecordSagaRetry(ctx, transfer.ID)
+		if err != nil {
+			log.Printf("Saga sweeper failed to record retry for transfer %d: %v", transfer.ID, err)
+			return
+		}
+		if _, err := publishTransfer(ctx, retried); err != nil {
+			alertOps(ctx, "critical", transfer, "transfer could not be re-published and was marked failed")
+			return
+		}
+		alertOps(ctx, "warning", transfer, fmt.Sprintf("transfer timed out with no account service record; re-published (attempt %d of %d)",
+			retried.SagaAttempts, cfg.MaxRepublish))
+
+	case recoveryFence:
+		// Fence the reference off first so a request still in flight is refused by the account
+		// service; if it got there first, its outcome stands
+		op, err := cancelAccountOperation(transfer, "transfer timed out")
+		if err != nil {
+			log.Printf("Saga sweeper failed to cancel transfer %d in account service: %v", transfer.ID, err)
+			alertOps(ctx, "critical", transfer, "transfer timed out and could not be cancelled in the account service: "+err.Error())
+			return
+		}
+		switch outcomeAction(op) {
+		case recoveryComplete:
+			if err := completeRecoveredTransfer(ctx, transfer, op); err != nil {
+				log.Printf("Saga sweeper failed to complete transfer %d: %v", transfer.ID, err)
+				return
+			}
+			alertOps(ctx, "warning", transfer, "transfer was processed by the account service just before it timed out; marked completed")
+		case recoveryHold:
+			log.Printf("Saga sweeper: reversal %d was held by the account service just before it timed out", transfer.ID)
+		default:
+			if _, err := transferRepo.MarkAsFailed(ctx, transfer.ID, "transfer timed out", models.TransitionCauseSagaTimeout); err != nil {
+				log.Printf("Saga sweeper failed to fail transfer %d: %v", transfer.ID, err)
+				return
+			}
+			alertOps(ctx, "critical", transfer, fmt.Sprintf("transfer timed out after %d re-publish attempts and was marked failed", transfer.SagaAttempts))
+		}
+
+	default:
+		// Leave the transfer alone; it will be picked up again on the next sweep
+		log.Printf("Saga sweeper could not query account service for transfer %d: %v", transfer.ID, err)
+		alertOps(ctx, "critical", transfer, "transfer is stuck and the account service could not be queried: "+err.Error())
+	}
+}
+
+// completeRecoveredTransfer applies a completed outcome recorded by the account service
+func completeRecoveredTransfer(ctx context.Context, transfer *models.Transfer, op *models.AccountOperation) error {
+	var err error
+	if transfer.ReversalOf != nil {
+		_, err = transferRepo.CompleteReversal(ctx, transfer.ID, op.Amount, models.TransitionCauseSagaRecovery)
+	} else {
+		_, err = transferRepo.MarkAsCompleted(ctx, transfer.ID, models.TransitionCauseSagaRecovery)
+	}
+	return err
+}
+
+// cancelAccountOperation has the account service record a transfer request as failed unless it
+// already processed it, and returns the outcome it has on record
+func cancelAccountOperation(transfer *models.Transfer, reason string) (*models.AccountOperation, error) {
+	operationType := "transfer"
+	if transfer.ReversalOf != nil {
+		operationType = "reversal"
+	}
+	return postAccountCancel(transfer.ReferenceID.String(), operationType, transfer.ID, reason)
+}
+
+// getAccountOperation asks the account service how it processed a transfer request
+func getAccountOperation(referenceID string) (*models.AccountOperation, error) {
+	accountServiceURL := getEnv("ACCOUNT_SERVICE_URL", "http://account.account.svc.cluster.local:8080")
+
+	req, err := http.NewRequest("GET", accountServiceURL+"/api/accounts/operations/"+referenceID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// The operations endpoint is admin only
+	req.Header.Set("X-User-ID", "0")
+	req.Header.Set("X-User-Role", "admin")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call account service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errOperationUnknown
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("account service returned status %d", resp.StatusCode)
+	}
+
+	var op models.AccountOperation
+	if err := json.NewDecoder(resp.Body).Decode(&op); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &op, nil
+}
+
+// postAccountCancel asks the account service to fence off a request by its reference ID. The
+// outcome it returns is the one now on record: the cancellation, or the request's own if it was
+// processed first.
+func postAccountCancel(referenceID, operationType string, sourceID int64, reason string) (*models.AccountOperation, error) {
+	accountServiceURL := getEnv("ACCOUNT_SERVICE_URL", "http://account.account.svc.cluster.local:8080")
+
+	body, err := json.Marshal(map[string]interface{}{
+		"operation_type": operationType,
+		"source_id":      sourceID,
+		"reason":         reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cancellation: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", accountServiceURL+"/api/accounts/operations/"+referenceID+"/cancel", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// The operations endpoint is admin only
+	req.Header.Set("X-User-ID", "0")
+	req.Header.Set("X-User-Role", "admin")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call account service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("account service returned status %d", resp.StatusCode)
+	}
+
+	var op models.AccountOperation
+	if err := json.NewDecoder(resp.Body).Decode(&op); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &op, nil
+}
+
+// alertOps logs and publishes an operator alert about a transfer
+func alertOps(ctx context.Context, severity string, transfer *models.Transfer, message string) {
+	log.Printf("[ALERT %s] transfer %d (ref: %s): %s", severity, transfer.ID, transfer.ReferenceID, message)
+
+	alert := models.OpsAlert{
+		Service:     "transfer",
+		Severity:    severity,
+		EntityType:  "transfer",
+		EntityID:    transfer.ID,
+		ReferenceID: transfer.ReferenceID.String(),
+		Message:     message,
+		CreatedAt:   time.Now(),
+	}
+	if err := kafkaProducer.PublishOpsAlert(ctx, alert); err != nil {
+		log.Printf("Failed to publish ops alert for transfer %d: %v", transfer.ID, err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"transfer/models"
+)
+
+func TestRecoveryAction(t *testing.T) {
+	cfg := sagaConfig{MaxRepublish: 3}
+	op := func(status string) *models.AccountOperation {
+		return &models.AccountOperation{Status: status}
+	}
+
+	tests := []struct {
+		name     string
+		attempts int
+		op       *models.AccountOperation
+		err      error
+		want     string
+	}{
+		{name: "completed on record", op: op(models.TransferStatusCompleted), want: recoveryComplete},
+		{name: "failed on record", op: op(models.TransferStatusFailed), want: recoveryFail},
+		{name: "held reversal on record", op: op(models.AccountOperationHeld), want: recoveryHold},
+		{name: "unknown with retries left", attempts: 2, err: errOperationUnknown, want: recoveryRepublish},
+		{name: "unknown with retries spent", attempts: 3, err: errOperationUnknown, want: recoveryFence},
+		{name: "account service unreachable", attempts: 3, err: errors.New("account service returned status 503"), want: recoveryWait},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfer := &models.Transfer{SagaAttempts: tt.attempts}
+			if got := recoveryAction(transfer, tt.op, tt.err, cfg); got != tt.want {
+				t.Errorf("recoveryAction() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFencedOutcome covers what the sweeper does with the outcome the account service returns
+// when a timed-out request is fenced off: a request that got there first keeps its outcome
+func TestFencedOutcome(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{status: models.TransferStatusCompleted, want: recoveryComplete},
+		{status: models.AccountOperationHeld, want: recoveryHold},
+		{status: models.TransferStatusFailed, want: recoveryFail},
+	}
+
+	for _, tt := range tests {
+		if got := outcomeAction(&models.AccountOperation{Status: tt.status}); got != tt.want {
+			t.Errorf("outcomeAction(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// parseTransferSearch reads the listing filters from the query string:
+// q, counterparty, category, min_amount, max_amount, from and to (dates are inclusive)
+func parseTransferSearch(c *gin.Context) (*models.TransferSearch, error) {
+	search := &models.TransferSearch{
+		Query:    c.Query("q"),
+		Category: c.Query("category"),
+	}
+
+	if v := c.Query("counterparty"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid counterparty account ID")
+		}
+		search.Counterparty = id
+	}
+
+	for _, p := range []struct {
+		name string
+		dest **decimal.Decimal
+	}{{"min_amount", &search.MinAmount}, {"max_amount", &search.MaxAmount}} {
+		if v := c.Query(p.name); v != "" {
+			amount, err := decimal.NewFromString(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s", p.name)
+			}
+			*p.dest = &amount
+		}
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, err := parseSearchDate(v, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date: %w", err)
+		}
+		search.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := parseSearchDate(v, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date: %w", err)
+		}
+		search.To = &to
+	}
+
+	return search, nil
+}
+
+// parseSearchDate accepts YYYY-MM-DD or RFC 3339. A bare date used as an upper bound
+// covers the whole day.
+func parseSearchDate(v string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		if endOfRange {
+			return t.AddDate(0, 0, 1), nil
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// setTransferCategory lets a party to a transfer reassign the category of their side of it: the
+// sender's category feeds their spending insights, the recipient keeps their own
+func setTransferCategory(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	transferID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer ID"})
+		return
+	}
+
+	var req models.SetCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	transfer, err := transferRepo.GetByID(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransferNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer"})
+		return
+	}
+
+	// Admins set the side they ask for, the sender's by default
+	side := req.Side
+	if role != "admin" {
+		accountIDs, err := getUserAccountIDs(userID, role)
+		if err != nil {
+			log.Printf("Failed to get user accounts: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user accounts"})
+			return
+		}
+		isSender := containsAccount(accountIDs, transfer.FromAccountID)
+		isRecipient := containsAccount(accountIDs, transfer.ToAccountID)
+		switch {
+		case !isSender && !isRecipient:
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		case side == "" && isSender, side == models.CategorySideSender && isSender:
+			side = models.CategorySideSender
+		case side == "" || side == models.CategorySideRecipient && isRecipient:
+			side = models.CategorySideRecipient
+		default:
+			c.JSON(http.StatusForbidden, gin.H{"error": "you can only categorize your own side of a transfer"})
+			return
+		}
+	}
+
+	transfer, err = transferRepo.SetCategory(ctx, transferID, side, req.Category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set transfer category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+func containsAccount(accountIDs []int64, id int64) bool {
+	for _, accountID := range accountIDs {
+		if accountID == id {
+			return true
+		}
+	}
+	return false
+}
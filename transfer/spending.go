@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"receipt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getTransferSpending summarizes the caller's completed transfers to other people's accounts
+// between from and to (YYYY-MM-DD, inclusive) per month and category, with their top
+// recipients. The payment service merges it into the spending insights.
+func getTransferSpending(c *gin.Context) {
+	userID, _, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("from") == "" || c.Query("to") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+	from, err := parseSearchDate(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date"})
+		return
+	}
+	to, err := parseSearchDate(c.Query("to"), true)
+	if err != nil || !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date"})
+		return
+	}
+
+	recipients, _ := strconv.Atoi(c.DefaultQuery("recipients", "10"))
+	if recipients <= 0 || recipients > 50 {
+		recipients = 10
+	}
+
+	// Spending is always the caller's own, so admins are not given every account either
+	accountIDs, err := getUserAccountIDs(userID, "customer")
+	if err != nil {
+		log.Printf("Failed to get user accounts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user accounts"})
+		return
+	}
+
+	spending, err := spendingRepo.Summarize(c.Request.Context(), userID, accountIDs, from, to, recipients)
+	if err != nil {
+		log.Printf("Failed to summarize transfer spending for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to summarize spending"})
+		return
+	}
+
+	for i := range spending.Recipients {
+		recipient := &spending.Recipients[i]
+		details, err := getAccountDetails(recipient.AccountID)
+		if err != nil {
+			log.Printf("Failed to look up recipient account %d: %v", recipient.AccountID, err)
+			continue
+		}
+		recipient.Account = receipt.MaskAccount(details.AccountNumber)
+	}
+
+	c.JSON(http.StatusOK, spending)
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"transfer/models"
+	"transfer/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// splitReminderSweepInterval is how often unpaid split shares are checked for reminders
+	splitReminderSweepInterval = 15 * time.Minute
+
+	// maxSplitReminders is how many automatic reminders a participant gets for one share
+	maxSplitReminders = 5
+
+	splitReminderBatchSize = 100
+)
+
+// createSplit shares a total amount out among other customers, evenly or by custom amounts
+func createSplit(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateSplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Method == "" {
+		req.Method = models.SplitMethodEven
+	}
+
+	amounts, err := repository.AllocateShares(req.TotalAmount, req.Method, req.Participants)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Looking up the receiving account as the caller verifies they own it
+	owner, status, err := getAccountSummary(req.AccountID, userID, role)
+	if err != nil {
+		log.Printf("Failed to look up account %d: %v", req.AccountID, err)
+		switch status {
+		case http.StatusForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case http.StatusNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up account"})
+		}
+		return
+	}
+
+	shares := make([]models.SplitShare, len(req.Participants))
+	seen := make(map[int64]bool, len(req.Participants))
+	for i, p := range req.Participants {
+		participant, status, err := getAccountSummary(p.AccountID, serviceUserID, "admin")
+		if err != nil {
+			log.Printf("Failed to look up participant account %d: %v", p.AccountID, err)
+			if status == http.StatusNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("participant account %d not found", p.AccountID)})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up participant account"})
+			return
+		}
+
+		if participant.UserID == owner.UserID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot be a participant in your own split"})
+			return
+		}
+		if seen[participant.UserID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": repository.ErrDuplicateSharing.Error()})
+			return
+		}
+		seen[participant.UserID] = true
+
+		shares[i] = models.SplitShare{UserID: participant.UserID, AccountID: p.AccountID, Amount: amounts[i]}
+	}
+
+	split := &models.BillSplit{
+		OwnerUserID: owner.UserID,
+		AccountID:   req.AccountID,
+		TotalAmount: req.TotalAmount,
+		Currency:    owner.Currency,
+		SplitMethod: req.Method,
+	}
+	if req.Description != "" {
+		split.Description = &req.Description
+	}
+
+	created, err := splitRepo.Create(c.Request.Context(), split, shares)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateSharing) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Failed to create bill split: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create bill split"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// listSplits lists the splits the caller owns or has a share in; admins see every split
+func listSplits(c *gin.Context) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	if role == "admin" {
+		userID = 0
+	}
+
+	result, err := splitRepo.List(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list bill splits: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list bill splits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getSplit returns a split and the progress of every share
+func getSplit(c *gin.Context) {
+	split, _, _, ok := loadSplit(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, split)
+}
+
+// paySplitShare pays the caller's share of a split with a transfer to the owner's account
+func paySplitShare(c *gin.Context) {
+	split, userID, role, ok := loadSplit(c)
+	if !ok {
+		return
+	}
+
+	var req models.PaySplitShareRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+
+	// Claim the share first so it can only ever be paid once
+	share, err := splitRepo.ClaimShare(ctx, split.ID, userID)
+	if err != nil {
+		respondSplitError(c, err)
+		return
+	}
+
+	if req.FromAccountID == 0 {
+		req.FromAccountID = share.AccountID
+	}
+
+	transferReq := models.CreateTransferRequest{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   split.AccountID,
+		Amount:        share.Amount,
+		Currency:      split.Currency,
+		Memo:          "Bill split",
+	}
+	if split.Description != nil {
+		transferReq.Memo = *split.Description
+	}
+
+	transfer, ok := initiateTransfer(c, userID, role, &transferReq)
+	if !ok {
+		if err := splitRepo.ReleaseShare(ctx, share.ID); err != nil {
+			log.Printf("Failed to release share %d of split %d: %v", share.ID, split.ID, err)
+		}
+		return
+	}
+
+	if linked, err := splitRepo.AttachShareTransfer(ctx, share.ID, transfer.ID); err != nil {
+		log.Printf("Failed to link transfer %d to share of split %d: %v", transfer.ID, split.ID, err)
+	} else {
+		share = linked
+	}
+
+	// The transfer may have settled before it was linked to the share; apply its result now
+	if current, err := transferRepo.GetByID(ctx, transfer.ID); err == nil && current.Status != transfer.Status {
+		if err := splitRepo.SyncShare(ctx, current.ID, current.Status); err != nil {
+			log.Printf("Failed to sync share of split %d with transfer %d: %v", split.ID, current.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":         "split share payment initiated",
+		"split_id":        split.ID,
+		"share":           share,
+		"transfer_id":     transfer.ID,
+		"transfer_status": transfer.Status,
+		"fee":             transfer.Fee,
+		"total_debit":     transfer.Amount.Add(transfer.Fee),
+	})
+}
+
+// remindSplit lets the owner send a reminder to every participant who has not paid yet
+func remindSplit(c *gin.Context) {
+	split, userID, _, ok := loadSplit(c)
+	if !ok {
+		return
+	}
+
+	if split.OwnerUserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can send reminders"})
+		return
+	}
+
+	if split.Status != models.SplitStatusOpen {
+		respondSplitError(c, repository.ErrSplitClosed)
+		return
+	}
+
+	reminded := 0
+	for i := range split.Shares {
+		if split.Shares[i].Status != models.ShareStatusUnpaid {
+			continue
+		}
+		if sendSplitReminder(c.Request.Context(), split, &split.Shares[i]) {
+			reminded++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"split_id": split.ID, "reminders_sent": reminded})
+}
+
+// cancelSplit lets the owner close a split that is still open
+func cancelSplit(c *gin.Context) {
+	split, userID, _, ok := loadSplit(c)
+	if !ok {
+		return
+	}
+
+	if split.OwnerUserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can cancel a split"})
+		return
+	}
+
+	cancelled, err := splitRepo.Cancel(c.Request.Context(), split.ID)
+	if err != nil {
+		respondSplitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// loadSplit fetches the split named in the path and checks the caller owns it, has a share in it or
+// is an admin, writing the error response and returning false otherwise
+func loadSplit(c *gin.Context) (*models.BillSplit, int64, string, bool) {
+	userID, role, err := getUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, 0, "", false
+	}
+
+	splitID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid split ID"})
+		return nil, 0, "", false
+	}
+
+	split, err := splitRepo.GetByID(c.Request.Context(), splitID)
+	if err != nil {
+		respondSplitError(c, err)
+		return nil, 0, "", false
+	}
+
+	if role != "admin" && split.OwnerUserID != userID && !hasShare(split, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return nil, 0, "", false
+	}
+
+	return split, userID, role, true
+}
+
+func hasShare(split *models.BillSplit, userID int64) bool {
+	for _, share := range split.Shares {
+		if share.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func respondSplitError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrSplitNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "bill split not found"})
+	case errors.Is(err, repository.ErrShareNotFound):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrSplitClosed), errors.Is(err, repository.ErrShareNotPayable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Bill split error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update bill split"})
+	}
+}
+
+// sendSplitReminder publishes a reminder for an unpaid share and records it, reporting whether it was sent
+func sendSplitReminder(ctx context.Context, split *models.BillSplit, share *models.SplitShare) bool {
+	event := models.SplitReminderEvent{
+		SplitID:       split.ID,
+		ShareID:       share.ID,
+		UserID:        share.UserID,
+		OwnerUserID:   split.OwnerUserID,
+		Amount:        share.Amount,
+		Currency:      split.Currency,
+		RemindersSent: share.RemindersSent + 1,
+	}
+	if split.Description != nil {
+		event.Description = *split.Description
+	}
+
+	if err := kafkaProducer.PublishSplitReminder(ctx, event); err != nil {
+		log.Printf("Failed to publish reminder for share %d of split %d: %v", share.ID, split.ID, err)
+		return false
+	}
+
+	if _, err := splitRepo.MarkReminded(ctx, share.ID); err != nil {
+		log.Printf("Failed to record reminder for share %d of split %d: %v", share.ID, split.ID, err)
+	}
+	return true
+}
+
+// runSplitReminders periodically reminds participants about shares left unpaid for longer than interval
+func runSplitReminders(ctx context.Context, interval time.Duration) {
+	log.Printf("Starting split reminders (every %s, at most %d per share)", interval, maxSplitReminders)
+
+	ticker := time.NewTicker(splitReminderSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping split reminders")
+			return
+		case <-ticker.C:
+			remindUnpaidShares(ctx, interval)
+		}
+	}
+}
+
+func remindUnpaidShares(ctx context.Context, interval time.Duration) {
+	shares, err := splitRepo.ListSharesDueReminder(ctx, time.Now().Add(-interval), maxSplitReminders, splitReminderBatchSize)
+	if err != nil {
+		log.Printf("Failed to list split shares due a reminder: %v", err)
+		return
+	}
+
+	splits := make(map[int64]*models.BillSplit)
+	for i := range shares {
+		if ctx.Err() != nil {
+			return
+		}
+
+		split, found := splits[shares[i].SplitID]
+		if !found {
+			split, err = splitRepo.GetByID(ctx, shares[i].SplitID)
+			if err != nil {
+				log.Printf("Failed to load split %d for reminders: %v", shares[i].SplitID, err)
+				continue
+			}
+			splits[split.ID] = split
+		}
+
+		sendSplitReminder(ctx, split, &shares[i])
+	}
+}
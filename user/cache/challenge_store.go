@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrChallengeNotFound is returned for unknown, expired or already used challenges
+var ErrChallengeNotFound = errors.New("challenge not found or expired")
+
+// ChallengeStore keeps step-up challenges in Redis; they only need to live for a few minutes
+// and expire on their own.
+type ChallengeStore struct {
+	redis *redis.Client
+}
+
+// NewChallengeStore creates a challenge store.
+func NewChallengeStore(redisClient *redis.Client) *ChallengeStore {
+	return &ChallengeStore{redis: redisClient}
+}
+
+func keyChallenge(id string) string {
+	return fmt.Sprintf("otp:challenge:%s", id)
+}
+
+func keyChallengeFailures(id string) string {
+	return fmt.Sprintf("otp:challenge:%s:failures", id)
+}
+
+// Save stores a challenge until it expires.
+func (s *ChallengeStore) Save(ctx context.Context, challenge *models.Challenge) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal challenge: %w", err)
+	}
+
+	if err := s.redis.Set(ctx, keyChallenge(challenge.ID), data, time.Until(challenge.ExpiresAt)).Err(); err != nil {
+		return fmt.Errorf("failed to store challenge: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a live challenge.
+func (s *ChallengeStore) Get(ctx context.Context, id string) (*models.Challenge, error) {
+	data, err := s.redis.Get(ctx, keyChallenge(id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+
+	var challenge models.Challenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal challenge: %w", err)
+	}
+	return &challenge, nil
+}
+
+// RecordFailure counts a wrong code against a challenge and returns the failures so far.
+func (s *ChallengeStore) RecordFailure(ctx context.Context, challenge *models.Challenge) (int64, error) {
+	key := keyChallengeFailures(challenge.ID)
+
+	pipe := s.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireAt(ctx, key, challenge.ExpiresAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record challenge failure: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+// Consume deletes a challenge, reporting false if it was already gone. Only the caller that
+// actually deletes it may treat the challenge as passed, which makes every challenge single use.
+func (s *ChallengeStore) Consume(ctx context.Context, id string) (bool, error) {
+	deleted, err := s.redis.Del(ctx, keyChallenge(id), keyChallengeFailures(id)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume challenge: %w", err)
+	}
+	return deleted > 0, nil
+}
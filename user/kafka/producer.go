@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"user-service/models"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	TopicOTPRequested = "auth.otp_requested"
+)
+
+type Producer struct {
+	writer *kafka.Writer
+}
+
+func NewProducer(brokers []string) *Producer {
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        TopicOTPRequested,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
+	return &Producer{writer: writer}
+}
+
+// PublishOTPRequested hands a one-time code to the notification service for delivery
+func (p *Producer) PublishOTPRequested(ctx context.Context, event models.OTPRequestedEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(fmt.Sprintf("%d", event.UserID)),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte("auth.otp_requested")},
+			{Key: "challenge_id", Value: []byte(event.ChallengeID)},
+		},
+	}
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	// The code itself is deliberately left out of the log
+	log.Printf("Published auth.otp_requested event for challenge %s (user %d, %s)", event.ChallengeID, event.UserID, event.Channel)
+	return nil
+}
+
+// Close closes the producer
+func (p *Producer) Close() error {
+	return p.writer.Close()
+}
+
+// EnsureTopicExists creates the topic if it doesn't exist
+func EnsureTopicExists(brokers []string, topic string) error {
+	conn, err := kafka.Dial("tcp", brokers[0])
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to get controller: %w", err)
+	}
+
+	controllerConn, err := kafka.Dial("tcp", fmt.Sprintf("%s:%d", controller.Host, controller.Port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to controller: %w", err)
+	}
+	defer controllerConn.Close()
+
+	topicConfigs := []kafka.TopicConfig{
+		{
+			Topic:             topic,
+			NumPartitions:     3,
+			ReplicationFactor: 1,
+		},
+	}
+
+	err = controllerConn.CreateTopics(topicConfigs...)
+	if err != nil {
+		log.Printf("Topic creation result for %s: %v", topic, err)
+	}
+
+	return nil
+}
@@ -8,11 +8,13 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"user-service/cache"
 	"user-service/db"
+	"user-service/kafka"
 	"user-service/models"
 	"user-service/repository"
 
@@ -28,6 +30,13 @@ type App struct {
 	repo        *repository.UserRepository
 	cachedRepo  *cache.CachedUserRepository
 	redisClient *redis.Client
+	challenges  *cache.ChallengeStore
+	producer    *kafka.Producer
+
+	// challengeTTL is how long a step-up challenge can be answered
+	challengeTTL time.Duration
+	// totpIssuer names the bank in authenticator apps
+	totpIssuer string
 }
 
 func main() {
@@ -63,7 +72,27 @@ func main() {
 	defer redisClient.Close()
 
 	cachedRepo := cache.NewCachedUserRepository(userRepo, redisClient)
-	app := &App{repo: userRepo, cachedRepo: cachedRepo, redisClient: redisClient}
+
+	// Initialize Kafka producer for one-time code delivery
+	kafkaBrokers := strings.Split(getEnv("KAFKA_BROKERS", "localhost:9092"), ",")
+	kafka.EnsureTopicExists(kafkaBrokers, kafka.TopicOTPRequested)
+	producer := kafka.NewProducer(kafkaBrokers)
+	defer producer.Close()
+
+	challengeTTL, err := time.ParseDuration(getEnv("STEP_UP_CHALLENGE_TTL", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid STEP_UP_CHALLENGE_TTL: %v", err)
+	}
+
+	app := &App{
+		repo:         userRepo,
+		cachedRepo:   cachedRepo,
+		redisClient:  redisClient,
+		challenges:   cache.NewChallengeStore(redisClient),
+		producer:     producer,
+		challengeTTL: challengeTTL,
+		totpIssuer:   getEnv("TOTP_ISSUER", "DemoBank"),
+	}
 
 	// Create Gin router
 	router := gin.Default()
@@ -106,6 +135,16 @@ func main() {
 		api.POST("", app.createUser)
 		api.PUT("/:id", app.updateUser)
 		api.DELETE("/:id", app.deleteUser)
+
+		// Authenticator app enrolment
+		api.GET("/:id/totp", app.getTOTP)
+		api.POST("/:id/totp", app.setupTOTP)
+		api.POST("/:id/totp/confirm", app.confirmTOTP)
+		api.DELETE("/:id/totp", app.deleteTOTP)
+
+		// Step-up challenges, issued and checked by the API gateway
+		api.POST("/:id/challenges", app.createChallenge)
+		api.POST("/:id/challenges/:challengeId/verify", app.verifyChallenge)
 	}
 
 	// Get port from environment or use default
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"user-service/cache"
+	"user-service/models"
+	"user-service/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxChallengeFailures is how many wrong codes a challenge tolerates before it is discarded
+const maxChallengeFailures = 5
+
+// createChallenge starts a step-up verification for a user and sends them a one-time code.
+// Only the API gateway (calling as the admin service user) issues challenges.
+func (app *App) createChallenge(c *gin.Context) {
+	ctx := c.Request.Context()
+	if c.GetHeader("X-User-Role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.CreateChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, err := app.cachedRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == repository.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+
+	if user.Status != "active" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "User is not active"})
+		return
+	}
+
+	code, err := models.GenerateOTPCode()
+	if err != nil {
+		log.Printf("Error generating one-time code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challenge"})
+		return
+	}
+	challengeID, err := newChallengeID()
+	if err != nil {
+		log.Printf("Error generating challenge ID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challenge"})
+		return
+	}
+
+	// Prefer SMS to a phone on file, falling back to email
+	channel, destination := "email", user.Email
+	if user.Phone != nil && *user.Phone != "" {
+		channel, destination = "sms", *user.Phone
+	}
+
+	challenge := &models.Challenge{
+		ID:          challengeID,
+		UserID:      user.ID,
+		Operation:   req.Operation,
+		Fingerprint: req.Fingerprint,
+		CodeHash:    models.HashOTPCode(challengeID, code),
+		Channel:     channel,
+		ExpiresAt:   time.Now().Add(app.challengeTTL),
+	}
+	if err := app.challenges.Save(ctx, challenge); err != nil {
+		log.Printf("Error saving challenge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challenge"})
+		return
+	}
+
+	methods := []string{models.MethodOTP}
+	if app.totpEnabled(c, user.ID) {
+		methods = append(methods, models.MethodTOTP)
+	}
+
+	event := models.OTPRequestedEvent{
+		ChallengeID: challenge.ID,
+		UserID:      user.ID,
+		Operation:   challenge.Operation,
+		Channel:     channel,
+		Destination: destination,
+		Code:        code,
+		ExpiresAt:   challenge.ExpiresAt,
+	}
+	if err := app.producer.PublishOTPRequested(ctx, event); err != nil {
+		log.Printf("Error publishing one-time code for challenge %s: %v", challenge.ID, err)
+		// An authenticator app can still answer the challenge; without one it is useless
+		if len(methods) == 1 {
+			app.challenges.Consume(ctx, challenge.ID)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to send verification code"})
+			return
+		}
+		methods = methods[1:]
+	}
+
+	c.JSON(http.StatusCreated, models.ChallengeResponse{
+		ChallengeID: challenge.ID,
+		Operation:   challenge.Operation,
+		Channel:     channel,
+		Methods:     methods,
+		ExpiresAt:   challenge.ExpiresAt,
+	})
+}
+
+// verifyChallenge checks a delivered or authenticator app code against a challenge. A challenge
+// passes at most once, and only for the request it was issued for.
+func (app *App) verifyChallenge(c *gin.Context) {
+	ctx := c.Request.Context()
+	if c.GetHeader("X-User-Role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.VerifyChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	challenge, err := app.challenges.Get(ctx, c.Param("challengeId"))
+	if err != nil {
+		if errors.Is(err, cache.ErrChallengeNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Challenge not found or expired"})
+			return
+		}
+		log.Printf("Error getting challenge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify challenge"})
+		return
+	}
+
+	if challenge.UserID != id || challenge.Fingerprint != req.Fingerprint {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Challenge does not match this request"})
+		return
+	}
+
+	method := models.MethodOTP
+	passed := subtle.ConstantTimeCompare([]byte(models.HashOTPCode(challenge.ID, req.Code)), []byte(challenge.CodeHash)) == 1
+	if !passed && app.checkTOTP(c, id, req.Code) {
+		method, passed = models.MethodTOTP, true
+	}
+
+	if !passed {
+		failures, err := app.challenges.RecordFailure(ctx, challenge)
+		if err != nil {
+			log.Printf("Error recording challenge failure: %v", err)
+		}
+		if failures >= maxChallengeFailures {
+			app.challenges.Consume(ctx, challenge.ID)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Too many incorrect codes, request a new challenge"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":              "Invalid verification code",
+			"attempts_remaining": maxChallengeFailures - failures,
+		})
+		return
+	}
+
+	consumed, err := app.challenges.Consume(ctx, challenge.ID)
+	if err != nil {
+		log.Printf("Error consuming challenge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify challenge"})
+		return
+	}
+	if !consumed {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Challenge not found or expired"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"verified":  true,
+		"operation": challenge.Operation,
+		"method":    method,
+	})
+}
+
+// getTOTP reports whether a user has an authenticator app enrolled
+func (app *App) getTOTP(c *gin.Context) {
+	id, ok := app.totpOwner(c)
+	if !ok {
+		return
+	}
+
+	totp, err := app.repo.GetTOTP(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrTOTPNotEnrolled) {
+			c.JSON(http.StatusOK, gin.H{"enabled": false})
+			return
+		}
+		log.Printf("Error getting TOTP enrolment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve authenticator app"})
+		return
+	}
+
+	c.JSON(http.StatusOK, totp)
+}
+
+// setupTOTP generates a new authenticator app secret; it only takes effect once confirmed
+func (app *App) setupTOTP(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := app.totpOwner(c)
+	if !ok {
+		return
+	}
+
+	user, err := app.cachedRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == repository.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return
+	}
+
+	secret, err := models.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up authenticator app"})
+		return
+	}
+
+	started, err := app.repo.StartTOTPEnrolment(ctx, id, secret)
+	if err != nil {
+		log.Printf("Error starting TOTP enrolment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up authenticator app"})
+		return
+	}
+	if !started {
+		c.JSON(http.StatusConflict, gin.H{"error": "Authenticator app already enabled, remove it first"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.TOTPSetupResponse{
+		Secret: secret,
+		URL:    models.TOTPURL(app.totpIssuer, user.Username, secret),
+	})
+}
+
+// confirmTOTP enables a pending authenticator app once the user enters a code from it
+func (app *App) confirmTOTP(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := app.totpOwner(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	totp, err := app.repo.GetTOTP(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrTOTPNotEnrolled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No authenticator app setup in progress"})
+			return
+		}
+		log.Printf("Error getting TOTP enrolment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm authenticator app"})
+		return
+	}
+	if totp.Enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Authenticator app already enabled"})
+		return
+	}
+
+	step, valid := models.ValidateTOTP(totp.Secret, req.Code, time.Now())
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	if err := app.repo.ConfirmTOTP(ctx, id, step); err != nil {
+		if errors.Is(err, repository.ErrTOTPNotEnrolled) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Authenticator app already enabled"})
+			return
+		}
+		log.Printf("Error confirming TOTP enrolment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm authenticator app"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Authenticator app enabled"})
+}
+
+// deleteTOTP removes a user's authenticator app
+func (app *App) deleteTOTP(c *gin.Context) {
+	id, ok := app.totpOwner(c)
+	if !ok {
+		return
+	}
+
+	if err := app.repo.DeleteTOTP(c.Request.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrTOTPNotEnrolled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No authenticator app enrolled"})
+			return
+		}
+		log.Printf("Error deleting TOTP enrolment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove authenticator app"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Authenticator app removed"})
+}
+
+// totpOwner parses the user ID from the path and checks the caller is that user or an admin
+func (app *App) totpOwner(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+
+	if c.GetHeader("X-User-Role") != "admin" && c.GetHeader("X-User-ID") != strconv.FormatInt(id, 10) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return 0, false
+	}
+
+	return id, true
+}
+
+// totpEnabled reports whether a user has a confirmed authenticator app
+func (app *App) totpEnabled(c *gin.Context, userID int64) bool {
+	totp, err := app.repo.GetTOTP(c.Request.Context(), userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrTOTPNotEnrolled) {
+			log.Printf("Error getting TOTP enrolment for user %d: %v", userID, err)
+		}
+		return false
+	}
+	return totp.Enabled
+}
+
+// checkTOTP validates an authenticator app code, consuming its time step so it can't be replayed
+func (app *App) checkTOTP(c *gin.Context, userID int64, code string) bool {
+	totp, err := app.repo.GetTOTP(c.Request.Context(), userID)
+	if err != nil || !totp.Enabled {
+		return false
+	}
+
+	step, valid := models.ValidateTOTP(totp.Secret, code, time.Now())
+	if !valid {
+		return false
+	}
+
+	fresh, err := app.repo.UseTOTPStep(c.Request.Context(), userID, step)
+	if err != nil {
+		log.Printf("Error recording TOTP use for user %d: %v", userID, err)
+		return false
+	}
+	return fresh
+}
+
+func newChallengeID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,14 @@
+-- Authenticator app (TOTP) enrolment, used as an alternative to delivered one-time codes
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    confirmed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Add comments for documentation
+COMMENT ON TABLE user_totp IS 'TOTP secrets for users who enrolled an authenticator app';
+COMMENT ON COLUMN user_totp.enabled IS 'False until the user confirms enrolment with a valid code';
+COMMENT ON COLUMN user_totp.last_used_step IS 'Last accepted 30-second time step, so a code cannot be replayed';
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Step-up operations the API gateway challenges before letting a request through
+const (
+	OperationTransfer        = "transfer"
+	OperationPayment         = "payment"
+	OperationExternalPayment = "external_payment"
+	OperationBeneficiary     = "beneficiary"
+	OperationCardPIN         = "card_pin"
+	OperationPassword        = "password"
+	OperationMFA             = "mfa"
+)
+
+// Step-up verification methods
+const (
+	MethodOTP  = "otp"  // a code delivered through the notification service
+	MethodTOTP = "totp" // a code from an enrolled authenticator app
+)
+
+// Challenge is a pending step-up verification. It is bound to a fingerprint of the request
+// it guards, so a code can't be used to confirm a different request.
+type Challenge struct {
+	ID          string    `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Operation   string    `json:"operation"`
+	Fingerprint string    `json:"fingerprint"`
+	CodeHash    string    `json:"code_hash"`
+	Channel     string    `json:"channel"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type CreateChallengeRequest struct {
+	Operation   string `json:"operation" binding:"required,oneof=transfer payment external_payment beneficiary card_pin password mfa"`
+	Fingerprint string `json:"fingerprint" binding:"required"`
+}
+
+type ChallengeResponse struct {
+	ChallengeID string    `json:"challenge_id"`
+	Operation   string    `json:"operation"`
+	Channel     string    `json:"channel"`
+	Methods     []string  `json:"methods"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type VerifyChallengeRequest struct {
+	Code        string `json:"code" binding:"required"`
+	Fingerprint string `json:"fingerprint" binding:"required"`
+}
+
+// TOTP is a user's authenticator app enrolment
+type TOTP struct {
+	UserID       int64      `json:"user_id"`
+	Secret       string     `json:"-"`
+	Enabled      bool       `json:"enabled"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// TOTPSetupResponse carries a new secret; it is only ever shown once, at enrolment
+type TOTPSetupResponse struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauth_url"`
+}
+
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// OTPRequestedEvent is published for the notification service to deliver a one-time code
+type OTPRequestedEvent struct {
+	ChallengeID string    `json:"challenge_id"`
+	UserID      int64     `json:"user_id"`
+	Operation   string    `json:"operation"`
+	Channel     string    `json:"channel"` // "sms" or "email"
+	Destination string    `json:"destination"`
+	Code        string    `json:"code"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
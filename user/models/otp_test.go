@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key from RFC 6238 appendix B
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "94287082"},
+		{unix: 1111111109, want: "07081804"},
+		{unix: 1111111111, want: "14050471"},
+		{unix: 1234567890, want: "89005924"},
+		{unix: 2000000000, want: "69279037"},
+		{unix: 20000000000, want: "65353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)), 8)
+		if err != nil {
+			t.Fatalf("TOTPCode at %d: unexpected error: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+
+	tests := []struct {
+		name    string
+		step    int64
+		wantOK  bool
+		wantHit int64
+	}{
+		{name: "current step", step: step, wantOK: true, wantHit: step},
+		{name: "previous step (clock drift)", step: step - 1, wantOK: true, wantHit: step - 1},
+		{name: "next step (clock drift)", step: step + 1, wantOK: true, wantHit: step + 1},
+		{name: "two steps old", step: step - 2, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := TOTPCode(rfc6238Secret, tt.step, TOTPDigits)
+			if err != nil {
+				t.Fatalf("TOTPCode: %v", err)
+			}
+			hit, ok := ValidateTOTP(rfc6238Secret, code, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ValidateTOTP ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && hit != tt.wantHit {
+				t.Errorf("ValidateTOTP step = %d, want %d", hit, tt.wantHit)
+			}
+		})
+	}
+
+	if _, ok := ValidateTOTP("not base32!", "123456", now); ok {
+		t.Error("ValidateTOTP accepted a code for an invalid secret")
+	}
+}
+
+func TestGenerateTOTPSecretRoundTrip(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("secret length = %d, want 32 base32 characters", len(secret))
+	}
+
+	now := time.Now()
+	code, err := TOTPCode(secret, TOTPStep(now), TOTPDigits)
+	if err != nil {
+		t.Fatalf("TOTPCode: %v", err)
+	}
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Error("ValidateTOTP rejected a code generated from the same secret")
+	}
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"user-service/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrTOTPNotEnrolled = errors.New("authenticator app not enrolled")
+
+// GetTOTP retrieves a user's authenticator app enrolment, confirmed or not
+func (r *UserRepository) GetTOTP(ctx context.Context, userID int64) (*models.TOTP, error) {
+	query := `
+		SELECT user_id, secret, enabled, last_used_step, created_at, confirmed_at
+		FROM user_totp
+		WHERE user_id = $1
+	`
+
+	totp := &models.TOTP{}
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&totp.UserID, &totp.Secret, &totp.Enabled, &totp.LastUsedStep, &totp.CreatedAt, &totp.ConfirmedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTOTPNotEnrolled
+		}
+		return nil, fmt.Errorf("failed to get TOTP enrolment: %w", err)
+	}
+
+	return totp, nil
+}
+
+// StartTOTPEnrolment stores a new, unconfirmed secret, replacing any earlier unconfirmed one.
+// It reports false, leaving the enrolment alone, if the user already has a confirmed app.
+func (r *UserRepository) StartTOTPEnrolment(ctx context.Context, userID int64, secret string) (bool, error) {
+	query := `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = NOW(), last_used_step = 0
+		WHERE user_totp.enabled = FALSE
+	`
+
+	tag, err := r.db.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return false, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// ConfirmTOTP enables a pending enrolment once the user has proven their app generates valid codes
+func (r *UserRepository) ConfirmTOTP(ctx context.Context, userID, step int64) error {
+	query := `
+		UPDATE user_totp
+		SET enabled = TRUE, confirmed_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND enabled = FALSE
+	`
+
+	tag, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to confirm TOTP enrolment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTOTPNotEnrolled
+	}
+
+	return nil
+}
+
+// UseTOTPStep records an accepted time step, reporting false if that step (or a later one)
+// was already used so a code can't be replayed
+func (r *UserRepository) UseTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	query := `
+		UPDATE user_totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND enabled = TRUE AND last_used_step < $2
+	`
+
+	tag, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP use: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteTOTP removes a user's authenticator app enrolment
+func (r *UserRepository) DeleteTOTP(ctx context.Context, userID int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete TOTP enrolment: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTOTPNotEnrolled
+	}
+	return nil
+}